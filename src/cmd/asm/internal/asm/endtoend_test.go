@@ -391,6 +391,10 @@ func TestPPC64EndToEnd(t *testing.T) {
 	testEndToEnd(t, "ppc64", "ppc64")
 }
 
+func TestPPC64Errors(t *testing.T) {
+	testErrors(t, "ppc64", "ppc64error")
+}
+
 func TestS390XEndToEnd(t *testing.T) {
 	testEndToEnd(t, "s390x", "s390x")
 }
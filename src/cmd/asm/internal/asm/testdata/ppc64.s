@@ -185,13 +185,13 @@ TEXT foo(SB),7,$0
 //	{
 //		outcode(int($1), &$2, 0, &$4);
 //	}
-		MOVW	R1, CR1
+		MOVW	R1, CR1 // 7c240120
 
 //	LMOVW rreg ',' lcr
 //	{
 //		outcode(int($1), &$2, 0, &$4);
 //	}
-	MOVW	R1, CR
+	MOVW	R1, CR // 7c2ff120
 
 //
 // integer operations
@@ -538,7 +538,8 @@ label1:
 //	{
 //		outcode(int($1), &$2, int($6.Reg), &$4);
 //	}
-//	FCMPU	F1, F2, CR0
+	FCMPU	F1, F2, CR0 // FCMPU F1, CR0, F2
+	FCMPU	F1, F2, CR5 // FCMPU F1, CR5, F2 // fe811000
 
 //
 // CMP
@@ -567,6 +568,26 @@ label1:
 //	}
 	CMP	R1, $4, CR0 // CMP R1, CR0, $4
 
+// The third operand selects the CR field that receives the result.
+	CMP	R1, R2 // 7c211000
+	CMP	R1, R2, CR0 // CMP R1, CR0, R2 // 7c211000
+	CMP	R1, R2, CR1 // CMP R1, CR1, R2 // 7ca11000
+	CMP	R1, R2, CR2 // CMP R1, CR2, R2 // 7d211000
+	CMP	R1, R2, CR3 // CMP R1, CR3, R2 // 7da11000
+	CMP	R1, R2, CR4 // CMP R1, CR4, R2 // 7e211000
+	CMP	R1, R2, CR5 // CMP R1, CR5, R2 // 7ea11000
+	CMP	R1, R2, CR6 // CMP R1, CR6, R2 // 7f211000
+	CMP	R1, R2, CR7 // CMP R1, CR7, R2 // 7fa11000
+	CMPW	R1, R2, CR7 // CMPW R1, CR7, R2 // 7f811000
+	CMPU	R1, R2, CR3 // CMPU R1, CR3, R2 // 7da11040
+	CMP	R1, $4 // 2c210004
+	CMP	R1, $4, CR6 // CMP R1, CR6, $4 // 2f210004
+	CMPWU	R1, $4, CR2 // CMPWU R1, CR2, $4 // 29010004
+
+// Moving a single CR field selects it by the source register.
+	MOVW	CR1, R1 // 7c340026
+	MOVW	CR7, R1 // 7c301026
+
 //
 // rotate and mask
 //
@@ -721,6 +742,19 @@ label1:
 	JMP	foo(SB)
 	CALL	foo(SB)
 
+// Byte operations.
+
+	CMPB	R3, R4, R5 // 7c851bf8
+	POPCNTB	R3, R4 // 7c6400f4
+	BPERMD	R3, R4, R5 // 7c8519f8
+
+// Direct moves between GPRs and FPRs, POWER8 and later.
+
+	MFVSRD	F1, R3 // 7c230066
+	MFFPRD	F31, R4 // 7fe40066
+	MTVSRD	R3, F1 // 7c230166
+	MTFPRD	R4, F31 // 7fe40166
+
 // END
 //
 //	LEND	comma // asm doesn't support the trailing comma.
@@ -0,0 +1,11 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+TEXT errors(SB),$0
+	CMP	R1, R2, R5	// ERROR "illegal CR field number"
+	CMPU	R1, R2, R0	// ERROR "illegal CR field number"
+	CMP	R1, $4, R3	// ERROR "illegal CR field number"
+	CMPWU	R1, $4, R31	// ERROR "illegal CR field number"
+	FCMPU	F1, F2, R7	// ERROR "illegal CR field number"
+	RET
@@ -54,6 +54,12 @@ Flags:
 		Remove the limit on the number of errors reported (default limit is 10).
 	-h
 		Halt with a stack trace at the first error detected.
+	-importcfg file
+		Read import configuration from file.
+		In the file, "packagefile path=file" lines give the package
+		file to use for each import path, and "importmap old=new"
+		lines work like -importmap. Imports are then resolved only
+		from the file, without searching -I directories or $GOROOT/pkg.
 	-importmap old=new
 		Interpret import "old" as import "new" during compilation.
 		The option may be repeated to add multiple mappings.
@@ -86,6 +92,14 @@ Flags:
 		Write a package (archive) file rather than an object file
 	-race
 		Compile with race detector enabled.
+	-sharestrings
+		Store a string constant that occurs in a longer string constant
+		of the package as a reference into the longer one. This makes
+		the object smaller but places unrelated strings together.
+	-ssastats file
+		Write to file a table of the total time spent in each SSA
+		phase and the number of functions it ran on, slowest first.
+		With -d=ssa/all/stats instead, the table goes to standard error.
 	-trimpath prefix
 		Remove prefix from recorded source file paths.
 	-u
@@ -128,6 +142,15 @@ not include a stack overflow check. This is most commonly used by low-level
 runtime sources invoked at times when it is unsafe for the calling goroutine to be
 preempted.
 
+	//go:noopt
+
+The //go:noopt directive specifies that the next function declared in the file must
+be compiled as if the -N and -l flags applied to it alone: without optimizations,
+without inlining calls into it, and without being inlined itself. It helps to debug
+suspected miscompilations without rebuilding the whole program with -N -l, which
+changes its timing. The debug flag -d=nooptfuncs=regexp has the same effect on the
+functions whose names, such as F or (*T).M, match the regular expression.
+
 	//go:linkname localname importpath.name
 
 The //go:linkname directive instructs the compiler to use ``importpath.name'' as the
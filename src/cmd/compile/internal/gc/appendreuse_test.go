@@ -0,0 +1,77 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"internal/testenv"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const appendReuseSrc = `
+package p
+
+func reuse(dst, src []byte) []byte {
+	return append(dst[:0], src...)
+}
+
+func reuseString(dst []byte, src string) []byte {
+	return append(dst[:0], src...)
+}
+
+func reuseTwoStatements(dst, src []byte) []byte {
+	dst = dst[:0]
+	dst = append(dst, src...)
+	return dst
+}
+
+func reusePointers(dst, src []*int) []*int {
+	return append(dst[:0], src...)
+}
+`
+
+// TestAppendReuse checks that append(x[:0], y...) compiles to a
+// growslice call if x is too small and a memmove, without checking
+// the bounds of the result once more.
+func TestAppendReuse(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	if runtime.GOARCH != "amd64" {
+		t.Skip("skipping on non-amd64")
+	}
+
+	funcs := compileFuncs(t, appendReuseSrc)
+	tests := []struct {
+		fn      string
+		want    []string
+		notWant []string
+	}{
+		{"reuse", []string{"CALL	runtime.memmove(SB)"}, []string{"panicslice"}},
+		{"reuseString", []string{"CALL	runtime.memmove(SB)"}, []string{"panicslice"}},
+		{"reuseTwoStatements", []string{"CALL	runtime.memmove(SB)"}, []string{"panicslice"}},
+		// Pointers are copied with write barriers.
+		{"reusePointers", []string{"CALL	runtime.typedslicecopy(SB)"}, []string{"panicslice", "memmove"}},
+	}
+	for _, tt := range tests {
+		asm, ok := funcs[tt.fn]
+		if !ok {
+			t.Errorf("%s not found in assembly listing", tt.fn)
+			continue
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(asm, s) {
+				t.Errorf("%s does not contain %q:\n%s", tt.fn, s, asm)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(asm, s) {
+				t.Errorf("%s contains %q:\n%s", tt.fn, s, asm)
+			}
+		}
+		if n := strings.Count(asm, "CALL	runtime.growslice(SB)"); n != 1 {
+			t.Errorf("%s calls growslice %d times, want 1:\n%s", tt.fn, n, asm)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type arMember struct {
+	name, data string
+}
+
+// writeArchive writes an archive with the given members to a file in
+// dir and returns the file's name.
+func writeArchive(t *testing.T, dir, name string, members []arMember) string {
+	var b bytes.Buffer
+	b.WriteString("!<arch>\n")
+	for _, m := range members {
+		var hdr [ArhdrSize]byte
+		formathdr(hdr[:], m.name, int64(len(m.data)))
+		b.Write(hdr[:])
+		b.WriteString(m.data)
+		if len(m.data)&1 != 0 {
+			b.WriteByte(0)
+		}
+	}
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, b.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestSkipToPkgdef(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skiptopkgdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const pkgdef = "go object linux amd64 go1 X:none\n\n$$\n"
+	tests := []struct {
+		name    string
+		members []arMember
+		err     string // if not "", skiptopkgdef fails with this error
+	}{
+		{
+			name:    "first.a",
+			members: []arMember{{"__.PKGDEF", pkgdef}, {"_go_.o", "go object\n"}},
+		},
+		{
+			// An odd-sized member is padded to an even size.
+			name:    "second.a",
+			members: []arMember{{"_cgo_main.o", "odd"}, {"__.PKGDEF", pkgdef}},
+		},
+		{
+			name:    "gnu.a",
+			members: []arMember{{"_x001.o/", "\x7fELF"}, {"__.PKGDEF/", pkgdef}},
+		},
+		{
+			name:    "missing.a",
+			members: []arMember{{"_go_.o", "go object\n"}, {"_cgo_main.o", "odd"}},
+			err:     "no __.PKGDEF member in archive with members _go_.o, _cgo_main.o",
+		},
+		{
+			name: "empty.a",
+			err:  "no __.PKGDEF member in empty archive",
+		},
+	}
+	for _, tt := range tests {
+		file := writeArchive(t, dir, tt.name, tt.members)
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := bufio.NewReader(f)
+		err = skiptopkgdef(b)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: skiptopkgdef: got error %v, want %q", tt.name, err, tt.err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: skiptopkgdef: %v", tt.name, err)
+		} else if line, _ := b.ReadString('\n'); !strings.HasPrefix(pkgdef, line) {
+			t.Errorf("%s: after skiptopkgdef, read %q, want the start of __.PKGDEF", tt.name, line)
+		}
+		f.Close()
+	}
+}
+
+func TestSkipToPkgdefNotArchive(t *testing.T) {
+	b := bufio.NewReader(strings.NewReader("go object linux amd64 go1 X:none\n"))
+	if err := skiptopkgdef(b); err == nil || err.Error() != "not an archive" {
+		t.Errorf("skiptopkgdef: got error %v, want %q", err, "not an archive")
+	}
+}
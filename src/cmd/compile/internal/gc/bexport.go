@@ -116,7 +116,10 @@ const debugFormat = false // default: false
 // TODO(gri) disable and remove once there is only one export format again
 const forceObjFileStability = true
 
-const exportVersion = "v0"
+// exportVersion is the version of the export data format. It changes
+// whenever the encoding changes, such as in v1 that of the post
+// statement of OFOR in inlined function bodies.
+const exportVersion = "v1"
 
 // exportInlined enables the export of inlined function bodies and related
 // dependencies. The compiler should work w/o any loss of functionality with
@@ -1431,7 +1434,13 @@ func (p *exporter) stmt(n *Node) {
 	case OFOR:
 		p.op(OFOR)
 		p.stmtList(n.Ninit)
-		p.exprsOrNil(n.Left, n.Right)
+		p.exprsOrNil(n.Left, nil)
+		// The post statement is a statement, not an expression.
+		var post Nodes
+		if n.Right != nil {
+			post.Set1(n.Right)
+		}
+		p.stmtList(post)
 		p.stmtList(n.Nbody)
 
 	case ORANGE:
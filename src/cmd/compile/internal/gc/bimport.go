@@ -1008,7 +1008,10 @@ func (p *importer) node() *Node {
 		markdcl()
 		n := Nod(OFOR, nil, nil)
 		n.Ninit.Set(p.stmtList())
-		n.Left, n.Right = p.exprsOrNil()
+		n.Left, _ = p.exprsOrNil()
+		if post := p.stmtList(); len(post) > 0 {
+			n.Right = post[0]
+		}
 		n.Nbody.Set(p.stmtList())
 		popdcl()
 		return n
@@ -1150,7 +1150,11 @@ ret:
 
 	// check range.
 	lno = setlineno(n)
-	overflow(v, n.Type)
+	if unsafeAssertion(norig, v, n.Type) {
+		n.Diag = 1
+	} else {
+		overflow(v, n.Type)
+	}
 	lineno = lno
 
 	// truncate precision for non-ideal float.
@@ -0,0 +1,209 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Devirtualization of interface method calls.
+//
+// A call x.M() of a method of an interface x whose dynamic type the
+// function itself determines, as in
+//
+//	var w io.Writer = &buf
+//	w.Write(p)
+//
+// is rewritten to the direct call x.(T).M(), where T is the dynamic type,
+// so that the inliner can expand it and escape analysis knows which
+// method is called. The type assertion is marked Bounded: it checks
+// only that x is not nil, panicking as the interface call would have.
+//
+// The dynamic type is known if every assignment to a local variable x
+// stores a conversion of a value of the same concrete type T, nil, or
+// another such local. Parameters, results of calls and variables whose
+// address is taken, including those captured by reference in closures,
+// can have any dynamic type.
+//
+// The -m flag reports devirtualized calls, and -m -m also reports the
+// calls that could not be devirtualized although the receiver is a
+// local variable, with the reason.
+
+package gc
+
+import "fmt"
+
+// devirtualize rewrites the interface method calls in fn whose receiver
+// has a known dynamic type into direct method calls.
+func devirtualize(fn *Node) {
+	if Debug['N'] != 0 || fn.Func.Pragma&Noopt != 0 {
+		return
+	}
+	savefn := Curfn
+	Curfn = fn
+	d := devirtualizer{fn: fn, assigns: make(map[*Node][]*Node)}
+	var calls []*Node
+	d.scan(fn.Nbody.Slice(), &calls)
+	for _, call := range calls {
+		d.call(call)
+	}
+	Curfn = savefn
+}
+
+type devirtualizer struct {
+	fn *Node
+
+	// assigns maps the interface-typed variables of fn to the values
+	// assigned to them. A nil value is the zero value of a declaration,
+	// and an assignment statement stands for a value of unknown type,
+	// such as a result of a call.
+	assigns map[*Node][]*Node
+
+	visited map[*Node]bool // variables already considered by dynamicType
+}
+
+// scan records the assignments in list and collects the interface
+// method calls in calls. Func literals are compiled as functions of
+// their own and scanned separately.
+func (d *devirtualizer) scan(list []*Node, calls *[]*Node) {
+	for _, n := range list {
+		if n == nil {
+			continue
+		}
+		switch n.Op {
+		case OCLOSURE:
+			continue
+
+		case OCALLINTER:
+			*calls = append(*calls, n)
+
+		case OAS, OSELRECV:
+			d.assign(n.Left, n.Right)
+
+		case OAS2:
+			for i, l := range n.List.Slice() {
+				d.assign(l, n.Rlist.Index(i))
+			}
+
+		case OAS2FUNC, OAS2RECV, OAS2MAPR, OAS2DOTTYPE, ORANGE, OSELRECV2:
+			for _, l := range n.List.Slice() {
+				d.assign(l, n)
+			}
+		}
+		d.scan([]*Node{n.Left, n.Right}, calls)
+		d.scan(n.List.Slice(), calls)
+		d.scan(n.Rlist.Slice(), calls)
+		d.scan(n.Ninit.Slice(), calls)
+		d.scan(n.Nbody.Slice(), calls)
+	}
+}
+
+func (d *devirtualizer) assign(l, r *Node) {
+	if l != nil && l.Op == ONAME && l.Type != nil && l.Type.IsInterface() {
+		d.assigns[l] = append(d.assigns[l], r)
+	}
+}
+
+// call devirtualizes the interface method call n if it can.
+func (d *devirtualizer) call(n *Node) {
+	lno := setlineno(n)
+	defer func() { lineno = lno }()
+
+	sel := n.Left // ODOTINTER
+	d.visited = make(map[*Node]bool)
+	t, ok, why := d.dynamicType(sel.Left)
+	if t == nil {
+		if !ok && why != "" && Debug['m'] > 1 {
+			fmt.Printf("%v: cannot devirtualize %v: %s\n", n.Line(), sel, why)
+		}
+		return
+	}
+	// Walk and the SSA back end expand only type assertions to
+	// pointer-shaped types inline.
+	if !isdirectiface(t) || Isfat(t) {
+		if Debug['m'] > 1 {
+			fmt.Printf("%v: cannot devirtualize %v: %v is not pointer-shaped\n", n.Line(), sel, t)
+		}
+		return
+	}
+
+	assert := Nod(ODOTTYPE, sel.Left, nil)
+	assert.Type = t
+	assert.Bounded = true
+	x := typecheck(NodSym(OXDOT, assert, sel.Sym), Erv|Ecall)
+	if x.Op != ODOTMETH {
+		// The method is promoted from an embedded interface,
+		// so the call is still an interface call.
+		return
+	}
+	if Debug['m'] != 0 {
+		fmt.Printf("%v: devirtualizing %v to %v\n", n.Line(), sel, t)
+	}
+	n.Op = OCALLMETH
+	n.Left = x
+}
+
+// dynamicType returns the concrete type of the non-nil values that the
+// interface-typed expression n can have. It returns ok with a nil type
+// if n can only be nil. If n can have values of more than one type, or
+// of types it cannot tell, it returns !ok and, if the reason is worth
+// reporting, why.
+func (d *devirtualizer) dynamicType(n *Node) (t *Type, ok bool, why string) {
+	if n == nil || isnil(n) {
+		return nil, true, ""
+	}
+	switch n.Op {
+	case OCONVIFACE:
+		if !n.Left.Type.IsInterface() {
+			return n.Left.Type, true, ""
+		}
+		// Conversions between interface types keep the dynamic type.
+		return d.dynamicType(n.Left)
+
+	case OCONVNOP:
+		return d.dynamicType(n.Left)
+
+	case ONAME:
+		switch {
+		case n.Class == PPARAM:
+			return nil, false, "type from parameter"
+		case n.Class != PAUTO || n.Name.Curfn != d.fn:
+			return nil, false, ""
+		case n.Addrtaken:
+			return nil, false, "escapes through a call"
+		}
+		if d.visited[n] {
+			// The values of n are accounted for where it was
+			// first reached.
+			return nil, true, ""
+		}
+		d.visited[n] = true
+		values := d.assigns[n]
+		if len(values) == 0 {
+			// A type switch variable.
+			return nil, false, ""
+		}
+		ok = true
+		for _, v := range values {
+			vt, vok, vwhy := d.dynamicType(v)
+			if !vok {
+				return nil, false, vwhy
+			}
+			if vt == nil {
+				continue
+			}
+			if t != nil && !Eqtype(t, vt) {
+				return nil, false, "multiple types"
+			}
+			t = vt
+		}
+		return t, ok, ""
+	}
+	return nil, false, ""
+}
+
+// isdevirtualized reports whether the method call n is the result of
+// devirtualizing an interface method call.
+func isdevirtualized(n *Node) bool {
+	x := n.Left.Left
+	for x.Op == ODOT || x.Op == ODOTPTR || x.Op == OIND {
+		x = x.Left
+	}
+	return x.Op == ODOTTYPE && x.Bounded
+}
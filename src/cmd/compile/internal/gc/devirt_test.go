@@ -0,0 +1,142 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"bytes"
+	"internal/testenv"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const devirtSrc = `
+package p
+
+import (
+	"bytes"
+	"io"
+)
+
+func write(p []byte) int {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	w.Write(p)
+	return buf.Len()
+}
+
+func writeParam(w io.Writer, p []byte) {
+	w.Write(p)
+}
+
+type counter struct{ n int }
+
+func (c *counter) Inc() { c.n++ }
+
+type incer interface {
+	Inc()
+}
+
+func inc() int {
+	var c counter
+	var i incer = &c
+	i.Inc()
+	return c.n
+}
+`
+
+func TestDevirtualize(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	if runtime.GOARCH != "amd64" {
+		t.Skip("skipping on non-amd64")
+	}
+
+	funcs := compileFuncs(t, devirtSrc)
+	tests := []struct {
+		fn      string
+		want    []string
+		notWant []string
+	}{
+		// The call is direct.
+		{"write", []string{`CALL	bytes.(*Buffer).Write(SB)`}, []string{"CALL	AX"}},
+		// The dynamic type of a parameter is not known.
+		{"writeParam", []string{"CALL	AX"}, nil},
+		// The direct call is inlined, and c does not escape.
+		{"inc", nil, []string{"CALL"}},
+	}
+	for _, tt := range tests {
+		asm, ok := funcs[tt.fn]
+		if !ok {
+			t.Errorf("%s not found in assembly listing", tt.fn)
+			continue
+		}
+		// Ignore the call of morestack in the prologue.
+		asm = strings.Replace(asm, "CALL	runtime.morestack_noctxt(SB)", "", -1)
+		for _, s := range tt.want {
+			if !strings.Contains(asm, s) {
+				t.Errorf("%s does not contain %q:\n%s", tt.fn, s, asm)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(asm, s) {
+				t.Errorf("%s contains %q:\n%s", tt.fn, s, asm)
+			}
+		}
+	}
+}
+
+// devirtFalse is false, but the compiler cannot tell, so that a writer
+// that might be os.Stdout can have either of two dynamic types.
+var devirtFalse = false
+
+var devirtSink int
+
+func BenchmarkBufferWriteDevirtualized(b *testing.B) {
+	p := []byte("x")
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		var w io.Writer = &buf
+		w.Write(p)
+		devirtSink += buf.Len()
+	}
+}
+
+func BenchmarkBufferWriteInterface(b *testing.B) {
+	p := []byte("x")
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		var w io.Writer = &buf
+		if devirtFalse {
+			w = os.Stdout
+		}
+		w.Write(p)
+		devirtSink += buf.Len()
+	}
+}
+
+func BenchmarkBufferLenDevirtualized(b *testing.B) {
+	buf := bytes.NewBufferString("x")
+	for i := 0; i < b.N; i++ {
+		var l interface {
+			Len() int
+		} = buf
+		devirtSink += l.Len()
+	}
+}
+
+func BenchmarkBufferLenInterface(b *testing.B) {
+	buf := bytes.NewBufferString("x")
+	for i := 0; i < b.N; i++ {
+		var l interface {
+			Len() int
+		} = buf
+		if devirtFalse {
+			l = new(bytes.Reader)
+		}
+		devirtSink += l.Len()
+	}
+}
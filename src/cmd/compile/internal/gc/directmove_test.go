@@ -0,0 +1,60 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"internal/testenv"
+	"strings"
+	"testing"
+)
+
+const directMoveSrc = `
+package p
+
+func roundTrip(x int64) int64 {
+	return int64(float64(x))
+}
+
+func fromUint(x uint64) float64 {
+	return float64(x)
+}
+`
+
+// TestPPC64DirectMove checks that on POWER8 and later the conversions
+// between integers and floats move the values between registers
+// directly, without storing them below the stack pointer, and that
+// they still go through memory for older processors.
+func TestPPC64DirectMove(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	tests := []struct {
+		env    []string
+		direct bool
+	}{
+		{[]string{"GOARCH=ppc64le", "GOPPC64=power8"}, true},
+		{[]string{"GOARCH=ppc64", "GOPPC64=power8"}, true},
+		{[]string{"GOARCH=ppc64le", "GOPPC64="}, false},
+		{[]string{"GOARCH=ppc64", "GOPPC64="}, false},
+		{[]string{"GOARCH=ppc64le", "GOPPC64=power5"}, false},
+	}
+	for _, tt := range tests {
+		funcs := compileFuncsEnv(t, tt.env, directMoveSrc)
+		for _, fn := range []string{"roundTrip", "fromUint"} {
+			asm, ok := funcs[fn]
+			if !ok {
+				t.Errorf("%v: %s not found in assembly listing", tt.env, fn)
+				continue
+			}
+			direct := strings.Contains(asm, "MTFPRD")
+			stack := strings.Contains(asm, "-8(R1)")
+			if direct != tt.direct || stack == tt.direct {
+				t.Errorf("%v: %s moves directly: %v, through the stack: %v; want direct %v\n%s", tt.env, fn, direct, stack, tt.direct, asm)
+			}
+		}
+		if asm := funcs["roundTrip"]; tt.direct && !strings.Contains(asm, "MFFPRD") {
+			t.Errorf("%v: roundTrip does not contain MFFPRD:\n%s", tt.env, asm)
+		}
+	}
+}
@@ -404,6 +404,19 @@ func cgen_dottype(n *Node, res, resok *Node, wb bool) {
 	Regalloc(&r1, byteptr, nil)
 	iface.Type = byteptr
 	Cgen(&iface, &r1)
+	if n.Bounded && resok == nil {
+		// The dynamic type is known to be n.Type unless iface is nil;
+		// see devirtualize. Check only for nil, as the interface
+		// method call it replaces did.
+		Cgen_checknil(&r1)
+		iface.Xoffset += int64(Widthptr)
+		Cgen(&iface, &r1)
+		Regfree(&iface)
+		r1.Type = res.Type
+		cgen_wb(&r1, res, wb)
+		Regfree(&r1)
+		return
+	}
 	if !n.Left.Type.IsEmptyInterface() {
 		// Holding itab, want concrete type in second word.
 		p := Thearch.Ginscmp(OEQ, byteptr, &r1, Nodintconst(0), -1)
@@ -277,6 +277,8 @@ var flag_installsuffix string
 
 var flag_race bool
 
+var flag_sharestrings bool
+
 var flag_msan bool
 
 var flag_largemodel bool
@@ -0,0 +1,86 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestImportcfg compiles a package whose imports are resolved only
+// through an -importcfg file.
+func TestImportcfg(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	dir, err := ioutil.TempDir("", "importcfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) string {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	compile := func(args ...string) (string, error) {
+		cmd := exec.Command(testenv.GoToolPath(t), append([]string{"tool", "compile"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	pkg := filepath.Join(dir, "p.a")
+	if out, err := compile("-pack", "-o", pkg, write("p.go", "package p\n\nfunc F() int { return 1 }\n")); err != nil {
+		t.Fatalf("compiling p: %v\n%s", err, out)
+	}
+
+	// A stale archive in the -I directory must not be used.
+	stale := filepath.Join(dir, "stale")
+	if err := os.MkdirAll(filepath.Join(stale, "example.com"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join("stale", "example.com", "p.a"), "not an archive")
+
+	cfg := write("importcfg", `# test configuration
+packagefile example.com/p=`+pkg+`
+importmap q=example.com/p
+`)
+	src := write("m.go", `package m
+
+import (
+	"example.com/p"
+	q "q"
+)
+
+func G() int { return p.F() + q.F() }
+`)
+	if out, err := compile("-importcfg", cfg, "-I", stale, "-o", filepath.Join(dir, "m.o"), src); err != nil {
+		t.Fatalf("compiling with -importcfg: %v\n%s", err, out)
+	}
+
+	// An import that is not listed is an error, even if the package
+	// is installed.
+	src = write("n.go", `package n
+
+import "fmt"
+
+var _ = fmt.Sprint
+`)
+	out, err := compile("-importcfg", cfg, "-o", filepath.Join(dir, "n.o"), src)
+	if err == nil {
+		t.Fatalf("compiling with missing importcfg entry succeeded:\n%s", out)
+	}
+	if want := `can't find import: "fmt": no packagefile for it in ` + cfg; !strings.Contains(out, want) {
+		t.Errorf("compiler output does not contain %q:\n%s", want, out)
+	}
+}
@@ -94,8 +94,8 @@ func caninl(fn *Node) {
 		Fatalf("caninl no nname %v", Nconv(fn, FmtSign))
 	}
 
-	// If marked "go:noinline", don't inline
-	if fn.Func.Pragma&Noinline != 0 {
+	// If marked "go:noinline" or "go:noopt", don't inline
+	if fn.Func.Pragma&(Noinline|Noopt) != 0 {
 		return
 	}
 
@@ -130,7 +130,10 @@ func caninl(fn *Node) {
 
 	const maxBudget = 80
 	budget := int32(maxBudget) // allowed hairyness
-	if ishairylist(fn.Nbody, &budget) || budget < 0 {
+	inlcallbacks = callbackparams(fn.Type, fn.Nbody.Slice())
+	hairy := ishairylist(fn.Nbody, &budget)
+	inlcallbacks = nil
+	if hairy || budget < 0 {
 		return
 	}
 
@@ -158,6 +161,94 @@ func caninl(fn *Node) {
 	Curfn = savefn
 }
 
+// inlcallbacks holds the callback parameters of the function being
+// analyzed by caninl. Calls of them are not hairy, and neither are
+// loops, because such a function is inlined with its loops only where
+// a func literal is passed for a callback, so that the literal's body
+// can be inlined into the loop in turn.
+var inlcallbacks []*Node
+
+// inlclosures maps the temporary that holds a callback parameter of an
+// inlined function to the func literal passed for it. Calls of the
+// temporary are inlined as calls of the func literal.
+var inlclosures map[*Node]*Node
+
+// callbackparams returns the parameters of function type t whose only
+// uses in body are as the function in a call. Such a parameter is never
+// stored, returned, passed on, compared or deferred, so calling it can
+// be replaced by inlining the func literal passed for it.
+// The facts are derived from the inlinable body itself, so they are
+// available for imported functions too.
+func callbackparams(t *Type, body []*Node) []*Node {
+	var params []*Node
+	for _, f := range t.Params().Fields().Slice() {
+		p := f.Nname
+		if p == nil || isblank(p) || f.Isddd || f.Type.Etype != TFUNC {
+			continue
+		}
+		calls, uses := 0, 0
+		for _, n := range body {
+			countcallbacks(n, p, &calls, &uses)
+		}
+		if calls > 0 && calls == uses {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// countcallbacks counts the uses of p in n, and how many of them
+// are calls of p.
+func countcallbacks(n, p *Node, calls, uses *int) {
+	if n == nil {
+		return
+	}
+	if n == p {
+		*uses++
+		return
+	}
+	switch n.Op {
+	case OCALLFUNC:
+		if n.Left == p {
+			*calls++
+		}
+	case ODEFER, OPROC:
+		if n.Left.Op == OCALLFUNC && n.Left.Left == p {
+			*uses++ // not a call that can be inlined
+		}
+	}
+	countcallbacks(n.Left, p, calls, uses)
+	countcallbacks(n.Right, p, calls, uses)
+	for _, l := range []Nodes{n.List, n.Rlist, n.Ninit, n.Nbody} {
+		for _, n1 := range l.Slice() {
+			countcallbacks(n1, p, calls, uses)
+		}
+	}
+}
+
+func iscallback(n *Node) bool {
+	for _, p := range inlcallbacks {
+		if n == p {
+			return true
+		}
+	}
+	return false
+}
+
+// hasloop reports whether any of the nodes contains a loop.
+func hasloop(l []*Node) bool {
+	for _, n := range l {
+		if n == nil {
+			continue
+		}
+		if n.Op == OFOR || hasloop([]*Node{n.Left, n.Right}) || hasloop(n.List.Slice()) ||
+			hasloop(n.Rlist.Slice()) || hasloop(n.Ninit.Slice()) || hasloop(n.Nbody.Slice()) {
+			return true
+		}
+	}
+	return false
+}
+
 // Look for anything we want to punt on.
 func ishairylist(ll Nodes, budget *int32) bool {
 	for _, n := range ll.Slice() {
@@ -186,12 +277,22 @@ func ishairy(n *Node, budget *int32) bool {
 				break
 			}
 		}
+		// Call of a callback parameter.
+		if iscallback(n.Left) {
+			break
+		}
 		if Debug['l'] < 4 {
 			return true
 		}
 
 	// Call is okay if inlinable and we have the budget for the body.
 	case OCALLMETH:
+		// The receiver of a devirtualized call is an unchecked type
+		// assertion, which cannot be exported, so the call is as hairy
+		// as the interface call it replaces.
+		if isdevirtualized(n) && Debug['l'] < 4 {
+			return true
+		}
 		t := n.Left.Type
 		if t == nil {
 			Fatalf("no function type for [%p] %v\n", n.Left, Nconv(n.Left, FmtSign))
@@ -216,15 +317,26 @@ func ishairy(n *Node, budget *int32) bool {
 	case OCLOSURE,
 		OCALLPART,
 		ORANGE,
-		OFOR,
 		OSELECT,
 		OTYPESW,
 		OPROC,
 		ODEFER,
 		ODCLTYPE, // can't print yet
-		OBREAK,
 		ORETJMP:
 		return true
+
+	// Loops are allowed only in functions with callbacks; see inlcallbacks.
+	// Labels are renamed when inlining, so labeled break and continue
+	// statements cannot be handled.
+	case OFOR:
+		if len(inlcallbacks) == 0 {
+			return true
+		}
+
+	case OBREAK, OCONTINUE:
+		if n.Left != nil || len(inlcallbacks) == 0 && n.Op == OBREAK {
+			return true
+		}
 	}
 
 	(*budget)--
@@ -270,6 +382,10 @@ func inlcopy(n *Node) *Node {
 // Inlcalls/nodelist/node walks fn's statements and expressions and substitutes any
 // calls made to inlineable functions. This is the external entry point.
 func inlcalls(fn *Node) {
+	// If marked "go:noopt", don't inline into it either.
+	if fn.Func.Pragma&Noopt != 0 {
+		return
+	}
 	savefn := Curfn
 	Curfn = fn
 	fn = inlnode(fn)
@@ -459,6 +575,8 @@ func inlnode(n *Node) *Node {
 		}
 		if n.Left.Func != nil && n.Left.Func.Inl.Len() != 0 && !isIntrinsicCall1(n) { // normal case
 			n = mkinlcall(n, n.Left, n.Isddd)
+		} else if clo := inlclosures[n.Left]; clo != nil { // callback of an inlined call
+			n = mkinlcall(n, clo.Func.Closure.Func.Nname, n.Isddd)
 		} else if n.Left.Op == ONAME && n.Left.Left != nil && n.Left.Left.Op == OTYPE && n.Left.Right != nil && n.Left.Right.Op == ONAME { // methods called as functions
 			if n.Left.Sym.Def != nil {
 				n = mkinlcall(n, n.Left.Sym.Def, n.Isddd)
@@ -536,6 +654,13 @@ func mkinlcall1(n *Node, fn *Node, isddd bool) *Node {
 		typecheckinl(fn)
 	}
 
+	// Functions with loops are inlined only to specialize them
+	// for func literals passed as callbacks.
+	closures := inlclosureargs(n, fn)
+	if len(closures) == 0 && hasloop(fn.Func.Inl.Slice()) {
+		return n
+	}
+
 	// Bingo, we have a function node, and it has an inlineable body
 	if Debug['m'] > 1 {
 		fmt.Printf("%v: inlining call to %v %v { %v }\n", n.Line(), fn.Sym, Tconv(fn.Type, FmtSharp), hconv(fn.Func.Inl, FmtSharp))
@@ -580,6 +705,33 @@ func mkinlcall1(n *Node, fn *Node, isddd bool) *Node {
 		}
 	}
 
+	for p, clo := range closures {
+		if inlclosures == nil {
+			inlclosures = make(map[*Node]*Node)
+		}
+		inlclosures[p.Name.Inlvar] = clo
+	}
+
+	// When inlining a func literal passed as a callback, the variables
+	// it captures are those of the enclosing function, which is the
+	// function being compiled. Refer to them directly, using the
+	// expressions capturevars computed to capture them.
+	if fn.Name.Defn != nil && fn.Name.Defn.Func.Closure != nil {
+		clo := fn.Name.Defn.Func.Closure
+		enter := clo.Func.Enter.Slice()
+		for _, v := range clo.Func.Cvars.Slice() {
+			if v.Op == OXXX {
+				continue // unused; see capturevars
+			}
+			outer := enter[0]
+			enter = enter[1:]
+			if outer.Op == OADDR {
+				outer = outer.Left
+			}
+			v.Name.Inlvar = outer
+		}
+	}
+
 	// temporaries for return values.
 	var m *Node
 	for _, t := range fn.Type.Results().Fields().Slice() {
@@ -829,6 +981,37 @@ func mkinlcall1(n *Node, fn *Node, isddd bool) *Node {
 	return n
 }
 
+// inlclosureargs returns the callback parameters of fn, as found by
+// callbackparams, for which the call n passes an inlinable func literal,
+// mapped to that literal.
+func inlclosureargs(n *Node, fn *Node) map[*Node]*Node {
+	args := n.List.Slice()
+	if fn.Type.Recv() != nil && n.Left.Op != ODOTMETH && len(args) > 0 {
+		args = args[1:] // receiver
+	}
+	params := fn.Type.Params().Fields().Slice()
+	if len(args) != len(params) {
+		return nil
+	}
+	var closures map[*Node]*Node
+	for _, p := range callbackparams(fn.Type, fn.Func.Inl.Slice()) {
+		for i, f := range params {
+			clo := args[i]
+			if f.Nname != p || clo.Op != OCLOSURE || clo.Func.Closure == nil {
+				continue
+			}
+			if nname := clo.Func.Closure.Func.Nname; nname.Func.Inl.Len() == 0 || nname.Name.Defn == Curfn {
+				continue
+			}
+			if closures == nil {
+				closures = make(map[*Node]*Node)
+			}
+			closures[p] = clo
+		}
+	}
+	return closures
+}
+
 // Every time we expand a function we generate a new set of tmpnames,
 // PAUTO's in the calling functions, and link them off of the
 // PPARAM's, PAUTOS and PPARAMOUTs of the called function.
@@ -72,6 +72,7 @@ const (
 	Nowritebarrier           // emit compiler error instead of write barrier
 	Nowritebarrierrec        // error on write barrier in this or recursive callees
 	CgoUnsafeArgs            // treat a pointer to one arg as a pointer to them all
+	Noopt                    // func should be compiled as with -N -l
 )
 
 type lexer struct {
@@ -913,6 +914,8 @@ func (l *lexer) getlinepragma() rune {
 			l.pragma |= Nosplit
 		case "go:noinline":
 			l.pragma |= Noinline
+		case "go:noopt":
+			l.pragma |= Noopt
 		case "go:systemstack":
 			if !compiling_runtime {
 				Yyerror("//go:systemstack only allowed in runtime")
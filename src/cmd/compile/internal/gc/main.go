@@ -14,9 +14,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -32,11 +34,13 @@ var (
 )
 
 var (
-	Debug_append  int
-	Debug_closure int
-	Debug_panic   int
-	Debug_slice   int
-	Debug_wb      int
+	Debug_append       int
+	Debug_closure      int
+	Debug_panic        int
+	Debug_shadowmethod int
+	Debug_slice        int
+	Debug_unusedassign int
+	Debug_wb           int
 )
 
 // Debug arguments.
@@ -47,18 +51,25 @@ var debugtab = []struct {
 	name string
 	val  *int
 }{
-	{"append", &Debug_append},         // print information about append compilation
-	{"closure", &Debug_closure},       // print information about closure compilation
-	{"disablenil", &Disable_checknil}, // disable nil checks
-	{"gcprog", &Debug_gcprog},         // print dump of GC programs
-	{"nil", &Debug_checknil},          // print information about nil checks
-	{"panic", &Debug_panic},           // do not hide any compiler panic
-	{"slice", &Debug_slice},           // print information about slice compilation
-	{"typeassert", &Debug_typeassert}, // print information about type assertion inlining
-	{"wb", &Debug_wb},                 // print information about write barriers
-	{"export", &Debug_export},         // print export data
+	{"append", &Debug_append},             // print information about append compilation
+	{"closure", &Debug_closure},           // print information about closure compilation
+	{"disablenil", &Disable_checknil},     // disable nil checks
+	{"gcprog", &Debug_gcprog},             // print dump of GC programs
+	{"nil", &Debug_checknil},              // print information about nil checks
+	{"panic", &Debug_panic},               // do not hide any compiler panic
+	{"shadowmethod", &Debug_shadowmethod}, // report methods that shadow promoted methods with a different signature
+	{"slice", &Debug_slice},               // print information about slice compilation
+	{"typeassert", &Debug_typeassert},     // print information about type assertion inlining
+	{"unusedassign", &Debug_unusedassign}, // report local variables that are assigned but never used
+	{"wb", &Debug_wb},                     // print information about write barriers
+	{"export", &Debug_export},             // print export data
 }
 
+// nooptFuncs selects the functions to compile as if marked //go:noopt,
+// for -d=nooptfuncs=regexp. The regexp is matched against the name of
+// the function within its package, such as F, T.M or (*T).M.
+var nooptFuncs *regexp.Regexp
+
 func usage() {
 	fmt.Printf("usage: compile [options] file.go...\n")
 	obj.Flagprint(1)
@@ -174,6 +185,7 @@ func Main() {
 	obj.Flagcount("g", "debug code generation", &Debug['g'])
 	obj.Flagcount("h", "halt on error", &Debug['h'])
 	obj.Flagcount("i", "debug line number stack", &Debug['i'])
+	obj.Flagfn1("importcfg", "read import configuration from `file`", readImportCfg)
 	obj.Flagfn1("importmap", "add `definition` of the form source=actual to import map", addImportMap)
 	flag.StringVar(&flag_installsuffix, "installsuffix", "", "set pkg directory `suffix`")
 	obj.Flagcount("j", "debug runtime-initialized variables", &Debug['j'])
@@ -190,6 +202,7 @@ func Main() {
 	obj.Flagcount("r", "debug generated wrappers", &Debug['r'])
 	flag.BoolVar(&flag_race, "race", false, "enable race detector")
 	obj.Flagcount("s", "warn about composite literals that can be simplified", &Debug['s'])
+	flag.BoolVar(&flag_sharestrings, "sharestrings", false, "share the data of strings that occur in longer strings")
 	flag.StringVar(&Ctxt.LineHist.TrimPathPrefix, "trimpath", "", "remove `prefix` from recorded source file paths")
 	flag.BoolVar(&safemode, "u", false, "reject unsafe code")
 	obj.Flagcount("v", "increase debug verbosity", &Debug['v'])
@@ -209,6 +222,7 @@ func Main() {
 	flag.StringVar(&memprofile, "memprofile", "", "write memory profile to `file`")
 	flag.Int64Var(&memprofilerate, "memprofilerate", 0, "set runtime.MemProfileRate to `rate`")
 	flag.BoolVar(&ssaEnabled, "ssa", true, "use SSA backend to generate code")
+	flag.StringVar(&ssastats, "ssastats", "", "write the time spent in each SSA phase to `file` (implies -d=ssa/all/stats)")
 	obj.Flagparse(usage)
 
 	Ctxt.Flag_shared = flag_dynlink || flag_shared
@@ -245,6 +259,15 @@ func Main() {
 			if name == "" {
 				continue
 			}
+			if strings.HasPrefix(name, "nooptfuncs=") {
+				// The functions to compile as if marked //go:noopt.
+				re, err := regexp.Compile(name[len("nooptfuncs="):])
+				if err != nil {
+					log.Fatalf("invalid debug value %v: %v", name, err)
+				}
+				nooptFuncs = re
+				continue
+			}
 			val := 1
 			if i := strings.Index(name, "="); i >= 0 {
 				var err error
@@ -282,6 +305,9 @@ func Main() {
 			log.Fatalf("unknown debug key -d %s\n", name)
 		}
 	}
+	if ssastats != "" {
+		ssa.PhaseTimes = true
+	}
 
 	// enable inlining.  for now:
 	//	default: inlining on.  (debug['l'] == 1)
@@ -377,6 +403,10 @@ func Main() {
 	}
 	resumecheckwidth()
 
+	if Debug_shadowmethod != 0 {
+		checkshadowmethods()
+	}
+
 	// Phase 3: Type check function bodies.
 	// Don't use range--typecheck can add closures to xtop.
 	for i := 0; i < len(xtop); i++ {
@@ -404,11 +434,36 @@ func Main() {
 
 	Curfn = nil
 
+	// Report variables assigned but never used, now that the
+	// closure variables are known.
+	if Debug_unusedassign != 0 {
+		for _, n := range xtop {
+			if n.Op == ODCLFUNC && n.Func.Closure == nil {
+				checkunusedassign(n)
+			}
+		}
+	}
+
 	if nsavederrors+nerrors != 0 {
 		errorexit()
 	}
 
-	// Phase 5: Inlining
+	// Functions selected by -d=nooptfuncs behave as if marked //go:noopt.
+	if nooptFuncs != nil {
+		for _, n := range xtop {
+			if n.Op == ODCLFUNC && nooptFuncs.MatchString(n.Func.Nname.Sym.Name) {
+				n.Func.Pragma |= Noopt
+			}
+		}
+	}
+
+	// Phase 5: Devirtualization and inlining
+	for _, n := range xtop {
+		if n.Op == ODCLFUNC {
+			devirtualize(n)
+		}
+	}
+
 	if Debug['l'] > 1 {
 		// Typecheck imported function bodies if debug['l'] > 1,
 		// otherwise lazily when used or re-exported.
@@ -427,8 +482,17 @@ func Main() {
 	if Debug['l'] != 0 {
 		// Find functions that can be inlined and clone them before walk expands them.
 		visitBottomUp(xtop, func(list []*Node, recursive bool) {
+			// Closures are listed after the functions containing them.
+			// Handle them first, innermost first, so that func literals
+			// passed as callbacks can be inlined into their callers.
+			for i := len(list) - 1; i >= 0; i-- {
+				if n := list[i]; n.Op == ODCLFUNC && n.Func.Closure != nil {
+					caninl(n)
+					inlcalls(n)
+				}
+			}
 			for _, n := range list {
-				if n.Op == ODCLFUNC {
+				if n.Op == ODCLFUNC && n.Func.Closure == nil {
 					caninl(n)
 					inlcalls(n)
 				}
@@ -496,6 +560,33 @@ func Main() {
 	}
 
 	Flusherrors()
+
+	if ssa.PhaseTimes {
+		writePhaseTimes()
+	}
+}
+
+// ssastats is the -ssastats file.
+var ssastats string
+
+// writePhaseTimes writes the table of time spent in each SSA phase
+// to the -ssastats file, or to standard error.
+func writePhaseTimes() {
+	if ssastats == "" {
+		ssa.WritePhaseTimes(os.Stderr)
+		return
+	}
+	f, err := os.Create(ssastats)
+	if err != nil {
+		log.Fatalf("-ssastats: %v", err)
+	}
+	err = ssa.WritePhaseTimes(f)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		log.Fatalf("-ssastats: %v", err)
+	}
 }
 
 var importMap = map[string]string{}
@@ -512,38 +603,121 @@ func addImportMap(s string) {
 	importMap[source] = actual
 }
 
+// importcfg is the -importcfg file, and packageFile maps each import
+// path listed in it to its package file. When packageFile is not nil,
+// findpkg consults it instead of searching directories.
+var (
+	importcfg   string
+	packageFile map[string]string
+)
+
+// readImportCfg reads the -importcfg file. Each line is blank, a
+// comment starting with #, or one of the directives
+//
+//	importmap source=actual
+//	packagefile path=file
+//
+// importmap lines add to the import map like -importmap does.
+func readImportCfg(file string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		log.Fatalf("-importcfg: %v", err)
+	}
+	importcfg = file
+	packageFile = map[string]string{}
+
+	for lineno, line := range strings.Split(string(data), "\n") {
+		lineno++ // 1-based
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		verb, args := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			verb, args = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		var before, after string
+		if i := strings.Index(args, "="); i >= 0 {
+			before, after = args[:i], args[i+1:]
+		}
+		switch verb {
+		case "importmap":
+			if before == "" || after == "" {
+				log.Fatalf(`%s:%d: invalid importmap: syntax is "importmap source=actual"`, file, lineno)
+			}
+			importMap[before] = after
+		case "packagefile":
+			if before == "" || after == "" {
+				log.Fatalf(`%s:%d: invalid packagefile: syntax is "packagefile path=file"`, file, lineno)
+			}
+			packageFile[before] = after
+		default:
+			log.Fatalf("%s:%d: unknown directive %q", file, lineno, verb)
+		}
+	}
+}
+
 func saveerrors() {
 	nsavederrors += nerrors
 	nerrors = 0
 }
 
-func arsize(b *bufio.Reader, name string) int {
+// arsize reads the header of the next archive member from b and
+// returns the member's name and size. It returns a size of -1 if there
+// are no more members or the header is malformed.
+func arsize(b *bufio.Reader) (string, int) {
 	var buf [ArhdrSize]byte
 	if _, err := io.ReadFull(b, buf[:]); err != nil {
-		return -1
+		return "", -1
+	}
+	if string(buf[58:60]) != "`\n" {
+		return "", -1
 	}
-	aname := strings.Trim(string(buf[0:16]), " ")
-	if !strings.HasPrefix(aname, name) {
-		return -1
+	// GNU ar ends names with a slash.
+	aname := strings.TrimSuffix(strings.TrimRight(string(buf[0:16]), " "), "/")
+	asize := strings.TrimRight(string(buf[48:58]), " ")
+	i, err := strconv.Atoi(asize)
+	if err != nil || i < 0 {
+		return "", -1
 	}
-	asize := strings.Trim(string(buf[48:58]), " ")
-	i, _ := strconv.Atoi(asize)
-	return i
+	return aname, i
 }
 
-func skiptopkgdef(b *bufio.Reader) bool {
+// skiptopkgdef positions b at the contents of the __.PKGDEF member of
+// the archive read from b, skipping the members before it, such as the
+// object files of cgo. If b is not an archive or has no __.PKGDEF
+// member, skiptopkgdef returns an error, which lists the members there
+// are.
+func skiptopkgdef(b *bufio.Reader) error {
 	// archive header
 	p, err := b.ReadString('\n')
-	if err != nil {
-		log.Fatalf("reading input: %v", err)
+	if err != nil && err != io.EOF {
+		return err
 	}
 	if p != "!<arch>\n" {
-		return false
+		return fmt.Errorf("not an archive")
 	}
 
-	// package export block should be first
-	sz := arsize(b, "__.PKGDEF")
-	return sz > 0
+	var members []string
+	for {
+		name, sz := arsize(b)
+		if sz < 0 {
+			break
+		}
+		if name == "__.PKGDEF" {
+			return nil
+		}
+		members = append(members, name)
+		// Members are padded to an even size.
+		if _, err := b.Discard(sz + sz&1); err != nil {
+			break
+		}
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("no __.PKGDEF member in empty archive")
+	}
+	return fmt.Errorf("no __.PKGDEF member in archive with members %s", strings.Join(members, ", "))
 }
 
 var idirs []string
@@ -572,6 +746,11 @@ func findpkg(name string) (file string, ok bool) {
 			return "", false
 		}
 
+		if packageFile != nil {
+			file, ok = packageFile[name]
+			return file, ok
+		}
+
 		// try .a before .6.  important for building libraries:
 		// if there is an array.6 in the array.a library,
 		// want to find all of array.a, not just array.6.
@@ -594,6 +773,11 @@ func findpkg(name string) (file string, ok bool) {
 		return "", false
 	}
 
+	if packageFile != nil {
+		file, ok = packageFile[name]
+		return file, ok
+	}
+
 	for _, dir := range idirs {
 		file = fmt.Sprintf("%s/%s.a", dir, name)
 		if _, err := os.Stat(file); err == nil {
@@ -735,7 +919,11 @@ func importfile(f *Val, indent []byte) {
 
 	file, found := findpkg(path_)
 	if !found {
-		Yyerror("can't find import: %q", path_)
+		if packageFile != nil {
+			Yyerror("can't find import: %q: no packagefile for it in %s", path_, importcfg)
+		} else {
+			Yyerror("can't find import: %q", path_)
+		}
 		errorexit()
 	}
 
@@ -755,11 +943,15 @@ func importfile(f *Val, indent []byte) {
 	defer impf.Close()
 	imp := bufio.NewReader(impf)
 
+	// where names the file, or the member of the archive, in which the
+	// export data is.
+	where := file
 	if strings.HasSuffix(file, ".a") {
-		if !skiptopkgdef(imp) {
-			Yyerror("import %s: not a package file", file)
+		if err := skiptopkgdef(imp); err != nil {
+			Yyerror("import %s: not a package file: %v", file, err)
 			errorexit()
 		}
+		where = file + "(__.PKGDEF)"
 	}
 
 	// check object header
@@ -773,13 +965,13 @@ func importfile(f *Val, indent []byte) {
 
 	if p != "empty archive" {
 		if !strings.HasPrefix(p, "go object ") {
-			Yyerror("import %s: not a go object file: %s", file, p)
+			Yyerror("import %s: not a go object file: %s", where, p)
 			errorexit()
 		}
 
 		q := fmt.Sprintf("%s %s %s %s", obj.Getgoos(), obj.Getgoarch(), obj.Getgoversion(), obj.Expstring())
 		if p[10:] != q {
-			Yyerror("import %s: object is [%s] expected [%s]", file, p[10:], q)
+			Yyerror("import %s: object is [%s] expected [%s]", where, p[10:], q)
 			errorexit()
 		}
 	}
@@ -0,0 +1,180 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const nMapEntries = 10000
+
+// mapProgram returns a program initializing a package-level map with
+// nMapEntries entries, with constant values if dynamic is false. The
+// program checks the map's contents and that iterating over it starts
+// at random entries, exiting with status 1 if not, and prints the
+// time taken by the package initialization.
+func mapProgram(dynamic bool) string {
+	var b bytes.Buffer
+	b.WriteString("package main\n\n")
+	b.WriteString("import (\n\t\"fmt\"\n\t\"os\"\n\t\"time\"\n)\n\n")
+	b.WriteString("var start = time.Now()\n\nvar zero int\n\n")
+	b.WriteString("var m = map[string]int{\n")
+	for i := 0; i < nMapEntries; i++ {
+		if dynamic {
+			fmt.Fprintf(&b, "\t\"k%d\": zero + %d,\n", i, i)
+		} else {
+			fmt.Fprintf(&b, "\t\"k%d\": %d,\n", i, i)
+		}
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, `
+var initTime = time.Since(start)
+
+func first() string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
+
+func main() {
+	bad := false
+	if len(m) != %d {
+		fmt.Printf("len(m) = %%d\n", len(m))
+		bad = true
+	}
+	for i := 0; i < len(m); i++ {
+		k := fmt.Sprintf("k%%d", i)
+		if v, ok := m[k]; !ok || v != i {
+			fmt.Printf("m[%%q] = %%d, %%v\n", k, v, ok)
+			bad = true
+		}
+	}
+	random := false
+	for i := 0; i < 10 && !random; i++ {
+		random = first() != first()
+	}
+	if !random {
+		fmt.Println("iteration always starts at", first())
+		bad = true
+	}
+	if bad {
+		os.Exit(1)
+	}
+	fmt.Println(initTime)
+}
+`, nMapEntries)
+	return b.String()
+}
+
+// textSize returns the size of the text symbol name in the object file obj.
+func textSize(t *testing.T, obj, name string) int {
+	out, err := exec.Command(testenv.GoToolPath(t), "tool", "nm", "-size", obj).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go tool nm: %v\n%s", err, out)
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		// addr size type name
+		f := strings.Fields(s.Text())
+		if len(f) < 4 || f[2] != "T" || f[3] != name {
+			continue
+		}
+		n, err := strconv.Atoi(f[1])
+		if err != nil {
+			t.Fatalf("bad nm line %q", s.Text())
+		}
+		return n
+	}
+	t.Fatalf("no text symbol %s in %s", name, obj)
+	return 0
+}
+
+func TestMapLiteralStaticInit(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "TestMapLiteralStaticInit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var size [2]int
+	var initTime [2]time.Duration
+	for i, dynamic := range []bool{false, true} {
+		file := filepath.Join(dir, fmt.Sprintf("x%d.go", i))
+		if err := ioutil.WriteFile(file, []byte(mapProgram(dynamic)), 0666); err != nil {
+			t.Fatal(err)
+		}
+		obj := filepath.Join(dir, "x.o")
+		if out, err := exec.Command(testenv.GoToolPath(t), "tool", "compile", "-o", obj, file).CombinedOutput(); err != nil {
+			t.Fatalf("go tool compile: %v\n%s", err, out)
+		}
+		size[i] = textSize(t, obj, "%22%22.init") // "".init
+
+		exe := filepath.Join(dir, "x.exe")
+		if out, err := exec.Command(testenv.GoToolPath(t), "build", "-o", exe, file).CombinedOutput(); err != nil {
+			t.Fatalf("go build: %v\n%s", err, out)
+		}
+		out, err := exec.Command(exe).CombinedOutput()
+		if err != nil {
+			t.Fatalf("dynamic=%v: program failed: %v\n%s", dynamic, err, out)
+		}
+		initTime[i], err = time.ParseDuration(strings.TrimSpace(string(out)))
+		if err != nil {
+			t.Fatalf("dynamic=%v: bad program output %q", dynamic, out)
+		}
+	}
+	t.Logf("%d-entry map: init is %d bytes of text and runs in %v with constant entries, %d bytes and %v with dynamic ones",
+		nMapEntries, size[0], initTime[0], size[1], initTime[1])
+
+	// The constant entries are added by a loop, not an assignment each.
+	if size[0] > 1024 {
+		t.Errorf("init of constant map is %d bytes, want at most 1024", size[0])
+	}
+	if size[0]*100 > size[1] {
+		t.Errorf("init of constant map is %d bytes, init of dynamic map %d bytes; want at least 100 times smaller", size[0], size[1])
+	}
+}
+
+func TestMapLiteralDuplicateKey(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	dir, err := ioutil.TempDir("", "TestMapLiteralDuplicateKey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Enough entries to be added by a loop, one of them duplicated.
+	var b bytes.Buffer
+	b.WriteString("package p\n\nvar m = map[int]string{\n")
+	for i := 0; i < 2*maplitLoopMin; i++ {
+		fmt.Fprintf(&b, "\t%d: \"v%d\",\n", i, i)
+	}
+	b.WriteString("\t7: \"dup\",\n}\n")
+	file := filepath.Join(dir, "x.go")
+	if err := ioutil.WriteFile(file, b.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(testenv.GoToolPath(t), "tool", "compile", "-o", filepath.Join(dir, "x.o"), file).CombinedOutput()
+	if err == nil || !bytes.Contains(out, []byte("duplicate key 7 in map literal")) {
+		t.Errorf("go tool compile: %v, want duplicate key error\n%s", err, out)
+	}
+}
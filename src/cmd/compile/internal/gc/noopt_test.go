@@ -0,0 +1,109 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"bufio"
+	"bytes"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const nooptSrc = `
+package p
+
+func add(a, b int) int { return a + b }
+
+//go:noopt
+func marked(x int) int {
+	y := add(x, 1)
+	return y * 2
+}
+
+func sibling(x int) int {
+	y := add(x, 1)
+	return y * 2
+}
+`
+
+// compileFuncs compiles src with the extra flags and returns the
+// assembly listing of each function.
+func compileFuncs(t *testing.T, src string, flags ...string) map[string]string {
+	return compileFuncsEnv(t, nil, src, flags...)
+}
+
+// compileFuncsEnv is like compileFuncs, but it adds env, a list of
+// key=value pairs such as GOARCH=ppc64le, to the compiler's environment.
+func compileFuncsEnv(t *testing.T, env []string, src string, flags ...string) map[string]string {
+	dir, err := ioutil.TempDir("", "noopt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "p.go")
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := append([]string{"tool", "compile", "-S", "-o", filepath.Join(dir, "p.o")}, flags...)
+	cmd := exec.Command(testenv.GoToolPath(t), append(args, file)...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go tool compile: %v\n%s", err, out)
+	}
+
+	funcs := make(map[string]string)
+	var fn string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "\t") {
+			fn = ""
+			if f := strings.Fields(line); len(f) > 1 && f[1] == "t=1" {
+				fn = strings.TrimPrefix(f[0], `"".`)
+			}
+			continue
+		}
+		if fn != "" {
+			funcs[fn] += line + "\n"
+		}
+	}
+	return funcs
+}
+
+func checkNoopt(t *testing.T, funcs map[string]string, fn string, noopt bool) {
+	asm, ok := funcs[fn]
+	if !ok {
+		t.Fatalf("%s not found in assembly listing", fn)
+	}
+	// Without optimizations add is called, not inlined,
+	// and y lives in its stack slot.
+	calls := strings.Contains(asm, `CALL	"".add(SB)`)
+	spills := strings.Contains(asm, `"".y+`)
+	if calls != noopt || spills != noopt {
+		t.Errorf("%s calls add: %v, spills y: %v; want %v\n%s", fn, calls, spills, noopt, asm)
+	}
+}
+
+func TestNoopt(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	if runtime.GOARCH != "amd64" {
+		t.Skip("skipping on non-amd64")
+	}
+
+	funcs := compileFuncs(t, nooptSrc)
+	checkNoopt(t, funcs, "marked", true)
+	checkNoopt(t, funcs, "sibling", false)
+
+	funcs = compileFuncs(t, nooptSrc, "-d=nooptfuncs=^sib")
+	checkNoopt(t, funcs, "marked", true)
+	checkNoopt(t, funcs, "sibling", true)
+}
@@ -9,7 +9,9 @@ import (
 	"cmd/internal/obj"
 	"crypto/sha256"
 	"fmt"
+	"index/suffixarray"
 	"io"
+	"sort"
 	"strconv"
 )
 
@@ -146,7 +148,15 @@ func dumpobj1(outfile string, mode int) {
 		ggloblsym(zero, int32(zerosize), obj.DUPOK|obj.RODATA)
 	}
 
+	shared := dumpstrings()
+
 	dumpdata()
+	if len(shared) > 0 {
+		// Redirect the references to shared strings once
+		// all the symbols have been assembled.
+		obj.Flushplist(Ctxt)
+		redirectStrings(shared)
+	}
 	obj.Writeobjdirect(Ctxt, bout.Writer)
 
 	if writearchive {
@@ -253,6 +263,16 @@ type stringConstantSyms struct {
 // contents to the pair of linker symbols for that string.
 var stringConstants = make(map[string]stringConstantSyms, 100)
 
+// stringData lists the contents and data symbols of the string
+// constants in the order in which they were created. The data is
+// written by dumpstrings.
+var stringData []stringDataSym
+
+type stringDataSym struct {
+	s    string
+	data *obj.LSym
+}
+
 func stringsym(s string) (hdr, data *obj.LSym) {
 	var symname string
 	if len(s) > 100 {
@@ -292,13 +312,138 @@ func stringsym(s string) (hdr, data *obj.LSym) {
 	off = duintxxLSym(symhdr, off, uint64(len(s)), Widthint)
 	ggloblLSym(symhdr, int32(off), obj.DUPOK|obj.RODATA|obj.LOCAL)
 
-	// string data
-	off = dsnameLSym(symdata, 0, s)
-	ggloblLSym(symdata, int32(off), obj.DUPOK|obj.RODATA|obj.LOCAL)
+	// string data, written by dumpstrings
+	stringData = append(stringData, stringDataSym{s, symdata})
 
 	return symhdr, symdata
 }
 
+// A sharedString records that the data of a string constant
+// is at offset off in the data of a longer string constant.
+type sharedString struct {
+	data *obj.LSym // data symbol of the substring, not emitted
+	in   *obj.LSym // data symbol of the containing string
+	off  int64
+}
+
+// dumpstrings writes the data of the string constants of the package.
+// Every distinct string has a single data symbol, named by its
+// contents, so identical strings anywhere in the package share it.
+// With -sharestrings, a string that occurs in a longer string is not
+// written; its references are redirected into the longer string, at
+// the cost of placing data used by unrelated code together.
+// dumpstrings returns the strings that must be redirected.
+func dumpstrings() []sharedString {
+	var shared []sharedString
+	if flag_sharestrings {
+		shared = findSubstrings(stringData)
+	}
+	omit := make(map[*obj.LSym]bool, len(shared))
+	for _, sh := range shared {
+		omit[sh.data] = true
+	}
+	for _, sd := range stringData {
+		if omit[sd.data] {
+			continue
+		}
+		off := dsnameLSym(sd.data, 0, sd.s)
+		ggloblLSym(sd.data, int32(off), obj.DUPOK|obj.RODATA|obj.LOCAL)
+	}
+	stringData = nil
+	return shared
+}
+
+// maxSubstringMatches bounds the number of occurrences of a string
+// that findSubstrings examines, so that very common short strings
+// do not make the search quadratic. A string whose occurrences in
+// longer strings are all beyond the bound is not shared.
+const maxSubstringMatches = 32
+
+// findSubstrings returns, for each string in strs that occurs in a
+// longer string of strs, where its data can be found. The result
+// always refers to a string that is written, even if the string was
+// found in a longer string that is itself shared.
+func findSubstrings(strs []stringDataSym) []sharedString {
+	// Search the concatenation of all the strings.
+	// starts[i] is the offset of strs[i] in it.
+	var buf []byte
+	starts := make([]int, len(strs))
+	for i, sd := range strs {
+		starts[i] = len(buf)
+		buf = append(buf, sd.s...)
+	}
+	index := suffixarray.New(buf)
+
+	// Place the strings longest first, so that the container
+	// of a string has been placed when the string is considered.
+	order := make([]int, len(strs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Stable(byStringLen{strs, order})
+
+	type loc struct {
+		in  int // index of the string holding the data
+		off int64
+	}
+	locs := make([]loc, len(strs))
+	var shared []sharedString
+	for _, i := range order {
+		s := strs[i].s
+		locs[i] = loc{in: i}
+		if s == "" {
+			continue
+		}
+		for _, p := range index.Lookup([]byte(s), maxSubstringMatches) {
+			// Find the string containing the occurrence at p:
+			// the last one starting at or before p.
+			j := sort.SearchInts(starts, p+1) - 1
+			if len(strs[j].s) <= len(s) || p+len(s) > starts[j]+len(strs[j].s) {
+				continue // s itself, or spans two strings
+			}
+			l := locs[j]
+			l.off += int64(p - starts[j])
+			locs[i] = l
+			shared = append(shared, sharedString{strs[i].data, strs[l.in].data, l.off})
+			break
+		}
+	}
+	return shared
+}
+
+type byStringLen struct {
+	strs  []stringDataSym
+	order []int
+}
+
+func (x byStringLen) Len() int { return len(x.order) }
+func (x byStringLen) Less(i, j int) bool {
+	return len(x.strs[x.order[i]].s) > len(x.strs[x.order[j]].s)
+}
+func (x byStringLen) Swap(i, j int) { x.order[i], x.order[j] = x.order[j], x.order[i] }
+
+// redirectStrings rewrites the relocations that refer to the data
+// of the shared strings to refer to their containing strings.
+func redirectStrings(shared []sharedString) {
+	to := make(map[*obj.LSym]sharedString, len(shared))
+	for _, sh := range shared {
+		to[sh.data] = sh
+	}
+	redirect := func(syms []*obj.LSym) {
+		for _, s := range syms {
+			for i := range s.R {
+				r := &s.R[i]
+				if sh, ok := to[r.Sym]; ok {
+					r.Sym = sh.in
+					r.Add += sh.off
+				}
+			}
+		}
+	}
+	redirect(Ctxt.Text)
+	redirect(Ctxt.Data)
+}
+
 var slicebytes_gen int
 
 func slicebytes(nam *Node, s string, len int) {
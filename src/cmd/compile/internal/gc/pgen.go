@@ -531,7 +531,7 @@ func genlegacy(ptxt *obj.Prog, gcargs, gclocals *Sym) {
 	Pc.Lineno = lineno
 
 	fixjmp(ptxt)
-	if Debug['N'] == 0 || Debug['R'] != 0 || Debug['P'] != 0 {
+	if Debug['N'] == 0 && Curfn.Func.Pragma&Noopt == 0 || Debug['R'] != 0 || Debug['P'] != 0 {
 		regopt(ptxt)
 		nilopt(ptxt)
 	}
@@ -341,7 +341,7 @@ func walkrange(n *Node) {
 //
 // Parameters are as in walkrange: "for v1, v2 = range a".
 func memclrrange(n, v1, v2, a *Node) bool {
-	if Debug['N'] != 0 || instrumenting {
+	if Debug['N'] != 0 || Curfn.Func.Pragma&Noopt != 0 || instrumenting {
 		return false
 	}
 	if v1 == nil || v2 != nil {
@@ -859,108 +859,81 @@ func slicelit(ctxt int, n *Node, var_ *Node, init *Nodes) {
 	init.Append(a)
 }
 
+// maplitLoopMin is the number of constant entries from which
+// maplit initializes a map with a loop over static arrays of
+// keys and values rather than an assignment per entry.
+const maplitLoopMin = 25
+
 func maplit(ctxt int, n *Node, var_ *Node, init *Nodes) {
 	ctxt = 0
 
 	// make the map var
-	nerr := nerrors
-
 	a := Nod(OMAKE, nil, nil)
 	a.List.Set1(typenod(n.Type))
 	litas(var_, a, init)
 
-	// count the initializers
-	b := 0
+	// Split the initializers into constant and dynamic entries.
+	var stat, dyn []*Node
 	for _, r := range n.List.Slice() {
 		if r.Op != OKEY {
 			Fatalf("maplit: rhs not OKEY: %v", r)
 		}
-		index := r.Left
-		value := r.Right
-
-		if isliteral(index) && isliteral(value) {
-			b++
+		if isliteral(r.Left) && isliteral(r.Right) {
+			stat = append(stat, r)
+		} else {
+			dyn = append(dyn, r)
 		}
 	}
 
-	if b != 0 {
-		// build type [count]struct { a Tindex, b Tvalue }
-		t := n.Type
-		tk := t.Key()
-		tv := t.Val()
-
-		syma := Lookup("a")
-		symb := Lookup("b")
-
-		var fields [2]*Field
-		fields[0] = newField()
-		fields[0].Type = tk
-		fields[0].Sym = syma
-		fields[1] = newField()
-		fields[1].Type = tv
-		fields[1].Sym = symb
-
-		tstruct := typ(TSTRUCT)
-		tstruct.SetFields(fields[:])
-
-		tarr := typArray(tstruct, int64(b))
+	if len(stat) >= maplitLoopMin {
+		// For many constant entries, put the keys and values in
+		// static arrays, which cost no code, and add them in a loop.
+		// build types [count]Tindex and [count]Tvalue
+		tk := typArray(n.Type.Key(), int64(len(stat)))
+		tv := typArray(n.Type.Val(), int64(len(stat)))
 
 		// TODO(josharian): suppress alg generation for these types?
-		dowidth(tarr)
+		dowidth(tk)
+		dowidth(tv)
 
-		// make and initialize static array
-		vstat := staticname(tarr, ctxt)
+		// make and initialize static arrays
+		vstatk := staticname(tk, ctxt)
+		vstatv := staticname(tv, ctxt)
 
-		b := int64(0)
-		for _, r := range n.List.Slice() {
-			if r.Op != OKEY {
-				Fatalf("maplit: rhs not OKEY: %v", r)
-			}
+		for i, r := range stat {
 			index := r.Left
 			value := r.Right
 
-			if isliteral(index) && isliteral(value) {
-				// build vstat[b].a = key;
-				setlineno(index)
-				a = Nodintconst(b)
-
-				a = Nod(OINDEX, vstat, a)
-				a = NodSym(ODOT, a, syma)
-				a = Nod(OAS, a, index)
-				a = typecheck(a, Etop)
-				a = walkexpr(a, init)
-				a.Dodata = 2
-				init.Append(a)
-
-				// build vstat[b].b = value;
-				setlineno(value)
-				a = Nodintconst(b)
-
-				a = Nod(OINDEX, vstat, a)
-				a = NodSym(ODOT, a, symb)
-				a = Nod(OAS, a, value)
-				a = typecheck(a, Etop)
-				a = walkexpr(a, init)
-				a.Dodata = 2
-				init.Append(a)
+			// build vstatk[i] = key;
+			setlineno(index)
+			a = Nod(OINDEX, vstatk, Nodintconst(int64(i)))
+			a = Nod(OAS, a, index)
+			a = typecheck(a, Etop)
+			a = walkexpr(a, init)
+			a.Dodata = 2
+			init.Append(a)
 
-				b++
-			}
+			// build vstatv[i] = value;
+			setlineno(value)
+			a = Nod(OINDEX, vstatv, Nodintconst(int64(i)))
+			a = Nod(OAS, a, value)
+			a = typecheck(a, Etop)
+			a = walkexpr(a, init)
+			a.Dodata = 2
+			init.Append(a)
 		}
 
-		// loop adding structure elements to map
-		// for i = 0; i < len(vstat); i++ {
-		//	map[vstat[i].a] = vstat[i].b
+		// loop adding the entries to the map
+		// for i = 0; i < len(vstatk); i++ {
+		//	map[vstatk[i]] = vstatv[i]
 		// }
 		index := temp(Types[TINT])
 
-		a = Nod(OINDEX, vstat, index)
+		a = Nod(OINDEX, vstatv, index)
 		a.Bounded = true
-		a = NodSym(ODOT, a, symb)
 
-		r := Nod(OINDEX, vstat, index)
+		r := Nod(OINDEX, vstatk, index)
 		r.Bounded = true
-		r = NodSym(ODOT, r, syma)
 		r = Nod(OINDEX, var_, r)
 
 		r = Nod(OAS, r, a)
@@ -969,36 +942,39 @@ func maplit(ctxt int, n *Node, var_ *Node, init *Nodes) {
 		a.Nbody.Set1(r)
 
 		a.Ninit.Set1(Nod(OAS, index, Nodintconst(0)))
-		a.Left = Nod(OLT, index, Nodintconst(tarr.NumElem()))
+		a.Left = Nod(OLT, index, Nodintconst(tk.NumElem()))
 		a.Right = Nod(OAS, index, Nod(OADD, index, Nodintconst(1)))
 
 		a = typecheck(a, Etop)
 		a = walkstmt(a)
 		init.Append(a)
+	} else {
+		// For a few constant entries, assignments are
+		// smaller than the loop.
+		addMapEntries(var_, stat, init)
 	}
 
 	// put in dynamic entries one-at-a-time
-	var key, val *Node
-	for _, r := range n.List.Slice() {
-		if r.Op != OKEY {
-			Fatalf("maplit: rhs not OKEY: %v", r)
-		}
-		index := r.Left
-		value := r.Right
+	addMapEntries(var_, dyn, init)
+}
 
-		if isliteral(index) && isliteral(value) {
-			continue
-		}
+// addMapEntries appends to init an assignment var_[key] = value
+// for each OKEY node in entries, in order.
+func addMapEntries(var_ *Node, entries []*Node, init *Nodes) {
+	if len(entries) == 0 {
+		return
+	}
 
-		// build list of var[c] = expr.
-		// use temporary so that mapassign1 can have addressable key, val.
-		if key == nil {
-			key = temp(var_.Type.Key())
-			val = temp(var_.Type.Val())
-		}
+	nerr := nerrors
 
+	// build list of var[c] = expr.
+	// use temporary so that mapassign1 can have addressable key, val.
+	key := temp(var_.Type.Key())
+	val := temp(var_.Type.Val())
+
+	for _, r := range entries {
 		setlineno(r.Left)
-		a = Nod(OAS, key, r.Left)
+		a := Nod(OAS, key, r.Left)
 		a = typecheck(a, Etop)
 		a = walkstmt(a)
 		init.Append(a)
@@ -1019,14 +995,12 @@ func maplit(ctxt int, n *Node, var_ *Node, init *Nodes) {
 		}
 	}
 
-	if key != nil {
-		a = Nod(OVARKILL, key, nil)
-		a = typecheck(a, Etop)
-		init.Append(a)
-		a = Nod(OVARKILL, val, nil)
-		a = typecheck(a, Etop)
-		init.Append(a)
-	}
+	a := Nod(OVARKILL, key, nil)
+	a = typecheck(a, Etop)
+	init.Append(a)
+	a = Nod(OVARKILL, val, nil)
+	a = typecheck(a, Etop)
+	init.Append(a)
 }
 
 func anylit(ctxt int, n *Node, var_ *Node, init *Nodes) {
@@ -106,6 +106,9 @@ func buildssa(fn *Node) *ssa.Func {
 	if fn.Func.Pragma&Nowritebarrier != 0 {
 		s.noWB = true
 	}
+	if fn.Func.Pragma&Noopt != 0 {
+		s.noopt = true
+	}
 	defer func() {
 		if s.WBLineno != 0 {
 			fn.Func.WBLineno = s.WBLineno
@@ -118,6 +121,7 @@ func buildssa(fn *Node) *ssa.Func {
 	s.config = initssa()
 	s.f = s.config.NewFunc()
 	s.f.Name = name
+	s.f.NoOpt = s.noopt
 	s.exitCode = fn.Func.Exit
 	s.panics = map[funcLine]*ssa.Block{}
 
@@ -303,6 +307,7 @@ type state struct {
 
 	cgoUnsafeArgs bool
 	noWB          bool
+	noopt         bool  // compile as with -N, for //go:noopt
 	WBLineno      int32 // line number of first write barrier. 0=no write barriers
 }
 
@@ -2066,7 +2071,7 @@ func (s *state) expr(n *Node) *ssa.Value {
 		if max != nil {
 			k = s.extendIndex(s.expr(max))
 		}
-		p, l, c := s.slice(n.Left.Type, v, i, j, k)
+		p, l, c := s.slice(n.Left.Type, v, i, j, k, n.Bounded)
 		return s.newValue3(ssa.OpSliceMake, n.Type, p, l, c)
 
 	case OSLICESTR:
@@ -2079,7 +2084,7 @@ func (s *state) expr(n *Node) *ssa.Value {
 		if high != nil {
 			j = s.extendIndex(s.expr(high))
 		}
-		p, l, _ := s.slice(n.Left.Type, v, i, j, nil)
+		p, l, _ := s.slice(n.Left.Type, v, i, j, nil, false)
 		return s.newValue2(ssa.OpStringMake, n.Type, p, l)
 
 	case OCALLFUNC:
@@ -2821,7 +2826,7 @@ func (s *state) addr(n *Node, bounded bool) *ssa.Value {
 // canSSA reports whether n is SSA-able.
 // n must be an ONAME (or an ODOT sequence with an ONAME base).
 func (s *state) canSSA(n *Node) bool {
-	if Debug['N'] != 0 {
+	if Debug['N'] != 0 || s.noopt {
 		return false
 	}
 	for n.Op == ODOT {
@@ -3250,7 +3255,8 @@ func (s *state) storeTypePtrsWB(t *Type, left, right *ssa.Value) {
 // slice computes the slice v[i:j:k] and returns ptr, len, and cap of result.
 // i,j,k may be nil, in which case they are set to their default value.
 // t is a slice, ptr to array, or string type.
-func (s *state) slice(t *Type, v, i, j, k *ssa.Value) (p, l, c *ssa.Value) {
+// If bounded is set, the indexes are known to be in bounds and are not checked.
+func (s *state) slice(t *Type, v, i, j, k *ssa.Value, bounded bool) (p, l, c *ssa.Value) {
 	var elemtype *Type
 	var ptrtype *Type
 	var ptr *ssa.Value
@@ -3296,12 +3302,14 @@ func (s *state) slice(t *Type, v, i, j, k *ssa.Value) (p, l, c *ssa.Value) {
 	}
 
 	// Panic if slice indices are not in bounds.
-	s.sliceBoundsCheck(i, j)
-	if j != k {
-		s.sliceBoundsCheck(j, k)
-	}
-	if k != cap {
-		s.sliceBoundsCheck(k, cap)
+	if !bounded {
+		s.sliceBoundsCheck(i, j)
+		if j != k {
+			s.sliceBoundsCheck(j, k)
+		}
+		if k != cap {
+			s.sliceBoundsCheck(k, cap)
+		}
 	}
 
 	// Generate the following code assuming that indexes are in bounds.
@@ -3637,6 +3645,14 @@ func (s *state) ifaceType(n *Node, v *ssa.Value) *ssa.Value {
 // If commaok is false, resok will be nil.
 func (s *state) dottype(n *Node, commaok bool) (res, resok *ssa.Value) {
 	iface := s.expr(n.Left)
+	if n.Bounded {
+		// The dynamic type is known to be n.Type unless iface is nil;
+		// see devirtualize. Check only for nil, as the interface
+		// method call it replaces did.
+		itab := s.newValue1(ssa.OpITab, Ptrto(Types[TUINT8]), iface)
+		s.nilCheck(itab)
+		return s.newValue1(ssa.OpIData, n.Type, iface), nil
+	}
 	typ := s.ifaceType(n.Left, iface)  // actual concrete type
 	target := s.expr(typename(n.Type)) // target type
 	if !isdirectiface(n.Type) {
@@ -3979,7 +3995,7 @@ func genssa(f *ssa.Func, ptxt *obj.Prog, gcargs, gclocals *Sym) {
 		}
 		// Emit control flow instructions for block
 		var next *ssa.Block
-		if i < len(f.Blocks)-1 && (Debug['N'] == 0 || b.Kind == ssa.BlockCall) {
+		if i < len(f.Blocks)-1 && (Debug['N'] == 0 && !f.NoOpt || b.Kind == ssa.BlockCall) {
 			// If -N, leave next==nil so every block with successors
 			// ends in a JMP (except call blocks - plive doesn't like
 			// select{send,recv} followed by a JMP call).  Helps keep
@@ -0,0 +1,97 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const ssastatsSrc = `package p
+
+func Sum(a []int) int {
+	s := 0
+	for _, x := range a {
+		s += x
+	}
+	return s
+}
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+`
+
+// TestSSAStats checks the table of time spent in each SSA phase
+// written with -ssastats and with -d=ssa/all/stats.
+func TestSSAStats(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	if runtime.GOARCH != "amd64" {
+		t.Skip("skipping on non-amd64")
+	}
+
+	dir, err := ioutil.TempDir("", "ssastats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "p.go")
+	if err := ioutil.WriteFile(src, []byte(ssastatsSrc), 0666); err != nil {
+		t.Fatal(err)
+	}
+	obj := filepath.Join(dir, "p.o")
+
+	stats := filepath.Join(dir, "stats")
+	out, err := exec.Command(testenv.GoToolPath(t), "tool", "compile", "-ssastats", stats, "-o", obj, src).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go tool compile -ssastats: %v\n%s", err, out)
+	}
+	table, err := ioutil.ReadFile(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSSAStats(t, string(table))
+
+	out, err = exec.Command(testenv.GoToolPath(t), "tool", "compile", "-d=ssa/all/stats", "-o", obj, src).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go tool compile -d=ssa/all/stats: %v\n%s", err, out)
+	}
+	checkSSAStats(t, string(out))
+}
+
+var ssastatsRow = regexp.MustCompile(`^(.+?) +([0-9]+) +([0-9]+)$`)
+
+func checkSSAStats(t *testing.T, table string) {
+	lines := strings.Split(strings.TrimSuffix(table, "\n"), "\n")
+	if len(lines) < 3 || !strings.HasPrefix(lines[0], "phase ") || !strings.HasPrefix(lines[len(lines)-1], "total ") {
+		t.Fatalf("malformed table:\n%s", table)
+	}
+	funcs := make(map[string]string)
+	for _, line := range lines[1 : len(lines)-1] {
+		m := ssastatsRow.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("malformed row %q in table:\n%s", line, table)
+		}
+		funcs[m[1]] = m[3]
+	}
+	// Both functions go through these phases.
+	for _, phase := range []string{"opt", "generic cse", "lower", "regalloc", "layout"} {
+		if n, ok := funcs[phase]; !ok {
+			t.Errorf("phase %q missing from table:\n%s", phase, table)
+		} else if n != "2" {
+			t.Errorf("phase %q ran on %s functions, want 2", phase, n)
+		}
+	}
+}
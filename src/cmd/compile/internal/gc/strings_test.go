@@ -0,0 +1,165 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	nStringFuncs = 1000
+	dupString    = "a string constant duplicated in many functions"
+)
+
+// stringsProgram returns a program with nStringFuncs functions returning
+// the same string and nStringFuncs functions returning distinct
+// substrings of a longer string. The program exits with status 1 if a
+// string has the wrong contents or, with -shared, does not point into
+// the longer string.
+func stringsProgram() (src string, long string) {
+	var l bytes.Buffer
+	for i := 0; i <= nStringFuncs; i++ {
+		fmt.Fprintf(&l, "%04d,", i)
+	}
+	long = l.String()
+
+	var b bytes.Buffer
+	b.WriteString("package main\n\n")
+	b.WriteString("import (\n\t\"flag\"\n\t\"fmt\"\n\t\"os\"\n\t\"unsafe\"\n)\n\n")
+	fmt.Fprintf(&b, "func long() string { return %q }\n\n", long)
+	for i := 0; i < nStringFuncs; i++ {
+		fmt.Fprintf(&b, "func dup%d() string { return %q }\n", i, dupString)
+		fmt.Fprintf(&b, "func sub%d() string { return %q }\n", i, long[5*i:5*i+9])
+	}
+	b.WriteString("\nvar dups = []func() string{\n")
+	for i := 0; i < nStringFuncs; i++ {
+		fmt.Fprintf(&b, "\tdup%d,\n", i)
+	}
+	b.WriteString("}\n\nvar subs = []func() string{\n")
+	for i := 0; i < nStringFuncs; i++ {
+		fmt.Fprintf(&b, "\tsub%d,\n", i)
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, `
+func data(s string) uintptr { return *(*uintptr)(unsafe.Pointer(&s)) }
+
+func main() {
+	shared := flag.Bool("shared", false, "check that substrings share data")
+	flag.Parse()
+	bad := false
+	for i, f := range dups {
+		if s := f(); s != %q {
+			fmt.Printf("dup%%d() = %%q\n", i, s)
+			bad = true
+		}
+	}
+	for i, f := range subs {
+		s := f()
+		if want := fmt.Sprintf("%%04d,%%04d", i, i+1); s != want {
+			fmt.Printf("sub%%d() = %%q, want %%q\n", i, s, want)
+			bad = true
+		}
+		if *shared && data(s) != data(long())+uintptr(5*i) {
+			fmt.Printf("sub%%d() does not point into long()\n", i)
+			bad = true
+		}
+	}
+	if bad {
+		os.Exit(1)
+	}
+}
+`, dupString)
+	return b.String(), long
+}
+
+// stringDataSize returns the total size of the string data symbols
+// in the object file obj.
+func stringDataSize(t *testing.T, obj string) int {
+	out, err := exec.Command(testenv.GoToolPath(t), "tool", "nm", "-size", obj).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go tool nm: %v\n%s", err, out)
+	}
+	total := 0
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		// addr size type name
+		f := strings.Fields(s.Text())
+		if len(f) < 4 || !strings.HasPrefix(f[3], "go.string.") || strings.HasPrefix(f[3], "go.string.hdr.") {
+			continue
+		}
+		n, err := strconv.Atoi(f[1])
+		if err != nil {
+			t.Fatalf("bad nm line %q", s.Text())
+		}
+		total += n
+	}
+	return total
+}
+
+func TestStringConstantData(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	dir, err := ioutil.TempDir("", "TestStringConstantData")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, long := stringsProgram()
+	file := filepath.Join(dir, "x.go")
+	if err := ioutil.WriteFile(file, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	const slack = 256 // other strings in the program
+	subLen := nStringFuncs * 9
+
+	for _, shared := range []bool{false, true} {
+		obj := filepath.Join(dir, "x.o")
+		args := []string{"tool", "compile", "-o", obj}
+		if shared {
+			args = append(args, "-sharestrings")
+		}
+		args = append(args, file)
+		if out, err := exec.Command(testenv.GoToolPath(t), args...).CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+
+		// Identical strings always share one symbol.
+		// Substrings share the data of long only with -sharestrings.
+		size := stringDataSize(t, obj)
+		min := len(dupString) + len(long)
+		max := min + slack
+		if !shared {
+			min += subLen
+			max += subLen
+		}
+		if size < min || size > max {
+			t.Errorf("-sharestrings=%v: string data is %d bytes, want between %d and %d", shared, size, min, max)
+		}
+
+		exe := filepath.Join(dir, "x.exe")
+		gcflags := ""
+		if shared {
+			gcflags = "-sharestrings"
+		}
+		cmd := exec.Command(testenv.GoToolPath(t), "build", "-gcflags", gcflags, "-o", exe, file)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go build -gcflags=%s: %v\n%s", gcflags, err, out)
+		}
+		if out, err := exec.Command(exe, "-shared="+strconv.FormatBool(shared)).CombinedOutput(); err != nil {
+			t.Errorf("-sharestrings=%v: program failed: %v\n%s", shared, err, out)
+		}
+	}
+}
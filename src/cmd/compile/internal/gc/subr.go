@@ -1728,6 +1728,77 @@ func expandmeth(t *Type) {
 	t.AllMethods().Set(ms)
 }
 
+// checkshadowmethods reports, for -d=shadowmethod, the methods declared
+// on struct types that shadow a method promoted from an embedded field
+// with a different signature. Such a method silently removes the
+// promoted one from the method set, which typically shows up as a type
+// that no longer satisfies an interface far from the declaration.
+// A method with the same signature as the promoted one is assumed
+// to wrap it on purpose and is not reported.
+// At -d=shadowmethod=2 the reports are errors instead of warnings.
+func checkshadowmethods() {
+	for _, n := range xtop {
+		if n.Op != ODCLFUNC || n.Func.Shortname == nil || n.Type == nil {
+			continue
+		}
+		s := n.Func.Shortname.Sym
+		if isblanksym(s) {
+			continue
+		}
+		t := methtype(n.Type.Recv().Type, 0)
+		if t == nil || !t.IsStruct() {
+			continue
+		}
+		m, via := promotedmethod(s, t)
+		if m == nil || Eqtype(n.Type, m.Type) {
+			continue
+		}
+		line := via.Type.Lineno
+		if m.Nname != nil {
+			line = m.Nname.Lineno
+		}
+		format := "method %v.%v shadows %v.%v.%v with a different signature\n\thave %v%v\n\tpromoted %v%v at %v"
+		args := []interface{}{t, s, t, via.Sym, s, s, Tconv(n.Type, FmtShort), s, Tconv(m.Type, FmtShort), linestr(line)}
+		if Debug_shadowmethod > 1 {
+			yyerrorl(n.Lineno, format, args...)
+		} else {
+			Warnl(n.Lineno, format, args...)
+		}
+	}
+}
+
+// promotedmethod returns the method named s that the embedded fields
+// of struct type t would promote if t did not declare a method named s,
+// and the embedded field it would be promoted through.
+// It returns nil if there is no such method or the selector is ambiguous.
+func promotedmethod(s *Sym, t *Type) (m *Field, via *Field) {
+	depth, count := -1, 0
+	for _, f := range t.Fields().Slice() {
+		if f.Embedded == 0 || f.Sym == nil {
+			continue
+		}
+		var m1 *Field
+		path, ambig := dotpath(s, f.Type, &m1, false)
+		if ambig {
+			return nil, nil
+		}
+		if path == nil {
+			continue
+		}
+		switch d := len(path); {
+		case depth < 0 || d < depth:
+			depth, count = d, 1
+			m, via = m1, f
+		case d == depth:
+			count++
+		}
+	}
+	if count != 1 || m.Type.Etype != TFUNC || m.Type.Recv() == nil {
+		return nil, nil
+	}
+	return m, via
+}
+
 // Given funarg struct list, return list of ODCLFIELD Node fn args.
 func structargs(tl *Type, mustname bool) []*Node {
 	var args []*Node
@@ -0,0 +1,120 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"bytes"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// trimpathSrc has the compiler-generated bounds and nil checks,
+// closures and inlinable functions whose positions end up in object data.
+var trimpathSrc = map[string]string{
+	"p/p.go": `package p
+
+type T struct{ x, y int }
+
+//go:noinline
+func Index(a []int, i int) int { return a[i] }
+
+func Get(a []int, i int) int { return a[i] }
+
+func Nil(t *T) int { return t.y }
+
+func Div(a, b int) int { return a / b }
+
+func Add(i int) func() int { return func() int { return i + 1 } }
+`,
+	"m/main.go": `package main
+
+import "p"
+
+func main() {
+	println(p.Add(1)())
+	println(p.Index(nil, 3))
+}
+`,
+}
+
+// writeTrimpathTree writes trimpathSrc below dir/src.
+func writeTrimpathTree(t *testing.T, dir string) {
+	for name, src := range trimpathSrc {
+		file := filepath.Join(dir, "src", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestTrimpath checks that the objects compiled with -trimpath from two
+// different directories are identical and that a panic's traceback
+// shows the trimmed file names.
+func TestTrimpath(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	tmp, err := ioutil.TempDir("", "trimpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var objs [2][]byte
+	for i := range objs {
+		dir := filepath.Join(tmp, string('a'+i))
+		writeTrimpathTree(t, dir)
+		src := filepath.Join(dir, "src")
+		obj := filepath.Join(dir, "p.o")
+		cmd := exec.Command(testenv.GoToolPath(t), "tool", "compile", "-trimpath", src, "-o", obj, "p.go")
+		cmd.Dir = filepath.Join(src, "p")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("compile failed: %v\n%s", err, out)
+		}
+		if objs[i], err = ioutil.ReadFile(obj); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(objs[i], []byte(src)) {
+			t.Errorf("object compiled with -trimpath %s contains %s", src, src)
+		}
+	}
+	if !bytes.Equal(objs[0], objs[1]) {
+		t.Errorf("objects compiled with -trimpath from different directories differ")
+	}
+
+	// A bounds check failure in p reports the trimmed file name.
+	dir := filepath.Join(tmp, "a")
+	exe := filepath.Join(dir, "m.exe")
+	cmd := exec.Command(testenv.GoToolPath(t), "build", "-gcflags", "-trimpath "+filepath.Join(dir, "src"), "-o", exe, "m")
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "GOPATH=") {
+			cmd.Env = append(cmd.Env, v)
+		}
+	}
+	cmd.Env = append(cmd.Env, "GOPATH="+dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v\n%s", err, out)
+	}
+	out, err := exec.Command(exe).CombinedOutput()
+	if err == nil {
+		t.Fatalf("program did not panic:\n%s", out)
+	}
+	if !strings.Contains(string(out), "index out of range") {
+		t.Fatalf("program did not fail a bounds check:\n%s", out)
+	}
+	if !strings.Contains(string(out), "\n\tp/p.go:6 ") {
+		t.Errorf("traceback does not show trimmed file name p/p.go:\n%s", out)
+	}
+	if strings.Contains(string(out), dir) {
+		t.Errorf("traceback shows untrimmed file names:\n%s", out)
+	}
+}
@@ -386,7 +386,9 @@ OpSwitch:
 			}
 			bound := v.U.(*Mpint).Int64()
 			if bound < 0 {
-				Yyerror("array bound must be non-negative")
+				if l.Diag == 0 {
+					Yyerror("array bound must be non-negative")
+				}
 				n.Type = nil
 				return n
 			}
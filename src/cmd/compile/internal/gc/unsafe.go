@@ -4,6 +4,8 @@
 
 package gc
 
+import "fmt"
+
 // unsafenmagic rewrites calls to package unsafe's functions into constants.
 func unsafenmagic(nn *Node) *Node {
 	fn := nn.Left
@@ -127,3 +129,60 @@ func isunsafebuiltin(n *Node) bool {
 	}
 	return false
 }
+
+// unsafeAssertion reports whether n, a constant subtraction whose
+// value v is being checked against type t, is a failed assertion on
+// the result of unsafe.Sizeof, Alignof or Offsetof, such as
+//
+//	const _ = unsafe.Sizeof(T{}) - 64
+//	var _ [64 - unsafe.Sizeof(T{})]byte
+//
+// where v overflows t because the measured value differs from the
+// expected one. If so, it reports the error in terms of the two values
+// instead of the overflowed difference.
+func unsafeAssertion(n *Node, v Val, t *Type) bool {
+	if n == nil || n.Op != OSUB || t == nil || !t.IsInteger() || !doesoverflow(v, t) {
+		return false
+	}
+	call, expected, sign := n.Left.Orig, n.Right, int64(-1)
+	if !isunsafecall(call) {
+		call, expected, sign = n.Right.Orig, n.Left, 1
+	}
+	if !isunsafecall(call) || !Isconst(expected, CTINT) {
+		return false
+	}
+	diff := new(Mpint)
+	diff.Set(v.U.(*Mpint))
+	if sign > 0 {
+		diff.Neg()
+	}
+	got := new(Mpint)
+	got.Set(expected.Val().U.(*Mpint))
+	got.Add(diff)
+
+	arg := call.List.First()
+	var what string
+	switch call.Left.Sym.Name {
+	case "Sizeof":
+		what = fmt.Sprintf("size of %v", arg.Type)
+	case "Alignof":
+		what = fmt.Sprintf("alignment of %v", arg.Type)
+	case "Offsetof":
+		what = fmt.Sprintf("offset of %v", arg)
+		if arg.Op == ODOT || arg.Op == ODOTPTR {
+			base := arg.Left.Type
+			if base.IsPtr() {
+				base = base.Elem()
+			}
+			what = fmt.Sprintf("offset of field %v in %v", arg.Sym, base)
+		}
+	}
+	Yyerror("%s is %v bytes, expected %v (difference %+d)", what, got, expected.Val().U.(*Mpint), diff.Int64())
+	return true
+}
+
+// isunsafecall reports whether n is a call of unsafe.Sizeof,
+// Alignof or Offsetof.
+func isunsafecall(n *Node) bool {
+	return n != nil && n.Op == OCALL && isunsafebuiltin(n.Left) && n.List.Len() > 0
+}
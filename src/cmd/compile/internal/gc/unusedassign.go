@@ -0,0 +1,172 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Reports of local variables that are assigned but never read.
+//
+// A variable that is never read is "declared and not used", but a
+// variable that is read by name in a func literal is considered used,
+// even if the func literal only assigns it:
+//
+//	n := 0
+//	forEach(func(x int) { n++ })
+//	return 0 // meant to return n
+//
+// With -d=unusedassign, the compiler warns about the local variables
+// of a function and of the func literals in it whose value is assigned
+// and never read anywhere. A variable whose address is taken, including
+// implicitly by a method call with a pointer receiver, may be read
+// through the pointer and is not reported. Results are read by the
+// caller and are not reported either.
+// At -d=unusedassign=2 the reports are errors instead of warnings.
+
+package gc
+
+import "strings"
+
+// checkunusedassign reports the local variables of fn and its func
+// literals that are assigned but never read. It runs after capturevars,
+// while the closure variables in func literals still refer to the
+// variables they capture.
+func checkunusedassign(fn *Node) {
+	u := unusedAssigns{vars: make(map[*Node]varUse)}
+	u.fn(fn)
+	for _, v := range u.order {
+		use := u.vars[v]
+		if !use.assigned || use.read || use.addrtaken {
+			continue
+		}
+		if Debug_unusedassign > 1 {
+			yyerrorl(v.Lineno, "%v is assigned but never used", v.Sym)
+		} else {
+			Warnl(v.Lineno, "%v is assigned but never used", v.Sym)
+		}
+	}
+}
+
+type varUse struct {
+	assigned  bool
+	read      bool
+	addrtaken bool
+}
+
+type unusedAssigns struct {
+	vars  map[*Node]varUse
+	order []*Node // the checked variables in declaration order
+}
+
+// fn adds the local variables of the function fn to the variables
+// checked and scans its body.
+func (u *unusedAssigns) fn(fn *Node) {
+	for _, v := range fn.Func.Dcl {
+		// Skip the temporaries and heap addresses the compiler adds.
+		if v.Op != ONAME || v.Class&^PHEAP != PAUTO || isblank(v) || strings.ContainsAny(v.Sym.Name[:1], ".&~") {
+			continue
+		}
+		if v.Name.Defn != nil && v.Name.Defn.Op == OTYPESW {
+			continue
+		}
+		u.vars[v] = varUse{}
+		u.order = append(u.order, v)
+	}
+	u.list(fn.Nbody)
+}
+
+// variable returns the variable checked that n names, if any.
+func (u *unusedAssigns) variable(n *Node) *Node {
+	if n == nil || n.Op != ONAME {
+		return nil
+	}
+	if n.Class == PPARAMREF {
+		// A closure variable; Defn is the captured variable.
+		n = n.Name.Defn
+	}
+	if _, ok := u.vars[n]; !ok {
+		return nil
+	}
+	return n
+}
+
+// assign records an assignment to n, which is read if it is not a
+// variable.
+func (u *unusedAssigns) assign(n *Node) {
+	if v := u.variable(n); v != nil {
+		use := u.vars[v]
+		use.assigned = true
+		u.vars[v] = use
+		return
+	}
+	u.expr(n)
+}
+
+func (u *unusedAssigns) list(l Nodes) {
+	for _, n := range l.Slice() {
+		u.expr(n)
+	}
+}
+
+func (u *unusedAssigns) expr(n *Node) {
+	if n == nil {
+		return
+	}
+	u.list(n.Ninit)
+
+	switch n.Op {
+	case ONAME:
+		if v := u.variable(n); v != nil {
+			use := u.vars[v]
+			use.read = true
+			u.vars[v] = use
+		}
+		return
+
+	case ODCL:
+		return
+
+	case OCLOSURE:
+		// The captured variables in Func.Enter are not uses.
+		u.fn(n.Func.Closure)
+		return
+
+	case OADDR:
+		if v := u.variable(n.Left); v != nil {
+			use := u.vars[v]
+			use.addrtaken = true
+			u.vars[v] = use
+			return
+		}
+
+	case OAS, OASOP, OSELRECV:
+		// An OAS without a value zeroes the variable it declares. It is
+		// not an assignment, so that a variable declared only for its
+		// type, as in unsafe.Sizeof(x), is not reported.
+		if n.Op != OAS || n.Right != nil {
+			u.assign(n.Left)
+		}
+		u.expr(n.Right)
+		return
+
+	case OSELRECV2:
+		u.assign(n.Left)
+		for _, l := range n.List.Slice() {
+			u.assign(l)
+		}
+		u.expr(n.Right)
+		return
+
+	case OAS2, OAS2FUNC, OAS2RECV, OAS2MAPR, OAS2DOTTYPE, ORANGE:
+		for _, l := range n.List.Slice() {
+			u.assign(l)
+		}
+		u.list(n.Rlist)
+		u.expr(n.Right)
+		u.list(n.Nbody)
+		return
+	}
+
+	u.expr(n.Left)
+	u.expr(n.Right)
+	u.list(n.List)
+	u.list(n.Rlist)
+	u.list(n.Nbody)
+}
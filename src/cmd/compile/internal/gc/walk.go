@@ -77,6 +77,9 @@ func walk(fn *Node) {
 }
 
 func walkstmtlist(s []*Node) {
+	for i := 1; i < len(s); i++ {
+		reuseappend(s[i-1], s[i])
+	}
 	for i := range s {
 		s[i] = walkstmt(s[i])
 	}
@@ -2774,6 +2777,10 @@ func addstr(n *Node, init *Nodes) *Node {
 //
 // l2 is allowed to be a string.
 func appendslice(n *Node, init *Nodes) *Node {
+	// append(x[:0], l2...) reuses the array of x for a copy of l2:
+	// the result has the length of l2 and starts at the array.
+	reuse := isreslicezero(n.List.First())
+
 	walkexprlistsafe(n.List.Slice(), init)
 
 	// walkexprlistsafe will leave OINDEX (s[n]) alone if both s
@@ -2795,7 +2802,11 @@ func appendslice(n *Node, init *Nodes) *Node {
 
 	// n := len(s) + len(l2)
 	nn := temp(Types[TINT])
-	l = append(l, Nod(OAS, nn, Nod(OADD, Nod(OLEN, s, nil), Nod(OLEN, l2, nil))))
+	if reuse {
+		l = append(l, Nod(OAS, nn, Nod(OLEN, l2, nil)))
+	} else {
+		l = append(l, Nod(OAS, nn, Nod(OADD, Nod(OLEN, s, nil), Nod(OLEN, l2, nil))))
+	}
 
 	// if uint(n) > uint(cap(s))
 	nif := Nod(OIF, nil, nil)
@@ -2812,16 +2823,24 @@ func appendslice(n *Node, init *Nodes) *Node {
 	l = append(l, nif)
 
 	// s = s[:n]
+	// n is at most cap(s), which growslice made large enough.
 	nt := Nod(OSLICE, s, nil)
 	nt.SetSliceBounds(nil, nn, nil)
 	nt.Etype = 1
+	nt.Bounded = true
 	l = append(l, Nod(OAS, s, nt))
 
+	// dst is s[len(l1):], the part of s that l2 is copied to.
+	dst := s
+	if !reuse {
+		dst = Nod(OSLICE, s, nil)
+		dst.SetSliceBounds(Nod(OLEN, l1, nil), nil, nil)
+		dst.Etype = 1
+	}
+
 	if haspointers(l1.Type.Elem()) {
 		// copy(s[len(l1):], l2)
-		nptr1 := Nod(OSLICE, s, nil)
-		nptr1.SetSliceBounds(Nod(OLEN, l1, nil), nil, nil)
-		nptr1.Etype = 1
+		nptr1 := dst
 		nptr2 := l2
 		fn := syslook("typedslicecopy")
 		fn = substArgTypes(fn, l1.Type, l2.Type)
@@ -2832,9 +2851,7 @@ func appendslice(n *Node, init *Nodes) *Node {
 	} else if instrumenting {
 		// rely on runtime to instrument copy.
 		// copy(s[len(l1):], l2)
-		nptr1 := Nod(OSLICE, s, nil)
-		nptr1.SetSliceBounds(Nod(OLEN, l1, nil), nil, nil)
-		nptr1.Etype = 1
+		nptr1 := dst
 		nptr2 := l2
 		var fn *Node
 		if l2.Type.IsString() {
@@ -2849,10 +2866,14 @@ func appendslice(n *Node, init *Nodes) *Node {
 		l = append(ln.Slice(), nt)
 	} else {
 		// memmove(&s[len(l1)], &l2[0], len(l2)*sizeof(T))
-		nptr1 := Nod(OINDEX, s, Nod(OLEN, l1, nil))
-		nptr1.Bounded = true
-
-		nptr1 = Nod(OADDR, nptr1, nil)
+		var nptr1 *Node
+		if reuse {
+			nptr1 = Nod(OSPTR, s, nil)
+		} else {
+			nptr1 = Nod(OINDEX, s, Nod(OLEN, l1, nil))
+			nptr1.Bounded = true
+			nptr1 = Nod(OADDR, nptr1, nil)
+		}
 
 		nptr2 := Nod(OSPTR, l2, nil)
 
@@ -2874,6 +2895,48 @@ func appendslice(n *Node, init *Nodes) *Node {
 	return s
 }
 
+// isreslicezero reports whether n is x[:0] or x[0:0] for a slice x.
+func isreslicezero(n *Node) bool {
+	if n.Op != OSLICE {
+		return false
+	}
+	low, high, _ := n.SliceBounds()
+	return (low == nil || isconstzero(low)) && high != nil && isconstzero(high)
+}
+
+func isconstzero(n *Node) bool {
+	return Isconst(n, CTINT) && n.Int64() == 0
+}
+
+// reuseappend rewrites the statements
+//
+//	x = x[:0]
+//	x = append(x, l2...)
+//
+// where x is a local variable whose address is not taken, so that
+// the second is x = append(x[:0], l2...), which appendslice compiles
+// to a copy to the start of the array of x. Nothing can change x
+// between the statements, so x and x[:0] are the same slice there.
+func reuseappend(prev, n *Node) {
+	if prev.Op != OAS || n.Op != OAS || prev.Left != n.Left {
+		return
+	}
+	x := n.Left
+	if x.Op != ONAME || x.Addrtaken || (x.Class != PAUTO && x.Class != PPARAM) {
+		return
+	}
+	if prev.Right == nil || !isreslicezero(prev.Right) || prev.Right.Left != x {
+		return
+	}
+	r := n.Right
+	if r == nil || r.Op != OAPPEND || !r.Isddd || r.List.First() != x {
+		return
+	}
+	l1 := Nod(OSLICE, x, nil)
+	l1.SetSliceBounds(nil, Nodintconst(0), nil)
+	r.List.SetIndex(0, typecheck(l1, Erv))
+}
+
 // Rewrite append(src, x, y, z) so that any side effects in
 // x, y, z (including runtime panics) are evaluated in
 // initialization statements before the append.
@@ -58,6 +58,11 @@ var resvd = []int{
 	ppc64.FREGTWO,
 }
 
+// directMove reports whether the target, POWER8 or later, has the
+// instructions that move values between integer and floating point
+// registers without a round trip through memory. See Main.
+var directMove bool
+
 /*
  * generate
  *	as $c, n
@@ -168,6 +173,41 @@ func bignodes() {
 	bigi.Convconst(&bigf, gc.Types[gc.TFLOAT64])
 }
 
+// gmoveFtoR copies the 64 bits of the floating point register f
+// to the integer register t, going through the stack below SP if
+// the target lacks the direct move.
+func gmoveFtoR(f *gc.Node, t *gc.Node) {
+	if directMove {
+		gins(ppc64.AMFFPRD, f, t)
+		return
+	}
+	p1 := gins(ppc64.AFMOVD, f, nil)
+	p1.To.Type = obj.TYPE_MEM
+	p1.To.Reg = ppc64.REGSP
+	p1.To.Offset = -8
+	p1 = gins(ppc64.AMOVD, nil, t)
+	p1.From.Type = obj.TYPE_MEM
+	p1.From.Reg = ppc64.REGSP
+	p1.From.Offset = -8
+}
+
+// gmoveRtoF copies the integer register f to the floating point
+// register t, like gmoveFtoR the other way around.
+func gmoveRtoF(f *gc.Node, t *gc.Node) {
+	if directMove {
+		gins(ppc64.AMTFPRD, f, t)
+		return
+	}
+	p1 := gins(ppc64.AMOVD, f, nil)
+	p1.To.Type = obj.TYPE_MEM
+	p1.To.Reg = ppc64.REGSP
+	p1.To.Offset = -8
+	p1 = gins(ppc64.AFMOVD, nil, t)
+	p1.From.Type = obj.TYPE_MEM
+	p1.From.Reg = ppc64.REGSP
+	p1.From.Offset = -8
+}
+
 /*
  * generate move:
  *	t = f
@@ -419,14 +459,7 @@ func gmove(f *gc.Node, t *gc.Node) {
 		var r3 gc.Node
 		gc.Regalloc(&r3, gc.Types[gc.TINT64], t)
 		gins(ppc64.AFCTIDZ, &r1, &r2)
-		p1 := gins(ppc64.AFMOVD, &r2, nil)
-		p1.To.Type = obj.TYPE_MEM
-		p1.To.Reg = ppc64.REGSP
-		p1.To.Offset = -8
-		p1 = gins(ppc64.AMOVD, nil, &r3)
-		p1.From.Type = obj.TYPE_MEM
-		p1.From.Reg = ppc64.REGSP
-		p1.From.Offset = -8
+		gmoveFtoR(&r2, &r3)
 		gc.Regfree(&r2)
 		gc.Regfree(&r1)
 		if tt == gc.TUINT64 {
@@ -457,14 +490,7 @@ func gmove(f *gc.Node, t *gc.Node) {
 		gc.Regalloc(&r1, gc.Types[gc.TINT64], nil)
 		gmove(f, &r1)
 		gc.Regalloc(&r2, gc.Types[gc.TFLOAT64], t)
-		p1 := gins(ppc64.AMOVD, &r1, nil)
-		p1.To.Type = obj.TYPE_MEM
-		p1.To.Reg = ppc64.REGSP
-		p1.To.Offset = -8
-		p1 = gins(ppc64.AFMOVD, nil, &r2)
-		p1.From.Type = obj.TYPE_MEM
-		p1.From.Reg = ppc64.REGSP
-		p1.From.Offset = -8
+		gmoveRtoF(&r1, &r2)
 		gins(ppc64.AFCFID, &r2, &r2)
 		gc.Regfree(&r1)
 		gmove(&r2, t)
@@ -487,14 +513,7 @@ func gmove(f *gc.Node, t *gc.Node) {
 		gc.Regalloc(&r1, gc.Types[gc.TUINT64], nil)
 		gmove(f, &r1)
 		gc.Regalloc(&r2, gc.Types[gc.TFLOAT64], t)
-		p1 := gins(ppc64.AMOVD, &r1, nil)
-		p1.To.Type = obj.TYPE_MEM
-		p1.To.Reg = ppc64.REGSP
-		p1.To.Offset = -8
-		p1 = gins(ppc64.AFMOVD, nil, &r2)
-		p1.From.Type = obj.TYPE_MEM
-		p1.From.Reg = ppc64.REGSP
-		p1.From.Offset = -8
+		gmoveRtoF(&r1, &r2)
 		gins(ppc64.AFCFIDU, &r2, &r2)
 		gc.Regfree(&r1)
 		gmove(&r2, t)
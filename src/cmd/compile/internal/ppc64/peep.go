@@ -635,7 +635,9 @@ func copyu(p *obj.Prog, v *obj.Addr, s *obj.Addr) int {
 		ppc64.AFRSP,
 		ppc64.AFNEG,
 		ppc64.AFNEGCC,
-		ppc64.AFSQRT:
+		ppc64.AFSQRT,
+		ppc64.AMFFPRD,
+		ppc64.AMTFPRD:
 		if s != nil {
 			if copysub(&p.From, v, s, true) {
 				return 1
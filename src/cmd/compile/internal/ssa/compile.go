@@ -6,10 +6,13 @@ package ssa
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -45,8 +48,8 @@ func Compile(f *Func) {
 		checkFunc(f)
 	}
 	const logMemStats = false
-	for _, p := range passes {
-		if !f.Config.optimize && !p.required || p.disabled {
+	for i, p := range passes {
+		if (!f.Config.optimize || f.NoOpt) && !p.required || p.disabled {
 			continue
 		}
 		f.pass = &p
@@ -63,6 +66,10 @@ func Compile(f *Func) {
 		tStart := time.Now()
 		p.fn(f)
 		tEnd := time.Now()
+		if PhaseTimes {
+			atomic.AddInt64(&phaseTimes[i].ns, tEnd.Sub(tStart).Nanoseconds())
+			atomic.AddInt64(&phaseTimes[i].funcs, 1)
+		}
 
 		// Need something less crude than "Log the whole intermediate result".
 		if f.Log() || f.Config.HTML != nil {
@@ -128,6 +135,63 @@ var BuildDebug int
 var BuildTest int
 var BuildStats int
 
+// PhaseTimes enables the accumulation of the time spent in each pass
+// over all functions compiled, for WritePhaseTimes.
+var PhaseTimes bool
+
+// phaseTimes[i] accumulates the time spent in passes[i] and the number
+// of functions it ran on. The counters are updated atomically.
+var phaseTimes [len(passes)]struct {
+	ns    int64
+	funcs int64
+}
+
+// WritePhaseTimes writes to w a table of the passes that ran, with the
+// total time spent in each and the number of functions it ran on,
+// slowest first.
+func WritePhaseTimes(w io.Writer) error {
+	var rows []phaseTime
+	var total int64
+	for i := range passes {
+		ns := atomic.LoadInt64(&phaseTimes[i].ns)
+		funcs := atomic.LoadInt64(&phaseTimes[i].funcs)
+		if funcs == 0 {
+			continue
+		}
+		rows = append(rows, phaseTime{passes[i].name, ns, funcs})
+		total += ns
+	}
+	sort.Sort(byPhaseTime(rows))
+
+	if _, err := fmt.Fprintf(w, "%-24s %14s %8s\n", "phase", "ns", "funcs"); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if _, err := fmt.Fprintf(w, "%-24s %14d %8d\n", r.name, r.ns, r.funcs); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%-24s %14d\n", "total", total)
+	return err
+}
+
+type phaseTime struct {
+	name  string
+	ns    int64
+	funcs int64
+}
+
+type byPhaseTime []phaseTime
+
+func (a byPhaseTime) Len() int      { return len(a) }
+func (a byPhaseTime) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byPhaseTime) Less(i, j int) bool {
+	if a[i].ns != a[j].ns {
+		return a[i].ns > a[j].ns
+	}
+	return a[i].name < a[j].name
+}
+
 // PhaseOption sets the specified flag in the specified ssa phase,
 // returning empty string if this was successful or a string explaining
 // the error if it was not.
@@ -138,6 +202,7 @@ var BuildStats int
 // Special cases that have turned out to be useful:
 //  ssa/check/on enables checking after each phase
 //  ssa/all/time enables time reporting for all phases
+//  ssa/all/stats reports the total time spent in each phase, see WritePhaseTimes
 //
 // See gc/lex.go for dissection of the option string.
 // Example uses:
@@ -158,6 +223,10 @@ func PhaseOption(phase, flag string, val int) string {
 
 	alltime := false
 	if phase == "all" {
+		if flag == "stats" {
+			PhaseTimes = val != 0
+			return ""
+		}
 		if flag == "time" {
 			alltime = val != 0
 		} else {
@@ -23,6 +23,7 @@ type Func struct {
 	vid        idAlloc     // value ID allocator
 
 	scheduled bool // Values in Blocks are in final order
+	NoOpt     bool // compile without optimizations, as if Config did not optimize
 
 	// when register allocation is done, maps value ids to locations
 	RegAlloc []Location
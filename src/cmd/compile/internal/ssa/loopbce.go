@@ -136,14 +136,6 @@ nextb:
 			}
 		}
 
-		if f.pass.debug > 1 {
-			if min.Op == OpConst64 {
-				b.Func.Config.Warnl(b.Line, "Induction variable with minimum %d and increment %d", min.AuxInt, inc.AuxInt)
-			} else {
-				b.Func.Config.Warnl(b.Line, "Induction variable with non-const minimum and increment %d", inc.AuxInt)
-			}
-		}
-
 		iv = append(iv, indVar{
 			ind:   ind,
 			inc:   inc,
@@ -164,6 +156,15 @@ func loopbce(f *Func) {
 
 	m := make(map[*Value]indVar)
 	for _, iv := range ivList {
+		if f.pass.debug > 1 {
+			// The loop header is the single predecessor of the entry.
+			line := iv.entry.Preds[0].b.Line
+			if iv.min.Op == OpConst64 {
+				f.Config.Warnl(line, "Induction variable with minimum %d and increment %d", iv.min.AuxInt, iv.inc.AuxInt)
+			} else {
+				f.Config.Warnl(line, "Induction variable with non-const minimum and increment %d", iv.inc.AuxInt)
+			}
+		}
 		m[iv.ind] = iv
 	}
 
@@ -464,6 +464,14 @@ func prove(f *Func) {
 
 	ft := newFactsTable()
 
+	// Induction variables known to be non-negative, for fuseBoundsChecks.
+	ivs := make(map[*Value]indVar)
+	for _, iv := range findIndVar(f) {
+		if isNonNegative(iv.min) {
+			ivs[iv.ind] = iv
+		}
+	}
+
 	// DFS on the dominator tree.
 	for len(work) > 0 {
 		node := work[len(work)-1]
@@ -497,6 +505,11 @@ func prove(f *Func) {
 
 		case simplify:
 			succ := simplifyBlock(ft, node.block)
+			if succ == unknown {
+				// The checks dominated by this block have been
+				// simplified already; fuse those that are left.
+				fuseBoundsChecks(ft, ivs, node.block)
+			}
 			if succ != unknown {
 				b := node.block
 				b.Kind = BlockFirst
@@ -513,6 +526,88 @@ func prove(f *Func) {
 	}
 }
 
+// fuseBoundsChecks replaces a chain of bounds checks of monotonically
+// offset indexes into the same slice, such as the checks for
+//
+//    x := uint32(a[i]) | uint32(a[i+1])<<8 | uint32(a[i+2])<<16
+//
+// by a single check of the maximum index at the start of the chain,
+// provided that the minimum index is known to be non-negative.
+//
+// The chain starts at b and follows the in-bounds successors for as long
+// as they have a single predecessor and no side effects, so that no
+// code that is observably different is executed before the checks that
+// are now done earlier. The other checks become always true.
+// It is called once the blocks dominated by b have been simplified,
+// so checks that prove removes on its own are not part of the chain.
+func fuseBoundsChecks(ft *factsTable, ivs map[*Value]indVar, b *Block) {
+	if b.Kind != BlockIf || b.Control.Op != OpIsInBounds {
+		return
+	}
+	c := b.Control
+	idx, limit := c.Args[0], c.Args[1]
+	base, first := dropAdd64(idx)
+	if base.Op == OpConst64 {
+		base, first = nil, base.AuxInt
+	}
+	min, max := first, first
+	var fused []*Block
+	for s := b.Succs[0].b; s != b && len(s.Preds) == 1; s = s.Succs[0].b {
+		for _, v := range s.Values {
+			if v.Type.IsMemory() || v.Op == OpNilCheck {
+				goto done
+			}
+		}
+		if s.Kind == BlockPlain || s.Kind == BlockFirst {
+			continue
+		}
+		if s.Kind != BlockIf || s.Control.Op != OpIsInBounds || s.Control.Args[1] != limit {
+			break
+		}
+		sbase, off := dropAdd64(s.Control.Args[0])
+		if sbase.Op == OpConst64 {
+			sbase, off = nil, sbase.AuxInt
+		}
+		if sbase != base {
+			break
+		}
+		if off < min {
+			min = off
+		}
+		if off > max {
+			max = off
+		}
+		fused = append(fused, s)
+	}
+done:
+	if len(fused) == 0 || min < 0 {
+		return
+	}
+	if base != nil && !ft.isNonNegative(base) {
+		iv, ok := ivs[base]
+		if !ok || !b.Func.sdom.isAncestorEq(iv.entry, b) {
+			return
+		}
+	}
+
+	if max != first {
+		// Check the maximum index instead.
+		f := b.Func
+		newIdx := f.ConstInt64(c.Line, idx.Type, max)
+		if base != nil {
+			newIdx = b.NewValue2(c.Line, OpAdd64, idx.Type, base, newIdx)
+		}
+		b.SetControl(b.NewValue2(c.Line, OpIsInBounds, c.Type, newIdx, limit))
+	}
+	for _, s := range fused {
+		if b.Func.pass.debug > 0 {
+			b.Func.Config.Warnl(s.Line, "Fused %s", s.Control.Op)
+		}
+		s.Kind = BlockFirst
+		s.SetControl(nil)
+	}
+}
+
 // getBranch returns the range restrictions added by p
 // when reaching b. p is the immediate dominator of b.
 func getBranch(sdom SparseTree, p *Block, b *Block) branch {
@@ -0,0 +1,82 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"testing"
+)
+
+// decodeVarint4 decodes a varint of at most 4 bytes, loading all of them
+// before looking at the continuation bits. The bounds checks of the
+// loads are fused into a single check of b[3].
+//go:noinline
+func decodeVarint4(b []byte) (uint32, int) {
+	b0, b1, b2, b3 := uint32(b[0]), uint32(b[1]), uint32(b[2]), uint32(b[3])
+	if b0 < 0x80 {
+		return b0, 1
+	}
+	if b1 < 0x80 {
+		return b0&0x7f | b1<<7, 2
+	}
+	if b2 < 0x80 {
+		return b0&0x7f | (b1&0x7f)<<7 | b2<<14, 3
+	}
+	return b0&0x7f | (b1&0x7f)<<7 | (b2&0x7f)<<14 | b3<<21, 4
+}
+
+//go:noinline
+func loadAt(b []byte, i int) uint32 {
+	if i < 0 {
+		return 0
+	}
+	return uint32(b[i]) | uint32(b[i+1])<<8 | uint32(b[i+2])<<16
+}
+
+//go:noinline
+func store2(b []byte) {
+	b[0] = 1
+	b[1] = 2
+}
+
+func shouldPanic(t *testing.T, name string, f func()) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s did not panic", name)
+		}
+	}()
+	f()
+}
+
+func TestFusedBoundsChecks(t *testing.T) {
+	if x, n := decodeVarint4([]byte{0x96, 0x01, 0, 0}); x != 150 || n != 2 {
+		t.Errorf("decodeVarint4 = %d, %d; want 150, 2", x, n)
+	}
+	shouldPanic(t, "decodeVarint4 of 3 bytes", func() { decodeVarint4([]byte{1, 2, 3}) })
+
+	b := []byte{1, 2, 3, 4}
+	if x := loadAt(b, 1); x != 0x040302 {
+		t.Errorf("loadAt(b, 1) = %#x, want 0x040302", x)
+	}
+	shouldPanic(t, "loadAt(b, 2)", func() { loadAt(b, 2) })
+
+	// The store to b[0] happens before the check of b[1] fails.
+	b = []byte{0}
+	shouldPanic(t, "store2 of 1 byte", func() { store2(b) })
+	if b[0] != 1 {
+		t.Errorf("store2 did not store b[0] before panicking")
+	}
+}
+
+var varintSink uint32
+
+func BenchmarkDecodeVarint4(b *testing.B) {
+	buf := []byte{0x96, 0x01, 0, 0, 0xff, 0xff, 0xff, 0x7f, 0x05, 0, 0, 0}
+	for i := 0; i < b.N; i++ {
+		for j := 0; j+4 <= len(buf); j += 4 {
+			x, _ := decodeVarint4(buf[j:])
+			varintSink += x
+		}
+	}
+}
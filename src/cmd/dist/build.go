@@ -28,6 +28,7 @@ var (
 	goos             string
 	goarm            string
 	go386            string
+	goppc64          string
 	goroot           string
 	goroot_final     string
 	goextlinkenabled string
@@ -135,6 +136,9 @@ func xinit() {
 	}
 	go386 = b
 
+	// An empty GOPPC64 leaves the choice to cmd/internal/obj.
+	goppc64 = os.Getenv("GOPPC64")
+
 	p := pathf("%s/src/all.bash", goroot)
 	if !isfile(p) {
 		fatal("$GOROOT is not set correctly or not exported\n"+
@@ -214,6 +218,7 @@ func xinit() {
 	os.Setenv("GOARM", goarm)
 	os.Setenv("GOHOSTARCH", gohostarch)
 	os.Setenv("GOHOSTOS", gohostos)
+	os.Setenv("GOPPC64", goppc64)
 	os.Setenv("GOOS", goos)
 	os.Setenv("GOROOT", goroot)
 	os.Setenv("GOROOT_FINAL", goroot_final)
@@ -986,6 +991,9 @@ func cmdenv() {
 	if goarch == "386" {
 		xprintf(format, "GO386", go386)
 	}
+	if (goarch == "ppc64" || goarch == "ppc64le") && goppc64 != "" {
+		xprintf(format, "GOPPC64", goppc64)
+	}
 
 	if *path {
 		sep := ":"
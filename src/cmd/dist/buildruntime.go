@@ -43,6 +43,7 @@ func mkzversion(dir, file string) {
 //	const defaultGOROOT = <goroot>
 //	const defaultGO386 = <go386>
 //	const defaultGOARM = <goarm>
+//	const defaultGOPPC64 = <goppc64>
 //	const defaultGOOS = runtime.GOOS
 //	const defaultGOARCH = runtime.GOARCH
 //	const defaultGO_EXTLINK_ENABLED = <goextlinkenabled>
@@ -70,13 +71,14 @@ func mkzbootstrap(file string) {
 			"const defaultGOROOT = `%s`\n"+
 			"const defaultGO386 = `%s`\n"+
 			"const defaultGOARM = `%s`\n"+
+			"const defaultGOPPC64 = `%s`\n"+
 			"const defaultGOOS = runtime.GOOS\n"+
 			"const defaultGOARCH = runtime.GOARCH\n"+
 			"const defaultGO_EXTLINK_ENABLED = `%s`\n"+
 			"const version = `%s`\n"+
 			"const stackGuardMultiplier = %d\n"+
 			"const goexperiment = `%s`\n",
-		goroot_final, go386, goarm, goextlinkenabled, findgoversion(), stackGuardMultiplier(), os.Getenv("GOEXPERIMENT"))
+		goroot_final, go386, goarm, goppc64, goextlinkenabled, findgoversion(), stackGuardMultiplier(), os.Getenv("GOEXPERIMENT"))
 
 	writefile(out, file, writeSkipSame)
 }
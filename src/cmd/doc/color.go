@@ -0,0 +1,174 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/scanner"
+	"go/token"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Colors, as ANSI escape sequences, for output to a terminal.
+// In declarations, keywords, exported identifiers and string and
+// character literals are colored. Comment text is not, except for
+// headings and the "Deprecated:" that begins a deprecation notice.
+const (
+	colorKeyword    = "\x1b[34m"   // blue
+	colorExported   = "\x1b[1m"    // bold
+	colorString     = "\x1b[32m"   // green
+	colorHeading    = "\x1b[1;4m"  // bold, underlined
+	colorDeprecated = "\x1b[1;31m" // bold red
+	colorReset      = "\x1b[0m"
+)
+
+// useColor reports whether to color the output written to w, according
+// to the -color flag. With -color=auto, the default, the output is
+// colored if w is a terminal and the NO_COLOR environment variable is
+// empty.
+func useColor(w io.Writer) (bool, error) {
+	switch colorFlag {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return os.Getenv("NO_COLOR") == "" && isTerminal(w), nil
+	}
+	return false, fmt.Errorf("invalid -color value %q: must be always, never or auto", colorFlag)
+}
+
+// isTerminal reports whether w is a terminal that understands colors.
+// On Windows and Plan 9 it never is, since the consoles there need not
+// understand ANSI escape sequences.
+func isTerminal(w io.Writer) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// A commentText is a comment formatted by doc.ToText at buf[start:end]
+// of a Package, with the text of its headings.
+type commentText struct {
+	start, end int
+	headings   []string
+}
+
+var headingHTML = regexp.MustCompile(`<h3 id="[^"]*">(.*)</h3>`)
+
+// headings returns the text of the headings go/doc finds in comment.
+func headings(comment string) []string {
+	var b bytes.Buffer
+	doc.ToHTML(&b, comment, nil)
+	var heads []string
+	for _, m := range headingHTML.FindAllStringSubmatch(b.String(), -1) {
+		heads = append(heads, html.UnescapeString(m[1]))
+	}
+	return heads
+}
+
+// colorize returns text with colors added. The comments, in order, are
+// the parts of text that are comment text; the rest is declarations
+// and the like.
+func colorize(text []byte, comments []commentText) []byte {
+	var b bytes.Buffer
+	last := 0
+	for _, c := range comments {
+		colorCode(&b, text[last:c.start])
+		colorComment(&b, text[c.start:c.end], c.headings)
+		last = c.end
+	}
+	colorCode(&b, text[last:])
+	return b.Bytes()
+}
+
+// colorCode writes src to b, coloring its Go tokens. Text that is not
+// Go, such as the file positions of the -where flag, is scanned as well,
+// but the scanner's errors are ignored and the text is not changed.
+func colorCode(b *bytes.Buffer, src []byte) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		off := file.Offset(pos)
+		if off < last || !bytes.HasPrefix(src[off:], []byte(lit)) {
+			// Already written, or a raw string from which the
+			// scanner removed carriage returns.
+			continue
+		}
+		color := ""
+		switch {
+		case tok == token.IDENT && bytes.HasPrefix(src[off:], []byte(deprecatedSummaryMarker)):
+			lit = strings.TrimSuffix(deprecatedSummaryMarker, " ")
+			color = colorDeprecated
+		case tok.IsKeyword():
+			color = colorKeyword
+		case tok == token.IDENT && ast.IsExported(lit):
+			color = colorExported
+		case tok == token.STRING || tok == token.CHAR:
+			color = colorString
+		}
+		if color == "" {
+			continue
+		}
+		b.Write(src[last:off])
+		b.WriteString(color)
+		b.WriteString(lit)
+		b.WriteString(colorReset)
+		last = off + len(lit)
+	}
+	b.Write(src[last:])
+}
+
+// colorComment writes the comment text to b, coloring the lines that
+// are the given headings and the "Deprecated:" that begins a line.
+func colorComment(b *bytes.Buffer, text []byte, headings []string) {
+	for _, line := range strings.SplitAfter(string(text), "\n") {
+		body := strings.TrimLeft(line, " \t")
+		b.WriteString(line[:len(line)-len(body)])
+		trimmed := strings.TrimRight(body, "\n")
+		switch {
+		case trimmed != "" && isHeading(trimmed, headings):
+			b.WriteString(colorHeading + trimmed + colorReset)
+			b.WriteString(body[len(trimmed):])
+		case strings.HasPrefix(body, deprecatedPrefix):
+			marker := strings.TrimSuffix(deprecatedPrefix, " ")
+			b.WriteString(colorDeprecated + marker + colorReset)
+			b.WriteString(body[len(marker):])
+		default:
+			b.WriteString(body)
+		}
+	}
+}
+
+// isHeading reports whether line is one of the headings.
+func isHeading(line string, headings []string) bool {
+	for _, h := range headings {
+		if line == h {
+			return true
+		}
+	}
+	return false
+}
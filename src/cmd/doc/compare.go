@@ -0,0 +1,280 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+)
+
+// An apiDecl is the declaration of an exported constant, variable,
+// function, type or method, as compared by the -compare flag.
+type apiDecl struct {
+	kind int    // Position of the kind in the output: apiConst, apiVar, ...
+	name string // Symbol name, or Type.Method for a method.
+	text string // Declaration, normalized by go/printer.
+	doc  string // Doc comment.
+}
+
+// The kinds of declaration, in the order they are listed. Methods are
+// listed with the types they belong to.
+const (
+	apiConst = iota
+	apiVar
+	apiFunc
+	apiType
+)
+
+// compare prints the differences between the exported APIs of the
+// packages in the directories oldDir and newDir: the declarations
+// removed, added and changed in newDir. Doc comment changes are listed
+// only with the -comments flag. It returns an error if any declaration
+// was removed or changed, since those changes may break clients.
+func compare(writer io.Writer, oldDir, newDir string) (err error) {
+	defer catchPackageError(&err)
+
+	oldPkg := parsePackage(writer, importDir(oldDir), oldDir)
+	newPkg := parsePackage(writer, importDir(newDir), newDir)
+	defer newPkg.flush()
+
+	oldAPI := oldPkg.api()
+	newAPI := newPkg.api()
+	var removed, added, changed, docChanged []apiDecl
+	for key, old := range oldAPI {
+		decl, ok := newAPI[key]
+		switch {
+		case !ok:
+			removed = append(removed, old)
+		case decl.text != old.text:
+			changed = append(changed, old, decl)
+		case decl.doc != old.doc:
+			docChanged = append(docChanged, decl)
+		}
+	}
+	for key, decl := range newAPI {
+		if _, ok := oldAPI[key]; !ok {
+			added = append(added, decl)
+		}
+	}
+
+	newPkg.apiSection("Removed", removed)
+	newPkg.apiSection("Added", added)
+	newPkg.apiSection("Changed", changed, "- ", "+ ")
+	if comments {
+		newPkg.apiSection("Doc comment changed", docChanged)
+	}
+	if len(removed) > 0 || len(changed) > 0 {
+		return fmt.Errorf("incompatible API changes: %d removed, %d changed", len(removed), len(changed)/2)
+	}
+	return nil
+}
+
+// catchPackageError recovers a PackageError panic raised by fatalf and
+// stores it in *err. Any other panic is passed on.
+func catchPackageError(err *error) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	pkgError, ok := e.(PackageError)
+	if ok {
+		*err = pkgError
+		return
+	}
+	panic(e)
+}
+
+// apiSection prints a titled list of declarations, sorted by kind and
+// name, unless the list is empty. With marks, the declarations come in
+// runs of len(marks) that share a name, such as the old and new versions
+// of a changed declaration, and each is preceded by its mark.
+func (pkg *Package) apiSection(title string, decls []apiDecl, marks ...string) {
+	if len(decls) == 0 {
+		return
+	}
+	n := len(marks)
+	if n == 0 {
+		n = 1
+	}
+	sort.Stable(apiRuns{decls, n})
+	pkg.Printf("%s:\n", title)
+	for i, decl := range decls {
+		mark := ""
+		if marks != nil {
+			mark = marks[i%n]
+		}
+		text := strings.Replace(decl.text, "\n", "\n"+indent+strings.Repeat(" ", len(mark)), -1)
+		pkg.Printf("%s%s%s\n", indent, mark, text)
+	}
+	pkg.newlines(2)
+}
+
+// apiRuns sorts a list of declarations in runs of n by the kind and
+// name of the first of each run.
+type apiRuns struct {
+	decls []apiDecl
+	n     int
+}
+
+func (r apiRuns) Len() int { return len(r.decls) / r.n }
+func (r apiRuns) Less(i, j int) bool {
+	a, b := r.decls[i*r.n], r.decls[j*r.n]
+	if a.kind != b.kind {
+		return a.kind < b.kind
+	}
+	return a.name < b.name
+}
+func (r apiRuns) Swap(i, j int) {
+	for k := 0; k < r.n; k++ {
+		r.decls[i*r.n+k], r.decls[j*r.n+k] = r.decls[j*r.n+k], r.decls[i*r.n+k]
+	}
+}
+
+// api returns the exported declarations of the package, keyed by kind
+// and name.
+func (pkg *Package) api() map[string]apiDecl {
+	api := make(map[string]apiDecl)
+	add := func(kind int, name, text, doc string) {
+		api[fmt.Sprint(kind, name)] = apiDecl{kind, name, text, doc}
+	}
+	values := func(kind int, tok token.Token, decl *ast.GenDecl, doc string) {
+		// A constant spec with no type or values repeats the previous one.
+		var typ ast.Expr
+		var vals []ast.Expr
+		for _, spec := range decl.Specs {
+			vspec := spec.(*ast.ValueSpec)
+			if tok == token.VAR || vspec.Type != nil || vspec.Values != nil {
+				typ, vals = vspec.Type, vspec.Values
+			}
+			for i, ident := range vspec.Names {
+				if !isExported(ident.Name) {
+					continue
+				}
+				text := fmt.Sprintf("%s %s", tok, ident.Name)
+				if typ != nil {
+					text += " " + apiText(typ)
+				}
+				if i < len(vals) {
+					text += " = " + apiText(vals[i])
+				}
+				add(kind, ident.Name, text, doc)
+			}
+		}
+	}
+	for _, value := range pkg.doc.Consts {
+		values(apiConst, token.CONST, value.Decl, value.Doc)
+	}
+	for _, value := range pkg.doc.Vars {
+		values(apiVar, token.VAR, value.Decl, value.Doc)
+	}
+	for _, fun := range pkg.doc.Funcs {
+		if isExported(fun.Name) {
+			add(apiFunc, fun.Name, apiFuncText(fun.Decl), fun.Doc)
+		}
+	}
+	for _, typ := range pkg.doc.Types {
+		if !isExported(typ.Name) {
+			continue
+		}
+		spec := *pkg.findTypeSpec(typ.Decl, typ.Name)
+		spec.Doc = nil
+		spec.Comment = nil
+		switch t := spec.Type.(type) {
+		case *ast.StructType:
+			s := *t
+			s.Fields = exportedFields(t.Fields)
+			spec.Type = &s
+		case *ast.InterfaceType:
+			i := *t
+			i.Methods = exportedFields(t.Methods)
+			// The order of an interface's methods does not matter.
+			sort.Sort(fieldsByName(i.Methods.List))
+			spec.Type = &i
+		}
+		add(apiType, typ.Name, "type "+apiText(&spec), typ.Doc)
+		for _, meth := range typ.Methods {
+			if isExported(meth.Name) {
+				add(apiType, typ.Name+"."+meth.Name, apiFuncText(meth.Decl), meth.Doc)
+			}
+		}
+	}
+	return api
+}
+
+// exportedFields returns the exported fields of a struct or methods of
+// an interface (all of them with the -u flag). Unlike
+// trimUnexportedFields it leaves no mark where they were, since they
+// are not part of the API.
+func exportedFields(fields *ast.FieldList) *ast.FieldList {
+	list := make([]*ast.Field, 0, len(fields.List))
+	for _, field := range fields.List {
+		names := field.Names
+		if len(names) == 0 {
+			// Embedded type, named by its type as in trimUnexportedFields.
+			expr := field.Type
+			if star, ok := expr.(*ast.StarExpr); ok {
+				expr = star.X
+			}
+			if sel, ok := expr.(*ast.SelectorExpr); ok {
+				expr = sel.Sel
+			}
+			if ident, ok := expr.(*ast.Ident); ok {
+				names = []*ast.Ident{ident}
+			}
+		}
+		ok := len(names) > 0
+		for _, name := range names {
+			ok = ok && isExported(name.Name)
+		}
+		if ok {
+			f := *field
+			f.Doc = nil
+			f.Comment = nil
+			list = append(list, &f)
+		}
+	}
+	return &ast.FieldList{Opening: fields.Opening, List: list, Closing: fields.Closing}
+}
+
+// fieldsByName sorts the methods of an interface by name, and the
+// interfaces it embeds by their type.
+type fieldsByName []*ast.Field
+
+func (f fieldsByName) Len() int           { return len(f) }
+func (f fieldsByName) Less(i, j int) bool { return fieldName(f[i]) < fieldName(f[j]) }
+func (f fieldsByName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// fieldName returns the first name of a field, or the text of its type
+// if it is embedded.
+func fieldName(field *ast.Field) string {
+	if len(field.Names) > 0 {
+		return field.Names[0].Name
+	}
+	return apiText(field.Type)
+}
+
+// apiFuncText returns the declaration of a function or method without
+// its doc comment and body.
+func apiFuncText(decl *ast.FuncDecl) string {
+	d := *decl
+	d.Doc = nil
+	d.Body = nil
+	return apiText(&d)
+}
+
+// apiText formats node without reference to its source positions, so
+// that the text does not depend on the comments and blank lines around
+// and within it.
+func apiText(node ast.Node) string {
+	var b bytes.Buffer
+	format.Node(&b, token.NewFileSet(), node)
+	return b.String()
+}
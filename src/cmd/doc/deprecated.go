@@ -0,0 +1,138 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"go/doc"
+	"strings"
+)
+
+// deprecatedPrefix introduces a paragraph of a doc comment that
+// explains that the symbol is deprecated and what to use instead.
+const deprecatedPrefix = "Deprecated: "
+
+// deprecation splits a doc comment into its deprecation notice, the
+// paragraph that begins with "Deprecated: ", and the remaining text of
+// the comment. If there is no such paragraph, notice is empty and rest
+// is the comment unchanged.
+func deprecation(comment string) (notice, rest string) {
+	var paras []string
+	for _, para := range paragraphs(comment) {
+		if notice == "" && strings.HasPrefix(para, deprecatedPrefix) {
+			notice = para
+			continue
+		}
+		paras = append(paras, para)
+	}
+	if notice == "" {
+		return "", comment
+	}
+	return notice, strings.Join(paras, "\n")
+}
+
+// paragraphs splits a comment into its paragraphs, the runs of non-blank
+// lines. Each paragraph ends with a newline.
+func paragraphs(comment string) []string {
+	var paras []string
+	para := ""
+	for _, line := range strings.SplitAfter(comment, "\n") {
+		if strings.TrimSpace(line) != "" {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			para += line
+			continue
+		}
+		if para != "" {
+			paras = append(paras, para)
+			para = ""
+		}
+	}
+	if para != "" {
+		paras = append(paras, para)
+	}
+	return paras
+}
+
+// deprecatedFirst returns the comment rearranged so that its deprecation
+// notice, if any, is the first paragraph.
+func deprecatedFirst(comment string) string {
+	notice, rest := deprecation(comment)
+	if notice == "" || rest == "" {
+		return comment
+	}
+	return notice + "\n" + rest
+}
+
+// isDeprecated reports whether the comment contains a deprecation notice.
+func isDeprecated(comment string) bool {
+	notice, _ := deprecation(comment)
+	return notice != ""
+}
+
+// deprecatedSummaryMarker precedes the one-line summary of a deprecated
+// symbol.
+const deprecatedSummaryMarker = "DEPRECATED: "
+
+// deprecatedMarker returns the marker that precedes the one-line summary
+// of a symbol with the doc comment, or "" if the symbol is not deprecated.
+func deprecatedMarker(comment string) string {
+	if isDeprecated(comment) {
+		return deprecatedSummaryMarker
+	}
+	return ""
+}
+
+// deprecatedDoc prints a one-line summary of each deprecated symbol in the
+// package, followed by its deprecation notice.
+func (pkg *Package) deprecatedDoc() {
+	defer pkg.flush()
+	for _, value := range pkg.doc.Consts {
+		pkg.deprecatedValue(value)
+	}
+	for _, value := range pkg.doc.Vars {
+		pkg.deprecatedValue(value)
+	}
+	for _, fun := range pkg.doc.Funcs {
+		if isExported(fun.Name) {
+			pkg.deprecatedItem(fun.Doc, func() { pkg.oneLineFunc(fun.Decl) })
+		}
+	}
+	for _, typ := range pkg.doc.Types {
+		spec := pkg.findTypeSpec(typ.Decl, typ.Name)
+		if !isExported(typ.Name) {
+			continue
+		}
+		pkg.deprecatedItem(typ.Doc, func() { pkg.oneLineTypeDecl(spec) })
+		for _, meth := range typ.Methods {
+			if isExported(meth.Name) {
+				pkg.deprecatedItem(meth.Doc, func() { pkg.oneLineFunc(meth.Decl) })
+			}
+		}
+	}
+}
+
+// deprecatedValue prints the summary of a deprecated const or var declaration.
+func (pkg *Package) deprecatedValue(value *doc.Value) {
+	for _, name := range value.Names {
+		if isExported(name) {
+			pkg.deprecatedItem(value.Doc, func() { pkg.oneLineValueGenDecl("", value.Decl) })
+			return
+		}
+	}
+}
+
+// deprecatedItem prints the one-line summary produced by oneLine, followed
+// by the deprecation notice in comment, if comment has one.
+func (pkg *Package) deprecatedItem(comment string, oneLine func()) {
+	notice, _ := deprecation(comment)
+	if notice == "" {
+		return
+	}
+	oneLine()
+	pkg.newlines(1)
+	pkg.toText(notice, indent, indent)
+	pkg.newlines(2)
+}
@@ -65,8 +65,16 @@ func (d *Dirs) walk() {
 // bfsWalkRoot walks a single directory hierarchy in breadth-first lexical order.
 // Each Go source directory it finds is delivered on d.scan.
 func (d *Dirs) bfsWalkRoot(root string) {
-	root = path.Join(root, "src")
+	bfsWalk(path.Join(root, "src"), func(dir string) bool {
+		d.scan <- dir
+		return true
+	})
+}
 
+// bfsWalk walks the directory hierarchy at root in breadth-first lexical
+// order, calling fn for each Go source directory it finds until fn
+// returns false.
+func bfsWalk(root string, fn func(dir string) bool) {
 	// this is the queue of directories to examine in this pass.
 	this := []string{}
 	// next is the queue of directories to examine in the next pass.
@@ -105,11 +113,42 @@ func (d *Dirs) bfsWalkRoot(root string) {
 				// Remember this (fully qualified) directory for the next pass.
 				next = append(next, filepath.Join(dir, name))
 			}
-			if hasGoFiles {
-				// It's a candidate.
-				d.scan <- dir
+			if hasGoFiles && !fn(dir) {
+				// It was a candidate, and the one wanted.
+				return
 			}
 		}
 
 	}
 }
+
+// vendorDirs returns the vendor directories that the go tool searches
+// for an import in the package in dir, nearest first: those of dir and
+// of its parents, up to the src directory of the GOROOT or GOPATH tree
+// that holds dir.
+func vendorDirs(dir string) []string {
+	for _, root := range append([]string{build.Default.GOROOT}, splitGopath()...) {
+		src := filepath.Join(root, "src")
+		rel, err := filepath.Rel(src, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		// As in go/build, there are no vendor directories for the
+		// packages in testdata.
+		if strings.Contains("/"+filepath.ToSlash(rel)+"/", "/testdata/") {
+			return nil
+		}
+		var vendors []string
+		for d := dir; ; d = filepath.Dir(d) {
+			vendor := filepath.Join(d, "vendor")
+			if fi, err := os.Stat(vendor); err == nil && fi.IsDir() {
+				vendors = append(vendors, vendor)
+			}
+			if d == src {
+				break
+			}
+		}
+		return vendors
+	}
+	return nil
+}
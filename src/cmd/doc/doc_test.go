@@ -7,6 +7,14 @@ package main
 import (
 	"bytes"
 	"flag"
+	"go/build"
+	"internal/testenv"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -99,6 +107,63 @@ var tests = []test{
 			`Comment about internal function`,  // No comment for internal function.
 		},
 	},
+	// Package dump with -all.
+	{
+		"full package with all",
+		[]string{`-all`, p},
+		[]string{
+			`Package comment`,
+			`(?m)^CONSTANTS$`,
+			`(?m)^VARIABLES$`,
+			`(?m)^FUNCTIONS$`,
+			`(?m)^TYPES$`,
+			`const ExportedConstant = 1\n    Comment about exported constant.`,
+			`Comment about block of constants.`,
+			`Comment before ConstOne.`,
+			`var ExportedVariable = 1\n    Comment about exported variable.`,
+			`Comment about block of variables.`,
+			`func ExportedFunc\(a int\) bool\n    Comment about exported function.`,
+			`type ExportedType struct {`,
+			`Comment about exported type.`,
+			`func ExportedTypeConstructor\(\) \*ExportedType\n    Comment about constructor for exported type.`,
+			`func \(ExportedType\) ExportedMethod\(a int\) bool\n    Comment about exported method.`,
+			`Comment about exported interface.`,
+			`Comment about superseded method.`,
+			// Source order, in each section and among the files.
+			`(?s)TYPES.*type ExportedType struct.*Constants tied to ExportedType.*ExportedTypeConstructor.*ExportedMethod.*type ExportedInterface.*type DeprecatedType.*Superseded.*Current.*type PromotedInterface`,
+			`(?s)CONSTANTS.*ExportedConstant.*ConstOne.*ConstFive.*CaseMatch.*DeprecatedConstant.*WhereConstOne.*VARIABLES`,
+			`(?s)FUNCTIONS.*ExportedFunc.*DeprecatedFunc.*WhereFunc.*WhereGenerated.*TYPES`,
+		},
+		[]string{
+			`internalConstant`,
+			`Comment about internal variable`,
+			`func internalFunc`,
+			`unexportedMethod`,
+			`Comment about unexported type`,
+			`(?s)CONSTANTS.*ExportedTypedConstant.*VARIABLES`, // Typed constant only with its type.
+			`(?s)FUNCTIONS.*ExportedTypeConstructor.*TYPES`,   // Constructor only with its type.
+		},
+	},
+	// Package dump with -all -u.
+	{
+		"full package with all and u",
+		[]string{`-all`, `-u`, p},
+		[]string{
+			`const internalConstant = 2\n    Comment about internal constant.`,
+			`func internalFunc\(a int\) bool\n    Comment about internal function.`,
+			`func \(ExportedType\) unexportedMethod\(a int\) bool\n    Comment about unexported method.`,
+			`type unexportedType int\n    Comment about unexported type.`,
+			`unexportedField`,
+		},
+		nil,
+	},
+	// A symbol with -all is shown as usual.
+	{
+		"symbol with all",
+		[]string{`-all`, p, `ExportedFunc`},
+		[]string{`Comment about exported function.`},
+		[]string{`CONSTANTS`, `Comment about exported type.`},
+	},
 
 	// Single constant.
 	{
@@ -320,6 +385,344 @@ var tests = []test{
 		nil,
 	},
 
+	// Deprecated symbols are marked in the package summary.
+	{
+		"deprecation markers",
+		[]string{p},
+		[]string{
+			`DEPRECATED: func DeprecatedFunc\(\) bool`,
+			`DEPRECATED: type DeprecatedType int`,
+			`DEPRECATED: const DeprecatedConstant = 3`,
+			`\nfunc ExportedFunc\(a int\) bool`,
+		},
+		[]string{
+			`DEPRECATED: func ExportedFunc`,
+		},
+	},
+	// The deprecation notice comes first in a symbol's documentation.
+	{
+		"deprecated function",
+		[]string{p, `DeprecatedFunc`},
+		[]string{
+			`func DeprecatedFunc\(\) bool\n    Deprecated: Use ExportedFunc instead.\n\n    Comment about deprecated function.`,
+		},
+		nil,
+	},
+	// Deprecated methods are marked in the type's method list.
+	{
+		"deprecated method in type",
+		[]string{p, `DeprecatedType`},
+		[]string{
+			`Deprecated: DeprecatedType is no longer used.\n\n    Comment about deprecated type.`,
+			`DEPRECATED: func \(DeprecatedType\) Superseded\(\) bool`,
+			`\nfunc \(DeprecatedType\) Current\(\) bool`,
+		},
+		nil,
+	},
+	// List only the deprecated symbols.
+	{
+		"deprecated",
+		[]string{`-deprecated`, p},
+		[]string{
+			`func DeprecatedFunc\(\) bool\n    Deprecated: Use ExportedFunc instead.`,
+			`type DeprecatedType int\n    Deprecated: DeprecatedType is no longer used.`,
+			`func \(DeprecatedType\) Superseded\(\) bool\n    Deprecated: Use the method of ExportedType instead.`,
+			`const DeprecatedConstant = 3\n    Deprecated: Use ExportedConstant instead.`,
+		},
+		[]string{
+			`Package comment`,
+			`Comment about`,
+			`DEPRECATED`,
+			`ExportedFunc\(a int\)`,
+			`Current`,
+		},
+	},
+
+	// Source positions in the package listing.
+	{
+		"where package",
+		[]string{`-where`, p},
+		[]string{
+			`\nconst WhereConstOne = 1 \.\.\. +where\.go:20\n`,
+			`\nfunc ExportedFunc\(a int\) bool +pkg\.go:55\n`,
+			`\nfunc WhereFunc\(\) +where\.go:10\n`,
+			`\nfunc WhereGenerated\(\) +generated\.y:102\n`,
+			`\ntype WhereType int +where\.go:13\n`,
+		},
+		[]string{
+			`/where\.go`,      // Full paths are only for single symbols.
+			`where\.go:2[56]`, // Lines behind the //line directive.
+		},
+	},
+	// Source position of a function.
+	{
+		"where function",
+		[]string{`-where`, p, `WhereFunc`},
+		[]string{
+			`\S+/testdata/where\.go:10\nfunc WhereFunc\(\)\n    Comment about WhereFunc\.`,
+		},
+		nil,
+	},
+	// Source position of a function behind a //line directive.
+	{
+		"where generated function",
+		[]string{`-where`, p, `WhereGenerated`},
+		[]string{
+			`\S+/testdata/generated\.y:102\nfunc WhereGenerated\(\)`,
+		},
+		nil,
+	},
+	// Source position of a method.
+	{
+		"where method",
+		[]string{`-where`, p, `WhereType.WhereMethod`},
+		[]string{
+			`\S+/testdata/where\.go:16\nfunc \(WhereType\) WhereMethod\(\)`,
+		},
+		nil,
+	},
+
+	// Symbol declared only in export_test.go.
+	{
+		"test helper",
+		[]string{`-test`, p, `ExportedTestHelper`},
+		[]string{
+			`// From test file export_test\.go of package pkg\.\nfunc ExportedTestHelper\(\) int\n    Comment about ExportedTestHelper\.`,
+		},
+		nil,
+	},
+	// Method of a type declared only in export_test.go.
+	{
+		"test method",
+		[]string{`-test`, p, `TestOnlyType.TestOnlyMethod`},
+		[]string{
+			`// From test file export_test\.go of package pkg\.\nfunc \(TestOnlyType\) TestOnlyMethod\(\)`,
+		},
+		nil,
+	},
+	// Example in the external test package.
+	{
+		"test example",
+		[]string{`-test`, p, `ExampleExportedFunc`},
+		[]string{
+			`// From test file example_test\.go of package pkg_test\.\nfunc ExampleExportedFunc\(\)\n    Comment about ExampleExportedFunc\.`,
+		},
+		nil,
+	},
+	// The package's own declaration shadows the test package's.
+	{
+		"test shadowed",
+		[]string{`-test`, p, `ExportedVariable`},
+		[]string{
+			`^var ExportedVariable = 1\n    Comment about exported variable\.`,
+			`// Shadows ExportedVariable in test file example_test\.go of package pkg_test\.\n$`,
+		},
+		[]string{
+			`ExportedVariable = 3`,
+			`package's own shadows`,
+		},
+	},
+	// Test files are left out of the package listing.
+	{
+		"test package",
+		[]string{`-test`, p},
+		[]string{
+			`func ExportedFunc\(a int\) bool`,
+		},
+		[]string{
+			`ExportedTestHelper`,
+			`ExampleExportedFunc`,
+		},
+	},
+	// Source position of a type and its methods.
+	{
+		"where type",
+		[]string{`-where`, p, `WhereType`},
+		[]string{
+			`\S+/testdata/where\.go:13\ntype WhereType int`,
+			`\nfunc \(WhereType\) WhereMethod\(\) +where\.go:16\n`,
+		},
+		nil,
+	},
+	// Source position of a const block.
+	{
+		"where const block",
+		[]string{`-where`, p, `WhereConstTwo`},
+		[]string{
+			`\S+/testdata/where\.go:19\nconst \(\n\tWhereConstOne = 1\n\tWhereConstTwo = 2\n\)`,
+		},
+		nil,
+	},
+	// No source positions without -where.
+	{
+		"where off",
+		[]string{p, `WhereFunc`},
+		[]string{
+			`^func WhereFunc\(\)`,
+		},
+		[]string{
+			`where\.go`,
+		},
+	},
+
+	// Method declared in an interface.
+	{
+		"interface method",
+		[]string{p, `PromotedInterface.Own`},
+		[]string{
+			`^func \(PromotedInterface\) Own\(\) int\n`,
+			`Comment about PromotedInterface.Own`,
+		},
+		[]string{
+			`promoted from`,
+		},
+	},
+	// Method promoted from an interface embedded from another package.
+	{
+		"method from embedded interface",
+		[]string{p, `PromotedInterface.Write`},
+		[]string{
+			`^// Method promoted from io.Writer.\nfunc \(Writer\) Write\(p \[\]byte\) \(n int, err error\)`,
+		},
+		nil,
+	},
+	// Interface methods of a type defined as another interface type.
+	{
+		"method of defined interface",
+		[]string{p, `DefinedInterface.Read`},
+		[]string{
+			`^// Method promoted from io.Reader.\nfunc \(Reader\) Read\(p \[\]byte\) \(n int, err error\)`,
+		},
+		nil,
+	},
+	// Method promoted from a struct embedded from another package.
+	{
+		"method from embedded struct",
+		[]string{p, `PromotedStruct.WriteString`},
+		[]string{
+			`^// Method promoted from bytes.Buffer.\nfunc \(b \*Buffer\) WriteString\(s string\)`,
+		},
+		nil,
+	},
+	// Method promoted through two levels of embedding.
+	{
+		"method from two levels",
+		[]string{p, `PromotedStruct.Deep`},
+		[]string{
+			`^// Method promoted from pkg.Deeper.\nfunc \(Deeper\) Deep\(\)`,
+			`Comment about Deeper.Deep`,
+		},
+		nil,
+	},
+	// Promoted method matched without regard to case.
+	{
+		"promoted method any case",
+		[]string{p, `promotedstruct.deep`},
+		[]string{
+			`^// Method promoted from pkg.Deeper.\nfunc \(Deeper\) Deep\(\)`,
+		},
+		nil,
+	},
+	// Promoted methods whose names differ only in case, at different depths.
+	{
+		"promoted methods of two names",
+		[]string{p, `CaseStruct.deep`},
+		[]string{
+			`(?s)^// Method promoted from pkg.Shallow.\nfunc \(Shallow\) DEEP\(\).*// Method promoted from pkg.Deeper.\nfunc \(Deeper\) Deep\(\)`,
+		},
+		nil,
+	},
+	// Promoted method matched with case.
+	{
+		"promoted method with -c",
+		[]string{"-c", p, `CaseStruct.Deep`},
+		[]string{
+			`^// Method promoted from pkg.Deeper.\nfunc \(Deeper\) Deep\(\)`,
+		},
+		[]string{
+			`DEEP`,
+		},
+	},
+
+	// Source of a function, with its body.
+	{
+		"function source",
+		[]string{"-src", p, `SrcFunc`},
+		[]string{
+			`^// Comment about SrcFunc.\nfunc SrcFunc\(n int\) int {\n\t// Comment in the body of SrcFunc.\n\treturn n \+ 1\n}\n`,
+		},
+		nil,
+	},
+	// Source of a method.
+	{
+		"method source",
+		[]string{"-src", p, `SrcType.First`},
+		[]string{
+			`^// Comment about SrcType.First.\nfunc \(t SrcType\) First\(\) int {\n\treturn t.SrcField // Comment in the body of First.\n}\n`,
+		},
+		[]string{
+			`Second`,
+		},
+	},
+	// Source of a constant: the whole block, unexported constants too.
+	{
+		"constant source",
+		[]string{"-src", p, `ConstTwo`},
+		[]string{
+			`^// Comment about block of constants.\nconst \(\n`,
+			`// Comment before ConstOne.\n\tConstOne   = 1\n`,
+			`ConstTwo   = 2 // Comment on line with ConstTwo.`,
+			`constThree = 3 // Comment on line with constThree.`,
+		},
+		nil,
+	},
+	// Source of a type declared in a group, followed by its methods.
+	{
+		"type source",
+		[]string{"-src", p, `SrcType`},
+		[]string{
+			`^// Comment about SrcType.\ntype SrcType struct {\n`,
+			`SrcField int // Comment on line with SrcField.`,
+			`srcField int // Comment on line with srcField.`,
+			`\nfunc \(t SrcType\) First\(\) int\n`,
+			`\nfunc \(t \*SrcType\) Second\(\)\n`,
+		},
+		[]string{
+			`Comment about the block of source types`,
+			`SrcOther`,
+			`Has unexported fields`,
+			`return`,
+			`third`,
+		},
+	},
+	// Source of an unexported method, with -u.
+	{
+		"unexported method source",
+		[]string{"-src", "-u", p, `SrcType.third`},
+		[]string{
+			`^// Comment about SrcType.third.\nfunc \(SrcType\) third\(\) {}\n`,
+		},
+		nil,
+	},
+	// Source of a method promoted from an embedded type.
+	{
+		"promoted method source",
+		[]string{"-src", p, `PromotedStruct.Deep`},
+		[]string{
+			`^// Method promoted from pkg.Deeper.\n// Comment about Deeper.Deep.\nfunc \(Deeper\) Deep\(\) {}\n`,
+		},
+		nil,
+	},
+	// Source of a method declared in an interface.
+	{
+		"interface method source",
+		[]string{"-src", p, `PromotedInterface.Own`},
+		[]string{
+			`^// Comment about PromotedInterface.Own.\nfunc \(PromotedInterface\) Own\(\) int\n`,
+		},
+		nil,
+	},
+
 	// Case matching off.
 	{
 		"case matching off",
@@ -381,6 +784,137 @@ func TestDoc(t *testing.T) {
 	}
 }
 
+// Test -all with no arguments, for the package in the current directory.
+func TestAllCurrentDir(t *testing.T) {
+	maybeSkip(t)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var b bytes.Buffer
+	var flagSet flag.FlagSet
+	if err := do(&b, &flagSet, []string{"-all"}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"Package comment.",
+		"Comment about exported constant.",
+		"Comment about exported variable.",
+		"Comment about exported function.",
+		"Comment about exported method.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+// Test that packages are found in the vendor directories of the package
+// in the current directory, the nearest first, by full and partial path.
+func TestVendor(t *testing.T) {
+	maybeSkip(t)
+	dir, err := ioutil.TempDir("", "doc-vendor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The current directory is compared with GOPATH.
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	for pkg, which := range map[string]string{
+		"example.com/vendlib":                            "gopath",
+		"proj/vendor/example.com/vendlib":                "proj",
+		"proj/sub/vendor/example.com/vendlib":            "sub",
+		"proj/sub/vendor/example.com/other/deep/vendlib": "deep",
+		"proj/sub/cmd":                                   "",
+		"proj/cmd":                                       "",
+		"other":                                          "",
+		"proj/sub/testdata/vendor/example.com/vendlib": "testdata",
+		"proj/sub/testdata/cmd":                        "",
+	} {
+		src := "package main\n"
+		if which != "" {
+			src = "package vendlib\n\nconst Which = \"" + which + "\"\n"
+		}
+		pkgDir := filepath.Join(dir, "src", filepath.FromSlash(pkg))
+		if err := os.MkdirAll(pkgDir, 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(pkgDir, "x.go"), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(gopath string) { build.Default.GOPATH = gopath }(build.Default.GOPATH)
+	build.Default.GOPATH = dir
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, test := range []struct {
+		cwd, arg, which string
+	}{
+		{"proj/sub/cmd", "example.com/vendlib", "sub"},
+		{"proj/sub/cmd", "vendlib", "sub"},
+		{"proj/sub/cmd", "vendlib.Which", "sub"},
+		{"proj/sub/cmd", "deep/vendlib", "deep"},
+		{"proj/cmd", "example.com/vendlib", "proj"},
+		{"proj/cmd", "vendlib", "proj"},
+		{"other", "example.com/vendlib", "gopath"},
+		// As for the go tool, packages in testdata have no vendor directories.
+		{"proj/sub/testdata/cmd", "example.com/vendlib", "gopath"},
+	} {
+		if err := os.Chdir(filepath.Join(dir, "src", filepath.FromSlash(test.cwd))); err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		if err := do(&b, &flagSet, []string{test.arg}); err != nil {
+			t.Errorf("in %s: go doc %s: %v", test.cwd, test.arg, err)
+			continue
+		}
+		want := `const Which = "` + test.which + `"`
+		if out := b.String(); !strings.Contains(out, want) {
+			t.Errorf("in %s: go doc %s: output does not contain %s:\n%s", test.cwd, test.arg, want, out)
+		}
+	}
+}
+
+// Test the errors for promoted methods that are ambiguous, shadowed
+// by a field, or spelled in another case with -c.
+func TestPromotedMethodErrors(t *testing.T) {
+	maybeSkip(t)
+	for _, test := range []struct {
+		flags       []string
+		symbol, err string
+	}{
+		{nil, "AmbiguousStruct.Read", "ambiguous method AmbiguousStruct.Read: promoted from bytes.Reader and strings.Reader"},
+		{nil, "ambiguousstruct.read", "ambiguous method AmbiguousStruct.Read: promoted from bytes.Reader and strings.Reader"},
+		{nil, "ShadowingStruct.Len", "no method ShadowingStruct.Len in package"},
+		{[]string{"-c"}, "CaseStruct.deep", "no method CaseStruct.deep in package"},
+	} {
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		err := do(&b, &flagSet, append(test.flags, p, test.symbol))
+		if err == nil {
+			t.Errorf("%s: no error; output:\n%s", test.symbol, b.Bytes())
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %q does not contain %q", test.symbol, err, test.err)
+		}
+	}
+}
+
 // Test the code to try multiple packages. Our test case is
 //	go doc rand.Float64
 // This needs to find math/rand.Float64; however crypto/rand, which doesn't
@@ -438,6 +972,242 @@ func TestMultiplePackages(t *testing.T) {
 	}
 }
 
+// Test the errors of the -src flag.
+func TestSrcErrors(t *testing.T) {
+	maybeSkip(t)
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-src", p}, "-src requires a symbol"},
+		{[]string{"-src", "-signature", p, "SrcFunc"}, "-src and -signature are mutually exclusive"},
+		{[]string{"-src", p, "SrcType.third"}, "no method SrcType.third in package"},
+	} {
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		err := do(&b, &flagSet, test.args)
+		if err == nil {
+			t.Errorf("%s: no error; output:\n%s", test.args, b.Bytes())
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %q does not contain %q", test.args, err, test.err)
+		}
+	}
+}
+
+// Test that the -deprecated flag does not take a symbol.
+func TestDeprecatedSymbol(t *testing.T) {
+	maybeSkip(t)
+	var b bytes.Buffer
+	var flagSet flag.FlagSet
+	err := do(&b, &flagSet, []string{"-deprecated", p, "DeprecatedFunc"})
+	if err == nil || !strings.Contains(err.Error(), "-deprecated does not take a symbol") {
+		t.Errorf("got error %v; output:\n%s", err, b.Bytes())
+	}
+}
+
+// Test the exact output of the -signature flag.
+func TestSignature(t *testing.T) {
+	maybeSkip(t)
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{"ExportedFunc", "func ExportedFunc(a int) bool\n"},
+		{"ExportedType.ExportedMethod", "func (ExportedType) ExportedMethod(a int) bool\n"},
+		{"ExportedType", "type ExportedType struct {...}\n"},
+		{"ExportedInterface", "type ExportedInterface interface {...}\n"},
+		{"DeprecatedType", "type DeprecatedType int\n"},
+		{"ConstTwo", "const ConstTwo = 2\n"},
+		{"PromotedStruct.Deep", "func (Deeper) Deep()\n"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		err := do(&b, &flagSet, []string{"-signature", p, test.symbol})
+		if err != nil {
+			t.Errorf("%s: %s", test.symbol, err)
+			continue
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.symbol, got, test.want)
+		}
+	}
+}
+
+// Test that doc -signature exits with a non-zero status for a missing
+// symbol. The test binary runs main in a subprocess.
+func TestSignatureMissing(t *testing.T) {
+	maybeSkip(t)
+	if os.Getenv("GO_DOC_TEST_MAIN") == "1" {
+		os.Args = []string{"doc", "-signature", p, "NoSuchSymbol"}
+		main()
+		os.Exit(0)
+	}
+	testenv.MustHaveExec(t)
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSignatureMissing$")
+	cmd.Env = append(os.Environ(), "GO_DOC_TEST_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("doc -signature of a missing symbol succeeded:\n%s", out)
+	}
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("running doc: %v", err)
+	}
+	if !strings.Contains(string(out), "no symbol NoSuchSymbol") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+// Test that test files are only searched with the -test flag.
+func TestNoTestFiles(t *testing.T) {
+	maybeSkip(t)
+	var b bytes.Buffer
+	var flagSet flag.FlagSet
+	err := do(&b, &flagSet, []string{p, "ExportedTestHelper"})
+	if err == nil {
+		t.Fatalf("expected error for ExportedTestHelper without -test; got:\n%s", b.Bytes())
+	}
+	if !strings.Contains(err.Error(), "no symbol ExportedTestHelper") {
+		t.Errorf("unexpected error %q for ExportedTestHelper without -test", err)
+	}
+}
+
+const (
+	oldAPI   = "testdata/compare/old"
+	newAPI   = "testdata/compare/new"
+	addedAPI = "testdata/compare/added"
+)
+
+const compareOutput = `Removed:
+    const RemovedConst = 2
+    func RemovedFunc()
+    func (s *Struct) RemovedMethod()
+
+Added:
+    const AddedKind Kind = iota
+    func AddedFunc()
+    func (s *Struct) AddedMethod()
+
+Changed:
+    - var ChangedVar int32
+    + var ChangedVar int64
+    - func ChangedFunc(a int) bool
+    + func ChangedFunc(a int, b string) bool
+    - type ChangedStruct struct{ A int }
+    + type ChangedStruct struct {
+      	A int
+      	B int
+      }
+    - func (s Struct) ChangedMethod(r io.Reader)
+    + func (s Struct) ChangedMethod(w io.Writer)
+
+`
+
+// Test the exact output of the -compare flag. Changes to comments,
+// layout, unexported fields and methods and the order of an interface's
+// methods are not reported, nor are doc comment changes without the
+// -comments flag.
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+		err  string
+	}{
+		{[]string{oldAPI, newAPI}, compareOutput, "incompatible API changes: 3 removed, 4 changed"},
+		{[]string{"-comments", oldAPI, newAPI}, compareOutput + "Doc comment changed:\n    func Func(a int) bool\n\n", "incompatible API changes: 3 removed, 4 changed"},
+		{[]string{oldAPI, oldAPI}, "", ""},
+		// Additions alone are compatible.
+		{[]string{oldAPI, addedAPI}, "Added:\n    func AddedFunc()\n\n", ""},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		err := do(&b, &flagSet, append([]string{"-compare"}, test.args...))
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: error %v, want %q", test.args, err, test.err)
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.args, got, test.want)
+		}
+	}
+}
+
+// Test that doc -compare exits with a non-zero status only for
+// incompatible changes. The test binary runs main in a subprocess.
+func TestCompareStatus(t *testing.T) {
+	if args := os.Getenv("GO_DOC_TEST_COMPARE"); args != "" {
+		os.Args = append([]string{"doc", "-compare"}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+	testenv.MustHaveExec(t)
+	for _, test := range []struct {
+		old, new string
+		ok       bool
+	}{
+		{oldAPI, newAPI, false},
+		{oldAPI, addedAPI, true},
+	} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCompareStatus$")
+		cmd.Env = append(os.Environ(), "GO_DOC_TEST_COMPARE="+test.old+" "+test.new)
+		out, err := cmd.CombinedOutput()
+		if _, ok := err.(*exec.ExitError); err != nil && !ok {
+			t.Fatalf("running doc: %v", err)
+		}
+		if ok := err == nil; ok != test.ok {
+			t.Errorf("doc -compare %s %s: success = %v, want %v\n%s", test.old, test.new, ok, test.ok, out)
+		}
+	}
+}
+
+var deprecationTests = []struct {
+	comment string
+	notice  string
+	rest    string
+}{
+	// Notice is the first paragraph.
+	{
+		"Deprecated: Use Bar.\n\nFoo does things.\n",
+		"Deprecated: Use Bar.\n",
+		"Foo does things.\n",
+	},
+	// Notice is a middle paragraph.
+	{
+		"Foo does things.\n\nDeprecated: Use Bar\nor Baz.\n\nMore about Foo.\n",
+		"Deprecated: Use Bar\nor Baz.\n",
+		"Foo does things.\n\nMore about Foo.\n",
+	},
+	// Notice is the last paragraph.
+	{
+		"Foo does things.\n\nDeprecated: Use Bar.\n",
+		"Deprecated: Use Bar.\n",
+		"Foo does things.\n",
+	},
+	// No notice.
+	{
+		"Foo does things.\nIt is not Deprecated: really.\n",
+		"",
+		"Foo does things.\nIt is not Deprecated: really.\n",
+	},
+	// No comment.
+	{
+		"",
+		"",
+		"",
+	},
+}
+
+func TestDeprecation(t *testing.T) {
+	for _, test := range deprecationTests {
+		notice, rest := deprecation(test.comment)
+		if notice != test.notice || rest != test.rest {
+			t.Errorf("deprecation(%q) = %q, %q; want %q, %q", test.comment, notice, rest, test.notice, test.rest)
+		}
+	}
+}
+
 type trimTest struct {
 	path   string
 	prefix string
@@ -466,3 +1236,148 @@ func TestTrim(t *testing.T) {
 		}
 	}
 }
+
+// Test that the -http server shows the same text as the command line.
+func TestServer(t *testing.T) {
+	maybeSkip(t)
+	handler := newServer()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	pages := []struct {
+		path string
+		args []string
+	}{
+		{"/pkg/" + p, []string{p}},
+		{"/pkg/" + p + "/ExportedType", []string{p, "ExportedType"}},
+		{"/pkg/" + p + "/ExportedType.ExportedMethod", []string{p, "ExportedType.ExportedMethod"}},
+		{"/pkg/json/Decoder/", []string{"json.Decoder"}},
+	}
+	for _, page := range pages {
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		if err := do(&b, &flagSet, page.args); err != nil {
+			t.Fatalf("%s: %s", page.args, err)
+		}
+		w := get(page.path)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d: %s", page.path, w.Code, w.Body)
+			continue
+		}
+		if ct := w.HeaderMap.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("%s: Content-Type %q, want text/plain", page.path, ct)
+		}
+		if w.Body.String() != b.String() {
+			t.Errorf("%s: got\n%s\nwant output of go doc %s:\n%s", page.path, w.Body, strings.Join(page.args, " "), &b)
+		}
+	}
+
+	// Errors are reported and do not stop the server.
+	for _, path := range []string{
+		"/pkg/" + p + "/NoSuchSymbol",
+		"/pkg/no/such/package",
+		"/pkg/" + p + "/A.B.C",
+	} {
+		if w := get(path); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+
+	w := get("/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("/: status %d: %s", w.Code, w.Body)
+	}
+	if link := `<a href="/pkg/` + p + `">`; !strings.Contains(w.Body.String(), link) {
+		t.Errorf("index does not link %s", p)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	k := func(s string) string { return colorKeyword + s + colorReset }
+	e := func(s string) string { return colorExported + s + colorReset }
+	s := func(s string) string { return colorString + s + colorReset }
+	tests := []struct {
+		text, want string
+	}{
+		{
+			"const Msg = \"type T struct\" + `func`\n",
+			k("const") + " " + e("Msg") + " = " + s(`"type T struct"`) + " + " + s("`func`") + "\n",
+		},
+		{
+			"var r, R = '\"', \"\\\"var\\\"\"\n",
+			k("var") + " r, " + e("R") + " = " + s(`'"'`) + ", " + s(`"\"var\""`) + "\n",
+		},
+		{
+			"func (t *T) m(x interface{}) chan<- int\n",
+			k("func") + " (t *" + e("T") + ") m(x " + k("interface") + "{}) " + k("chan") + "<- int\n",
+		},
+		{
+			"type T int // A T is a type, not a func.\n",
+			k("type") + " " + e("T") + " int // A T is a type, not a func.\n",
+		},
+		{
+			"DEPRECATED: func Old()\n",
+			colorDeprecated + "DEPRECATED:" + colorReset + " " + k("func") + " " + e("Old") + "()\n",
+		},
+	}
+	for _, test := range tests {
+		if got := string(colorize([]byte(test.text), nil)); got != test.want {
+			t.Errorf("colorize(%q) =\n%q\nwant\n%q", test.text, got, test.want)
+		}
+	}
+
+	// In comments, only headings and deprecation notices are colored.
+	comment := "Intro for \"func\".\n\nThe Heading\n\nDeprecated: Use New.\n"
+	text := "func F()\n    Intro for \"func\".\n\n    The Heading\n\n    Deprecated: Use New.\n"
+	want := k("func") + " " + e("F") + "()\n    Intro for \"func\".\n\n    " +
+		colorHeading + "The Heading" + colorReset + "\n\n    " +
+		colorDeprecated + "Deprecated:" + colorReset + " Use New.\n"
+	comments := []commentText{{len("func F()\n"), len(text), headings(comment)}}
+	if got := string(colorize([]byte(text), comments)); got != want {
+		t.Errorf("colorize(%q) =\n%q\nwant\n%q", text, got, want)
+	}
+}
+
+func TestColorFlag(t *testing.T) {
+	maybeSkip(t)
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	for _, test := range []struct {
+		flag    string
+		noColor string
+		color   bool
+	}{
+		{"auto", "", false}, // Not a terminal.
+		{"never", "", false},
+		{"always", "", true},
+		{"always", "1", true},
+	} {
+		os.Setenv("NO_COLOR", test.noColor)
+		var b bytes.Buffer
+		var flagSet flag.FlagSet
+		if err := do(&b, &flagSet, []string{"-color=" + test.flag, p, "ExportedFunc"}); err != nil {
+			t.Errorf("-color=%s: %s", test.flag, err)
+			continue
+		}
+		if got := strings.Contains(b.String(), "\x1b["); got != test.color {
+			t.Errorf("-color=%s with NO_COLOR=%q: colored %t, want %t:\n%q", test.flag, test.noColor, got, test.color, b.String())
+		}
+	}
+
+	var flagSet flag.FlagSet
+	if err := do(new(bytes.Buffer), &flagSet, []string{"-color=sometimes", p}); err == nil {
+		t.Errorf("-color=sometimes succeeded")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isTerminal(w) {
+		t.Errorf("isTerminal reports a pipe as a terminal")
+	}
+}
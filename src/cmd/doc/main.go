@@ -18,6 +18,11 @@
 // directory is chosen. However, if the argument begins with a capital
 // letter it is always assumed to be a symbol in the current directory.
 //
+// Packages in the vendor directories of the package in the current
+// directory come first, as they do for an import in that package, and
+// those of the nearest vendor directory before the others, whether the
+// package is given by its full path or by a partial one.
+//
 // Two arguments:
 //	go doc <pkg> <sym>[.<method>]
 //
@@ -25,9 +30,60 @@
 // first argument must be a full package path. This is similar to the
 // command-line usage for the godoc command.
 //
+// A method may also be one that the type acquires from an embedded
+// field or an embedded interface, possibly declared in another
+// package. Its documentation is preceded by a line naming the type it
+// is promoted from. As in the language, a method or field at a
+// shallower depth of embedding hides those of the same name below it,
+// and two of the same name at the same depth are reported as
+// ambiguous, listing the types they come from.
+//
 // For commands, unless the -cmd flag is present "go doc command"
 // shows only the package-level docs for the package.
 //
+// With the -all flag and no symbol, doc prints the full documentation
+// of the package: the package doc followed by the constants, variables,
+// functions and types, each with its doc comment, and each type with
+// its constants, variables, constructors and methods. Within each
+// section the declarations are in source order.
+//
+// With the -test flag, symbols are also looked up in the package's test
+// files and in its external test package.
+//
+// With the -signature flag, doc prints only the declaration of the symbol
+// or method, for use in scripts and editor status lines: no package
+// clause, no comments, and for a type a single line with its fields or
+// methods elided. Doc exits with a non-zero status if there is no such
+// symbol.
+//
+// With the -src flag, doc prints the source of the symbol or method:
+// its declaration as it is in the file, with its doc comment, the
+// comments inside it and, for a function or method, its body. A type is
+// followed by the usual summary of its constants, constructors and
+// methods, and a constant or variable by the whole declaration block it
+// is in. The -u flag still decides whether unexported symbols and
+// methods can be found, but the source shown is not trimmed. The -src
+// flag requires a symbol.
+//
+// With the -compare flag, doc takes two directories holding two versions
+// of a package and lists the exported declarations removed, added and
+// changed in the second, a method counting as a declaration of its own.
+// A declaration has changed if its text, as formatted by gofmt without
+// comments, differs; changes to doc comments alone are listed as well
+// with the -comments flag. Doc exits with a non-zero status if any
+// declaration was removed or changed, so that a script can check that
+// a new version of a package is compatible with the old one.
+//
+// The -color flag controls whether the output is colored: always, never
+// or auto, the default, which colors it if standard output is a terminal
+// and the NO_COLOR environment variable is empty. The colors are ANSI
+// escape sequences, so auto never colors on Windows or Plan 9. Keywords,
+// exported identifiers and literals in declarations are colored, and in
+// comments only headings and deprecation notices.
+//
+// With the -http flag, doc takes no arguments and instead serves the
+// same output over HTTP; see server.go.
+//
 // For complete documentation, run "go help doc".
 package main
 
@@ -46,9 +102,20 @@ import (
 )
 
 var (
-	unexported bool // -u flag
-	matchCase  bool // -c flag
-	showCmd    bool // -cmd flag
+	unexported bool   // -u flag
+	showAll    bool   // -all flag
+	matchCase  bool   // -c flag
+	showCmd    bool   // -cmd flag
+	deprecated bool   // -deprecated flag
+	where      bool   // -where flag
+	testFiles  bool   // -test flag
+	signature  bool   // -signature flag
+	showSrc    bool   // -src flag
+	compareAPI bool   // -compare flag
+	comments   bool   // -comments flag
+	httpAddr   string // -http flag
+	colorFlag  string // -color flag
+	color      bool   // whether to color the output
 )
 
 // usage is a replacement usage function for the flags package.
@@ -59,6 +126,8 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "\tgo doc <sym>[.<method>]\n")
 	fmt.Fprintf(os.Stderr, "\tgo doc [<pkg>].<sym>[.<method>]\n")
 	fmt.Fprintf(os.Stderr, "\tgo doc <pkg> <sym>[.<method>]\n")
+	fmt.Fprintf(os.Stderr, "\tgo doc -compare <dir1> <dir2>\n")
+	fmt.Fprintf(os.Stderr, "\tgo doc -http=<addr>\n")
 	fmt.Fprintf(os.Stderr, "For more information run\n")
 	fmt.Fprintf(os.Stderr, "\tgo help doc\n\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
@@ -79,17 +148,63 @@ func main() {
 func do(writer io.Writer, flagSet *flag.FlagSet, args []string) (err error) {
 	flagSet.Usage = usage
 	unexported = false
+	showAll = false
 	matchCase = false
+	where = false
+	testFiles = false
+	signature = false
+	showSrc = false
+	compareAPI = false
+	comments = false
+	httpAddr = ""
+	colorFlag = "auto"
+	color = false
 	flagSet.BoolVar(&unexported, "u", false, "show unexported symbols as well as exported")
+	flagSet.BoolVar(&showAll, "all", false, "show the full documentation of the package, with all its symbols")
 	flagSet.BoolVar(&matchCase, "c", false, "symbol matching honors case (paths not affected)")
 	flagSet.BoolVar(&showCmd, "cmd", false, "show symbols with package docs even if package is a command")
+	flagSet.BoolVar(&deprecated, "deprecated", false, "list only the deprecated symbols of the package")
+	flagSet.BoolVar(&where, "where", false, "show the source position of each symbol")
+	flagSet.BoolVar(&testFiles, "test", false, "also look up symbols in the package's test files")
+	flagSet.BoolVar(&signature, "signature", false, "print only the declaration of the symbol")
+	flagSet.BoolVar(&showSrc, "src", false, "show the source of the symbol")
+	flagSet.BoolVar(&compareAPI, "compare", false, "list the changes to the exported API between the packages in two directories")
+	flagSet.BoolVar(&comments, "comments", false, "with -compare, also list declarations whose doc comment changed")
+	flagSet.StringVar(&httpAddr, "http", "", "serve documentation over HTTP on `addr` instead of printing it")
+	flagSet.StringVar(&colorFlag, "color", "auto", "color the output: `when` is always, never or auto (if a terminal)")
 	flagSet.Parse(args)
+	if httpAddr != "" {
+		if flagSet.NArg() > 0 {
+			usage()
+		}
+		return serve(httpAddr)
+	}
+	if showSrc && signature {
+		return fmt.Errorf("-src and -signature are mutually exclusive")
+	}
+	if color, err = useColor(writer); err != nil {
+		return err
+	}
+	if compareAPI {
+		if flagSet.NArg() != 2 {
+			usage()
+		}
+		return compare(writer, flagSet.Arg(0), flagSet.Arg(1))
+	}
+	return document(writer, flagSet.Args())
+}
+
+// document writes the documentation requested by args, the arguments
+// left after the flags, to writer.
+func document(writer io.Writer, args []string) (err error) {
+	defer catchPackageError(&err)
+
 	var paths []string
 	var symbol, method string
 	// Loop until something is printed.
 	dirs.Reset()
 	for i := 0; ; i++ {
-		buildPackage, userPath, sym, more := parseArgs(flagSet.Args())
+		buildPackage, userPath, sym, more := parseArgs(args)
 		if i > 0 && !more { // Ignore the "more" bit on the first iteration.
 			return failMessage(paths, symbol, method)
 		}
@@ -97,19 +212,7 @@ func do(writer io.Writer, flagSet *flag.FlagSet, args []string) (err error) {
 		pkg := parsePackage(writer, buildPackage, userPath)
 		paths = append(paths, pkg.prettyPath())
 
-		defer func() {
-			pkg.flush()
-			e := recover()
-			if e == nil {
-				return
-			}
-			pkgError, ok := e.(PackageError)
-			if ok {
-				err = pkgError
-				return
-			}
-			panic(e)
-		}()
+		defer pkg.flush()
 
 		// The builtin package needs special treatment: its symbols are lower
 		// case but we want to see them, always.
@@ -118,14 +221,34 @@ func do(writer io.Writer, flagSet *flag.FlagSet, args []string) (err error) {
 		}
 
 		switch {
+		case symbol == "" && signature:
+			return fmt.Errorf("-signature requires a symbol")
+		case symbol == "" && showSrc:
+			return fmt.Errorf("-src requires a symbol")
+		case symbol != "" && deprecated:
+			return fmt.Errorf("-deprecated does not take a symbol")
+		case symbol == "" && deprecated:
+			pkg.deprecatedDoc()
+			return
+		case symbol == "" && showAll:
+			pkg.allDoc()
+			return
 		case symbol == "":
 			pkg.packageDoc() // The package exists, so we got some output.
 			return
 		case method == "":
 			if pkg.symbolDoc(symbol) {
+				pkg.shadowedTests(symbol)
+				return
+			}
+			if pkg.testSymbolDoc(symbol, "") {
 				return
 			}
 		default:
+			// The type may be declared in a test file only.
+			if pkg.findTypes(symbol) == nil && pkg.testSymbolDoc(symbol, method) {
+				return
+			}
 			if pkg.methodDoc(symbol, method) {
 				return
 			}
@@ -174,9 +297,9 @@ func parseArgs(args []string) (pkg *build.Package, path, symbol string, more boo
 		// Done below.
 	case 2:
 		// Package must be importable.
-		pkg, err := build.Import(args[0], "", build.ImportComment)
+		pkg, err := importPackage(args[0])
 		if err != nil {
-			log.Fatalf("%s", err)
+			fatalf("%s", err)
 		}
 		return pkg, args[0], args[1], false
 	}
@@ -186,7 +309,7 @@ func parseArgs(args []string) (pkg *build.Package, path, symbol string, more boo
 	// First, is it a complete package path as it is? If so, we are done.
 	// This avoids confusion over package paths that have other
 	// package paths as their prefix.
-	pkg, err := build.Import(arg, "", build.ImportComment)
+	pkg, err := importPackage(arg)
 	if err == nil {
 		return pkg, arg, "", false
 	}
@@ -221,10 +344,15 @@ func parseArgs(args []string) (pkg *build.Package, path, symbol string, more boo
 			symbol = arg[period+1:]
 		}
 		// Have we identified a package already?
-		pkg, err := build.Import(arg[0:period], "", build.ImportComment)
+		pkg, err := importPackage(arg[0:period])
 		if err == nil {
 			return pkg, arg[0:period], symbol, false
 		}
+		// The vendor directories of the current package come first,
+		// as they do for a complete package path.
+		if path, ok := findVendorPackage(arg[0:period]); ok {
+			return importDir(path), arg[0:period], symbol, false
+		}
 		// See if we have the basename or tail of a package, as in json for encoding/json
 		// or ivy/value for robpike.io/ivy/value.
 		// Launch findPackage as a goroutine so it can return multiple paths if required.
@@ -236,17 +364,30 @@ func parseArgs(args []string) (pkg *build.Package, path, symbol string, more boo
 	}
 	// If it has a slash, we've failed.
 	if slash >= 0 {
-		log.Fatalf("no such package %s", arg[0:period])
+		fatalf("no such package %s", arg[0:period])
 	}
 	// Guess it's a symbol in the current directory.
 	return importDir(pwd()), "", arg, false
 }
 
+// importPackage imports the package with the given path as an import in
+// the package in the current directory would, so that a package in one
+// of its vendor directories comes before one in GOROOT or GOPATH, and
+// the nearest vendor directory first. A relative path is not a package
+// path, though, and is not imported relative to the current directory.
+func importPackage(path string) (*build.Package, error) {
+	srcDir := pwd()
+	if build.IsLocalImport(path) {
+		srcDir = ""
+	}
+	return build.Import(path, srcDir, build.ImportComment)
+}
+
 // importDir is just an error-catching wrapper for build.ImportDir.
 func importDir(dir string) *build.Package {
 	pkg, err := build.ImportDir(dir, build.ImportComment)
 	if err != nil {
-		log.Fatal(err)
+		fatalf("%s", err)
 	}
 	return pkg
 }
@@ -265,8 +406,7 @@ func parseSymbol(str string) (symbol, method string) {
 		method = elem[1]
 		isIdentifier(method)
 	default:
-		log.Printf("too many periods in symbol specification")
-		usage()
+		fatalf("too many periods in symbol specification")
 	}
 	symbol = elem[0]
 	isIdentifier(symbol)
@@ -274,19 +414,27 @@ func parseSymbol(str string) (symbol, method string) {
 }
 
 // isIdentifier checks that the name is valid Go identifier, and
-// logs and exits if it is not.
+// reports a fatal error if it is not.
 func isIdentifier(name string) {
 	if len(name) == 0 {
-		log.Fatal("empty symbol")
+		fatalf("empty symbol")
 	}
 	for i, ch := range name {
 		if unicode.IsLetter(ch) || ch == '_' || i > 0 && unicode.IsDigit(ch) {
 			continue
 		}
-		log.Fatalf("invalid identifier %q", name)
+		fatalf("invalid identifier %q", name)
 	}
 }
 
+// fatalf is like log.Fatalf, but panics with a PackageError so that
+// document recovers it and returns it as its error. For the command line the
+// outcome is the same, but a server started by -http keeps running
+// after a request for a missing package or symbol.
+func fatalf(format string, args ...interface{}) {
+	panic(PackageError(fmt.Sprintf(format, args...)))
+}
+
 // isExported reports whether the name is an exported identifier.
 // If the unexported flag (-u) is true, isExported returns true because
 // it means that we treat the name as if it is exported.
@@ -300,6 +448,31 @@ func isUpper(name string) bool {
 	return unicode.IsUpper(ch)
 }
 
+// findVendorPackage returns the full file name path of the package in the
+// vendor directories of the current directory that first matches the
+// (perhaps partial) package path pkg, searching the nearest vendor
+// directory first. The boolean reports if any match was found.
+func findVendorPackage(pkg string) (string, bool) {
+	if pkg == "" || isUpper(pkg) { // Upper case symbol cannot be a package name.
+		return "", false
+	}
+	pkgString := filepath.Clean(string(filepath.Separator) + pkg)
+	for _, vendor := range vendorDirs(pwd()) {
+		path := ""
+		bfsWalk(vendor, func(dir string) bool {
+			if strings.HasSuffix(dir, pkgString) {
+				path = dir
+				return false
+			}
+			return true
+		})
+		if path != "" {
+			return path, true
+		}
+	}
+	return "", false
+}
+
 // findPackage returns the full file name path that first matches the
 // (perhaps partial) package path pkg. The boolean reports if any match was found.
 func findPackage(pkg string) (string, bool) {
@@ -12,12 +12,16 @@ import (
 	"go/doc"
 	"go/format"
 	"go/parser"
+	"go/printer"
 	"go/token"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"unicode"
 	"unicode/utf8"
 )
@@ -38,6 +42,9 @@ type Package struct {
 	build    *build.Package
 	fs       *token.FileSet // Needed for printing.
 	buf      bytes.Buffer
+	tests    []*Package    // Test files of the package and its external test package (-test flag).
+	testFile bool          // Package parsed from test files.
+	comments []commentText // Comment text in buf, for colorize.
 }
 
 type PackageError string // type returned by pkg.Fatalf.
@@ -92,27 +99,45 @@ func trim(path, prefix string) (string, bool) {
 }
 
 // pkg.Fatalf is like log.Fatalf, but panics so it can be recovered in the
-// main do function, so it doesn't cause an exit. Allows testing to work
+// document function, so it doesn't cause an exit. Allows testing to work
 // without running a subprocess. The log prefix will be added when
 // logged in main; it is not added here.
 func (pkg *Package) Fatalf(format string, args ...interface{}) {
-	panic(PackageError(fmt.Sprintf(format, args...)))
+	fatalf(format, args...)
 }
 
 // parsePackage turns the build package we found into a parsed package
-// we can then use to generate documentation.
+// we can then use to generate documentation. With the -test flag, the
+// test files in the package and those of the external test package are
+// parsed as well, each into a Package of their own in the tests field.
 func parsePackage(writer io.Writer, pkg *build.Package, userPath string) *Package {
+	// The files must be in the build package's GoFiles or CgoFiles
+	// list only (no tag-ignored files, tests, swig or other non-Go files).
+	names := append(append([]string(nil), pkg.GoFiles...), pkg.CgoFiles...)
+	p := parseFiles(writer, pkg, userPath, pkg.Name, names)
+	if !testFiles {
+		return p
+	}
+	if len(pkg.TestGoFiles) > 0 {
+		t := parseFiles(writer, pkg, userPath, pkg.Name, pkg.TestGoFiles)
+		t.testFile = true
+		p.tests = append(p.tests, t)
+	}
+	if len(pkg.XTestGoFiles) > 0 {
+		t := parseFiles(writer, pkg, userPath, pkg.Name+"_test", pkg.XTestGoFiles)
+		t.testFile = true
+		p.tests = append(p.tests, t)
+	}
+	return p
+}
+
+// parseFiles parses the named files of the build package, which must all
+// belong to the package called name.
+func parseFiles(writer io.Writer, pkg *build.Package, userPath, name string, names []string) *Package {
 	fs := token.NewFileSet()
 	// include tells parser.ParseDir which files to include.
-	// That means the file must be in the build package's GoFiles or CgoFiles
-	// list only (no tag-ignored files, tests, swig or other non-Go files).
 	include := func(info os.FileInfo) bool {
-		for _, name := range pkg.GoFiles {
-			if name == info.Name() {
-				return true
-			}
-		}
-		for _, name := range pkg.CgoFiles {
+		for _, name := range names {
 			if name == info.Name() {
 				return true
 			}
@@ -121,13 +146,13 @@ func parsePackage(writer io.Writer, pkg *build.Package, userPath string) *Packag
 	}
 	pkgs, err := parser.ParseDir(fs, pkg.Dir, include, parser.ParseComments)
 	if err != nil {
-		log.Fatal(err)
+		fatalf("%s", err)
 	}
 	// Make sure they are all in one package.
 	if len(pkgs) != 1 {
-		log.Fatalf("multiple packages in directory %s", pkg.Dir)
+		fatalf("multiple packages in directory %s", pkg.Dir)
 	}
-	astPkg := pkgs[pkg.Name]
+	astPkg := pkgs[name]
 
 	// TODO: go/doc does not include typed constants in the constants
 	// list, which is what we want. For instance, time.Sunday is of type
@@ -137,7 +162,11 @@ func parsePackage(writer io.Writer, pkg *build.Package, userPath string) *Packag
 	// from finding the symbol. Work around this for now, but we
 	// should fix it in go/doc.
 	// A similar story applies to factory functions.
-	docPkg := doc.New(astPkg, pkg.ImportPath, doc.AllDecls)
+	mode := doc.AllDecls
+	if showSrc {
+		mode |= doc.PreserveAST // Keep the function bodies and comments.
+	}
+	docPkg := doc.New(astPkg, pkg.ImportPath, mode)
 	for _, typ := range docPkg.Types {
 		docPkg.Consts = append(docPkg.Consts, typ.Consts...)
 		docPkg.Vars = append(docPkg.Vars, typ.Vars...)
@@ -146,7 +175,7 @@ func parsePackage(writer io.Writer, pkg *build.Package, userPath string) *Packag
 
 	return &Package{
 		writer:   writer,
-		name:     pkg.Name,
+		name:     name,
 		userPath: userPath,
 		pkg:      astPkg,
 		file:     ast.MergePackageFiles(astPkg, 0),
@@ -161,11 +190,28 @@ func (pkg *Package) Printf(format string, args ...interface{}) {
 }
 
 func (pkg *Package) flush() {
-	_, err := pkg.writer.Write(pkg.buf.Bytes())
+	text := pkg.buf.Bytes()
+	if color {
+		text = colorize(text, pkg.comments)
+	}
+	_, err := pkg.writer.Write(text)
 	if err != nil {
 		log.Fatal(err)
 	}
 	pkg.buf.Reset() // Not needed, but it's a flush.
+	pkg.comments = nil
+}
+
+// toText prints the comment as doc.ToText formats it, recording where
+// it is for colorize.
+func (pkg *Package) toText(comment, indent, preIndent string) {
+	c := commentText{start: pkg.buf.Len()}
+	doc.ToText(&pkg.buf, comment, indent, preIndent, indentedWidth)
+	c.end = pkg.buf.Len()
+	if color {
+		c.headings = headings(comment)
+	}
+	pkg.comments = append(pkg.comments, c)
 }
 
 var newlineBytes = []byte("\n\n") // We never ask for more than 2.
@@ -177,8 +223,11 @@ func (pkg *Package) newlines(n int) {
 	}
 }
 
-// emit prints the node.
+// emit prints the node. With the -signature flag, it omits the comment.
 func (pkg *Package) emit(comment string, node ast.Node) {
+	if signature {
+		comment = ""
+	}
 	if node != nil {
 		err := format.Node(&pkg.buf, pkg.fs, node)
 		if err != nil {
@@ -186,7 +235,7 @@ func (pkg *Package) emit(comment string, node ast.Node) {
 		}
 		if comment != "" {
 			pkg.newlines(1)
-			doc.ToText(&pkg.buf, comment, "    ", indent, indentedWidth)
+			pkg.toText(deprecatedFirst(comment), "    ", indent)
 			pkg.newlines(2) // Blank line after comment to separate from next item.
 		} else {
 			pkg.newlines(1)
@@ -194,6 +243,64 @@ func (pkg *Package) emit(comment string, node ast.Node) {
 	}
 }
 
+// emitDecl prints the declaration the user asked for, as emit does, or
+// with the -src flag as it is in the source, with its comments.
+func (pkg *Package) emitDecl(comment string, node ast.Node) {
+	if !showSrc {
+		pkg.emit(comment, node)
+		return
+	}
+	// The comments inside the declaration are only in its file's list.
+	file := pkg.pkg.Files[pkg.fs.File(node.Pos()).Name()]
+	err := format.Node(&pkg.buf, pkg.fs, &printer.CommentedNode{Node: node, Comments: file.Comments})
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg.newlines(2)
+}
+
+// summaryPos appends the base name and line of the declaration at pos
+// to the one-line summary just printed, if the -where flag is set. The
+// positions form a second column that alignSummaries lines up.
+func (pkg *Package) summaryPos(pos token.Pos) {
+	if !where {
+		return
+	}
+	posn := pkg.fs.Position(pos)
+	pkg.buf.Truncate(pkg.buf.Len() - 1) // Drop the newline.
+	pkg.Printf("\t%s:%d\n", filepath.Base(posn.Filename), posn.Line)
+}
+
+// alignSummaries aligns the position column of the summaries printed
+// since the buffer held start bytes, if the -where flag is set.
+func (pkg *Package) alignSummaries(start int) {
+	if !where {
+		return
+	}
+	text := append([]byte(nil), pkg.buf.Bytes()[start:]...)
+	pkg.buf.Truncate(start)
+	tw := tabwriter.NewWriter(&pkg.buf, 0, 8, 2, ' ', 0)
+	tw.Write(text)
+	tw.Flush()
+}
+
+// declPos prints the full file name and line of the declaration at pos
+// on a line of its own, if the -where flag is set. If the declaration
+// is in a test file, declPos first prints a comment naming the file.
+func (pkg *Package) declPos(pos token.Pos) {
+	if signature {
+		return
+	}
+	posn := pkg.fs.Position(pos)
+	if pkg.testFile {
+		pkg.Printf("// From test file %s of package %s.\n", filepath.Base(posn.Filename), pkg.name)
+	}
+	if !where {
+		return
+	}
+	pkg.Printf("%s:%d\n", posn.Filename, posn.Line)
+}
+
 var formatBuf bytes.Buffer // Reusable to avoid allocation.
 
 // formatNode is a helper function for printing.
@@ -210,8 +317,9 @@ func (pkg *Package) oneLineFunc(decl *ast.FuncDecl) {
 	pkg.emit("", decl)
 }
 
-// oneLineValueGenDecl prints a var or const declaration as a single line.
-func (pkg *Package) oneLineValueGenDecl(decl *ast.GenDecl) {
+// oneLineValueGenDecl prints a var or const declaration as a single line,
+// preceded by prefix.
+func (pkg *Package) oneLineValueGenDecl(prefix string, decl *ast.GenDecl) {
 	decl.Doc = nil
 	dotDotDot := ""
 	if len(decl.Specs) > 1 {
@@ -231,7 +339,7 @@ func (pkg *Package) oneLineValueGenDecl(decl *ast.GenDecl) {
 		if i < len(valueSpec.Values) && valueSpec.Values[i] != nil {
 			val = fmt.Sprintf(" = %s", pkg.formatNode(valueSpec.Values[i]))
 		}
-		pkg.Printf("%s %s%s%s%s\n", decl.Tok, valueSpec.Names[0], typ, val, dotDotDot)
+		pkg.Printf("%s%s %s%s%s%s\n", prefix, decl.Tok, valueSpec.Names[0], typ, val, dotDotDot)
 		break
 	}
 }
@@ -250,6 +358,43 @@ func (pkg *Package) oneLineTypeDecl(spec *ast.TypeSpec) {
 	}
 }
 
+// signatureTypeDecl prints a type declaration as a single line for the
+// -signature flag, eliding the fields of a struct and the methods of an
+// interface.
+func (pkg *Package) signatureTypeDecl(spec *ast.TypeSpec) {
+	switch spec.Type.(type) {
+	case *ast.InterfaceType:
+		pkg.Printf("type %s interface {...}\n", spec.Name)
+	case *ast.StructType:
+		pkg.Printf("type %s struct {...}\n", spec.Name)
+	default:
+		pkg.Printf("type %s %s\n", spec.Name, pkg.formatNode(spec.Type))
+	}
+}
+
+// signatureValue prints, for the -signature flag, each name in specs
+// that matches symbol as a declaration of its own, such as
+// "const MaxInt8 = 1<<7 - 1".
+func (pkg *Package) signatureValue(tok token.Token, symbol string, specs []ast.Spec) {
+	for _, spec := range specs {
+		vspec := spec.(*ast.ValueSpec)
+		for i, ident := range vspec.Names {
+			if !match(symbol, ident.Name) {
+				continue
+			}
+			typ := ""
+			if vspec.Type != nil {
+				typ = fmt.Sprintf(" %s", pkg.formatNode(vspec.Type))
+			}
+			val := ""
+			if i < len(vspec.Values) {
+				val = fmt.Sprintf(" = %s", pkg.formatNode(vspec.Values[i]))
+			}
+			pkg.Printf("%s %s%s%s\n", tok, ident, typ, val)
+		}
+	}
+}
+
 // packageDoc prints the docs for the package (package doc plus one-liners of the rest).
 func (pkg *Package) packageDoc() {
 	defer pkg.flush()
@@ -257,7 +402,7 @@ func (pkg *Package) packageDoc() {
 		pkg.packageClause(false)
 	}
 
-	doc.ToText(&pkg.buf, pkg.doc.Doc, "", indent, indentedWidth)
+	pkg.toText(pkg.doc.Doc, "", indent)
 	pkg.newlines(1)
 
 	if !pkg.showInternals() {
@@ -266,13 +411,233 @@ func (pkg *Package) packageDoc() {
 	}
 
 	pkg.newlines(2) // Guarantee blank line before the components.
+	start := pkg.buf.Len()
 	pkg.valueSummary(pkg.doc.Consts)
 	pkg.valueSummary(pkg.doc.Vars)
 	pkg.funcSummary(pkg.doc.Funcs, false)
 	pkg.typeSummary()
+	pkg.alignSummaries(start)
+	pkg.bugs()
+}
+
+// allDoc prints the docs for the package, for the -all flag: the package
+// doc followed by the full docs of its constants, variables, functions
+// and types, each type followed by its constants, variables, constructors
+// and methods. Within each section the declarations are in source order.
+func (pkg *Package) allDoc() {
+	defer pkg.flush()
+	if pkg.showInternals() {
+		pkg.packageClause(false)
+	}
+
+	pkg.toText(pkg.doc.Doc, "", indent)
+	pkg.newlines(1)
+
+	if !pkg.showInternals() {
+		// Show only package docs for commands.
+		return
+	}
+
+	// The constants, variables and constructors of types are in the
+	// package's lists as well; print them with their types.
+	typeValues := make(map[*doc.Value]bool)
+	typeFuncs := make(map[*doc.Func]bool)
+	for _, typ := range pkg.doc.Types {
+		for _, value := range typ.Consts {
+			typeValues[value] = true
+		}
+		for _, value := range typ.Vars {
+			typeValues[value] = true
+		}
+		for _, fun := range typ.Funcs {
+			typeFuncs[fun] = true
+		}
+	}
+
+	header := ""
+	printHeader := func(s string) {
+		if header != s {
+			pkg.newlines(2)
+			pkg.Printf("%s\n\n", s)
+			header = s
+		}
+	}
+	for _, value := range pkg.sortedValues(pkg.doc.Consts) {
+		if !typeValues[value] && pkg.hasExportedValue(value) {
+			printHeader("CONSTANTS")
+			pkg.valueDoc(value)
+		}
+	}
+	for _, value := range pkg.sortedValues(pkg.doc.Vars) {
+		if !typeValues[value] && pkg.hasExportedValue(value) {
+			printHeader("VARIABLES")
+			pkg.valueDoc(value)
+		}
+	}
+	for _, fun := range pkg.sortedFuncs(pkg.doc.Funcs) {
+		if !typeFuncs[fun] && isExported(fun.Name) {
+			printHeader("FUNCTIONS")
+			pkg.funcDoc(fun)
+		}
+	}
+	for _, typ := range pkg.sortedTypes(pkg.doc.Types) {
+		if isExported(typ.Name) {
+			printHeader("TYPES")
+			pkg.typeDoc(typ)
+		}
+	}
 	pkg.bugs()
 }
 
+// hasExportedValue reports whether the declaration of value declares
+// an exported name.
+func (pkg *Package) hasExportedValue(value *doc.Value) bool {
+	for _, name := range value.Names {
+		if isExported(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueDoc prints the full docs for a var or const declaration, keeping
+// only the specs that declare an exported name, as symbolDoc does.
+func (pkg *Package) valueDoc(value *doc.Value) {
+	specs := make([]ast.Spec, 0, len(value.Decl.Specs))
+	for _, spec := range value.Decl.Specs {
+		vspec := spec.(*ast.ValueSpec)
+		for _, ident := range vspec.Names {
+			if isExported(ident.Name) {
+				specs = append(specs, vspec)
+				break
+			}
+		}
+	}
+	value.Decl.Specs = specs
+	pkg.newlines(2)
+	pkg.declPos(value.Decl.Pos())
+	pkg.emit(value.Doc, value.Decl)
+}
+
+// funcDoc prints the full docs for a function or method.
+func (pkg *Package) funcDoc(fun *doc.Func) {
+	fun.Decl.Body = nil
+	pkg.newlines(2)
+	pkg.declPos(fun.Decl.Pos())
+	pkg.emit(fun.Doc, fun.Decl)
+}
+
+// typeDoc prints the full docs for a type, followed by those of its
+// constants, variables, constructors and methods.
+func (pkg *Package) typeDoc(typ *doc.Type) {
+	decl := typ.Decl
+	spec := pkg.findTypeSpec(decl, typ.Name)
+	trimUnexportedElems(spec)
+	// If there are multiple types defined, reduce to just this one.
+	if len(decl.Specs) > 1 {
+		decl.Specs = []ast.Spec{spec}
+	}
+	pkg.newlines(2)
+	pkg.declPos(spec.Pos())
+	pkg.emit(typ.Doc, decl)
+	for _, value := range pkg.sortedValues(typ.Consts) {
+		if pkg.hasExportedValue(value) {
+			pkg.valueDoc(value)
+		}
+	}
+	for _, value := range pkg.sortedValues(typ.Vars) {
+		if pkg.hasExportedValue(value) {
+			pkg.valueDoc(value)
+		}
+	}
+	for _, fun := range pkg.sortedFuncs(typ.Funcs) {
+		if isExported(fun.Name) {
+			pkg.funcDoc(fun)
+		}
+	}
+	for _, fun := range pkg.sortedFuncs(typ.Methods) {
+		if isExported(fun.Name) {
+			pkg.funcDoc(fun)
+		}
+	}
+}
+
+// sortedValues returns a copy of values in source order; go/doc sorts
+// them by name.
+func (pkg *Package) sortedValues(values []*doc.Value) []*doc.Value {
+	values = append([]*doc.Value(nil), values...)
+	sort.Stable(valuesByPos{pkg, values})
+	return values
+}
+
+// sortedFuncs returns a copy of funcs in source order.
+func (pkg *Package) sortedFuncs(funcs []*doc.Func) []*doc.Func {
+	funcs = append([]*doc.Func(nil), funcs...)
+	sort.Stable(funcsByPos{pkg, funcs})
+	return funcs
+}
+
+// sortedTypes returns a copy of types in source order.
+func (pkg *Package) sortedTypes(types []*doc.Type) []*doc.Type {
+	types = append([]*doc.Type(nil), types...)
+	sort.Stable(typesByPos{pkg, types})
+	return types
+}
+
+// before reports whether the declaration at x comes before the one at y
+// in the package's files, taken in order of their names.
+func (pkg *Package) before(x, y token.Pos) bool {
+	px, py := pkg.fs.PositionFor(x, false), pkg.fs.PositionFor(y, false)
+	if px.Filename != py.Filename {
+		return px.Filename < py.Filename
+	}
+	return px.Offset < py.Offset
+}
+
+type valuesByPos struct {
+	pkg    *Package
+	values []*doc.Value
+}
+
+func (v valuesByPos) Len() int      { return len(v.values) }
+func (v valuesByPos) Swap(i, j int) { v.values[i], v.values[j] = v.values[j], v.values[i] }
+func (v valuesByPos) Less(i, j int) bool {
+	return v.pkg.before(v.values[i].Decl.Pos(), v.values[j].Decl.Pos())
+}
+
+type funcsByPos struct {
+	pkg   *Package
+	funcs []*doc.Func
+}
+
+func (f funcsByPos) Len() int      { return len(f.funcs) }
+func (f funcsByPos) Swap(i, j int) { f.funcs[i], f.funcs[j] = f.funcs[j], f.funcs[i] }
+func (f funcsByPos) Less(i, j int) bool {
+	return f.pkg.before(f.funcs[i].Decl.Pos(), f.funcs[j].Decl.Pos())
+}
+
+type typesByPos struct {
+	pkg   *Package
+	types []*doc.Type
+}
+
+func (t typesByPos) Len() int      { return len(t.types) }
+func (t typesByPos) Swap(i, j int) { t.types[i], t.types[j] = t.types[j], t.types[i] }
+func (t typesByPos) Less(i, j int) bool {
+	return t.pkg.before(typeSpecPos(t.types[i]), typeSpecPos(t.types[j]))
+}
+
+// typeSpecPos returns the position of the spec that declares typ, which
+// may be one of several in its declaration.
+func typeSpecPos(typ *doc.Type) token.Pos {
+	for _, spec := range typ.Decl.Specs {
+		if spec := spec.(*ast.TypeSpec); spec.Name.Name == typ.Name {
+			return spec.Pos()
+		}
+	}
+	return typ.Decl.Pos()
+}
+
 // showInternals reports whether we should show the internals
 // of a package as opposed to just the package docs.
 // Used to decide whether to suppress internals for commands.
@@ -286,6 +651,9 @@ func (pkg *Package) showInternals() bool {
 // user's argument is identical to the actual package path or
 // is empty, meaning it's the current directory.
 func (pkg *Package) packageClause(checkUserPath bool) {
+	if signature {
+		return
+	}
 	if checkUserPath {
 		if pkg.userPath == "" || pkg.userPath == pkg.build.ImportPath {
 			return
@@ -304,7 +672,11 @@ func (pkg *Package) packageClause(checkUserPath bool) {
 // valueSummary prints a one-line summary for each set of values and constants.
 func (pkg *Package) valueSummary(values []*doc.Value) {
 	for _, value := range values {
-		pkg.oneLineValueGenDecl(value.Decl)
+		before := pkg.buf.Len()
+		pkg.oneLineValueGenDecl(deprecatedMarker(value.Doc), value.Decl)
+		if pkg.buf.Len() > before {
+			pkg.summaryPos(summarySpecPos(value.Decl))
+		}
 	}
 }
 
@@ -326,7 +698,9 @@ func (pkg *Package) funcSummary(funcs []*doc.Func, showConstructors bool) {
 		// Exported functions only. The go/doc package does not include methods here.
 		if isExported(fun.Name) {
 			if !isConstructor[fun] {
+				pkg.Printf("%s", deprecatedMarker(fun.Doc))
 				pkg.oneLineFunc(decl)
+				pkg.summaryPos(decl.Pos())
 			}
 		}
 	}
@@ -338,12 +712,16 @@ func (pkg *Package) typeSummary() {
 		for _, spec := range typ.Decl.Specs {
 			typeSpec := spec.(*ast.TypeSpec) // Must succeed.
 			if isExported(typeSpec.Name.Name) {
+				pkg.Printf("%s", deprecatedMarker(typ.Doc))
 				pkg.oneLineTypeDecl(typeSpec)
+				pkg.summaryPos(typeSpec.Pos())
 				// Now print the constructors.
 				for _, constructor := range typ.Funcs {
 					if isExported(constructor.Name) {
 						pkg.Printf(indent)
+						pkg.Printf("%s", deprecatedMarker(constructor.Doc))
 						pkg.oneLineFunc(constructor.Decl)
+						pkg.summaryPos(constructor.Decl.Pos())
 					}
 				}
 			}
@@ -351,6 +729,18 @@ func (pkg *Package) typeSummary() {
 	}
 }
 
+// summarySpecPos returns the position of the first exported name in a
+// var or const declaration, the name that oneLineValueGenDecl prints.
+func summarySpecPos(decl *ast.GenDecl) token.Pos {
+	for _, spec := range decl.Specs {
+		valueSpec := spec.(*ast.ValueSpec) // Must succeed; we can't mix types in one genDecl.
+		if isExported(valueSpec.Names[0].Name) {
+			return valueSpec.Names[0].Pos()
+		}
+	}
+	return decl.Pos()
+}
+
 // bugs prints the BUGS information for the package.
 // TODO: Provide access to TODOs and NOTEs as well (very noisy so off by default)?
 func (pkg *Package) bugs() {
@@ -421,8 +811,11 @@ func (pkg *Package) symbolDoc(symbol string) bool {
 		}
 		// Symbol is a function.
 		decl := fun.Decl
-		decl.Body = nil
-		pkg.emit(fun.Doc, decl)
+		if !showSrc {
+			decl.Body = nil
+		}
+		pkg.declPos(decl.Pos())
+		pkg.emitDecl(fun.Doc, decl)
 		found = true
 	}
 	// Constants and variables behave the same.
@@ -447,11 +840,19 @@ func (pkg *Package) symbolDoc(symbol string) bool {
 		if len(specs) == 0 {
 			continue
 		}
-		value.Decl.Specs = specs
+		if signature {
+			pkg.signatureValue(value.Decl.Tok, symbol, specs)
+			found = true
+			continue
+		}
+		if !showSrc {
+			value.Decl.Specs = specs
+		}
 		if !found {
 			pkg.packageClause(true)
 		}
-		pkg.emit(value.Doc, value.Decl)
+		pkg.declPos(value.Decl.Pos())
+		pkg.emitDecl(value.Doc, value.Decl)
 		found = true
 	}
 	// Types.
@@ -461,20 +862,37 @@ func (pkg *Package) symbolDoc(symbol string) bool {
 		}
 		decl := typ.Decl
 		spec := pkg.findTypeSpec(decl, typ.Name)
-		trimUnexportedElems(spec)
-		// If there are multiple types defined, reduce to just this one.
-		if len(decl.Specs) > 1 {
-			decl.Specs = []ast.Spec{spec}
+		if signature {
+			pkg.signatureTypeDecl(spec)
+			found = true
+			continue
+		}
+		if showSrc {
+			// A type declared in a group has a declaration of
+			// its own from go/doc, with the group's doc comment;
+			// show the type's own.
+			if decl.TokPos == spec.Pos() {
+				decl.Doc = spec.Doc
+			}
+		} else {
+			trimUnexportedElems(spec)
+			// If there are multiple types defined, reduce to just this one.
+			if len(decl.Specs) > 1 {
+				decl.Specs = []ast.Spec{spec}
+			}
 		}
-		pkg.emit(typ.Doc, decl)
+		pkg.declPos(spec.Pos())
+		pkg.emitDecl(typ.Doc, decl)
 		// Show associated methods, constants, etc.
 		if len(typ.Consts) > 0 || len(typ.Vars) > 0 || len(typ.Funcs) > 0 || len(typ.Methods) > 0 {
 			pkg.Printf("\n")
 		}
+		start := pkg.buf.Len()
 		pkg.valueSummary(typ.Consts)
 		pkg.valueSummary(typ.Vars)
 		pkg.funcSummary(typ.Funcs, true)
 		pkg.funcSummary(typ.Methods, true)
+		pkg.alignSummaries(start)
 		found = true
 	}
 	if !found {
@@ -486,6 +904,55 @@ func (pkg *Package) symbolDoc(symbol string) bool {
 	return true
 }
 
+// symbolPos returns the position of a top-level declaration of symbol,
+// the first one symbolDoc would print. The boolean reports whether there
+// is any.
+func (pkg *Package) symbolPos(symbol string) (token.Pos, bool) {
+	if funcs := pkg.findFuncs(symbol); len(funcs) > 0 {
+		return funcs[0].Decl.Pos(), true
+	}
+	values := pkg.findValues(symbol, pkg.doc.Consts)
+	values = append(values, pkg.findValues(symbol, pkg.doc.Vars)...)
+	if len(values) > 0 {
+		return values[0].Decl.Pos(), true
+	}
+	if types := pkg.findTypes(symbol); len(types) > 0 {
+		return types[0].Decl.Pos(), true
+	}
+	return token.NoPos, false
+}
+
+// testSymbolDoc prints the docs for symbol, or for symbol.method if
+// method is not empty, from the first test file set that has them.
+// It reports whether it found any.
+func (pkg *Package) testSymbolDoc(symbol, method string) bool {
+	for _, test := range pkg.tests {
+		if method == "" {
+			if test.symbolDoc(symbol) {
+				return true
+			}
+		} else if test.findTypes(symbol) != nil && test.methodDoc(symbol, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// shadowedTests prints a note for each declaration of symbol in a test
+// file set, which the declaration in the package itself shadows.
+func (pkg *Package) shadowedTests(symbol string) {
+	defer pkg.flush()
+	if signature {
+		return
+	}
+	for _, test := range pkg.tests {
+		if pos, ok := test.symbolPos(symbol); ok {
+			posn := test.fs.Position(pos)
+			pkg.Printf("// Shadows %s in test file %s of package %s.\n", symbol, filepath.Base(posn.Filename), test.name)
+		}
+	}
+}
+
 // trimUnexportedElems modifies spec in place to elide unexported fields from
 // structs and methods from interfaces (unless the unexported flag is set).
 func trimUnexportedElems(spec *ast.TypeSpec) {
@@ -576,8 +1043,11 @@ func (pkg *Package) printMethodDoc(symbol, method string) bool {
 		for _, meth := range typ.Methods {
 			if match(method, meth.Name) {
 				decl := meth.Decl
-				decl.Body = nil
-				pkg.emit(meth.Doc, decl)
+				if !showSrc {
+					decl.Body = nil
+				}
+				pkg.declPos(decl.Pos())
+				pkg.emitDecl(meth.Doc, decl)
 				found = true
 			}
 		}
@@ -586,9 +1056,242 @@ func (pkg *Package) printMethodDoc(symbol, method string) bool {
 }
 
 // methodDoc prints the docs for matches of symbol.method.
+// If the type does not declare the method, it looks for the method
+// among the ones the type acquires through embedding.
 func (pkg *Package) methodDoc(symbol, method string) bool {
 	defer pkg.flush()
-	return pkg.printMethodDoc(symbol, method)
+	return pkg.printMethodDoc(symbol, method) || pkg.promotedMethodDoc(symbol, method)
+}
+
+// An embedding is a type reached while looking for a promoted method.
+type embedding struct {
+	pkg  *Package
+	name string
+	// underlying is set when the type was reached as the definition
+	// of another type, as in "type T io.Writer": the methods declared
+	// on it do not belong to the other type, but those of an
+	// interface, and the embedded fields of a struct, do.
+	underlying bool
+}
+
+func (e embedding) String() string {
+	return e.pkg.name + "." + e.name
+}
+
+// A selection is a method or field named like the requested method.
+type selection struct {
+	from embedding
+	name string
+	doc  string
+	decl *ast.FuncDecl // nil for a field
+}
+
+// promotedMethodDoc prints the docs for method when a type matching
+// symbol acquires it from an embedded struct field or an embedded
+// interface, possibly declared in another package. It also finds the
+// methods that an interface type declares directly. It reports whether
+// it found the method.
+func (pkg *Package) promotedMethodDoc(symbol, method string) bool {
+	found := false
+	for _, typ := range pkg.findTypes(symbol) {
+		if pkg.typePromotedMethodDoc(typ.Name, method) {
+			found = true
+		}
+	}
+	return found
+}
+
+// typePromotedMethodDoc prints the docs for the methods matching method
+// that the type name acquires through embedding. Embedded types are
+// searched breadth first so that, as in the language, a method or field
+// at a shallower depth shadows those of the same name below it, and two
+// of the same name at the same depth make the selector ambiguous. When
+// method matches several names, as a lower-case method may without the
+// -c flag, each name is looked up on its own.
+func (pkg *Package) typePromotedMethodDoc(name, method string) bool {
+	level := []embedding{{pkg: pkg, name: name}}
+	seen := make(map[string]bool)
+	settled := make(map[string]bool) // names found at a shallower depth
+	loaded := map[string]*Package{pkg.build.ImportPath: pkg}
+	printed := false
+	for depth := 0; len(level) > 0; depth++ {
+		var names []string // in the order they were found
+		found := make(map[string][]selection)
+		add := func(sel selection) {
+			if settled[sel.name] {
+				return
+			}
+			if found[sel.name] == nil {
+				names = append(names, sel.name)
+			}
+			found[sel.name] = append(found[sel.name], sel)
+		}
+		var next []embedding
+		for i := 0; i < len(level); i++ {
+			e := level[i]
+			key := e.pkg.build.ImportPath + "." + e.name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			typ := e.pkg.findType(e.name)
+			if typ == nil {
+				continue
+			}
+			if depth > 0 && !e.underlying {
+				for _, meth := range typ.Methods {
+					if meth.Level == 0 && match(method, meth.Name) {
+						decl := meth.Decl
+						if !showSrc {
+							decl.Body = nil
+						}
+						add(selection{e, meth.Name, meth.Doc, decl})
+					}
+				}
+			}
+			spec := e.pkg.findTypeSpec(typ.Decl, typ.Name)
+			switch t := spec.Type.(type) {
+			case *ast.InterfaceType:
+				for _, field := range t.Methods.List {
+					if len(field.Names) == 0 {
+						if emb, ok := e.pkg.embeddedType(field.Type, spec.Pos(), loaded); ok {
+							next = append(next, emb)
+						}
+						continue
+					}
+					for _, ident := range field.Names {
+						if match(method, ident.Name) {
+							decl := &ast.FuncDecl{
+								Recv: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent(e.name)}}},
+								Name: ident,
+								Type: field.Type.(*ast.FuncType),
+							}
+							if showSrc {
+								decl.Doc = field.Doc
+							}
+							add(selection{e, ident.Name, field.Doc.Text(), decl})
+						}
+					}
+				}
+			case *ast.StructType:
+				for _, field := range t.Fields.List {
+					if len(field.Names) == 0 {
+						if emb, ok := e.pkg.embeddedType(field.Type, spec.Pos(), loaded); ok {
+							next = append(next, emb)
+						}
+						continue
+					}
+					for _, ident := range field.Names {
+						if match(method, ident.Name) {
+							add(selection{from: e, name: ident.Name})
+						}
+					}
+				}
+			case *ast.Ident, *ast.SelectorExpr:
+				// A type defined as another named type has the
+				// underlying type's interface methods and
+				// embedded fields at the same depth.
+				if def, ok := e.pkg.embeddedType(t, spec.Pos(), loaded); ok {
+					def.underlying = true
+					level = append(level, def)
+				}
+			}
+		}
+		for _, n := range names {
+			settled[n] = true
+			sels := found[n]
+			if len(sels) > 1 {
+				var from []string
+				for _, sel := range sels {
+					from = append(from, sel.from.String())
+				}
+				pkg.Fatalf("ambiguous method %s.%s: promoted from %s", name, n, strings.Join(from, " and "))
+			}
+			sel := sels[0]
+			if sel.decl == nil {
+				// A field shadows the methods below it.
+				continue
+			}
+			p := sel.from.pkg
+			if depth > 0 && !signature {
+				p.Printf("// Method promoted from %s.\n", sel.from)
+			}
+			p.declPos(sel.decl.Pos())
+			p.emitDecl(sel.doc, sel.decl)
+			p.flush()
+			printed = true
+		}
+		level = next
+	}
+	return printed
+}
+
+// findType returns the doc.Type of the type declared as name,
+// exported or not, or nil if there is none.
+func (pkg *Package) findType(name string) *doc.Type {
+	for _, typ := range pkg.doc.Types {
+		if typ.Name == name {
+			return typ
+		}
+	}
+	return nil
+}
+
+// embeddedType returns the type named by expr, the type of an embedded
+// field in the declaration at pos. If the type is declared in another
+// package, embeddedType loads it, keeping it in loaded by import path.
+// The boolean reports whether expr names a type that could be found.
+func (pkg *Package) embeddedType(expr ast.Expr, pos token.Pos, loaded map[string]*Package) (embedding, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return embedding{pkg: pkg, name: x.Name}, true
+	case *ast.SelectorExpr:
+		id, ok := x.X.(*ast.Ident)
+		if !ok {
+			return embedding{}, false
+		}
+		bp := pkg.imported(id.Name, pos)
+		if bp == nil {
+			return embedding{}, false
+		}
+		p := loaded[bp.ImportPath]
+		if p == nil {
+			p = parsePackage(pkg.writer, bp, bp.ImportPath)
+			loaded[bp.ImportPath] = p
+		}
+		return embedding{pkg: p, name: x.Sel.Name}, true
+	}
+	return embedding{}, false
+}
+
+// imported returns the package imported as name by the file that
+// contains pos, or nil if there is none.
+func (pkg *Package) imported(name string, pos token.Pos) *build.Package {
+	for _, file := range pkg.pkg.Files {
+		if pos < file.Pos() || file.End() < pos {
+			continue
+		}
+		for _, imp := range file.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			if imp.Name != nil && imp.Name.Name != name {
+				continue
+			}
+			bp, err := build.Import(path, pkg.build.Dir, build.ImportComment)
+			if err != nil {
+				continue
+			}
+			if imp.Name != nil || bp.Name == name {
+				return bp
+			}
+		}
+	}
+	return nil
 }
 
 // match reports whether the user's symbol matches the program's.
@@ -0,0 +1,119 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/build"
+	"html"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// serveMu serializes requests, since the flags, the directory scan and
+// the handling of the builtin package are global state.
+var serveMu sync.Mutex
+
+// serve serves documentation over HTTP on addr, for the -http flag.
+// It shows the same text as the command line, which makes it easy to
+// share documentation without running godoc. The server has these pages:
+//
+//	/                               the packages in GOROOT and GOPATH
+//	/pkg/<pkg>                      go doc <pkg>
+//	/pkg/<pkg>/<sym>[.<method>]     go doc <pkg>.<sym>[.<method>]
+//
+// where <pkg> is any package argument the command line accepts, such as
+// encoding/json or just json. Browsers do not send the fragment of a URL,
+// so /pkg/<pkg>#<sym> shows the whole package. The other flags, such as
+// -u or -c, apply to every request.
+func serve(addr string) error {
+	log.Printf("serving documentation at http://%s/", addr)
+	return http.ListenAndServe(addr, newServer())
+}
+
+// newServer returns the handler for the -http flag.
+func newServer() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/pkg/", servePkg)
+	return mux
+}
+
+// serveIndex lists the packages found by the directory scan,
+// with links to their documentation.
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	serveMu.Lock()
+	defer serveMu.Unlock()
+
+	var b bytes.Buffer
+	b.WriteString("<pre>\n")
+	dirs.Reset()
+	for {
+		dir, ok := dirs.Next()
+		if !ok {
+			break
+		}
+		path := html.EscapeString(importPath(dir))
+		b.WriteString(`<a href="/pkg/` + path + `">` + path + "</a>\n")
+	}
+	b.WriteString("</pre>\n")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// servePkg shows the documentation for the package and symbol in the
+// path following /pkg/, as plain text.
+func servePkg(w http.ResponseWriter, r *http.Request) {
+	serveMu.Lock()
+	defer serveMu.Unlock()
+
+	// The builtin package turns on -u; do not let that leak
+	// into the following requests.
+	defer func(u bool) { unexported = u }(unexported)
+
+	var b bytes.Buffer
+	err := document(&b, docArgs(strings.Trim(strings.TrimPrefix(r.URL.Path, "/pkg/"), "/")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// docArgs returns the command line arguments equivalent to a path
+// <pkg> or <pkg>/<sym>[.<method>] on the server.
+func docArgs(path string) []string {
+	if path == "" {
+		return nil
+	}
+	if _, err := build.Import(path, "", build.ImportComment); err == nil {
+		return []string{path}
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return []string{path[:i] + "." + path[i+1:]}
+	}
+	return []string{path}
+}
+
+// importPath returns the import path of the source directory dir,
+// found by the directory scan in GOROOT or GOPATH.
+func importPath(dir string) string {
+	dir = filepath.ToSlash(dir)
+	roots := append([]string{build.Default.GOROOT}, splitGopath()...)
+	for _, root := range roots {
+		if p, ok := trim(dir, filepath.ToSlash(filepath.Join(root, "src"))); ok {
+			return p
+		}
+	}
+	return dir
+}
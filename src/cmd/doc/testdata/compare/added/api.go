@@ -0,0 +1,67 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package api is a version of the old package that only adds to its
+// API, so the -compare flag finds no incompatible changes.
+package api
+
+import "io"
+
+// Comment about Same.
+const Same = 1
+
+// Comment about block of kinds.
+const (
+	KindA Kind = iota
+	KindB
+)
+
+// RemovedConst is removed.
+const RemovedConst = 2
+
+// Comment about ChangedVar.
+var ChangedVar int32
+
+// Comment about Func.
+func Func(a int) bool { return a > 0 }
+
+// Comment about ChangedFunc.
+func ChangedFunc(a int) bool { return a > 0 }
+
+// RemovedFunc is removed.
+func RemovedFunc() {}
+
+// Comment about Kind.
+type Kind int
+
+// Comment about Struct.
+type Struct struct {
+	A int // Comment about A.
+	b int
+}
+
+// Comment about Method.
+func (s *Struct) Method() {}
+
+// Comment about RemovedMethod.
+func (s *Struct) RemovedMethod() {}
+
+// Comment about ChangedMethod.
+func (s Struct) ChangedMethod(r io.Reader) {}
+
+func (s *Struct) unexportedMethod() {}
+
+// Comment about ChangedStruct.
+type ChangedStruct struct {
+	A int
+}
+
+// Comment about Interface.
+type Interface interface {
+	io.Reader
+	Method()
+}
+
+// AddedFunc is added.
+func AddedFunc() {}
@@ -0,0 +1,67 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package api is the new version of a package whose API is compared
+// with the old one by the -compare flag.
+package api
+
+import "io"
+
+// Comment about Same.
+const Same = 1
+
+// Comment about block of kinds.
+const (
+	KindA Kind = iota
+	KindB
+	AddedKind
+)
+
+// Comment about ChangedVar.
+var ChangedVar int64
+
+// New comment about Func.
+func Func(a int) bool {
+	return a > 0
+}
+
+// Comment about ChangedFunc.
+func ChangedFunc(a int, b string) bool { return a > 0 }
+
+// AddedFunc is added.
+func AddedFunc() {}
+
+// Comment about Kind.
+type Kind int
+
+// Comment about Struct.
+type Struct struct {
+	// Comment about A.
+	A int
+
+	c string
+}
+
+// Comment about Method.
+func (s *Struct) Method() {}
+
+// Comment about ChangedMethod.
+func (s Struct) ChangedMethod(w io.Writer) {}
+
+// AddedMethod is added.
+func (s *Struct) AddedMethod() {}
+
+func (s *Struct) otherUnexportedMethod() {}
+
+// Comment about ChangedStruct.
+type ChangedStruct struct {
+	A int
+	B int
+}
+
+// Comment about Interface.
+type Interface interface {
+	Method()
+	io.Reader
+}
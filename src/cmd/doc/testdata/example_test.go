@@ -0,0 +1,12 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg_test
+
+// Comment about ExampleExportedFunc.
+func ExampleExportedFunc() {}
+
+// Comment about the test package's ExportedVariable, which the
+// package's own shadows.
+var ExportedVariable = 3
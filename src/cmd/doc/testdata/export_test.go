@@ -0,0 +1,16 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+// Test helpers shown by the -test flag.
+
+// Comment about ExportedTestHelper.
+func ExportedTestHelper() int { return internalConstant }
+
+// Comment about TestOnlyType.
+type TestOnlyType int
+
+// Comment about TestOnlyMethod.
+func (TestOnlyType) TestOnlyMethod() {}
@@ -119,3 +119,30 @@ const unexportedTypedConstant unexportedType = 1 // In a separate section to tes
 // For case matching.
 const CaseMatch = 1
 const Casematch = 2
+
+// Comment about deprecated function.
+//
+// Deprecated: Use ExportedFunc instead.
+func DeprecatedFunc() bool
+
+// Deprecated: DeprecatedType is no longer used.
+//
+// Comment about deprecated type.
+type DeprecatedType int
+
+// Comment about superseded method.
+//
+// Deprecated: Use the method of ExportedType instead.
+func (DeprecatedType) Superseded() bool {
+	return true
+}
+
+// Comment about current method.
+func (DeprecatedType) Current() bool {
+	return true
+}
+
+// Comment about deprecated constant.
+//
+// Deprecated: Use ExportedConstant instead.
+const DeprecatedConstant = 3
@@ -0,0 +1,65 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Types that acquire methods through embedding.
+
+// Comment about PromotedInterface.
+type PromotedInterface interface {
+	io.ReadWriter
+	// Comment about PromotedInterface.Own.
+	Own() int
+}
+
+// DefinedInterface has the methods of PromotedInterface.
+type DefinedInterface PromotedInterface
+
+// Comment about PromotedStruct.
+type PromotedStruct struct {
+	*bytes.Buffer
+	Inner
+}
+
+// Inner embeds a type with a method two levels below PromotedStruct.
+type Inner struct {
+	Deeper
+}
+
+// Deeper is embedded in Inner.
+type Deeper struct{}
+
+// Comment about Deeper.Deep.
+func (Deeper) Deep() {}
+
+// AmbiguousStruct has two Read methods at the same depth.
+type AmbiguousStruct struct {
+	*bytes.Reader
+	*strings.Reader
+}
+
+// ShadowingStruct has a Len field that shadows the Len method of Buffer.
+type ShadowingStruct struct {
+	*bytes.Buffer
+	Len int
+}
+
+// CaseStruct acquires DEEP from Shallow and Deep, two levels below,
+// from Inner. Without -c, deep matches both.
+type CaseStruct struct {
+	Inner
+	Shallow
+}
+
+// Shallow is embedded in CaseStruct.
+type Shallow struct{}
+
+// Comment about Shallow.DEEP.
+func (Shallow) DEEP() {}
@@ -0,0 +1,38 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+// Declarations whose source is shown by the -src flag.
+
+// Comment about SrcFunc.
+func SrcFunc(n int) int {
+	// Comment in the body of SrcFunc.
+	return n + 1
+}
+
+// Comment about the block of source types.
+type (
+	// Comment about SrcType.
+	SrcType struct {
+		SrcField int // Comment on line with SrcField.
+		srcField int // Comment on line with srcField.
+	}
+
+	// Comment about SrcOther.
+	SrcOther int
+)
+
+// Comment about SrcType.First.
+func (t SrcType) First() int {
+	return t.SrcField // Comment in the body of First.
+}
+
+// Comment about SrcType.Second.
+func (t *SrcType) Second() {
+	t.srcField++
+}
+
+// Comment about SrcType.third.
+func (SrcType) third() {}
@@ -0,0 +1,27 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkg
+
+// Declarations whose source positions are shown by the -where flag.
+
+// Comment about WhereFunc.
+func WhereFunc() {}
+
+// Comment about WhereType.
+type WhereType int
+
+// Comment about WhereMethod.
+func (WhereType) WhereMethod() {}
+
+// Comment about block of where constants.
+const (
+	WhereConstOne = 1
+	WhereConstTwo = 2
+)
+
+//line generated.y:100
+
+// Comment about generated function.
+func WhereGenerated() {}
@@ -242,7 +242,11 @@ letter it is assumed to identify a symbol or method in the current directory.
 For packages, the order of scanning is determined lexically in breadth-first order.
 That is, the package presented is the one that matches the search and is nearest
 the root and lexically first at its level of the hierarchy.  The GOROOT tree is
-always scanned in its entirety before GOPATH.
+always scanned in its entirety before GOPATH. Before either, though, the vendor
+directories of the package in the current directory are scanned, as they are
+for an import in that package: the nearest first, and each in the same order.
+A vendored package also takes precedence over one with the same full path in
+GOROOT or GOPATH.
 
 If there is no package specified or matched, the package in the current
 directory is selected, so "go doc Foo" shows the documentation for symbol Foo in
@@ -263,6 +267,18 @@ either case but upper-case letters match exactly. This means that there may be
 multiple matches of a lower-case argument in a package if different symbols have
 different cases. If this occurs, documentation for all matches is printed.
 
+A method may also be one that the type acquires from an embedded field or an
+embedded interface, possibly declared in another package. Its documentation is
+preceded by a line naming the type it is promoted from. As in the language, a
+method or field at a shallower depth of embedding hides those of the same name
+below it, and two of the same name at the same depth are reported as ambiguous,
+listing the types they come from.
+
+With the -compare flag, doc takes two directories holding two versions of
+the same package and lists the changes to its exported API:
+
+	go doc -compare <dir1> <dir2>
+
 Examples:
 	go doc
 		Show documentation for current package.
@@ -282,6 +298,10 @@ Examples:
 		Show package docs for the doc command.
 	go doc -cmd cmd/doc
 		Show package docs and exported symbols within the doc command.
+	go doc -all json
+		Show the full documentation of encoding/json, with all its symbols.
+	go doc -src bytes.Buffer.ReadFrom
+		Show the source of bytes.Buffer's ReadFrom method.
 	go doc template.new
 		Show documentation for html/template's New function.
 		(html/template is lexically before text/template)
@@ -299,15 +319,69 @@ Examples:
 	cd go/src/encoding/json; go doc decode
 
 Flags:
+	-all
+		Show the full documentation of the package: the package doc,
+		then all its constants, variables, functions and types, each
+		with its doc comment and each type with its methods.
 	-c
 		Respect case when matching symbols.
 	-cmd
 		Treat a command (package main) like a regular package.
 		Otherwise package main's exported symbols are hidden
 		when showing the package's top-level documentation.
+	-color when
+		Color the output: when is always, never or auto, the default.
+		With auto, the output is colored if it goes to a terminal and
+		the NO_COLOR environment variable is empty, except on Windows
+		and Plan 9, whose consoles need not understand the ANSI escape
+		sequences used. Keywords, exported identifiers and literals
+		are colored in declarations, and only headings and deprecation
+		notices in comments.
+	-comments
+		With -compare, also list the declarations whose doc comment
+		changed.
+	-compare
+		Compare the exported API of the packages in two directories,
+		typically an old and a new version of a dependency. Doc lists
+		the constants, variables, functions, types and methods removed,
+		added and changed in the second, where a declaration has
+		changed if its text, formatted without comments, differs.
+		Doc exits with a non-zero status if any declaration was
+		removed or changed.
+	-deprecated
+		List only the deprecated symbols of the package, those whose
+		documentation has a paragraph beginning "Deprecated: ", each
+		followed by that paragraph. It does not take a symbol.
+	-http addr
+		Instead of printing, serve the same output over HTTP on addr,
+		such as localhost:6060. The page /pkg/<pkg>/<sym>[.<method>]
+		shows what "go doc <pkg>.<sym>[.<method>]" would print, as
+		plain text, and / lists the packages in GOROOT and GOPATH.
+	-signature
+		Print only the declaration of the symbol or method, with no
+		package clause and no comments. A type is printed on one
+		line with its fields or methods elided, as in
+		"type Buffer struct {...}". Doc exits with a non-zero status
+		if there is no such symbol.
+	-src
+		Show the source of the symbol or method: its declaration with
+		its doc comment, the comments inside it and, for a function or
+		method, its body. A type is followed by the usual summary of its
+		methods. It requires a symbol, and -u still decides whether
+		unexported ones can be found.
+	-test
+		Also look up symbols in the package's test files and in its
+		external test package, such as test helpers and examples.
+		Each is labeled with the test file that declares it. A symbol
+		of the package itself shadows a test symbol of the same name,
+		which is mentioned after its documentation.
 	-u
 		Show documentation for unexported as well as exported
 		symbols and methods.
+	-where
+		Show where each symbol is declared: the file base name and
+		line after each symbol in a package listing, and the full file
+		name and line before the declaration of a single symbol.
 
 
 Print Go environment information
@@ -1097,6 +1171,10 @@ Architecture-specific environment variables:
 	GO386
 		For GOARCH=386, the floating point instruction set.
 		Valid values are 387, sse2.
+	GOPPC64
+		For GOARCH=ppc64 and ppc64le, the POWER processor level
+		for which to compile. Valid values are power5, power8.
+		The default is power5.
 
 Special-purpose environment variables:
 
@@ -475,6 +475,10 @@ Architecture-specific environment variables:
 	GO386
 		For GOARCH=386, the floating point instruction set.
 		Valid values are 387, sse2.
+	GOPPC64
+		For GOARCH=ppc64 and ppc64le, the POWER processor level
+		for which to compile. Valid values are power5, power8.
+		The default is power5.
 
 Special-purpose environment variables:
 
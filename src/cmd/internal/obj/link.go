@@ -276,6 +276,7 @@ const (
 	AGLOBL
 	AJMP
 	ANOP
+	APCALIGN
 	APCDATA
 	ARET
 	ATEXT
@@ -335,6 +336,10 @@ type LSym struct {
 	// local in this sense unless there is a cgo_export_* directive).
 	Local bool
 
+	// Align is the alignment of a function's code, if it needs more
+	// than the architecture's function alignment because of PCALIGN.
+	Align int32
+
 	RefIdx int // Index of this symbol in the symbol reference list.
 	Args   int32
 	Locals int32
@@ -465,6 +470,11 @@ const (
 	R_CALLARM
 	R_CALLARM64
 	R_CALLIND
+	// R_CALLPOWER (only used on ppc64) resolves to the word displacement of
+	// the target of a direct branch (b or bl), encoded in bits 6-29 of the
+	// instruction. The linker may point the branch at a trampoline instead
+	// when the target is out of range; trampolines clobber only REGTMP and
+	// CTR, so the branch must not expect either to survive it.
 	R_CALLPOWER
 	// R_CALLMIPS (only used on mips64) resolves to non-PC-relative target address
 	// of a CALL (JAL) instruction, by encoding the address into the instruction.
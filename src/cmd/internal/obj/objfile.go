@@ -68,6 +68,7 @@
 //		1<<0 leaf
 //		1<<1 C function
 //		1<<2 function may call reflect.Type.Method
+//		1<<3 and up: alignment of the code, if not the default
 //	- nlocal [int]
 //	- local [nlocal automatics]
 //	- pcln [pcln table]
@@ -431,6 +432,7 @@ func (w *objWriter) writeSym(s *LSym) {
 	if s.ReflectMethod {
 		flags |= 1 << 2
 	}
+	flags |= int64(s.Align) << 3
 	w.writeInt(flags)
 	n := 0
 	for a := s.Autom; a != nil; a = a.Link {
@@ -539,6 +539,17 @@ const (
 	/* more 64-bit operations */
 	AHRFID
 
+	/* byte operations, ISA 2.05 and later */
+	ACMPB
+	APOPCNTB
+	ABPERMD
+
+	/* direct moves between GPRs and FPRs, ISA 2.07 (POWER8) and later */
+	AMFVSRD
+	AMFFPRD
+	AMTVSRD
+	AMTFPRD
+
 	ALAST
 
 	// aliases
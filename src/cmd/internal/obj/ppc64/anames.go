@@ -301,5 +301,12 @@ var Anames = []string{
 	"REMDUV",
 	"REMDUVCC",
 	"HRFID",
+	"CMPB",
+	"POPCNTB",
+	"BPERMD",
+	"MFVSRD",
+	"MFFPRD",
+	"MTVSRD",
+	"MTFPRD",
 	"LAST",
 }
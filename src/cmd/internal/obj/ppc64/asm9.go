@@ -41,6 +41,9 @@ import (
 
 const (
 	FuncAlign = 8
+
+	// pcAlignMax is the largest alignment PCALIGN accepts.
+	pcAlignMax = 64
 )
 
 const (
@@ -316,6 +319,8 @@ var optab = []Optab{
 	{ADWORD, C_DCON, C_NONE, C_NONE, C_NONE, 31, 8, 0},
 	{AADDME, C_REG, C_NONE, C_NONE, C_REG, 47, 4, 0},
 	{AEXTSB, C_REG, C_NONE, C_NONE, C_REG, 48, 4, 0},
+	{APOPCNTB, C_REG, C_NONE, C_NONE, C_REG, 48, 4, 0},
+	{ACMPB, C_REG, C_REG, C_NONE, C_REG, 6, 4, 0}, /* byte op Rb,Rs,Ra */
 	{AEXTSB, C_NONE, C_NONE, C_NONE, C_REG, 48, 4, 0},
 	{ANEG, C_REG, C_NONE, C_NONE, C_REG, 47, 4, 0},
 	{ANEG, C_NONE, C_NONE, C_NONE, C_REG, 47, 4, 0},
@@ -388,6 +393,8 @@ var optab = []Optab{
 	{ATLBIE, C_SCON, C_NONE, C_NONE, C_REG, 49, 4, 0},
 	{ASLBMFEE, C_REG, C_NONE, C_NONE, C_REG, 55, 4, 0},
 	{ASLBMTE, C_REG, C_NONE, C_NONE, C_REG, 55, 4, 0},
+	{AMFVSRD, C_FREG, C_NONE, C_NONE, C_REG, 82, 4, 0},
+	{AMTVSRD, C_REG, C_NONE, C_NONE, C_FREG, 83, 4, 0},
 	{ASTSW, C_REG, C_NONE, C_NONE, C_ZOREG, 44, 4, 0},
 	{ASTSW, C_REG, C_NONE, C_LCON, C_ZOREG, 41, 4, 0},
 	{ALSW, C_ZOREG, C_NONE, C_NONE, C_REG, 45, 4, 0},
@@ -397,6 +404,7 @@ var optab = []Optab{
 	{obj.APCDATA, C_LCON, C_NONE, C_NONE, C_LCON, 0, 0, 0},
 	{obj.AFUNCDATA, C_SCON, C_NONE, C_NONE, C_ADDR, 0, 0, 0},
 	{obj.ANOP, C_NONE, C_NONE, C_NONE, C_NONE, 0, 0, 0},
+	{obj.APCALIGN, C_LCON, C_NONE, C_NONE, C_NONE, 0, 0, 0},   // variable size, see pcAlignPadding
 	{obj.ADUFFZERO, C_NONE, C_NONE, C_NONE, C_LBRA, 11, 4, 0}, // same as ABR/ABL
 	{obj.ADUFFCOPY, C_NONE, C_NONE, C_NONE, C_LBRA, 11, 4, 0}, // same as ABR/ABL
 
@@ -424,10 +432,22 @@ func span9(ctxt *obj.Link, cursym *obj.LSym) {
 
 	var m int
 	var o *Optab
+	align := int64(FuncAlign)
 	for p = p.Link; p != nil; p = p.Link {
 		ctxt.Curp = p
 		p.Pc = c
 		o = oplook(ctxt, p)
+		if p.As == obj.APCALIGN {
+			if a := p.From.Offset; a <= 0 || a&(a-1) != 0 || a > pcAlignMax {
+				ctxt.Diag("PCALIGN alignment must be a power of two no larger than %d\n%v", pcAlignMax, p)
+				p.From.Offset = 4
+			}
+			if p.From.Offset > align {
+				align = p.From.Offset
+			}
+			c += pcAlignPadding(p)
+			continue
+		}
 		m = int(o.size)
 		if m == 0 {
 			if p.As != obj.ANOP && p.As != obj.AFUNCDATA && p.As != obj.APCDATA && p.As != obj.AUSEFIELD {
@@ -441,11 +461,21 @@ func span9(ctxt *obj.Link, cursym *obj.LSym) {
 
 	cursym.Size = c
 
+	// The padding of PCALIGN is relative to the start of the function,
+	// so the function must be at least as aligned.
+	if align > FuncAlign {
+		cursym.Align = int32(align)
+	}
+
 	/*
 	 * if any procedure is large enough to
 	 * generate a large SBRA branch, then
 	 * generate extra passes putting branches
 	 * around jmps to fix. this is rare.
+	 *
+	 * the padding of PCALIGN changes when the code before it
+	 * grows, which can move branch targets already checked in
+	 * this pass, so the passes continue until no pc moves.
 	 */
 	bflag := 1
 
@@ -458,6 +488,9 @@ func span9(ctxt *obj.Link, cursym *obj.LSym) {
 		bflag = 0
 		c = 0
 		for p = cursym.Text.Link; p != nil; p = p.Link {
+			if p.Pc != c {
+				bflag = 1
+			}
 			p.Pc = c
 			o = oplook(ctxt, p)
 
@@ -485,6 +518,10 @@ func span9(ctxt *obj.Link, cursym *obj.LSym) {
 				}
 			}
 
+			if p.As == obj.APCALIGN {
+				c += pcAlignPadding(p)
+				continue
+			}
 			m = int(o.size)
 			if m == 0 {
 				if p.As != obj.ANOP && p.As != obj.AFUNCDATA && p.As != obj.APCDATA && p.As != obj.AUSEFIELD {
@@ -514,6 +551,13 @@ func span9(ctxt *obj.Link, cursym *obj.LSym) {
 	for p := cursym.Text.Link; p != nil; p = p.Link {
 		ctxt.Pc = p.Pc
 		ctxt.Curp = p
+		if p.As == obj.APCALIGN {
+			for n := pcAlignPadding(p); n > 0; n -= 4 {
+				ctxt.Arch.ByteOrder.PutUint32(bp, OP_NOP)
+				bp = bp[4:]
+			}
+			continue
+		}
 		o = oplook(ctxt, p)
 		if int(o.size) > 4*len(out) {
 			log.Fatalf("out array in span9 is too small, need at least %d for %v", o.size/4, p)
@@ -526,6 +570,13 @@ func span9(ctxt *obj.Link, cursym *obj.LSym) {
 	}
 }
 
+// pcAlignPadding returns the number of bytes of NOP fill that the
+// PCALIGN instruction p needs at its current pc to align the
+// instruction following it.
+func pcAlignPadding(p *obj.Prog) int64 {
+	return -p.Pc & (p.From.Offset - 1)
+}
+
 func isint32(v int64) bool {
 	return int64(int32(v)) == v
 }
@@ -1087,6 +1138,17 @@ func buildop(ctxt *obj.Link) {
 			opset(AEXTSWCC, r0)
 			opset(ACNTLZDCC, r0)
 
+		case APOPCNTB: /* op Rs, Ra */
+
+		case ACMPB: /* byte op Rb,Rs,Ra */
+			opset(ABPERMD, r0)
+
+		case AMFVSRD: /* mfvsrd Fs,Ra */
+			opset(AMFFPRD, r0)
+
+		case AMTVSRD: /* mtvsrd Rs,Ft */
+			opset(AMTFPRD, r0)
+
 		case AFABS: /* fop [s,]d */
 			opset(AFABSCC, r0)
 
@@ -1281,6 +1343,7 @@ func buildop(ctxt *obj.Link) {
 			AWORD,
 			ADWORD,
 			obj.ANOP,
+			obj.APCALIGN,
 			obj.ATEXT,
 			obj.AUNDEF,
 			obj.AUSEFIELD,
@@ -1368,6 +1431,7 @@ const (
 	OP_MULLD  = 31<<26 | 233<<1 | 0<<10 | 0
 	OP_OR     = 31<<26 | 444<<1 | 0<<10 | 0
 	OP_ORI    = 24<<26 | 0<<1 | 0<<10 | 0
+	OP_NOP    = OP_ORI // ori 0,0,0
 	OP_ORIS   = 25<<26 | 0<<1 | 0<<10 | 0
 	OP_RLWINM = 21<<26 | 0<<1 | 0<<10 | 0
 	OP_SUBF   = 31<<26 | 40<<1 | 0<<10 | 0
@@ -1749,6 +1813,8 @@ func asmout(ctxt *obj.Link, p *obj.Prog, o *Optab, out []uint32) {
 				v &^= 03
 			}
 
+			// The linker redirects the branch through a
+			// trampoline if the target ends up out of range.
 			rel.Add = int64(v)
 			rel.Type = obj.R_CALLPOWER
 		}
@@ -2384,7 +2450,7 @@ func asmout(ctxt *obj.Link, p *obj.Prog, o *Optab, out []uint32) {
 
 	case 68: /* mfcr rD; mfocrf CRM,rD */
 		if p.From.Type == obj.TYPE_REG && REG_CR0 <= p.From.Reg && p.From.Reg <= REG_CR7 {
-			v := int32(1 << uint(7-(p.To.Reg&7)))                                 /* CR(n) */
+			v := int32(1 << uint(7-(p.From.Reg&7)))                               /* CR(n) */
 			o1 = AOP_RRR(OP_MFCR, uint32(p.To.Reg), 0, 0) | 1<<20 | uint32(v)<<12 /* new form, mfocrf */
 		} else {
 			o1 = AOP_RRR(OP_MFCR, uint32(p.To.Reg), 0, 0) /* old form, whole register */
@@ -2398,7 +2464,7 @@ func asmout(ctxt *obj.Link, p *obj.Prog, o *Optab, out []uint32) {
 			}
 			v = regoff(ctxt, p.From3) & 0xff
 		} else {
-			if p.To.Reg == 0 {
+			if p.To.Reg == 0 || p.To.Reg == REG_CR {
 				v = 0xff /* CR */
 			} else {
 				v = 1 << uint(7-(p.To.Reg&7)) /* CR(n) */
@@ -2408,22 +2474,12 @@ func asmout(ctxt *obj.Link, p *obj.Prog, o *Optab, out []uint32) {
 		o1 = AOP_RRR(OP_MTCRF, uint32(p.From.Reg), 0, 0) | uint32(v)<<12
 
 	case 70: /* [f]cmp r,r,cr*/
-		var r int
-		if p.Reg == 0 {
-			r = 0
-		} else {
-			r = (int(p.Reg) & 7) << 2
-		}
-		o1 = AOP_RRR(oprrr(ctxt, p.As), uint32(r), uint32(p.From.Reg), uint32(p.To.Reg))
+		r := crfield(ctxt, p)
+		o1 = AOP_RRR(oprrr(ctxt, p.As), r, uint32(p.From.Reg), uint32(p.To.Reg))
 
 	case 71: /* cmp[l] r,i,cr*/
-		var r int
-		if p.Reg == 0 {
-			r = 0
-		} else {
-			r = (int(p.Reg) & 7) << 2
-		}
-		o1 = AOP_RRR(opirr(ctxt, p.As), uint32(r), uint32(p.From.Reg), 0) | uint32(regoff(ctxt, &p.To))&0xffff
+		r := crfield(ctxt, p)
+		o1 = AOP_RRR(opirr(ctxt, p.As), r, uint32(p.From.Reg), 0) | uint32(regoff(ctxt, &p.To))&0xffff
 
 	case 72: /* slbmte (Rb+Rs -> slb[Rb]) -> Rs, Rb */
 		o1 = AOP_RRR(oprrr(ctxt, p.As), uint32(p.From.Reg), 0, uint32(p.To.Reg))
@@ -2510,6 +2566,12 @@ func asmout(ctxt *obj.Link, p *obj.Prog, o *Optab, out []uint32) {
 		rel.Siz = 8
 		rel.Sym = p.From.Sym
 		rel.Type = obj.R_ADDRPOWER_GOT
+
+	case 82: /* mfvsrd Fs,Ra: XX1-form with SX=0, as F0-F31 are VSR0-VSR31 */
+		o1 = AOP_RRR(oprrr(ctxt, p.As), uint32(p.From.Reg), uint32(p.To.Reg), 0)
+
+	case 83: /* mtvsrd Rs,Ft: XX1-form with TX=0 */
+		o1 = AOP_RRR(oprrr(ctxt, p.As), uint32(p.To.Reg), uint32(p.From.Reg), 0)
 	}
 
 	out[0] = o1
@@ -2688,6 +2750,18 @@ func oprrr(ctxt *obj.Link, a obj.As) uint32 {
 	case AEQVCC:
 		return OPVCC(31, 284, 0, 1)
 
+	case ACMPB:
+		return OPVCC(31, 508, 0, 0)
+	case APOPCNTB:
+		return OPVCC(31, 122, 0, 0)
+	case ABPERMD:
+		return OPVCC(31, 252, 0, 0)
+
+	case AMFVSRD, AMFFPRD:
+		return OPVCC(31, 51, 0, 0) /* mfvsrd, ISA 2.07 */
+	case AMTVSRD, AMTFPRD:
+		return OPVCC(31, 179, 0, 0) /* mtvsrd, ISA 2.07 */
+
 	case AEXTSB:
 		return OPVCC(31, 954, 0, 0)
 	case AEXTSBCC:
@@ -3042,6 +3116,20 @@ func oprrr(ctxt *obj.Link, a obj.As) uint32 {
 	return 0
 }
 
+// crfield returns the BF field of a compare instruction, which selects
+// the CR field that receives the result. The CR field is given in
+// p.Reg, and CR0 is used if p.Reg is 0.
+func crfield(ctxt *obj.Link, p *obj.Prog) uint32 {
+	if p.Reg == 0 {
+		return 0
+	}
+	if p.Reg < REG_CR0 || REG_CR7 < p.Reg {
+		ctxt.Diag("illegal CR field number: %v", p)
+		return 0
+	}
+	return (uint32(p.Reg) & 7) << 2
+}
+
 func opirr(ctxt *obj.Link, a obj.As) uint32 {
 	switch a {
 	case AADD:
@@ -446,16 +446,12 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym) {
 	}
 
 	autosize := int32(0)
-	var aoffset int
-	var mov obj.As
 	var p1 *obj.Prog
 	var p2 *obj.Prog
 	for p := cursym.Text; p != nil; p = p.Link {
 		o := p.As
 		switch o {
 		case obj.ATEXT:
-			mov = AMOVD
-			aoffset = 0
 			autosize = int32(textstksiz)
 
 			if p.Mark&LEAF != 0 && autosize == 0 && p.From3.Offset&obj.NOFRAME == 0 {
@@ -514,12 +510,52 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym) {
 				q = stacksplit(ctxt, q, autosize) // emit split check
 			}
 
+			if autosize > stackProbeSize {
+				q = stackprobe(ctxt, q, autosize) // touch each page of the frame
+			}
+
+			// The traceback of a profiling signal may start at any
+			// instruction, and for an innermost frame that is not
+			// empty according to the pcsp table it reads the return
+			// address from 0(R1). So the LR must be saved there
+			// before R1 is adjusted, or in the same instruction,
+			// even in leaf functions, which do not need it otherwise.
 			if autosize != 0 {
-				/* use MOVDU to adjust R1 when saving R31, if autosize is small */
-				if cursym.Text.Mark&LEAF == 0 && autosize >= -BIG && autosize <= BIG {
-					mov = AMOVDU
-					aoffset = int(-autosize)
+				q = obj.Appendp(ctxt, q)
+				q.As = AMOVD
+				q.Lineno = p.Lineno
+				q.From.Type = obj.TYPE_REG
+				q.From.Reg = REG_LR
+				q.To.Type = obj.TYPE_REG
+				q.To.Reg = REGTMP
+
+				if autosize >= -BIG && autosize <= BIG {
+					// MOVDU saves the LR and adjusts R1 in one instruction.
+					q = obj.Appendp(ctxt, q)
+					q.As = AMOVDU
+					q.Lineno = p.Lineno
+					q.From.Type = obj.TYPE_REG
+					q.From.Reg = REGTMP
+					q.To.Type = obj.TYPE_MEM
+					q.To.Offset = int64(-autosize)
+					q.To.Reg = REGSP
+					q.Spadj = +autosize
 				} else {
+					// The frame is too large for MOVDU: save the LR below
+					// the current frame, then allocate the frame. The
+					// store synthesizes its offset in REGTMP, so the LR
+					// has to be moved out of REGTMP first.
+					q.To.Reg = REG_R29
+
+					q = obj.Appendp(ctxt, q)
+					q.As = AMOVD
+					q.Lineno = p.Lineno
+					q.From.Type = obj.TYPE_REG
+					q.From.Reg = REG_R29
+					q.To.Type = obj.TYPE_MEM
+					q.To.Offset = int64(-autosize)
+					q.To.Reg = REGSP
+
 					q = obj.Appendp(ctxt, q)
 					q.As = AADD
 					q.Lineno = p.Lineno
@@ -541,26 +577,6 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym) {
 				break
 			}
 
-			q = obj.Appendp(ctxt, q)
-			q.As = AMOVD
-			q.Lineno = p.Lineno
-			q.From.Type = obj.TYPE_REG
-			q.From.Reg = REG_LR
-			q.To.Type = obj.TYPE_REG
-			q.To.Reg = REGTMP
-
-			q = obj.Appendp(ctxt, q)
-			q.As = mov
-			q.Lineno = p.Lineno
-			q.From.Type = obj.TYPE_REG
-			q.From.Reg = REGTMP
-			q.To.Type = obj.TYPE_MEM
-			q.To.Offset = int64(aoffset)
-			q.To.Reg = REGSP
-			if q.As == AMOVDU {
-				q.Spadj = int32(-aoffset)
-			}
-
 			if ctxt.Flag_shared {
 				q = obj.Appendp(ctxt, q)
 				q.As = AMOVD
@@ -823,6 +839,94 @@ func preprocess(ctxt *obj.Link, cursym *obj.LSym) {
 		q = p;
 	}
 */
+const (
+	// stackProbeSize is the distance between stack probes. It is the
+	// smallest page size, and so the smallest guard region that a frame
+	// must not be able to skip over.
+	stackProbeSize = 4096
+
+	// stackProbeUnroll is the largest number of probes emitted as
+	// straight-line code. The offsets of the unrolled probes must fit
+	// in a load/store displacement.
+	stackProbeUnroll = 4
+)
+
+// stackprobe emits code after p that stores to every page between SP
+// and SP-framesize, from the top down, so that the frame cannot extend
+// past a guard page without faulting on it. SP itself is not changed
+// until the frame is allocated, so the sequence needs no stack pointer
+// adjustments and unwinding is correct at every instruction. It only
+// uses REGTMP and CTR, which are not live at function entry.
+func stackprobe(ctxt *obj.Link, p *obj.Prog, framesize int32) *obj.Prog {
+	n := int64(framesize / stackProbeSize)
+	if n <= stackProbeUnroll {
+		//	MOVD	R0, -stackProbeSize*i(SP)	for i = 1..n
+		for i := int64(1); i <= n; i++ {
+			p = obj.Appendp(ctxt, p)
+			p.As = AMOVD
+			p.From.Type = obj.TYPE_REG
+			p.From.Reg = REGZERO
+			p.To.Type = obj.TYPE_MEM
+			p.To.Reg = REGSP
+			p.To.Offset = -stackProbeSize * i
+		}
+		return p
+	}
+
+	//	MOVD	$n, R31
+	//	MOVD	R31, CTR
+	//	MOVD	SP, R31
+	// loop:
+	//	ADD	$-stackProbeSize, R31
+	//	MOVD	R0, (R31)
+	//	BC	16, 0, loop	// decrement CTR, loop if not zero
+	p = obj.Appendp(ctxt, p)
+	p.As = AMOVD
+	p.From.Type = obj.TYPE_CONST
+	p.From.Offset = n
+	p.To.Type = obj.TYPE_REG
+	p.To.Reg = REGTMP
+
+	p = obj.Appendp(ctxt, p)
+	p.As = AMOVD
+	p.From.Type = obj.TYPE_REG
+	p.From.Reg = REGTMP
+	p.To.Type = obj.TYPE_REG
+	p.To.Reg = REG_CTR
+
+	p = obj.Appendp(ctxt, p)
+	p.As = AMOVD
+	p.From.Type = obj.TYPE_REG
+	p.From.Reg = REGSP
+	p.To.Type = obj.TYPE_REG
+	p.To.Reg = REGTMP
+
+	p = obj.Appendp(ctxt, p)
+	loop := p
+	p.As = AADD
+	p.From.Type = obj.TYPE_CONST
+	p.From.Offset = -stackProbeSize
+	p.To.Type = obj.TYPE_REG
+	p.To.Reg = REGTMP
+
+	p = obj.Appendp(ctxt, p)
+	p.As = AMOVD
+	p.From.Type = obj.TYPE_REG
+	p.From.Reg = REGZERO
+	p.To.Type = obj.TYPE_MEM
+	p.To.Reg = REGTMP
+
+	p = obj.Appendp(ctxt, p)
+	p.As = ABC
+	p.From.Type = obj.TYPE_CONST
+	p.From.Offset = 16
+	p.Reg = REG_R0
+	p.To.Type = obj.TYPE_BRANCH
+	p.Pcond = loop
+
+	return p
+}
+
 func stacksplit(ctxt *obj.Link, p *obj.Prog, framesize int32) *obj.Prog {
 	// MOVD	g_stackguard(g), R3
 	p = obj.Appendp(ctxt, p)
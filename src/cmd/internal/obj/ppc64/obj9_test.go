@@ -0,0 +1,454 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ppc64_test
+
+import (
+	"bufio"
+	"bytes"
+	"cmd/internal/goobj"
+	"encoding/binary"
+	"go/build"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const probeTestdata = `
+#include "textflag.h"
+
+TEXT ·page(SB),$4064-0
+	RET
+TEXT ·unrolled(SB),$12288-0
+	RET
+TEXT ·loop(SB),$4194304-0
+	RET
+TEXT ·nosplit(SB),NOSPLIT,$8192-0
+	RET
+`
+
+// The expected probe instructions of each function in probeTestdata.
+var probeTests = map[string][]string{
+	// A frame that fits in one page needs no probes.
+	"page": nil,
+	"unrolled": {
+		"MOVD R0, -4096(R1)",
+		"MOVD R0, -8192(R1)",
+		"MOVD R0, -12288(R1)",
+	},
+	"loop": {
+		"MOVD $1024, R31",
+		"MOVD R31, CTR",
+		"MOVD R1, R31",
+		"ADD $-4096, R31",
+		"MOVD R0, (R31)",
+		"BC $16, R0,",
+	},
+	"nosplit": {
+		"MOVD R0, -4096(R1)",
+		"MOVD R0, -8192(R1)",
+	},
+}
+
+func asmOutput(t *testing.T, s string) []byte {
+	listing, _ := assemble(t, s)
+	return listing
+}
+
+// assemble assembles s for ppc64le and returns the assembler listing
+// and the object file.
+func assemble(t *testing.T, s string) (listing, object []byte) {
+	tmpdir, err := ioutil.TempDir("", "stackprobetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	tmpfile := filepath.Join(tmpdir, "input.s")
+	if err := ioutil.WriteFile(tmpfile, []byte(s), 0666); err != nil {
+		t.Fatal(err)
+	}
+	gofolder := filepath.Join(build.Default.GOROOT, "bin")
+	if gobin := os.Getenv("GOBIN"); len(gobin) != 0 {
+		gofolder = gobin
+	}
+
+	cmd := exec.Command(
+		filepath.Join(gofolder, "go"), "tool", "asm", "-S",
+		"-I", filepath.Join(build.Default.GOROOT, "pkg", "include"),
+		"-o", filepath.Join(tmpdir, "output.9"), tmpfile)
+
+	var env []string
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "GOARCH=") {
+			env = append(env, v)
+		}
+	}
+	cmd.Env = append(env, "GOARCH=ppc64le")
+	asmout, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("error %s output %s", err, asmout)
+	}
+	object, err = ioutil.ReadFile(filepath.Join(tmpdir, "output.9"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return asmout, object
+}
+
+// parseProbes returns the stack probe instructions of each function in
+// the assembler listing: the instructions between the end of the stack
+// split check and the frame allocation.
+func parseProbes(asmout []byte) map[string][]string {
+	probes := make(map[string][]string)
+	var fn string
+	inProbes := false
+	scanner := bufio.NewScanner(bytes.NewReader(asmout))
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 || parts[0] != "" {
+			continue
+		}
+		insn := strings.Join(strings.Fields(parts[2]), " ")
+		switch {
+		case strings.HasPrefix(insn, "TEXT "):
+			fn = insn[strings.Index(insn, ".")+1 : strings.Index(insn, "(")]
+			probes[fn] = nil
+			// NOSPLIT functions have no split check to skip.
+			inProbes = strings.Contains(insn, "), 4,")
+		case insn == "NOP":
+			// The end of the stack split check.
+			inProbes = true
+		case strings.HasPrefix(insn, "MOVD LR, "):
+			// The frame allocation, which starts by saving the LR.
+			inProbes = false
+		case inProbes:
+			probes[fn] = append(probes[fn], insn)
+		}
+	}
+	return probes
+}
+
+func TestStackProbes(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	if os.Getenv("GOHOSTARCH") != "" {
+		t.Skip("skipping when GOHOSTARCH is set")
+	}
+
+	probes := parseProbes(asmOutput(t, probeTestdata))
+	for fn, want := range probeTests {
+		got, ok := probes[fn]
+		if !ok {
+			t.Errorf("%s: function not found in assembler output", fn)
+			continue
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got probes %q, want %q", fn, got, want)
+			continue
+		}
+		for i := range want {
+			if !strings.HasPrefix(got[i], want[i]) {
+				t.Errorf("%s: got probes %q, want %q", fn, got, want)
+				break
+			}
+		}
+	}
+}
+
+const pcAlignTestdata = `
+#include "textflag.h"
+
+TEXT ·align16(SB),NOSPLIT,$0-0
+	MOVD	$10, R3
+	PCALIGN	$16
+loop:
+	ADD	$-1, R3
+	CMP	R3, $0
+	BNE	loop
+	RET
+TEXT ·align32(SB),NOSPLIT,$0-0
+	MOVD	$10, R3
+	PCALIGN	$32
+loop:
+	ADD	$-1, R3
+	CMP	R3, $0
+	BNE	loop
+	RET
+TEXT ·aligned(SB),NOSPLIT,$0-0
+	MOVD	$10, R3
+	MOVD	$10, R4
+	MOVD	$10, R5
+	MOVD	$10, R6
+	PCALIGN	$16
+	RET
+`
+
+// The layout of each function in pcAlignTestdata: the instructions
+// before PCALIGN end at code, the NOP fill runs up to head, and head
+// holds the instruction following PCALIGN, little endian.
+var pcAlignTests = []struct {
+	fn         string
+	code, head int
+	insn       []byte
+}{
+	{"align16", 4, 16, []byte{0xff, 0xff, 0x63, 0x38}}, // ADD $-1, R3
+	{"align32", 4, 32, []byte{0xff, 0xff, 0x63, 0x38}},
+	{"aligned", 16, 16, []byte{0x20, 0x00, 0x80, 0x4e}}, // RET
+}
+
+// parseSymBytes returns the bytes of each function in the assembler listing.
+func parseSymBytes(asmout []byte) map[string][]byte {
+	syms := make(map[string][]byte)
+	var fn string
+	scanner := bufio.NewScanner(bytes.NewReader(asmout))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "\t") {
+			// The header of a symbol: "".name t=1 ...
+			fn = strings.TrimPrefix(strings.Fields(line)[0], `"".`)
+			continue
+		}
+		// Lines of bytes have no tab after the offset.
+		fields := strings.Fields(line)
+		if strings.Contains(line[1:], "\t") || len(fields) < 2 {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if len(f) != 2 {
+				break
+			}
+			b, err := strconv.ParseUint(f, 16, 8)
+			if err != nil {
+				break
+			}
+			syms[fn] = append(syms[fn], byte(b))
+		}
+	}
+	return syms
+}
+
+func TestPCAlign(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	if os.Getenv("GOHOSTARCH") != "" {
+		t.Skip("skipping when GOHOSTARCH is set")
+	}
+
+	nop := []byte{0x00, 0x00, 0x00, 0x60} // ori 0,0,0
+	syms := parseSymBytes(asmOutput(t, pcAlignTestdata))
+	for _, tt := range pcAlignTests {
+		b, ok := syms[tt.fn]
+		if !ok {
+			t.Errorf("%s: function not found in assembler output", tt.fn)
+			continue
+		}
+		if len(b) < tt.head+4 {
+			t.Errorf("%s: got %d bytes, want at least %d", tt.fn, len(b), tt.head+4)
+			continue
+		}
+		for off := tt.code; off < tt.head; off += 4 {
+			if !bytes.Equal(b[off:off+4], nop) {
+				t.Errorf("%s: got % x at %#x, want NOP", tt.fn, b[off:off+4], off)
+			}
+		}
+		if !bytes.Equal(b[tt.head:tt.head+4], tt.insn) {
+			t.Errorf("%s: got % x at %#x, want % x", tt.fn, b[tt.head:tt.head+4], tt.head, tt.insn)
+		}
+	}
+}
+
+const frameTestdata = `
+#include "textflag.h"
+
+TEXT ·leaf(SB),NOSPLIT,$16-0
+	MOVD	R3, 8(R1)
+	RET
+TEXT ·small(SB),$16-0
+	CALL	·leaf(SB)
+	RET
+TEXT ·bigleaf(SB),$40000-0
+	MOVD	R3, 8(R1)
+	RET
+TEXT ·big(SB),$40000-0
+	CALL	·leaf(SB)
+	RET
+TEXT ·huge(SB),$4194304-0
+	CALL	·leaf(SB)
+	RET
+TEXT ·noframe(SB),NOSPLIT|NOFRAME,$0-0
+	RET
+`
+
+// pcValues decodes the pc-value table tab of a function
+// into the value at each instruction.
+func pcValues(tab []byte) map[int]int32 {
+	const quantum = 4
+	vals := make(map[int]int32)
+	val := int32(-1)
+	pc := 0
+	for first := true; len(tab) > 0; first = false {
+		uv, n := binary.Uvarint(tab)
+		tab = tab[n:]
+		if uv == 0 && !first {
+			break
+		}
+		val += int32(uv>>1) ^ -int32(uv&1)
+		pcdelta, n := binary.Uvarint(tab)
+		tab = tab[n:]
+		for i := 0; i < int(pcdelta); i++ {
+			vals[pc] = val
+			pc += quantum
+		}
+	}
+	return vals
+}
+
+// frameInsn is an instruction of the assembler listing.
+type frameInsn struct {
+	pc, size int
+	op       string
+	args     []string
+}
+
+func (in frameInsn) String() string {
+	return in.op + " " + strings.Join(in.args, ", ")
+}
+
+// parseInsns returns the instructions of each function in the assembler
+// listing, omitting the pseudo-instructions that occupy no space.
+func parseInsns(t *testing.T, asmout []byte) map[string][]frameInsn {
+	insns := make(map[string][]frameInsn)
+	var fn string
+	scanner := bufio.NewScanner(bytes.NewReader(asmout))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "\t") {
+			fn = strings.TrimPrefix(strings.Fields(line)[0], `"".`)
+			continue
+		}
+		// pc, line, instruction
+		parts := strings.SplitN(line[1:], "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		pc, err := strconv.ParseInt(strings.Fields(parts[0])[0], 0, 64)
+		if err != nil {
+			t.Fatalf("bad listing line %q", line)
+		}
+		list := insns[fn]
+		if n := len(list); n > 0 {
+			list[n-1].size = int(pc) - list[n-1].pc
+		}
+		args := strings.Split(parts[2], ", ")
+		list = append(list, frameInsn{pc: int(pc), op: parts[1], args: args})
+		insns[fn] = list
+	}
+	for fn, list := range insns {
+		// The size of the last instruction is the rest of the function.
+		var keep []frameInsn
+		for _, in := range list {
+			if in.op == "JMP" && in.args[0] == "LR" {
+				in.size = 4
+			}
+			if in.size > 0 {
+				keep = append(keep, in)
+			}
+		}
+		insns[fn] = keep
+	}
+	return insns
+}
+
+// memOffset returns the offset of a memory operand based on R1.
+func memOffset(arg string) (int, bool) {
+	if !strings.HasSuffix(arg, "(R1)") {
+		return 0, false
+	}
+	off := strings.TrimSuffix(arg, "(R1)")
+	if off == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(off)
+	return n, err == nil
+}
+
+// TestFrameUnwind checks that a traceback starting at any instruction of
+// a function can unwind it: the pcsp table must give the size of the
+// frame actually allocated, including within the instruction sequences
+// that synthesize large constants, and once there is a frame its return
+// address must be saved at 0(R1), where the runtime looks for it.
+func TestFrameUnwind(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	if os.Getenv("GOHOSTARCH") != "" {
+		t.Skip("skipping when GOHOSTARCH is set")
+	}
+
+	listing, object := assemble(t, frameTestdata)
+	pkg, err := goobj.Parse(bytes.NewReader(object), "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcsp := make(map[string]map[int]int32)
+	for _, s := range pkg.Syms {
+		if s.Func != nil {
+			d := s.Func.PCSP
+			pcsp[strings.TrimPrefix(s.Name, "p.")] = pcValues(object[d.Offset : d.Offset+d.Size])
+		}
+	}
+
+	for fn, insns := range parseInsns(t, listing) {
+		tab, ok := pcsp[fn]
+		if !ok {
+			t.Errorf("%s: no pcsp table", fn)
+			continue
+		}
+		sp := 0                 // frame size
+		lr := map[string]bool{} // registers holding the LR
+		saved := map[int]bool{} // frame sizes whose 0(R1) holds the LR
+		for _, in := range insns {
+			for pc := in.pc; pc < in.pc+in.size; pc += 4 {
+				if got := tab[pc]; int(got) != sp {
+					t.Errorf("%s: pcsp at %#x (%v) is %d, want %d", fn, pc, in, got, sp)
+				}
+				if sp != 0 && !saved[sp] {
+					t.Errorf("%s: LR not saved at %#x (%v) with frame %d", fn, pc, in, sp)
+				}
+			}
+			dst := in.args[len(in.args)-1]
+			switch {
+			case in.op == "MOVD" && in.args[0] == "LR":
+				lr[dst] = true
+				continue
+			case in.op == "ADD" && len(in.args) == 2 && dst == "R1":
+				c, err := strconv.Atoi(strings.TrimPrefix(in.args[0], "$"))
+				if err != nil {
+					t.Fatalf("%s: bad instruction %v", fn, in)
+				}
+				sp -= c
+			case in.op == "MOVD" || in.op == "MOVDU":
+				off, ok := memOffset(dst)
+				if !ok {
+					break
+				}
+				if lr[in.args[0]] {
+					saved[sp-off] = true
+				}
+				if in.op == "MOVDU" {
+					sp -= off
+				}
+			}
+			delete(lr, dst)
+		}
+		if sp != 0 {
+			t.Errorf("%s: frame %d at return", fn, sp)
+		}
+	}
+}
@@ -57,6 +57,21 @@ func Getgoarm() int32 {
 	panic("unreachable")
 }
 
+// Getgoppc64 returns the POWER processor level, 5 or 8, for which to
+// compile on ppc64 and ppc64le. Without GOPPC64, it is 5: the POWER8
+// code has not yet been tested on a ppc64le builder.
+func Getgoppc64() int {
+	switch v := envOr("GOPPC64", defaultGOPPC64); v {
+	case "", "power5":
+		return 5
+	case "power8":
+		return 8
+	}
+	// Fail here, rather than validate at multiple call sites.
+	log.Fatalf("Invalid GOPPC64 value. Must be power5 or power8.")
+	panic("unreachable")
+}
+
 func Getgo386() string {
 	// Validated by cmd/compile.
 	return envOr("GO386", defaultGO386)
@@ -480,6 +495,7 @@ var Anames = []string{
 	"GLOBL",
 	"JMP",
 	"NOP",
+	"PCALIGN",
 	"PCDATA",
 	"RET",
 	"TEXT",
@@ -1920,30 +1920,112 @@ func textaddress() {
 	va := uint64(INITTEXT)
 	sect.Vaddr = va
 	for _, sym := range Ctxt.Textp {
-		sym.Sect = sect
-		if sym.Type&obj.SSUB != 0 {
-			continue
+		va = assigntextaddress(sect, sym, va, false)
+	}
+
+	if Thearch.Trampoline != nil {
+		// Every function now has a tentative address. Lay the
+		// text out again, giving the architecture a chance to
+		// redirect calls whose targets are out of range of a direct
+		// branch through trampolines, which are placed right after
+		// the calling function.
+		va = sect.Vaddr
+		ntramps := 0
+		for _, sym := range Ctxt.Textp {
+			va = assigntextaddress(sect, sym, va, true)
+			trampoline(sym)
+			for ; ntramps < len(Ctxt.Tramps); ntramps++ {
+				va = assigntextaddress(sect, Ctxt.Tramps[ntramps], va, false)
+			}
 		}
-		if sym.Align != 0 {
-			va = uint64(Rnd(int64(va), int64(sym.Align)))
-		} else {
-			va = uint64(Rnd(int64(va), int64(Funcalign)))
+
+		// Merge the trampolines into Textp, which must stay sorted
+		// by address.
+		if ntramps != 0 {
+			textp := make([]*LSym, 0, len(Ctxt.Textp)+ntramps)
+			i := 0
+			for _, sym := range Ctxt.Textp {
+				for ; i < ntramps && Ctxt.Tramps[i].Value < sym.Value; i++ {
+					textp = append(textp, Ctxt.Tramps[i])
+				}
+				textp = append(textp, sym)
+			}
+			textp = append(textp, Ctxt.Tramps[i:]...)
+			Ctxt.Textp = textp
 		}
-		sym.Value = 0
-		for sub := sym; sub != nil; sub = sub.Sub {
-			sub.Value += int64(va)
+	}
+
+	sect.Length = va - sect.Vaddr
+}
+
+// assigntextaddress assigns the address va, suitably aligned, to the
+// text symbol sym and its subsymbols in section sect and returns the
+// address following sym. If again is set, sym has already been
+// assigned an address, which is replaced.
+func assigntextaddress(sect *Section, sym *LSym, va uint64, again bool) uint64 {
+	sym.Sect = sect
+	if sym.Type&obj.SSUB != 0 {
+		return va
+	}
+	if sym.Align != 0 {
+		va = uint64(Rnd(int64(va), int64(sym.Align)))
+		if sym.Align > sect.Align {
+			sect.Align = sym.Align
 		}
-		if sym.Size == 0 && sym.Sub != nil {
-			Ctxt.Cursym = sym
+	} else {
+		va = uint64(Rnd(int64(va), int64(Funcalign)))
+	}
+	if again {
+		// Make the subsymbol values relative to sym again.
+		for sub := sym.Sub; sub != nil; sub = sub.Sub {
+			sub.Value -= sym.Value
 		}
-		if sym.Size < MINFUNC {
-			va += MINFUNC // spacing required for findfunctab
-		} else {
-			va += uint64(sym.Size)
+	}
+	sym.Value = 0
+	for sub := sym; sub != nil; sub = sub.Sub {
+		sub.Value += int64(va)
+	}
+	if sym.Size == 0 && sym.Sub != nil {
+		Ctxt.Cursym = sym
+	}
+	if sym.Size < MINFUNC {
+		va += MINFUNC // spacing required for findfunctab
+	} else {
+		va += uint64(sym.Size)
+	}
+	return va
+}
+
+// trampoline calls Thearch.Trampoline for each direct call or jump in
+// the text symbol s to a target that is laid out in the text section.
+// Calls to dynamic imports and host object code are left to the PLT
+// and the external linker.
+func trampoline(s *LSym) {
+	for ri := range s.R {
+		r := &s.R[ri]
+		switch r.Type {
+		case obj.R_CALL, obj.R_CALLARM, obj.R_CALLARM64, obj.R_CALLPOWER, obj.R_CALLMIPS:
+		default:
+			continue
+		}
+		if r.Sym == nil || r.Sym.Type&obj.SMASK != obj.STEXT || r.Sym.Sect != s.Sect {
+			continue
 		}
+		Thearch.Trampoline(r, s)
 	}
+}
 
-	sect.Length = va - sect.Vaddr
+// AddTramp adds the trampoline tramp, whose contents must be
+// complete, to the text being laid out. It is called by
+// Thearch.Trampoline and is placed right after the calling function.
+func AddTramp(tramp *LSym) {
+	tramp.Type = obj.STEXT
+	tramp.Attr |= AttrReachable
+	tramp.Attr |= AttrOnList
+	Ctxt.Tramps = append(Ctxt.Tramps, tramp)
+	if Debug['v'] != 0 {
+		fmt.Fprintf(Bso, "%5.2f trampoline %s inserted\n", obj.Cputime(), tramp.Name)
+	}
 }
 
 // assign addresses
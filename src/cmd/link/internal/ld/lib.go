@@ -105,6 +105,7 @@ type Arch struct {
 	Gentext          func()
 	Machoreloc1      func(*Reloc, int64) int
 	PEreloc1         func(*Reloc, int64) bool
+	Trampoline       func(*Reloc, *LSym)
 	Wput             func(uint16)
 	Lput             func(uint32)
 	Vput             func(uint64)
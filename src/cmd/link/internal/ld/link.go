@@ -179,6 +179,7 @@ type Link struct {
 	Cursym     *LSym
 	Version    int
 	Textp      []*LSym
+	Tramps     []*LSym // trampolines added by Thearch.Trampoline
 	Filesyms   []*LSym
 	Moduledata *LSym
 	LSymBatch  []LSym
@@ -70,6 +70,7 @@ package ld
 //		1<<0 leaf
 //		1<<1 C function
 //		1<<2 function may call reflect.Type.Method
+//		1<<3 and up: alignment of the code, if not the default
 //	- nlocal [int]
 //	- local [nlocal automatics]
 //	- pcln [pcln table]
@@ -345,6 +346,9 @@ overwrite:
 		if flags&(1<<2) != 0 {
 			s.Attr |= AttrReflectMethod
 		}
+		if align := int32(flags >> 3); align != 0 {
+			s.Align = align
+		}
 		n := r.readInt()
 		pc.Autom = r.autom[:n:n]
 		if !isdup {
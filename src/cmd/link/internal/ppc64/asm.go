@@ -123,6 +123,73 @@ func genplt() {
 	}
 }
 
+// trampSlack is the distance by which a call must be in range of its
+// target for trampoline to leave it alone. The target of a forward call
+// has not been laid out yet and may move up by the size of the
+// trampolines inserted before it.
+const trampSlack = 1 << 20
+
+// trampoline redirects the call r in s through a trampoline if its
+// target is out of range of the 24-bit word displacement of a direct
+// branch. Calls to the same target from nearby functions share a
+// trampoline.
+func trampoline(r *ld.Reloc, s *ld.LSym) {
+	if r.Type != obj.R_CALLPOWER {
+		return
+	}
+	if ld.DynlinkingGo() || ld.Buildmode == ld.BuildmodeCArchive || ld.Buildmode == ld.BuildmodeCShared || ld.Buildmode == ld.BuildmodePIE {
+		// The TOC pointer is maintained in R2 in these modes,
+		// and the external linker inserts its own long branch
+		// stubs.
+		return
+	}
+	t := ld.Symaddr(r.Sym) + r.Add - (s.Value + int64(r.Off))
+	if -(1<<25)+trampSlack <= t && t < 1<<25-trampSlack {
+		return
+	}
+
+	var tramp *ld.LSym
+	for i := 0; ; i++ {
+		// The offset is part of the name: calls to duffzero+8
+		// and duffzero+256 need distinct trampolines.
+		name := fmt.Sprintf("%s-tramp%d", r.Sym.Name, i)
+		if r.Add != 0 {
+			name = fmt.Sprintf("%s%+x-tramp%d", r.Sym.Name, r.Add, i)
+		}
+		tramp = ld.Linklookup(ld.Ctxt, name, int(r.Sym.Version))
+		if tramp.Type == 0 {
+			gentramp(tramp, r.Sym, r.Add)
+			ld.AddTramp(tramp)
+			break
+		}
+		// Trampolines are laid out as they are created,
+		// so the distance to an existing one is exact.
+		t = ld.Symaddr(tramp) - (s.Value + int64(r.Off))
+		if int64(int32(t<<6)>>6) == t {
+			break
+		}
+	}
+	r.Sym = tramp
+	r.Add = 0 // folded into the trampoline
+}
+
+// gentramp generates in tramp a trampoline that jumps to target+offset
+// by loading the absolute address into CTR. It clobbers R31 (REGTMP)
+// and CTR, which are not live across a call, and leaves LR alone, so a
+// bl to the trampoline returns to the original caller.
+func gentramp(tramp, target *ld.LSym, offset int64) {
+	r := ld.Addrel(tramp)
+	r.Off = 0
+	r.Siz = 8
+	r.Sym = target
+	r.Add = offset
+	r.Type = obj.R_ADDRPOWER
+	ld.Adduint32(ld.Ctxt, tramp, 0x3fe00000) // lis r31,target@ha
+	ld.Adduint32(ld.Ctxt, tramp, 0x3bff0000) // addi r31,r31,target@l
+	ld.Adduint32(ld.Ctxt, tramp, 0x7fe903a6) // mtctr r31
+	ld.Adduint32(ld.Ctxt, tramp, 0x4e800420) // bctr
+}
+
 func genaddmoduledata() {
 	addmoduledata := ld.Linkrlookup(ld.Ctxt, "runtime.addmoduledata", 0)
 	if addmoduledata.Type == obj.STEXT {
@@ -578,9 +645,8 @@ func archreloc(r *ld.Reloc, s *ld.LSym, val *int64) int {
 			ld.Ctxt.Diag("relocation for %s+%d is not aligned: %d", r.Sym.Name, r.Off, t)
 		}
 		if int64(int32(t<<6)>>6) != t {
-			// TODO(austin) This can happen if text > 32M.
-			// Add a call trampoline to .text in that case.
-			ld.Ctxt.Diag("relocation for %s+%d is too big: %d", r.Sym.Name, r.Off, t)
+			// trampoline should have redirected this call.
+			ld.Ctxt.Diag("call from %s+%d to %s is out of range of a direct branch: %d", s.Name, r.Off, r.Sym.Name, t)
 		}
 
 		*val |= int64(uint32(t) &^ 0xfc000003)
@@ -0,0 +1,95 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestLargeText links a program whose text is larger than the range of
+// a direct branch on ppc64, so that calls between its ends must go
+// through trampolines inserted by the linker, and checks that the
+// program runs. On other systems the program is only linked, for
+// ppc64le.
+func TestLargeText(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	testenv.MustHaveGoBuild(t)
+
+	tmpdir, err := ioutil.TempDir("", "bigtext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// The filler functions are 12MB each, so calls from main past
+	// them into the runtime are out of range of the ±32MB of a
+	// direct branch. They are linked in but never run.
+	const nfn = 4
+	var w bytes.Buffer
+	for i := 0; i < nfn; i++ {
+		w.Reset()
+		fmt.Fprintf(&w, "#include \"textflag.h\"\n\n")
+		fmt.Fprintf(&w, "TEXT ·bigfn%d(SB),NOSPLIT,$0\n", i)
+		for j := 0; j < 3<<20; j++ {
+			fmt.Fprintf(&w, "\tMOVD\tR0, R3\n")
+		}
+		fmt.Fprintf(&w, "\tRET\n")
+		if err := ioutil.WriteFile(filepath.Join(tmpdir, fmt.Sprintf("bigfn%d_ppc64x.s", i)), w.Bytes(), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Reset()
+	fmt.Fprintf(&w, "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\n")
+	for i := 0; i < nfn; i++ {
+		fmt.Fprintf(&w, "func bigfn%d()\n", i)
+	}
+	fmt.Fprintf(&w, "\nfunc main() {\n")
+	fmt.Fprintf(&w, "\tif os.Getenv(\"LINKTESTARG\") != \"\" {\n")
+	for i := 0; i < nfn; i++ {
+		fmt.Fprintf(&w, "\t\tbigfn%d()\n", i)
+	}
+	fmt.Fprintf(&w, "\t}\n")
+	fmt.Fprintf(&w, "\tfmt.Println(\"PASS\")\n}\n")
+	if err := ioutil.WriteFile(filepath.Join(tmpdir, "bigtext.go"), w.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	native := runtime.GOARCH == "ppc64" || runtime.GOARCH == "ppc64le"
+	linkmodes := []string{"internal"}
+	if native && runtime.GOOS == "linux" {
+		if _, err := exec.LookPath("gcc"); err == nil {
+			linkmodes = append(linkmodes, "external")
+		}
+	}
+	for _, linkmode := range linkmodes {
+		exe := filepath.Join(tmpdir, "bigtext-"+linkmode)
+		cmd := exec.Command(testenv.GoToolPath(t), "build", "-ldflags=-linkmode="+linkmode, "-o", exe)
+		cmd.Dir = tmpdir
+		if !native {
+			cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH=ppc64le", "CGO_ENABLED=0")
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("building with -linkmode=%s failed: %v\n%s", linkmode, err, out)
+		}
+		if !native {
+			continue
+		}
+		out, err := exec.Command(exe).CombinedOutput()
+		if err != nil || strings.TrimSpace(string(out)) != "PASS" {
+			t.Errorf("running with -linkmode=%s: %v\n%s", linkmode, err, out)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Statistical attribution of heap allocation to goroutines.
+
+package main
+
+import (
+	"internal/trace"
+	"sort"
+	"sync"
+)
+
+// allocAnalysis is an estimate of the bytes allocated by each goroutine.
+//
+// The trace does not record individual allocations. It records the
+// size of the live heap (EvHeapAlloc) when it changes. allocEstimates
+// takes the growth of the heap between two consecutive HeapAlloc
+// events and distributes it across the goroutines that ran in that
+// interval, in proportion to their execution time in the interval.
+//
+// The estimate is exact when a single goroutine runs in each interval.
+// Otherwise it assumes that the goroutines that ran in an interval
+// allocated at the same rate, and its error is the difference between
+// their actual allocation rates: a goroutine that allocates nothing
+// while it shares an interval with one that allocates is charged part
+// of the other's allocation. Over many intervals with different sets
+// of goroutines the errors average out, so the estimate is best for
+// groups of goroutines and for long traces. It is also affected by
+//
+//   - the granularity of the heap counter: the runtime accounts for
+//     small allocations when an mcache obtains a span, not when the
+//     objects in the span are allocated, so the interval in which the
+//     span is obtained is charged for the objects allocated from it
+//     later, possibly by other goroutines on the same P;
+//   - garbage collection: the heap size drops at the end of a cycle,
+//     and the growth in the interval that contains the drop is not
+//     known, so it is not attributed to anyone.
+type allocAnalysis struct {
+	// Bytes is the estimated number of bytes allocated, by goroutine.
+	Bytes map[uint64]uint64
+
+	// Unattributed is the heap growth in intervals in which
+	// no goroutine ran, such as the runtime's own allocations.
+	Unattributed uint64
+}
+
+// allocEstimates estimates the bytes allocated by each goroutine in
+// events. See allocAnalysis for the method and its accuracy.
+func allocEstimates(events []*trace.Event) *allocAnalysis {
+	a := &allocAnalysis{Bytes: make(map[uint64]uint64)}
+	type running struct {
+		g     uint64
+		since int64
+	}
+	ps := make(map[int]*running)   // goroutine running on each P
+	exec := make(map[uint64]int64) // execution time in the current interval
+	credit := func(r *running, ts int64) {
+		exec[r.g] += ts - r.since
+		r.since = ts
+	}
+	stop := func(ev *trace.Event) {
+		if r := ps[ev.P]; r != nil {
+			credit(r, ev.Ts)
+			delete(ps, ev.P)
+		}
+	}
+	var heap uint64
+	haveHeap := false
+	for _, ev := range events {
+		switch ev.Type {
+		case trace.EvGoStart:
+			stop(ev)
+			ps[ev.P] = &running{g: ev.G, since: ev.Ts}
+		case trace.EvProcStop:
+			stop(ev)
+		case trace.EvGoEnd, trace.EvGoStop, trace.EvGoSched, trace.EvGoPreempt,
+			trace.EvGoSleep, trace.EvGoBlock, trace.EvGoBlockSend, trace.EvGoBlockRecv,
+			trace.EvGoBlockSelect, trace.EvGoBlockSync, trace.EvGoBlockCond, trace.EvGoBlockNet,
+			trace.EvGoSysBlock:
+			if ev.P < trace.FakeP {
+				stop(ev)
+			}
+		case trace.EvHeapAlloc:
+			for _, r := range ps {
+				credit(r, ev.Ts)
+			}
+			if haveHeap && ev.Args[0] > heap {
+				a.distribute(ev.Args[0]-heap, exec, ev.G)
+			}
+			heap, haveHeap = ev.Args[0], true
+			exec = make(map[uint64]int64)
+		}
+	}
+	return a
+}
+
+// distribute attributes n bytes to the goroutines in exec in proportion
+// to their execution time. If no goroutine ran, the bytes go to g,
+// the goroutine that reported the heap change, if any.
+func (a *allocAnalysis) distribute(n uint64, exec map[uint64]int64, g uint64) {
+	var total int64
+	var gs []uint64
+	for g1, t := range exec {
+		if g1 != 0 && t > 0 {
+			total += t
+			gs = append(gs, g1)
+		}
+	}
+	if total == 0 {
+		if g == 0 {
+			a.Unattributed += n
+		} else {
+			a.Bytes[g] += n
+		}
+		return
+	}
+	// Assign the shares by cumulative execution time so that
+	// rounding never loses or invents bytes.
+	sort.Sort(uint64Slice(gs))
+	var cum int64
+	var assigned uint64
+	for _, g1 := range gs {
+		cum += exec[g1]
+		upto := uint64(float64(n) * float64(cum) / float64(total))
+		if cum == total {
+			upto = n
+		}
+		a.Bytes[g1] += upto - assigned
+		assigned = upto
+	}
+}
+
+type uint64Slice []uint64
+
+func (x uint64Slice) Len() int           { return len(x) }
+func (x uint64Slice) Less(i, j int) bool { return x[i] < x[j] }
+func (x uint64Slice) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+var (
+	allocInit  sync.Once
+	allocStats *allocAnalysis
+)
+
+// analyzeAlloc estimates the allocation of each goroutine
+// and stores it in allocStats.
+func analyzeAlloc(events []*trace.Event) {
+	allocInit.Do(func() {
+		allocStats = allocEstimates(events)
+	})
+}
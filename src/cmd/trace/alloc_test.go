@@ -0,0 +1,133 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"internal/trace"
+	"reflect"
+	"testing"
+)
+
+// allocTrace builds a synthetic trace for allocEstimates.
+type allocTrace struct {
+	events []*trace.Event
+}
+
+func (t *allocTrace) ev(ts int64, typ byte, p int, g uint64, args ...uint64) {
+	e := &trace.Event{Ts: ts, Type: typ, P: p, G: g}
+	copy(e.Args[:], args)
+	t.events = append(t.events, e)
+}
+
+// run starts g on p at ts.
+func (t *allocTrace) run(ts int64, p int, g uint64) { t.ev(ts, trace.EvGoStart, p, g, g) }
+
+// block blocks g on p at ts.
+func (t *allocTrace) block(ts int64, p int, g uint64) { t.ev(ts, trace.EvGoBlock, p, g) }
+
+// heap records that g on p observed a heap size of n at ts.
+func (t *allocTrace) heap(ts int64, p int, g uint64, n uint64) { t.ev(ts, trace.EvHeapAlloc, p, g, n) }
+
+func TestAllocEstimates(t *testing.T) {
+	tests := []struct {
+		name         string
+		build        func(*allocTrace)
+		want         map[uint64]uint64
+		unattributed uint64
+	}{
+		{
+			// A goroutine running alone is charged for all of the growth.
+			name: "single",
+			build: func(t *allocTrace) {
+				t.run(0, 0, 1)
+				t.heap(0, 0, 1, 100)
+				t.heap(500, 0, 1, 300)
+				t.heap(1000, 0, 1, 1300)
+				t.block(1000, 0, 1)
+			},
+			want: map[uint64]uint64{1: 1200},
+		},
+		{
+			// Goroutines that take turns on a P are each charged for
+			// the intervals in which they ran alone.
+			name: "turns",
+			build: func(t *allocTrace) {
+				t.run(0, 0, 1)
+				t.heap(0, 0, 1, 0)
+				t.heap(100, 0, 1, 40)
+				t.block(100, 0, 1)
+				t.run(100, 0, 2)
+				t.heap(300, 0, 2, 1040)
+				t.block(300, 0, 2)
+				t.run(300, 0, 1)
+				t.heap(400, 0, 1, 1050)
+			},
+			want: map[uint64]uint64{1: 50, 2: 1000},
+		},
+		{
+			// Goroutines that run at the same time share the growth
+			// in proportion to their execution time, which is exact
+			// when they allocate at the same rate: here 1 byte per ns.
+			name: "concurrent",
+			build: func(t *allocTrace) {
+				t.run(0, 0, 1)
+				t.heap(0, 0, 1, 0)
+				t.run(250, 1, 2)
+				t.block(600, 1, 2)
+				t.run(600, 1, 3)
+				t.heap(1000, 0, 1, 1750)
+			},
+			want: map[uint64]uint64{1: 1000, 2: 350, 3: 400},
+		},
+		{
+			// The heap shrinks at the end of a GC cycle. The growth
+			// across the drop is unknown and not attributed.
+			name: "gc",
+			build: func(t *allocTrace) {
+				t.run(0, 0, 1)
+				t.heap(0, 0, 1, 1000)
+				t.heap(100, 0, 1, 1500)
+				t.heap(200, 0, 1, 400)
+				t.heap(300, 0, 1, 600)
+			},
+			want: map[uint64]uint64{1: 700},
+		},
+		{
+			// Growth with no goroutine running is charged to the
+			// goroutine that reported it or, failing that, to no one.
+			name: "idle",
+			build: func(t *allocTrace) {
+				t.heap(0, 0, 0, 0)
+				t.heap(100, 0, 0, 64)
+				t.heap(200, 0, 5, 96)
+			},
+			want:         map[uint64]uint64{5: 32},
+			unattributed: 64,
+		},
+		{
+			// Rounding never loses bytes.
+			name: "rounding",
+			build: func(t *allocTrace) {
+				for g := uint64(1); g <= 3; g++ {
+					t.run(0, int(g), g)
+				}
+				t.heap(0, 1, 1, 0)
+				t.heap(30, 1, 1, 100)
+			},
+			want: map[uint64]uint64{1: 33, 2: 33, 3: 34},
+		},
+	}
+	for _, tt := range tests {
+		var tr allocTrace
+		tt.build(&tr)
+		a := allocEstimates(tr.events)
+		if !reflect.DeepEqual(a.Bytes, tt.want) {
+			t.Errorf("%s: estimates = %v, want %v", tt.name, a.Bytes, tt.want)
+		}
+		if a.Unattributed != tt.unattributed {
+			t.Errorf("%s: unattributed = %d, want %d", tt.name, a.Unattributed, tt.unattributed)
+		}
+	}
+}
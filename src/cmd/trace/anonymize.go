@@ -0,0 +1,222 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/build"
+	"internal/trace"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// anonymize writes to the file out the trace in the file in, with the
+// strings that could identify the traced program replaced, so that the
+// trace can be shared outside the organization that produced it:
+//
+//	- file paths become a hash of the path followed by the extension
+//	  of its base name, such as 3fa2b1c0.go;
+//	- function names outside the standard library become tokens such as
+//	  pkg3.func7, numbering packages and the functions in each package
+//	  in the order they first appear in the trace;
+//	- goroutine labels and any other strings become hashes.
+//
+// The hashes are salted with a random key, so that guessable names such
+// as label values cannot be recovered by hashing candidates; they are
+// stable within one anonymized trace only. Events, including their
+// timestamps, are preserved exactly. If key is not empty, anonymize
+// writes the replacements to the file key, one "token original" pair
+// per line, so that the owner of the trace can translate answers about
+// the anonymized trace back.
+func anonymize(in, out, key string) error {
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	raw, err := trace.ReadRaw(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	a, err := newAnonymizer()
+	if err != nil {
+		return err
+	}
+	a.rewrite(raw)
+
+	f, err = os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := trace.WriteRaw(f, raw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if key != "" {
+		return a.writeKey(key)
+	}
+	return nil
+}
+
+// anonymizer replaces the strings of a trace.
+type anonymizer struct {
+	salt  []byte
+	pkgs  map[string]int    // package numbers
+	funcs map[string]int    // number of functions in each package
+	std   map[string]bool   // whether a package is in the standard library
+	key   map[string]string // replacement to original string
+}
+
+func newAnonymizer() (*anonymizer, error) {
+	a := &anonymizer{
+		salt:  make([]byte, 16),
+		pkgs:  make(map[string]int),
+		funcs: make(map[string]int),
+		std:   make(map[string]bool),
+		key:   make(map[string]string),
+	}
+	if _, err := rand.Read(a.salt); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// rewrite replaces the strings of raw. The string IDs do not change,
+// so the events need no changes.
+func (a *anonymizer) rewrite(raw *trace.RawTrace) {
+	// Classify the strings by how the events use them.
+	// Before 1.7 stacks held PCs only and there were no strings.
+	const (
+		funcString = iota + 1
+		fileString
+	)
+	use := make(map[uint64]int)
+	var ids []uint64 // function names in the order they first appear
+	for _, ev := range raw.Events {
+		if ev.Type != trace.EvStack || raw.Ver < 1007 {
+			continue
+		}
+		// [stack id, number of frames, array of {PC, func string ID, file string ID, line}]
+		for i := 2; i+3 < len(ev.Args); i += 4 {
+			fn, file := ev.Args[i+1], ev.Args[i+2]
+			if use[fn] == 0 {
+				use[fn] = funcString
+				ids = append(ids, fn)
+			}
+			if use[file] == 0 {
+				use[file] = fileString
+			}
+		}
+	}
+
+	// Number the functions in a deterministic order.
+	for _, id := range ids {
+		if s, ok := raw.Strings[id]; ok {
+			raw.Strings[id] = a.funcName(s)
+		}
+	}
+	for id, s := range raw.Strings {
+		switch use[id] {
+		case funcString:
+			// Done above.
+		case fileString:
+			raw.Strings[id] = a.replace(s, a.hash(s)+path.Ext(filepath.ToSlash(s)))
+		default:
+			// Goroutine labels and any strings of unknown use.
+			raw.Strings[id] = a.replace(s, a.hash(s))
+		}
+	}
+}
+
+// funcName returns the replacement of the function name fn, such as
+// pkg3.func7, or fn itself if it is in the standard library.
+func (a *anonymizer) funcName(fn string) string {
+	pkg := funcPackage(fn)
+	if a.isStd(pkg) {
+		return fn
+	}
+	n, ok := a.pkgs[pkg]
+	if !ok {
+		n = len(a.pkgs) + 1
+		a.pkgs[pkg] = n
+	}
+	a.funcs[pkg]++
+	return a.replace(fn, fmt.Sprintf("pkg%d.func%d", n, a.funcs[pkg]))
+}
+
+// funcPackage returns the import path of the package of the function
+// name fn, such as net/http for net/http.(*conn).serve.
+func funcPackage(fn string) string {
+	slash := strings.LastIndex(fn, "/")
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		return fn[:slash+1+dot]
+	}
+	return fn
+}
+
+// isStd reports whether the package pkg is in the standard library,
+// that is, in GOROOT but not a command.
+func (a *anonymizer) isStd(pkg string) bool {
+	std, ok := a.std[pkg]
+	if !ok {
+		elem := pkg
+		if i := strings.Index(pkg, "/"); i >= 0 {
+			elem = pkg[:i]
+		}
+		if pkg != "main" && elem != "cmd" && !strings.Contains(elem, ".") {
+			fi, err := os.Stat(filepath.Join(build.Default.GOROOT, "src", filepath.FromSlash(pkg)))
+			std = err == nil && fi.IsDir()
+		}
+		a.std[pkg] = std
+	}
+	return std
+}
+
+// hash returns a salted hash of s.
+func (a *anonymizer) hash(s string) string {
+	h := sha256.New()
+	h.Write(a.salt)
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil)[:4])
+}
+
+// replace records that the string s is replaced by r and returns r.
+func (a *anonymizer) replace(s, r string) string {
+	a.key[r] = s
+	return r
+}
+
+// writeKey writes the replacements to the file name.
+func (a *anonymizer) writeKey(name string) error {
+	var tokens []string
+	for r := range a.key {
+		tokens = append(tokens, r)
+	}
+	sort.Strings(tokens)
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range tokens {
+		fmt.Fprintf(w, "%s %s\n", r, a.key[r])
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"internal/proflabel"
+	"internal/trace"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	rtrace "runtime/trace"
+	"strings"
+	"testing"
+)
+
+//go:noinline
+func anonymizeWorker(c chan int) {
+	proflabel.Do(proflabel.Labels("customer", "acme-corporation"), func() {
+		c <- <-c
+	})
+}
+
+func TestAnonymize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace-anonymize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	c := make(chan int)
+	go anonymizeWorker(c)
+	c <- 1
+	<-c
+	rtrace.Stop()
+
+	in := filepath.Join(dir, "trace.out")
+	out := filepath.Join(dir, "anonymized.out")
+	key := filepath.Join(dir, "key.txt")
+	if err := ioutil.WriteFile(in, buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := anonymize(in, out, key); err != nil {
+		t.Fatalf("anonymize: %v", err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := trace.Parse(bytes.NewReader(buf.Bytes()), "")
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	anon, err := trace.Parse(bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("failed to parse anonymized trace: %v", err)
+	}
+	if len(anon) != len(events) {
+		t.Fatalf("anonymized trace has %d events, want %d", len(anon), len(events))
+	}
+
+	// The events are the same except for their strings, and no
+	// original identifier is left.
+	secret := map[string]bool{"customer": true, "acme-corporation": true}
+	var worker string
+	for i, ev := range events {
+		ev1 := anon[i]
+		if ev1.Type != ev.Type || ev1.Ts != ev.Ts || ev1.P != ev.P || ev1.G != ev.G || ev1.Args != ev.Args || len(ev1.Stk) != len(ev.Stk) {
+			t.Fatalf("event %d differs:\n\t%+v\n\t%+v", i, ev1, ev)
+		}
+		for j, f := range ev.Stk {
+			f1 := ev1.Stk[j]
+			if f1.PC != f.PC || f1.Line != f.Line {
+				t.Errorf("frame %d of event %d is %+v, want %+v", j, i, f1, f)
+			}
+			secret[f.File] = true
+			if pkg := funcPackage(f.Fn); pkg != "main" && !strings.HasPrefix(pkg, "cmd/") {
+				// The standard library.
+				if f1.Fn != f.Fn {
+					t.Errorf("standard library function %s replaced by %s", f.Fn, f1.Fn)
+				}
+				continue
+			}
+			secret[f.Fn] = true
+			if strings.HasSuffix(f.Fn, ".anonymizeWorker") {
+				worker = f1.Fn
+			}
+		}
+	}
+	if !strings.HasPrefix(worker, "pkg") || !strings.Contains(worker, ".func") {
+		t.Errorf("anonymizeWorker replaced by %q, want a pkgNN.funcMM token", worker)
+	}
+	for s := range secret {
+		if bytes.Contains(data, []byte(s)) {
+			t.Errorf("anonymized trace contains %q", s)
+		}
+	}
+
+	// The key maps the tokens back.
+	k, err := ioutil.ReadFile(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains("\n"+string(k), "\n"+worker+" ") || !strings.Contains(string(k), " acme-corporation\n") {
+		t.Errorf("key does not map %s and the label value back:\n%s", worker, k)
+	}
+
+	// The function tokens are deterministic.
+	if err := anonymize(in, out, ""); err != nil {
+		t.Fatalf("anonymize: %v", err)
+	}
+	data1, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data1, []byte(worker)) {
+		t.Errorf("second anonymization does not contain %s", worker)
+	}
+}
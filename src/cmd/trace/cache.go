@@ -0,0 +1,418 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// On-disk cache of the parsed trace and the analysis derived from it,
+// so that reopening a large trace does not repeat the parse.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"internal/trace"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// cacheMagic starts every cache file. The digit is the format version
+// and must be incremented whenever the encoding changes.
+const cacheMagic = "go trace cache 3\n"
+
+// cachedTrace is the state saved in a cache file.
+type cachedTrace struct {
+	events []*trace.Event
+	ranges []Range
+	gs     map[uint64]*trace.GDesc
+}
+
+// cacheHits counts the successful cache loads (for testing).
+var cacheHits int
+
+// cacheName returns the name of the cache file for traceFile.
+func cacheName(traceFile string) string {
+	return traceFile + ".cache"
+}
+
+// cacheKey identifies the contents of traceFile as analyzed by this
+// version of the tool with the given program binary. A cache file is
+// only used if it was written with the same key. The binary is
+// identified by its name, size and modification time, so that
+// rebuilding it invalidates the cache.
+func cacheKey(traceFile, binary string) ([]byte, error) {
+	var size, mtime int64
+	if binary != "" {
+		fi, err := os.Stat(binary)
+		if err != nil {
+			return nil, err
+		}
+		size, mtime = fi.Size(), fi.ModTime().UnixNano()
+	}
+	f, err := os.Open(traceFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00%d\x00", cacheMagic, runtime.Version(), binary, size, mtime)
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// readCache loads the cached analysis of traceFile.
+// It fails if there is no cache or the cache is stale.
+func readCache(traceFile, binary string) (*cachedTrace, error) {
+	key, err := cacheKey(traceFile, binary)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(cacheName(traceFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := &cacheReader{r: bufio.NewReader(f)}
+	if r.string() != cacheMagic || r.string() != string(key) {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, errors.New("stale cache")
+	}
+	c := r.trace()
+	if r.err != nil {
+		return nil, r.err
+	}
+	cacheHits++
+	return c, nil
+}
+
+// writeCache saves c as the cached analysis of traceFile.
+func writeCache(traceFile, binary string, c *cachedTrace) error {
+	key, err := cacheKey(traceFile, binary)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that an interrupted
+	// write never leaves a truncated cache behind.
+	name := cacheName(traceFile)
+	f, err := os.Create(filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".tmp"))
+	if err != nil {
+		return err
+	}
+	w := &cacheWriter{w: bufio.NewWriter(f)}
+	w.string(cacheMagic)
+	w.string(string(key))
+	w.trace(c)
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	if err := f.Close(); w.err == nil {
+		w.err = err
+	}
+	if w.err == nil {
+		w.err = os.Rename(f.Name(), name)
+	}
+	if w.err != nil {
+		os.Remove(f.Name())
+	}
+	return w.err
+}
+
+// The cache file holds, in order: a string table, a table of stacks
+// whose frames refer to the string table, the events, the ranges and
+// the goroutine statistics. Integers are varint-encoded; events refer
+// to their stack by ID and to their linked event by index.
+
+type cacheWriter struct {
+	w       *bufio.Writer
+	err     error
+	buf     [binary.MaxVarintLen64]byte
+	strings map[string]int
+}
+
+func (w *cacheWriter) uint(v uint64) {
+	if w.err == nil {
+		_, w.err = w.w.Write(w.buf[:binary.PutUvarint(w.buf[:], v)])
+	}
+}
+
+func (w *cacheWriter) int(v int64) {
+	if w.err == nil {
+		_, w.err = w.w.Write(w.buf[:binary.PutVarint(w.buf[:], v)])
+	}
+}
+
+func (w *cacheWriter) string(s string) {
+	w.uint(uint64(len(s)))
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+func (w *cacheWriter) trace(c *cachedTrace) {
+	// Collect the strings and stacks.
+	w.strings = make(map[string]int)
+	var strs []string
+	str := func(s string) {
+		if _, ok := w.strings[s]; !ok {
+			w.strings[s] = len(strs)
+			strs = append(strs, s)
+		}
+	}
+	stacks := make(map[uint64][]*trace.Frame)
+	var stkIDs []uint64
+	for _, ev := range c.events {
+		if ev.StkID == 0 {
+			continue
+		}
+		if _, ok := stacks[ev.StkID]; !ok {
+			stacks[ev.StkID] = ev.Stk
+			stkIDs = append(stkIDs, ev.StkID)
+			for _, f := range ev.Stk {
+				str(f.Fn)
+				str(f.File)
+			}
+		}
+	}
+	for _, ev := range c.events {
+		for _, a := range ev.SArgs {
+			str(a)
+		}
+	}
+	for _, g := range c.gs {
+		str(g.Name)
+		for k, v := range g.Labels {
+			str(k)
+			str(v)
+		}
+	}
+
+	w.uint(uint64(len(strs)))
+	for _, s := range strs {
+		w.string(s)
+	}
+
+	w.uint(uint64(len(stkIDs)))
+	for _, id := range stkIDs {
+		stk := stacks[id]
+		w.uint(id)
+		w.uint(uint64(len(stk)))
+		for _, f := range stk {
+			w.uint(f.PC)
+			w.uint(uint64(w.strings[f.Fn]))
+			w.uint(uint64(w.strings[f.File]))
+			w.int(int64(f.Line))
+		}
+	}
+
+	index := make(map[*trace.Event]int, len(c.events))
+	for i, ev := range c.events {
+		index[ev] = i
+	}
+	w.uint(uint64(len(c.events)))
+	for _, ev := range c.events {
+		w.int(int64(ev.Off))
+		w.uint(uint64(ev.Type))
+		w.int(ev.Ts)
+		w.int(int64(ev.P))
+		w.uint(ev.G)
+		w.uint(ev.StkID)
+		for _, a := range ev.Args {
+			w.uint(a)
+		}
+		w.uint(uint64(len(ev.SArgs)))
+		for _, a := range ev.SArgs {
+			w.uint(uint64(w.strings[a]))
+		}
+		link := -1
+		if ev.Link != nil {
+			link = index[ev.Link]
+		}
+		w.int(int64(link))
+	}
+
+	w.uint(uint64(len(c.ranges)))
+	for _, r := range c.ranges {
+		w.string(r.Name)
+		w.int(int64(r.Start))
+		w.int(int64(r.End))
+	}
+
+	w.uint(uint64(len(c.gs)))
+	for _, g := range c.gs {
+		w.uint(g.ID)
+		w.uint(uint64(w.strings[g.Name]))
+		w.uint(g.PC)
+		for _, t := range []int64{
+			g.CreationTime, g.StartTime, g.EndTime,
+			g.ExecTime, g.SchedWaitTime, g.IOTime, g.BlockTime,
+			g.SyscallTime, g.GCTime, g.SweepTime, g.TotalTime,
+		} {
+			w.int(t)
+		}
+		w.uint(uint64(len(g.Labels)))
+		for k, v := range g.Labels {
+			w.uint(uint64(w.strings[k]))
+			w.uint(uint64(w.strings[v]))
+		}
+	}
+}
+
+type cacheReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+var errCacheCorrupt = errors.New("corrupt cache")
+
+func (r *cacheReader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(r.r)
+	r.err = err
+	return v
+}
+
+func (r *cacheReader) int() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(r.r)
+	r.err = err
+	return v
+}
+
+// len reads a length or index and checks that it is less than max.
+func (r *cacheReader) len(max int) int {
+	n := r.uint()
+	if r.err == nil && n >= uint64(max) {
+		r.err = errCacheCorrupt
+		return 0
+	}
+	return int(n)
+}
+
+func (r *cacheReader) string() string {
+	n := r.len(1 << 30)
+	if r.err != nil {
+		return ""
+	}
+	buf := make([]byte, n)
+	_, r.err = io.ReadFull(r.r, buf)
+	return string(buf)
+}
+
+func (r *cacheReader) trace() *cachedTrace {
+	const maxLen = 1 << 30
+	strs := make([]string, r.len(maxLen))
+	for i := range strs {
+		strs[i] = r.string()
+	}
+	str := func() string {
+		i := r.len(len(strs))
+		if r.err != nil {
+			return ""
+		}
+		return strs[i]
+	}
+
+	stacks := make(map[uint64][]*trace.Frame)
+	for n := r.len(maxLen); n > 0 && r.err == nil; n-- {
+		id := r.uint()
+		stk := make([]*trace.Frame, r.len(maxLen))
+		for i := range stk {
+			stk[i] = &trace.Frame{
+				PC:   r.uint(),
+				Fn:   str(),
+				File: str(),
+				Line: int(r.int()),
+			}
+		}
+		stacks[id] = stk
+	}
+
+	c := new(cachedTrace)
+	c.events = make([]*trace.Event, r.len(maxLen))
+	for i := range c.events {
+		c.events[i] = new(trace.Event)
+	}
+	for _, ev := range c.events {
+		ev.Off = int(r.int())
+		ev.Type = byte(r.uint())
+		ev.Ts = r.int()
+		ev.P = int(r.int())
+		ev.G = r.uint()
+		ev.StkID = r.uint()
+		ev.Stk = stacks[ev.StkID]
+		for i := range ev.Args {
+			ev.Args[i] = r.uint()
+		}
+		if n := r.len(maxLen); n > 0 {
+			ev.SArgs = make([]string, n)
+			for i := range ev.SArgs {
+				ev.SArgs[i] = str()
+			}
+		}
+		if link := r.int(); link >= 0 {
+			if link >= int64(len(c.events)) {
+				r.err = errCacheCorrupt
+				return nil
+			}
+			ev.Link = c.events[link]
+		}
+		if r.err != nil {
+			return nil
+		}
+	}
+
+	// A nil ranges means the trace was not split.
+	if n := r.len(maxLen); n > 0 {
+		c.ranges = make([]Range, n)
+	}
+	for i := range c.ranges {
+		c.ranges[i] = Range{
+			Name:  r.string(),
+			Start: int(r.int()),
+			End:   int(r.int()),
+		}
+	}
+
+	c.gs = make(map[uint64]*trace.GDesc)
+	for n := r.len(maxLen); n > 0 && r.err == nil; n-- {
+		g := &trace.GDesc{
+			ID:   r.uint(),
+			Name: str(),
+			PC:   r.uint(),
+		}
+		for _, t := range []*int64{
+			&g.CreationTime, &g.StartTime, &g.EndTime,
+			&g.ExecTime, &g.SchedWaitTime, &g.IOTime, &g.BlockTime,
+			&g.SyscallTime, &g.GCTime, &g.SweepTime, &g.TotalTime,
+		} {
+			*t = r.int()
+		}
+		if n := r.len(maxLen); n > 0 {
+			g.Labels = make(map[string]string, n)
+			for ; n > 0 && r.err == nil; n-- {
+				k := str()
+				g.Labels[k] = str()
+			}
+		}
+		c.gs[g.ID] = g
+	}
+	if r.err == nil {
+		// The whole file must have been consumed.
+		if _, err := r.r.ReadByte(); err != io.EOF {
+			r.err = errCacheCorrupt
+		}
+	}
+	return c
+}
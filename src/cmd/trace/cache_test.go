@@ -0,0 +1,212 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"internal/proflabel"
+	"internal/trace"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	rtrace "runtime/trace"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTestTrace traces some goroutine activity into a file in dir.
+func writeTestTrace(t *testing.T, dir string) string {
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	var wg sync.WaitGroup
+	c := make(chan int)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		labels := proflabel.Labels("parity", strconv.Itoa(i%2))
+		go func() {
+			defer wg.Done()
+			proflabel.Do(labels, func() {
+				c <- 1
+			})
+		}()
+		<-c
+	}
+	wg.Wait()
+	time.Sleep(time.Millisecond)
+	rtrace.Stop()
+
+	name := filepath.Join(dir, "trace.out")
+	if err := ioutil.WriteFile(name, buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// analyze parses and analyzes the trace file the way main does.
+func analyze(t *testing.T, name string) *cachedTrace {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	events, err := trace.Parse(bufio.NewReader(f), "")
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	data := generateTrace(&traceParams{events: events, endTime: int64(1<<63 - 1)})
+	return &cachedTrace{
+		events: events,
+		ranges: splitTrace(data.consume),
+		gs:     trace.GoroutineStats(events),
+	}
+}
+
+// page renders the trace viewer data for events.
+func page(t *testing.T, events []*trace.Event) []byte {
+	data, err := json.Marshal(generateTrace(&traceParams{events: events, endTime: int64(1<<63 - 1)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// gdescStats returns the exported statistics of g.
+func gdescStats(g *trace.GDesc) [14]interface{} {
+	return [14]interface{}{
+		g.ID, g.Name, g.PC, g.CreationTime, g.StartTime, g.EndTime,
+		g.ExecTime, g.SchedWaitTime, g.IOTime, g.BlockTime,
+		g.SyscallTime, g.GCTime, g.SweepTime, g.TotalTime,
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := writeTestTrace(t, dir)
+
+	if _, err := readCache(name, ""); err == nil {
+		t.Fatalf("readCache succeeded without a cache file")
+	}
+
+	want := analyze(t, name)
+	labeled := 0
+	for _, g := range want.gs {
+		if g.Labels["parity"] != "" {
+			labeled++
+		}
+	}
+	if labeled != 10 {
+		t.Errorf("trace has %d labeled goroutines, want 10", labeled)
+	}
+	if err := writeCache(name, "", want); err != nil {
+		t.Fatalf("writeCache: %v", err)
+	}
+	hits := cacheHits
+	got, err := readCache(name, "")
+	if err != nil {
+		t.Fatalf("readCache: %v", err)
+	}
+	if cacheHits != hits+1 {
+		t.Errorf("cache hits = %d, want %d", cacheHits, hits+1)
+	}
+
+	if len(got.events) != len(want.events) {
+		t.Fatalf("cache has %d events, want %d", len(got.events), len(want.events))
+	}
+	for i, ev := range want.events {
+		gev := got.events[i]
+		if gev.Off != ev.Off || gev.Type != ev.Type || gev.Ts != ev.Ts || gev.P != ev.P ||
+			gev.G != ev.G || gev.StkID != ev.StkID || gev.Args != ev.Args ||
+			!reflect.DeepEqual(gev.SArgs, ev.SArgs) ||
+			!reflect.DeepEqual(gev.Stk, ev.Stk) || (gev.Link == nil) != (ev.Link == nil) ||
+			gev.Link != nil && gev.Link.Off != ev.Link.Off {
+			t.Fatalf("event %d = %+v, want %+v", i, gev, ev)
+		}
+	}
+	if !bytes.Equal(page(t, got.events), page(t, want.events)) {
+		t.Errorf("trace page differs when loaded from the cache")
+	}
+	if !reflect.DeepEqual(got.ranges, want.ranges) {
+		t.Errorf("ranges = %v, want %v", got.ranges, want.ranges)
+	}
+	if len(got.gs) != len(want.gs) {
+		t.Errorf("cache has %d goroutines, want %d", len(got.gs), len(want.gs))
+	}
+	for id, g := range want.gs {
+		gg := got.gs[id]
+		if gg == nil {
+			t.Errorf("goroutine %d missing from cache", id)
+			continue
+		}
+		if gdescStats(gg) != gdescStats(g) {
+			t.Errorf("goroutine %d = %+v, want %+v", id, gdescStats(gg), gdescStats(g))
+		}
+		if !reflect.DeepEqual(gg.Labels, g.Labels) {
+			t.Errorf("goroutine %d has labels %v, want %v", id, gg.Labels, g.Labels)
+		}
+	}
+
+	// Modifying the trace must invalidate the cache.
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1]++
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCache(name, ""); err == nil {
+		t.Errorf("readCache used the cache of a modified trace")
+	}
+}
+
+func TestCacheBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := writeTestTrace(t, dir)
+	binary := filepath.Join(dir, "prog")
+	if err := ioutil.WriteFile(binary, []byte("old"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	c := analyze(t, name)
+	if err := writeCache(name, binary, c); err != nil {
+		t.Fatalf("writeCache: %v", err)
+	}
+	if _, err := readCache(name, binary); err != nil {
+		t.Fatalf("readCache: %v", err)
+	}
+
+	// Rebuilding the binary must invalidate the cache.
+	if err := ioutil.WriteFile(binary, []byte("newer"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCache(name, binary); err == nil {
+		t.Errorf("readCache used the cache of a rebuilt binary")
+	}
+	if err := writeCache(name, binary, c); err != nil {
+		t.Fatalf("writeCache: %v", err)
+	}
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(binary, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCache(name, binary); err == nil {
+		t.Errorf("readCache used the cache of a binary with a new modification time")
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 func init() {
@@ -21,12 +23,105 @@ func init() {
 	http.HandleFunc("/goroutine", httpGoroutine)
 }
 
-// gtype describes a group of goroutines grouped by start PC.
+// gtype describes a group of goroutines grouped by start PC or by
+// creation site and, optionally, the value of a profiler label.
 type gtype struct {
-	ID       uint64 // Unique identifier (PC).
-	Name     string // Start function.
-	N        int    // Total number of goroutines in this group.
-	ExecTime int64  // Total execution time of all goroutines in this group.
+	ID    uint64 // Unique identifier (start PC or creation site PC).
+	Name  string // Start function.
+	Site  string // Creation site, when grouped by creation site.
+	Label string // Value of the grouping label.
+	N     int    // Total number of goroutines in this group.
+	Alloc uint64 // Estimated bytes allocated by all goroutines in this group.
+
+	// Total times of all goroutines in this group, in nanoseconds.
+	ExecTime      int64
+	IOTime        int64
+	BlockTime     int64
+	SyscallTime   int64
+	SchedWaitTime int64
+	SweepTime     int64
+	GCTime        int64
+}
+
+// gkey identifies a group of goroutines.
+type gkey struct {
+	pc    uint64
+	label string
+}
+
+// A grouping is how goroutines are grouped.
+type grouping struct {
+	site  bool   // by creation site rather than start PC
+	label string // and by the value of this profiler label, if not ""
+}
+
+// key returns the key of the group of g. sites holds the creation
+// sites of the goroutines.
+func (by grouping) key(g *trace.GDesc, sites map[uint64]*trace.Frame) gkey {
+	k := gkey{pc: g.PC}
+	if by.site {
+		k.pc = 0
+		if f := sites[g.ID]; f != nil {
+			k.pc = f.PC
+		}
+	}
+	if by.label != "" {
+		k.label = g.Labels[by.label]
+	}
+	return k
+}
+
+// param returns the groupby parameter for the grouping.
+func (by grouping) param() string {
+	var p []string
+	if by.site {
+		p = append(p, "site")
+	}
+	if by.label != "" {
+		p = append(p, "label:"+by.label)
+	}
+	return strings.Join(p, ",")
+}
+
+// groupGoroutines groups the goroutines of gs and sums their
+// statistics by group. alloc holds the estimated allocation of each
+// goroutine.
+func groupGoroutines(gs map[uint64]*trace.GDesc, sites map[uint64]*trace.Frame, by grouping, alloc map[uint64]uint64) gtypeList {
+	gss := make(map[gkey]*gtype)
+	for _, g := range gs {
+		k := by.key(g, sites)
+		gs1 := gss[k]
+		if gs1 == nil {
+			gs1 = &gtype{ID: k.pc, Name: g.Name, Label: k.label}
+			if by.site {
+				gs1.Site = siteName(sites[g.ID])
+			}
+			gss[k] = gs1
+		}
+		gs1.N++
+		gs1.Alloc += alloc[g.ID]
+		gs1.ExecTime += g.ExecTime
+		gs1.IOTime += g.IOTime
+		gs1.BlockTime += g.BlockTime
+		gs1.SyscallTime += g.SyscallTime
+		gs1.SchedWaitTime += g.SchedWaitTime
+		gs1.SweepTime += g.SweepTime
+		gs1.GCTime += g.GCTime
+	}
+	var glist gtypeList
+	for _, v := range gss {
+		glist = append(glist, *v)
+	}
+	sort.Sort(glist)
+	return glist
+}
+
+// siteName describes the creation site f.
+func siteName(f *trace.Frame) string {
+	if f == nil {
+		return "unknown (created before the trace started)"
+	}
+	return fmt.Sprintf("%s:%d in %s", f.File, f.Line, f.Fn)
 }
 
 type gtypeList []gtype
@@ -60,53 +155,123 @@ func (l gdescList) Swap(i, j int) {
 var (
 	gsInit sync.Once
 	gs     map[uint64]*trace.GDesc
+
+	sitesInit sync.Once
+	sites     map[uint64]*trace.Frame
 )
 
-// analyzeGoroutines generates statistics about execution of all goroutines and stores them in gs.
+// analyzeGoroutines generates statistics about execution of all goroutines and stores them in gs,
+// and finds their creation sites and stores them in sites.
+// The statistics may have been loaded from the cache already; the sites are not cached.
 func analyzeGoroutines(events []*trace.Event) {
 	gsInit.Do(func() {
 		gs = trace.GoroutineStats(events)
 	})
+	sitesInit.Do(func() {
+		sites = creationSites(events)
+	})
+}
+
+// creationSites returns the go statement that created each goroutine, by ID.
+// The goroutines that existed when the trace started have no creation site.
+func creationSites(events []*trace.Event) map[uint64]*trace.Frame {
+	sites := make(map[uint64]*trace.Frame)
+	for _, ev := range events {
+		if ev.Type == trace.EvGoCreate && len(ev.Stk) > 0 {
+			sites[ev.Args[0]] = ev.Stk[0]
+		}
+	}
+	return sites
 }
 
 // httpGoroutines serves list of goroutine groups.
+// Goroutines are grouped by start PC or, with the parameter groupby=site,
+// by the go statement that created them. With the parameter groupby=label:key,
+// the goroutines of each group are further split by the value of their
+// profiler label key. Both can be given, as in groupby=site,label:key.
+// Only the standard library can set labels, with internal/proflabel, so
+// the goroutines of user programs all have the empty label value.
 func httpGoroutines(w http.ResponseWriter, r *http.Request) {
 	events, err := parseEvents()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	by, err := parseGroupBy(r.FormValue("groupby"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	analyzeGoroutines(events)
-	gss := make(map[uint64]gtype)
-	for _, g := range gs {
-		gs1 := gss[g.PC]
-		gs1.ID = g.PC
-		gs1.Name = g.Name
-		gs1.N++
-		gs1.ExecTime += g.ExecTime
-		gss[g.PC] = gs1
+	analyzeAlloc(events)
+	glist := groupGoroutines(gs, sites, by, allocStats.Bytes)
+	templGoroutines.Execute(w, struct {
+		Key          string
+		GroupBy      string
+		Groups       gtypeList
+		Unattributed uint64
+	}{by.label, by.param(), glist, allocStats.Unattributed})
+}
+
+// parseGroupBy parses the groupby parameter, a comma-separated list
+// of site and label:key. If it is empty, goroutines are grouped by
+// start PC only.
+func parseGroupBy(groupBy string) (grouping, error) {
+	var by grouping
+	if groupBy == "" {
+		return by, nil
 	}
-	var glist gtypeList
-	for k, v := range gss {
-		v.ID = k
-		glist = append(glist, v)
+	for _, f := range strings.Split(groupBy, ",") {
+		switch {
+		case f == "site" && !by.site:
+			by.site = true
+		case strings.HasPrefix(f, "label:") && f != "label:" && by.label == "":
+			by.label = strings.TrimPrefix(f, "label:")
+		default:
+			return grouping{}, fmt.Errorf("bad groupby parameter '%v': want site, label:key or both", groupBy)
+		}
 	}
-	sort.Sort(glist)
-	templGoroutines.Execute(w, glist)
+	return by, nil
 }
 
-var templGoroutines = template.Must(template.New("").Parse(`
+var templGoroutines = template.Must(template.New("").Funcs(template.FuncMap{
+	"dur": func(ns int64) time.Duration { return time.Duration(ns) },
+}).Parse(`
 <html>
 <body>
-Goroutines: <br>
-{{range $}}
-  <a href="/goroutine?id={{.ID}}">{{.Name}}</a> N={{.N}} <br>
+Goroutines{{if .GroupBy}} grouped by {{.GroupBy}}{{end}}
+(group by <a href="/goroutines">start function</a>,
+<a href="/goroutines?groupby=site">creation site</a>): <br>
+{{range .Groups}}
+  <a href="/goroutine?id={{.ID}}{{if .Site}}&site=1{{end}}{{if $.Key}}&label={{$.Key}}&value={{.Label}}{{end}}">{{.Name}}</a>
+  {{- if .Site}} created at {{.Site}}{{end}}
+  {{- if $.Key}} {{$.Key}}={{printf "%q" .Label}}{{end}} N={{.N}} alloc~{{.Alloc}}
+  exec={{dur .ExecTime}} network={{dur .IOTime}} sync={{dur .BlockTime}} syscall={{dur .SyscallTime}}
+  sched={{dur .SchedWaitTime}} sweep={{dur .SweepTime}} gc={{dur .GCTime}} <br>
 {{end}}
+<p>
+The times are summed over the goroutines of the group:
+execution, blocked on the network, on synchronization, in blocking syscalls,
+waiting to be scheduled once runnable, sweeping,
+and alive during the GC pauses.
+The trace does not record GC assists, which are counted in the execution time.
+</p>
+<p>
+alloc~ is an estimate of the bytes allocated by the group.
+The trace records only the size of the heap, so each increase of the heap
+is divided among the goroutines that ran since the previous one,
+in proportion to their execution time.
+The estimate is exact for a goroutine that allocates while running alone
+and approximate when goroutines with different allocation rates run at the same time.
+Heap growth while no goroutine was running: {{.Unattributed}} bytes.
+</p>
 </body>
 </html>
 `))
 
-// httpGoroutine serves list of goroutines in a particular group.
+// httpGoroutine serves list of goroutines in a particular group:
+// those with start PC id or, with the parameter site, created at id,
+// and, with the parameters label and value, with that label value.
 func httpGoroutine(w http.ResponseWriter, r *http.Request) {
 	events, err := parseEvents()
 	if err != nil {
@@ -118,16 +283,27 @@ func httpGoroutine(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("failed to parse id parameter '%v': %v", r.FormValue("id"), err), http.StatusInternalServerError)
 		return
 	}
+	by := grouping{site: r.FormValue("site") != "", label: r.FormValue("label")}
+	want := gkey{pc: pc, label: r.FormValue("value")}
 	analyzeGoroutines(events)
+	analyzeAlloc(events)
 	var glist gdescList
+	var site string
 	for _, g := range gs {
-		if g.PC != pc || g.ExecTime == 0 {
+		if by.key(g, sites) != want || g.ExecTime == 0 {
 			continue
 		}
 		glist = append(glist, g)
+		if by.site {
+			site = siteName(sites[g.ID])
+		}
 	}
 	sort.Sort(glist)
-	err = templGoroutine.Execute(w, glist)
+	err = templGoroutine.Execute(w, struct {
+		Site  string
+		Gs    gdescList
+		Alloc map[uint64]uint64
+	}{site, glist, allocStats.Bytes})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
 		return
@@ -137,6 +313,7 @@ func httpGoroutine(w http.ResponseWriter, r *http.Request) {
 var templGoroutine = template.Must(template.New("").Parse(`
 <html>
 <body>
+{{if .Site}}Goroutines created at {{.Site}}:<br>{{end}}
 <table border="1" sortable="1">
 <tr>
 <th> Goroutine </th>
@@ -148,8 +325,9 @@ var templGoroutine = template.Must(template.New("").Parse(`
 <th> Scheduler wait time, ns </th>
 <th> GC sweeping time, ns </th>
 <th> GC pause time, ns </th>
+<th> Allocated, bytes (estimate) </th>
 </tr>
-{{range $}}
+{{range .Gs}}
   <tr>
     <td> <a href="/trace?goid={{.ID}}">{{.ID}}</a> </td>
     <td> {{.TotalTime}} </td>
@@ -160,9 +338,15 @@ var templGoroutine = template.Must(template.New("").Parse(`
     <td> {{.SchedWaitTime}} </td>
     <td> {{.SweepTime}} </td>
     <td> {{.GCTime}} </td>
+    <td> {{index $.Alloc .ID}} </td>
   </tr>
 {{end}}
 </table>
+<p>
+The allocation of a goroutine is estimated from the size of the heap:
+each increase is divided among the goroutines that ran since the previous one,
+in proportion to their execution time.
+</p>
 </body>
 </html>
 `))
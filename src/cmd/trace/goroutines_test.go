@@ -0,0 +1,170 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"internal/proflabel"
+	"internal/trace"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	rtrace "runtime/trace"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolWorker is a worker of a pool that serves requests for a handler.
+// Workers with an empty handler are not labeled.
+func poolWorker(handler string, wg *sync.WaitGroup) {
+	defer wg.Done()
+	if handler == "" {
+		time.Sleep(time.Millisecond)
+		return
+	}
+	proflabel.Do(proflabel.Labels("handler", handler), func() {
+		time.Sleep(time.Millisecond)
+	})
+}
+
+func TestGoroutinesGroupByLabel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	var wg sync.WaitGroup
+	for _, handler := range []string{"api", "static", "api", "", "api", "static"} {
+		wg.Add(1)
+		go poolWorker(handler, &wg)
+	}
+	wg.Wait()
+	rtrace.Stop()
+
+	events, err := trace.Parse(bufio.NewReader(&buf), "")
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	loader.once.Do(func() {
+		loader.events = events
+	})
+
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		httpGoroutines(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != 200 {
+			t.Fatalf("%s: status %d: %s", url, w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	// By default, the whole pool is one group.
+	page := get("/goroutines")
+	if !regexp.MustCompile(`\.poolWorker</a> N=6 `).MatchString(page) {
+		t.Errorf("/goroutines does not have the pool as a group of 6:\n%s", page)
+	}
+
+	page = get("/goroutines?groupby=label:handler")
+	for _, want := range []string{
+		`\.poolWorker</a> handler=&#34;api&#34; N=3 `,
+		`\.poolWorker</a> handler=&#34;static&#34; N=2 `,
+		`\.poolWorker</a> handler=&#34;&#34; N=1 `,
+	} {
+		if !regexp.MustCompile(want).MatchString(page) {
+			t.Errorf("/goroutines?groupby=label:handler does not match %#q:\n%s", want, page)
+		}
+	}
+}
+
+// siteEvents is a synthetic trace in which main.main creates goroutines
+// 2 and 3 running main.worker at main.go:10 and goroutine 4 running
+// main.worker at main.go:20. Timestamps are in ns.
+func siteEvents() []*trace.Event {
+	var events []*trace.Event
+	ev := func(ts int64, typ byte, g uint64, args ...uint64) *trace.Event {
+		e := &trace.Event{Ts: ts, Type: typ, G: g}
+		copy(e.Args[:], args)
+		events = append(events, e)
+		return e
+	}
+	create := func(ts int64, g uint64, line int) {
+		e := ev(ts, trace.EvGoCreate, 1, g)
+		e.Stk = []*trace.Frame{{PC: uint64(line), Fn: "main.main", File: "main.go", Line: line}}
+	}
+	start := func(ts int64, g uint64) {
+		e := ev(ts, trace.EvGoStart, g, g)
+		e.Stk = []*trace.Frame{{PC: 100, Fn: "main.worker", File: "worker.go", Line: 5}}
+	}
+
+	// Timestamp 0 means "not blocked" to GoroutineStats.
+	create(1, 2, 10)
+	create(1, 3, 10)
+	create(1, 4, 20)
+	start(5, 4)
+	ev(15, trace.EvGoEnd, 4)
+	start(10, 2)
+	start(20, 3)
+	ev(30, trace.EvGoBlockNet, 2)
+	ev(40, trace.EvGoBlockSync, 3)
+	ev(45, trace.EvGoUnblock, 1, 3)
+	ev(50, trace.EvGoUnblock, 1, 2)
+	start(50, 3)
+	ev(60, trace.EvGoEnd, 3)
+	start(60, 2)
+	ev(70, trace.EvGoSysBlock, 2)
+	ev(90, trace.EvGoSysExit, 2)
+	start(100, 2)
+	ev(105, trace.EvGCStart, 1)
+	ev(108, trace.EvGCDone, 1)
+	ev(110, trace.EvGoEnd, 2)
+	return events
+}
+
+func TestGoroutinesGroupBySite(t *testing.T) {
+	events := siteEvents()
+	gs := trace.GoroutineStats(events)
+	sites := creationSites(events)
+
+	byName := groupGoroutines(gs, sites, grouping{}, nil)
+	if len(byName) != 1 || byName[0].N != 3 || byName[0].Name != "main.worker" {
+		t.Errorf("grouped by start function: got %+v, want one group of 3 main.worker", byName)
+	}
+
+	got := groupGoroutines(gs, sites, grouping{site: true}, nil)
+	want := gtypeList{
+		{ID: 10, Name: "main.worker", Site: "main.go:10 in main.main", N: 2,
+			ExecTime: 70, IOTime: 20, BlockTime: 5, SyscallTime: 20, SchedWaitTime: 53, GCTime: 3},
+		{ID: 20, Name: "main.worker", Site: "main.go:20 in main.main", N: 1,
+			ExecTime: 10, SchedWaitTime: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("grouped by creation site:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	for _, tt := range []struct {
+		param string
+		want  grouping
+		ok    bool
+	}{
+		{"", grouping{}, true},
+		{"site", grouping{site: true}, true},
+		{"label:handler", grouping{label: "handler"}, true},
+		{"site,label:handler", grouping{site: true, label: "handler"}, true},
+		{"label:", grouping{}, false},
+		{"site,site", grouping{}, false},
+		{"pc", grouping{}, false},
+	} {
+		by, err := parseGroupBy(tt.param)
+		if (err == nil) != tt.ok || by != tt.want {
+			t.Errorf("parseGroupBy(%q) = %+v, %v; want %+v, ok %v", tt.param, by, err, tt.want, tt.ok)
+		}
+		if err == nil && by.param() != tt.param {
+			t.Errorf("parseGroupBy(%q).param() = %q", tt.param, by.param())
+		}
+	}
+}
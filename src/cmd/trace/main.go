@@ -15,6 +15,10 @@ Generate a trace file with 'go test':
 	go test -trace trace.out pkg
 View the trace in a web browser:
 	go tool trace trace.out
+Anonymize the trace before sharing it:
+	go tool trace -anonymize trace.out shared.out
+View the traces of several processes side by side:
+	go tool trace -merge a.trace b.trace
 */
 package main
 
@@ -43,12 +47,30 @@ Open a web browser displaying trace:
 [pkg.test] argument is required for traces produced by Go 1.6 and below.
 Go 1.7 does not require the binary argument.
 
+Write a copy of a trace with file paths, function names outside the
+standard library and goroutine labels replaced, for sharing:
+	go tool trace -anonymize [-key=file] trace.out anonymized.out
+
+Open a web browser displaying the traces of several processes that ran
+on the same machine side by side, aligned in time by their clock sync
+events, or by the times the trace files were last written if they have
+none, with a summary of each trace:
+	go tool trace -merge a.trace b.trace ...
+
 Flags:
 	-http=addr: HTTP service address (e.g., ':6060')
+	-nocache: do not read or write the analysis cache (trace.out.cache)
+	-anonymize: write an anonymized copy of the trace instead of viewing it
+	-key=file: with -anonymize, write the replaced names to file
+	-merge: view several traces side by side
 `
 
 var (
-	httpFlag = flag.String("http", "localhost:0", "HTTP service address (e.g., ':6060')")
+	httpFlag    = flag.String("http", "localhost:0", "HTTP service address (e.g., ':6060')")
+	nocacheFlag = flag.Bool("nocache", false, "do not read or write the analysis cache")
+	anonFlag    = flag.Bool("anonymize", false, "write an anonymized copy of the trace instead of viewing it")
+	keyFlag     = flag.String("key", "", "with -anonymize, write the replaced names to this file")
+	mergeFlag   = flag.Bool("merge", false, "view several traces side by side")
 
 	// The binary file name, left here for serveSVGProfile.
 	programBinary string
@@ -62,6 +84,24 @@ func main() {
 	}
 	flag.Parse()
 
+	if *anonFlag {
+		if flag.NArg() != 2 {
+			flag.Usage()
+		}
+		if err := anonymize(flag.Arg(0), flag.Arg(1), *keyFlag); err != nil {
+			dief("%v\n", err)
+		}
+		return
+	}
+
+	if *mergeFlag {
+		if flag.NArg() < 2 {
+			flag.Usage()
+		}
+		mergeMain(flag.Args())
+		return
+	}
+
 	// Go 1.7 traces embed symbol info and does not require the binary.
 	// But we optionally accept binary as first arg for Go 1.5 traces.
 	switch flag.NArg() {
@@ -85,15 +125,32 @@ func main() {
 		dief("%v\n", err)
 	}
 
-	log.Printf("Serializing trace...")
-	params := &traceParams{
-		events:  events,
-		endTime: int64(1<<63 - 1),
+	analyzeProcs(events)
+	if n := len(procsStats.Changes); n > 0 {
+		log.Printf("Warning: GOMAXPROCS changed %d times during the trace; utilization is computed per segment", n)
 	}
-	data := generateTrace(params)
 
-	log.Printf("Splitting trace...")
-	ranges = splitTrace(data)
+	if loader.cached {
+		log.Printf("Using cached analysis from %s", cacheName(traceFile))
+	} else {
+		log.Printf("Splitting trace...")
+		params := &traceParams{
+			events:  events,
+			endTime: int64(1<<63 - 1),
+		}
+		ranges = splitTrace(func(c traceConsumer) {
+			streamTrace(params, c)
+		})
+
+		if !*nocacheFlag {
+			log.Printf("Saving analysis to %s...", cacheName(traceFile))
+			analyzeGoroutines(events)
+			c := &cachedTrace{events: events, ranges: ranges, gs: gs}
+			if err := writeCache(traceFile, programBinary, c); err != nil {
+				log.Printf("failed to write cache: %v", err)
+			}
+		}
+	}
 
 	log.Printf("Opening browser")
 	if !startBrowser("http://" + ln.Addr().String()) {
@@ -112,10 +169,23 @@ var loader struct {
 	once   sync.Once
 	events []*trace.Event
 	err    error
+	cached bool // events, ranges and gs were loaded from the cache
 }
 
 func parseEvents() ([]*trace.Event, error) {
 	loader.once.Do(func() {
+		if !*nocacheFlag {
+			if c, err := readCache(traceFile, programBinary); err == nil {
+				loader.events = c.events
+				loader.cached = true
+				ranges = c.ranges
+				gsInit.Do(func() {
+					gs = c.gs
+				})
+				return
+			}
+		}
+
 		tracef, err := os.Open(traceFile)
 		if err != nil {
 			loader.err = fmt.Errorf("failed to open trace file: %v", err)
@@ -136,28 +206,80 @@ func parseEvents() ([]*trace.Event, error) {
 
 // httpMain serves the starting page.
 func httpMain(w http.ResponseWriter, r *http.Request) {
-	if err := templMain.Execute(w, ranges); err != nil {
+	data := struct {
+		Ranges     []Range
+		Start, End string // wall clock times of the first and last events
+		Procs      *procsAnalysis
+		Pacer      bool // the trace has GC pacer events
+	}{Ranges: ranges}
+	if events, err := parseEvents(); err == nil && len(events) > 0 {
+		if clock := trace.NewWallClock(events); clock != nil {
+			data.Start = wallTime(clock.Wall(events[0].Ts).UnixNano())
+			data.End = wallTime(clock.Wall(events[len(events)-1].Ts).UnixNano())
+		}
+		analyzeProcs(events)
+		data.Procs = procsStats
+		analyzePacer(events)
+		data.Pacer = len(pacerStats) > 0
+	}
+	if err := templMain.Execute(w, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-var templMain = template.Must(template.New("").Parse(`
+var templMain = template.Must(template.New("").Funcs(template.FuncMap{
+	"percent": func(f float64) float64 { return f * 100 },
+}).Parse(`
 <html>
 <body>
-{{if $}}
-	{{range $e := $}}
+{{if .Start}}
+	Wall clock: {{.Start}} to {{.End}}<br>
+	<br>
+{{end}}
+{{with .Procs}}{{if .Changes}}
+	<b>GOMAXPROCS changed during the trace.</b>
+	Utilization is computed against the number of Ps in effect at each instant.<br>
+	<table border="1">
+	<tr><th> At, ns </th><th> Goroutine </th><th> GOMAXPROCS </th><th> Stack </th></tr>
+	{{range .Changes}}
+		<tr>
+		<td> <a href="/trace?from={{.From}}&to={{.To}}">{{.Ts}}</a> </td>
+		<td> <a href="/trace?goid={{.G}}">{{.G}}</a> </td>
+		<td> {{.Old}} &rarr; {{.New}} </td>
+		<td> {{range .Stk}}{{.Fn}} {{.File}}:{{.Line}}<br>{{end}} </td>
+		</tr>
+	{{end}}
+	</table>
+	<table border="1">
+	<tr><th> From, ns </th><th> To, ns </th><th> GOMAXPROCS </th><th> Utilization </th></tr>
+	{{range .Segments}}
+		<tr>
+		<td> {{.From}} </td>
+		<td> {{.To}} </td>
+		<td> {{.Procs}} </td>
+		<td> {{printf "%.1f%%" (percent .Utilization)}} </td>
+		</tr>
+	{{end}}
+	</table>
+	Overall utilization: {{printf "%.1f%%" (percent .Utilization)}}<br>
+	<br>
+{{end}}{{end}}
+{{if .Ranges}}
+	{{range $e := .Ranges}}
 		<a href="/trace?start={{$e.Start}}&end={{$e.End}}">View trace ({{$e.Name}})</a><br>
 	{{end}}
 	<br>
 {{else}}
 	<a href="/trace">View trace</a><br>
 {{end}}
-<a href="/goroutines">Goroutine analysis</a><br>
-<a href="/io">Network blocking profile</a><br>
-<a href="/block">Synchronization blocking profile</a><br>
+<a href="/goroutines">Goroutine analysis</a> (<a href="/goroutines?groupby=site">by creation site</a>)<br>
+<a href="/io">Network blocking profile</a> (<a href="/io?stacks=1">stacks</a>)<br>
+<a href="/block">Synchronization blocking profile</a> (<a href="/block?stacks=1">stacks</a>)<br>
 <a href="/syscall">Syscall blocking profile</a><br>
 <a href="/sched">Scheduler latency profile</a><br>
+<a href="/netpoll">Network wakeup latency</a><br>
+{{if .Pacer}}<a href="/pacer">GC pacer</a><br>{{end}}
 </body>
 </html>
 `))
@@ -0,0 +1,355 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Merged view of the traces of several processes, aligned in time.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"internal/trace"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A mergedTrace is one of the traces shown side by side by -merge.
+type mergedTrace struct {
+	Name   string
+	events []*trace.Event
+
+	// Start is the wall clock time of timestamp 0, in nanoseconds
+	// since the Unix epoch. It is found with the clock sync events of
+	// the trace if it has some (Synced is true), and otherwise by
+	// assuming that the last event happened when the trace file was
+	// last modified, which is only as good as the file time.
+	Start  int64
+	Synced bool
+
+	// Offset is added to the timestamps of the trace to place them
+	// on the merged time axis, which starts at the earliest Start.
+	Offset int64
+}
+
+// traceStart returns the wall clock time of timestamp 0 of events, and
+// whether it was found with clock sync events rather than with mtime,
+// the time the trace file was last modified.
+func traceStart(events []*trace.Event, mtime time.Time) (int64, bool) {
+	if clock := trace.NewWallClock(events); clock != nil {
+		return clock.Wall(0).UnixNano(), true
+	}
+	var last int64
+	if len(events) > 0 {
+		last = events[len(events)-1].Ts
+	}
+	return mtime.UnixNano() - last, false
+}
+
+// alignTraces sets the offsets of traces so that the same wall clock
+// time has the same timestamp on the merged axis in all of them.
+func alignTraces(traces []*mergedTrace) {
+	if len(traces) == 0 {
+		return
+	}
+	base := traces[0].Start
+	for _, t := range traces[1:] {
+		if t.Start < base {
+			base = t.Start
+		}
+	}
+	for _, t := range traces {
+		t.Offset = t.Start - base
+	}
+}
+
+// generateMergedTrace generates the json trace of traces for the
+// trace viewer, the events of each trace in its own pair of process
+// groups, headed by the trace name. Only the events from from to to,
+// in nanoseconds on the merged axis, are included.
+func generateMergedTrace(traces []*mergedTrace, from, to int64) ViewerData {
+	merged := ViewerData{
+		Frames:   make(map[string]ViewerFrame),
+		TimeUnit: "ns",
+	}
+	var footer []*ViewerEvent
+	var arrowBase uint64
+	for i, t := range traces {
+		// Timestamps relative to startTime are on the merged axis
+		// relative to from.
+		params := &traceParams{
+			events:    t.events,
+			startTime: from - t.Offset,
+			endTime:   to - t.Offset,
+		}
+		data := generateTrace(params)
+
+		// Renumber the stack frames and arrows after those of the
+		// previous traces, and move the two process groups of the
+		// trace, PROCS and STATS, to pids of their own.
+		frameBase := len(merged.Frames)
+		for id, f := range data.Frames {
+			n, _ := strconv.Atoi(id)
+			if f.Parent != 0 {
+				f.Parent += frameBase
+			}
+			merged.Frames[strconv.Itoa(n+frameBase)] = f
+		}
+		maxArrow := arrowBase
+		for j, e := range data.Events {
+			if e.Stack != 0 {
+				e.Stack += frameBase
+			}
+			if e.EndStack != 0 {
+				e.EndStack += frameBase
+			}
+			if e.ID != 0 {
+				e.ID += arrowBase
+				if e.ID > maxArrow {
+					maxArrow = e.ID
+				}
+			}
+			e.Pid += uint64(2 * i)
+			switch arg := e.Arg.(type) {
+			case *NameArg:
+				if e.Name == "process_name" {
+					e.Arg = &NameArg{t.Name + " " + arg.Name}
+				}
+			case *SortIndexArg:
+				if e.Name == "process_sort_index" {
+					e.Arg = &SortIndexArg{2*i + arg.Index}
+				}
+			}
+			if j < data.footer {
+				merged.Events = append(merged.Events, e)
+			} else {
+				footer = append(footer, e)
+			}
+		}
+		arrowBase = maxArrow
+	}
+	sort.Stable(viewerEventsByTime(merged.Events))
+	merged.footer = len(merged.Events)
+	merged.Events = append(merged.Events, footer...)
+	return merged
+}
+
+type viewerEventsByTime []*ViewerEvent
+
+func (l viewerEventsByTime) Len() int           { return len(l) }
+func (l viewerEventsByTime) Less(i, j int) bool { return l[i].Time < l[j].Time }
+func (l viewerEventsByTime) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+// mergedView is the state of the server for -merge.
+var mergedView struct {
+	traces []*mergedTrace
+	ranges []Range
+}
+
+// mergeMain parses the trace files, aligns them and serves the merged
+// view.
+func mergeMain(files []string) {
+	ln, err := net.Listen("tcp", *httpFlag)
+	if err != nil {
+		dief("failed to create server socket: %v\n", err)
+	}
+
+	for _, file := range files {
+		log.Printf("Parsing %s...", file)
+		t, err := readMergedTrace(file)
+		if err != nil {
+			dief("%v\n", err)
+		}
+		if !t.Synced {
+			log.Printf("Warning: %s has no clock sync events; aligning it by the file modification time", file)
+		}
+		mergedView.traces = append(mergedView.traces, t)
+	}
+	alignTraces(mergedView.traces)
+
+	log.Printf("Splitting trace...")
+	mergedView.ranges = splitTrace(generateMergedTrace(mergedView.traces, 0, int64(1<<63-1)).consume)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", httpMergeMain)
+	mux.HandleFunc("/trace", httpMergeTrace)
+	mux.HandleFunc("/jsontrace", httpMergeJsonTrace)
+	mux.HandleFunc("/trace_viewer_html", httpTraceViewerHTML)
+
+	log.Printf("Opening browser")
+	if !startBrowser("http://" + ln.Addr().String()) {
+		fmt.Fprintf(os.Stderr, "Trace viewer is listening on http://%s\n", ln.Addr().String())
+	}
+	err = http.Serve(ln, mux)
+	dief("failed to start http server: %v\n", err)
+}
+
+// readMergedTrace parses the trace file and finds the wall clock time
+// at which it starts. The traces of Go 1.7 and later embed symbol
+// information, so no binary is needed.
+func readMergedTrace(file string) (*mergedTrace, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %v", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	events, err := trace.Parse(bufio.NewReader(f), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trace %s: %v", file, err)
+	}
+	t := &mergedTrace{Name: filepath.Base(file), events: events}
+	t.Start, t.Synced = traceStart(events, fi.ModTime())
+	return t, nil
+}
+
+// httpMergeTrace serves the trace viewer for the merged trace.
+func httpMergeTrace(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	html := strings.Replace(templTrace, "{{PARAMS}}", r.Form.Encode(), -1)
+	w.Write([]byte(html))
+}
+
+// httpMergeJsonTrace serves the json of the merged trace, all of it,
+// the range start/end of its events, or the window from/to, in
+// nanoseconds on the merged axis.
+func httpMergeJsonTrace(w http.ResponseWriter, r *http.Request) {
+	// This is an AJAX handler, so instead of http.Error we use log.Printf to log errors.
+	from, to := int64(0), int64(1<<63-1)
+	if fromStr, toStr := r.FormValue("from"), r.FormValue("to"); fromStr != "" && toStr != "" {
+		var err error
+		from, err = strconv.ParseInt(fromStr, 10, 64)
+		if err != nil {
+			log.Printf("failed to parse from parameter '%v': %v", fromStr, err)
+			return
+		}
+		to, err = strconv.ParseInt(toStr, 10, 64)
+		if err != nil {
+			log.Printf("failed to parse to parameter '%v': %v", toStr, err)
+			return
+		}
+	}
+	data := generateMergedTrace(mergedView.traces, from, to)
+
+	if startStr, endStr := r.FormValue("start"), r.FormValue("end"); startStr != "" && endStr != "" {
+		start, err := strconv.ParseUint(startStr, 10, 64)
+		if err != nil {
+			log.Printf("failed to parse start parameter '%v': %v", startStr, err)
+			return
+		}
+		end, err := strconv.ParseUint(endStr, 10, 64)
+		if err != nil {
+			log.Printf("failed to parse end parameter '%v': %v", endStr, err)
+			return
+		}
+		if start >= uint64(data.footer) || end <= start || end > uint64(data.footer) {
+			log.Printf("bogus start/end parameters: %v/%v, trace size %v", start, end, data.footer)
+			return
+		}
+		data.Events = append(data.Events[start:end], data.Events[data.footer:]...)
+		data.footer = int(end - start)
+	}
+	bw := bufio.NewWriter(w)
+	data.consume(viewerDataTraceConsumer(bw, 0, data.footer))
+	if err := bw.Flush(); err != nil {
+		log.Printf("failed to serialize trace: %v", err)
+		return
+	}
+}
+
+// mergedSummary is the summary of one of the merged traces.
+type mergedSummary struct {
+	*mergedTrace
+	Wall        string // wall clock time of timestamp 0
+	Events      int
+	Goroutines  int
+	ExecTime    time.Duration // summed over goroutines
+	Utilization float64
+}
+
+// httpMergeMain serves the starting page of -merge: a summary of each
+// trace and links to the merged view.
+func httpMergeMain(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Traces []mergedSummary
+		Ranges []Range
+		Start  string
+		End    time.Duration // length of the merged axis
+	}{Ranges: mergedView.ranges}
+	for _, t := range mergedView.traces {
+		s := mergedSummary{
+			mergedTrace: t,
+			Wall:        wallTime(t.Start),
+			Events:      len(t.events),
+			Utilization: procsUtilization(t.events).Utilization,
+		}
+		for _, g := range trace.GoroutineStats(t.events) {
+			s.Goroutines++
+			s.ExecTime += time.Duration(g.ExecTime)
+		}
+		if n := len(t.events); n > 0 {
+			if end := time.Duration(t.Offset + t.events[n-1].Ts); end > data.End {
+				data.End = end
+			}
+		}
+		if t.Offset == 0 {
+			data.Start = wallTime(t.Start)
+		}
+		data.Traces = append(data.Traces, s)
+	}
+	if err := templMergeMain.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+var templMergeMain = template.Must(template.New("").Funcs(template.FuncMap{
+	"percent": func(f float64) float64 { return f * 100 },
+	"offset":  func(ns int64) time.Duration { return time.Duration(ns) },
+}).Parse(`
+<html>
+<body>
+Merged traces, from {{.Start}} for {{.End}}.<br>
+<br>
+<table border="1">
+<tr><th> Trace </th><th> Starts at </th><th> Offset </th><th> Aligned by </th><th> Events </th><th> Goroutines </th><th> Execution time </th><th> Utilization </th></tr>
+{{range .Traces}}
+	<tr>
+	<td> {{.Name}} </td>
+	<td> {{.Wall}} </td>
+	<td> {{offset .Offset}} </td>
+	<td> {{if .Synced}}clock sync{{else}}file time{{end}} </td>
+	<td> {{.Events}} </td>
+	<td> {{.Goroutines}} </td>
+	<td> {{.ExecTime}} </td>
+	<td> {{printf "%.1f%%" (percent .Utilization)}} </td>
+	</tr>
+{{end}}
+</table>
+<br>
+{{if .Ranges}}
+	{{range $e := .Ranges}}
+		<a href="/trace?start={{$e.Start}}&end={{$e.End}}">View merged trace ({{$e.Name}})</a><br>
+	{{end}}
+{{else}}
+	<a href="/trace">View merged trace</a><br>
+{{end}}
+<br>
+For the analyses of one trace, run go tool trace on it alone.
+</body>
+</html>
+`))
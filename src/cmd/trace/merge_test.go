@@ -0,0 +1,111 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"internal/trace"
+	"math"
+	"testing"
+	"time"
+)
+
+// mergeEvents is a synthetic trace that started at the wall clock time
+// start, in which goroutine g runs on P 0 at the wall clock time marker
+// for 100µs.
+func mergeEvents(start, marker time.Time, g uint64) []*trace.Event {
+	ts := int64(marker.Sub(start))
+	sync := &trace.Event{Type: trace.EvClockSync, Ts: 0}
+	sync.Args[0] = uint64(start.UnixNano())
+	run := &trace.Event{Type: trace.EvGoStart, Ts: ts, P: 0, G: g}
+	run.Args[0] = g
+	run.Link = &trace.Event{Type: trace.EvGoBlock, Ts: ts + int64(100*time.Microsecond), P: 0, G: g}
+	return []*trace.Event{
+		sync,
+		{Type: trace.EvProcStart, Ts: 0, P: 0},
+		run,
+		run.Link,
+	}
+}
+
+func TestMergeAlignment(t *testing.T) {
+	// Process b starts tracing 2.5ms after process a, and each runs a
+	// goroutine at the same instant, 10ms after a starts.
+	start := time.Unix(1e9, 0)
+	offset := 2500 * time.Microsecond
+	marker := start.Add(10 * time.Millisecond)
+	a := &mergedTrace{Name: "a.trace", events: mergeEvents(start, marker, 1)}
+	b := &mergedTrace{Name: "b.trace", events: mergeEvents(start.Add(offset), marker, 2)}
+	traces := []*mergedTrace{a, b}
+	for _, m := range traces {
+		m.Start, m.Synced = traceStart(m.events, time.Time{})
+		if !m.Synced {
+			t.Fatalf("%s: not aligned by the clock sync event", m.Name)
+		}
+	}
+	alignTraces(traces)
+	if a.Offset != 0 || b.Offset != int64(offset) {
+		t.Fatalf("got offsets %v and %v, want 0 and %v", a.Offset, b.Offset, int64(offset))
+	}
+
+	data := generateMergedTrace(traces, 0, math.MaxInt64)
+	slices := make(map[uint64]*ViewerEvent)
+	names := make(map[uint64]string)
+	for _, e := range data.Events {
+		switch {
+		case e.Phase == "X" && (e.Name == "G1" || e.Name == "G2"):
+			slices[e.Pid] = e
+		case e.Name == "process_name":
+			names[e.Pid] = e.Arg.(*NameArg).Name
+		}
+	}
+	sa, sb := slices[0], slices[2]
+	if sa == nil || sb == nil {
+		t.Fatalf("goroutine slices not found in process groups 0 and 2: %v", slices)
+	}
+	if sa.Name != "G1" || sb.Name != "G2" {
+		t.Errorf("got slices %s in group 0 and %s in group 2, want G1 and G2", sa.Name, sb.Name)
+	}
+	// Times are in µs.
+	if d := math.Abs(sa.Time - sb.Time); d > 1 {
+		t.Errorf("simultaneous slices at %vµs and %vµs on the merged axis", sa.Time, sb.Time)
+	}
+	if want := float64(10 * time.Millisecond / time.Microsecond); math.Abs(sa.Time-want) > 1 {
+		t.Errorf("slice at %vµs on the merged axis, want %vµs", sa.Time, want)
+	}
+	for pid, want := range map[uint64]string{0: "a.trace PROCS", 1: "a.trace STATS", 2: "b.trace PROCS", 3: "b.trace STATS"} {
+		if names[pid] != want {
+			t.Errorf("process group %d is named %q, want %q", pid, names[pid], want)
+		}
+	}
+
+	// A window of the merged axis is the same window of both traces.
+	data = generateMergedTrace(traces, int64(9*time.Millisecond), int64(11*time.Millisecond))
+	n := 0
+	for _, e := range data.Events {
+		if e.Phase == "X" {
+			n++
+			if want := float64(time.Millisecond / time.Microsecond); math.Abs(e.Time-want) > 1 {
+				t.Errorf("%s at %vµs in the window, want %vµs", e.Name, e.Time, want)
+			}
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d slices in the window, want 2", n)
+	}
+}
+
+func TestMergeFileTime(t *testing.T) {
+	// Without clock sync events, the last event is taken to have
+	// happened when the file was written.
+	events := mergeEvents(time.Unix(0, 0), time.Unix(0, int64(time.Millisecond)), 1)[1:]
+	mtime := time.Unix(1e9, 0)
+	start, synced := traceStart(events, mtime)
+	if synced {
+		t.Errorf("aligned by clock sync events, but there are none")
+	}
+	if want := mtime.UnixNano() - events[len(events)-1].Ts; start != want {
+		t.Errorf("got start %v, want %v", start, want)
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Network poller wakeup latency.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"internal/trace"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func init() {
+	http.HandleFunc("/netpoll", httpNetpoll)
+}
+
+// Causes of the delay between a network unblock and the start of the
+// unblocked goroutine.
+const (
+	causeGC       = "GC"            // a GC was running during the wait
+	causeNoIdleP  = "no idle P"     // every P was running a goroutine
+	causeRunQueue = "run queue"     // other goroutines were runnable ahead of it
+	causeWakeup   = "idle P wakeup" // an idle P had to be woken to run it
+)
+
+var netpollCauses = []string{causeGC, causeNoIdleP, causeRunQueue, causeWakeup}
+
+// netpollWait describes the wait of one goroutine that was made runnable
+// by the network poller until it started running.
+type netpollWait struct {
+	G       uint64 // The unblocked goroutine.
+	Ts      int64  // Time of the network unblock.
+	Latency int64  // Time from the unblock to the goroutine's start, in ns.
+	Cause   string // Why the goroutine waited, one of the cause constants.
+	RunQ    int    // Number of goroutines runnable when it was unblocked.
+}
+
+type netpollWaitList []*netpollWait
+
+func (l netpollWaitList) Len() int {
+	return len(l)
+}
+
+func (l netpollWaitList) Less(i, j int) bool {
+	return l[i].Latency > l[j].Latency
+}
+
+func (l netpollWaitList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// pState is the state of a P while walking the trace.
+type pState struct {
+	g uint64 // goroutine running on the P, or 0 if the P is idle
+}
+
+// netpollLatencies returns the wait of every network unblock in events
+// that is followed by a start of the unblocked goroutine, in trace order.
+// The cause of each wait is derived from the state of the Ps, the GC and
+// the set of runnable goroutines at the time of the unblock, plus whether
+// a GC started before the goroutine did.
+func netpollLatencies(events []*trace.Event) []*netpollWait {
+	var waits []*netpollWait
+	gomaxprocs := 0
+	ps := make(map[int]*pState)
+	runnable := make(map[uint64]bool)
+	pending := make(map[uint64]*netpollWait)
+	gcRunning := false
+	for _, ev := range events {
+		switch ev.Type {
+		case trace.EvGomaxprocs:
+			gomaxprocs = int(ev.Args[0])
+		case trace.EvProcStart:
+			ps[ev.P] = &pState{}
+		case trace.EvProcStop:
+			delete(ps, ev.P)
+		case trace.EvGCStart:
+			gcRunning = true
+			for _, w := range pending {
+				w.Cause = causeGC
+			}
+		case trace.EvGCDone:
+			gcRunning = false
+		case trace.EvGoCreate:
+			runnable[ev.Args[0]] = true
+		case trace.EvGoStart:
+			delete(runnable, ev.G)
+			p := ps[ev.P]
+			if p == nil {
+				// The P was started before the trace.
+				p = &pState{}
+				ps[ev.P] = p
+			}
+			p.g = ev.G
+			if w := pending[ev.G]; w != nil {
+				w.Latency = ev.Ts - w.Ts
+				waits = append(waits, w)
+				delete(pending, ev.G)
+			}
+		case trace.EvGoSched, trace.EvGoPreempt:
+			runnable[ev.G] = true
+			if p := ps[ev.P]; p != nil {
+				p.g = 0
+			}
+		case trace.EvGoEnd, trace.EvGoStop,
+			trace.EvGoSleep, trace.EvGoBlock, trace.EvGoBlockSend, trace.EvGoBlockRecv,
+			trace.EvGoBlockSelect, trace.EvGoBlockSync, trace.EvGoBlockCond, trace.EvGoBlockNet,
+			trace.EvGoSysBlock:
+			if p := ps[ev.P]; p != nil {
+				p.g = 0
+			}
+		case trace.EvGoSysExit:
+			runnable[ev.G] = true
+		case trace.EvGoUnblock:
+			g := ev.Args[0]
+			if ev.P == trace.NetpollP && ev.Link != nil {
+				w := &netpollWait{G: g, Ts: ev.Ts, RunQ: len(runnable)}
+				busy := 0
+				for _, p := range ps {
+					if p.g != 0 {
+						busy++
+					}
+				}
+				switch {
+				case gcRunning:
+					w.Cause = causeGC
+				case gomaxprocs > 0 && busy >= gomaxprocs:
+					w.Cause = causeNoIdleP
+				case w.RunQ > 0:
+					w.Cause = causeRunQueue
+				default:
+					w.Cause = causeWakeup
+				}
+				pending[g] = w
+			}
+			runnable[g] = true
+		}
+	}
+	return waits
+}
+
+var (
+	netpollInit  sync.Once
+	netpollWaits []*netpollWait
+)
+
+// analyzeNetpoll computes the network wakeup latencies and stores them
+// in netpollWaits.
+func analyzeNetpoll(events []*trace.Event) {
+	netpollInit.Do(func() {
+		netpollWaits = netpollLatencies(events)
+	})
+}
+
+// netpollBucket is a histogram bucket of wakeup latencies.
+type netpollBucket struct {
+	Max   time.Duration // Upper bound of the latencies in the bucket.
+	N     int           // Number of waits in the bucket.
+	Width int           // Width of the bucket's bar, in pixels.
+}
+
+// netpollCause summarizes the waits with the same cause.
+type netpollCause struct {
+	Cause string
+	N     int
+	Total time.Duration
+	Mean  time.Duration
+	Max   time.Duration
+}
+
+// netpollWorst is one of the longest waits, with the trace window to show.
+type netpollWorst struct {
+	*netpollWait
+	Latency  time.Duration
+	From, To int64
+}
+
+type netpollSummary struct {
+	N       int
+	Buckets []netpollBucket
+	Causes  []netpollCause
+	Worst   []netpollWorst
+}
+
+// summarizeNetpoll computes the histogram, the breakdown by cause and
+// the n longest of waits.
+func summarizeNetpoll(waits []*netpollWait, n int) *netpollSummary {
+	const (
+		barWidth  = 400                           // width of the largest bucket's bar
+		minMargin = int64(100 * time.Microsecond) // context around the worst waits
+	)
+	s := &netpollSummary{N: len(waits)}
+	causes := make(map[string]*netpollCause)
+	for _, cause := range netpollCauses {
+		causes[cause] = &netpollCause{Cause: cause}
+	}
+	maxN := 0
+	for _, w := range waits {
+		lat := time.Duration(w.Latency)
+		// Buckets double in size, starting at 1µs.
+		i := 0
+		for max := time.Microsecond; lat > max; max *= 2 {
+			i++
+		}
+		for len(s.Buckets) <= i {
+			s.Buckets = append(s.Buckets, netpollBucket{Max: time.Microsecond << uint(len(s.Buckets))})
+		}
+		s.Buckets[i].N++
+		if s.Buckets[i].N > maxN {
+			maxN = s.Buckets[i].N
+		}
+		c := causes[w.Cause]
+		c.N++
+		c.Total += lat
+		if lat > c.Max {
+			c.Max = lat
+		}
+	}
+	for i := range s.Buckets {
+		s.Buckets[i].Width = s.Buckets[i].N * barWidth / maxN
+	}
+	for _, cause := range netpollCauses {
+		c := causes[cause]
+		if c.N > 0 {
+			c.Mean = c.Total / time.Duration(c.N)
+		}
+		s.Causes = append(s.Causes, *c)
+	}
+	worst := make(netpollWaitList, len(waits))
+	copy(worst, waits)
+	sort.Stable(worst)
+	if len(worst) > n {
+		worst = worst[:n]
+	}
+	for _, w := range worst {
+		// Show the wait with at least as much context on either side.
+		margin := w.Latency
+		if margin < minMargin {
+			margin = minMargin
+		}
+		from := w.Ts - margin
+		if from < 0 {
+			from = 0
+		}
+		s.Worst = append(s.Worst, netpollWorst{
+			netpollWait: w,
+			Latency:     time.Duration(w.Latency),
+			From:        from,
+			To:          w.Ts + w.Latency + margin,
+		})
+	}
+	return s
+}
+
+// httpNetpoll serves the network wakeup latency summary: the latency from
+// the network poller making a goroutine runnable to the goroutine running.
+// The parameter n sets the number of longest waits listed.
+func httpNetpoll(w http.ResponseWriter, r *http.Request) {
+	events, err := parseEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	n := 20
+	if s := r.FormValue("n"); s != "" {
+		n, err = strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("bad n parameter '%v'", s), http.StatusBadRequest)
+			return
+		}
+	}
+	analyzeNetpoll(events)
+	err = templNetpoll.Execute(w, summarizeNetpoll(netpollWaits, n))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+var templNetpoll = template.Must(template.New("").Parse(`
+<html>
+<body>
+<h2>Network wakeup latency</h2>
+Time from the network poller making a goroutine runnable to the goroutine running, N={{.N}}.
+<h3>Histogram</h3>
+<table>
+{{range .Buckets}}
+  <tr>
+    <td> &le; {{.Max}} </td>
+    <td> <div style="background-color: #4a7ebb; height: 1em; width: {{.Width}}px"></div> </td>
+    <td> {{.N}} </td>
+  </tr>
+{{end}}
+</table>
+<h3>Causes</h3>
+<table border="1">
+<tr>
+<th> Cause </th>
+<th> Count </th>
+<th> Total </th>
+<th> Mean </th>
+<th> Max </th>
+</tr>
+{{range .Causes}}
+  <tr>
+    <td> {{.Cause}} </td>
+    <td> {{.N}} </td>
+    <td> {{.Total}} </td>
+    <td> {{.Mean}} </td>
+    <td> {{.Max}} </td>
+  </tr>
+{{end}}
+</table>
+<h3>Longest waits</h3>
+<table border="1">
+<tr>
+<th> Goroutine </th>
+<th> Unblocked at, ns </th>
+<th> Latency </th>
+<th> Cause </th>
+<th> Runnable goroutines </th>
+</tr>
+{{range .Worst}}
+  <tr>
+    <td> <a href="/trace?goid={{.G}}">{{.G}}</a> </td>
+    <td> <a href="/trace?from={{.From}}&to={{.To}}">{{.Ts}}</a> </td>
+    <td> {{.Latency}} </td>
+    <td> {{.Cause}} </td>
+    <td> {{.RunQ}} </td>
+  </tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
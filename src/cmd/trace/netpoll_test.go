@@ -0,0 +1,163 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"internal/trace"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// netpollEvents is a synthetic trace with GOMAXPROCS=2 in which
+// goroutines 10 to 14 are woken by the network poller and wait for
+// a P for different reasons. Timestamps are in µs.
+func netpollEvents() []*trace.Event {
+	us := int64(time.Microsecond)
+	var events []*trace.Event
+	ev := func(ts int64, typ byte, p int, g uint64, args ...uint64) *trace.Event {
+		e := &trace.Event{Ts: ts * us, Type: typ, P: p, G: g}
+		copy(e.Args[:], args)
+		events = append(events, e)
+		return e
+	}
+	// unblock makes g runnable from the network poller,
+	// linked to its next start.
+	starts := make(map[uint64]**trace.Event)
+	unblock := func(ts int64, p int, g uint64) {
+		e := ev(ts, trace.EvGoUnblock, p, 0, g)
+		starts[g] = &e.Link
+	}
+	start := func(ts int64, p int, g uint64) {
+		e := ev(ts, trace.EvGoStart, p, g, g)
+		if link := starts[g]; link != nil {
+			*link = e
+			delete(starts, g)
+		}
+	}
+
+	ev(0, trace.EvGomaxprocs, 0, 0, 2)
+	ev(0, trace.EvProcStart, 0, 0)
+	ev(0, trace.EvProcStart, 1, 0)
+
+	// Both Ps are idle: an idle P has to be woken up.
+	unblock(100, trace.NetpollP, 10)
+	start(150, 0, 10)
+	ev(200, trace.EvGoBlockNet, 0, 10)
+
+	// Both Ps are busy until 1 yields.
+	start(300, 0, 1)
+	start(300, 1, 2)
+	unblock(400, trace.NetpollP, 11)
+	ev(1400, trace.EvGoSched, 0, 1)
+	start(1400, 0, 11)
+	ev(1500, trace.EvGoBlockNet, 0, 11)
+	ev(1500, trace.EvGoBlock, 1, 2)
+
+	// 1 is runnable ahead of 12.
+	unblock(2000, trace.NetpollP, 12)
+	start(2100, 0, 1)
+	start(2300, 1, 12)
+	ev(2400, trace.EvGoBlockNet, 1, 12)
+
+	// A GC is running when 13 is unblocked.
+	ev(3000, trace.EvGCStart, 0, 0)
+	unblock(3100, trace.NetpollP, 13)
+	ev(3500, trace.EvGCDone, 0, 0)
+	start(3600, 1, 13)
+
+	// Both Ps are busy when 14 is unblocked, but a GC starts before it runs.
+	unblock(4000, trace.NetpollP, 14)
+	ev(4100, trace.EvGCStart, 0, 0)
+	ev(4150, trace.EvGoBlock, 1, 13)
+	start(4200, 1, 14)
+	ev(4300, trace.EvGCDone, 0, 0)
+
+	// Unblocks by other goroutines and unblocks of goroutines
+	// that never run are not network wakeups.
+	unblock(5000, 0, 15)
+	start(5100, 1, 15)
+	unblock(6000, trace.NetpollP, 16)
+	return events
+}
+
+func TestNetpollLatencies(t *testing.T) {
+	us := int64(time.Microsecond)
+	want := []netpollWait{
+		{G: 10, Ts: 100 * us, Latency: 50 * us, Cause: causeWakeup, RunQ: 0},
+		{G: 11, Ts: 400 * us, Latency: 1000 * us, Cause: causeNoIdleP, RunQ: 0},
+		{G: 12, Ts: 2000 * us, Latency: 300 * us, Cause: causeRunQueue, RunQ: 1},
+		{G: 13, Ts: 3100 * us, Latency: 500 * us, Cause: causeGC, RunQ: 0},
+		{G: 14, Ts: 4000 * us, Latency: 200 * us, Cause: causeGC, RunQ: 0},
+	}
+	waits := netpollLatencies(netpollEvents())
+	if len(waits) != len(want) {
+		t.Fatalf("got %d waits, want %d", len(waits), len(want))
+	}
+	for i, w := range waits {
+		if *w != want[i] {
+			t.Errorf("wait %d: got %+v, want %+v", i, *w, want[i])
+		}
+	}
+}
+
+func TestSummarizeNetpoll(t *testing.T) {
+	s := summarizeNetpoll(netpollLatencies(netpollEvents()), 2)
+	if s.N != 5 {
+		t.Errorf("N = %d, want 5", s.N)
+	}
+
+	// Buckets double from 1µs: 50µs falls in ≤64µs, 200µs in ≤256µs,
+	// 300µs and 500µs in ≤512µs and 1ms in ≤1.024ms.
+	var got []int
+	for i, b := range s.Buckets {
+		if b.Max != time.Microsecond<<uint(i) {
+			t.Errorf("bucket %d: Max = %v, want %v", i, b.Max, time.Microsecond<<uint(i))
+		}
+		got = append(got, b.N)
+	}
+	if want := []int{0, 0, 0, 0, 0, 0, 1, 0, 1, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bucket counts = %v, want %v", got, want)
+	}
+	if w := s.Buckets[9].Width; w != 400 {
+		t.Errorf("largest bucket width = %d, want 400", w)
+	}
+
+	wantCauses := []netpollCause{
+		{causeGC, 2, 700 * time.Microsecond, 350 * time.Microsecond, 500 * time.Microsecond},
+		{causeNoIdleP, 1, time.Millisecond, time.Millisecond, time.Millisecond},
+		{causeRunQueue, 1, 300 * time.Microsecond, 300 * time.Microsecond, 300 * time.Microsecond},
+		{causeWakeup, 1, 50 * time.Microsecond, 50 * time.Microsecond, 50 * time.Microsecond},
+	}
+	if !reflect.DeepEqual(s.Causes, wantCauses) {
+		t.Errorf("causes = %+v, want %+v", s.Causes, wantCauses)
+	}
+
+	if len(s.Worst) != 2 {
+		t.Fatalf("got %d worst waits, want 2", len(s.Worst))
+	}
+	us := int64(time.Microsecond)
+	// The context before the wait is cut at the start of the trace.
+	if w := s.Worst[0]; w.G != 11 || w.From != 0 || w.To != 2400*us {
+		t.Errorf("worst wait = G%d [%d, %d], want G11 [0, %d]", w.G, w.From, w.To, 2400*us)
+	}
+	if w := s.Worst[1]; w.G != 13 || w.From != 2600*us || w.To != 4100*us {
+		t.Errorf("second worst wait = G%d [%d, %d], want G13 [%d, %d]", w.G, w.From, w.To, 2600*us, 4100*us)
+	}
+	// The 100µs minimum context applies to short waits.
+	if w := summarizeNetpoll(netpollLatencies(netpollEvents()), 5).Worst[4]; w.G != 10 || w.From != 0 || w.To != 250*us {
+		t.Errorf("shortest wait = G%d [%d, %d], want G10 [0, %d]", w.G, w.From, w.To, 250*us)
+	}
+
+	var buf bytes.Buffer
+	if err := templNetpoll.Execute(&buf, s); err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+	if link := `<a href="/trace?from=0&to=2400000">400000</a>`; !strings.Contains(buf.String(), link) {
+		t.Errorf("page does not link to the longest wait with %s:\n%s", link, buf.String())
+	}
+}
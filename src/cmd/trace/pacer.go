@@ -0,0 +1,228 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// GC pacer decisions.
+
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"internal/trace"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+func init() {
+	http.HandleFunc("/pacer", httpPacer)
+}
+
+// pacerCycle describes the pacer decisions of one concurrent GC cycle,
+// from its EvGCPacer events.
+type pacerCycle struct {
+	N          int    // Number of the cycle in the trace, starting at 1.
+	Start, End int64  // Times of the start and end events; End is 0 if the cycle did not end in the trace.
+	Trigger    uint64 // Heap size that triggered the cycle, in bytes.
+	Goal       uint64 // Heap goal of the cycle, in bytes.
+	StartLive  uint64 // Live heap at the start of the cycle, in bytes.
+	EndLive    uint64 // Live heap at the end of the mark phase, in bytes.
+
+	// Assist ratios, in scan work per allocated byte, at the start
+	// of the cycle, at its largest and at the end of the mark phase.
+	StartRatio, MaxRatio, EndRatio float64
+	Revisions                      int // Number of revise events.
+
+	// TriggerOvershoot is how far the live heap had grown past the
+	// trigger when the cycle started, and GoalOvershoot how far past
+	// the goal it had grown when the mark phase ended, both as
+	// fractions of the trigger and goal respectively. A negative
+	// GoalOvershoot means the cycle finished before reaching the goal.
+	TriggerOvershoot, GoalOvershoot float64
+}
+
+// pacerCycles returns the concurrent GC cycles in events that have
+// pacer events, in trace order. Pacer events before the first cycle
+// start, of a cycle that was in progress when tracing started, are
+// ignored.
+func pacerCycles(events []*trace.Event) []*pacerCycle {
+	var cycles []*pacerCycle
+	var c *pacerCycle // cycle in progress
+	for _, ev := range events {
+		if ev.Type != trace.EvGCPacer {
+			continue
+		}
+		trigger, goal, live := ev.Args[1], ev.Args[2], ev.Args[3]
+		ratio := math.Float64frombits(ev.Args[4])
+		switch ev.Args[0] {
+		case trace.GCPacerStart:
+			c = &pacerCycle{
+				N:          len(cycles) + 1,
+				Start:      ev.Ts,
+				Trigger:    trigger,
+				Goal:       goal,
+				StartLive:  live,
+				StartRatio: ratio,
+				MaxRatio:   ratio,
+			}
+			if trigger > 0 {
+				c.TriggerOvershoot = float64(live)/float64(trigger) - 1
+			}
+			cycles = append(cycles, c)
+		case trace.GCPacerRevise:
+			if c == nil {
+				continue
+			}
+			c.Revisions++
+			if ratio > c.MaxRatio {
+				c.MaxRatio = ratio
+			}
+		case trace.GCPacerEnd:
+			if c == nil {
+				continue
+			}
+			c.End = ev.Ts
+			c.EndLive = live
+			c.EndRatio = ratio
+			if ratio > c.MaxRatio {
+				c.MaxRatio = ratio
+			}
+			if goal > 0 {
+				c.GoalOvershoot = float64(live)/float64(goal) - 1
+			}
+			c = nil
+		}
+	}
+	return cycles
+}
+
+var (
+	pacerInit  sync.Once
+	pacerStats []*pacerCycle
+)
+
+// analyzePacer computes the GC pacer decisions and stores them
+// in pacerStats.
+func analyzePacer(events []*trace.Event) {
+	pacerInit.Do(func() {
+		pacerStats = pacerCycles(events)
+	})
+}
+
+// pacerRow is a cycle as shown on the /pacer page.
+type pacerRow struct {
+	*pacerCycle
+	Duration time.Duration
+	From, To int64 // Trace window of the cycle.
+}
+
+type pacerSummary struct {
+	Cycles        []pacerRow
+	Overshot      int     // Number of ended cycles that overshot the goal.
+	Ended         int     // Number of cycles that ended in the trace.
+	MeanOvershoot float64 // Mean GoalOvershoot of the ended cycles.
+	MaxOvershoot  float64 // Largest GoalOvershoot of the ended cycles.
+}
+
+// summarizePacer computes the rows of the /pacer page and the goal
+// overshoot statistics of the cycles that ended in the trace.
+func summarizePacer(cycles []*pacerCycle) *pacerSummary {
+	s := new(pacerSummary)
+	var total float64
+	for _, c := range cycles {
+		row := pacerRow{pacerCycle: c, From: c.Start, To: c.End}
+		if c.End != 0 {
+			row.Duration = time.Duration(c.End - c.Start)
+			// Show the cycle with some context on either side.
+			margin := (c.End - c.Start) / 10
+			row.From -= margin
+			row.To += margin
+			if row.From < 0 {
+				row.From = 0
+			}
+			if s.Ended == 0 || c.GoalOvershoot > s.MaxOvershoot {
+				s.MaxOvershoot = c.GoalOvershoot
+			}
+			s.Ended++
+			total += c.GoalOvershoot
+			if c.GoalOvershoot > 0 {
+				s.Overshot++
+			}
+		} else {
+			row.To = 1<<63 - 1
+		}
+		s.Cycles = append(s.Cycles, row)
+	}
+	if s.Ended > 0 {
+		s.MeanOvershoot = total / float64(s.Ended)
+	}
+	return s
+}
+
+// httpPacer serves the GC pacer page: the trigger, goal, live heap and
+// assist ratio of each concurrent GC cycle in the trace.
+func httpPacer(w http.ResponseWriter, r *http.Request) {
+	events, err := parseEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	analyzePacer(events)
+	err = templPacer.Execute(w, summarizePacer(pacerStats))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+var templPacer = template.Must(template.New("").Funcs(template.FuncMap{
+	"mb":      func(b uint64) string { return fmt.Sprintf("%.1f", float64(b)/(1<<20)) },
+	"percent": func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+}).Parse(`
+<html>
+<body>
+<h2>GC pacer</h2>
+{{if .Cycles}}
+The trigger and goal of each concurrent GC cycle, and the assist ratio in scan work per allocated byte.
+Heap sizes are in MB.
+{{if .Ended}}
+{{.Overshot}} of {{.Ended}} cycles overshot the heap goal; mean goal overshoot {{percent .MeanOvershoot}}, max {{percent .MaxOvershoot}}.
+{{end}}
+<table border="1">
+<tr>
+<th> GC </th>
+<th> Start, ns </th>
+<th> Duration </th>
+<th> Trigger </th>
+<th> Goal </th>
+<th> Live at start </th>
+<th> Trigger overshoot </th>
+<th> Live at end </th>
+<th> Goal overshoot </th>
+<th> Assist ratio (start / max / end) </th>
+<th> Revisions </th>
+</tr>
+{{range .Cycles}}
+  <tr>
+    <td> {{.N}} </td>
+    <td> <a href="/trace?from={{.From}}&to={{.To}}">{{.Start}}</a> </td>
+    <td> {{if .End}}{{.Duration}}{{end}} </td>
+    <td> {{mb .Trigger}} </td>
+    <td> {{mb .Goal}} </td>
+    <td> {{mb .StartLive}} </td>
+    <td> {{percent .TriggerOvershoot}} </td>
+    <td> {{if .End}}{{mb .EndLive}}{{end}} </td>
+    <td> {{if .End}}{{percent .GoalOvershoot}}{{end}} </td>
+    <td> {{printf "%.3g" .StartRatio}} / {{printf "%.3g" .MaxRatio}} / {{if .End}}{{printf "%.3g" .EndRatio}}{{end}} </td>
+    <td> {{.Revisions}} </td>
+  </tr>
+{{end}}
+</table>
+{{else}}
+The trace has no GC pacer events. It was recorded by an older version of Go, or no concurrent GC cycle started during the trace.
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,152 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"internal/trace"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pacerEvents is a synthetic trace with three concurrent GC cycles:
+// the first overshoots its goal, the second finishes early and the
+// third is still running at the end of the trace. Timestamps are in µs
+// and heap sizes in MB.
+func pacerEvents() []*trace.Event {
+	us := int64(time.Microsecond)
+	var events []*trace.Event
+	pacer := func(ts int64, phase uint64, trigger, goal, live, ratio float64) {
+		e := &trace.Event{Ts: ts * us, Type: trace.EvGCPacer}
+		e.Args = [5]uint64{phase, uint64(trigger * (1 << 20)), uint64(goal * (1 << 20)), uint64(live * (1 << 20)), math.Float64bits(ratio)}
+		events = append(events, e)
+	}
+
+	// The end of a cycle that started before the trace.
+	pacer(100, trace.GCPacerRevise, 4, 5, 4.5, 2)
+	pacer(200, trace.GCPacerEnd, 4, 5, 4.9, 2)
+
+	pacer(1000, trace.GCPacerStart, 8, 10, 8.5, 0.5)
+	pacer(1200, trace.GCPacerRevise, 8, 10, 9, 0.75)
+	pacer(1400, trace.GCPacerRevise, 8, 10, 9.5, 1.5)
+	pacer(2000, trace.GCPacerEnd, 8, 10, 11, 1.25)
+
+	pacer(5000, trace.GCPacerStart, 10, 16, 10, 0.25)
+	pacer(6000, trace.GCPacerEnd, 10, 16, 12, 0.25)
+
+	pacer(9000, trace.GCPacerStart, 12, 20, 13.5, 0.5)
+	return events
+}
+
+func TestPacerCycles(t *testing.T) {
+	us := int64(time.Microsecond)
+	const mb = 1 << 20
+	want := []pacerCycle{
+		{
+			N: 1, Start: 1000 * us, End: 2000 * us,
+			Trigger: 8 * mb, Goal: 10 * mb, StartLive: 8.5 * mb, EndLive: 11 * mb,
+			StartRatio: 0.5, MaxRatio: 1.5, EndRatio: 1.25, Revisions: 2,
+			TriggerOvershoot: 0.0625, GoalOvershoot: 0.1,
+		},
+		{
+			N: 2, Start: 5000 * us, End: 6000 * us,
+			Trigger: 10 * mb, Goal: 16 * mb, StartLive: 10 * mb, EndLive: 12 * mb,
+			StartRatio: 0.25, MaxRatio: 0.25, EndRatio: 0.25,
+			TriggerOvershoot: 0, GoalOvershoot: -0.25,
+		},
+		{
+			N: 3, Start: 9000 * us,
+			Trigger: 12 * mb, Goal: 20 * mb, StartLive: 13.5 * mb,
+			StartRatio: 0.5, MaxRatio: 0.5,
+			TriggerOvershoot: 0.125,
+		},
+	}
+	cycles := pacerCycles(pacerEvents())
+	if len(cycles) != len(want) {
+		t.Fatalf("got %d cycles, want %d", len(cycles), len(want))
+	}
+	for i, c := range cycles {
+		got := *c
+		w := want[i]
+		// Compare the derived ratios approximately.
+		if math.Abs(got.TriggerOvershoot-w.TriggerOvershoot) > 1e-9 || math.Abs(got.GoalOvershoot-w.GoalOvershoot) > 1e-9 {
+			t.Errorf("cycle %d: overshoots = %v, %v, want %v, %v", i+1, got.TriggerOvershoot, got.GoalOvershoot, w.TriggerOvershoot, w.GoalOvershoot)
+		}
+		got.TriggerOvershoot, got.GoalOvershoot = 0, 0
+		w.TriggerOvershoot, w.GoalOvershoot = 0, 0
+		if got != w {
+			t.Errorf("cycle %d: got %+v, want %+v", i+1, got, w)
+		}
+	}
+}
+
+func TestSummarizePacer(t *testing.T) {
+	us := int64(time.Microsecond)
+	s := summarizePacer(pacerCycles(pacerEvents()))
+	if s.Ended != 2 || s.Overshot != 1 {
+		t.Errorf("%d of %d cycles overshot, want 1 of 2", s.Overshot, s.Ended)
+	}
+	if want := (0.1 - 0.25) / 2; math.Abs(s.MeanOvershoot-want) > 1e-9 {
+		t.Errorf("mean overshoot = %v, want %v", s.MeanOvershoot, want)
+	}
+	if math.Abs(s.MaxOvershoot-0.1) > 1e-9 {
+		t.Errorf("max overshoot = %v, want 0.1", s.MaxOvershoot)
+	}
+	if r := s.Cycles[0]; r.Duration != time.Millisecond || r.From != 900*us || r.To != 2100*us {
+		t.Errorf("cycle 1: duration %v, window [%d, %d], want 1ms, [%d, %d]", r.Duration, r.From, r.To, 900*us, 2100*us)
+	}
+
+	var buf bytes.Buffer
+	if err := templPacer.Execute(&buf, s); err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		`1 of 2 cycles overshot the heap goal; mean goal overshoot -7.5%, max 10.0%`,
+		`<a href="/trace?from=900000&to=2100000">1000000</a>`,
+		`<td> 10.0% </td>`,
+		`<td> -25.0% </td>`,
+		`0.5 / 1.5 / 1.25`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %s:\n%s", want, page)
+		}
+	}
+
+	buf.Reset()
+	if err := templPacer.Execute(&buf, summarizePacer(nil)); err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+	if !strings.Contains(buf.String(), "no GC pacer events") {
+		t.Errorf("page of a trace without pacer events does not say so:\n%s", buf.String())
+	}
+}
+
+func TestPacerCounters(t *testing.T) {
+	var got []string
+	for _, e := range generateTrace(&traceParams{events: pacerEvents(), endTime: int64(1<<63 - 1)}).Events {
+		if e.Phase == "C" && (e.Name == "GC pacer" || e.Name == "Assist ratio") {
+			got = append(got, fmt.Sprintf("%s %+v", e.Name, e.Arg))
+		}
+	}
+	for _, want := range []string{
+		"GC pacer &{Trigger:8388608 Goal:2097152}",
+		"Assist ratio &{AssistRatio:1.5}",
+		"Assist ratio &{AssistRatio:0}",
+	} {
+		found := false
+		for _, g := range got {
+			if g == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("counters do not include %q:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+}
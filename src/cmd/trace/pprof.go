@@ -10,11 +10,16 @@ import (
 	"bufio"
 	"cmd/internal/pprof/profile"
 	"fmt"
+	"html/template"
 	"internal/trace"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
+	"time"
 )
 
 func init() {
@@ -33,52 +38,152 @@ type Record struct {
 
 // httpIO serves IO pprof-like profile (time spent in IO wait).
 func httpIO(w http.ResponseWriter, r *http.Request) {
-	events, err := parseEvents()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	serveBlockingProfile(w, r, "io")
+}
+
+// httpBlock serves blocking pprof-like profile (time spent blocked on synchronization primitives).
+func httpBlock(w http.ResponseWriter, r *http.Request) {
+	serveBlockingProfile(w, r, "block")
+}
+
+// blockingProfile returns the name of the blocking profile that counts
+// ev, "io" for network waits and "block" for synchronization, or ""
+// if neither counts it.
+func blockingProfile(ev *trace.Event) string {
+	if ev == nil || ev.Link == nil || ev.StkID == 0 || len(ev.Stk) == 0 {
+		return ""
 	}
-	prof := make(map[uint64]Record)
-	for _, ev := range events {
-		if ev.Type != trace.EvGoBlockNet || ev.Link == nil || ev.StkID == 0 || len(ev.Stk) == 0 {
+	switch ev.Type {
+	case trace.EvGoBlockNet:
+		return "io"
+	case trace.EvGoBlockSend, trace.EvGoBlockRecv, trace.EvGoBlockSelect,
+		trace.EvGoBlockSync, trace.EvGoBlockCond:
+		return "block"
+	}
+	return ""
+}
+
+// blockingRecords returns the records of the blocking profile prof,
+// keyed by stack id, counting the events included in params.
+// The stack id is the trace's id of the deduplicated stack, so
+// it identifies the same stack in every view of the trace.
+func blockingRecords(params *traceParams, prof string) map[uint64]Record {
+	recs := make(map[uint64]Record)
+	for _, ev := range params.events {
+		if blockingProfile(ev) != prof || !params.includes(ev) {
 			continue
 		}
-		rec := prof[ev.StkID]
+		rec := recs[ev.StkID]
 		rec.stk = ev.Stk
 		rec.n++
 		rec.time += ev.Link.Ts - ev.Ts
-		prof[ev.StkID] = rec
+		recs[ev.StkID] = rec
 	}
-	serveSVGProfile(w, r, prof)
+	return recs
 }
 
-// httpBlock serves blocking pprof-like profile (time spent blocked on synchronization primitives).
-func httpBlock(w http.ResponseWriter, r *http.Request) {
+// serveBlockingProfile serves the blocking profile prof, restricted by
+// the goid and time window parameters accepted by /trace. With the
+// stacks parameter it lists the stacks, each linking to its instances
+// in the trace, instead of drawing the profile.
+func serveBlockingProfile(w http.ResponseWriter, r *http.Request, prof string) {
 	events, err := parseEvents()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	prof := make(map[uint64]Record)
-	for _, ev := range events {
-		switch ev.Type {
-		case trace.EvGoBlockSend, trace.EvGoBlockRecv, trace.EvGoBlockSelect,
-			trace.EvGoBlockSync, trace.EvGoBlockCond:
-		default:
-			continue
-		}
-		if ev.Link == nil || ev.StkID == 0 || len(ev.Stk) == 0 {
-			continue
+	params, err := parseTraceParams(r, events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	recs := blockingRecords(params, prof)
+	if r.FormValue("stacks") == "" {
+		serveSVGProfile(w, r, recs)
+		return
+	}
+	err = templStacks.Execute(w, stackList(recs, prof, r.Form))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// profileStack is a stack of a blocking profile, as listed by templStacks.
+type profileStack struct {
+	ID     uint64
+	N      uint64
+	Time   time.Duration
+	Frames []*trace.Frame
+	Link   string // the stack's instances in the trace
+}
+
+type profileStackList []*profileStack
+
+func (l profileStackList) Len() int {
+	return len(l)
+}
+
+func (l profileStackList) Less(i, j int) bool {
+	if l[i].Time != l[j].Time {
+		return l[i].Time > l[j].Time
+	}
+	return l[i].ID < l[j].ID
+}
+
+func (l profileStackList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// stackList returns the stacks of the blocking profile prof, by
+// decreasing blocked time. The link of each stack keeps the goid and
+// time window parameters of form.
+func stackList(recs map[uint64]Record, prof string, form url.Values) profileStackList {
+	var l profileStackList
+	for id, rec := range recs {
+		q := make(url.Values)
+		for _, k := range []string{"goid", "from", "to", "wallfrom", "wallto"} {
+			if v, ok := form[k]; ok {
+				q[k] = v
+			}
 		}
-		rec := prof[ev.StkID]
-		rec.stk = ev.Stk
-		rec.n++
-		rec.time += ev.Link.Ts - ev.Ts
-		prof[ev.StkID] = rec
+		q.Set("profile", prof)
+		q.Set("stk", strconv.FormatUint(id, 10))
+		l = append(l, &profileStack{
+			ID:     id,
+			N:      rec.n,
+			Time:   time.Duration(rec.time),
+			Frames: rec.stk,
+			Link:   "/trace?" + q.Encode(),
+		})
 	}
-	serveSVGProfile(w, r, prof)
+	sort.Sort(l)
+	return l
 }
 
+var templStacks = template.Must(template.New("").Parse(`
+<html>
+<body>
+<table border="1">
+<tr>
+<th> Stack </th>
+<th> Count </th>
+<th> Time </th>
+<th> Frames </th>
+</tr>
+{{range .}}
+  <tr>
+    <td> <a href="{{.Link}}">{{.ID}}</a> </td>
+    <td> {{.N}} </td>
+    <td> {{.Time}} </td>
+    <td> {{range .Frames}}{{.Fn}} {{.File}}:{{.Line}}<br>{{end}} </td>
+  </tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
+
 // httpSyscall serves syscall pprof-like profile (time spent blocked in syscalls).
 func httpSyscall(w http.ResponseWriter, r *http.Request) {
 	events, err := parseEvents()
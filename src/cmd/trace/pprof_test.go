@@ -0,0 +1,166 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"internal/trace"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingEvents is a synthetic trace in which goroutines 1 to 4 block
+// on channels, a select and the network at stacks 1 to 3, and are
+// unblocked by goroutine 5 or the network poller. Timestamps are in µs.
+func blockingEvents() []*trace.Event {
+	us := int64(time.Microsecond)
+	stacks := map[uint64][]*trace.Frame{
+		1: {{PC: 0x10, Fn: "main.recv", File: "main.go", Line: 10}, {PC: 0x11, Fn: "main.main", File: "main.go", Line: 3}},
+		2: {{PC: 0x20, Fn: "net.(*conn).Read", File: "net.go", Line: 20}},
+		3: {{PC: 0x30, Fn: "main.sel", File: "main.go", Line: 30}},
+		4: {{PC: 0x40, Fn: "main.send", File: "main.go", Line: 40}},
+	}
+	var events []*trace.Event
+	ev := func(ts int64, typ byte, p int, g uint64, stk uint64) *trace.Event {
+		e := &trace.Event{Ts: ts * us, Type: typ, P: p, G: g, StkID: stk, Stk: stacks[stk]}
+		events = append(events, e)
+		return e
+	}
+	// block blocks g at stk, unblocked by goroutine 5 at stack 4 or,
+	// for a network wait, by the poller at unblockTs. It returns the
+	// blocking event.
+	block := func(ts int64, typ byte, g uint64, stk uint64, unblockTs int64) *trace.Event {
+		b := ev(ts, typ, 0, g, stk)
+		if unblockTs != 0 {
+			if typ == trace.EvGoBlockNet {
+				b.Link = ev(unblockTs, trace.EvGoUnblock, trace.NetpollP, 0, 0)
+			} else {
+				b.Link = ev(unblockTs, trace.EvGoUnblock, 1, 5, 4)
+			}
+			b.Link.Args[0] = g
+		}
+		return b
+	}
+
+	start := ev(50, trace.EvGoStart, 0, 1, 1)
+	start.Link = block(100, trace.EvGoBlockRecv, 1, 1, 200)
+	block(150, trace.EvGoBlockNet, 3, 2, 400)
+	block(500, trace.EvGoBlockRecv, 1, 1, 700)
+	block(600, trace.EvGoBlockRecv, 4, 1, 900)
+	block(800, trace.EvGoBlockSelect, 2, 3, 1000)
+	// A goroutine that is never unblocked is not in the profile.
+	block(1100, trace.EvGoBlockRecv, 2, 1, 0)
+	sort.Stable(eventList(events))
+	return events
+}
+
+type eventList []*trace.Event
+
+func (l eventList) Len() int           { return len(l) }
+func (l eventList) Less(i, j int) bool { return l[i].Ts < l[j].Ts }
+func (l eventList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+// instance is a blocking event, identified by goroutine and time in µs.
+type instance struct {
+	G  uint64
+	Ts float64
+}
+
+// timeline follows link, as listed in the stacks of a blocking profile,
+// and returns the blocking events the trace viewer shows.
+func timeline(t *testing.T, events []*trace.Event, link string) []instance {
+	params, err := parseTraceParams(httptest.NewRequest("GET", link, nil), events)
+	if err != nil {
+		t.Fatalf("%s: %v", link, err)
+	}
+	data := generateTrace(params)
+	var got []instance
+	for _, e := range data.Events {
+		if e.Phase != "X" {
+			continue
+		}
+		arg, ok := e.Arg.(*StackArg)
+		if !ok || arg.Profile != params.profile || arg.Stack != params.stk {
+			t.Errorf("%s: slice %s at %v has args %+v", link, e.Name, e.Time, e.Arg)
+			continue
+		}
+		got = append(got, instance{e.Tid, e.Time + float64(params.startTime)/1000})
+	}
+	return got
+}
+
+func TestBlockingProfileLinks(t *testing.T) {
+	events := blockingEvents()
+	for _, prof := range []string{"io", "block"} {
+		params := &traceParams{events: events, endTime: 1<<63 - 1}
+		stacks := stackList(blockingRecords(params, prof), prof, nil)
+		if len(stacks) == 0 {
+			t.Errorf("%s: no stacks", prof)
+		}
+		for _, s := range stacks {
+			// The timeline shows exactly the events counted for the stack.
+			var want []instance
+			for _, ev := range events {
+				if ev.StkID == s.ID && blockingProfile(ev) == prof {
+					want = append(want, instance{ev.G, float64(ev.Ts) / 1000})
+				}
+			}
+			got := timeline(t, events, s.Link)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: stack %d: timeline shows %v, want %v", prof, s.ID, got, want)
+			}
+			if uint64(len(got)) != s.N {
+				t.Errorf("%s: stack %d: count %d, timeline shows %d events", prof, s.ID, s.N, len(got))
+			}
+		}
+	}
+}
+
+func TestBlockingProfileWindow(t *testing.T) {
+	events := blockingEvents()
+	r := httptest.NewRequest("GET", "/block?stacks=1&from=0&to=550000", nil)
+	params, err := parseTraceParams(r, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stacks := stackList(blockingRecords(params, "block"), "block", r.Form)
+	if len(stacks) != 1 || stacks[0].ID != 1 || stacks[0].N != 2 || stacks[0].Time != 300*time.Microsecond {
+		t.Fatalf("stacks in window = %+v, want stack 1 blocked twice for 300µs", stacks)
+	}
+	link := stacks[0].Link
+	if !strings.Contains(link, "from=0") || !strings.Contains(link, "to=550000") {
+		t.Errorf("link %s does not keep the time window", link)
+	}
+	got := timeline(t, events, link)
+	if want := []instance{{1, 100}, {1, 500}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline shows %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := templStacks.Execute(&buf, stacks); err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+	if want := `<a href="/trace?from=0&amp;profile=block&amp;stk=1&amp;to=550000">1</a>`; !strings.Contains(buf.String(), want) {
+		t.Errorf("page does not link to stack 1 with %s:\n%s", want, buf.String())
+	}
+}
+
+func TestBlockingSliceArgs(t *testing.T) {
+	data := generateTrace(&traceParams{events: blockingEvents(), endTime: 1<<63 - 1})
+	for _, e := range data.Events {
+		if e.Phase == "X" && e.Tid == 0 && e.Time == 50 {
+			// The goroutine's run ends with it blocking at stack 1.
+			if arg, ok := e.Arg.(*StackArg); !ok || *arg != (StackArg{"block", 1}) {
+				t.Errorf("slice %s has args %+v, want stack 1 of the block profile", e.Name, e.Arg)
+			}
+			return
+		}
+	}
+	t.Errorf("no slice for the run of goroutine 1")
+}
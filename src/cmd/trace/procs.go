@@ -0,0 +1,145 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// GOMAXPROCS changes and processor utilization.
+
+package main
+
+import (
+	"internal/trace"
+	"sync"
+	"time"
+)
+
+// procsMargin is the context shown on either side of a GOMAXPROCS change.
+const procsMargin = int64(time.Millisecond)
+
+// procsChange is a change of GOMAXPROCS during the trace.
+type procsChange struct {
+	Ts       int64          // Time of the change.
+	G        uint64         // Goroutine that changed GOMAXPROCS.
+	Old, New int            // GOMAXPROCS before and after the change.
+	Stk      []*trace.Frame // Stack of the call that changed it.
+	From, To int64          // Trace window around the change to show.
+}
+
+// procsSegment is a part of the trace during which GOMAXPROCS
+// did not change.
+type procsSegment struct {
+	From, To    int64   // Time range of the segment.
+	Procs       int     // GOMAXPROCS during the segment.
+	Busy        int64   // Time spent running goroutines, summed over Ps, in ns.
+	Utilization float64 // Busy as a fraction of Procs*(To-From).
+}
+
+type procsAnalysis struct {
+	Changes  []*procsChange
+	Segments []procsSegment
+
+	// Utilization is the fraction of the available P time spent
+	// running goroutines over the whole trace. The available time
+	// is computed from the value of GOMAXPROCS in effect at each
+	// instant, so Ps that do not exist for part of the trace do
+	// not count as idle.
+	Utilization float64
+}
+
+// procsUtilization finds the GOMAXPROCS changes in events and computes
+// the P utilization of each part of the trace between changes.
+func procsUtilization(events []*trace.Event) *procsAnalysis {
+	a := new(procsAnalysis)
+	ps := make(map[int]*pState)
+	busy := 0 // number of Ps running a goroutine
+	var seg *procsSegment
+	var last int64
+	var busyTotal, available int64
+	closeSegment := func(ts int64) {
+		seg.To = ts
+		if d := int64(seg.Procs) * (seg.To - seg.From); d > 0 {
+			seg.Utilization = float64(seg.Busy) / float64(d)
+			available += d
+		}
+		busyTotal += seg.Busy
+		a.Segments = append(a.Segments, *seg)
+	}
+	run := func(p int, g uint64) {
+		st := ps[p]
+		if st == nil {
+			// The P was started before the trace.
+			st = &pState{}
+			ps[p] = st
+		}
+		switch {
+		case st.g == 0 && g != 0:
+			busy++
+		case st.g != 0 && g == 0:
+			busy--
+		}
+		st.g = g
+	}
+	for _, ev := range events {
+		if seg != nil {
+			seg.Busy += int64(busy) * (ev.Ts - last)
+		}
+		last = ev.Ts
+		switch ev.Type {
+		case trace.EvGomaxprocs:
+			procs := int(ev.Args[0])
+			if seg == nil {
+				seg = &procsSegment{From: ev.Ts, Procs: procs}
+				continue
+			}
+			if procs == seg.Procs {
+				continue
+			}
+			from := ev.Ts - procsMargin
+			if from < 0 {
+				from = 0
+			}
+			a.Changes = append(a.Changes, &procsChange{
+				Ts:   ev.Ts,
+				G:    ev.G,
+				Old:  seg.Procs,
+				New:  procs,
+				Stk:  ev.Stk,
+				From: from,
+				To:   ev.Ts + procsMargin,
+			})
+			closeSegment(ev.Ts)
+			seg = &procsSegment{From: ev.Ts, Procs: procs}
+		case trace.EvProcStop:
+			run(ev.P, 0)
+			delete(ps, ev.P)
+		case trace.EvGoStart:
+			run(ev.P, ev.G)
+		case trace.EvGoEnd, trace.EvGoStop, trace.EvGoSched, trace.EvGoPreempt,
+			trace.EvGoSleep, trace.EvGoBlock, trace.EvGoBlockSend, trace.EvGoBlockRecv,
+			trace.EvGoBlockSelect, trace.EvGoBlockSync, trace.EvGoBlockCond, trace.EvGoBlockNet,
+			trace.EvGoSysBlock:
+			if ev.P < trace.FakeP {
+				run(ev.P, 0)
+			}
+		}
+	}
+	if seg != nil {
+		closeSegment(last)
+	}
+	if available > 0 {
+		a.Utilization = float64(busyTotal) / float64(available)
+	}
+	return a
+}
+
+var (
+	procsInit  sync.Once
+	procsStats *procsAnalysis
+)
+
+// analyzeProcs computes the GOMAXPROCS changes and the P utilization
+// and stores them in procsStats.
+func analyzeProcs(events []*trace.Event) {
+	procsInit.Do(func() {
+		procsStats = procsUtilization(events)
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"internal/trace"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// procsEvents is a synthetic trace in which goroutine 1 raises
+// GOMAXPROCS from 2 to 4 at 1ms and goroutine 6 lowers it to 1 at
+// 2ms. Timestamps are in µs.
+func procsEvents() []*trace.Event {
+	us := int64(time.Microsecond)
+	var events []*trace.Event
+	ev := func(ts int64, typ byte, p int, g uint64, args ...uint64) *trace.Event {
+		e := &trace.Event{Ts: ts * us, Type: typ, P: p, G: g}
+		copy(e.Args[:], args)
+		events = append(events, e)
+		return e
+	}
+	// run runs g on p from start to end, when it blocks.
+	run := func(start, end int64, p int, g uint64) {
+		s := ev(start, trace.EvGoStart, p, g, g)
+		s.Link = ev(end, trace.EvGoBlock, p, g)
+	}
+	procs := func(ts int64, g uint64, n uint64) {
+		e := ev(ts, trace.EvGomaxprocs, 0, g, n)
+		e.Stk = []*trace.Frame{
+			{PC: 1, Fn: "runtime.GOMAXPROCS", File: "proc.go", Line: 10},
+			{PC: 2, Fn: "main.main", File: "main.go", Line: 20},
+		}
+	}
+
+	procs(0, 0, 2)
+	ev(0, trace.EvProcStart, 0, 0)
+	ev(0, trace.EvProcStart, 1, 0)
+
+	// One of two Ps is busy: 50%.
+	s := ev(0, trace.EvGoStart, 0, 1, 1)
+	procs(1000, 1, 4)
+	s.Link = ev(1000, trace.EvGoBlock, 0, 1)
+
+	// All four Ps are busy: 100%.
+	ev(1000, trace.EvProcStart, 2, 0)
+	ev(1000, trace.EvProcStart, 3, 0)
+	for p := 0; p < 4; p++ {
+		run(1000, 2000, p, uint64(2+p))
+	}
+
+	// The only P is busy half of the time: 50%.
+	s = ev(2000, trace.EvGoStart, 0, 6, 6)
+	procs(2000, 6, 1)
+	ev(2000, trace.EvProcStop, 1, 0)
+	ev(2000, trace.EvProcStop, 2, 0)
+	ev(2000, trace.EvProcStop, 3, 0)
+	s.Link = ev(2500, trace.EvGoBlock, 0, 6)
+	ev(3000, trace.EvProcStop, 0, 0)
+	return events
+}
+
+func TestProcsUtilization(t *testing.T) {
+	us := int64(time.Microsecond)
+	a := procsUtilization(procsEvents())
+
+	if len(a.Changes) != 2 {
+		t.Fatalf("got %d GOMAXPROCS changes, want 2", len(a.Changes))
+	}
+	wantChanges := []procsChange{
+		{Ts: 1000 * us, G: 1, Old: 2, New: 4, From: 0, To: 2000 * us},
+		{Ts: 2000 * us, G: 6, Old: 4, New: 1, From: 1000 * us, To: 3000 * us},
+	}
+	for i, c := range a.Changes {
+		got := *c
+		got.Stk = nil
+		if !reflect.DeepEqual(got, wantChanges[i]) {
+			t.Errorf("change %d: got %+v, want %+v", i, got, wantChanges[i])
+		}
+		if len(c.Stk) != 2 || c.Stk[1].Fn != "main.main" {
+			t.Errorf("change %d: stack is missing the caller", i)
+		}
+	}
+
+	wantSegments := []procsSegment{
+		{From: 0, To: 1000 * us, Procs: 2, Busy: 1000 * us, Utilization: 0.5},
+		{From: 1000 * us, To: 2000 * us, Procs: 4, Busy: 4000 * us, Utilization: 1},
+		{From: 2000 * us, To: 3000 * us, Procs: 1, Busy: 500 * us, Utilization: 0.5},
+	}
+	if len(a.Segments) != len(wantSegments) {
+		t.Fatalf("got %d segments, want %d", len(a.Segments), len(wantSegments))
+	}
+	for i, seg := range a.Segments {
+		if seg != wantSegments[i] {
+			t.Errorf("segment %d: got %+v, want %+v", i, seg, wantSegments[i])
+		}
+	}
+
+	// 5.5ms of busy P time out of 2*1ms + 4*1ms + 1*1ms available.
+	// Normalizing by the largest P count instead would give 5.5/12.
+	if want := 5.5 / 7; math.Abs(a.Utilization-want) > 1e-9 {
+		t.Errorf("utilization = %v, want %v", a.Utilization, want)
+	}
+}
+
+func TestProcsMarkers(t *testing.T) {
+	markers := func(params *traceParams) []string {
+		var names []string
+		for _, e := range generateTrace(params).Events {
+			if strings.HasPrefix(e.Name, "GOMAXPROCS") {
+				if e.Phase != "I" || e.Scope != "g" {
+					t.Errorf("marker %q is not a global instant: phase %q, scope %q", e.Name, e.Phase, e.Scope)
+				}
+				names = append(names, e.Name)
+			}
+		}
+		return names
+	}
+
+	got := markers(&traceParams{events: procsEvents(), endTime: int64(1<<63 - 1)})
+	if want := "GOMAXPROCS 2 -> 4,GOMAXPROCS 4 -> 1"; strings.Join(got, ",") != want {
+		t.Errorf("markers = %q, want %q", got, want)
+	}
+
+	// A window starting after the first change still shows the
+	// old value of the second one.
+	us := int64(time.Microsecond)
+	got = markers(&traceParams{events: procsEvents(), startTime: 1500 * us, endTime: 3000 * us})
+	if want := "GOMAXPROCS 4 -> 1"; strings.Join(got, ",") != want {
+		t.Errorf("markers in window = %q, want %q", got, want)
+	}
+}
+
+func TestProcsSummary(t *testing.T) {
+	data := struct {
+		Ranges     []Range
+		Start, End string
+		Procs      *procsAnalysis
+		Pacer      bool
+	}{Procs: procsUtilization(procsEvents())}
+	var buf bytes.Buffer
+	if err := templMain.Execute(&buf, data); err != nil {
+		t.Fatalf("failed to execute template: %v", err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		`<a href="/trace?from=0&to=2000000">1000000</a>`,
+		`2 &rarr; 4`,
+		`4 &rarr; 1`,
+		`main.main main.go:20`,
+		`Overall utilization: 78.6%`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("summary does not contain %s:\n%s", want, page)
+		}
+	}
+}
@@ -5,10 +5,13 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"internal/trace"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"path/filepath"
 	"runtime"
@@ -139,6 +142,46 @@ func httpJsonTrace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := parseTraceParams(r, events)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	start, end := 0, -1
+	if startStr, endStr := r.FormValue("start"), r.FormValue("end"); startStr != "" && endStr != "" {
+		// If start/end arguments are present, we are rendering a range of the trace.
+		start, err = strconv.Atoi(startStr)
+		if err != nil {
+			log.Printf("failed to parse start parameter '%v': %v", startStr, err)
+			return
+		}
+		end, err = strconv.Atoi(endStr)
+		if err != nil {
+			log.Printf("failed to parse end parameter '%v': %v", endStr, err)
+			return
+		}
+		if start < 0 || end <= start {
+			log.Printf("bogus start/end parameters: %v/%v", start, end)
+			return
+		}
+	}
+
+	// The events are written as they are generated, so that the json
+	// of a large trace is never in memory all at once.
+	bw := bufio.NewWriter(w)
+	streamTrace(params, viewerDataTraceConsumer(bw, start, end))
+	if err := bw.Flush(); err != nil {
+		log.Printf("failed to serialize trace: %v", err)
+		return
+	}
+}
+
+// parseTraceParams returns the part of events to render for the request r:
+// the goroutine goid and the goroutines related to it, the time window
+// from/to (or wallfrom/wallto in wall clock time), and the instances of
+// the stack stk in the blocking profile named by the profile parameter.
+func parseTraceParams(r *http.Request, events []*trace.Event) (*traceParams, error) {
 	params := &traceParams{
 		events:  events,
 		endTime: int64(1<<63 - 1),
@@ -148,8 +191,7 @@ func httpJsonTrace(w http.ResponseWriter, r *http.Request) {
 		// If goid argument is present, we are rendering a trace for this particular goroutine.
 		goid, err := strconv.ParseUint(goids, 10, 64)
 		if err != nil {
-			log.Printf("failed to parse goid parameter '%v': %v", goids, err)
-			return
+			return nil, fmt.Errorf("failed to parse goid parameter '%v': %v", goids, err)
 		}
 		analyzeGoroutines(events)
 		g := gs[goid]
@@ -160,31 +202,68 @@ func httpJsonTrace(w http.ResponseWriter, r *http.Request) {
 		params.gs = trace.RelatedGoroutines(events, goid)
 	}
 
-	data := generateTrace(params)
-
-	if startStr, endStr := r.FormValue("start"), r.FormValue("end"); startStr != "" && endStr != "" {
-		// If start/end arguments are present, we are rendering a range of the trace.
-		start, err := strconv.ParseUint(startStr, 10, 64)
+	var from, to int64
+	window := false
+	if fromStr, toStr := r.FormValue("from"), r.FormValue("to"); fromStr != "" && toStr != "" {
+		// If from/to arguments are present, we are rendering a time window of the trace.
+		window = true
+		var err error
+		from, err = strconv.ParseInt(fromStr, 10, 64)
 		if err != nil {
-			log.Printf("failed to parse start parameter '%v': %v", startStr, err)
-			return
+			return nil, fmt.Errorf("failed to parse from parameter '%v': %v", fromStr, err)
 		}
-		end, err := strconv.ParseUint(endStr, 10, 64)
+		to, err = strconv.ParseInt(toStr, 10, 64)
 		if err != nil {
-			log.Printf("failed to parse end parameter '%v': %v", endStr, err)
-			return
+			return nil, fmt.Errorf("failed to parse to parameter '%v': %v", toStr, err)
 		}
-		if start >= uint64(len(data.Events)) || end <= start || end > uint64(len(data.Events)) {
-			log.Printf("bogus start/end parameters: %v/%v, trace size %v", start, end, len(data.Events))
-			return
+	}
+	if fromStr, toStr := r.FormValue("wallfrom"), r.FormValue("wallto"); fromStr != "" && toStr != "" {
+		// The same, but the window is given in wall clock time.
+		clock := trace.NewWallClock(events)
+		if clock == nil {
+			return nil, fmt.Errorf("trace has no clock sync events to convert wallfrom/wallto parameters")
+		}
+		window = true
+		var err error
+		from, err = wallTs(clock, fromStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse wallfrom parameter '%v': %v", fromStr, err)
+		}
+		to, err = wallTs(clock, toStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse wallto parameter '%v': %v", toStr, err)
 		}
-		data.Events = append(data.Events[start:end], data.Events[data.footer:]...)
 	}
-	err = json.NewEncoder(w).Encode(data)
-	if err != nil {
-		log.Printf("failed to serialize trace: %v", err)
-		return
+	if window {
+		if from < params.startTime {
+			from = params.startTime
+		}
+		if to > params.endTime {
+			to = params.endTime
+		}
+		params.startTime = from
+		params.endTime = to
 	}
+
+	if prof, stks := r.FormValue("profile"), r.FormValue("stk"); prof != "" && stks != "" {
+		// If profile/stk arguments are present, we are rendering the
+		// blocking events of this stack, one row per goroutine.
+		stk, err := strconv.ParseUint(stks, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stk parameter '%v': %v", stks, err)
+		}
+		gs := make(map[uint64]bool)
+		for _, ev := range events {
+			if ev.StkID == stk && blockingProfile(ev) == prof && params.includes(ev) {
+				gs[ev.G] = true
+			}
+		}
+		params.gtrace = true
+		params.gs = gs
+		params.profile = prof
+		params.stk = stk
+	}
+	return params, nil
 }
 
 type Range struct {
@@ -193,31 +272,56 @@ type Range struct {
 	End   int
 }
 
-// splitTrace splits the trace into a number of ranges,
+// splitTrace splits the trace generated by generate into a number of ranges,
 // each resulting in approx 100MB of json output (trace viewer can hardly handle more).
-func splitTrace(data ViewerData) []Range {
+// Only the time and json size of each event are kept, not the events.
+func splitTrace(generate func(traceConsumer)) []Range {
 	const rangeSize = 100 << 20
-	var ranges []Range
+	type eventSize struct {
+		time float64
+		size int
+	}
+	var sizes []eventSize
+	// auxSize is the size of the mandatory part of the trace,
+	// which is in every range. This includes stack traces and thread names.
+	auxSize := 0
 	cw := new(countingWriter)
 	enc := json.NewEncoder(cw)
-	// First calculate size of the mandatory part of the trace.
-	// This includes stack traces and thread names.
-	data1 := data
-	data1.Events = data.Events[data.footer:]
-	enc.Encode(data1)
-	auxSize := cw.size
-	cw.size = 0
-	// Then calculate size of each individual event and group them into ranges.
-	for i, start := 0, 0; i < data.footer; i++ {
-		enc.Encode(data.Events[i])
-		if cw.size+auxSize > rangeSize || i == data.footer-1 {
+	encodedSize := func(v interface{}) int {
+		cw.size = 0
+		enc.Encode(v)
+		return cw.size
+	}
+	generate(traceConsumer{
+		consumeTimeUnit: func(unit string) {
+			auxSize += encodedSize(unit)
+		},
+		consumeViewerEvent: func(v *ViewerEvent, required bool) {
+			if required {
+				auxSize += encodedSize(v)
+				return
+			}
+			sizes = append(sizes, eventSize{v.Time, encodedSize(v)})
+		},
+		consumeViewerFrame: func(key string, f ViewerFrame) {
+			auxSize += encodedSize(key) + encodedSize(f)
+		},
+		flush: func() {},
+	})
+
+	// Group the events into ranges.
+	var ranges []Range
+	size := 0
+	for i, start := 0, 0; i < len(sizes); i++ {
+		size += sizes[i].size
+		if size+auxSize > rangeSize || i == len(sizes)-1 {
 			ranges = append(ranges, Range{
-				Name:  fmt.Sprintf("%v-%v", time.Duration(data.Events[start].Time*1000), time.Duration(data.Events[i].Time*1000)),
+				Name:  fmt.Sprintf("%v-%v", time.Duration(sizes[start].time*1000), time.Duration(sizes[i].time*1000)),
 				Start: start,
 				End:   i + 1,
 			})
 			start = i + 1
-			cw.size = 0
+			size = 0
 		}
 	}
 	if len(ranges) == 1 {
@@ -242,11 +346,25 @@ type traceParams struct {
 	endTime   int64
 	maing     uint64
 	gs        map[uint64]bool
+
+	// If profile is set, only the blocking events with stack stk
+	// counted by that blocking profile are rendered.
+	profile string
+	stk     uint64
+}
+
+// includes reports whether ev is within the time window of p
+// and from one of its goroutines.
+func (p *traceParams) includes(ev *trace.Event) bool {
+	if p.gs != nil && ev.P < trace.FakeP && !p.gs[ev.G] {
+		return false
+	}
+	return ev.Ts >= p.startTime && ev.Ts <= p.endTime
 }
 
 type traceContext struct {
 	*traceParams
-	data      ViewerData
+	consumer  traceConsumer
 	frameTree frameNode
 	frameSeq  int
 	arrowSeq  uint64
@@ -257,6 +375,7 @@ type traceContext struct {
 	grunning  uint64
 	insyscall uint64
 	prunning  uint64
+	procs     uint64 // current GOMAXPROCS
 }
 
 type frameNode struct {
@@ -273,6 +392,68 @@ type ViewerData struct {
 	footer int
 }
 
+// consume feeds data to c, as streamTrace would have generated it.
+func (data ViewerData) consume(c traceConsumer) {
+	c.consumeTimeUnit(data.TimeUnit)
+	for key, f := range data.Frames {
+		c.consumeViewerFrame(key, f)
+	}
+	for i, e := range data.Events {
+		c.consumeViewerEvent(e, i >= data.footer)
+	}
+	c.flush()
+}
+
+// A traceConsumer receives the parts of the trace viewer data as
+// streamTrace generates them. The required events are the mandatory
+// part of the trace, such as thread names, which is in every range of
+// a split trace. The ranges index the events that are not required.
+type traceConsumer struct {
+	consumeTimeUnit    func(unit string)
+	consumeViewerEvent func(v *ViewerEvent, required bool)
+	consumeViewerFrame func(key string, f ViewerFrame)
+	flush              func()
+}
+
+// viewerDataTraceConsumer returns a consumer that writes the trace to w
+// as the json of a ViewerData, each event as it comes. Of the events
+// that are not required, only those with index start to end-1 are
+// written, or from start on if end is negative.
+func viewerDataTraceConsumer(w io.Writer, start, end int) traceConsumer {
+	enc := json.NewEncoder(w)
+	frames := make(map[string]ViewerFrame)
+	unit := ""
+	i := 0 // index of the next event that is not required
+	sep := ""
+	io.WriteString(w, `{"traceEvents":[`)
+	return traceConsumer{
+		consumeTimeUnit: func(u string) {
+			unit = u
+		},
+		consumeViewerEvent: func(v *ViewerEvent, required bool) {
+			if !required {
+				i++
+				if i <= start || end >= 0 && i > end {
+					return
+				}
+			}
+			io.WriteString(w, sep)
+			sep = ","
+			enc.Encode(v)
+		},
+		consumeViewerFrame: func(key string, f ViewerFrame) {
+			frames[key] = f
+		},
+		flush: func() {
+			io.WriteString(w, `],"stackFrames":`)
+			enc.Encode(frames)
+			io.WriteString(w, `,"displayTimeUnit":`)
+			enc.Encode(unit)
+			io.WriteString(w, "}\n")
+		},
+	}
+}
+
 type ViewerEvent struct {
 	Name     string      `json:"name,omitempty"`
 	Phase    string      `json:"ph"`
@@ -300,18 +481,46 @@ type SortIndexArg struct {
 	Index int `json:"sort_index"`
 }
 
-// generateTrace generates json trace for trace-viewer:
+// generateTrace generates the trace viewer data of params all at once.
+// See streamTrace.
+func generateTrace(params *traceParams) ViewerData {
+	data := ViewerData{Frames: make(map[string]ViewerFrame)}
+	var footer []*ViewerEvent
+	streamTrace(params, traceConsumer{
+		consumeTimeUnit: func(unit string) {
+			data.TimeUnit = unit
+		},
+		consumeViewerEvent: func(v *ViewerEvent, required bool) {
+			if required {
+				footer = append(footer, v)
+				return
+			}
+			data.Events = append(data.Events, v)
+		},
+		consumeViewerFrame: func(key string, f ViewerFrame) {
+			data.Frames[key] = f
+		},
+		flush: func() {
+			data.footer = len(data.Events)
+			data.Events = append(data.Events, footer...)
+		},
+	})
+	return data
+}
+
+// streamTrace generates json trace for trace-viewer:
 // https://github.com/google/trace-viewer
 // Trace format is described at:
 // https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU/view
+// and feeds it to c as it goes.
 // If gtrace=true, generate trace for goroutine goid, otherwise whole trace.
 // startTime, endTime determine part of the trace that we are interested in.
 // gset restricts goroutines that are included in the resulting trace.
-func generateTrace(params *traceParams) ViewerData {
-	ctx := &traceContext{traceParams: params}
+// If profile is set, only the blocking events with stack stk are included.
+func streamTrace(params *traceParams, c traceConsumer) {
+	ctx := &traceContext{traceParams: params, consumer: c}
 	ctx.frameTree.children = make(map[uint64]frameNode)
-	ctx.data.Frames = make(map[string]ViewerFrame)
-	ctx.data.TimeUnit = "ns"
+	c.consumeTimeUnit("ns")
 	maxProc := 0
 	gnames := make(map[uint64]string)
 	for _, ev := range ctx.events {
@@ -327,12 +536,27 @@ func generateTrace(params *traceParams) ViewerData {
 			}
 		}
 
+		// Track GOMAXPROCS outside of the timeframe too, so that the
+		// first change inside it is shown with the right old value.
+		if ev.Type == trace.EvGomaxprocs {
+			old := ctx.procs
+			ctx.procs = ev.Args[0]
+			if old != 0 && old != ctx.procs && ev.Ts >= ctx.startTime && ev.Ts <= ctx.endTime {
+				ctx.emitProcsChange(ev, old)
+			}
+			continue
+		}
+
 		// Ignore events that are from uninteresting goroutines
 		// or outside of the interesting timeframe.
-		if ctx.gs != nil && ev.P < trace.FakeP && !ctx.gs[ev.G] {
+		if !ctx.includes(ev) {
 			continue
 		}
-		if ev.Ts < ctx.startTime || ev.Ts > ctx.endTime {
+
+		if ctx.profile != "" {
+			if ev.StkID == ctx.stk && blockingProfile(ev) == ctx.profile {
+				ctx.emitBlocked(ev)
+			}
 			continue
 		}
 
@@ -419,29 +643,32 @@ func generateTrace(params *traceParams) ViewerData {
 		case trace.EvNextGC:
 			ctx.nextGC = ev.Args[0]
 			ctx.emitHeapCounters(ev)
+		case trace.EvClockSync:
+			ctx.emitClockSync(ev)
+		case trace.EvGCPacer:
+			ctx.emitPacerCounters(ev)
 		}
 	}
 
-	ctx.data.footer = len(ctx.data.Events)
-	ctx.emit(&ViewerEvent{Name: "process_name", Phase: "M", Pid: 0, Arg: &NameArg{"PROCS"}})
-	ctx.emit(&ViewerEvent{Name: "process_sort_index", Phase: "M", Pid: 0, Arg: &SortIndexArg{1}})
+	ctx.emitFooter(&ViewerEvent{Name: "process_name", Phase: "M", Pid: 0, Arg: &NameArg{"PROCS"}})
+	ctx.emitFooter(&ViewerEvent{Name: "process_sort_index", Phase: "M", Pid: 0, Arg: &SortIndexArg{1}})
 
-	ctx.emit(&ViewerEvent{Name: "process_name", Phase: "M", Pid: 1, Arg: &NameArg{"STATS"}})
-	ctx.emit(&ViewerEvent{Name: "process_sort_index", Phase: "M", Pid: 1, Arg: &SortIndexArg{0}})
+	ctx.emitFooter(&ViewerEvent{Name: "process_name", Phase: "M", Pid: 1, Arg: &NameArg{"STATS"}})
+	ctx.emitFooter(&ViewerEvent{Name: "process_sort_index", Phase: "M", Pid: 1, Arg: &SortIndexArg{0}})
 
-	ctx.emit(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: trace.NetpollP, Arg: &NameArg{"Network"}})
-	ctx.emit(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: trace.NetpollP, Arg: &SortIndexArg{-5}})
+	ctx.emitFooter(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: trace.NetpollP, Arg: &NameArg{"Network"}})
+	ctx.emitFooter(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: trace.NetpollP, Arg: &SortIndexArg{-5}})
 
-	ctx.emit(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: trace.TimerP, Arg: &NameArg{"Timers"}})
-	ctx.emit(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: trace.TimerP, Arg: &SortIndexArg{-4}})
+	ctx.emitFooter(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: trace.TimerP, Arg: &NameArg{"Timers"}})
+	ctx.emitFooter(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: trace.TimerP, Arg: &SortIndexArg{-4}})
 
-	ctx.emit(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: trace.SyscallP, Arg: &NameArg{"Syscalls"}})
-	ctx.emit(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: trace.SyscallP, Arg: &SortIndexArg{-3}})
+	ctx.emitFooter(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: trace.SyscallP, Arg: &NameArg{"Syscalls"}})
+	ctx.emitFooter(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: trace.SyscallP, Arg: &SortIndexArg{-3}})
 
 	if !ctx.gtrace {
 		for i := 0; i <= maxProc; i++ {
-			ctx.emit(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: uint64(i), Arg: &NameArg{fmt.Sprintf("Proc %v", i)}})
-			ctx.emit(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: uint64(i), Arg: &SortIndexArg{i}})
+			ctx.emitFooter(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: uint64(i), Arg: &NameArg{fmt.Sprintf("Proc %v", i)}})
+			ctx.emitFooter(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: uint64(i), Arg: &SortIndexArg{i}})
 		}
 	}
 
@@ -450,17 +677,22 @@ func generateTrace(params *traceParams) ViewerData {
 			if !ctx.gs[k] {
 				continue
 			}
-			ctx.emit(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: k, Arg: &NameArg{v}})
+			ctx.emitFooter(&ViewerEvent{Name: "thread_name", Phase: "M", Pid: 0, Tid: k, Arg: &NameArg{v}})
 		}
-		ctx.emit(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: ctx.maing, Arg: &SortIndexArg{-2}})
-		ctx.emit(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: 0, Arg: &SortIndexArg{-1}})
+		ctx.emitFooter(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: ctx.maing, Arg: &SortIndexArg{-2}})
+		ctx.emitFooter(&ViewerEvent{Name: "thread_sort_index", Phase: "M", Pid: 0, Tid: 0, Arg: &SortIndexArg{-1}})
 	}
 
-	return ctx.data
+	c.flush()
 }
 
 func (ctx *traceContext) emit(e *ViewerEvent) {
-	ctx.data.Events = append(ctx.data.Events, e)
+	ctx.consumer.consumeViewerEvent(e, false)
+}
+
+// emitFooter emits e in the mandatory part of the trace.
+func (ctx *traceContext) emitFooter(e *ViewerEvent) {
+	ctx.consumer.consumeViewerEvent(e, true)
 }
 
 func (ctx *traceContext) time(ev *trace.Event) float64 {
@@ -476,7 +708,20 @@ func (ctx *traceContext) proc(ev *trace.Event) uint64 {
 	}
 }
 
+// StackArg identifies the stack of a blocking event
+// in the blocking profile that counts it.
+type StackArg struct {
+	Profile string
+	Stack   uint64
+}
+
 func (ctx *traceContext) emitSlice(ev *trace.Event, name string) {
+	var arg interface{}
+	if prof := blockingProfile(ev.Link); prof != "" {
+		// The slice ends with the goroutine blocking:
+		// link it to the stack in the profile.
+		arg = &StackArg{prof, ev.Link.StkID}
+	}
 	ctx.emit(&ViewerEvent{
 		Name:     name,
 		Phase:    "X",
@@ -485,6 +730,23 @@ func (ctx *traceContext) emitSlice(ev *trace.Event, name string) {
 		Tid:      ctx.proc(ev),
 		Stack:    ctx.stack(ev.Stk),
 		EndStack: ctx.stack(ev.Link.Stk),
+		Arg:      arg,
+	})
+}
+
+// emitBlocked emits a slice for the time the goroutine was blocked by
+// ev, a blocking event counted by the profile ctx.profile, ending with
+// the stack of the goroutine that unblocked it.
+func (ctx *traceContext) emitBlocked(ev *trace.Event) {
+	ctx.emit(&ViewerEvent{
+		Name:     trace.EventDescriptions[ev.Type].Name,
+		Phase:    "X",
+		Time:     ctx.time(ev),
+		Dur:      ctx.time(ev.Link) - ctx.time(ev),
+		Tid:      ctx.proc(ev),
+		Stack:    ctx.stack(ev.Stk),
+		EndStack: ctx.stack(ev.Link.Stk),
+		Arg:      &StackArg{ctx.profile, ev.StkID},
 	})
 }
 
@@ -503,6 +765,33 @@ func (ctx *traceContext) emitHeapCounters(ev *trace.Event) {
 	ctx.emit(&ViewerEvent{Name: "Heap", Phase: "C", Time: ctx.time(ev), Pid: 1, Arg: &Arg{ctx.heapAlloc, diff}})
 }
 
+// emitPacerCounters emits the GC pacer state in ev: the trigger with
+// the goal stacked above it, and the assist ratio, which drops to zero
+// when the mark phase ends and assists stop.
+func (ctx *traceContext) emitPacerCounters(ev *trace.Event) {
+	type Arg struct {
+		Trigger uint64
+		Goal    uint64
+	}
+	type RatioArg struct {
+		AssistRatio float64
+	}
+	if ctx.gtrace {
+		return
+	}
+	trigger, goal := ev.Args[1], ev.Args[2]
+	diff := uint64(0)
+	if goal > trigger {
+		diff = goal - trigger
+	}
+	ratio := math.Float64frombits(ev.Args[4])
+	if ev.Args[0] == trace.GCPacerEnd {
+		ratio = 0
+	}
+	ctx.emit(&ViewerEvent{Name: "GC pacer", Phase: "C", Time: ctx.time(ev), Pid: 1, Arg: &Arg{trigger, diff}})
+	ctx.emit(&ViewerEvent{Name: "Assist ratio", Phase: "C", Time: ctx.time(ev), Pid: 1, Arg: &RatioArg{ratio}})
+}
+
 func (ctx *traceContext) emitGoroutineCounters(ev *trace.Event) {
 	type Arg struct {
 		Running  uint64
@@ -536,6 +825,30 @@ func (ctx *traceContext) emitInstant(ev *trace.Event, name string) {
 	ctx.emit(&ViewerEvent{Name: name, Phase: "I", Scope: "t", Time: ctx.time(ev), Tid: ctx.proc(ev), Stack: ctx.stack(ev.Stk), Arg: arg})
 }
 
+// emitClockSync emits a global instant showing the wall clock time.
+func (ctx *traceContext) emitClockSync(ev *trace.Event) {
+	type Arg struct {
+		WallClock string
+	}
+	ctx.emit(&ViewerEvent{Name: "clock sync", Phase: "I", Scope: "g", Time: ctx.time(ev), Arg: &Arg{wallTime(int64(ev.Args[0]))}})
+}
+
+// emitProcsChange emits a global instant marking a change of
+// GOMAXPROCS from old to the value in ev, across all rows.
+func (ctx *traceContext) emitProcsChange(ev *trace.Event, old uint64) {
+	type Arg struct {
+		Old, New uint64
+	}
+	ctx.emit(&ViewerEvent{
+		Name:  fmt.Sprintf("GOMAXPROCS %v -> %v", old, ev.Args[0]),
+		Phase: "I",
+		Scope: "g",
+		Time:  ctx.time(ev),
+		Stack: ctx.stack(ev.Stk),
+		Arg:   &Arg{old, ev.Args[0]},
+	})
+}
+
 func (ctx *traceContext) emitArrow(ev *trace.Event, name string) {
 	if ev.Link == nil {
 		// The other end of the arrow is not captured in the trace.
@@ -576,7 +889,23 @@ func (ctx *traceContext) buildBranch(parent frameNode, stk []*trace.Frame) int {
 		node.id = ctx.frameSeq
 		node.children = make(map[uint64]frameNode)
 		parent.children[frame.PC] = node
-		ctx.data.Frames[strconv.Itoa(node.id)] = ViewerFrame{fmt.Sprintf("%v:%v", frame.Fn, frame.Line), parent.id}
+		ctx.consumer.consumeViewerFrame(strconv.Itoa(node.id), ViewerFrame{fmt.Sprintf("%v:%v", frame.Fn, frame.Line), parent.id})
 	}
 	return ctx.buildBranch(node, stk)
 }
+
+// wallTime formats the wall clock time wall, in nanoseconds since the
+// Unix epoch, for display.
+func wallTime(wall int64) string {
+	return time.Unix(0, wall).UTC().Format(time.RFC3339Nano)
+}
+
+// wallTs returns the trace timestamp of the wall clock time s,
+// given in RFC 3339 format.
+func wallTs(clock *trace.WallClock, s string) (int64, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Ts(t), nil
+}
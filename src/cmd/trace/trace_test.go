@@ -0,0 +1,103 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"internal/trace"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// viewerJSON returns the json of data decoded into generic values.
+func viewerJSON(t *testing.T, data []byte) interface{} {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("bad json: %v\n%s", err, data)
+	}
+	return v
+}
+
+func TestStreamTrace(t *testing.T) {
+	params := &traceParams{events: blockingEvents(), endTime: 1<<63 - 1}
+	data := generateTrace(params)
+	if data.footer < 2 {
+		t.Fatalf("trace has %d events, want at least 2", data.footer)
+	}
+	for _, r := range []Range{{Start: 0, End: -1}, {Start: 1, End: data.footer - 1}} {
+		var buf bytes.Buffer
+		streamTrace(params, viewerDataTraceConsumer(&buf, r.Start, r.End))
+		want := data
+		if r.End >= 0 && r.End < data.footer {
+			want.Events = append(data.Events[r.Start:r.End:r.End], data.Events[data.footer:]...)
+		}
+		wantJSON, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := viewerJSON(t, buf.Bytes()), viewerJSON(t, wantJSON); !reflect.DeepEqual(got, want) {
+			t.Errorf("range %d-%d: streamed json differs:\n%s\nwant:\n%s", r.Start, r.End, buf.Bytes(), wantJSON)
+		}
+	}
+}
+
+// runEvents is a synthetic trace in which goroutine 1 runs n times on
+// P 0 for 1µs.
+func runEvents(n int) []*trace.Event {
+	events := make([]*trace.Event, 0, 2*n+1)
+	events = append(events, &trace.Event{Type: trace.EvProcStart})
+	for i := 0; i < n; i++ {
+		s := &trace.Event{Type: trace.EvGoStart, Ts: int64(2*i+1) * 1000, G: 1}
+		s.Args[0] = 1
+		s.Link = &trace.Event{Type: trace.EvGoBlock, Ts: int64(2*i+2) * 1000, G: 1}
+		events = append(events, s, s.Link)
+	}
+	return events
+}
+
+// heapWriter discards what is written to it, noting the peak size of
+// the heap every few MB.
+type heapWriter struct {
+	size, next int
+	peak       uint64
+}
+
+func (w *heapWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	if w.size >= w.next {
+		w.next = w.size + 1<<20
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		if ms.HeapAlloc > w.peak {
+			w.peak = ms.HeapAlloc
+		}
+	}
+	return len(data), nil
+}
+
+func TestStreamTraceMemory(t *testing.T) {
+	n := 1 << 20
+	if testing.Short() {
+		n = 1 << 17
+	}
+	defer debug.SetGCPercent(debug.SetGCPercent(10))
+	params := &traceParams{events: runEvents(n), endTime: 1<<63 - 1}
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	w := new(heapWriter)
+	streamTrace(params, viewerDataTraceConsumer(w, 0, -1))
+	growth := int64(w.peak) - int64(ms.HeapAlloc)
+	t.Logf("%d events: %d MB of json, heap grew by %d MB", len(params.events), w.size>>20, growth>>20)
+	// The json is not kept, nor are the viewer events it encodes, so
+	// the heap grows only by the garbage the collector allows, a
+	// fraction of the heap of the events.
+	if max := int64(8<<20 + ms.HeapAlloc/4); growth > max {
+		t.Errorf("streaming %d MB of json grew the heap by %d MB, want at most %d MB", w.size>>20, growth>>20, max>>20)
+	}
+}
@@ -435,8 +435,10 @@ var printVerbs = []printVerb{
 	{'g', numFlag, argFloat | argComplex},
 	{'G', numFlag, argFloat | argComplex},
 	{'o', sharpNumFlag, argInt},
-	{'p', "-#", argPointer},
+	{'p', "+-#", argPointer},
 	{'q', " -+.0#", argRune | argInt | argString},
+	{'r', numFlag, argInt},
+	{'R', numFlag, argInt},
 	{'s', " -+.0", argString},
 	{'t', "-", argBool},
 	{'T', "-", anyType},
@@ -83,6 +83,7 @@ func PrintfTests() {
 	fmt.Printf("%v %v", 3, i)
 	fmt.Printf("%x %x %x %x", 3, i, "hi", s)
 	fmt.Printf("%X %X %X %X", 3, i, "hi", s)
+	fmt.Printf("%.36r %+08.3R %.*r", 3, i, 16, i)
 	fmt.Printf("%.*s %d %g", 3, "hi", 23, 2.3)
 	fmt.Printf("%s", &stringerv)
 	fmt.Printf("%v", &stringerv)
@@ -121,6 +122,7 @@ func PrintfTests() {
 	fmt.Printf("%U", x)                        // ERROR "arg x for printf verb %U of wrong type"
 	fmt.Printf("%x", nil)                      // ERROR "arg nil for printf verb %x of wrong type"
 	fmt.Printf("%X", 2.3)                      // ERROR "arg 2.3 for printf verb %X of wrong type"
+	fmt.Printf("%.36r", "hi")                  // ERROR "arg .hi. for printf verb %r of wrong type"
 	fmt.Printf("%s", stringerv)                // ERROR "arg stringerv for printf verb %s of wrong type"
 	fmt.Printf("%t", stringerv)                // ERROR "arg stringerv for printf verb %t of wrong type"
 	fmt.Printf("%q", notstringerv)             // ERROR "arg notstringerv for printf verb %q of wrong type"
@@ -156,7 +158,7 @@ func PrintfTests() {
 	f := new(stringer)
 	f.Warn(0, "%s", "hello", 3)  // ERROR "possible formatting directive in Warn call"
 	f.Warnf(0, "%s", "hello", 3) // ERROR "wrong number of args for format in Warnf call"
-	f.Warnf(0, "%r", "hello")    // ERROR "unrecognized printf verb"
+	f.Warnf(0, "%y", "hello")    // ERROR "unrecognized printf verb"
 	f.Warnf(0, "%#s", "hello")   // ERROR "unrecognized printf flag"
 	Printf("d%", 2)              // ERROR "missing verb at end of format string in Printf call"
 	Printf("%d", percentDV)
@@ -200,11 +202,12 @@ func PrintfTests() {
 	var et5 errorTest5
 	et5.error() // ok, not an error method.
 	// Can't print a function.
-	Printf("%d", someFunction) // ERROR "arg someFunction in printf call is a function value, not a function call"
-	Printf("%v", someFunction) // ERROR "arg someFunction in printf call is a function value, not a function call"
-	Println(someFunction)      // ERROR "arg someFunction in Println call is a function value, not a function call"
-	Printf("%p", someFunction) // ok: maybe someone wants to see the pointer
-	Printf("%T", someFunction) // ok: maybe someone wants to see the type
+	Printf("%d", someFunction)  // ERROR "arg someFunction in printf call is a function value, not a function call"
+	Printf("%v", someFunction)  // ERROR "arg someFunction in printf call is a function value, not a function call"
+	Println(someFunction)       // ERROR "arg someFunction in Println call is a function value, not a function call"
+	Printf("%p", someFunction)  // ok: maybe someone wants to see the pointer
+	Printf("%+p", someFunction) // ok: prints the symbol too
+	Printf("%T", someFunction)  // ok: maybe someone wants to see the type
 	// Bug: used to recur forever.
 	Printf("%p %x", recursiveStructV, recursiveStructV.next)
 	Printf("%p %x", recursiveStruct1V, recursiveStruct1V.next)
@@ -9,6 +9,7 @@ package sha256
 import (
 	"crypto"
 	"hash"
+	"internal/sha256block"
 )
 
 func init() {
@@ -109,14 +110,14 @@ func (d *digest) Write(p []byte) (nn int, err error) {
 		n := copy(d.x[d.nx:], p)
 		d.nx += n
 		if d.nx == chunk {
-			block(d, d.x[:])
+			sha256block.Block(&d.h, d.x[:])
 			d.nx = 0
 		}
 		p = p[n:]
 	}
 	if len(p) >= chunk {
 		n := len(p) &^ (chunk - 1)
-		block(d, p[:n])
+		sha256block.Block(&d.h, p[:n])
 		p = p[n:]
 	}
 	if len(p) > 0 {
@@ -27,6 +27,9 @@
 		%d	base 10
 		%o	base 8
 		%q	a single-quoted character literal safely escaped with Go syntax.
+		%r	base given by the precision, from 2 to 36, with lower-case
+			letters for the digits from 10 up, e.g. %.36r; base 10 by default
+		%R	like %r, with upper-case letters
 		%x	base 16, with lower-case letters for a-f
 		%X	base 16, with upper-case letters for A-F
 		%U	Unicode format: U+1234; same as "U+%04X"
@@ -46,7 +49,9 @@
 		%x	base 16, lower-case, two characters per byte
 		%X	base 16, upper-case, two characters per byte
 	Pointer:
-		%p	base 16 notation, with leading 0x
+		%p	base 16 notation, with leading 0x;
+			%+p also names the function containing a code address
+			and the offset within it, as in 0x4a2c40 (main.handler+0x40)
 
 	There is no 'u' flag.  Integers are printed unsigned if they have unsigned type.
 	Similarly, there is no need to specify the size of the operand (int8, int64).
@@ -66,6 +71,13 @@
 		maps:               map[key1:value1 key2:value2]
 		pointer to above:   &{}, &[], &map[]
 
+	Maps are printed in key-sorted order, so that the output is
+	deterministic. Numbers, including floats, sort numerically,
+	with NaNs first; strings sort by bytes; false sorts before true;
+	pointers and channels sort by address; structs and arrays
+	compare element by element; and interface keys sort by the
+	type of their concrete value, then by that value. Nil sorts low.
+
 	Width is specified by an optional decimal number immediately preceding the verb.
 	If absent, the width is whatever is necessary to represent the value.
 	Precision is specified after the (optional) width by a period followed by a
@@ -82,7 +94,8 @@
 	that is, runes. (This differs from C's printf where the
 	units are always measured in bytes.) Either or both of the flags
 	may be replaced with the character '*', causing their values to be
-	obtained from the next operand, which must be of type int.
+	obtained from the next operand, which may be of any integer type.
+	A negative width left-justifies the field, as the '-' flag does.
 
 	For most values, width is the minimum number of runes to output,
 	padding the formatted form with spaces if necessary.
@@ -93,12 +106,22 @@
 	runes, but for these types when formatted with the %x or %X format
 	it is measured in bytes.
 
+	For %r and %R, precision is the base rather than a minimum number of
+	digits, so %.*r takes the base from an operand; use the 0 flag with a
+	width for leading zeros, as in %08.2r.
+
 	For floating-point values, width sets the minimum width of the field and
 	precision sets the number of places after the decimal, if appropriate,
 	except that for %g/%G precision sets the total number of significant
 	digits. For example, given 12.345 the format %6.3f prints 12.345 while
 	%.3g prints 12.3. The default precision for %e and %f is 6; for %g it
 	is the smallest number of digits necessary to identify the value uniquely.
+	Uniqueness is with respect to the operand's own type: a float32, or a
+	component of a complex64, prints the shortest form that identifies it
+	among float32 values, however it is reached (directly, through an
+	interface, or as an element of a struct, slice, array or map). Thus
+	float32(0.1) prints 0.1, not the 0.10000000149011612 of its float64
+	conversion.
 
 	For complex numbers, the width and precision apply to the two
 	components independently and the result is parenthesized, so %f applied
@@ -106,7 +129,8 @@
 
 	Other flags:
 		+	always print a sign for numeric values;
-			guarantee ASCII-only output for %q (%+q)
+			guarantee ASCII-only output for %q (%+q);
+			annotate code addresses with their symbol for %p (%+p)
 		-	pad with spaces on the right rather than the left (left-justify the field)
 		#	alternate format: add leading 0 for octal (%#o), 0x for hex (%#x);
 			0X for hex (%#X); suppress 0x for %p (%#p);
@@ -125,7 +149,9 @@
 	For each Printf-like function, there is also a Print function
 	that takes no format and is equivalent to saying %v for every
 	operand.  Another variant Println inserts blanks between
-	operands and appends a newline.
+	operands and appends a newline, and Printj separates the
+	operands with a string given by the caller, such as a tab
+	or a comma, and appends nothing.
 
 	Regardless of the verb, if an operand is an interface value,
 	the internal concrete value is used, not the interface itself.
@@ -143,6 +169,10 @@
 
 	2. If an operand implements the Formatter interface, it will
 	be invoked. Formatter provides fine control of formatting.
+	The State passed to it by the printing functions also
+	implements StateEx, which reports the position of the operand
+	among the operands of the call and whether its verb was
+	implicit, as for Println.
 
 	3. If the %v verb is used with the # flag (%#v) and the operand
 	implements the GoStringer interface, that will be invoked.
@@ -211,9 +241,11 @@
 			Printf("hi", "guys"):      hi%!(EXTRA string=guys)
 		Too few arguments: %!verb(MISSING)
 			Printf("hi%d"):            hi%!d(MISSING)
-		Non-int for width or precision: %!(BADWIDTH) or %!(BADPREC)
+		Non-integer or out-of-range width or precision: %!(BADWIDTH) or %!(BADPREC)
 			Printf("%*s", 4.5, "hi"):  %!(BADWIDTH)hi
 			Printf("%.*s", 4.5, "hi"): %!(BADPREC)hi
+		Base of %r or %R out of range: %!verb(BADBASE=n)
+			Printf("%.40r", 7):        %!r(BADBASE=40)
 		Invalid or invalid use of argument index: %!(BADINDEX)
 			Printf("%*[2]d", 7):       %!d(BADINDEX)
 			Printf("%.[2]d", 7):       %!d(BADINDEX)
@@ -245,7 +277,10 @@
 	Scan, Fscan, Sscan treat newlines in the input as spaces.
 
 	Scanln, Fscanln and Sscanln stop scanning at a newline and
-	require that the items be followed by a newline or EOF.
+	require that the items be followed by a newline or EOF, so a
+	final line without a newline scans like any other.
+	FscanlnLines also skips blank lines before the items and
+	reports how many newlines it consumed, for tracking line numbers.
 
 	Scanf, Fscanf and Sscanf require that (after skipping spaces)
 	newlines in the format are matched by newlines in the input
@@ -271,6 +306,8 @@
 		%T is not implemented
 		%e %E %f %F %g %G are all equivalent and scan any floating point or complex value
 		%s and %v on strings scan a space-delimited token
+		%v on booleans accepts the forms strconv.ParseBool accepts:
+		   1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False
 		Flags # and + are not implemented.
 
 	The familiar base-setting prefixes 0 (octal) and 0x
@@ -287,6 +324,12 @@
 	   Sscanf(" 12 34 567 ", "%5s%d", &s, &i)
 	will set s to "12" and i to 34.
 
+	FscanLimited and FscanfLimited are like Fscan and Fscanf but
+	fail with an error as soon as a token, such as the text of a
+	string or number, grows longer than a given number of bytes,
+	for reading input that may be malicious without unbounded
+	memory use. A width still applies within that limit.
+
 	In all the scanning functions, a carriage return followed
 	immediately by a newline is treated as a plain newline
 	(\r\n means the same as \n).
@@ -297,8 +340,18 @@
 	if the number of arguments scanned is less than the number of
 	arguments provided, an error is returned.
 
+	Otherwise, if an operand implements method UnmarshalText (that
+	is, it implements the encoding.TextUnmarshaler interface) and is
+	scanned with %v or %s, the next space-delimited word, up to the
+	width if one is given, is passed to that method; an error it
+	returns is reported along with the operand's number, counting
+	from 1. A *time.Duration scanned with %v or %s is likewise set
+	from a word such as 300ms, as parsed by time.ParseDuration; with
+	%v, a word that is an integer is still a number of nanoseconds.
+
 	All arguments to be scanned must be either pointers to basic
-	types or implementations of the Scanner interface.
+	types or implementations of the Scanner or TextUnmarshaler
+	interfaces.
 
 	Note: Fscan etc. can read one character (rune) past the input
 	they return, which means that a loop calling a scan routine
@@ -307,7 +360,10 @@
 	provided to Fscan implements ReadRune, that method will be used
 	to read characters.  If the reader also implements UnreadRune,
 	that method will be used to save the character and successive
-	calls will not lose data.  To attach ReadRune and UnreadRune
+	calls will not lose data: when a scan completes, whether it
+	succeeds, stops at a width limit or EOF, or fails with an error,
+	the reader is left positioned immediately after the last character
+	consumed.  To attach ReadRune and UnreadRune
 	methods to a reader without that capability, use
 	bufio.NewReader.
 */
@@ -335,6 +391,9 @@
 		%d	十进制表示
 		%o	八进制表示
 		%q	单引号围绕的字符字面值，由Go语法安全地转义
+		%r	以精度为进制（2 到 36）的表示，10 及以上的数字用小写字母表示，
+			例如 %.36r；默认为十进制
+		%R	同 %r，但使用大写字母
 		%x	十六进制表示，字母形式为小写 a-f
 		%X	十六进制表示，字母形式为大写 A-F
 		%U	Unicode格式：U+1234，等同于 "U+%04X"
@@ -352,25 +411,41 @@
 		%x	十六进制，小写字母，每字节两个字符
 		%X	十六进制，大写字母，每字节两个字符
 	指针：
-		%p	十六进制表示，前缀 0x
+		%p	十六进制表示，前缀 0x；
+			%+p 还会给出包含代码地址的函数名及其在函数中的偏移量，
+			例如 0x4a2c40 (main.handler+0x40)
 
 	这里没有 'u' 标记。若整数为无符号类型，他们就会被打印成无符号的。类似地，
 	这里也不需要指定操作数的大小（int8，int64）。
 
+	映射会按照键排序后的顺序打印，因此其输出是确定的。数值（包括浮点数）
+	按数值大小排序，NaN 排在最前；字符串按字节排序；false 排在 true 之前；
+	指针和信道按地址排序；结构体和数组逐个元素比较；接口类型的键先按其具体值
+	的类型排序，再按该值排序。nil 排在最前。
+
 	宽度与精度的控制格式以Unicode码点为单位。（这点与C的 printf 不同，
 	它以字节数为单位。）二者或其中之一均可用字符 '*' 表示，
-	此时它们的值会从下一个操作数中获取，该操作数的类型必须为 int。
+	此时它们的值会从下一个操作数中获取，该操作数可以为任何整数类型。
+	负的宽度会像 '-' 旗标那样让该字段左对齐。
 
 	对数值而言，宽度为该数值占用区域的最小宽度；精度为小数点之后的位数。
 	但对于 %g/%G 而言，精度为所有数字的总数。例如，对于123.45，格式 %6.2f
 	会打印123.45，而 %.4g 会打印123.5。%e 和 %f 的默认精度为6；但对于 %g 而言，
-	它的默认精度为确定该值所必须的最小位数。
+	它的默认精度为确定该值所必须的最小位数。唯一性是相对于操作数自身的类型而言的：
+	无论 float32 或 complex64 的分量是如何被访问到的（直接访问、通过接口访问，
+	或作为结构体、切片、数组或映射的元素访问），都会打印出能在 float32
+	值中唯一确定它的最短形式。因此 float32(0.1) 会打印成 0.1，而非将其转换为
+	float64 后的 0.10000000149011612。
 
 	对大多数值而言，宽度为输出的最小字符数，如果必要的话会为已格式化的形式填充空格。
 	对字符串而言，精度为输出的最大字符数，如果必要的话会直接截断。
 
+	对 %r 和 %R 而言，精度为进制而非数字的最小位数，因此 %.*r 会从操作数中获取进制；
+	若需要前导的 0，请使用 0 标记和宽度，例如 %08.2r。
+
 	其它标记：
-		+	总打印数值的正负号；对于%q（%+q）保证只输出ASCII编码的字符。
+		+	总打印数值的正负号；对于%q（%+q）保证只输出ASCII编码的字符；
+			对于%p（%+p）为代码地址注明其符号。
 		-	在右侧而非左侧填充空格（左对齐该区域）
 		#	备用格式：为八进制添加前导 0（%#o），为十六进制添加前导 0x（%#x）或
 			0X（%#X），为 %p（%#p）去掉前导 0x；对于 %q，若 strconv.CanBackquote
@@ -386,7 +461,8 @@
 
 	对于每一个 Printf 类的函数，都有一个 Print 函数，该函数不接受任何格式化，
 	它等价于对每一个操作数都应用 %v。另一个变参函数 Println 会在操作数之间插入空白，
-	并在末尾追加一个换行符。
+	并在末尾追加一个换行符；而 Printj 则用调用者给定的字符串（例如制表符或逗号）
+	分隔操作数，且不在末尾追加任何内容。
 
 	不考虑占位符的话，如果操作数是接口值，就会使用其内部的具体值，而非接口本身。
 	因此：
@@ -395,6 +471,8 @@
 	会打印 23。
 
 	若一个操作数实现了 Formatter 接口，该接口就能更好地用于控制格式化。
+	打印函数传给它的 State 也实现了 StateEx，它会报告该操作数在该调用的
+	所有操作数中的位置，以及其占位符是否为隐式的（如 Println）。
 
 	若其格式（它对于 Println 等函数是隐式的 %v）对于字符串是有效的
 	（%s %q %v %x %X），以下两条规则也适用：
@@ -422,9 +500,11 @@
 			Printf("hi", "guys"):      hi%!(EXTRA string=guys)
 		实参太少： %!verb(MISSING)
 			Printf("hi%d"):            hi %!d(MISSING)
-		宽度或精度不是int类型: %!(BADWIDTH) 或 %!(BADPREC)
+		宽度或精度不是整数或超出范围: %!(BADWIDTH) 或 %!(BADPREC)
 			Printf("%*s", 4.5, "hi"):  %!(BADWIDTH)hi
 			Printf("%.*s", 4.5, "hi"): %!(BADPREC)hi
+		%r 或 %R 的进制超出范围：%!verb(BADBASE=n)
+			Printf("%.40r", 7):        %!r(BADBASE=40)
 
 	所有错误都始于“%!”，有时紧跟着单个字符（占位符），并以小括号括住的描述结尾。
 
@@ -436,6 +516,9 @@
 	Sscan、Sscanf 和 Sscanln 从实参字符串中读取。Scanln、Fscanln 和 Sscanln
 	在换行符处停止扫描，且需要条目紧随换行符之后；Scanf、Fscanf 和 Sscanf
 	需要输入换行符来匹配格式中的换行符；其它函数则将换行符视为空格。
+	Scanln、Fscanln 和 Sscanln 的条目之后须为换行符或 EOF，因此没有换行符的
+	最后一行会像其它行一样被扫描。FscanlnLines 还会跳过条目之前的空行，
+	并报告它消耗的换行符数量，以便跟踪行号。
 
 	Scanf、Fscanf 和 Sscanf 根据格式字符串解析实参，类似于 Printf。例如，%x
 	会将一个整数扫描为十六进制数，而 %v 则会扫描该值的默认表现格式。
@@ -446,6 +529,8 @@
 		%T 没有实现
 		%e %E %f %F %g %G 都完全等价，且可扫描任何浮点数或复合数值
 		%s 和 %v 在扫描字符串时会将其中的空格作为分隔符
+		%v 在扫描布尔值时接受 strconv.ParseBool 所接受的形式：
+		   1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False
 		标记 # 和 + 没有实现
 
 	在或使用 %v 占位符扫描整数时，可接受友好的进制前缀0（八进制）和0x（十六进制）。
@@ -453,6 +538,10 @@
 	宽度被解释为输入的文本（%5s 意为最多从输入中读取5个符文来扫描成字符串），
 	而扫描函数则没有精度的语法（没有 %5.2f，只有 %5f）。
 
+	FscanLimited 和 FscanfLimited 类似于 Fscan 和 Fscanf，但一旦记号（例如字符串
+	或数字的文本）超过给定的字节数，它们就会立即以错误失败，以便在读取可能恶意的
+	输入时不会无限制地使用内存。宽度在该限制内仍然有效。
+
 	当以某种格式进行扫描时，无论在格式中还是在输入中，所有非空的连续空白字符
 	（除换行符外）都等价于单个空格。由于这种限制，格式字符串文本必须匹配输入的文本，
 	如果不匹配，扫描过程就会停止，并返回已扫描的实参数。
@@ -461,12 +550,21 @@
 	该操作数将使用该方法扫描其文本。此外，若已扫描的实参数少于所提供的实参数，
 	就会返回一个错误。
 
-	所有需要被扫描的实参都必须是基本类型或 Scanner 接口的实现。
+	否则，若一个操作数实现了 UnmarshalText 方法（即它实现了
+	encoding.TextUnmarshaler 接口）且以 %v 或 %s 扫描，下一个由空格分隔的单词
+	（若给定了宽度则至多为该宽度）就会被传给该方法；它返回的错误会连同该操作数
+	的序号（从 1 开始计数）一同报告。以 %v 或 %s 扫描的 *time.Duration 同样会从
+	诸如 300ms 的单词中设置，其解析方式同 time.ParseDuration；对于 %v，
+	为整数的单词仍表示纳秒数。
+
+	所有需要被扫描的实参都必须是基本类型或 Scanner 或 TextUnmarshaler 接口的实现。
 
 	注意：Fscan 等函数会从输入中多读取一个字符（符文），因此，如果循环调用扫描函数，
 	可能会跳过输入中的某些数据。一般只有在输入的数据中没有空白符时该问题才会出现。
 	若提供给 Fscan 的读取器实现了 ReadRune，就会用该方法读取字符。若此读取器还实现了
-	UnreadRune 方法，就会用该方法保存字符，而连续的调用将不会丢失数据。若要为没有
+	UnreadRune 方法，就会用该方法保存字符，而连续的调用将不会丢失数据：无论扫描成功、
+	因宽度限制或 EOF 而停止，还是因错误而失败，在扫描完成时，该读取器都会恰好位于最后
+	消耗的字符之后。若要为没有
 	ReadRune 和 UnreadRune 方法的读取器加上这些功能，需使用 bufio.NewReader。
 */
 package fmt
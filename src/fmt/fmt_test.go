@@ -6,16 +6,19 @@ package fmt_test
 
 import (
 	"bytes"
+	"errors"
 	. "fmt"
 	"internal/race"
 	"io"
 	"math"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 	"unicode"
+	"unsafe"
 )
 
 type (
@@ -381,6 +384,28 @@ var fmtTests = []struct {
 	{"% .68d", 42, zeroFill(" ", 68, "42")},
 	{"% +.68d", 42, zeroFill("+", 68, "42")},
 
+	// arbitrary bases
+	// 任意进制
+	{"%r", 12345, "12345"},
+	{"%.36r", 1295, "zz"},
+	{"%.36R", 1295, "ZZ"},
+	{"%.36r", -1296, "-100"},
+	{"%.3r", 5, "12"},
+	{"%.2r", int64(-1 << 63), zeroFill("-1", 63, "")},
+	{"%.36r", ^uint64(0), "3w5e11264sgsf"},
+	{"%+.16r", 255, "+ff"},
+	{"% .16r", 255, " ff"},
+	{"%8.16r", 255, "      ff"},
+	{"%-8.16r", 255, "ff      "},
+	{"%08.16r", -255, "-00000ff"},
+	{"%#.16r", 255, "ff"},
+	{"%#.8r", 8, "10"},
+	{"%.0r", 7, "%!r(BADBASE=0)"},
+	{"%.1r", 7, "%!r(BADBASE=1)"},
+	{"%.37R", 7, "%!R(BADBASE=37)"},
+	{"%.36r", []int{35, 36}, "[z 10]"},
+	{"%.36r", "z", "%!r(string=z)"},
+
 	// unicode format
 	// Unicode格式
 	{"%U", 0, "U+0000"},
@@ -785,6 +810,9 @@ var fmtTests = []struct {
 	{"%p", &slice, "0xPTR"},
 	{"%8.2p", (*int)(nil), "    0x00"},
 	{"%-20.16p", &intVar, "0xPTR  "},
+	{"%+p", (*int)(nil), "0x0"},
+	{"%+p", &intVar, "0xPTR"}, // not a code address
+	{"%+p", new(int), "0xPTR"},
 	// %p on non-pointers
 	// %p 作用于非指针类型
 	{"%p", make(chan int), "0xPTR"},
@@ -838,6 +866,19 @@ var fmtTests = []struct {
 	// 的零值，它会被格式化为 <nil>。此测试只会检查它是否会显示两个 NaN。
 	{"%v", map[float64]int{NaN: 1, NaN: 2}, "map[NaN:<nil> NaN:<nil>]"},
 
+	// Maps are printed with their keys sorted.
+	// 映射会按照键排序后打印。
+	{"%v", map[int]string{3: "c", -1: "z", 1: "a", 2: "b"}, "map[-1:z 1:a 2:b 3:c]"},
+	{"%v", map[uint8]bool{200: true, 7: false}, "map[7:false 200:true]"},
+	{"%v", map[string]int{"b": 2, "a": 1, "ab": 3, "B": 4}, "map[B:4 a:1 ab:3 b:2]"},
+	{"%+v", map[B]int{{2, 1}: 1, {1, 3}: 2, {1, 2}: 3}, "map[{I:<1> j:2}:3 {I:<1> j:3}:2 {I:<2> j:1}:1]"},
+	{"%#v", map[string]int{"b": 2, "a": 1}, `map[string]int{"a":1, "b":2}`},
+	{"%#v", map[[2]int]bool{{2, 1}: true, {1, 2}: false}, `map[[2]int]bool{[2]int{1, 2}:false, [2]int{2, 1}:true}`},
+	{"%v", map[float64]string{2.5: "b", NaN: "n", -1: "a"}, "map[NaN:<nil> -1:a 2.5:b]"},
+	{"%v", map[bool]int{true: 1, false: 0}, "map[false:0 true:1]"},
+	{"%v", map[interface{}]int{"b": 2, "a": 1, nil: 0}, "map[<nil>:0 a:1 b:2]"},
+	{"%v", map[interface{}]int{2: 2, 1: 1, 0: 0}, "map[0:0 1:1 2:2]"},
+
 	// Comparison of padding rules with C printf.
 	/*
 		C program:
@@ -1055,6 +1096,48 @@ func TestSprintf(t *testing.T) {
 	}
 }
 
+// TestGoString checks that GoString agrees with %#v for every value
+// in fmtTests, whatever verb the table formats it with.
+
+// TestGoString 检查对于 fmtTests 中的每个值，无论表中用什么占位符格式化它，
+// GoString 的结果都与 %#v 一致。
+func TestGoString(t *testing.T) {
+	for _, tt := range fmtTests {
+		want := Sprintf("%#v", tt.val)
+		if s := GoString(tt.val); s != want {
+			t.Errorf("GoString(%v) = %q want %q", tt.val, s, want)
+		}
+	}
+	for _, v := range []interface{}{nil, G(1), &SI{G(2)}, []interface{}{nil, 3, "x"}} {
+		if s, want := GoString(v), Sprintf("%#v", v); s != want {
+			t.Errorf("GoString(%v) = %q want %q", v, s, want)
+		}
+	}
+}
+
+func symbolTarget() {}
+
+func TestPointerSymbol(t *testing.T) {
+	addr := Sprintf("%p", symbolTarget)
+	want := addr + " (fmt_test.symbolTarget+0x0)"
+	if s := Sprintf("%+p", symbolTarget); s != want {
+		t.Errorf("Sprintf(%q, symbolTarget) = %q, want %q", "%+p", s, want)
+	}
+	// The symbol follows the padded address.
+	// 符号跟在填充后的地址之后。
+	want = Sprintf("%20p", symbolTarget) + " (fmt_test.symbolTarget+0x0)"
+	if s := Sprintf("%+20p", symbolTarget); s != want {
+		t.Errorf("Sprintf(%q, symbolTarget) = %q, want %q", "%+20p", s, want)
+	}
+	// A function value stored as an unsafe.Pointer to its code.
+	// 存储为指向其代码的 unsafe.Pointer 的函数值。
+	code := unsafe.Pointer(reflect.ValueOf(symbolTarget).Pointer())
+	want = Sprintf("%p", code) + " (fmt_test.symbolTarget+0x0)"
+	if s := Sprintf("%+p", code); s != want {
+		t.Errorf("Sprintf(%q, code) = %q, want %q", "%+p", s, want)
+	}
+}
+
 // TestComplexFormatting checks that a complex always formats to the same
 // thing as if done by hand with two singleton prints.
 func TestComplexFormatting(t *testing.T) {
@@ -1099,6 +1182,68 @@ func TestComplexFormatting(t *testing.T) {
 	}
 }
 
+// float32Tests are float32 values whose shortest float32 and float64
+// representations differ, so printing them as float64 would show
+// extra digits.
+var float32Tests = []struct {
+	f   float32
+	out string
+}{
+	{0.1, "0.1"},
+	{1.0 / 3, "0.33333334"},
+	{16777215, "1.6777215e+07"},
+	{1e10, "1e+10"},
+	{3.4028235e38, "3.4028235e+38"},            // largest finite
+	{1.1754944e-38, "1.1754944e-38"},           // smallest normal
+	{1.1754942e-38, "1.1754942e-38"},           // largest subnormal
+	{1.4e-45, "1e-45"},                         // smallest subnormal
+	{float32(math.Copysign(0, -1)), "-0"},      // negative zero
+	{-7.006492e-45, "-7e-45"},                  // negative subnormal
+	{float32(1<<24 + 2), "1.6777218e+07"},      // beyond exact integers
+	{float32(math.Pi), "3.1415927"},            // float64 constant rounded
+	{float32(0.1) + float32(0.2), "0.3"},       // 0.30000001192092896 as a float64
+	{float32(1.0000001), "1.0000001"},          // next float32 after 1
+	{float32(0.99999994), "0.99999994"},        // previous float32 before 1
+	{float32(123456789), "1.2345679e+08"},      // float64 shows 1.23456792e+08
+	{float32(2.5e-44), "2.5e-44"},              // subnormal, short
+	{float32(-3.4028235e38), "-3.4028235e+38"}, // most negative finite
+}
+
+// TestFloat32Shortest checks that float32 values print the shortest
+// representation that identifies them as float32, wherever they appear.
+func TestFloat32Shortest(t *testing.T) {
+	type F struct{ F float32 }
+	type I struct{ I interface{} }
+	for _, tt := range float32Tests {
+		for _, c := range []struct {
+			format string
+			arg    interface{}
+			out    string
+		}{
+			{"%v", tt.f, tt.out},
+			{"%g", tt.f, tt.out},
+			{"%v", renamedFloat32(tt.f), tt.out},
+			{"%v", F{tt.f}, "{" + tt.out + "}"},
+			{"%+v", F{tt.f}, "{F:" + tt.out + "}"},
+			{"%v", I{tt.f}, "{" + tt.out + "}"},
+			{"%v", &F{tt.f}, "&{" + tt.out + "}"},
+			{"%v", []float32{tt.f}, "[" + tt.out + "]"},
+			{"%v", [1]float32{tt.f}, "[" + tt.out + "]"},
+			{"%v", []interface{}{tt.f}, "[" + tt.out + "]"},
+			{"%v", map[string]float32{"k": tt.f}, "map[k:" + tt.out + "]"},
+			{"%#v", tt.f, tt.out},
+			{"%v", complex(tt.f, 0), "(" + tt.out + "+0i)"},
+		} {
+			if s := Sprintf(c.format, c.arg); s != c.out {
+				t.Errorf("Sprintf(%q, %T(%v)) = %q, want %q", c.format, c.arg, tt.f, s, c.out)
+			}
+		}
+		if s := Sprint(tt.f); s != tt.out {
+			t.Errorf("Sprint(%v) = %q, want %q", tt.f, s, tt.out)
+		}
+	}
+}
+
 type SE []interface{} // slice of empty; notational compactness.
 
 var reorderTests = []struct {
@@ -1285,6 +1430,15 @@ func BenchmarkSprintfStructure(b *testing.B) {
 	})
 }
 
+func BenchmarkGoString(b *testing.B) {
+	s := &[]interface{}{SI{12345}, map[int]string{0: "hello"}}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			GoString(s)
+		}
+	})
+}
+
 func BenchmarkManyArgs(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		var buf bytes.Buffer
@@ -1312,6 +1466,42 @@ func BenchmarkFprintfBytes(b *testing.B) {
 	}
 }
 
+// The operands of the Fprintln benchmarks are already interfaces,
+// so any allocation comes from the call itself.
+var printlnArg interface{} = "hello"
+
+func BenchmarkFprintln1(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		Fprintln(&buf, printlnArg)
+	}
+}
+
+func BenchmarkFprintln2(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		Fprintln(&buf, printlnArg, printlnArg)
+	}
+}
+
+func BenchmarkFprintln3(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		Fprintln(&buf, printlnArg, printlnArg, printlnArg)
+	}
+}
+
+func BenchmarkFprintln8(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		Fprintln(&buf, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg)
+	}
+}
+
 func BenchmarkFprintIntNoAlloc(b *testing.B) {
 	var x interface{} = 123456
 	var buf bytes.Buffer
@@ -1341,6 +1531,18 @@ var mallocTest = []struct {
 		mallocBuf.Reset()
 		Fprintf(&mallocBuf, "%x %x %x", mallocPointer, mallocPointer, mallocPointer)
 	}},
+	// The ... slice of Fprintln does not escape, so printing values that
+	// are already interfaces does not allocate.
+	{0, `Fprintln(buf, arg)`, func() { mallocBuf.Reset(); Fprintln(&mallocBuf, printlnArg) }},
+	{0, `Fprintln(buf, arg, arg)`, func() { mallocBuf.Reset(); Fprintln(&mallocBuf, printlnArg, printlnArg) }},
+	{0, `Fprintln(buf, arg, arg, arg)`, func() {
+		mallocBuf.Reset()
+		Fprintln(&mallocBuf, printlnArg, printlnArg, printlnArg)
+	}},
+	{0, `Fprintln(buf, 8 x arg)`, func() {
+		mallocBuf.Reset()
+		Fprintln(&mallocBuf, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg, printlnArg)
+	}},
 }
 
 var _ bytes.Buffer
@@ -1457,25 +1659,6 @@ func TestSlicePrinter(t *testing.T) {
 	}
 }
 
-// presentInMap checks map printing using substrings so we don't depend on the
-// print order.
-
-// presentInMap 用子字符串来检查映射的打印，因此我们无需依赖打印顺序。
-func presentInMap(s string, a []string, t *testing.T) {
-	for i := 0; i < len(a); i++ {
-		loc := strings.Index(s, a[i])
-		if loc < 0 {
-			t.Errorf("map print: expected to find %q in %q", a[i], s)
-		}
-		// make sure the match ends here
-		// 确保已经匹配到结尾
-		loc += len(a[i])
-		if loc >= len(s) || (s[loc] != ' ' && s[loc] != ']') {
-			t.Errorf("map print: %q not properly terminated in %q", a[i], s)
-		}
-	}
-}
-
 func TestMapPrinter(t *testing.T) {
 	m0 := make(map[int]string)
 	s := Sprint(m0)
@@ -1483,15 +1666,35 @@ func TestMapPrinter(t *testing.T) {
 		t.Errorf("empty map printed as %q not %q", s, "map[]")
 	}
 	m1 := map[int]string{1: "one", 2: "two", 3: "three"}
-	a := []string{"1:one", "2:two", "3:three"}
-	presentInMap(Sprintf("%v", m1), a, t)
-	presentInMap(Sprint(m1), a, t)
+	const m1Str = "map[1:one 2:two 3:three]"
+	if s := Sprintf("%v", m1); s != m1Str {
+		t.Errorf("map printed as %q not %q", s, m1Str)
+	}
+	if s := Sprint(m1); s != m1Str {
+		t.Errorf("map printed as %q not %q", s, m1Str)
+	}
 	// Pointer to map prints the same but with initial &.
-	if !strings.HasPrefix(Sprint(&m1), "&") {
-		t.Errorf("no initial & for address of map")
+	// 指向映射的指针打印出的内容相同，只是以 & 开头。
+	if s := Sprint(&m1); s != "&"+m1Str {
+		t.Errorf("pointer to map printed as %q not %q", s, "&"+m1Str)
+	}
+	if s := Sprintf("%v", &m1); s != "&"+m1Str {
+		t.Errorf("pointer to map printed as %q not %q", s, "&"+m1Str)
+	}
+}
+
+// TestMapPointerKeys checks that pointer keys print in address order.
+
+// TestMapPointerKeys 检查指针键是否按照地址顺序打印。
+func TestMapPointerKeys(t *testing.T) {
+	var a [3]int
+	m := map[*int]string{&a[2]: "c", &a[0]: "a", &a[1]: "b"}
+	want := Sprintf("map[%p:a %p:b %p:c]", &a[0], &a[1], &a[2])
+	for i := 0; i < 10; i++ {
+		if s := Sprint(m); s != want {
+			t.Fatalf("map printed as %q not %q", s, want)
+		}
 	}
-	presentInMap(Sprintf("%v", &m1), a, t)
-	presentInMap(Sprint(&m1), a, t)
 }
 
 func TestEmptyMap(t *testing.T) {
@@ -1534,6 +1737,37 @@ func TestBlankln(t *testing.T) {
 	}
 }
 
+var printjTests = []struct {
+	sep string
+	in  []interface{}
+	out string
+}{
+	{",", nil, ""},
+	{",", args("a"), "a"},
+	{"\t", args("<", 1, ">:", 1, 2, 3, "!"), "<\t1\t>:\t1\t2\t3\t!"},
+	{"", args(1, 2, "x", "y"), "12xy"},
+	{" · ", args(I(1), nil, Errorf("e"), F(2)), "<1> · <nil> · e · <v=F(2)>"},
+	{"⌘", args(1.5, []int{1, 2}, true), "1.5⌘[1 2]⌘true"},
+}
+
+// TestPrintj checks that Sprintj (and hence Printj, Fprintj) puts sep
+// between all arg pairs and nowhere else, formatting each arg as Print does.
+
+// TestPrintj 检查 Sprintj（同时也就检查了 Printj 和 Fprintj）是否只在所有的实参对之间放置
+// sep，并像 Print 那样格式化每个实参。
+func TestPrintj(t *testing.T) {
+	for _, tt := range printjTests {
+		if s := Sprintj(tt.sep, tt.in...); s != tt.out {
+			t.Errorf("Sprintj(%q, %v) = %q want %q", tt.sep, tt.in, s, tt.out)
+		}
+		var buf bytes.Buffer
+		n, err := Fprintj(&buf, tt.sep, tt.in...)
+		if n != len(tt.out) || err != nil || buf.String() != tt.out {
+			t.Errorf("Fprintj(%q, %v) = %d, %v writing %q want %d, nil writing %q", tt.sep, tt.in, n, err, buf.String(), len(tt.out), tt.out)
+		}
+	}
+}
+
 // TestFormatterPrintln checks Formatter with Sprint, Sprintln, Sprintf.
 
 // TestFormatterPrintln 用 Sprint、Sprintln 和 Sprintf 检查 Formatter。
@@ -1554,6 +1788,67 @@ func TestFormatterPrintln(t *testing.T) {
 	}
 }
 
+// argPrinter formats itself as the position of the operand reported by StateEx.
+
+// argPrinter 将自身格式化为由 StateEx 报告的操作数位置。
+type argPrinter struct{}
+
+func (argPrinter) Format(f State, c rune) {
+	s, ok := f.(StateEx)
+	if !ok {
+		io.WriteString(f, "no StateEx")
+		return
+	}
+	Fprintf(f, "%c%d/%d", c, s.ArgNum(), s.NumArgs())
+	if s.Implicit() {
+		io.WriteString(f, "i")
+	}
+}
+
+// Formats for stateExTests with extra arguments, in variables so vet
+// does not complain.
+// 用于 stateExTests 中带有多余实参的格式，将其放入变量中以免 vet 报错。
+var (
+	starFormat  = "%*d"
+	extraFormat = "%v"
+)
+
+var stateExTests = []struct {
+	out string
+	got string
+}{
+	{"v0/1i", Sprint(argPrinter{})},
+	{"x 1 v2/3i", Sprint("x ", 1, argPrinter{})},
+	{"v0/2i v1/2i\n", Sprintln(argPrinter{}, argPrinter{})},
+	{"v0/3i, v1/3i, 2", Sprintj(", ", argPrinter{}, argPrinter{}, 2)},
+	{"d0/2 s1/2", Sprintf("%d %s", argPrinter{}, argPrinter{})},
+	{"1 x1/2", Sprintf("%d %x", 1, argPrinter{})},
+	{"q1/2 v0/2", Sprintf("%[2]q %[1]v", argPrinter{}, argPrinter{})},
+	{"v0/2 [v1/2]", Sprintf("%v %v", argPrinter{}, []argPrinter{{}})},
+	{"d1/3", Sprintf(starFormat, 4, argPrinter{}, 2)[:4]},
+	{"v0/2%!(EXTRA fmt_test.argPrinter=v1/2i)", Sprintf(extraFormat, argPrinter{}, argPrinter{})},
+	{"v0/1i", GoString(argPrinter{})},
+}
+
+func TestStateEx(t *testing.T) {
+	for i, tt := range stateExTests {
+		if tt.got != tt.out {
+			t.Errorf("%d: got %q want %q", i, tt.got, tt.out)
+		}
+	}
+	// A Formatter called from another one's Format sees its own call.
+	// 在另一个格式化器的 Format 中调用的格式化器看到的是它自己的调用。
+	if s := Sprintf("%v %v", nestedPrinter{}, argPrinter{}); s != "(v0/1) v1/2" {
+		t.Errorf("nested: got %q want %q", s, "(v0/1) v1/2")
+	}
+}
+
+type nestedPrinter struct{}
+
+func (nestedPrinter) Format(f State, c rune) {
+	Fprintf(f, "(%v)", argPrinter{})
+}
+
 func args(a ...interface{}) []interface{} { return a }
 
 var startests = []struct {
@@ -1573,14 +1868,33 @@ var startests = []struct {
 	{"%0*d", args(uint64(4), 42), "0042"},
 	{"%0*d", args('\x04', 42), "0042"},
 	{"%0*d", args(uintptr(4), 42), "0042"},
+	{"%0*d", args(int8(4), 42), "0042"},
+	{"%0*d", args(int16(4), 42), "0042"},
+	{"%0*d", args(int32(4), 42), "0042"},
+	{"%0*d", args(int64(4), 42), "0042"},
+	{"%0*d", args(uint8(4), 42), "0042"},
+	{"%0*d", args(uint16(4), 42), "0042"},
+	{"%0*d", args(uint32(4), 42), "0042"},
+	{"%.*d", args(int32(4), 42), "0042"},
+	{"%.*d", args(uint16(4), 42), "0042"},
+	{"%*.*d", args(int64(8), uint8(4), 42), "    0042"},
+	{"%*d", args(int8(-4), 42), "42  "},
+	{"%*d", args(int32(-4), 42), "42  "},
+	{"%*d", args(int64(-4), 42), "42  "},
 
 	// erroneous
 	// 错误的
 	{"%*d", args(nil, 42), "%!(BADWIDTH)42"},
 	{"%*d", args(int(1e7), 42), "%!(BADWIDTH)42"},
 	{"%*d", args(int(-1e7), 42), "%!(BADWIDTH)42"},
+	{"%*d", args(4.0, 42), "%!(BADWIDTH)42"},
+	{"%*d", args(int64(1e7), 42), "%!(BADWIDTH)42"},
+	{"%*d", args(uint64(1<<63), 42), "%!(BADWIDTH)42"},   // Exceeds MaxInt64.
+	{"%*d", args(uint64(1<<64-1), 42), "%!(BADWIDTH)42"}, // Not -1.
 	{"%.*d", args(nil, 42), "%!(BADPREC)42"},
 	{"%.*d", args(-1, 42), "%!(BADPREC)42"},
+	{"%.*d", args(int8(-1), 42), "%!(BADPREC)42"},
+	{"%.*d", args(int64(-1), 42), "%!(BADPREC)42"},
 	{"%.*d", args(int(1e7), 42), "%!(BADPREC)42"},
 	{"%.*d", args(uint(1e7), 42), "%!(BADPREC)42"},
 	{"%.*d", args(uint64(1<<63), 42), "%!(BADPREC)42"},   // Huge negative (-inf).
@@ -1588,6 +1902,36 @@ var startests = []struct {
 	{"%*d", args(5, "foo"), "%!d(string=  foo)"},
 	{"%*% %d", args(20, 5), "% 5"},
 	{"%*", args(4), "%!(NOVERB)"},
+	{"%.*r", args(36, 1295), "zz"},
+	{"%*.*r", args(6, 2, 5), "   101"},
+	{"%.*r", args(40, 7), "%!r(BADBASE=40)"},
+}
+
+func TestBaseVerb(t *testing.T) {
+	values := []int64{0, 1, -1, 35, 36, -36, 1e9, -1e9, 1<<63 - 1, -1 << 63}
+	for _, v := range values {
+		// Bases 2, 8 and 16 agree with %b, %o, %x and %X.
+		for _, c := range []struct{ format, want string }{
+			{"%.2r", "%b"},
+			{"%.8r", "%o"},
+			{"%.16r", "%x"},
+			{"%.16R", "%X"},
+			{"%+08.16r", "%+08x"},
+		} {
+			if got, want := Sprintf(c.format, v), Sprintf(c.want, v); got != want {
+				t.Errorf("Sprintf(%q, %d) = %q, want %q like %s", c.format, v, got, want, c.want)
+			}
+		}
+		// Every base round-trips through strconv.ParseInt.
+		for base := 2; base <= 36; base++ {
+			for _, verb := range "rR" {
+				s := Sprintf("%.*"+string(verb), base, v)
+				if got, err := strconv.ParseInt(s, base, 64); err != nil || got != v {
+					t.Errorf("Sprintf(%%.%d%c, %d) = %q, which parses as %d, %v", base, verb, v, s, got, err)
+				}
+			}
+		}
+	}
 }
 
 func TestWidthAndPrecision(t *testing.T) {
@@ -1794,3 +2138,97 @@ func TestFormatterFlags(t *testing.T) {
 		}
 	}
 }
+
+type locator interface {
+	Location() (file string, line int)
+}
+
+// errorfHere returns an error made by Errorf together with the
+// line of that call.
+func errorfHere(format string, a ...interface{}) (error, int) {
+	_, _, line, _ := runtime.Caller(0)
+	return Errorf(format, a...), line + 1
+}
+
+func TestErrorfCallers(t *testing.T) {
+	_, thisFile, _, _ := runtime.Caller(0)
+
+	err, _ := errorfHere("off")
+	if _, ok := err.(locator); ok {
+		t.Errorf("Errorf recorded a location by default")
+	}
+
+	defer SetErrorfCallers(0)
+	SetErrorfCallers(1)
+
+	inner, innerLine := errorfHere("inner %d", 1)
+	outer, outerLine := errorfHere("outer: %v", inner)
+	if got, want := outer.Error(), "outer: inner 1"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	for _, tt := range []struct {
+		err  error
+		line int
+	}{
+		{inner, innerLine},
+		{outer, outerLine},
+	} {
+		l, ok := tt.err.(locator)
+		if !ok {
+			t.Errorf("%q: no Location method", tt.err)
+			continue
+		}
+		file, line := l.Location()
+		if file != thisFile || line != tt.line {
+			t.Errorf("%q: Location() = %s:%d, want %s:%d", tt.err, file, line, thisFile, tt.line)
+		}
+	}
+
+	// With depth 2 the location is that of the caller of errorfHere.
+	SetErrorfCallers(2)
+	_, _, line, _ := runtime.Caller(0)
+	err, _ = errorfHere("wrapped")
+	if l, ok := err.(locator); !ok {
+		t.Errorf("depth 2: no Location method")
+	} else if file, got := l.Location(); file != thisFile || got != line+1 {
+		t.Errorf("depth 2: Location() = %s:%d, want %s:%d", file, got, thisFile, line+1)
+	}
+
+	SetErrorfCallers(0)
+	err, _ = errorfHere("off again")
+	if _, ok := err.(locator); ok {
+		t.Errorf("Errorf recorded a location after SetErrorfCallers(0)")
+	}
+}
+
+var errorSink error
+
+func TestErrorfCallersOffAllocs(t *testing.T) {
+	switch {
+	case testing.Short():
+		t.Skip("skipping malloc count in short mode")
+	case runtime.GOMAXPROCS(0) > 1:
+		t.Skip("skipping; GOMAXPROCS>1")
+	case race.Enabled:
+		t.Skip("skipping malloc count under race detector")
+	}
+	errorf := testing.AllocsPerRun(100, func() { errorSink = Errorf("%x", 7) })
+	want := testing.AllocsPerRun(100, func() { errorSink = errors.New(Sprintf("%x", 7)) })
+	if errorf != want {
+		t.Errorf("Errorf: got %v allocs, want %v as for errors.New(Sprintf(...))", errorf, want)
+	}
+}
+
+func BenchmarkErrorf(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Errorf("%x", 7)
+	}
+}
+
+func BenchmarkErrorfCallers(b *testing.B) {
+	defer SetErrorfCallers(0)
+	SetErrorfCallers(1)
+	for i := 0; i < b.N; i++ {
+		Errorf("%x", 7)
+	}
+}
@@ -12,6 +12,12 @@ import (
 const (
 	ldigits = "0123456789abcdefx"
 	udigits = "0123456789ABCDEFX"
+
+	// The digits of bases up to 36, for %r and %R.
+
+	// 最大为 36 进制的数字，用于 %r 和 %R。
+	ldigits36 = "0123456789abcdefghijklmnopqrstuvwxyz"
+	udigits36 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 )
 
 const (
@@ -272,7 +278,18 @@ func (f *fmt) fmt_integer(u uint64, base int, isSigned bool, digits string) {
 			u >>= 1
 		}
 	default:
-		panic("fmt: unknown base; can't happen")
+		// Any other base, for %r and %R.
+		// 其它进制，用于 %r 和 %R。
+		if base < 2 || base > len(digits) {
+			panic("fmt: unknown base; can't happen")
+		}
+		b := uint64(base)
+		for u >= b {
+			i--
+			next := u / b
+			buf[i] = digits[u-next*b]
+			u = next
+		}
 	}
 	i--
 	buf[i] = digits[u]
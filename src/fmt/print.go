@@ -6,10 +6,14 @@ package fmt
 
 import (
 	"errors"
+	"internal/fmtsort"
 	"io"
 	"os"
 	"reflect"
+	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"unicode/utf8"
 )
 
@@ -28,6 +32,7 @@ const (
 	extraString       = "%!(EXTRA "
 	badWidthString    = "%!(BADWIDTH)"
 	badPrecString     = "%!(BADPREC)"
+	badBaseString     = "(BADBASE="
 	noVerbString      = "%!(NOVERB)"
 	invReflectString  = "<invalid reflect.Value>"
 )
@@ -56,6 +61,38 @@ type State interface {
 	Flag(c int) bool
 }
 
+// StateEx is implemented by the State passed to custom formatters by the
+// printing functions of this package. It extends State with the position
+// of the operand being formatted. A formatter that needs it should check
+// for it with a type assertion, as other implementations of State may
+// not provide it.
+
+// StateEx 由本包中的打印函数传递给定制格式化器的 State 所实现。它在 State
+// 的基础上扩展了正在格式化的操作数的位置。需要它的格式化器应通过类型断言来检查，
+// 因为 State 的其它实现可能并不提供它。
+type StateEx interface {
+	State
+
+	// ArgNum returns the index of the operand being formatted
+	// among the operands of the call, counting from 0.
+
+	// ArgNum 返回正在格式化的操作数在该调用的所有操作数中的下标，从 0 开始计数。
+	ArgNum() int
+
+	// NumArgs returns the number of operands of the call.
+
+	// NumArgs 返回该调用的操作数的数量。
+	NumArgs() int
+
+	// Implicit reports whether the verb was implied, as for the
+	// operands of Print, Println and Printj, rather than given in
+	// a format string.
+
+	// Implicit 返回该占位符是否为隐式的（如 Print、Println 和 Printj
+	// 的操作数），而非在格式字符串中给出。
+	Implicit() bool
+}
+
 // Formatter is the interface implemented by values with a custom formatter.
 // The implementation of Format may call Sprint(f) or Fprint(f) etc.
 // to generate its output.
@@ -146,6 +183,15 @@ type pp struct {
 	panicking bool
 	// erroring is set when printing an error string to guard against calling handleMethods.
 	erroring bool
+
+	// argNum is the index of the operand being printed and numArgs the
+	// number of operands. implicit records whether the verb was implied
+	// rather than given in a format string.
+	// argNum 为正在打印的操作数的下标，numArgs 为操作数的数量。implicit
+	// 记录该占位符是否为隐式的，而非在格式字符串中给出。
+	argNum   int
+	numArgs  int
+	implicit bool
 }
 
 var ppFree = sync.Pool{
@@ -173,6 +219,12 @@ func (p *pp) free() {
 	ppFree.Put(p)
 }
 
+func (p *pp) ArgNum() int { return p.argNum }
+
+func (p *pp) NumArgs() int { return p.numArgs }
+
+func (p *pp) Implicit() bool { return p.implicit }
+
 func (p *pp) Width() (wid int, ok bool) { return p.fmt.wid, p.fmt.widPresent }
 
 func (p *pp) Precision() (prec int, ok bool) { return p.fmt.prec, p.fmt.precPresent }
@@ -238,11 +290,78 @@ func Sprintf(format string, a ...interface{}) string {
 	return s
 }
 
+// errorfCallers holds the depth set by SetErrorfCallers.
+// It is accessed atomically; 0 means Errorf records no location.
+var errorfCallers int32
+
+// SetErrorfCallers controls whether the errors returned by Errorf record
+// the source location they were created at, to help find where an error
+// that surfaced far from its origin came from. With depth > 0, Errorf
+// records the file and line of the function depth levels above it:
+// 1 is the caller of Errorf, 2 is the caller of a helper that wraps
+// Errorf, and so on. Depth <= 0, the default, turns recording off.
+//
+// A recorded location is reported by the error's Location method, which
+// can be reached with a type assertion to
+//
+//	interface {
+//		Location() (file string, line int)
+//	}
+//
+// The Error method of the error is unchanged. Recording costs one call
+// to runtime.Caller per Errorf, a few hundred nanoseconds, and is meant for
+// debugging; when it is off, Errorf behaves and allocates exactly as if
+// this function did not exist.
+
+// SetErrorfCallers 控制 Errorf 返回的错误是否记录它们被创建时的源码位置，
+// 以便找出在远离其源头处出现的错误来自何处。当 depth > 0 时，Errorf
+// 会记录它之上 depth 层的函数的文件和行号：1 为 Errorf 的调用者，2 为包装了
+// Errorf 的辅助函数的调用者，以此类推。depth <= 0（默认值）则关闭记录。
+//
+// 记录的位置由该错误的 Location 方法报告，可通过类型断言为
+//
+//	interface {
+//		Location() (file string, line int)
+//	}
+//
+// 来获取。该错误的 Error 方法保持不变。每次 Errorf 记录位置都会调用一次
+// runtime.Caller，开销约为几百纳秒，因此仅用于调试；当它关闭时，Errorf
+// 的行为和内存分配与该函数不存在时完全相同。
+func SetErrorfCallers(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	atomic.StoreInt32(&errorfCallers, int32(depth))
+}
+
+// locatedError is the error returned by Errorf when SetErrorfCallers
+// has turned on recording of the call site.
+type locatedError struct {
+	s    string
+	file string
+	line int
+}
+
+func (e *locatedError) Error() string {
+	return e.s
+}
+
+func (e *locatedError) Location() (file string, line int) {
+	return e.file, e.line
+}
+
 // Errorf formats according to a format specifier and returns the string
 // as a value that satisfies error.
 
 // Errorf 根据于格式说明符进行格式化并将字符串作为满足 error 的值返回。
 func Errorf(format string, a ...interface{}) error {
+	if depth := atomic.LoadInt32(&errorfCallers); depth > 0 {
+		s := Sprintf(format, a...)
+		if _, file, line, ok := runtime.Caller(int(depth)); ok {
+			return &locatedError{s, file, line}
+		}
+		return errors.New(s)
+	}
 	return errors.New(Sprintf(format, a...))
 }
 
@@ -333,6 +452,68 @@ func Sprintln(a ...interface{}) string {
 	return s
 }
 
+// These routines end in 'j', do not take a format string, and join
+// their operands with a separator chosen by the caller. No newline is
+// added.
+// 这些程序以“j”结尾，它们不接受格式字符串，并用调用者选择的分隔符连接其操作数。
+// 它们不会添加换行符。
+
+// Fprintj formats using the default formats for its operands and writes to w.
+// The operands are separated by sep, whether or not they are strings.
+// It returns the number of bytes written and any write error encountered.
+
+// Fprintj 使用其操作数的默认格式进行格式化并写入到 w。
+// 无论操作数是否为字符串，它们之间都以 sep 分隔。
+// 它返回写入的字节数以及任何遇到的错误。
+func Fprintj(w io.Writer, sep string, a ...interface{}) (n int, err error) {
+	p := newPrinter()
+	p.doPrintj(sep, a)
+	n, err = w.Write(p.buf)
+	p.free()
+	return
+}
+
+// Printj formats using the default formats for its operands and writes to standard output.
+// The operands are separated by sep, whether or not they are strings.
+// It returns the number of bytes written and any write error encountered.
+
+// Printj 使用其操作数的默认格式进行格式化并写入到标准输出。
+// 无论操作数是否为字符串，它们之间都以 sep 分隔。
+// 它返回写入的字节数以及任何遇到的错误。
+func Printj(sep string, a ...interface{}) (n int, err error) {
+	return Fprintj(os.Stdout, sep, a...)
+}
+
+// Sprintj formats using the default formats for its operands and returns the resulting string.
+// The operands are separated by sep, whether or not they are strings.
+
+// Sprintj 使用其操作数的默认格式进行格式化并返回其结果字符串。
+// 无论操作数是否为字符串，它们之间都以 sep 分隔。
+func Sprintj(sep string, a ...interface{}) string {
+	p := newPrinter()
+	p.doPrintj(sep, a)
+	s := string(p.buf)
+	p.free()
+	return s
+}
+
+// GoString returns a Go-syntax representation of v. The result is the
+// same as that of Sprintf("%#v", v), including calls to the GoString
+// method of values that implement GoStringer, but no format string is
+// parsed.
+
+// GoString 返回 v 的Go语法表示。其结果与 Sprintf("%#v", v) 相同，
+// 包括对实现了 GoStringer 的值调用其 GoString 方法，但不会解析格式字符串。
+func GoString(v interface{}) string {
+	p := newPrinter()
+	p.setArgs(1, true)
+	p.fmt.sharpV = true
+	p.printArg(v, 'v')
+	s := string(p.buf)
+	p.free()
+	return s
+}
+
 // getField gets the i'th field of the struct value.
 // If the field is itself is an interface, return a value for
 // the thing inside the interface, not the interface itself.
@@ -403,12 +584,11 @@ func (p *pp) badVerb(verb rune) {
 }
 
 func (p *pp) fmtBool(v bool, verb rune) {
-	switch verb {
-	case 't', 'v':
-		p.fmt.fmt_boolean(v)
-	default:
+	if !verbAccepts(verb, opBool) {
 		p.badVerb(verb)
+		return
 	}
+	p.fmt.fmt_boolean(v)
 }
 
 // fmt0x64 formats a uint64 in hexadecimal and prefixes it with 0x or
@@ -423,8 +603,37 @@ func (p *pp) fmt0x64(v uint64, leading0x bool) {
 	p.fmt.sharp = sharp
 }
 
+// fmtBase formats a signed or unsigned integer for %r and %R, in the
+// base given by the precision, 10 by default, by temporarily clearing
+// the precision and the sharp flag.
+
+// fmtBase 为 %r 和 %R 格式化有符号或无符号整数，其进制由精度给出，默认为 10。
+// 它会临时清除精度和 # 号标记。
+func (p *pp) fmtBase(v uint64, isSigned bool, verb rune, digits string) {
+	base := 10
+	if p.fmt.precPresent {
+		base = p.fmt.prec
+	}
+	if base < 2 || base > len(digits) {
+		p.buf.WriteString(percentBangString)
+		p.buf.WriteRune(verb)
+		p.buf.WriteString(badBaseString)
+		p.buf = strconv.AppendInt(p.buf, int64(base), 10)
+		p.buf.WriteByte(')')
+		return
+	}
+	precPresent, sharp := p.fmt.precPresent, p.fmt.sharp
+	p.fmt.precPresent, p.fmt.sharp = false, false
+	p.fmt.fmt_integer(v, base, isSigned, digits)
+	p.fmt.precPresent, p.fmt.sharp = precPresent, sharp
+}
+
 // fmtInteger formats a signed or unsigned integer.
 func (p *pp) fmtInteger(v uint64, isSigned bool, verb rune) {
+	if !verbAccepts(verb, opInteger) {
+		p.badVerb(verb)
+		return
+	}
 	switch verb {
 	case 'v':
 		if p.fmt.sharpV && !isSigned {
@@ -442,6 +651,10 @@ func (p *pp) fmtInteger(v uint64, isSigned bool, verb rune) {
 		p.fmt.fmt_integer(v, 16, isSigned, ldigits)
 	case 'X':
 		p.fmt.fmt_integer(v, 16, isSigned, udigits)
+	case 'r':
+		p.fmtBase(v, isSigned, verb, ldigits36)
+	case 'R':
+		p.fmtBase(v, isSigned, verb, udigits36)
 	case 'c':
 		p.fmt.fmt_c(v)
 	case 'q':
@@ -452,14 +665,16 @@ func (p *pp) fmtInteger(v uint64, isSigned bool, verb rune) {
 		}
 	case 'U':
 		p.fmt.fmt_unicode(v)
-	default:
-		p.badVerb(verb)
 	}
 }
 
 // fmtFloat formats a float. The default precision for each verb
 // is specified as last argument in the call to fmt_float.
 func (p *pp) fmtFloat(v float64, size int, verb rune) {
+	if !verbAccepts(verb, opFloat) {
+		p.badVerb(verb)
+		return
+	}
 	switch verb {
 	case 'v':
 		p.fmt.fmt_float(v, size, 'g', -1)
@@ -469,8 +684,6 @@ func (p *pp) fmtFloat(v float64, size int, verb rune) {
 		p.fmt.fmt_float(v, size, verb, 6)
 	case 'F':
 		p.fmt.fmt_float(v, size, 'f', 6)
-	default:
-		p.badVerb(verb)
 	}
 }
 
@@ -480,22 +693,25 @@ func (p *pp) fmtFloat(v float64, size int, verb rune) {
 func (p *pp) fmtComplex(v complex128, size int, verb rune) {
 	// Make sure any unsupported verbs are found before the
 	// calls to fmtFloat to not generate an incorrect error string.
-	switch verb {
-	case 'v', 'b', 'g', 'G', 'f', 'F', 'e', 'E':
-		oldPlus := p.fmt.plus
-		p.buf.WriteByte('(')
-		p.fmtFloat(real(v), size/2, verb)
-		// Imaginary part always has a sign.
-		p.fmt.plus = true
-		p.fmtFloat(imag(v), size/2, verb)
-		p.buf.WriteString("i)")
-		p.fmt.plus = oldPlus
-	default:
+	if !verbAccepts(verb, opFloat) {
 		p.badVerb(verb)
+		return
 	}
+	oldPlus := p.fmt.plus
+	p.buf.WriteByte('(')
+	p.fmtFloat(real(v), size/2, verb)
+	// Imaginary part always has a sign.
+	p.fmt.plus = true
+	p.fmtFloat(imag(v), size/2, verb)
+	p.buf.WriteString("i)")
+	p.fmt.plus = oldPlus
 }
 
 func (p *pp) fmtString(v string, verb rune) {
+	if !verbAccepts(verb, opString) {
+		p.badVerb(verb)
+		return
+	}
 	switch verb {
 	case 'v':
 		if p.fmt.sharpV {
@@ -511,8 +727,6 @@ func (p *pp) fmtString(v string, verb rune) {
 		p.fmt.fmt_sx(v, udigits)
 	case 'q':
 		p.fmt.fmt_q(v)
-	default:
-		p.badVerb(verb)
 	}
 }
 
@@ -557,14 +771,18 @@ func (p *pp) fmtBytes(v []byte, verb rune, typeString string) {
 }
 
 func (p *pp) fmtPointer(value reflect.Value, verb rune) {
-	var u uintptr
+	var op operand
 	switch value.Kind() {
-	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
-		u = value.Pointer()
-	default:
+	case reflect.Chan, reflect.Func, reflect.Ptr, reflect.UnsafePointer:
+		op = opPointer
+	case reflect.Map, reflect.Slice:
+		op = opRef
+	}
+	if !verbAccepts(verb, op) {
 		p.badVerb(verb)
 		return
 	}
+	u := value.Pointer()
 
 	switch verb {
 	case 'v':
@@ -586,14 +804,41 @@ func (p *pp) fmtPointer(value reflect.Value, verb rune) {
 			}
 		}
 	case 'p':
+		if !p.fmt.plus {
+			p.fmt0x64(uint64(u), !p.fmt.sharp)
+			break
+		}
+		// %+p annotates code addresses with their symbol.
+		// %+p 会为代码地址注明其符号。
+		p.fmt.plus = false
 		p.fmt0x64(uint64(u), !p.fmt.sharp)
+		p.fmt.plus = true
+		if u != 0 {
+			p.fmtSymbol(u)
+		}
 	case 'b', 'o', 'd', 'x', 'X':
 		p.fmtInteger(uint64(u), unsigned, verb)
-	default:
-		p.badVerb(verb)
 	}
 }
 
+// fmtSymbol appends the name of the function containing the code address u
+// and the offset of u within it, as in " (main.handler+0x40)". It appends
+// nothing if u is not in the program's text segment.
+
+// fmtSymbol 追加包含代码地址 u 的函数名以及 u 在该函数中的偏移量，例如
+// " (main.handler+0x40)"。若 u 不在程序的代码段中，则什么也不追加。
+func (p *pp) fmtSymbol(u uintptr) {
+	f := runtime.FuncForPC(u)
+	if f == nil {
+		return
+	}
+	p.buf.WriteString(" (")
+	p.buf.WriteString(f.Name())
+	p.buf.WriteString("+0x")
+	p.buf = strconv.AppendUint(p.buf, uint64(u-f.Entry()), 16)
+	p.buf.WriteByte(')')
+}
+
 func (p *pp) catchPanic(arg interface{}, verb rune) {
 	if err := recover(); err != nil {
 		// If it's a nil pointer, just say "<nil>". The likeliest causes are a
@@ -656,8 +901,7 @@ func (p *pp) handleMethods(verb rune) (handled bool) {
 		//
 		// 若一个字符串是否可以接受取决于其格式，就看它的值是否满足其中一种字符串值的接口。
 		// Println 等函数会将占位符设置为 %v，它是“可字符串化”的。
-		switch verb {
-		case 'v', 's', 'x', 'X', 'q':
+		if verbUsesMethods(verb) {
 			// Is it an error or Stringer?
 			// The duplication in the bodies is necessary:
 			// setting handled and deferring catchPanic
@@ -815,8 +1059,8 @@ func (p *pp) printValue(value reflect.Value, verb rune, depth int) {
 		} else {
 			p.buf.WriteString(mapString)
 		}
-		keys := f.MapKeys()
-		for i, key := range keys {
+		sorted := fmtsort.Sort(f)
+		for i, key := range sorted.Key {
 			if i > 0 {
 				if p.fmt.sharpV {
 					p.buf.WriteString(commaSpaceString)
@@ -826,7 +1070,7 @@ func (p *pp) printValue(value reflect.Value, verb rune, depth int) {
 			}
 			p.printValue(key, verb, depth+1)
 			p.buf.WriteByte(':')
-			p.printValue(f.MapIndex(key), verb, depth+1)
+			p.printValue(sorted.Value[i], verb, depth+1)
 		}
 		if p.fmt.sharpV {
 			p.buf.WriteByte('}')
@@ -1028,6 +1272,7 @@ func (p *pp) doPrintf(format string, a []interface{}) {
 	argNum := 0         // we process one argument per non-trivial format // 我们为每个非平凡格式都处理一个实参。
 	afterIndex := false // previous item in format was an index like [3]. // 格式中的前一项是否为类似于 [3] 的下标。
 	p.reordered = false
+	p.setArgs(len(a), false)
 formatLoop:
 	for i := 0; i < end; {
 		p.goodArgNum = true
@@ -1074,6 +1319,7 @@ formatLoop:
 						p.fmt.plusV = p.fmt.plus
 						p.fmt.plus = false
 					}
+					p.argNum = argNum
 					p.printArg(a[argNum], rune(c))
 					argNum++
 					i++
@@ -1169,6 +1415,7 @@ formatLoop:
 			p.fmt.plus = false
 			fallthrough
 		default:
+			p.argNum = argNum
 			p.printArg(a[argNum], verb)
 			argNum++
 		}
@@ -1179,8 +1426,10 @@ formatLoop:
 	// been used and arguably OK if they're not.
 	if !p.reordered && argNum < len(a) {
 		p.fmt.clearflags()
+		p.implicit = true
 		p.buf.WriteString(extraString)
 		for i, arg := range a[argNum:] {
+			p.argNum = argNum + i
 			if i > 0 {
 				p.buf.WriteString(commaSpaceString)
 			}
@@ -1196,9 +1445,21 @@ formatLoop:
 	}
 }
 
+// setArgs records the number of operands about to be printed
+// and whether their verbs are implicit.
+
+// setArgs 记录将要打印的操作数的数量，以及其占位符是否为隐式的。
+func (p *pp) setArgs(numArgs int, implicit bool) {
+	p.argNum = 0
+	p.numArgs = numArgs
+	p.implicit = implicit
+}
+
 func (p *pp) doPrint(a []interface{}) {
+	p.setArgs(len(a), true)
 	prevString := false
 	for argNum, arg := range a {
+		p.argNum = argNum
 		isString := arg != nil && reflect.TypeOf(arg).Kind() == reflect.String
 		// Add a space between two non-string arguments.
 		if argNum > 0 && !isString && !prevString {
@@ -1212,7 +1473,9 @@ func (p *pp) doPrint(a []interface{}) {
 // doPrintln is like doPrint but always adds a space between arguments
 // and a newline after the last argument.
 func (p *pp) doPrintln(a []interface{}) {
+	p.setArgs(len(a), true)
 	for argNum, arg := range a {
+		p.argNum = argNum
 		if argNum > 0 {
 			p.buf.WriteByte(' ')
 		}
@@ -1220,3 +1483,16 @@ func (p *pp) doPrintln(a []interface{}) {
 	}
 	p.buf.WriteByte('\n')
 }
+
+// doPrintj is like doPrintln but separates the arguments with sep
+// and adds no newline.
+func (p *pp) doPrintj(sep string, a []interface{}) {
+	p.setArgs(len(a), true)
+	for argNum, arg := range a {
+		p.argNum = argNum
+		if argNum > 0 {
+			p.buf.WriteString(sep)
+		}
+		p.printArg(arg, 'v')
+	}
+}
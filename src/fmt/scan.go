@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 	"unicode/utf8"
 )
 
@@ -181,6 +182,29 @@ func Fscanln(r io.Reader, a ...interface{}) (n int, err error) {
 	return
 }
 
+// FscanlnLines is like Fscanln, but first skips any blank lines, those
+// holding only spaces, and also returns the number of newlines it
+// consumed: those of the blank lines and the one ending the line of the
+// items, if it read it. Adding up lines over successive calls gives the
+// number of the line being read, for reporting errors in line-oriented
+// input. A final line ended by EOF rather than a newline is scanned
+// like any other, but adds no newline to the count.
+
+// FscanlnLines 类似于 Fscanln，但它会先跳过所有空行，即只包含空白的行，
+// 并且还会返回它所消耗的换行符数量：即空行的换行符，以及（若已读取）结束条目
+// 所在行的换行符。将连续调用所返回的 lines 累加即可得到当前读取的行号，
+// 以便在面向行的输入中报告错误。以 EOF 而非换行符结束的最后一行会像其它行
+// 一样被扫描，但不会增加换行符的计数。
+func FscanlnLines(r io.Reader, a ...interface{}) (n, lines int, err error) {
+	s, old := newScanState(r, false, true)
+	if err = s.skipBlankLines(); err == nil {
+		n, err = s.doScan(a)
+	}
+	lines = s.lines
+	s.free(old)
+	return
+}
+
 // Fscanf scans text read from r, storing successive space-separated
 // values into successive arguments as determined by the format. It
 // returns the number of items successfully parsed.
@@ -195,6 +219,40 @@ func Fscanf(r io.Reader, format string, a ...interface{}) (n int, err error) {
 	return
 }
 
+// FscanLimited is like Fscan, but fails with the error "token too long:
+// exceeds N bytes" as soon as a token it reads, such as the text of a
+// string or number, grows longer than maxTokenBytes bytes. It reads at
+// most maxTokenBytes+utf8.UTFMax bytes of a token, so that input from an
+// untrusted source cannot make it use an unbounded amount of memory.
+// A maxTokenBytes of 0 or less means no limit.
+
+// FscanLimited 类似于 Fscan，但在它读取的记号（例如字符串或数字的文本）
+// 超过 maxTokenBytes 个字节时，它会立即以错误 "token too long: exceeds N bytes"
+// 失败。对于每个记号，它最多读取 maxTokenBytes+utf8.UTFMax 个字节，
+// 因此来自不可信来源的输入无法使其无限制地占用内存。maxTokenBytes 为 0
+// 或更小表示不限制。
+func FscanLimited(r io.Reader, maxTokenBytes int, a ...interface{}) (n int, err error) {
+	s, old := newScanState(r, true, false)
+	s.setMaxToken(maxTokenBytes)
+	n, err = s.doScan(a)
+	s.free(old)
+	return
+}
+
+// FscanfLimited is like Fscanf, with the limit on the length of tokens
+// of FscanLimited. A width in the format still limits its operand's
+// token, which must also fit within maxTokenBytes.
+
+// FscanfLimited 类似于 Fscanf，但具有 FscanLimited 对记号长度的限制。
+// 格式中的宽度仍会限制其操作数的记号，该记号也必须在 maxTokenBytes 之内。
+func FscanfLimited(r io.Reader, maxTokenBytes int, format string, a ...interface{}) (n int, err error) {
+	s, old := newScanState(r, false, false)
+	s.setMaxToken(maxTokenBytes)
+	n, err = s.doScanf(format, a)
+	s.free(old)
+	return
+}
+
 // scanError represents an error generated by the scanning software.
 // It's used as a unique signature to identify such errors when recovering.
 
@@ -209,10 +267,13 @@ const eof = -1
 
 // ss 为 ScanState 的内部实现。
 type ss struct {
-	rs    io.RuneScanner // where to read input
-	buf   buffer         // token accumulator
-	count int            // runes consumed so far.
-	atEOF bool           // already read EOF
+	rs     io.RuneScanner // where to read input
+	buf    buffer         // token accumulator
+	count  int            // runes consumed so far.
+	atEOF  bool           // already read EOF
+	lines  int            // newlines consumed so far.
+	wasNL  bool           // the last rune read was a newline
+	maxTok int            // max length of a token in bytes, 0 for no limit
 	ssave
 }
 
@@ -227,6 +288,7 @@ type ssave struct {
 	argLimit  int  // max value of ss.count for this arg; argLimit <= limit // 该字段为 ss.count 的最大值；argLimit <= limit
 	limit     int  // max value of ss.count.                // ss.count 的最大值
 	maxWid    int  // width of this field.                  // 该字段的宽度。
+	argNum    int  // index of the operand being scanned.   // 正在扫描的操作数的索引。
 }
 
 // The Read method is only in ScanState so that ScanState
@@ -246,10 +308,14 @@ func (s *ss) ReadRune() (r rune, size int, err error) {
 	}
 
 	r, size, err = s.rs.ReadRune()
+	s.wasNL = err == nil && r == '\n'
 	if err == nil {
 		s.count++
-		if s.nlIsEnd && r == '\n' {
-			s.atEOF = true
+		if r == '\n' {
+			s.lines++
+			if s.nlIsEnd {
+				s.atEOF = true
+			}
 		}
 	} else if err == io.EOF {
 		s.atEOF = true
@@ -298,6 +364,10 @@ func (s *ss) UnreadRune() error {
 	s.rs.UnreadRune()
 	s.atEOF = false
 	s.count--
+	if s.wasNL {
+		s.lines--
+		s.wasNL = false
+	}
 	return nil
 }
 
@@ -309,6 +379,41 @@ func (s *ss) errorString(err string) {
 	panic(scanError{errors.New(err)})
 }
 
+// writeRune adds r to the token being scanned, which must not grow longer
+// than the limit set by FscanLimited.
+
+// writeRune 将 r 添加到正在扫描的记号中，该记号的长度不能超过 FscanLimited
+// 设置的限制。
+func (s *ss) writeRune(r rune) {
+	if s.maxTok > 0 && len(s.buf)+utf8.RuneLen(r) > s.maxTok {
+		s.tokenTooLong()
+	}
+	s.buf.WriteRune(r)
+}
+
+// writeByte is like writeRune for a single byte.
+
+// writeByte 类似于 writeRune，但只针对单个字节。
+func (s *ss) writeByte(b byte) {
+	if s.maxTok > 0 && len(s.buf)+1 > s.maxTok {
+		s.tokenTooLong()
+	}
+	s.buf.WriteByte(b)
+}
+
+// setMaxToken sets the limit of FscanLimited and FscanfLimited.
+
+// setMaxToken 设置 FscanLimited 和 FscanfLimited 的限制。
+func (s *ss) setMaxToken(maxTokenBytes int) {
+	if maxTokenBytes > 0 {
+		s.maxTok = maxTokenBytes
+	}
+}
+
+func (s *ss) tokenTooLong() {
+	s.errorString("token too long: exceeds " + strconv.Itoa(s.maxTok) + " bytes")
+}
+
 func (s *ss) Token(skipSpace bool, f func(rune) bool) (tok []byte, err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -478,8 +583,12 @@ func newScanState(r io.Reader, nlIsSpace, nlIsEnd bool) (s *ss, old ssave) {
 	s.limit = hugeWid
 	s.argLimit = hugeWid
 	s.maxWid = hugeWid
+	s.argNum = 0
 	s.validSave = true
 	s.count = 0
+	s.lines = 0
+	s.wasNL = false
+	s.maxTok = 0
 	return
 }
 
@@ -532,6 +641,27 @@ func (s *ss) skipSpace(stopAtNewline bool) {
 	}
 }
 
+// skipBlankLines consumes the lines that hold only spaces, up to the
+// first one that holds anything else or EOF.
+
+// skipBlankLines 消耗只包含空白的行，直到遇到第一个包含其它内容的行或 EOF。
+func (s *ss) skipBlankLines() (err error) {
+	defer errorHandler(&err)
+	for {
+		r := s.getRune()
+		switch {
+		case r == eof:
+			return
+		case r == '\n':
+			// A newline ends the scan; this one only ends a blank line.
+			s.atEOF = false
+		case !isSpace(r):
+			s.UnreadRune()
+			return
+		}
+	}
+}
+
 // token returns the next space-delimited string from the input. It
 // skips white space. For Scanln, it stops at newlines. For Scan,
 // newlines are treated as spaces.
@@ -553,7 +683,7 @@ func (s *ss) token(skipSpace bool, f func(rune) bool) []byte {
 			s.UnreadRune()
 			break
 		}
-		s.buf.WriteRune(r)
+		s.writeRune(r)
 	}
 	return s.buf
 }
@@ -581,13 +711,13 @@ func (s *ss) consume(ok string, accept bool) bool {
 	}
 	if indexRune(ok, r) >= 0 {
 		if accept {
-			s.buf.WriteRune(r)
+			s.writeRune(r)
 		}
 		return true
 	}
-	if r != eof && accept {
-		s.UnreadRune()
-	}
+	// Put the rune back even if it is not being accepted, so that
+	// a failed scan leaves the input positioned at the offending rune.
+	s.UnreadRune()
 	return false
 }
 
@@ -642,6 +772,9 @@ func (s *ss) scanBool(verb rune) bool {
 	if !s.okVerb(verb, "tv", "boolean") {
 		return false
 	}
+	if verb == 'v' {
+		return s.scanLenientBool()
+	}
 	// Syntax-checking a boolean is annoying. We're not fastidious about case.
 	switch s.getRune() {
 	case '0':
@@ -662,6 +795,45 @@ func (s *ss) scanBool(verb rune) bool {
 	return false
 }
 
+// boolForms lists the tokens accepted by scanLenientBool, as strconv.ParseBool does.
+const boolForms = "1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False"
+
+// isLetter reports whether r is an ASCII letter.
+func isLetter(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+}
+
+// scanLenientBool returns the value of the boolean represented by the next
+// token for the %v verb, which accepts the forms of strconv.ParseBool.
+// A token is a single digit or a run of letters, so that "FALSE23" scans
+// as a boolean followed by a number.
+
+// scanLenientBool 为 %v 占位符返回下一个标记所表示的布尔值，
+// 它接受 strconv.ParseBool 所接受的形式。标记为单个数字或一串字母，
+// 因此 "FALSE23" 会被扫描为一个布尔值后跟一个数字。
+func (s *ss) scanLenientBool() bool {
+	var tok string
+	if s.accept("0123456789") {
+		tok = string(s.buf)
+	} else {
+		tok = string(s.token(false, isLetter))
+	}
+	switch tok {
+	case "1", "t", "T", "TRUE", "true", "True":
+		return true
+	case "0", "f", "F", "FALSE", "false", "False":
+		return false
+	}
+	if tok == "" {
+		// Report the offending rune, which the token stopped at.
+		if r := s.getRune(); r != eof {
+			tok = string(r)
+		}
+	}
+	s.errorString("syntax error scanning boolean: " + strconv.Quote(tok) + " is not one of " + boolForms)
+	return false
+}
+
 // Numerical elements
 
 // 数值元素。
@@ -970,16 +1142,16 @@ func (s *ss) quotedString() string {
 			if r == quote {
 				break
 			}
-			s.buf.WriteRune(r)
+			s.writeRune(r)
 		}
 		return string(s.buf)
 	case '"':
 		// Double-quoted: Include the quotes and let strconv.Unquote do the backslash escapes.
 		// 双引号围绕的：包括该引号并让 strconv.Unquote 进行反斜杠转义。
-		s.buf.WriteByte('"')
+		s.writeByte('"')
 		for {
 			r := s.mustReadRune()
-			s.buf.WriteRune(r)
+			s.writeRune(r)
 			if r == '\\' {
 				// In a legal backslash escape, no matter how long, only the character
 				// immediately after the escape can itself be a backslash or quote.
@@ -988,7 +1160,7 @@ func (s *ss) quotedString() string {
 				// 在一个合法的反斜杠转义中，无论多长，
 				// 只有紧跟反斜杠之后的转义本身才可以是反斜杠或引号。
 				// 因此我们之需要保证第一个字符在反斜杠之后即可。
-				s.buf.WriteRune(s.mustReadRune())
+				s.writeRune(s.mustReadRune())
 			} else if r == '"' {
 				break
 			}
@@ -999,6 +1171,7 @@ func (s *ss) quotedString() string {
 		}
 		return result
 	default:
+		s.UnreadRune()
 		s.errorString("expected quoted string")
 	}
 	return ""
@@ -1039,6 +1212,7 @@ func (s *ss) hexByte() (b byte, ok bool) {
 	}
 	value2, ok := hexDigit(s.mustReadRune())
 	if !ok {
+		s.UnreadRune()
 		s.errorString("illegal hex digit")
 		return
 	}
@@ -1055,7 +1229,7 @@ func (s *ss) hexString() string {
 		if !ok {
 			break
 		}
-		s.buf.WriteByte(b)
+		s.writeByte(b)
 	}
 	if len(s.buf) == 0 {
 		s.errorString("no hex data for %x string")
@@ -1091,6 +1265,16 @@ func (s *ss) scanOne(verb rune, arg interface{}) {
 		}
 		return
 	}
+	// If it can unmarshal itself from text, pass it the next word.
+	// 若它能从文本中解组自身，就将下一个单词传给它。
+	if v, ok := arg.(textUnmarshaler); ok && (verb == 's' || verb == 'v') {
+		s.skipSpace(false)
+		s.notEOF()
+		if err = v.UnmarshalText(s.token(true, notSpace)); err != nil {
+			s.operandError(err)
+		}
+		return
+	}
 
 	switch v := arg.(type) {
 	case *bool:
@@ -1138,6 +1322,27 @@ func (s *ss) scanOne(verb rune, arg interface{}) {
 		}
 	case *string:
 		*v = s.convertString(verb)
+	case *time.Duration:
+		if verb != 's' && verb != 'v' {
+			*v = time.Duration(s.scanInt(verb, 64))
+			break
+		}
+		s.skipSpace(false)
+		s.notEOF()
+		tok := string(s.token(true, notSpace))
+		// With %v, an integer without a unit is a number of nanoseconds,
+		// as it would be for any other int64.
+		// 对于 %v，不带单位的整数即为纳秒数，这与其它 int64 相同。
+		if verb == 'v' {
+			if i, e := strconv.ParseInt(tok, 0, 64); e == nil {
+				*v = time.Duration(i)
+				break
+			}
+		}
+		*v, err = time.ParseDuration(tok)
+		if err != nil {
+			s.operandError(err)
+		}
 	case *[]byte:
 		// We scan to string and convert so we get a copy of the data.
 		// If we scanned to bytes, the slice would point at the buffer.
@@ -1184,6 +1389,22 @@ func (s *ss) scanOne(verb rune, arg interface{}) {
 	}
 }
 
+// textUnmarshaler is encoding.TextUnmarshaler, which fmt does not import.
+
+// textUnmarshaler 即 encoding.TextUnmarshaler，fmt 并不导入它。
+type textUnmarshaler interface {
+	UnmarshalText(text []byte) error
+}
+
+// operandError reports err, returned by the conversion of the text of
+// the operand being scanned, numbering the operand from 1.
+
+// operandError 报告扫描当前操作数的文本时，其转换所返回的错误 err，
+// 操作数从 1 开始编号。
+func (s *ss) operandError(err error) {
+	s.error(errors.New("operand " + strconv.Itoa(s.argNum+1) + ": " + err.Error()))
+}
+
 // errorHandler turns local panics into error returns.
 
 // errorHandler 将局部panic转为错误返回。
@@ -1204,7 +1425,8 @@ func errorHandler(errp *error) {
 // doScan 进行真正的扫描工作而无需格式字符串。
 func (s *ss) doScan(a []interface{}) (numProcessed int, err error) {
 	defer errorHandler(&err)
-	for _, arg := range a {
+	for i, arg := range a {
+		s.argNum = i
 		s.scanOne('v', arg)
 		numProcessed++
 	}
@@ -1292,7 +1514,9 @@ func (s *ss) advance(format string) (i int) {
 				// We've reached a newline, stop now; don't read further.
 				return
 			}
-			s.UnreadRune()
+			if inputc != eof {
+				s.UnreadRune()
+			}
 			if wasNewline {
 				s.errorString("newline in format does not match input")
 			}
@@ -1360,6 +1584,7 @@ func (s *ss) doScanf(format string, a []interface{}) (numProcessed int, err erro
 		}
 		arg := a[numProcessed]
 
+		s.argNum = numProcessed
 		s.scanOne(c, arg)
 		numProcessed++
 		s.argLimit = s.limit
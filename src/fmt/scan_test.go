@@ -11,10 +11,13 @@ import (
 	. "fmt"
 	"io"
 	"math"
+	"net"
 	"reflect"
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 )
 
@@ -474,6 +477,154 @@ func TestScanOverflow(t *testing.T) {
 	}
 }
 
+var lenientBoolTests = []struct {
+	text string
+	out  bool
+}{
+	{"1", true},
+	{"t", true},
+	{"T", true},
+	{"TRUE", true},
+	{"true", true},
+	{"True", true},
+	{"0", false},
+	{"f", false},
+	{"F", false},
+	{"FALSE", false},
+	{"false", false},
+	{"False", false},
+}
+
+func TestScanLenientBool(t *testing.T) {
+	for _, test := range lenientBoolTests {
+		for _, scan := range []func(string, *bool) (int, error){
+			func(text string, b *bool) (int, error) { return Sscan(text, b) },
+			func(text string, b *bool) (int, error) { return Sscanf(text, "%v", b) },
+		} {
+			b := !test.out
+			n, err := scan(" "+test.text+" ", &b)
+			if n != 1 || err != nil {
+				t.Errorf("scanning %q: got %d, %v", test.text, n, err)
+				continue
+			}
+			if b != test.out {
+				t.Errorf("scanning %q: got %v, want %v", test.text, b, test.out)
+			}
+		}
+	}
+}
+
+func TestScanLenientBoolErrors(t *testing.T) {
+	for _, text := range []string{"yes", "no", "y", "2", "tRuE", "fAlSe", "truex", "-1", "+"} {
+		var b bool
+		_, err := Sscanf(text, "%v", &b)
+		if err == nil {
+			t.Errorf("scanning %q: expected error", text)
+			continue
+		}
+		tok := text
+		if text == "-1" || text == "+" {
+			tok = text[:1]
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, `"`+tok+`"`) || !strings.Contains(msg, "1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False") {
+			t.Errorf("scanning %q: error %q does not name the token and the accepted forms", text, msg)
+		}
+	}
+}
+
+func TestScanLenientBoolWidth(t *testing.T) {
+	var b bool
+	var i int
+	n, err := Sscanf("10", "%1v%d", &b, &i)
+	if n != 2 || err != nil || !b || i != 0 {
+		t.Errorf(`Sscanf("10", "%%1v%%d"): got %d, %v, %v, %d; want 2, <nil>, true, 0`, n, err, b, i)
+	}
+	n, err = Sscanf("FALSE23", "%v%d", &b, &i)
+	if n != 2 || err != nil || b || i != 23 {
+		t.Errorf(`Sscanf("FALSE23", "%%v%%d"): got %d, %v, %v, %d; want 2, <nil>, false, 23`, n, err, b, i)
+	}
+	n, err = Sscanf("TRUE", "%1v", &b)
+	if n != 1 || err != nil || !b {
+		t.Errorf(`Sscanf("TRUE", "%%1v"): got %d, %v, %v; want 1, <nil>, true`, n, err, b)
+	}
+	n, err = Sscanf("False", "%3v", &b)
+	if err == nil {
+		t.Errorf(`Sscanf("False", "%%3v"): got %d, %v; want error for "Fal"`, n, b)
+	}
+	// %t keeps its own syntax.
+	if _, err = Sscanf("True", "%t", &b); err != nil || !b {
+		t.Errorf(`Sscanf("True", "%%t"): got %v, %v`, b, err)
+	}
+}
+
+// badText fails to unmarshal any text.
+type badText struct{}
+
+func (*badText) UnmarshalText(text []byte) error {
+	return errors.New("bad text " + string(text))
+}
+
+func TestScanTextUnmarshaler(t *testing.T) {
+	var ip1, ip2 net.IP
+	n, err := Sscan(" 10.0.0.1\n2001:db8::1 ", &ip1, &ip2)
+	if n != 2 || err != nil || !ip1.Equal(net.IPv4(10, 0, 0, 1)) || !ip2.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("Sscan IPs: got %d, %v, %v, %v", n, err, ip1, ip2)
+	}
+	// The width limits the text passed to UnmarshalText.
+	var i int
+	n, err = Sscanf("1.2.3.45", "%7v%d", &ip1, &i)
+	if n != 2 || err != nil || !ip1.Equal(net.IPv4(1, 2, 3, 4)) || i != 5 {
+		t.Errorf(`Sscanf("1.2.3.45", "%%7v%%d"): got %d, %v, %v, %d`, n, err, ip1, i)
+	}
+
+	var tm time.Time
+	var s string
+	n, err = Sscanf("2016-11-01T15:04:05Z done", "%s %v", &tm, &s)
+	if want := time.Date(2016, 11, 1, 15, 4, 5, 0, time.UTC); n != 2 || err != nil || !tm.Equal(want) || s != "done" {
+		t.Errorf("Sscanf time: got %d, %v, %v, %q; want 2, <nil>, %v, \"done\"", n, err, tm, s, want)
+	}
+
+	// Other verbs keep the scanning of the underlying type.
+	var d1, d2, d3 time.Duration
+	n, err = Sscanf("300ms 1h2m 5", "%v %s %d", &d1, &d2, &d3)
+	if n != 3 || err != nil || d1 != 300*time.Millisecond || d2 != time.Hour+2*time.Minute || d3 != 5 {
+		t.Errorf("Sscanf durations: got %d, %v, %v, %v, %v", n, err, d1, d2, d3)
+	}
+
+	// With %v, plain integers are still nanoseconds.
+	n, err = Sscan("300 -5 0x10", &d1, &d2, &d3)
+	if n != 3 || err != nil || d1 != 300 || d2 != -5 || d3 != 16 {
+		t.Errorf("Sscan integer durations: got %d, %v, %v, %v, %v", n, err, d1, d2, d3)
+	}
+	n, err = Sscanf("300", "%s", &d1)
+	if n != 0 || err == nil {
+		t.Errorf(`Sscanf("300", "%%s"): got %d, %v; want error`, n, err)
+	}
+}
+
+func TestScanTextUnmarshalerErrors(t *testing.T) {
+	var s string
+	var bad badText
+	n, err := Sscan("x y", &s, &bad)
+	if n != 1 || err == nil || err.Error() != "operand 2: bad text y" {
+		t.Errorf(`Sscan("x y"): got %d, %v; want 1, "operand 2: bad text y"`, n, err)
+	}
+	var tm time.Time
+	n, err = Sscanf("2016-11-01", "%v", &tm)
+	if n != 0 || err == nil || !strings.HasPrefix(err.Error(), "operand 1: parsing time ") {
+		t.Errorf(`Sscanf("2016-11-01"): got %d, %v; want error for operand 1`, n, err)
+	}
+	var d time.Duration
+	n, err = Sscanf("3 lightyears", "%d %v", new(int), &d)
+	if n != 1 || err == nil || !strings.HasPrefix(err.Error(), "operand 2: time: ") {
+		t.Errorf(`Sscanf("3 lightyears"): got %d, %v; want error for operand 2`, n, err)
+	}
+	if _, err = Sscan("", &bad); err != io.EOF {
+		t.Errorf("Sscan of empty input: got %v, want EOF", err)
+	}
+}
+
 func verifyNaN(str string, t *testing.T) {
 	var f float64
 	var f32 float32
@@ -661,6 +812,67 @@ func TestScanlnWithMiddleNewline(t *testing.T) {
 	}
 }
 
+func TestScanlnFinalLineWithoutNewline(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("1 2\n3 4"))
+	var a, b int
+	for i, want := range [][2]int{{1, 2}, {3, 4}} {
+		n, err := Fscanln(r, &a, &b)
+		if n != 2 || err != nil || a != want[0] || b != want[1] {
+			t.Fatalf("line %d: got %d, %v, %d %d; want 2, <nil>, %d %d", i+1, n, err, a, b, want[0], want[1])
+		}
+	}
+	if n, err := Fscanln(r, &a, &b); n != 0 || err != io.EOF {
+		t.Errorf("after the last line: got %d, %v; want 0, EOF", n, err)
+	}
+}
+
+var scanlnLinesTests = []struct {
+	name  string
+	text  string
+	lines []int // newlines consumed by each call that scans two ints
+}{
+	{"newlines", "1 2\n3 4\n", []int{1, 1}},
+	{"final line without newline", "1 2\n3 4", []int{1, 0}},
+	{"leading blank lines", "\n  \n\t\n1 2\n3 4\n", []int{4, 1}},
+	{"blank lines between", "1 2\n\n\n3 4", []int{1, 2}},
+	{"CRLF", "1 2\r\n\r\n3 4\r\n", []int{1, 2}},
+	{"CRLF mixed", "\r\n1 2\n \r\n3 4\r\n", []int{2, 2}},
+}
+
+func TestFscanlnLines(t *testing.T) {
+	for _, test := range scanlnLinesTests {
+		r := bufio.NewReader(strings.NewReader(test.text))
+		var a, b int
+		for i, want := range test.lines {
+			n, lines, err := FscanlnLines(r, &a, &b)
+			if n != 2 || err != nil || a != 2*i+1 || b != 2*i+2 || lines != want {
+				t.Errorf("%s: call %d: got %d, %d lines, %v, %d %d; want 2, %d lines, <nil>, %d %d",
+					test.name, i+1, n, lines, err, a, b, want, 2*i+1, 2*i+2)
+			}
+		}
+		if n, _, err := FscanlnLines(r, &a, &b); n != 0 || err != io.EOF {
+			t.Errorf("%s: after the last line: got %d, %v; want 0, EOF", test.name, n, err)
+		}
+	}
+}
+
+func TestFscanlnLinesError(t *testing.T) {
+	// A parser adding up lines knows which line failed.
+	r := bufio.NewReader(strings.NewReader("1 2\n\n3 x\n5 6\n"))
+	var a, b int
+	line := 1
+	for {
+		_, lines, err := FscanlnLines(r, &a, &b)
+		if err != nil {
+			if line+lines != 3 {
+				t.Errorf("error %q reported on line %d, want 3", err, line+lines)
+			}
+			return
+		}
+		line += lines
+	}
+}
+
 // eofCounter is a special Reader that counts reads at end of file.
 
 // eofCounter 在读到文件末时对读取进行计数的特殊 Reader。
@@ -1225,3 +1437,182 @@ func TestHexByte(t *testing.T) {
 		t.Fatalf("expected 0123 got %x", h)
 	}
 }
+
+// TestScanfLeavesReaderPositioned checks that Fscanf, given a reader that
+// implements io.RuneScanner, consumes exactly the input it scanned, so that
+// scanning can be interleaved with direct reads of the same reader.
+func TestScanfLeavesReaderPositioned(t *testing.T) {
+	var (
+		i int
+		s string
+		b bool
+	)
+	var tests = []struct {
+		name   string
+		text   string
+		format string
+		arg    interface{}
+		ok     bool
+		rest   string
+	}{
+		{"width", "12345 abc\n", "%3d", &i, true, "45 abc\n"},
+		{"width string", "abcdef\n", "%2s", &s, true, "cdef\n"},
+		{"full token", "123 abc\n", "%d", &i, true, " abc\n"},
+		{"eof", "42", "%d", &i, true, ""},
+		{"space at eof", "42 ", "%d ", &i, true, ""},
+		{"bad integer", "x1\n", "%d", &i, false, "x1\n"},
+		{"bad unicode", "X+1\n", "%U", &i, false, "X+1\n"},
+		{"other boolean", "yes\n", "%t", &b, true, "es\n"}, // %t consumes any other rune as false // %t 会将任何其它符文作为 false 消耗掉
+		{"bad quote", "abc\n", "%q", &s, false, "abc\n"},
+		{"bad hex", "4z\n", "%x", &s, false, "z\n"},
+		{"format mismatch", "b1\n", "a%d", &i, false, "b1\n"},
+	}
+	for _, test := range tests {
+		r := bufio.NewReader(strings.NewReader(test.text))
+		_, err := Fscanf(r, test.format, test.arg)
+		if test.ok && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s: expected error; got none", test.name)
+		}
+		rest, _ := r.ReadString(0)
+		if rest != test.rest {
+			t.Errorf("%s: remaining input %q; want %q", test.name, rest, test.rest)
+		}
+	}
+}
+
+// TestScanfInterleavedReads alternates Fscanf and ReadString on one
+// bufio.Reader and checks that no byte is lost or read twice.
+func TestScanfInterleavedReads(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("0012abc:rest 7|x\n99"))
+	var a, b, c int
+	if _, err := Fscanf(r, "%2d%2d", &a, &b); err != nil || a != 0 || b != 12 {
+		t.Fatalf("first scan: got %d %d, %v", a, b, err)
+	}
+	if s, err := r.ReadString(':'); err != nil || s != "abc:" {
+		t.Fatalf("first read: got %q, %v", s, err)
+	}
+	if _, err := Fscanf(r, "%d", &c); err == nil {
+		t.Fatalf("second scan: expected error; got none")
+	}
+	if s, err := r.ReadString(' '); err != nil || s != "rest " {
+		t.Fatalf("second read: got %q, %v", s, err)
+	}
+	if _, err := Fscanf(r, "%d", &c); err != nil || c != 7 {
+		t.Fatalf("third scan: got %d, %v", c, err)
+	}
+	if s, err := r.ReadString('\n'); err != nil || s != "|x\n" {
+		t.Fatalf("third read: got %q, %v", s, err)
+	}
+	if _, err := Fscanf(r, "%d", &c); err != nil || c != 99 {
+		t.Fatalf("fourth scan: got %d, %v", c, err)
+	}
+	if s, err := r.ReadString('\n'); err != io.EOF || s != "" {
+		t.Fatalf("fourth read: got %q, %v", s, err)
+	}
+}
+
+// endlessReader returns n bytes of b, as a stream with no spaces,
+// and counts the bytes read.
+type endlessReader struct {
+	b    byte
+	n    int
+	read int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	if r.read >= r.n {
+		return 0, io.EOF
+	}
+	if len(p) > r.n-r.read {
+		p = p[:r.n-r.read]
+	}
+	for i := range p {
+		p[i] = r.b
+	}
+	r.read += len(p)
+	return len(p), nil
+}
+
+func TestFscanLimited(t *testing.T) {
+	const limit = 1024
+	const size = 8 << 20
+	for _, test := range []struct {
+		b      byte
+		format string
+		arg    interface{}
+	}{
+		{'a', "", new(string)},
+		{'1', "", new(int)},
+		{'1', "", new(float64)},
+		{'a', "%s", new(string)},
+		{'a', "%x", new(string)},
+		{'a', "%v", new([]byte)},
+	} {
+		r := &endlessReader{b: test.b, n: size}
+		var mem0, mem1 runtime.MemStats
+		runtime.ReadMemStats(&mem0)
+		var err error
+		if test.format == "" {
+			_, err = FscanLimited(r, limit, test.arg)
+		} else {
+			_, err = FscanfLimited(r, limit, test.format, test.arg)
+		}
+		runtime.ReadMemStats(&mem1)
+		if err == nil || err.Error() != "token too long: exceeds 1024 bytes" {
+			t.Errorf("%q %T: got error %v, want token too long", test.format, test.arg, err)
+		}
+		// A %x token holds a byte for every two hex digits read.
+		max := limit + utf8.UTFMax
+		if test.format == "%x" {
+			max *= 2
+		}
+		if r.read > max {
+			t.Errorf("%q %T: read %d bytes of a %d-byte limit", test.format, test.arg, r.read, limit)
+		}
+		if alloc := mem1.TotalAlloc - mem0.TotalAlloc; alloc > 64<<10 {
+			t.Errorf("%q %T: allocated %d bytes", test.format, test.arg, alloc)
+		}
+	}
+
+	// A quoted string is a token, quotes included.
+	var s string
+	if _, err := FscanfLimited(strings.NewReader(`"abcdef"`), 7, "%q", &s); err == nil {
+		t.Errorf("scanning an 8-byte quoted string with a 7-byte limit: got %q, want error", s)
+	}
+	if _, err := FscanfLimited(strings.NewReader(`"abcdef"`), 8, "%q", &s); err != nil || s != "abcdef" {
+		t.Errorf("scanning an 8-byte quoted string with an 8-byte limit: got %q, %v", s, err)
+	}
+
+	// Tokens within the limit scan as usual, and the limit is per token.
+	var i int
+	if n, err := FscanLimited(strings.NewReader("hello 12345\n"), 5, &s, &i); n != 2 || err != nil || s != "hello" || i != 12345 {
+		t.Errorf("FscanLimited: got %d, %v: %q %d", n, err, s, i)
+	}
+	// A limit of 0 is no limit.
+	if _, err := FscanLimited(&endlessReader{b: 'a', n: 64 << 10}, 0, &s); err != nil || len(s) != 64<<10 {
+		t.Errorf("FscanLimited with no limit: got %d bytes, %v", len(s), err)
+	}
+}
+
+func TestFscanfLimitedWidth(t *testing.T) {
+	var s string
+	var i int
+	// A width within the limit stops the token at the width.
+	if n, err := FscanfLimited(strings.NewReader(" 1234567 "), 5, "%5s%d", &s, &i); n != 2 || err != nil || s != "12345" || i != 67 {
+		t.Errorf("%%5s%%d with a limit of 5: got %d, %v: %q %d", n, err, s, i)
+	}
+	// A width over the limit does not raise it.
+	if _, err := FscanfLimited(strings.NewReader("123456789"), 4, "%8s", &s); err == nil {
+		t.Errorf("%%8s with a limit of 4: got %q, want error", s)
+	}
+	// The limit counts bytes, the width runes.
+	if _, err := FscanfLimited(strings.NewReader("ééé"), 5, "%3s", &s); err == nil {
+		t.Errorf("%%3s of 6 bytes with a limit of 5: got %q, want error", s)
+	}
+	if _, err := FscanfLimited(strings.NewReader("ééé"), 6, "%2s", &s); err != nil || s != "éé" {
+		t.Errorf("%%2s with a limit of 6: got %q, %v", s, err)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmt
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// A VerbClass classifies a verb of the Printf family by the kind of
+// formatting it does.
+
+// VerbClass 按照格式化的种类对 Printf 系列函数的占位符进行分类。
+type VerbClass int
+
+const (
+	GenericVerb VerbClass = iota // %v, %T and %%, which format any operand or none // 可格式化任何操作数或不需要操作数的 %v、%T 和 %%
+	BoolVerb                     // %t
+	IntegerVerb                  // %b, %c, %d, %o, %r, %R, %U, %x and %X
+	FloatVerb                    // %e, %E, %f, %F, %g and %G
+	StringVerb                   // %s and %q
+	PointerVerb                  // %p
+)
+
+// VerbInfo describes a verb of the Printf family. Verbs applied to an
+// operand whose kind is not listed in Kinds print an error such as
+// %!d(string=hi), except that arrays, slices, maps and structs accept
+// every verb but %p that their elements accept, since the verb is applied
+// to each element, and byte slices and arrays also accept the verbs of
+// strings.
+// Operands implementing Formatter accept every verb.
+
+// VerbInfo 描述了 Printf 系列函数的一个占位符。若将占位符应用到其种类未在 Kinds
+// 中列出的操作数上，就会打印出诸如 %!d(string=hi) 的错误。不过数组、切片、映射和结构体
+// 接受其元素所接受的除 %p 以外的所有占位符，因为该占位符会应用到每一个元素上；字节切片和字节数组
+// 也接受字符串的占位符。实现了 Formatter 的操作数接受所有的占位符。
+type VerbInfo struct {
+	Verb    rune           // the verb, such as 'd' // 占位符，例如 'd'
+	Class   VerbClass      // the kind of formatting it does // 它所做的格式化的种类
+	Kinds   []reflect.Kind // kinds of operands it formats // 它所格式化的操作数的种类
+	Flags   string         // flags that can change its output, from "+-# 0" // 可改变其输出的标记，取自 "+-# 0"
+	Arg     bool           // whether it consumes an operand; false only for %% // 它是否消耗一个操作数；只有 %% 为 false
+	Methods bool           // whether it uses the Error and String methods of operands // 它是否使用操作数的 Error 和 String 方法
+}
+
+// Verbs returns a description of every verb of the Printf family,
+// ordered by verb. It is the table the printer itself consults, so
+// tools that check format strings can rely on it instead of keeping
+// their own copy.
+
+// Verbs 返回 Printf 系列函数中每一个占位符的描述，按占位符排序。
+// 它就是打印器本身所参考的表，因此检查格式字符串的工具可以依赖它，
+// 而无需自行维护一份副本。
+func Verbs() []VerbInfo {
+	var verbs []VerbInfo
+	for verb, d := range verbTable {
+		if d.flags == "" && d.operands == 0 && verb != '%' {
+			continue
+		}
+		info := VerbInfo{
+			Verb:    rune(verb),
+			Class:   d.class,
+			Flags:   d.flags,
+			Arg:     verb != '%',
+			Methods: d.methods,
+		}
+		for _, c := range operandKinds {
+			if d.operands&c.op != 0 {
+				info.Kinds = append(info.Kinds, c.kinds...)
+			}
+		}
+		verbs = append(verbs, info)
+	}
+	return verbs
+}
+
+// operand is a set of classes of operands.
+type operand uint8
+
+const (
+	opBool      operand = 1 << iota
+	opInteger           // integers of any size
+	opFloat             // floating-point and complex numbers
+	opString            // strings and, in fmtBytes, byte slices
+	opPointer           // channels, functions, pointers and unsafe pointers
+	opRef               // maps and slices, formatted as pointers by %p
+	opAggregate         // arrays and structs, formatted as a whole only by %v and %T
+	opAll       = opBool | opInteger | opFloat | opString | opPointer | opRef | opAggregate
+)
+
+// operandKinds lists the kinds of operands in each class.
+var operandKinds = []struct {
+	op    operand
+	kinds []reflect.Kind
+}{
+	{opBool, []reflect.Kind{reflect.Bool}},
+	{opInteger, []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+	}},
+	{opFloat, []reflect.Kind{reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128}},
+	{opString, []reflect.Kind{reflect.String}},
+	{opPointer, []reflect.Kind{reflect.Chan, reflect.Func, reflect.Ptr, reflect.UnsafePointer}},
+	{opRef, []reflect.Kind{reflect.Map, reflect.Slice}},
+	{opAggregate, []reflect.Kind{reflect.Array, reflect.Struct}},
+}
+
+// verbDesc describes a verb for the printer and for Verbs.
+type verbDesc struct {
+	class    VerbClass
+	operands operand // classes of operands formatted
+	flags    string  // flags that can change the output
+	methods  bool    // whether Error and String methods are used
+}
+
+// verbTable describes each verb, indexed by verb. The printer checks it
+// before formatting an operand and reports verbs that do not accept the
+// operand as bad, so that Verbs describes exactly what the printer does.
+var verbTable = [utf8.RuneSelf]verbDesc{
+	'%': {GenericVerb, 0, "", false},
+	'T': {GenericVerb, opAll, "-0", false},
+	'v': {GenericVerb, opAll, "+-# 0", true},
+	't': {BoolVerb, opBool, "-0", false},
+	'b': {IntegerVerb, opInteger | opFloat | opPointer, "+- 0", false},
+	'c': {IntegerVerb, opInteger, "-0", false},
+	'd': {IntegerVerb, opInteger | opPointer, "+- 0", false},
+	'o': {IntegerVerb, opInteger | opPointer, "+-# 0", false},
+	'r': {IntegerVerb, opInteger, "+- 0", false},
+	'R': {IntegerVerb, opInteger, "+- 0", false},
+	'U': {IntegerVerb, opInteger, "-#", false},
+	'x': {IntegerVerb, opInteger | opString | opPointer, "+-# 0", true},
+	'X': {IntegerVerb, opInteger | opString | opPointer, "+-# 0", true},
+	'e': {FloatVerb, opFloat, "+- 0", false},
+	'E': {FloatVerb, opFloat, "+- 0", false},
+	'f': {FloatVerb, opFloat, "+- 0", false},
+	'F': {FloatVerb, opFloat, "+- 0", false},
+	'g': {FloatVerb, opFloat, "+- 0", false},
+	'G': {FloatVerb, opFloat, "+- 0", false},
+	's': {StringVerb, opString, "-0", true},
+	'q': {StringVerb, opInteger | opString, "+-#0", true},
+	'p': {PointerVerb, opPointer | opRef, "+-# 0", false},
+}
+
+// verbAccepts reports whether verb formats operands of class op.
+func verbAccepts(verb rune, op operand) bool {
+	return verb < utf8.RuneSelf && verbTable[verb].operands&op != 0
+}
+
+// verbUsesMethods reports whether verb uses the Error and String
+// methods of operands.
+func verbUsesMethods(verb rune) bool {
+	return verb < utf8.RuneSelf && verbTable[verb].methods
+}
@@ -0,0 +1,160 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmt_test
+
+import (
+	. "fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// verbOperands has operands of every kind that verbs format directly.
+// Integers are positive and valid runes, so that %c and %q accept them.
+var verbOperands = []interface{}{
+	true,
+	int(65), int8(5), int16(5), int32(0x4e16), int64(5),
+	uint(5), uint8(65), uint16(5), uint32(5), uint64(5), uintptr(5),
+	float32(1.5), float64(-1.5), complex64(1 + 2i), complex128(-1 - 2i),
+	"héllo",
+	make(chan int), func() {}, new(int), unsafe.Pointer(new(int)),
+	map[int]int{1: 2}, []int{1}, [1]int{1}, struct{ A int }{1},
+}
+
+func verbAccepts(info VerbInfo, k reflect.Kind) bool {
+	for _, k1 := range info.Kinds {
+		if k1 == k {
+			return true
+		}
+	}
+	return false
+}
+
+func isBadVerb(s string, verb rune) bool {
+	return strings.HasPrefix(s, "%!"+string(verb)+"(")
+}
+
+// TestVerbKinds checks that the verbs accept exactly the kinds of
+// operands listed by Verbs.
+func TestVerbKinds(t *testing.T) {
+	verbs := Verbs()
+	known := make(map[rune]bool)
+	for _, info := range verbs {
+		known[info.Verb] = true
+		if info.Arg != (info.Verb != '%') {
+			t.Errorf("%%%c: Arg is %v", info.Verb, info.Arg)
+		}
+		if !info.Arg {
+			continue
+		}
+		format := "%" + string(info.Verb)
+		for _, x := range verbOperands {
+			k := reflect.TypeOf(x).Kind()
+			s := Sprintf(format, x)
+			accepted := verbAccepts(info, k)
+			if (k == reflect.Map || k == reflect.Slice || k == reflect.Array || k == reflect.Struct) && !accepted && info.Verb != 'p' {
+				// The verb is applied to the elements.
+				elem := Sprintf(format, 1)
+				want := "[" + elem + "]"
+				switch k {
+				case reflect.Map:
+					want = "map[" + elem + ":" + Sprintf(format, 2) + "]"
+				case reflect.Struct:
+					want = "{" + elem + "}"
+				}
+				if s != want {
+					t.Errorf("Sprintf(%q, %T) = %q, want %q", format, x, s, want)
+				}
+				continue
+			}
+			if isBadVerb(s, info.Verb) == accepted {
+				t.Errorf("Sprintf(%q, %T) = %q, but Verbs says %v is accepted: %v", format, x, s, k, accepted)
+			}
+		}
+	}
+
+	// Every other verb is bad for every operand.
+	for verb := rune(' ' + 1); verb < utf8.RuneSelf; verb++ {
+		if known[verb] || strings.ContainsRune("+-# 0123456789.*[", verb) {
+			continue
+		}
+		for _, x := range verbOperands[:17] {
+			if s := Sprintf("%"+string(verb), x); !isBadVerb(s, verb) {
+				t.Errorf("Sprintf(%q, %T) = %q, but Verbs does not list %%%c", "%"+string(verb), x, s, verb)
+			}
+		}
+	}
+}
+
+// TestVerbFlags checks that each verb honors exactly the flags listed by
+// Verbs: a flag is honored if it changes the output for some operand.
+func TestVerbFlags(t *testing.T) {
+	for _, info := range Verbs() {
+		if !info.Arg {
+			continue
+		}
+		for _, flag := range "+-# 0" {
+			honored := false
+			for _, x := range verbOperands {
+				if !verbAccepts(info, reflect.TypeOf(x).Kind()) {
+					continue
+				}
+				verb := string(info.Verb)
+				if Sprintf("%"+string(flag)+verb, x) != Sprintf("%"+verb, x) ||
+					Sprintf("%"+string(flag)+"20"+verb, x) != Sprintf("%20"+verb, x) {
+					honored = true
+					break
+				}
+			}
+			if want := strings.ContainsRune(info.Flags, flag); honored != want {
+				t.Errorf("%%%c: flag %q honored is %v, Verbs says %v", info.Verb, flag, honored, want)
+			}
+		}
+	}
+}
+
+type verbStringer int
+
+func (verbStringer) String() string { return "str" }
+
+// TestVerbMethods checks which verbs use String methods.
+func TestVerbMethods(t *testing.T) {
+	for _, info := range Verbs() {
+		if !info.Arg {
+			continue
+		}
+		format := "%" + string(info.Verb)
+		s := Sprintf(format, verbStringer(5))
+		if uses := s == Sprintf(format, "str"); uses != info.Methods {
+			t.Errorf("Sprintf(%q, verbStringer) = %q, but Verbs says Methods is %v", format, s, info.Methods)
+		}
+	}
+}
+
+func TestVerbClasses(t *testing.T) {
+	classes := map[VerbClass]string{
+		GenericVerb: "%Tv",
+		BoolVerb:    "t",
+		IntegerVerb: "bcdorRUxX",
+		FloatVerb:   "eEfFgG",
+		StringVerb:  "sq",
+		PointerVerb: "p",
+	}
+	var all []rune
+	for _, info := range Verbs() {
+		all = append(all, info.Verb)
+		if !strings.ContainsRune(classes[info.Class], info.Verb) {
+			t.Errorf("%%%c has class %d", info.Verb, info.Class)
+		}
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1] >= all[i] {
+			t.Errorf("Verbs is not sorted: %q", all)
+			break
+		}
+	}
+}
@@ -108,7 +108,10 @@ var pkgDeps = map[string][]string{
 	"image":               {"L2", "image/color"}, // interfaces
 	"image/color":         {"L2"},                // interfaces
 	"image/color/palette": {"L2", "image/color"},
-	"reflect":             {"L2"},
+
+	// SHA-256 block step, for reflect and crypto/sha256.
+	"internal/sha256block": {},
+	"reflect":              {"L2", "internal/sha256block"},
 
 	"L3": {
 		"L2",
@@ -165,8 +168,15 @@ var pkgDeps = map[string][]string{
 		"time",
 	},
 
-	// Formatted I/O: few dependencies (L1) but we must add reflect.
-	"fmt": {"L1", "os", "reflect"},
+	// Goroutine profiler labels.
+	"internal/proflabel": {"L0", "sort"},
+
+	// Map key ordering for printing.
+	"internal/fmtsort": {"reflect", "sort"},
+
+	// Formatted I/O: few dependencies (L1) but we must add reflect
+	// and internal/fmtsort.
+	"fmt": {"L1", "os", "reflect", "time", "internal/fmtsort"},
 	"log": {"L1", "os", "fmt", "time"},
 
 	// Packages used by testing must be low-level (L2+fmt).
@@ -320,7 +330,7 @@ var pkgDeps = map[string][]string{
 	"crypto/md5":    {"L3"},
 	"crypto/rc4":    {"L3"},
 	"crypto/sha1":   {"L3"},
-	"crypto/sha256": {"L3"},
+	"crypto/sha256": {"L3", "internal/sha256block"},
 	"crypto/sha512": {"L3"},
 
 	"CRYPTO": {
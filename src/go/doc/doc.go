@@ -86,10 +86,16 @@ const (
 	// show all embedded methods, not just the ones of
 	// invisible (unexported) anonymous fields
 	AllMethods
+
+	// leave the AST unmodified: do not remove function bodies,
+	// doc comments and comments from it (but without AllDecls,
+	// unexported declarations are still filtered out)
+	PreserveAST
 )
 
 // New computes the package documentation for the given package AST.
-// New takes ownership of the AST pkg and may edit or overwrite it.
+// New takes ownership of the AST pkg and may edit or overwrite it,
+// unless the mode has PreserveAST and AllDecls.
 //
 func New(pkg *ast.Package, importPath string, mode Mode) *Package {
 	var r reader
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
@@ -144,3 +145,50 @@ func Test(t *testing.T) {
 	test(t, AllDecls)
 	test(t, AllMethods)
 }
+
+const preserveSrc = `// Package p is a package.
+package p
+
+// T is a type.
+type T int
+
+// M is a method.
+func (T) M() int {
+	// A comment in the body.
+	return 1
+}
+
+// C is a constant.
+const C = 1
+`
+
+func TestPreserveAST(t *testing.T) {
+	for _, mode := range []Mode{AllDecls, AllDecls | PreserveAST} {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", preserveSrc, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg := &ast.Package{Name: "p", Files: map[string]*ast.File{"p.go": file}}
+		doc := New(pkg, "p", mode)
+
+		preserved := mode&PreserveAST != 0
+		meth := doc.Types[0].Methods[0].Decl
+		if got := meth.Body != nil; got != preserved {
+			t.Errorf("mode %d: method has body: %v, want %v", mode, got, preserved)
+		}
+		docs := []*ast.CommentGroup{file.Doc, meth.Doc, doc.Types[0].Decl.Doc, doc.Consts[0].Decl.Doc}
+		for i, g := range docs {
+			if got := g != nil; got != preserved {
+				t.Errorf("mode %d: doc comment %d kept: %v, want %v", mode, i, got, preserved)
+			}
+		}
+		if got := len(file.Comments) > 0; got != preserved {
+			t.Errorf("mode %d: comments kept: %v, want %v", mode, got, preserved)
+		}
+		// The documentation is the same either way.
+		if doc.Doc != "Package p is a package.\n" || doc.Types[0].Methods[0].Doc != "M is a method.\n" {
+			t.Errorf("mode %d: wrong docs %q, %q", mode, doc.Doc, doc.Types[0].Methods[0].Doc)
+		}
+	}
+}
@@ -66,7 +66,11 @@ func BImportData(imports map[string]*types.Package, data []byte, path string) (i
 
 	// --- generic export data ---
 
-	if v := p.string(); v != "v0" {
+	// v1 differs from v0 only in inlined function bodies, which are
+	// not read here.
+	switch v := p.string(); v {
+	case "v0", "v1":
+	default:
 		return p.read, nil, fmt.Errorf("unknown export data version: %s", v)
 	}
 
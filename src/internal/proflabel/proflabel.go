@@ -0,0 +1,101 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package proflabel attaches profiler labels to goroutines.
+// New goroutines inherit the labels of the goroutine that created them,
+// and while an execution trace is being collected every change of a
+// goroutine's labels is recorded in the trace.
+package proflabel
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// A Set is a set of labels. It is a list of key, value pairs sorted by
+// key with each key appearing at most once. The runtime relies on this
+// representation to record the labels in execution traces.
+type Set []string
+
+// Labels takes an even number of strings representing key-value pairs
+// and makes a Set containing them.
+// A label overwrites a prior label with the same key.
+func Labels(args ...string) Set {
+	if len(args)%2 != 0 {
+		panic("uneven number of arguments to proflabel.Labels")
+	}
+	m := make(map[string]string, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		m[args[i]] = args[i+1]
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := make(Set, 0, 2*len(keys))
+	for _, k := range keys {
+		s = append(s, k, m[k])
+	}
+	return s
+}
+
+// Lookup returns the value of the label with the given key, and a boolean
+// indicating whether that label exists.
+func (s Set) Lookup(key string) (string, bool) {
+	i := sort.Search(len(s)/2, func(i int) bool { return s[2*i] >= key })
+	if 2*i < len(s) && s[2*i] == key {
+		return s[2*i+1], true
+	}
+	return "", false
+}
+
+// With returns a new Set with the labels of t added to those of s.
+// A label of t overwrites a label of s with the same key.
+func (s Set) With(t Set) Set {
+	merged := make(Set, 0, len(s)+len(t))
+	i := 0
+	for j := 0; j+1 < len(t); j += 2 {
+		for ; i < len(s) && s[i] < t[j]; i += 2 {
+			merged = append(merged, s[i], s[i+1])
+		}
+		if i < len(s) && s[i] == t[j] {
+			i += 2
+		}
+		merged = append(merged, t[j], t[j+1])
+	}
+	return append(merged, s[i:]...)
+}
+
+// Goroutine returns the labels of the current goroutine.
+func Goroutine() Set {
+	p := runtime_getProfLabel()
+	if p == nil {
+		return nil
+	}
+	return *(*Set)(p)
+}
+
+// SetGoroutine sets the labels of the current goroutine to s.
+// The caller must not modify s afterwards.
+func SetGoroutine(s Set) {
+	if len(s) == 0 {
+		runtime_setProfLabel(nil)
+		return
+	}
+	runtime_setProfLabel(unsafe.Pointer(&s))
+}
+
+// Do calls f with the given labels added to those of the current
+// goroutine. Goroutines created while executing f inherit the augmented
+// label set. The previous labels are restored once f returns.
+func Do(labels Set, f func()) {
+	defer SetGoroutine(Goroutine())
+	SetGoroutine(Goroutine().With(labels))
+	f()
+}
+
+// Implemented in runtime.
+func runtime_getProfLabel() unsafe.Pointer
+func runtime_setProfLabel(labels unsafe.Pointer)
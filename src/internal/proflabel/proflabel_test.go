@@ -0,0 +1,113 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proflabel_test
+
+import (
+	"bytes"
+	. "internal/proflabel"
+	"internal/trace"
+	"reflect"
+	rtrace "runtime/trace"
+	"strings"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	s := Labels("key", "value", "a", "b")
+	if v, ok := s.Lookup("key"); !ok || v != "value" {
+		t.Errorf(`Lookup("key") = %q, %v, want "value", true`, v, ok)
+	}
+	if v, ok := s.Lookup("missing"); ok {
+		t.Errorf(`Lookup("missing") = %q, true, want "", false`, v)
+	}
+
+	// New labels are merged in key order and override existing ones.
+	s2 := s.With(Labels("key", "new", "z", "last", "z", "later"))
+	want := Set{"a", "b", "key", "new", "z", "later"}
+	if !reflect.DeepEqual(s2, want) {
+		t.Errorf("labels = %q, want %q", s2, want)
+	}
+	// The original set is unchanged.
+	want = Set{"a", "b", "key", "value"}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("original labels = %q, want %q", s, want)
+	}
+}
+
+func TestDo(t *testing.T) {
+	if s := Goroutine(); s != nil {
+		t.Fatalf("goroutine has labels %q before Do", s)
+	}
+	Do(Labels("a", "1"), func() {
+		Do(Labels("b", "2"), func() {
+			want := Set{"a", "1", "b", "2"}
+			if s := Goroutine(); !reflect.DeepEqual(s, want) {
+				t.Errorf("labels in nested Do = %q, want %q", s, want)
+			}
+			c := make(chan Set)
+			go func() {
+				c <- Goroutine()
+			}()
+			if s := <-c; !reflect.DeepEqual(s, want) {
+				t.Errorf("labels of new goroutine = %q, want %q", s, want)
+			}
+		})
+		want := Set{"a", "1"}
+		if s := Goroutine(); !reflect.DeepEqual(s, want) {
+			t.Errorf("labels after nested Do = %q, want %q", s, want)
+		}
+	})
+	if s := Goroutine(); s != nil {
+		t.Errorf("goroutine has labels %q after Do", s)
+	}
+}
+
+// TestDoTrace checks the goroutine labels set by Do as recorded
+// in an execution trace.
+func TestDoTrace(t *testing.T) {
+	var buf bytes.Buffer
+	if err := rtrace.Start(&buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	c := make(chan bool)
+	Do(Labels("handler", "api"), func() {
+		go func() {
+			c <- true
+		}()
+		<-c
+	})
+	go func() {
+		c <- true
+	}()
+	<-c
+	rtrace.Stop()
+
+	events, err := trace.Parse(&buf, "")
+	if err == trace.ErrTimeOrder {
+		t.Skipf("skipping trace: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	gs := trace.GoroutineStats(events)
+	var labeled, unlabeled int
+	for _, g := range gs {
+		if !strings.HasPrefix(g.Name, "internal/proflabel_test.TestDoTrace.") {
+			continue
+		}
+		switch g.Labels["handler"] {
+		case "api":
+			labeled++
+		case "":
+			unlabeled++
+		}
+	}
+	if labeled != 1 {
+		t.Errorf("%d goroutines have the handler label, want 1", labeled)
+	}
+	if unlabeled != 1 {
+		t.Errorf("%d goroutines have no handler label, want 1", unlabeled)
+	}
+}
@@ -2,8 +2,6 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build !amd64,!386,!s390x
+package sha256block
 
-package sha256
-
-var block = blockGeneric
+var UseAsm = &useAsm
@@ -0,0 +1,28 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build s390x
+
+package sha256block_test
+
+import (
+	. "internal/sha256block"
+	"testing"
+)
+
+// Tests the fallback code path in case the optimized asm
+// implementation cannot be used.
+// See also TestBlockGeneric.
+func TestGenericPath(t *testing.T) {
+	if *UseAsm == false {
+		t.Skipf("assembly implementation unavailable")
+	}
+	*UseAsm = false
+	defer func() { *UseAsm = true }()
+	dig := initial
+	Block(&dig, abcBlock())
+	if dig != abcDigest {
+		t.Fatalf("mismatch: got %08x, wanted %08x", dig, abcDigest)
+	}
+}
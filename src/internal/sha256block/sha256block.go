@@ -2,11 +2,12 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// SHA256 block step.
-// In its own file so that a faster assembly or C version
-// can be substituted easily.
+// Package sha256block implements the SHA-256 block step, for
+// crypto/sha256 and for packages such as reflect that cannot import it.
+package sha256block
 
-package sha256
+// chunk is the SHA-256 block size in bytes.
+const chunk = 64
 
 var _K = []uint32{
 	0x428a2f98,
@@ -75,9 +76,9 @@ var _K = []uint32{
 	0xc67178f2,
 }
 
-func blockGeneric(dig *digest, p []byte) {
+func blockGeneric(dig *[8]uint32, p []byte) {
 	var w [64]uint32
-	h0, h1, h2, h3, h4, h5, h6, h7 := dig.h[0], dig.h[1], dig.h[2], dig.h[3], dig.h[4], dig.h[5], dig.h[6], dig.h[7]
+	h0, h1, h2, h3, h4, h5, h6, h7 := dig[0], dig[1], dig[2], dig[3], dig[4], dig[5], dig[6], dig[7]
 	for len(p) >= chunk {
 		// Can interlace the computation of w with the
 		// rounds below if needed for speed.
@@ -122,5 +123,5 @@ func blockGeneric(dig *digest, p []byte) {
 		p = p[chunk:]
 	}
 
-	dig.h[0], dig.h[1], dig.h[2], dig.h[3], dig.h[4], dig.h[5], dig.h[6], dig.h[7] = h0, h1, h2, h3, h4, h5, h6, h7
+	dig[0], dig[1], dig[2], dig[3], dig[4], dig[5], dig[6], dig[7] = h0, h1, h2, h3, h4, h5, h6, h7
 }
@@ -558,7 +558,7 @@
 	;                                  \
 	ADDL  y3, h                        // h = t1 + S0 + MAJ					// --
 
-TEXT ·block(SB), 0, $536-32
+TEXT ·Block(SB), 0, $536-32
 	CMPB runtime·support_avx2(SB), $1
 	JE   avx2
 
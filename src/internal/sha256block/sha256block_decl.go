@@ -4,8 +4,8 @@
 
 // +build 386 amd64 s390x
 
-package sha256
+package sha256block
 
+// Block updates the hash state dig with the whole 64-byte blocks of p.
 //go:noescape
-
-func block(dig *digest, p []byte)
+func Block(dig *[8]uint32, p []byte)
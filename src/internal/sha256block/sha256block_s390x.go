@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package sha256
+package sha256block
 
 // featureCheck reports whether the CPU supports the
 // SHA256 compute intermediate message digest (KIMD)
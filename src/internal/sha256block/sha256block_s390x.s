@@ -18,8 +18,8 @@ nosha256:
 	MOVB	$0, ret+0(FP)
 	RET
 
-// func block(dig *digest, p []byte)
-TEXT ·block(SB),NOSPLIT,$0-32
+// func Block(dig *[8]uint32, p []byte)
+TEXT ·Block(SB),NOSPLIT,$0-32
 	MOVBZ	·useAsm(SB), R4
 	LMG	dig+0(FP), R1, R3 // R2 = &p[0], R3 = len(p)
 	CMPBNE	R4, $1, generic
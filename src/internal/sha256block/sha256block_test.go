@@ -0,0 +1,52 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sha256block_test
+
+import (
+	. "internal/sha256block"
+	"math/rand"
+	"testing"
+)
+
+// initial is the SHA-256 hash state before any block.
+var initial = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// abcDigest is the SHA-256 hash state after abcBlock.
+var abcDigest = [8]uint32{
+	0xba7816bf, 0x8f01cfea, 0x414140de, 0x5dae2223,
+	0xb00361a3, 0x96177a9c, 0xb410ff61, 0xf20015ad,
+}
+
+// abcBlock returns the message "abc" padded to a single block.
+func abcBlock() []byte {
+	p := make([]byte, 64)
+	copy(p, "abc")
+	p[3] = 0x80
+	p[63] = 3 * 8
+	return p
+}
+
+func TestBlock(t *testing.T) {
+	dig := initial
+	Block(&dig, abcBlock())
+	if dig != abcDigest {
+		t.Errorf("Block: got %08x, want %08x", dig, abcDigest)
+	}
+}
+
+// Tests that BlockGeneric (pure Go) and Block (in assembly for some architectures) match.
+func TestBlockGeneric(t *testing.T) {
+	gen, asm := initial, initial
+	buf := make([]byte, 64*20) // arbitrary factor
+	rand.Read(buf)
+	BlockGeneric(&gen, buf)
+	Block(&asm, buf)
+	if gen != asm {
+		t.Error("Block and BlockGeneric resulted in different states")
+	}
+}
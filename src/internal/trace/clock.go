@@ -0,0 +1,65 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import "time"
+
+// WallClock converts between trace timestamps and the wall and monotonic
+// clocks of the traced program, using the EvClockSync events of a trace.
+// Each conversion uses the offset between the clocks at the closest
+// preceding clock sync event, so that steps of the wall clock, such as
+// NTP adjustments or resuming from suspend, take effect at the next
+// clock sync event instead of skewing the times before it.
+type WallClock struct {
+	syncs []*Event // EvClockSync events in time order
+}
+
+// NewWallClock returns a WallClock for the events of a trace,
+// or nil if the trace has no EvClockSync events.
+func NewWallClock(events []*Event) *WallClock {
+	var syncs []*Event
+	for _, ev := range events {
+		if ev.Type == EvClockSync {
+			syncs = append(syncs, ev)
+		}
+	}
+	if len(syncs) == 0 {
+		return nil
+	}
+	return &WallClock{syncs}
+}
+
+// sync returns the last clock sync event for which before returns true,
+// or the first event if there is none.
+func (c *WallClock) sync(before func(ev *Event) bool) *Event {
+	s := c.syncs[0]
+	for _, ev := range c.syncs[1:] {
+		if !before(ev) {
+			break
+		}
+		s = ev
+	}
+	return s
+}
+
+// Wall returns the wall clock time of the trace timestamp ts.
+func (c *WallClock) Wall(ts int64) time.Time {
+	s := c.sync(func(ev *Event) bool { return ev.Ts <= ts })
+	return time.Unix(0, int64(s.Args[0])+ts-s.Ts)
+}
+
+// Ts returns the trace timestamp of the wall clock time t.
+func (c *WallClock) Ts(t time.Time) int64 {
+	wall := t.UnixNano()
+	s := c.sync(func(ev *Event) bool { return int64(ev.Args[0]) <= wall })
+	return s.Ts + wall - int64(s.Args[0])
+}
+
+// MonoTs returns the trace timestamp of the monotonic clock reading mono,
+// as returned by runtime.Now.
+func (c *WallClock) MonoTs(mono int64) int64 {
+	s := c.sync(func(ev *Event) bool { return int64(ev.Args[1]) <= mono })
+	return s.Ts + mono - int64(s.Args[1])
+}
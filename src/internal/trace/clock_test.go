@@ -0,0 +1,44 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWallClock(t *testing.T) {
+	if NewWallClock([]*Event{{Type: EvGoStart}}) != nil {
+		t.Fatalf("NewWallClock of a trace without clock sync events is not nil")
+	}
+
+	// The wall clock steps forward by 10s between the two syncs.
+	const s = int64(time.Second)
+	events := []*Event{
+		{Type: EvClockSync, Ts: 1 * s, Args: [5]uint64{uint64(1000 * s), uint64(5 * s)}},
+		{Type: EvGoStart, Ts: s + s/2},
+		{Type: EvClockSync, Ts: 2 * s, Args: [5]uint64{uint64(1011 * s), uint64(6 * s)}},
+	}
+	c := NewWallClock(events)
+	for _, tt := range []struct {
+		ts, wall int64
+	}{
+		{0, 999 * s}, // before the first sync
+		{1 * s, 1000 * s},
+		{s + s/2, 1000*s + s/2},
+		{2 * s, 1011 * s},
+		{3 * s, 1012 * s},
+	} {
+		if got := c.Wall(tt.ts).UnixNano(); got != tt.wall {
+			t.Errorf("Wall(%d) = %d, want %d", tt.ts, got, tt.wall)
+		}
+		if got := c.Ts(time.Unix(0, tt.wall)); got != tt.ts {
+			t.Errorf("Ts(%d) = %d, want %d", tt.wall, got, tt.ts)
+		}
+	}
+	if got, want := c.MonoTs(5*s+s/2), s+s/2; got != want {
+		t.Errorf("MonoTs(%d) = %d, want %d", 5*s+s/2, got, want)
+	}
+}
@@ -22,6 +22,10 @@ type GDesc struct {
 	SweepTime     int64
 	TotalTime     int64
 
+	// Labels holds the last value of each profiler label the goroutine
+	// had during the trace, by key.
+	Labels map[string]string
+
 	*gdesc // private part
 }
 
@@ -34,6 +38,24 @@ type gdesc struct {
 	blockSweepTime   int64
 	blockGCTime      int64
 	blockSchedTime   int64
+	labels           map[string]string // current profiler labels
+}
+
+// setLabel records that the goroutine's profiler label key has the value.
+// An empty value means the goroutine no longer has the label.
+func (g *GDesc) setLabel(key, value string) {
+	if value == "" {
+		delete(g.labels, key)
+		return
+	}
+	if g.labels == nil {
+		g.labels = make(map[string]string)
+	}
+	g.labels[key] = value
+	if g.Labels == nil {
+		g.Labels = make(map[string]string)
+	}
+	g.Labels[key] = value
 }
 
 // GoroutineStats generates statistics for all goroutines in the trace.
@@ -47,7 +69,17 @@ func GoroutineStats(events []*Event) map[uint64]*GDesc {
 		case EvGoCreate:
 			g := &GDesc{ID: ev.Args[0], CreationTime: ev.Ts, gdesc: new(gdesc)}
 			g.blockSchedTime = ev.Ts
+			// The new goroutine inherits the labels of its creator.
+			if creator := gs[ev.G]; creator != nil {
+				for k, v := range creator.labels {
+					g.setLabel(k, v)
+				}
+			}
 			gs[g.ID] = g
+		case EvGoLabel:
+			if g := gs[ev.Args[0]]; g != nil {
+				g.setLabel(ev.SArgs[0], ev.SArgs[1])
+			}
 		case EvGoStart:
 			g := gs[ev.G]
 			if g.PC == 0 {
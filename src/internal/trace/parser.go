@@ -27,7 +27,8 @@ type Event struct {
 	G     uint64    // G on which the event happened
 	StkID uint64    // unique stack ID
 	Stk   []*Frame  // stack trace (can be empty)
-	Args  [3]uint64 // event-type-specific arguments
+	Args  [5]uint64 // event-type-specific arguments; only EvGCPacer, since 1.8, uses more than 3
+	SArgs []string  // event-type-specific string arguments
 	// linked event (can be nil), depends on event type:
 	// for GCStart: the GCStop
 	// for GCScanStart: the GCScanDone
@@ -112,7 +113,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		return
 	}
 	switch ver {
-	case 1005, 1007:
+	case 1005, 1007, 1008:
 		break
 	default:
 		err = fmt.Errorf("unsupported trace file version %v.%v (update Go toolchain) %v", ver/1000, ver%1000, ver)
@@ -352,7 +353,7 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 			case EvGoStart, EvGoStartLocal:
 				lastG = e.Args[0]
 				e.G = lastG
-			case EvGCStart, EvGCDone, EvGCScanStart, EvGCScanDone:
+			case EvGCStart, EvGCDone, EvGCScanStart, EvGCScanDone, EvClockSync, EvGCPacer:
 				e.G = 0
 			case EvGoEnd, EvGoStop, EvGoSched, EvGoPreempt,
 				EvGoSleep, EvGoBlock, EvGoBlockSend, EvGoBlockRecv,
@@ -361,6 +362,8 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 				lastG = 0
 			case EvGoSysExit, EvGoWaiting, EvGoInSyscall:
 				e.G = e.Args[0]
+			case EvGoLabel:
+				e.SArgs = []string{strings[e.Args[1]], strings[e.Args[2]]}
 			}
 			batches[lastP] = append(batches[lastP], e)
 		}
@@ -872,7 +875,18 @@ const (
 	EvGoStartLocal   = 38 // goroutine starts running on the same P as the last event [timestamp, goroutine id]
 	EvGoUnblockLocal = 39 // goroutine is unblocked on the same P as the last event [timestamp, goroutine id, stack]
 	EvGoSysExitLocal = 40 // syscall exit on the same P as the last event [timestamp, goroutine id, real timestamp]
-	EvCount          = 41
+	EvGoLabel        = 41 // goroutine profiler label changes [timestamp, goroutine id, key string ID, value string ID]
+	EvClockSync      = 42 // wall and monotonic clock readings [timestamp, wall time, monotonic time]
+	EvGCPacer        = 43 // GC pacer state [timestamp, phase, heap trigger, heap goal, heap live, assist ratio bits]
+	EvCount          = 44
+)
+
+// Phases of EvGCPacer events. The assist ratio, in scan work per
+// allocated byte, is encoded as the bits of a float64.
+const (
+	GCPacerStart  = 0 // a concurrent GC cycle starts
+	GCPacerRevise = 1 // the assist ratio changed during the cycle
+	GCPacerEnd    = 2 // the concurrent mark phase ends
 )
 
 var EventDescriptions = [EvCount]struct {
@@ -922,4 +936,7 @@ var EventDescriptions = [EvCount]struct {
 	EvGoStartLocal:   {"GoStartLocal", 1007, false, []string{"g"}},
 	EvGoUnblockLocal: {"GoUnblockLocal", 1007, true, []string{"g"}},
 	EvGoSysExitLocal: {"GoSysExitLocal", 1007, false, []string{"g", "ts"}},
+	EvGoLabel:        {"GoLabel", 1008, false, []string{"g", "key", "value"}},
+	EvClockSync:      {"ClockSync", 1008, false, []string{"wall", "mono"}},
+	EvGCPacer:        {"GCPacer", 1008, false, []string{"phase", "trigger", "goal", "live", "ratio"}},
 }
@@ -62,6 +62,7 @@ func TestParseVersion(t *testing.T) {
 	tests := map[string]int{
 		"go 1.5 trace\x00\x00\x00\x00": 1005,
 		"go 1.7 trace\x00\x00\x00\x00": 1007,
+		"go 1.8 trace\x00\x00\x00\x00": 1008,
 		"go 1.10 trace\x00\x00\x00":    1010,
 		"go 1.25 trace\x00\x00\x00":    1025,
 		"go 1.234 trace\x00\x00":       1234,
@@ -86,6 +87,23 @@ func TestParseVersion(t *testing.T) {
 	}
 }
 
+func TestParseEventVersion(t *testing.T) {
+	// EvGoLabel, EvClockSync and EvGCPacer are new in 1.8.
+	events := "\x02\x80\x94\xeb\xdc\x03" + // EvFrequency
+		"\x41\x00\xac\x02" + // EvBatch
+		"\xe9\x04\x05\x01\x01\x00" // EvGoLabel
+	if _, err := ReadRaw(strings.NewReader("go 1.8 trace\x00\x00\x00\x00" + events)); err != nil {
+		t.Errorf("failed to read 1.8 trace: %v", err)
+	}
+	if _, err := ReadRaw(strings.NewReader("go 1.7 trace\x00\x00\x00\x00" + events)); err == nil {
+		t.Errorf("read 1.7 trace with EvGoLabel")
+	}
+	raw := &RawTrace{Ver: 1007, Events: []RawEvent{{Type: EvGCPacer, Args: []uint64{5, GCPacerStart, 1, 2, 3, 4}}}}
+	if err := WriteRaw(ioutil.Discard, raw); err == nil {
+		t.Errorf("wrote 1.7 trace with EvGCPacer")
+	}
+}
+
 func TestTimestampOverflow(t *testing.T) {
 	// Test that parser correctly handles large timestamps (long tracing).
 	w := newWriter()
@@ -0,0 +1,118 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RawTrace is a trace in its wire format, for tools that rewrite traces.
+// Unlike the events returned by Parse, raw events keep their arguments
+// as encoded, such as timestamp differences and string IDs, so writing
+// a RawTrace back with WriteRaw reproduces the same events exactly.
+type RawTrace struct {
+	Ver     int               // format version, such as 1008 for Go 1.8
+	Events  []RawEvent        // events other than EvString, in file order
+	Strings map[uint64]string // string dictionary
+}
+
+// RawEvent is an event in wire format.
+type RawEvent struct {
+	Off  int      // offset in input file
+	Type byte     // one of Ev*
+	Args []uint64 // all arguments, including timestamps, sequence numbers and stack IDs
+}
+
+// ReadRaw reads a trace in wire format. It checks the encoding of the
+// events but, unlike Parse, not their meaning.
+func ReadRaw(r io.Reader) (*RawTrace, error) {
+	ver, raws, strings, err := readTrace(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	t := &RawTrace{Ver: ver, Events: make([]RawEvent, len(raws)), Strings: strings}
+	for i, raw := range raws {
+		t.Events[i] = RawEvent{Off: raw.off, Type: raw.typ, Args: raw.args}
+	}
+	return t, nil
+}
+
+// WriteRaw writes t in wire format. The string dictionary is written
+// first, ordered by ID, followed by the events.
+func WriteRaw(w io.Writer, t *RawTrace) error {
+	header := []byte(fmt.Sprintf("go %d.%d trace", t.Ver/1000, t.Ver%1000))
+	if len(header) > 16 {
+		return fmt.Errorf("bad trace version %v", t.Ver)
+	}
+	header = append(header, make([]byte, 16-len(header))...)
+	if _, err := parseHeader(header); err != nil {
+		return fmt.Errorf("bad trace version %v", t.Ver)
+	}
+
+	// See readTrace for the encoding. The argument count in the top
+	// two bits of the event type does not include the timestamp, nor
+	// before 1.7 the sequence number.
+	minArgs, inlineArgs := 1, 4
+	if t.Ver < 1007 {
+		minArgs, inlineArgs = 2, 5
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(header)
+	var ids []uint64
+	for id := range t.Strings {
+		ids = append(ids, id)
+	}
+	sort.Sort(uint64Slice(ids))
+	var buf []byte
+	for _, id := range ids {
+		s := t.Strings[id]
+		if id == 0 || s == "" {
+			return fmt.Errorf("bad string %v %q", id, s)
+		}
+		buf = append(buf[:0], EvString)
+		buf = appendVal(buf, id)
+		buf = appendVal(buf, uint64(len(s)))
+		buf = append(buf, s...)
+		bw.Write(buf)
+	}
+	var args []byte
+	for _, ev := range t.Events {
+		if ev.Type == EvNone || ev.Type == EvString || ev.Type >= EvCount ||
+			EventDescriptions[ev.Type].minVersion > t.Ver || len(ev.Args) < minArgs {
+			return fmt.Errorf("bad event %v with %v arguments", ev.Type, len(ev.Args))
+		}
+		args = args[:0]
+		for _, a := range ev.Args {
+			args = appendVal(args, a)
+		}
+		if len(ev.Args) < inlineArgs {
+			buf = append(buf[:0], ev.Type|byte(len(ev.Args)-minArgs)<<6)
+		} else {
+			buf = append(buf[:0], ev.Type|3<<6)
+			buf = appendVal(buf, uint64(len(args)))
+		}
+		buf = append(buf, args...)
+		bw.Write(buf)
+	}
+	return bw.Flush()
+}
+
+// appendVal appends v to buf in the unsigned base-128 encoding read by readVal.
+func appendVal(buf []byte, v uint64) []byte {
+	for ; v >= 0x80; v >>= 7 {
+		buf = append(buf, 0x80|byte(v))
+	}
+	return append(buf, byte(v))
+}
+
+type uint64Slice []uint64
+
+func (s uint64Slice) Len() int           { return len(s) }
+func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
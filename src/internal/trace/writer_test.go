@@ -0,0 +1,104 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRaw(t *testing.T) {
+	files, err := ioutil.ReadDir("./testdata")
+	if err != nil {
+		t.Fatalf("failed to read ./testdata: %v", err)
+	}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join("./testdata", f.Name()))
+		if err != nil {
+			t.Fatalf("failed to read input file: %v", err)
+		}
+		raw, err := ReadRaw(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%v: failed to read raw trace: %v", f.Name(), err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := WriteRaw(&buf, raw); err != nil {
+			t.Errorf("%v: failed to write raw trace: %v", f.Name(), err)
+			continue
+		}
+		raw1, err := ReadRaw(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Errorf("%v: failed to read written trace: %v", f.Name(), err)
+			continue
+		}
+		if raw1.Ver != raw.Ver || !reflect.DeepEqual(raw1.Strings, raw.Strings) || len(raw1.Events) != len(raw.Events) {
+			t.Errorf("%v: written trace differs: version %v, %v strings, %v events; want %v, %v, %v",
+				f.Name(), raw1.Ver, len(raw1.Strings), len(raw1.Events), raw.Ver, len(raw.Strings), len(raw.Events))
+			continue
+		}
+		for i, ev := range raw.Events {
+			ev1 := raw1.Events[i]
+			if ev1.Type != ev.Type || !reflect.DeepEqual(ev1.Args, ev.Args) {
+				t.Errorf("%v: event %v is %v %v, want %v %v", f.Name(), i, ev1.Type, ev1.Args, ev.Type, ev.Args)
+				break
+			}
+		}
+
+		// The written trace parses to the same events.
+		events, err := Parse(bytes.NewReader(data), "")
+		if err != nil {
+			continue
+		}
+		events1, err := Parse(bytes.NewReader(buf.Bytes()), "")
+		if err != nil {
+			t.Errorf("%v: failed to parse written trace: %v", f.Name(), err)
+			continue
+		}
+		if len(events1) != len(events) {
+			t.Errorf("%v: written trace has %v events, want %v", f.Name(), len(events1), len(events))
+			continue
+		}
+		for i, ev := range events {
+			ev1 := events1[i]
+			if ev1.Type != ev.Type || ev1.Ts != ev.Ts || ev1.P != ev.P || ev1.G != ev.G || ev1.Args != ev.Args {
+				t.Errorf("%v: event %v differs", f.Name(), i)
+				break
+			}
+		}
+	}
+}
+
+func TestWriteRawEncoding(t *testing.T) {
+	raw := &RawTrace{
+		Ver:     1008,
+		Strings: map[uint64]string{1: "main.main"},
+		Events: []RawEvent{
+			{Type: EvFrequency, Args: []uint64{1e9}},
+			{Type: EvBatch, Args: []uint64{0, 300}},
+			{Type: EvGoLabel, Args: []uint64{5, 1, 1, 0}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteRaw(&buf, raw); err != nil {
+		t.Fatal(err)
+	}
+	want := "go 1.8 trace\x00\x00\x00\x00" +
+		"\x25\x01\x09main.main" + // EvString
+		"\x02\x80\x94\xeb\xdc\x03" + // EvFrequency, 1 argument
+		"\x41\x00\xac\x02" + // EvBatch, 2 arguments
+		"\xe9\x04\x05\x01\x01\x00" // EvGoLabel, 4 arguments after their length
+	if got := buf.String(); got != want {
+		t.Errorf("WriteRaw wrote %q, want %q", got, want)
+	}
+
+	raw.Events = append(raw.Events, RawEvent{Type: EvString, Args: []uint64{1}})
+	if err := WriteRaw(&buf, raw); err == nil {
+		t.Errorf("WriteRaw of an EvString event succeeded")
+	}
+}
@@ -6,19 +6,24 @@ package reflect_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"flag"
 	"fmt"
+	"internal/testenv"
 	"io"
 	"math"
+	mathbig "math/big"
 	"math/rand"
 	"os"
+	"os/exec"
 	. "reflect"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 	"unicode"
@@ -2694,6 +2699,474 @@ func TestSetLenCap(t *testing.T) {
 	shouldPanic(func() { va.SetCap(8) })
 }
 
+func TestBoundsErrorMessages(t *testing.T) {
+	xs := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	xa := [4]int{10, 20, 30, 40}
+	str := "hello"
+	vs := ValueOf(&xs).Elem().Slice3(0, 3, 4)
+	va := ValueOf(&xa).Elem()
+	vstr := ValueOf(str)
+	ys := xs[0:3:4]
+	vy := ValueOf(&ys).Elem()
+
+	tests := []struct {
+		f    func()
+		want string
+	}{
+		{func() { vs.Index(3) }, "reflect: slice index out of range [3] with length 3"},
+		{func() { vs.Index(-1) }, "reflect: slice index out of range [-1]"},
+		{func() { va.Index(4) }, "reflect: array index out of range [4] with length 4"},
+		{func() { vstr.Index(5) }, "reflect: string index out of range [5] with length 5"},
+
+		{func() { vs.Slice(0, 5) }, "reflect.Value.Slice: slice bounds out of range [:5] with capacity 4"},
+		{func() { vs.Slice(3, 2) }, "reflect.Value.Slice: slice bounds out of range [3:2]"},
+		{func() { vs.Slice(-1, 2) }, "reflect.Value.Slice: slice bounds out of range [-1:]"},
+		{func() { vs.Slice(0, -1) }, "reflect.Value.Slice: slice bounds out of range [:-1]"},
+		{func() { va.Slice(1, 5) }, "reflect.Value.Slice: array slice bounds out of range [:5] with capacity 4"},
+		{func() { vstr.Slice(2, 6) }, "reflect.Value.Slice: string slice bounds out of range [:6] with length 5"},
+		{func() { vstr.Slice(3, 1) }, "reflect.Value.Slice: string slice bounds out of range [3:1]"},
+
+		{func() { vs.Slice3(0, 1, 5) }, "reflect.Value.Slice3: slice bounds out of range [::5] with capacity 4"},
+		{func() { vs.Slice3(0, 3, 2) }, "reflect.Value.Slice3: slice bounds out of range [:3:2]"},
+		{func() { vs.Slice3(2, 1, 3) }, "reflect.Value.Slice3: slice bounds out of range [2:1:]"},
+		{func() { vs.Slice3(-1, 1, 3) }, "reflect.Value.Slice3: slice bounds out of range [-1::]"},
+		{func() { vs.Slice3(0, -1, 3) }, "reflect.Value.Slice3: slice bounds out of range [:-1:]"},
+		{func() { va.Slice3(0, 1, 6) }, "reflect.Value.Slice3: array slice bounds out of range [::6] with capacity 4"},
+
+		{func() { vy.SetLen(5) }, "reflect.Value.SetLen: slice length out of range [5] with capacity 4"},
+		{func() { vy.SetLen(-1) }, "reflect.Value.SetLen: slice length out of range [-1]"},
+		{func() { vy.SetCap(2) }, "reflect.Value.SetCap: slice capacity out of range [2] with length 3"},
+		{func() { vy.SetCap(9) }, "reflect.Value.SetCap: slice capacity out of range [9] with capacity 4"},
+	}
+	for i, tt := range tests {
+		got := func() (msg interface{}) {
+			defer func() { msg = recover() }()
+			tt.f()
+			return nil
+		}()
+		if got != tt.want {
+			t.Errorf("#%d: panic = %v; want %q", i, got, tt.want)
+		}
+	}
+
+	// In-range operations are unaffected.
+	if got := vs.Slice(1, 4).Len(); got != 3 {
+		t.Errorf("vs.Slice(1, 4).Len() = %d; want 3", got)
+	}
+	if got := va.Slice3(1, 2, 4).Cap(); got != 3 {
+		t.Errorf("va.Slice3(1, 2, 4).Cap() = %d; want 3", got)
+	}
+	if got := vstr.Slice(5, 5).Len(); got != 0 {
+		t.Errorf("vstr.Slice(5, 5).Len() = %d; want 0", got)
+	}
+}
+
+type tryStruct struct {
+	Exported   int
+	unexported string
+}
+
+func TestTryMethods(t *testing.T) {
+	n := 42
+	var nilPtr *int
+	var iface interface{} = "hi"
+	var nilIface interface{}
+	st := tryStruct{Exported: 1, unexported: "x"}
+	arr := [2]int{5, 6}
+	sl := []string{"a", "b", "c"}
+
+	// Failures must return the zero Value (or nil) and false,
+	// where the panicking counterpart panics.
+	for _, v := range []Value{{}, ValueOf(n), ValueOf(st), ValueOf(sl)} {
+		if x, ok := v.TryElem(); ok || x.IsValid() {
+			t.Errorf("%v.TryElem() = %v, %v; want invalid, false", v, x, ok)
+		}
+		shouldPanic(func() { v.Elem() })
+	}
+	fieldFails := []struct {
+		v Value
+		i int
+	}{
+		{Value{}, 0},
+		{ValueOf(n), 0},
+		{ValueOf(&st), 0},
+		{ValueOf(st), -1},
+		{ValueOf(st), 2},
+	}
+	for _, tt := range fieldFails {
+		if x, ok := tt.v.TryField(tt.i); ok || x.IsValid() {
+			t.Errorf("%v.TryField(%d) = %v, %v; want invalid, false", tt.v, tt.i, x, ok)
+		}
+		shouldPanic(func() { tt.v.Field(tt.i) })
+	}
+	indexFails := []struct {
+		v Value
+		i int
+	}{
+		{Value{}, 0},
+		{ValueOf(n), 0},
+		{ValueOf(st), 0},
+		{ValueOf(&arr), 0},
+		{ValueOf(arr), -1},
+		{ValueOf(arr), 2},
+		{ValueOf(sl), 3},
+		{ValueOf(sl[:0]), 0},
+		{ValueOf("abc"), 3},
+		{ValueOf(""), 0},
+	}
+	for _, tt := range indexFails {
+		if x, ok := tt.v.TryIndex(tt.i); ok || x.IsValid() {
+			t.Errorf("%v.TryIndex(%d) = %v, %v; want invalid, false", tt.v, tt.i, x, ok)
+		}
+		shouldPanic(func() { tt.v.Index(tt.i) })
+	}
+	for _, v := range []Value{{}, ValueOf(st).Field(1), ValueOf(&st).Elem().Field(1)} {
+		if x, ok := v.TryInterface(); ok || x != nil {
+			t.Errorf("TryInterface() = %v, %v; want nil, false", x, ok)
+		}
+		shouldPanic(func() { v.Interface() })
+	}
+
+	// Successes must match the panicking counterparts.
+	for _, v := range []Value{ValueOf(&n), ValueOf(nilPtr), ValueOf(&iface).Elem(), ValueOf(&nilIface).Elem()} {
+		x, ok := v.TryElem()
+		if want := v.Elem(); !ok || x != want {
+			t.Errorf("%v.TryElem() = %v, %v; want %v, true", v, x, ok, want)
+		}
+	}
+	for _, v := range []Value{ValueOf(st), ValueOf(&st).Elem()} {
+		for i := 0; i < v.NumField(); i++ {
+			x, ok := v.TryField(i)
+			if want := v.Field(i); !ok || x != want {
+				t.Errorf("%v.TryField(%d) = %v, %v; want %v, true", v, i, x, ok, want)
+			}
+		}
+	}
+	for _, v := range []Value{ValueOf(arr), ValueOf(&arr).Elem(), ValueOf(sl), ValueOf("abc")} {
+		for i := 0; i < v.Len(); i++ {
+			x, ok := v.TryIndex(i)
+			if want := v.Index(i); !ok || x != want {
+				t.Errorf("%v.TryIndex(%d) = %v, %v; want %v, true", v, i, x, ok, want)
+			}
+		}
+	}
+	for _, v := range []Value{ValueOf(n), ValueOf(st), ValueOf(&iface).Elem(), ValueOf(&nilIface).Elem(), ValueOf(st).Field(0)} {
+		x, ok := v.TryInterface()
+		if want := v.Interface(); !ok || !DeepEqual(x, want) {
+			t.Errorf("%v.TryInterface() = %v, %v; want %v, true", v, x, ok, want)
+		}
+	}
+}
+
+func TestForEach(t *testing.T) {
+	type T struct {
+		A int
+		b []int
+	}
+	arr := [3]string{"x", "y", "z"}
+	one := [1]*int{new(int)}
+	sl := []T{{1, nil}, {2, []int{3, 4}}}
+	st := T{b: []int{5, 6, 7}}
+	for _, v := range []Value{
+		ValueOf(arr),
+		ValueOf(&arr).Elem(),
+		ValueOf(one),
+		ValueOf(&one).Elem(),
+		ValueOf(sl),
+		ValueOf(sl[:0]),
+		ValueOf([]int(nil)),
+		ValueOf("héllo"),
+		ValueOf(st).Field(1),
+		ValueOf(&st).Elem().Field(1),
+	} {
+		n := 0
+		v.ForEach(func(i int, elem Value) bool {
+			if i != n {
+				t.Errorf("%v.ForEach: got index %d, want %d", v, i, n)
+			}
+			if want := v.Index(i); elem != want {
+				t.Errorf("%v.ForEach: elem %d = %#v, want %#v", v, i, elem, want)
+			}
+			if elem.CanAddr() != v.Index(i).CanAddr() || elem.CanSet() != v.Index(i).CanSet() {
+				t.Errorf("%v.ForEach: elem %d CanAddr/CanSet = %v/%v, want %v/%v", v, i,
+					elem.CanAddr(), elem.CanSet(), v.Index(i).CanAddr(), v.Index(i).CanSet())
+			}
+			n++
+			return true
+		})
+		if n != v.Len() {
+			t.Errorf("%v.ForEach visited %d elements, want %d", v, n, v.Len())
+		}
+	}
+
+	// Returning false stops the iteration.
+	var seen []int
+	ValueOf([]int{10, 20, 30, 40}).ForEach(func(i int, elem Value) bool {
+		seen = append(seen, int(elem.Int()))
+		return i < 1
+	})
+	if want := []int{10, 20}; !DeepEqual(seen, want) {
+		t.Errorf("early stop: saw %v, want %v", seen, want)
+	}
+
+	// Elements of a slice refer to its storage.
+	s := []int{1, 2, 3}
+	ValueOf(s).ForEach(func(i int, elem Value) bool {
+		elem.SetInt(elem.Int() * 10)
+		return true
+	})
+	if want := []int{10, 20, 30}; !DeepEqual(s, want) {
+		t.Errorf("after SetInt: got %v, want %v", s, want)
+	}
+
+	for _, v := range []Value{{}, ValueOf(1), ValueOf(&arr), ValueOf(map[int]int{})} {
+		shouldPanic(func() { v.ForEach(func(int, Value) bool { return true }) })
+	}
+}
+
+func TestForEachMap(t *testing.T) {
+	type K struct {
+		A, B string
+	}
+	type T struct {
+		m map[int]string
+	}
+	for _, v := range []Value{
+		ValueOf(map[int]string{1: "a", 2: "b", 3: "c"}),
+		ValueOf(map[K][2]int{{"a", "b"}: {1, 2}, {"c", "d"}: {3, 4}}),
+		ValueOf(map[string]*int{"x": new(int), "y": nil}),
+		ValueOf(map[int]int{}),
+		ValueOf(map[int]int(nil)),
+		ValueOf(T{map[int]string{4: "d", 5: "e"}}).Field(0),
+	} {
+		seen := make(map[interface{}]bool)
+		v.ForEachMap(func(key, val Value) bool {
+			k := valueToString(key)
+			if seen[k] {
+				t.Errorf("%v.ForEachMap: key %s visited twice", v, k)
+			}
+			seen[k] = true
+			want := v.MapIndex(key)
+			if !DeepEqual(valueToString(val), valueToString(want)) {
+				t.Errorf("%v.ForEachMap: val for %s = %s, want %s", v, k, valueToString(val), valueToString(want))
+			}
+			if val.CanInterface() != want.CanInterface() || key.CanSet() || val.CanSet() {
+				t.Errorf("%v.ForEachMap: wrong flags for key %s", v, k)
+			}
+			return true
+		})
+		if len(seen) != v.Len() {
+			t.Errorf("%v.ForEachMap visited %d entries, want %d", v, len(seen), v.Len())
+		}
+	}
+
+	// Returning false stops the iteration.
+	m := map[int]int{1: 1, 2: 2, 3: 3, 4: 4}
+	n := 0
+	ValueOf(m).ForEachMap(func(key, val Value) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Errorf("early stop: visited %d entries, want 2", n)
+	}
+
+	// Like a range statement, entries deleted during
+	// iteration are not visited.
+	n = 0
+	ValueOf(m).ForEachMap(func(key, val Value) bool {
+		for k := range m {
+			if k != int(key.Int()) {
+				delete(m, k)
+			}
+		}
+		n++
+		return true
+	})
+	if n != 1 || len(m) != 1 {
+		t.Errorf("delete during iteration: visited %d entries, %d left; want 1, 1", n, len(m))
+	}
+
+	// Keys and values are copies that may be retained.
+	am := map[[2]int][2]int{{1, 2}: {3, 4}}
+	var keys, vals []Value
+	ValueOf(am).ForEachMap(func(key, val Value) bool {
+		keys = append(keys, key)
+		vals = append(vals, val)
+		return true
+	})
+	am[[2]int{1, 2}] = [2]int{5, 6}
+	if k := keys[0].Interface(); k != [2]int{1, 2} {
+		t.Errorf("retained key = %v, want [1 2]", k)
+	}
+	if v := vals[0].Interface(); v != [2]int{3, 4} {
+		t.Errorf("retained val = %v, want [3 4]", v)
+	}
+
+	for _, v := range []Value{{}, ValueOf(1), ValueOf([]int{})} {
+		shouldPanic(func() { v.ForEachMap(func(Value, Value) bool { return true }) })
+	}
+}
+
+func TestMapIter(t *testing.T) {
+	type T struct {
+		m map[int]string
+	}
+	for _, v := range []Value{
+		ValueOf(map[int]string{1: "a", 2: "b", 3: "c"}),
+		ValueOf(map[[2]int][2]int{{1, 2}: {3, 4}, {5, 6}: {7, 8}}),
+		ValueOf(map[string]*int{"x": new(int), "y": nil}),
+		ValueOf(map[int]int{}),
+		ValueOf(map[int]int(nil)),
+		ValueOf(T{map[int]string{4: "d", 5: "e"}}).Field(0),
+	} {
+		seen := make(map[interface{}]bool)
+		iter := v.MapRange()
+		for iter.Next() {
+			key, val := iter.Key(), iter.Value()
+			k := valueToString(key)
+			if seen[k] {
+				t.Errorf("%v.MapRange: key %s visited twice", v, k)
+			}
+			seen[k] = true
+			want := v.MapIndex(key)
+			if valueToString(val) != valueToString(want) {
+				t.Errorf("%v.MapRange: val for %s = %s, want %s", v, k, valueToString(val), valueToString(want))
+			}
+			if val.CanInterface() != want.CanInterface() || key.CanSet() || val.CanSet() {
+				t.Errorf("%v.MapRange: wrong flags for key %s", v, k)
+			}
+		}
+		if len(seen) != v.Len() {
+			t.Errorf("%v.MapRange visited %d entries, want %d", v, len(seen), v.Len())
+		}
+		shouldPanic(func() { iter.Next() })
+		shouldPanic(func() { iter.Key() })
+		shouldPanic(func() { iter.Value() })
+	}
+
+	iter := ValueOf(map[int]int{1: 1}).MapRange()
+	shouldPanic(func() { iter.Key() })
+	shouldPanic(func() { iter.Value() })
+
+	// Like a range statement, entries deleted during
+	// iteration are not visited.
+	m := map[int]int{1: 1, 2: 2, 3: 3, 4: 4}
+	n := 0
+	for iter := ValueOf(m).MapRange(); iter.Next(); n++ {
+		for k := range m {
+			if k != int(iter.Key().Int()) {
+				delete(m, k)
+			}
+		}
+	}
+	if n != 1 || len(m) != 1 {
+		t.Errorf("delete during iteration: visited %d entries, %d left; want 1, 1", n, len(m))
+	}
+
+	// Keys and values are copies that may be retained.
+	am := map[[2]int][2]int{{1, 2}: {3, 4}}
+	iter = ValueOf(am).MapRange()
+	iter.Next()
+	key, val := iter.Key(), iter.Value()
+	am[[2]int{1, 2}] = [2]int{5, 6}
+	if k := key.Interface(); k != [2]int{1, 2} {
+		t.Errorf("retained key = %v, want [1 2]", k)
+	}
+	if v := val.Interface(); v != [2]int{3, 4} {
+		t.Errorf("retained val = %v, want [3 4]", v)
+	}
+
+	for _, v := range []Value{{}, ValueOf(1), ValueOf([]int{})} {
+		shouldPanic(func() { v.MapRange() })
+	}
+}
+
+func TestClear(t *testing.T) {
+	type big [40]*int // stored indirectly in maps
+	m1 := map[int]*int{1: new(int), 2: new(int)}
+	m2 := map[big]big{{new(int)}: {new(int)}}
+	m3 := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		m3[strconv.Itoa(i)] = i
+	}
+	s1 := []*int{new(int), new(int), new(int)}
+	s2 := []big{{new(int)}, {new(int)}}
+
+	for _, v := range []Value{ValueOf(m1), ValueOf(m2), ValueOf(m3), ValueOf(map[int]int(nil))} {
+		v.Clear()
+		if v.Len() != 0 {
+			t.Errorf("%v after Clear has %d entries, want 0", v.Type(), v.Len())
+		}
+	}
+	// A cleared map can be refilled.
+	for i := 0; i < 1000; i++ {
+		m3[strconv.Itoa(i)] = -i
+	}
+	if len(m3) != 1000 || m3["999"] != -999 {
+		t.Errorf("refilled map has %d entries, m3[999] = %d", len(m3), m3["999"])
+	}
+	// Like deleted entries, cleared entries are not visited by
+	// an iteration in progress.
+	iter := ValueOf(m3).MapRange()
+	iter.Next()
+	ValueOf(m3).Clear()
+	if iter.Next() {
+		t.Errorf("iteration visited %v after Clear", iter.Key())
+	}
+
+	ValueOf(s1[:2]).Clear()
+	if s1[0] != nil || s1[1] != nil || s1[2] == nil {
+		t.Errorf("Clear of s1[:2] left %v", s1)
+	}
+	ValueOf(s2).Clear()
+	if s2[0] != (big{}) || s2[1] != (big{}) || len(s2) != 2 {
+		t.Errorf("Clear of s2 left %v", s2)
+	}
+
+	// Unexported fields can be read but not cleared.
+	type T struct {
+		m map[int]int
+		s []int
+	}
+	tv := ValueOf(T{map[int]int{1: 1}, []int{1}})
+	shouldPanic(func() { tv.Field(0).Clear() })
+	shouldPanic(func() { tv.Field(1).Clear() })
+	for _, v := range []Value{{}, ValueOf(1), ValueOf([1]int{}), ValueOf("x")} {
+		shouldPanic(func() { v.Clear() })
+	}
+}
+
+func TestClearReleasesPointers(t *testing.T) {
+	// The objects are large enough to stay out of the tiny
+	// allocator, whose blocks are freed together.
+	type obj [4]int
+	var freed int32
+	alloc := func() *obj {
+		p := new(obj)
+		runtime.SetFinalizer(p, func(*obj) { atomic.AddInt32(&freed, 1) })
+		return p
+	}
+	const n = 10
+	m := make(map[int]*[16]*obj)
+	s := make([]*obj, n)
+	for i := 0; i < n; i++ {
+		m[i] = &[16]*obj{alloc()}
+		s[i] = alloc()
+	}
+	ValueOf(m).Clear()
+	ValueOf(s).Clear()
+	for i := 0; i < 50 && atomic.LoadInt32(&freed) < 2*n; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&freed); got != 2*n {
+		t.Errorf("%d of %d objects freed after Clear", got, 2*n)
+	}
+	runtime.KeepAlive(m)
+	runtime.KeepAlive(s)
+}
+
 func TestVariadic(t *testing.T) {
 	var b bytes.Buffer
 	V := ValueOf
@@ -2772,6 +3245,55 @@ func TestBytes(t *testing.T) {
 	if &x[0] != &y[0] {
 		t.Errorf("ValueOf(%p).Bytes() = %p", &x[0], &y[0])
 	}
+
+	// Named element type.
+	type E uint8
+	e := []E{5, 6}
+	z := ValueOf(e).Bytes()
+	if len(z) != 2 || z[0] != 5 || z[1] != 6 {
+		t.Fatalf("ValueOf(%v).Bytes() = %v", e, z)
+	}
+	z[0] = 7
+	if e[0] != 7 {
+		t.Errorf("write through Bytes not visible in slice: %v", e)
+	}
+
+	shouldPanic(func() { ValueOf([]int{1}).Bytes() })
+	shouldPanic(func() { ValueOf("abc").Bytes() })
+}
+
+func TestBytesArray(t *testing.T) {
+	type E uint8
+	type A [4]E
+	var a A
+	v := ValueOf(&a).Elem()
+	b := v.Bytes()
+	if len(b) != 4 || cap(b) != 4 {
+		t.Fatalf("len, cap of Bytes() = %d, %d, want 4, 4", len(b), cap(b))
+	}
+	if unsafe.Pointer(&b[0]) != unsafe.Pointer(&a[0]) {
+		t.Fatalf("Bytes() = %p, want %p", &b[0], &a[0])
+	}
+	b[1] = 1
+	a[2] = 2
+	if a[1] != 1 || b[2] != 2 {
+		t.Errorf("Bytes() does not alias the array: array %v, slice %v", a, b)
+	}
+
+	// Elements of addressable arrays are addressable too.
+	var m [2][3]byte
+	m[1] = [3]byte{1, 2, 3}
+	if b := ValueOf(&m).Elem().Index(1).Bytes(); !bytes.Equal(b, []byte{1, 2, 3}) {
+		t.Errorf("Bytes() of array element = %v", b)
+	}
+
+	var z [0]byte
+	if b := ValueOf(&z).Elem().Bytes(); len(b) != 0 {
+		t.Errorf("Bytes() of empty array = %v", b)
+	}
+
+	shouldPanic(func() { ValueOf(a).Bytes() })
+	shouldPanic(func() { ValueOf(&[2]int{}).Elem().Bytes() })
 }
 
 func TestSetBytes(t *testing.T) {
@@ -2785,6 +3307,17 @@ func TestSetBytes(t *testing.T) {
 	if &x[0] != &y[0] {
 		t.Errorf("ValueOf(%p).Bytes() = %p", &x[0], &y[0])
 	}
+
+	// Named element type.
+	type E uint8
+	var e []E
+	ValueOf(&e).Elem().SetBytes(y)
+	if len(e) != 4 || unsafe.Pointer(&e[0]) != unsafe.Pointer(&y[0]) {
+		t.Errorf("SetBytes(%p) = %p", &y[0], &e[0])
+	}
+
+	shouldPanic(func() { ValueOf(x).SetBytes(y) })
+	shouldPanic(func() { ValueOf(&[4]byte{}).Elem().SetBytes(y) })
 }
 
 type Private struct {
@@ -2856,6 +3389,178 @@ func TestUnexported(t *testing.T) {
 	shouldPanic(func() { v.Type().Method(0) })
 }
 
+func TestAllowUnexportedAccess(t *testing.T) {
+	type inner struct {
+		n int
+		s []string
+	}
+	type outer struct {
+		x     int
+		p     *inner
+		m     map[string]inner
+		inner // embedded unexported
+	}
+	o := outer{x: 1, p: &inner{n: 2}, m: map[string]inner{"k": {n: 3}}}
+	o.s = []string{"a"}
+
+	// Normal Values stay read-only.
+	v := ValueOf(&o).Elem()
+	before := v.Field(0)
+	shouldPanic(func() { before.Interface() })
+	shouldPanic(func() { before.SetInt(10) })
+	shouldPanic(func() { AllowUnexportedAccess(ValueOf(o)) })
+	shouldPanic(func() { AllowUnexportedAccess(Value{}) })
+
+	u := AllowUnexportedAccess(v)
+	if x := u.Field(0).Interface().(int); x != 1 {
+		t.Errorf("o.x = %d, want 1", x)
+	}
+	u.Field(0).SetInt(10)
+	if o.x != 10 {
+		t.Errorf("after SetInt, o.x = %d, want 10", o.x)
+	}
+
+	// Derived Values inherit the permission.
+	u.Field(1).Elem().Field(0).Set(ValueOf(20))
+	if o.p.n != 20 {
+		t.Errorf("after Set, o.p.n = %d, want 20", o.p.n)
+	}
+	if n := u.FieldByName("n").Interface().(int); n != 0 {
+		t.Errorf("o.inner.n = %d, want 0", n)
+	}
+	u.FieldByName("s").Index(0).SetString("b")
+	if o.s[0] != "b" {
+		t.Errorf("after SetString, o.s[0] = %q, want %q", o.s[0], "b")
+	}
+	if n := u.Field(2).MapIndex(ValueOf("k")).Field(0).Interface().(int); n != 3 {
+		t.Errorf("o.m[k].n = %d, want 3", n)
+	}
+	if k := u.Field(2).MapKeys()[0].Interface().(string); k != "k" {
+		t.Errorf("o.m key = %q, want %q", k, "k")
+	}
+	if p := u.Field(0).Addr().Interface().(*int); p != &o.x {
+		t.Errorf("Addr of o.x = %p, want %p", p, &o.x)
+	}
+
+	// Values obtained before the call keep their flags.
+	shouldPanic(func() { before.Interface() })
+	shouldPanic(func() { v.Field(3).Field(0).Interface() })
+}
+
+type fingerprintList struct {
+	Value int
+	Next  *fingerprintList
+}
+
+type fingerprintTree struct {
+	Kids map[string]*fingerprintTree
+}
+
+type fingerprintCelsius int16
+
+var fingerprintGolden = []struct {
+	typ  Type
+	want string
+}{
+	{TypeOf(int32(0)), "365aec6369d743b7b680f04dd225c6e8488f49d61957162813ff21e467bd662e"},
+	{TypeOf([4]uint16{}), "b3a3da644df7265ef6bd8b80c5e3c25908f8bda6e1461eb77394e9f52cc98c9c"},
+	{TypeOf(struct {
+		A int32 `k:"v"`
+		B uint8
+	}{}), "99d3595787a369391b798671e5de0e11ca70921f04f3808de7050b387635f35c"},
+	{TypeOf(fingerprintCelsius(0)), "20de5d71c0b1e47b4904f618de70a168847471db2086a36dccfa97e79ce81e49"},
+}
+
+func TestTypeFingerprint(t *testing.T) {
+	// Fingerprints are the SHA-256 digest of the encoding.
+	for _, typ := range []Type{
+		TypeOf(0),
+		TypeOf(""),
+		TypeOf(fingerprintList{}),
+		TypeOf((func(int, ...string) (bool, error))(nil)),
+		TypeOf((*io.ReadWriter)(nil)).Elem(),
+	} {
+		if got, want := TypeFingerprint(typ), sha256.Sum256(TypeEncoding(typ)); got != want {
+			t.Errorf("TypeFingerprint(%v) = %x, want %x", typ, got, want)
+		}
+	}
+
+	// Fixed-size types have the same fingerprint on every
+	// architecture and in every program.
+	for _, tt := range fingerprintGolden {
+		if got := fmt.Sprintf("%x", TypeFingerprint(tt.typ)); got != tt.want {
+			t.Errorf("TypeFingerprint(%v) = %s, want %s", tt.typ, got, tt.want)
+		}
+	}
+
+	// Structurally identical unnamed types from different packages have
+	// the same fingerprint, although the package paths of their
+	// unexported fields differ.
+	anon := TypeOf(struct {
+		x int
+		Y string `json:"y"`
+	}{})
+	if TypeFingerprint(anon) != TypeFingerprint(FingerprintAnon) {
+		t.Errorf("fingerprints of %v from packages reflect and reflect_test differ", anon)
+	}
+
+	// Changing a tag, a name or the package of a named type
+	// changes the fingerprint.
+	for _, typ := range []Type{
+		TypeOf(struct {
+			x int
+			Y string `json:"z"`
+		}{}),
+		TypeOf(struct {
+			x int
+			Y string
+		}{}),
+		TypeOf(struct {
+			z int
+			Y string `json:"y"`
+		}{}),
+		TypeOf(struct {
+			x int32
+			Y string `json:"y"`
+		}{}),
+	} {
+		if TypeFingerprint(typ) == TypeFingerprint(anon) {
+			t.Errorf("%v has the same fingerprint as %v", typ, anon)
+		}
+	}
+	type Kind uint
+	kinds := []Type{TypeOf(uint(0)), TypeOf(Invalid), TypeOf(Kind(0))}
+	for i, k1 := range kinds {
+		for _, k2 := range kinds[i+1:] {
+			if TypeFingerprint(k1) == TypeFingerprint(k2) {
+				t.Errorf("%v and %v have the same fingerprint", k1, k2)
+			}
+		}
+	}
+
+	// Channel directions and variadic functions are distinguished.
+	for _, pair := range [][2]Type{
+		{TypeOf((chan int)(nil)), TypeOf((<-chan int)(nil))},
+		{TypeOf((chan<- int)(nil)), TypeOf((<-chan int)(nil))},
+		{TypeOf((func(...int))(nil)), TypeOf((func([]int))(nil))},
+		{TypeOf((func(int) bool)(nil)), TypeOf((func(int, bool))(nil))},
+		{TypeOf(map[string]int{}), TypeOf(map[int]string{})},
+	} {
+		if TypeFingerprint(pair[0]) == TypeFingerprint(pair[1]) {
+			t.Errorf("%v and %v have the same fingerprint", pair[0], pair[1])
+		}
+	}
+
+	// Recursive types are encoded with back references.
+	list := TypeOf(fingerprintList{})
+	if TypeFingerprint(list) == TypeFingerprint(TypeOf(fingerprintTree{})) {
+		t.Errorf("%v and %v have the same fingerprint", list, TypeOf(fingerprintTree{}))
+	}
+	if TypeFingerprint(list) != TypeFingerprint(list.Field(1).Type.Elem()) {
+		t.Errorf("fingerprint of %v changed", list)
+	}
+}
+
 func TestSetPanic(t *testing.T) {
 	ok := func(f func()) { f() }
 	bad := shouldPanic
@@ -3074,6 +3779,10 @@ type MyRunes []int32
 type MyFunc func()
 type MyByte byte
 
+type MyStruct struct {
+	x int `some:"bar"`
+}
+
 var convertTests = []struct {
 	in  Value
 	out Value
@@ -3421,6 +4130,90 @@ var convertTests = []struct {
 	{V(new(io.Reader)), V(new(io.Reader))},
 	{V(new(io.Writer)), V(new(io.Writer))},
 
+	// structs with different tags
+	{V(struct {
+		x int `some:"foo"`
+	}{1}), V(struct {
+		x int `some:"bar"`
+	}{1})},
+	{V(struct {
+		x int `some:"bar"`
+	}{1}), V(struct {
+		x int `some:"foo"`
+	}{1})},
+	{V(MyStruct{1}), V(struct {
+		x int `some:"foo"`
+	}{1})},
+	{V(struct {
+		x int `some:"foo"`
+	}{1}), V(MyStruct{1})},
+	{V(MyStruct{1}), V(struct {
+		x int `some:"bar"`
+	}{1})},
+	{V(struct {
+		x int `some:"bar"`
+	}{1}), V(MyStruct{1})},
+	{V(&struct {
+		p int `some:"foo"`
+	}{2}), V(&struct {
+		p int `some:"bar"`
+	}{2})},
+	{V(&struct {
+		p int `some:"bar"`
+	}{2}), V(&struct {
+		p int `some:"foo"`
+	}{2})},
+
+	// nested structs and embedded fields with different tags
+	{V(struct {
+		y struct {
+			z string `some:"foo"`
+		}
+	}{struct {
+		z string `some:"foo"`
+	}{"a"}}), V(struct {
+		y struct {
+			z string `some:"bar"`
+		}
+	}{struct {
+		z string `some:"bar"`
+	}{"a"}})},
+	{V(struct {
+		y struct {
+			z string `some:"bar"`
+		}
+	}{struct {
+		z string `some:"bar"`
+	}{"a"}}), V(struct {
+		y struct {
+			z string `some:"foo"`
+		}
+	}{struct {
+		z string `some:"foo"`
+	}{"a"}})},
+	{V(struct {
+		MyByte `some:"foo"`
+		w      []*struct {
+			v int `some:"foo"`
+		}
+	}{3, nil}), V(struct {
+		MyByte `some:"bar"`
+		w      []*struct {
+			v int `some:"bar"`
+		}
+	}{3, nil})},
+	{V(struct {
+		MyByte `some:"bar"`
+		w      []*struct {
+			v int `some:"bar"`
+		}
+	}{3, nil}), V(struct {
+		MyByte `some:"foo"`
+		w      []*struct {
+			v int `some:"foo"`
+		}
+	}{3, nil})},
+
 	// interfaces
 	{V(int(1)), EmptyInterfaceV(int(1))},
 	{V(string("hello")), EmptyInterfaceV(string("hello"))},
@@ -3508,6 +4301,363 @@ func TestConvert(t *testing.T) {
 	}
 }
 
+// Struct tags are ignored by conversions only: types that differ in
+// their tags, at any depth, are neither identical nor assignable to
+// each other, but they are convertible.
+func TestStructTagsAssignability(t *testing.T) {
+	tests := []struct{ t1, t2 Type }{
+		{TypeOf(struct {
+			x int `some:"foo"`
+		}{}), TypeOf(struct {
+			x int `some:"bar"`
+		}{})},
+		{TypeOf(struct {
+			y struct {
+				z int `some:"foo"`
+			}
+		}{}), TypeOf(struct {
+			y struct {
+				z int `some:"bar"`
+			}
+		}{})},
+		{TypeOf([]struct {
+			MyByte `some:"foo"`
+		}{}), TypeOf([]struct {
+			MyByte `some:"bar"`
+		}{})},
+		{TypeOf(func(struct {
+			x int `some:"foo"`
+		}) {
+		}), TypeOf(func(struct {
+			x int `some:"bar"`
+		}) {
+		})},
+	}
+	for _, tt := range tests {
+		if tt.t1 == tt.t2 {
+			t.Errorf("%v and %v are the same type", tt.t1, tt.t2)
+		}
+		if tt.t1.AssignableTo(tt.t2) || tt.t2.AssignableTo(tt.t1) {
+			t.Errorf("%v and %v are assignable to each other", tt.t1, tt.t2)
+		}
+		if !tt.t1.ConvertibleTo(tt.t2) || !tt.t2.ConvertibleTo(tt.t1) {
+			t.Errorf("%v and %v are not convertible to each other", tt.t1, tt.t2)
+		}
+	}
+
+	// A named struct type is not identical to an unnamed one,
+	// so a field of that type is not convertible to a field of
+	// the unnamed type.
+	type inner struct {
+		z int `some:"foo"`
+	}
+	t1 := TypeOf(struct{ y inner }{})
+	t2 := TypeOf(struct {
+		y struct {
+			z int `some:"foo"`
+		}
+	}{})
+	if t1.ConvertibleTo(t2) {
+		t.Errorf("(%v).ConvertibleTo(%v) = true, want false", t1, t2)
+	}
+}
+
+func TestSetConvert(t *testing.T) {
+	type T struct {
+		I8  int8
+		U8  uint8
+		I   int
+		I64 int64
+		U16 uint16
+		F32 float32
+		C64 complex64
+		S   string
+		B   []byte
+		R   io.Reader
+		x   int
+	}
+	var s T
+	v := ValueOf(&s).Elem()
+
+	ok := []struct {
+		field string
+		x     interface{}
+	}{
+		{"I8", int64(-128)},
+		{"I8", uint(127)},
+		{"I8", 12.9},
+		{"I8", -128.5},
+		{"I64", float64(math.MinInt64)},
+		{"U8", int64(255)},
+		{"U8", float32(255.5)},
+		{"I64", uint64(1 << 40)},
+		{"U16", int8(7)},
+		{"F32", 1e38},
+		{"F32", int64(-1 << 62)},
+		{"C64", complex(1e38, -1e38)},
+		{"S", []byte("bytes")},
+		{"B", "string"},
+		{"R", &bytes.Buffer{}},
+		{"R", nil},
+	}
+	for _, tt := range ok {
+		f := v.FieldByName(tt.field)
+		x := ValueOf(tt.x)
+		if tt.x == nil {
+			x = Zero(f.Type())
+		}
+		if err := f.SetConvert(x); err != nil {
+			t.Errorf("SetConvert(%s, %T(%v)): %v", tt.field, tt.x, tt.x, err)
+			continue
+		}
+		if want := x.Convert(f.Type()).Interface(); !DeepEqual(f.Interface(), want) {
+			t.Errorf("SetConvert(%s, %T(%v)) stored %v, want %v", tt.field, tt.x, tt.x, f.Interface(), want)
+		}
+	}
+
+	bad := []struct {
+		field string
+		x     interface{}
+		err   string
+	}{
+		{"I8", int64(128), "reflect.Value.SetConvert: value 128 of type int64 overflows type int8"},
+		{"I8", uint64(1 << 63), "reflect.Value.SetConvert: value 9223372036854775808 of type uint64 overflows type int8"},
+		{"I8", -129.5, "reflect.Value.SetConvert: value -129.5 of type float64 overflows type int8"},
+		{"I64", 1 << 63 * 1.0, "reflect.Value.SetConvert: value 9.223372036854776e+18 of type float64 overflows type int64"},
+		{"U8", -1, "reflect.Value.SetConvert: value -1 of type int overflows type uint8"},
+		{"U8", uint16(256), "reflect.Value.SetConvert: value 256 of type uint16 overflows type uint8"},
+		{"U8", math.NaN(), "reflect.Value.SetConvert: value NaN of type float64 overflows type uint8"},
+		{"U16", 65536.0, "reflect.Value.SetConvert: value 65536 of type float64 overflows type uint16"},
+		{"F32", 1e39, "reflect.Value.SetConvert: value 1e+39 of type float64 overflows type float32"},
+		{"C64", complex(1, -1e39), "reflect.Value.SetConvert: value (1-1e+39i) of type complex128 overflows type complex64"},
+		{"I", "12", "reflect.Value.SetConvert: value of type string cannot be converted to type int"},
+		{"S", 1.5, "reflect.Value.SetConvert: value of type float64 cannot be converted to type string"},
+		{"B", []int{1}, "reflect.Value.SetConvert: value of type []int cannot be converted to type []uint8"},
+		{"R", 1, "reflect.Value.SetConvert: value of type int cannot be converted to type io.Reader"},
+	}
+	for _, tt := range bad {
+		f := v.FieldByName(tt.field)
+		old := f.Interface()
+		err := f.SetConvert(ValueOf(tt.x))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("SetConvert(%s, %T(%v)) = %v, want %q", tt.field, tt.x, tt.x, err, tt.err)
+		}
+		if _, ok := err.(*ConversionError); !ok {
+			t.Errorf("SetConvert(%s, %T(%v)) returned %T, want *ConversionError", tt.field, tt.x, tt.x, err)
+		}
+		if !DeepEqual(f.Interface(), old) {
+			t.Errorf("SetConvert(%s, %T(%v)) changed the field to %v", tt.field, tt.x, tt.x, f.Interface())
+		}
+	}
+
+	if err := v.FieldByName("I8").SetIntChecked(-128); err != nil || s.I8 != -128 {
+		t.Errorf("SetIntChecked(-128) = %v, stored %d", err, s.I8)
+	}
+	if err := v.FieldByName("I8").SetIntChecked(300); err == nil || err.Error() != "reflect.Value.SetIntChecked: value 300 of type int64 overflows type int8" {
+		t.Errorf("SetIntChecked(300) = %v", err)
+	}
+	if err := v.FieldByName("U16").SetUintChecked(65535); err != nil || s.U16 != 65535 {
+		t.Errorf("SetUintChecked(65535) = %v, stored %d", err, s.U16)
+	}
+	if err := v.FieldByName("U16").SetUintChecked(65536); err == nil || err.Error() != "reflect.Value.SetUintChecked: value 65536 of type uint64 overflows type uint16" {
+		t.Errorf("SetUintChecked(65536) = %v", err)
+	}
+	if err := v.FieldByName("F32").SetFloatChecked(-2.5); err != nil || s.F32 != -2.5 {
+		t.Errorf("SetFloatChecked(-2.5) = %v, stored %g", err, s.F32)
+	}
+	if err := v.FieldByName("F32").SetFloatChecked(-1e300); err == nil || err.Error() != "reflect.Value.SetFloatChecked: value -1e+300 of type float64 overflows type float32" {
+		t.Errorf("SetFloatChecked(-1e300) = %v", err)
+	}
+
+	// Programmer errors still panic.
+	shouldPanic(func() { ValueOf(s).Field(0).SetConvert(ValueOf(1)) })
+	shouldPanic(func() { v.FieldByName("x").SetConvert(ValueOf(1)) })
+	shouldPanic(func() { v.FieldByName("I").SetConvert(v.FieldByName("x")) })
+	shouldPanic(func() { v.FieldByName("S").SetIntChecked(1) })
+	shouldPanic(func() { v.FieldByName("I").SetUintChecked(1) })
+	shouldPanic(func() { v.FieldByName("I").SetFloatChecked(1) })
+}
+
+var satTypes = []Type{
+	TypeOf(int8(0)), TypeOf(int16(0)), TypeOf(int32(0)), TypeOf(int64(0)), TypeOf(int(0)),
+	TypeOf(uint8(0)), TypeOf(uint16(0)), TypeOf(uint32(0)), TypeOf(uint64(0)), TypeOf(uint(0)), TypeOf(uintptr(0)),
+	TypeOf(float32(0)), TypeOf(float64(0)),
+}
+
+// satValues returns the values at and around the limits of every
+// numeric type, plus the special floating-point values.
+func satValues() []*mathbig.Float {
+	vals := []*mathbig.Float{
+		mathbig.NewFloat(math.MaxFloat64), mathbig.NewFloat(-math.MaxFloat64),
+		mathbig.NewFloat(math.MaxFloat32), mathbig.NewFloat(-math.MaxFloat32),
+		mathbig.NewFloat(math.Inf(1)), mathbig.NewFloat(math.Inf(-1)),
+	}
+	for _, x := range []float64{0, 0.5, 1, 1.5} {
+		vals = append(vals, mathbig.NewFloat(x), mathbig.NewFloat(-x))
+	}
+	for _, bits := range []uint{8, 16, 32, 64} {
+		for _, p := range []*mathbig.Float{pow2(bits - 1), pow2(bits)} {
+			for _, d := range []float64{-1, -0.5, 0, 0.5, 1} {
+				x := new(mathbig.Float).Add(p, mathbig.NewFloat(d))
+				vals = append(vals, x, new(mathbig.Float).Neg(x))
+			}
+		}
+	}
+	return vals
+}
+
+func pow2(n uint) *mathbig.Float {
+	return new(mathbig.Float).SetMantExp(mathbig.NewFloat(1), int(n))
+}
+
+// satSource returns x as a value of type t, which may round x if t is
+// a floating-point type, or false if x is not an integer of type t.
+func satSource(t Type, x *mathbig.Float) (Value, bool) {
+	v := New(t).Elem()
+	switch t.Kind() {
+	case Float32, Float64:
+		f, _ := x.Float64()
+		v.SetFloat(f)
+		return v, true
+	}
+	if x.IsInf() || !x.IsInt() {
+		return v, false
+	}
+	i, _ := x.Int(nil)
+	if min, max := intLimits(t); i.Cmp(min) < 0 || i.Cmp(max) > 0 {
+		return v, false
+	}
+	switch t.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		v.SetInt(i.Int64())
+	default:
+		v.SetUint(i.Uint64())
+	}
+	return v, true
+}
+
+// intLimits returns the minimum and maximum values of the integer type t.
+func intLimits(t Type) (min, max *mathbig.Int) {
+	bits := uint(t.Size() * 8)
+	one := mathbig.NewInt(1)
+	switch t.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		max = new(mathbig.Int).Sub(new(mathbig.Int).Lsh(one, bits-1), one)
+		min = new(mathbig.Int).Neg(new(mathbig.Int).Lsh(one, bits-1))
+	default:
+		max = new(mathbig.Int).Sub(new(mathbig.Int).Lsh(one, bits), one)
+		min = new(mathbig.Int)
+	}
+	return min, max
+}
+
+func bigValue(v Value) *mathbig.Int {
+	switch v.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		return mathbig.NewInt(v.Int())
+	}
+	return new(mathbig.Int).SetUint64(v.Uint())
+}
+
+func TestConvertSatChecked(t *testing.T) {
+	vals := satValues()
+	for _, src := range satTypes {
+		var srcs []Value
+		for _, x := range vals {
+			if v, ok := satSource(src, x); ok {
+				srcs = append(srcs, v)
+			}
+		}
+		if src.Kind() == Float32 || src.Kind() == Float64 {
+			srcs = append(srcs, ValueOf(math.NaN()).Convert(src))
+		}
+
+		for _, dst := range satTypes {
+			for _, v := range srcs {
+				sat := v.ConvertSat(dst)
+				checked, err := v.ConvertChecked(dst)
+				if sat.Type() != dst {
+					t.Errorf("%s(%v).ConvertSat(%s) has type %s", src, v, dst, sat.Type())
+				}
+
+				if dst.Kind() == Float32 || dst.Kind() == Float64 {
+					// Floating-point conversions follow Convert,
+					// but ConvertChecked rejects finite values
+					// that overflow to infinity.
+					want := v.Convert(dst).Float()
+					if math.Float64bits(sat.Float()) != math.Float64bits(want) {
+						t.Errorf("%s(%v).ConvertSat(%s) = %v, want %v", src, v, dst, sat, want)
+					}
+					overflow := math.IsInf(want, 0) && !math.IsInf(v.Float(), 0) && (src.Kind() == Float32 || src.Kind() == Float64)
+					if overflow != (err != nil) {
+						t.Errorf("%s(%v).ConvertChecked(%s) = %v, %v", src, v, dst, checked, err)
+					} else if err == nil && math.Float64bits(checked.Float()) != math.Float64bits(want) {
+						t.Errorf("%s(%v).ConvertChecked(%s) = %v, want %v", src, v, dst, checked, want)
+					}
+					continue
+				}
+
+				min, max := intLimits(dst)
+				var want *mathbig.Int // the saturated value
+				inRange := false
+				switch src.Kind() {
+				case Float32, Float64:
+					f := v.Float()
+					switch {
+					case f != f:
+						want = new(mathbig.Int)
+					case math.IsInf(f, -1):
+						want = min
+					case math.IsInf(f, 1):
+						want = max
+					default:
+						want, _ = mathbig.NewFloat(f).Int(nil)
+					}
+					inRange = f == f && !math.IsInf(f, 0)
+				default:
+					want = bigValue(v)
+					inRange = true
+				}
+				if want.Cmp(min) < 0 {
+					want, inRange = min, false
+				} else if want.Cmp(max) > 0 {
+					want, inRange = max, false
+				}
+
+				if got := bigValue(sat); got.Cmp(want) != 0 {
+					t.Errorf("%s(%v).ConvertSat(%s) = %v, want %v", src, v, dst, got, want)
+				}
+				if inRange {
+					if err != nil {
+						t.Errorf("%s(%v).ConvertChecked(%s): %v", src, v, dst, err)
+					} else if got := bigValue(checked); got.Cmp(want) != 0 {
+						t.Errorf("%s(%v).ConvertChecked(%s) = %v, want %v", src, v, dst, got, want)
+					}
+				} else if _, ok := err.(*ConversionError); !ok {
+					t.Errorf("%s(%v).ConvertChecked(%s) = %v, %v, want *ConversionError", src, v, dst, checked, err)
+				}
+			}
+		}
+	}
+
+	// Other conversions follow Convert.
+	if s := ValueOf(300).ConvertSat(TypeOf("")); s.String() != "Ĭ" {
+		t.Errorf("ConvertSat(300, string) = %q", s)
+	}
+	if s, err := ValueOf([]byte("hi")).ConvertChecked(TypeOf("")); err != nil || s.String() != "hi" {
+		t.Errorf("ConvertChecked([]byte, string) = %q, %v", s, err)
+	}
+	_, err := ValueOf(int16(300)).ConvertChecked(TypeOf(int8(0)))
+	if want := "reflect.Value.ConvertChecked: value 300 of type int16 overflows type int8"; err == nil || err.Error() != want {
+		t.Errorf("ConvertChecked(int16(300), int8) = %v, want %q", err, want)
+	}
+	_, err = ValueOf("12").ConvertChecked(TypeOf(0))
+	if want := "reflect.Value.ConvertChecked: value of type string cannot be converted to type int"; err == nil || err.Error() != want {
+		t.Errorf("ConvertChecked(\"12\", int) = %v, want %q", err, want)
+	}
+	shouldPanic(func() { ValueOf("12").ConvertSat(TypeOf(0)) })
+}
+
 type ComparableStruct struct {
 	X int
 }
@@ -3585,6 +4735,100 @@ func TestOverflow(t *testing.T) {
 	}
 }
 
+var fitsIntTests = []struct {
+	x    interface{}
+	bits int
+	fits bool
+}{
+	{int64(math.MaxInt32), 32, true},
+	{int64(math.MaxInt32 + 1), 32, false},
+	{int64(math.MinInt32), 32, true},
+	{int64(math.MinInt32 - 1), 32, false},
+	{int8(-128), 8, true},
+	{int16(128), 8, false},
+	{int64(math.MinInt64), 64, true},
+	{uint64(math.MaxInt64), 64, true},
+	{uint64(math.MaxInt64 + 1), 64, false},
+	{uint8(255), 8, false},
+	{uint8(127), 8, true},
+	{uintptr(1 << 15), 16, false},
+	{float64(math.MaxInt32), 32, true},
+	{float64(math.MaxInt32 + 1), 32, false},
+	{float64(math.MaxInt32) + 0.5, 32, false},
+	{float64(math.MinInt32), 32, true},
+	{float64(math.MinInt32) - 1, 32, false},
+	{float64(math.MinInt32) - 0.5, 32, false},
+	{float64(1 << 53), 64, true},
+	{float64(1<<53 + 1), 64, true},      // rounds to 1<<53 as a constant
+	{float64(math.MaxInt64), 64, false}, // rounds up to 1<<63
+	{float64(math.MinInt64), 64, true},
+	{math.Nextafter(math.MinInt64, math.Inf(-1)), 64, false},
+	{math.Nextafter(1<<63, 0), 64, true},
+	{math.Copysign(0, -1), 8, true},
+	{0.5, 64, false},
+	{-1.5, 64, false},
+	{1e300, 64, false},
+	{math.NaN(), 64, false},
+	{math.Inf(1), 64, false},
+	{math.Inf(-1), 64, false},
+	{float32(1 << 31), 32, false},
+	{float32(-1 << 31), 32, true},
+	{float32(16777217), 32, true}, // rounds to 1<<24
+	{float32(1.25), 32, false},
+	{int64(1 << 40), 0, PtrSize == 8},
+	{float64(1 << 40), 0, PtrSize == 8},
+}
+
+func TestFitsInt(t *testing.T) {
+	for _, tt := range fitsIntTests {
+		if fits := ValueOf(tt.x).FitsInt(tt.bits); fits != tt.fits {
+			t.Errorf("FitsInt(%T(%v), %d) = %v, want %v", tt.x, tt.x, tt.bits, fits, tt.fits)
+		}
+	}
+	shouldPanic(func() { ValueOf(1).FitsInt(12) })
+	shouldPanic(func() { ValueOf("1").FitsInt(64) })
+	shouldPanic(func() { ValueOf(complex(1, 0)).FitsInt(64) })
+}
+
+var fitsFloatTests = []struct {
+	x    interface{}
+	bits int
+	fits bool
+}{
+	{int64(1 << 53), 64, true},
+	{int64(1<<53 - 1), 64, true},
+	{int64(1<<53 + 1), 64, false},
+	{int64(-1<<53 - 1), 64, false},
+	{int64(-1<<53 + 1), 64, true},
+	{int64(1<<54 + 4), 64, true},
+	{int64(1<<54 + 2), 64, false},
+	{int64(math.MaxInt64), 64, false},
+	{int64(math.MinInt64), 64, true},
+	{int64(math.MinInt64 + 1), 64, false},
+	{uint64(math.MaxUint64), 64, false},
+	{uint64(1 << 63), 64, true},
+	{int32(1 << 24), 32, true},
+	{int32(1<<24 + 1), 32, false},
+	{int32(math.MaxInt32), 32, false},
+	{int32(math.MaxInt32), 64, true},
+	{int32(math.MinInt32), 32, true},
+	{uint32(math.MaxUint32), 32, false},
+	{uint16(math.MaxUint16), 32, true},
+	{int8(-128), 32, true},
+	{0, 32, true},
+	{uintptr(0), 64, true},
+}
+
+func TestFitsFloat(t *testing.T) {
+	for _, tt := range fitsFloatTests {
+		if fits := ValueOf(tt.x).FitsFloat(tt.bits); fits != tt.fits {
+			t.Errorf("FitsFloat(%T(%v), %d) = %v, want %v", tt.x, tt.x, tt.bits, fits, tt.fits)
+		}
+	}
+	shouldPanic(func() { ValueOf(1).FitsFloat(16) })
+	shouldPanic(func() { ValueOf(1.0).FitsFloat(64) })
+}
+
 func checkSameType(t *testing.T, x, y interface{}) {
 	if TypeOf(x) != TypeOf(y) {
 		t.Errorf("did not find preexisting type for %s (vs %s)", TypeOf(x), TypeOf(y))
@@ -4428,12 +5672,12 @@ func TestStructOfWithInterface(t *testing.T) {
 			val:  ValueOf(StructIPtr(want)),
 			impl: false,
 		},
-		// {
-		//	typ:  TypeOf((*Iface)(nil)).Elem(), // FIXME(sbinet): fix method.ifn/tfn
-		//	val:  ValueOf(StructI(want)),
-		//	impl: true,
-		// },
-	} {
+		{
+			typ:  TypeOf((*Iface)(nil)).Elem(),
+			val:  ValueOf(StructI(want)),
+			impl: true,
+		},
+	} {
 		rt := StructOf(
 			[]StructField{
 				{
@@ -4472,6 +5716,661 @@ func TestStructOfWithInterface(t *testing.T) {
 	}
 }
 
+type promoteA struct{ N int }
+
+func (a promoteA) Name() string { return "A" }
+func (a promoteA) A() int       { return a.N }
+func (a *promoteA) SetA(n int)  { a.N = n }
+func (a promoteA) Sum(x ...int) int {
+	for _, v := range x {
+		a.N += v
+	}
+	return a.N
+}
+
+type promoteB struct{}
+
+func (promoteB) Name() string { return "B" }
+
+type promoteDeep struct{ promoteB }
+
+func TestStructOfPromotedMethods(t *testing.T) {
+	writer := TypeOf((*io.Writer)(nil)).Elem()
+	stringer := TypeOf((*fmt.Stringer)(nil)).Elem()
+
+	// The methods of bytes.Buffer have pointer receivers, so only the
+	// pointer to a struct embedding it implements io.Writer.
+	st := StructOf([]StructField{
+		{Name: "X", Type: TypeOf(0)},
+		{Type: TypeOf(bytes.Buffer{})},
+	})
+	if st.Implements(writer) || !PtrTo(st).Implements(writer) {
+		t.Errorf("%v implements io.Writer %v, its pointer %v; want false, true",
+			st, st.Implements(writer), PtrTo(st).Implements(writer))
+	}
+	p := New(st)
+	p.Interface().(io.Writer).Write([]byte("hello"))
+	if s := p.Interface().(fmt.Stringer).String(); s != "hello" {
+		t.Errorf("String() = %q, want %q", s, "hello")
+	}
+	if s := p.Elem().Field(1).Interface().(bytes.Buffer); s.String() != "hello" {
+		t.Errorf("embedded buffer holds %q, want %q", s.String(), "hello")
+	}
+
+	// Embedding a pointer promotes the pointer methods to the struct.
+	st = StructOf([]StructField{
+		{Name: "X", Type: TypeOf(0)},
+		{Type: TypeOf(new(bytes.Buffer))},
+	})
+	if !st.Implements(writer) || !st.Implements(stringer) {
+		t.Errorf("%v does not implement io.Writer and fmt.Stringer", st)
+	}
+	v := New(st).Elem()
+	v.Field(1).Set(ValueOf(new(bytes.Buffer)))
+	fmt.Fprintf(v.Interface().(io.Writer), "%d", 42)
+	v.MethodByName("WriteString").Call([]Value{ValueOf("!")})
+	m, _ := st.MethodByName("String")
+	if s := m.Func.Call([]Value{v})[0].String(); s != "42!" {
+		t.Errorf("Type.Method String = %q, want %q", s, "42!")
+	}
+	shouldPanic(func() { Zero(st).Interface().(io.Writer).Write(nil) })
+
+	// A direct struct type gets its only field as the receiver word.
+	st = StructOf([]StructField{{Type: TypeOf(new(bytes.Buffer))}})
+	v = New(st).Elem()
+	v.Field(0).Set(ValueOf(bytes.NewBufferString("direct")))
+	if s := fmt.Sprint(v.Interface()); s != "direct" {
+		t.Errorf("fmt.Sprint = %q, want %q", s, "direct")
+	}
+
+	// An embedded interface provides its methods.
+	st = StructOf([]StructField{{Type: stringer}, {Name: "Y", Type: TypeOf(0)}})
+	v = New(st).Elem()
+	v.Field(0).Set(ValueOf(time.Second))
+	if s := v.Interface().(fmt.Stringer).String(); s != "1s" {
+		t.Errorf("String() = %q, want %q", s, "1s")
+	}
+
+	// A method is promoted from the shallowest depth, only if no other
+	// method or field has its name there.
+	for _, test := range []struct {
+		fields  []StructField
+		methods string // names of the methods of the pointer type
+		name    string // result of Name, if any
+	}{
+		{[]StructField{{Type: TypeOf(promoteA{})}}, "A Name SetA Sum", "A"},
+		{[]StructField{{Type: TypeOf(promoteA{})}, {Type: TypeOf(promoteB{})}}, "A SetA Sum", ""},
+		{[]StructField{{Type: TypeOf(promoteDeep{})}, {Type: TypeOf(promoteA{})}}, "A Name SetA Sum", "A"},
+		{[]StructField{{Type: TypeOf(promoteDeep{})}, {Name: "Z", Type: TypeOf(0)}}, "Name", "B"},
+		{[]StructField{{Type: TypeOf(promoteDeep{})}, {Name: "Name", Type: TypeOf("")}}, "", ""},
+	} {
+		st := StructOf(test.fields)
+		var names []string
+		for i := 0; i < PtrTo(st).NumMethod(); i++ {
+			names = append(names, PtrTo(st).Method(i).Name)
+		}
+		if got := strings.Join(names, " "); got != test.methods {
+			t.Errorf("%v has methods %q, want %q", st, got, test.methods)
+		}
+		if m := New(st).MethodByName("Name"); m.IsValid() != (test.name != "") {
+			t.Errorf("%v has method Name %v, want %v", st, m.IsValid(), test.name != "")
+		} else if m.IsValid() && m.Call(nil)[0].String() != test.name {
+			t.Errorf("%v.Name() = %q, want %q", st, m.Call(nil)[0].String(), test.name)
+		}
+	}
+
+	// Pointer and variadic methods of an embedded value get the field.
+	st = StructOf([]StructField{{Name: "S", Type: TypeOf("")}, {Type: TypeOf(promoteA{})}})
+	p = New(st)
+	p.MethodByName("SetA").Call([]Value{ValueOf(3)})
+	if n := p.Elem().MethodByName("Sum").Call([]Value{ValueOf(1), ValueOf(2)})[0].Int(); n != 6 {
+		t.Errorf("Sum(1, 2) = %d, want 6", n)
+	}
+	if n := p.Elem().MethodByName("Sum").CallSlice([]Value{ValueOf([]int{4})})[0].Int(); n != 7 {
+		t.Errorf("Sum([]int{4}...) = %d, want 7", n)
+	}
+
+	// Types with many methods have room for all of them.
+	st = StructOf([]StructField{{Type: TypeOf(time.Time{})}, {Name: "N", Type: TypeOf(0)}})
+	if st.NumMethod() != TypeOf(time.Time{}).NumMethod() || PtrTo(st).NumMethod() != PtrTo(TypeOf(time.Time{})).NumMethod() {
+		t.Errorf("%v has %d and %d methods, want %d and %d", st, st.NumMethod(), PtrTo(st).NumMethod(),
+			TypeOf(time.Time{}).NumMethod(), PtrTo(TypeOf(time.Time{})).NumMethod())
+	}
+	v = New(st).Elem()
+	v.Field(0).Set(ValueOf(time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC)))
+	if y := v.Interface().(interface {
+		Year() int
+	}).Year(); y != 2016 {
+		t.Errorf("Year() = %d, want 2016", y)
+	}
+}
+
+func TestMethodCacheBounded(t *testing.T) {
+	n := 100000
+	if testing.Short() {
+		n = 20000
+	}
+	types := make([]Type, n)
+	for i := range types {
+		types[i] = StructOf([]StructField{
+			{Type: TypeOf(StructI(0))},
+			{Name: "F" + strconv.Itoa(i), Type: TypeOf(0)},
+		})
+	}
+	var m1, m2 runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m1)
+	// Method would build a func type for each of the types, which the
+	// FuncOf cache keeps; NumMethod only enumerates the methods.
+	for _, typ := range types {
+		if typ.NumMethod() != 1 {
+			t.Fatalf("%v has %d methods, want 1", typ, typ.NumMethod())
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&m2)
+	runtime.KeepAlive(types)
+
+	if l := MethodCacheLen(); l > MethodCacheSize {
+		t.Errorf("method cache holds %d types, want at most %d", l, MethodCacheSize)
+	}
+	// Caching every type would take several bytes per type for the
+	// map alone; the bounded cache takes a fixed amount.
+	const maxGrowth = MethodCacheSize * 256
+	if m2.HeapAlloc > m1.HeapAlloc && m2.HeapAlloc-m1.HeapAlloc > maxGrowth {
+		t.Errorf("enumerating the methods of %d types grew the heap by %d bytes, want at most %d", n, m2.HeapAlloc-m1.HeapAlloc, maxGrowth)
+	}
+}
+
+func TestMethodCacheConcurrent(t *testing.T) {
+	typ := StructOf([]StructField{
+		{Type: TypeOf(StructI(0))},
+		{Name: "Concurrent", Type: TypeOf(0)},
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if m := typ.Method(0); m.Name != "Get" || typ.NumMethod() != 1 {
+					t.Errorf("%v has method %q, want Get only", typ, m.Name)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTypeCachesConcurrent(t *testing.T) {
+	// Fresh element types, so that the goroutines race to create
+	// the derived types.
+	elems := make([]Type, 50)
+	for i := range elems {
+		elems[i] = StructOf([]StructField{{Name: "Race" + strconv.Itoa(i), Type: TypeOf(0)}})
+	}
+	derive := func(e Type) []Type {
+		return []Type{
+			PtrTo(e),
+			SliceOf(e),
+			ArrayOf(3, e),
+			MapOf(TypeOf(""), e),
+			ChanOf(BothDir, e),
+			FuncOf([]Type{e}, []Type{e}, false),
+		}
+	}
+	const n = 8
+	results := make([][]Type, n)
+	var wg sync.WaitGroup
+	for g := 0; g < n; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for _, e := range elems {
+				results[g] = append(results[g], derive(e)...)
+			}
+		}(g)
+	}
+	wg.Wait()
+	for g := 1; g < n; g++ {
+		for i, typ := range results[g] {
+			if typ != results[0][i] {
+				t.Fatalf("goroutine %d got a different %v than goroutine 0", g, typ)
+			}
+		}
+	}
+	for i, e := range elems {
+		for j, typ := range derive(e) {
+			if typ != results[0][i*6+j] {
+				t.Errorf("%v is not cached", typ)
+			}
+		}
+	}
+}
+
+func TestTypeCacheAllocs(t *testing.T) {
+	elem, key := TypeOf(T{}), TypeOf("")
+	lookups := func() {
+		PtrTo(elem)
+		SliceOf(elem)
+		ArrayOf(4, elem)
+		MapOf(key, elem)
+		ChanOf(RecvDir, elem)
+		elem.NumMethod()
+	}
+	lookups()
+	if n := testing.AllocsPerRun(100, lookups); n != 0 {
+		t.Errorf("looking up cached types allocates %v times, want 0", n)
+	}
+}
+
+// BenchmarkMethodCacheParallel looks up the methods of 1000 types from
+// GOMAXPROCS goroutines; run with -cpu to see how lookups scale.
+func BenchmarkMethodCacheParallel(b *testing.B) {
+	types := make([]Type, 1000)
+	for i := range types {
+		types[i] = StructOf([]StructField{
+			{Type: TypeOf(StructI(0))},
+			{Name: "Bench" + strconv.Itoa(i), Type: TypeOf(0)},
+		})
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			typ := types[i%len(types)]
+			if typ.NumMethod() != 1 {
+				b.Fatalf("%v has %d methods, want 1", typ, typ.NumMethod())
+			}
+			if i%16 == 0 && typ.Method(0).Name != "Get" {
+				b.Fatalf("%v has no method Get", typ)
+			}
+			i++
+		}
+	})
+}
+
+type setter interface {
+	SetN(int)
+}
+
+func TestStructOfWithMethods(t *testing.T) {
+	fields := []StructField{
+		{Name: "Name", Type: TypeOf("")},
+		{Name: "N", Type: TypeOf(0)},
+	}
+	base := StructOf(fields)
+	str := MakeFunc(FuncOf([]Type{base}, []Type{TypeOf("")}, false), func(in []Value) []Value {
+		return []Value{ValueOf(fmt.Sprintf("%s=%d", in[0].Field(0).String(), in[0].Field(1).Int()))}
+	})
+	set := MakeFunc(FuncOf([]Type{PtrTo(base), TypeOf(0)}, nil, false), func(in []Value) []Value {
+		in[0].Elem().Field(1).Set(in[1])
+		return nil
+	})
+	typ, err := StructOfWithMethods(fields, []Method{
+		{Name: "String", Func: str},
+		{Name: "SetN", Func: set},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ptr := PtrTo(typ)
+
+	if typ == base {
+		t.Fatalf("StructOfWithMethods returned the type without methods")
+	}
+	if StructOf(fields) != base {
+		t.Errorf("StructOf(fields) changed after StructOfWithMethods")
+	}
+	stringer := TypeOf((*fmt.Stringer)(nil)).Elem()
+	setterType := TypeOf((*setter)(nil)).Elem()
+	if n := typ.NumMethod(); n != 1 {
+		t.Errorf("%v has %d methods, want 1", typ, n)
+	}
+	if n := ptr.NumMethod(); n != 2 {
+		t.Errorf("%v has %d methods, want 2", ptr, n)
+	}
+	if m := ptr.Method(0); m.Name != "SetN" || m.Type.String() != "func("+ptr.String()+", int)" {
+		t.Errorf("%v.Method(0) = %s %v, want SetN func(%v, int)", ptr, m.Name, m.Type, ptr)
+	}
+	if !typ.Implements(stringer) || !ptr.Implements(stringer) {
+		t.Errorf("%v or %v does not implement fmt.Stringer", typ, ptr)
+	}
+	if typ.Implements(setterType) || !ptr.Implements(setterType) {
+		t.Errorf("only %v should implement setter", ptr)
+	}
+
+	v := New(typ).Elem()
+	v.Field(0).SetString("x")
+	v.Field(1).SetInt(3)
+	if s := v.Interface().(fmt.Stringer).String(); s != "x=3" {
+		t.Errorf("String() = %q, want %q", s, "x=3")
+	}
+	v.Addr().Interface().(setter).SetN(7)
+	if s := fmt.Sprint(v.Interface()); s != "x=7" {
+		t.Errorf("after SetN(7), fmt.Sprint = %q, want %q", s, "x=7")
+	}
+	if s := fmt.Sprint(v.Addr().Interface()); s != "x=7" {
+		t.Errorf("fmt.Sprint of pointer = %q, want %q", s, "x=7")
+	}
+	if s := v.Method(0).Call(nil)[0].String(); s != "x=7" {
+		t.Errorf("Value.Method(0).Call = %q, want %q", s, "x=7")
+	}
+	if s := typ.Method(0).Func.Call([]Value{v})[0].String(); s != "x=7" {
+		t.Errorf("Type.Method(0).Func.Call = %q, want %q", s, "x=7")
+	}
+	v.Addr().MethodByName("SetN").Call([]Value{ValueOf(9)})
+	if s := ptr.Method(1).Func.Call([]Value{v.Addr()})[0].String(); s != "x=9" {
+		t.Errorf("pointer Type.Method(1).Func.Call = %q, want %q", s, "x=9")
+	}
+
+	var nilp interface{} = Zero(ptr).Interface()
+	shouldPanic(func() { _ = nilp.(fmt.Stringer).String() })
+}
+
+func TestStructOfWithMethodsSort(t *testing.T) {
+	fields := []StructField{{Name: "S", Type: TypeOf([]int(nil))}}
+	base := StructOf(fields)
+	ints := func(v Value) []int { return v.Field(0).Interface().([]int) }
+	method := func(name string, in, out []Type, fn func(s []int, in []Value) []Value) Method {
+		in = append([]Type{base}, in...)
+		return Method{Name: name, Func: MakeFunc(FuncOf(in, out, false), func(in []Value) []Value {
+			return fn(ints(in[0]), in[1:])
+		})}
+	}
+	intType, boolType := TypeOf(0), TypeOf(false)
+	typ, err := StructOfWithMethods(fields, []Method{
+		method("Swap", []Type{intType, intType}, nil, func(s []int, in []Value) []Value {
+			i, j := in[0].Int(), in[1].Int()
+			s[i], s[j] = s[j], s[i]
+			return nil
+		}),
+		method("Len", nil, []Type{intType}, func(s []int, in []Value) []Value {
+			return []Value{ValueOf(len(s))}
+		}),
+		method("Less", []Type{intType, intType}, []Type{boolType}, func(s []int, in []Value) []Value {
+			return []Value{ValueOf(s[in[0].Int()] < s[in[1].Int()])}
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !typ.Implements(TypeOf((*sort.Interface)(nil)).Elem()) {
+		t.Fatalf("%v does not implement sort.Interface", typ)
+	}
+	for i, name := range []string{"Len", "Less", "Swap"} {
+		if m := typ.Method(i); m.Name != name {
+			t.Errorf("%v.Method(%d) = %s, want %s", typ, i, m.Name, name)
+		}
+	}
+
+	v := New(typ).Elem()
+	s := []int{5, 2, 4, 1, 3}
+	v.Field(0).Set(ValueOf(s))
+	sort.Sort(v.Interface().(sort.Interface))
+	if !sort.IntsAreSorted(s) {
+		t.Errorf("sort.Sort left %v", s)
+	}
+}
+
+func TestStructOfWithMethodsDirectIface(t *testing.T) {
+	// A struct with a single pointer field is stored directly in
+	// interface values, so methods get the receiver itself.
+	fields := []StructField{{Name: "P", Type: TypeOf((*int)(nil))}}
+	base := StructOf(fields)
+	str := MakeFunc(FuncOf([]Type{base}, []Type{TypeOf("")}, false), func(in []Value) []Value {
+		return []Value{ValueOf(strconv.Itoa(int(in[0].Field(0).Elem().Int())))}
+	})
+	typ, err := StructOfWithMethods(fields, []Method{{Name: "String", Func: str}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := 42
+	v := New(typ).Elem()
+	v.Field(0).Set(ValueOf(&x))
+	if s := v.Interface().(fmt.Stringer).String(); s != "42" {
+		t.Errorf("String() = %q, want %q", s, "42")
+	}
+	if s := v.Addr().Interface().(fmt.Stringer).String(); s != "42" {
+		t.Errorf("String() through pointer = %q, want %q", s, "42")
+	}
+}
+
+func TestStructOfWithMethodsErrors(t *testing.T) {
+	fields := []StructField{{Name: "X", Type: TypeOf(0)}}
+	base := StructOf(fields)
+	fn := func(recv Type) Value {
+		return MakeFunc(FuncOf([]Type{recv}, nil, false), func([]Value) []Value { return nil })
+	}
+	other := StructOf([]StructField{{Name: "Y", Type: TypeOf(0)}})
+	for _, ms := range [][]Method{
+		{{Name: "unexported", Func: fn(base)}},
+		{{Name: "M", PkgPath: "p", Func: fn(base)}},
+		{{Name: "M", Func: fn(base)}, {Name: "M", Func: fn(base)}},
+		{{Name: "X", Func: fn(base)}},
+		{{Name: "M", Func: fn(other)}},
+		{{Name: "M", Func: fn(PtrTo(other))}},
+		{{Name: "M", Func: fn(TypeOf(0))}},
+		{{Name: "M", Func: ValueOf(0)}},
+	} {
+		shouldPanic(func() { StructOfWithMethods(fields, ms) })
+	}
+}
+
+func TestStructOfWithMethodsMany(t *testing.T) {
+	// Each method calls its own func value through its own stub.
+	fields := []StructField{{Name: "X", Type: TypeOf(0)}}
+	base := StructOf(fields)
+	stringer := TypeOf((*fmt.Stringer)(nil)).Elem()
+	var vs []fmt.Stringer
+	for i := 0; i < 10; i++ {
+		s := strconv.Itoa(i)
+		str := MakeFunc(FuncOf([]Type{base}, []Type{TypeOf("")}, false), func(in []Value) []Value {
+			return []Value{ValueOf(s + ":" + strconv.Itoa(int(in[0].Field(0).Int())))}
+		})
+		typ, err := StructOfWithMethods(fields, []Method{{Name: "String", Func: str}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := New(typ).Elem()
+		v.Field(0).SetInt(int64(i * 10))
+		vs = append(vs, v.Interface().(fmt.Stringer), v.Addr().Interface().(fmt.Stringer))
+		if !typ.Implements(stringer) {
+			t.Fatalf("%v does not implement fmt.Stringer", typ)
+		}
+	}
+	for i, v := range vs {
+		if got, want := v.String(), fmt.Sprintf("%d:%d", i/2, i/2*10); got != want {
+			t.Errorf("String() of value %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestStructOfWithMethodsExhausted(t *testing.T) {
+	fields := []StructField{{Name: "X", Type: TypeOf(0)}}
+	fn := MakeFunc(FuncOf([]Type{StructOf(fields)}, nil, false), func([]Value) []Value { return nil })
+	ms := []Method{{Name: "A", Func: fn}, {Name: "B", Func: fn}}
+	old := SetMethodStubsUsed(MethodStubCount - 1)
+	defer SetMethodStubsUsed(old)
+	if typ, err := StructOfWithMethods(fields, ms); err == nil {
+		t.Fatalf("StructOfWithMethods built %v with no method stubs left", typ)
+	}
+	// The failed call used no stubs.
+	typ, err := StructOfWithMethods(fields, ms[:1])
+	if err != nil {
+		t.Fatalf("StructOfWithMethods with one stub left: %v", err)
+	}
+	if typ.NumMethod() != 1 {
+		t.Errorf("%v has %d methods, want 1", typ, typ.NumMethod())
+	}
+}
+
+func TestStructOfPromotedMethodsWithoutStubs(t *testing.T) {
+	// The methods promoted from a first embedded field that gets the
+	// receiver word unchanged use no method stubs.
+	old := SetMethodStubsUsed(MethodStubCount)
+	defer SetMethodStubsUsed(old)
+
+	st := StructOf([]StructField{
+		{Type: TypeOf(bytes.Buffer{})},
+		{Name: "NoStubs", Type: TypeOf(0)},
+	})
+	p := New(st)
+	p.Interface().(io.Writer).Write([]byte("hello"))
+	if s := p.Interface().(fmt.Stringer).String(); s != "hello" {
+		t.Errorf("String() = %q, want %q", s, "hello")
+	}
+
+	st = StructOf([]StructField{
+		{Type: TypeOf(time.Duration(0))},
+		{Name: "NoStubs", Type: TypeOf(0)},
+	})
+	v := New(st).Elem()
+	v.Field(0).SetInt(int64(time.Second))
+	if s := v.Interface().(fmt.Stringer).String(); s != "1s" {
+		t.Errorf("String() = %q, want %q", s, "1s")
+	}
+
+	// Other promoted methods need stubs: those of a field at a nonzero
+	// offset, and those of the pointer type of a struct type holding
+	// only a pointer, which get the pointer to the field.
+	shouldPanic(func() {
+		StructOf([]StructField{
+			{Name: "NoStubs", Type: TypeOf([5]int64{})},
+			{Type: TypeOf(bytes.Reader{})},
+		})
+	})
+	shouldPanic(func() {
+		StructOf([]StructField{{Type: TypeOf(new(bytes.Reader)), Tag: "nostubs"}})
+	})
+}
+
+func TestMethodStubs(t *testing.T) {
+	// The stubs are the entries of a single function.
+	first := runtime.FuncForPC(MethodStubCode(0))
+	last := runtime.FuncForPC(MethodStubCode(MethodStubCount - 1))
+	if first == nil || first.Name() != "reflect.methodStubs" || last == nil || last.Entry() != first.Entry() {
+		t.Errorf("method stubs are not in reflect.methodStubs")
+	}
+}
+
+func TestNamedOf(t *testing.T) {
+	const pkgPath = "example.com/temp"
+	for _, u := range []Type{
+		TypeOf(0),
+		TypeOf(""),
+		TypeOf(unsafe.Pointer(nil)),
+		TypeOf([2]int{}),
+		TypeOf(make(chan int)),
+		TypeOf(func(int, string) error { return nil }),
+		TypeOf(func(a, b, c, d, e, f, g, h, i, j int) {}),
+		TypeOf((*io.Reader)(nil)).Elem(),
+		TypeOf(map[string]int{}),
+		TypeOf(new(int)),
+		TypeOf([]byte{}),
+		TypeOf(struct{ X, y int }{}),
+		TypeOf(time.Duration(0)),
+	} {
+		n := NamedOf("Celsius", pkgPath, u)
+		if n.Name() != "Celsius" || n.PkgPath() != pkgPath || n.String() != "temp.Celsius" {
+			t.Errorf("NamedOf(%v): Name, PkgPath, String = %q, %q, %q; want %q, %q, %q",
+				u, n.Name(), n.PkgPath(), n.String(), "Celsius", pkgPath, "temp.Celsius")
+		}
+		if pp := StrPkgPath(n); pp != pkgPath {
+			t.Errorf("NamedOf(%v): name has import path %q, want %q", u, pp, pkgPath)
+		}
+		if n.Kind() != u.Kind() || n.Size() != u.Size() || n.Align() != u.Align() || n.Comparable() != u.Comparable() {
+			t.Errorf("NamedOf(%v) has kind %v, size %d, align %d, comparable %v; want %v, %d, %d, %v",
+				u, n.Kind(), n.Size(), n.Align(), n.Comparable(), u.Kind(), u.Size(), u.Align(), u.Comparable())
+		}
+		if n == u || NamedOf("Celsius", pkgPath, u) != n {
+			t.Errorf("NamedOf(%v) is not a type of its own", u)
+		}
+		if NamedOf("Celsius", "example.com/other/temp", u) == n || NamedOf("Kelvin", pkgPath, u) == n {
+			t.Errorf("NamedOf(%v) is the same for different names", u)
+		}
+		// A named type is assignable from or to only an unnamed type,
+		// unless both are interfaces with the same methods.
+		if want := u.Name() == "" || u.Kind() == Interface; n.AssignableTo(u) != want || u.AssignableTo(n) != want {
+			t.Errorf("NamedOf(%v): AssignableTo = %v, %v; want %v", u, n.AssignableTo(u), u.AssignableTo(n), want)
+		}
+		if !n.ConvertibleTo(u) || !u.ConvertibleTo(n) {
+			t.Errorf("NamedOf(%v) is not convertible to and from it", u)
+		}
+		if u.Kind() != Interface && n.NumMethod() != 0 {
+			t.Errorf("NamedOf(%v) has %d methods, want none", u, n.NumMethod())
+		}
+		if u.Kind() == Func {
+			if n.NumIn() != u.NumIn() || n.NumOut() != u.NumOut() {
+				t.Fatalf("NamedOf(%v) has %d parameters, %d results", u, n.NumIn(), n.NumOut())
+			}
+			for i := 0; i < u.NumIn(); i++ {
+				if n.In(i) != u.In(i) {
+					t.Errorf("NamedOf(%v).In(%d) = %v", u, i, n.In(i))
+				}
+			}
+			for i := 0; i < u.NumOut(); i++ {
+				if n.Out(i) != u.Out(i) {
+					t.Errorf("NamedOf(%v).Out(%d) = %v", u, i, n.Out(i))
+				}
+			}
+		}
+		// Values of the type keep it through interfaces.
+		if got := TypeOf(New(n).Elem().Interface()); u.Kind() != Interface && got != n {
+			t.Errorf("NamedOf(%v): value has type %v", u, got)
+		}
+	}
+}
+
+func TestNamedOfValues(t *testing.T) {
+	celsius := NamedOf("Celsius", "example.com/temp", TypeOf(0.0))
+	c := ValueOf(21.5).Convert(celsius)
+	m := MakeMap(MapOf(celsius, TypeOf("")))
+	m.SetMapIndex(c, ValueOf("warm"))
+	runtime.GC()
+	if got := m.MapIndex(ValueOf(21.5).Convert(celsius)); got.String() != "warm" {
+		t.Errorf("map lookup = %v, want warm", got)
+	}
+	if got := c.Convert(TypeOf(0.0)).Interface(); got != 21.5 {
+		t.Errorf("converted back = %v, want 21.5", got)
+	}
+	if s := fmt.Sprintf("%v %T", c, c.Interface()); s != "21.5 temp.Celsius" {
+		t.Errorf("Sprintf = %q, want %q", s, "21.5 temp.Celsius")
+	}
+	shouldPanic(func() { ValueOf(21.5).Set(c) })
+
+	// A named type has only the methods of its underlying interface.
+	stringer := TypeOf((*fmt.Stringer)(nil)).Elem()
+	empty := TypeOf((*interface{})(nil)).Elem()
+	if celsius.Implements(stringer) || !celsius.Implements(empty) {
+		t.Errorf("Celsius implements Stringer %v, interface{} %v; want false, true",
+			celsius.Implements(stringer), celsius.Implements(empty))
+	}
+	if NamedOf("Duration", "example.com/time", TypeOf(time.Duration(0))).Implements(stringer) {
+		t.Errorf("named type has the methods of its underlying type")
+	}
+	reader := NamedOf("Reader", "example.com/io", TypeOf((*io.Reader)(nil)).Elem())
+	if reader.NumMethod() != 1 || !TypeOf(new(bytes.Buffer)).Implements(reader) || TypeOf(0).Implements(reader) {
+		t.Errorf("Reader interface has %d methods, implemented by *bytes.Buffer %v, int %v",
+			reader.NumMethod(), TypeOf(new(bytes.Buffer)).Implements(reader), TypeOf(0).Implements(reader))
+	}
+	r := New(reader).Elem()
+	r.Set(ValueOf(bytes.NewBufferString("hello")))
+	buf := make([]byte, 5)
+	if n, err := r.Interface().(io.Reader).Read(buf); n != 5 || err != nil || string(buf) != "hello" {
+		t.Errorf("Read = %d, %v, %q", n, err, buf)
+	}
+}
+
+func TestNamedOfErrors(t *testing.T) {
+	shouldPanic(func() { NamedOf("", "p", TypeOf(0)) })
+	shouldPanic(func() { NamedOf("p.T", "p", TypeOf(0)) })
+	shouldPanic(func() { NamedOf("1T", "p", TypeOf(0)) })
+	shouldPanic(func() { NamedOf("T", "", TypeOf(0)) })
+	shouldPanic(func() { NamedOf("T", "p", TypeOf(struct{ io.Reader }{})) })
+}
+
 func TestChanOf(t *testing.T) {
 	// check construction and use of type not in binary
 	type T string
@@ -4669,6 +6568,77 @@ func TestTypelinksSorted(t *testing.T) {
 	}
 }
 
+func TestFuncOfManyArgs(t *testing.T) {
+	intType := TypeOf(0)
+	for _, n := range []int{51, 100, 127, 128, 129, 300} {
+		in := make([]Type, n-1)
+		for i := range in {
+			in[i] = intType
+		}
+		ft := FuncOf(in, []Type{intType}, false)
+		if ft.NumIn() != n-1 || ft.NumOut() != 1 {
+			t.Errorf("%d args: NumIn, NumOut = %d, %d", n, ft.NumIn(), ft.NumOut())
+			continue
+		}
+		if ft2 := FuncOf(in, []Type{intType}, false); ft2 != ft {
+			t.Errorf("%d args: FuncOf returned a different type the second time", n)
+		}
+		want := "func(" + strings.Repeat("int, ", n-2) + "int) int"
+		if ft.String() != want {
+			t.Errorf("%d args: String() = %q", n, ft.String())
+		}
+		variadic := FuncOf(append(in[:n-2:n-2], SliceOf(intType)), []Type{intType}, true)
+		if variadic == ft || !variadic.IsVariadic() {
+			t.Errorf("%d args: variadic variant not distinct", n)
+		}
+		other := FuncOf(append(in[:n-2:n-2], TypeOf(int64(0))), []Type{intType}, false)
+		if other == ft || other.ConvertibleTo(ft) || !ft.ConvertibleTo(ft) {
+			t.Errorf("%d args: types differing in the last argument are not distinct", n)
+		}
+
+		sum := MakeFunc(ft, func(args []Value) []Value {
+			s := 0
+			for _, a := range args {
+				s += int(a.Int())
+			}
+			return []Value{ValueOf(s)}
+		})
+		args := make([]Value, n-1)
+		want2 := 0
+		for i := range args {
+			args[i] = ValueOf(i)
+			want2 += i
+		}
+		runtime.GC()
+		if got := int(sum.Call(args)[0].Int()); got != want2 {
+			t.Errorf("%d args: sum = %d, want %d", n, got, want2)
+		}
+		for i := 0; i < ft.NumIn(); i++ {
+			if ft.In(i) != intType {
+				t.Errorf("%d args: In(%d) = %v after GC", n, i, ft.In(i))
+				break
+			}
+		}
+	}
+
+	// A type with many arguments that is in the binary is found.
+	var f100 func(int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int,
+		int, int, int, int, int, int, int, int, int, int) bool
+	in := make([]Type, 100)
+	for i := range in {
+		in[i] = intType
+	}
+	checkSameType(t, Zero(FuncOf(in, []Type{TypeOf(false)}, false)).Interface(), f100)
+}
+
 func TestFuncOf(t *testing.T) {
 	// check construction and use of type not in binary
 	type K string
@@ -5653,6 +7623,101 @@ func TestNames(t *testing.T) {
 	}
 }
 
+// kindPredicates lists the predicates of every Kind, so that adding a
+// kind without deciding its predicates fails TestKindPredicates.
+var kindPredicates = []struct {
+	kind                                       Kind
+	integer, unsigned, float, complex, numeric bool
+}{
+	{Invalid, false, false, false, false, false},
+	{Bool, false, false, false, false, false},
+	{Int, true, false, false, false, true},
+	{Int8, true, false, false, false, true},
+	{Int16, true, false, false, false, true},
+	{Int32, true, false, false, false, true},
+	{Int64, true, false, false, false, true},
+	{Uint, true, true, false, false, true},
+	{Uint8, true, true, false, false, true},
+	{Uint16, true, true, false, false, true},
+	{Uint32, true, true, false, false, true},
+	{Uint64, true, true, false, false, true},
+	{Uintptr, true, true, false, false, true},
+	{Float32, false, false, true, false, true},
+	{Float64, false, false, true, false, true},
+	{Complex64, false, false, false, true, true},
+	{Complex128, false, false, false, true, true},
+	{Array, false, false, false, false, false},
+	{Chan, false, false, false, false, false},
+	{Func, false, false, false, false, false},
+	{Interface, false, false, false, false, false},
+	{Map, false, false, false, false, false},
+	{Ptr, false, false, false, false, false},
+	{Slice, false, false, false, false, false},
+	{String, false, false, false, false, false},
+	{Struct, false, false, false, false, false},
+	{UnsafePointer, false, false, false, false, false},
+}
+
+func TestKindPredicates(t *testing.T) {
+	for i, tt := range kindPredicates {
+		k := tt.kind
+		if k != Kind(i) {
+			t.Fatalf("kindPredicates[%d] is %v, want %v", i, k, Kind(i))
+		}
+		if got := k.IsInteger(); got != tt.integer {
+			t.Errorf("%v.IsInteger() = %v, want %v", k, got, tt.integer)
+		}
+		if got := k.IsUnsigned(); got != tt.unsigned {
+			t.Errorf("%v.IsUnsigned() = %v, want %v", k, got, tt.unsigned)
+		}
+		if got := k.IsFloat(); got != tt.float {
+			t.Errorf("%v.IsFloat() = %v, want %v", k, got, tt.float)
+		}
+		if got := k.IsComplex(); got != tt.complex {
+			t.Errorf("%v.IsComplex() = %v, want %v", k, got, tt.complex)
+		}
+		if got := k.IsNumeric(); got != tt.numeric {
+			t.Errorf("%v.IsNumeric() = %v, want %v", k, got, tt.numeric)
+		}
+	}
+	// Every kind with a name must be in the table.
+	if k := Kind(len(kindPredicates)); !strings.HasPrefix(k.String(), "kind") {
+		t.Errorf("kindPredicates has no entry for %v", k)
+	}
+	for _, k := range []Kind{Kind(len(kindPredicates)), 1000} {
+		if k.IsInteger() || k.IsUnsigned() || k.IsFloat() || k.IsComplex() || k.IsNumeric() {
+			t.Errorf("%v has a predicate set", k)
+		}
+	}
+}
+
+func TestValueKindPredicates(t *testing.T) {
+	type myUint uint16
+	type myFloat float64
+	tests := []struct {
+		v                                          interface{}
+		integer, unsigned, float, complex, numeric bool
+	}{
+		{3, true, false, false, false, true},
+		{myUint(3), true, true, false, false, true},
+		{uintptr(3), true, true, false, false, true},
+		{myFloat(3), false, false, true, false, true},
+		{3i, false, false, false, true, true},
+		{"3", false, false, false, false, false},
+		{unsafe.Pointer(nil), false, false, false, false, false},
+		{nil, false, false, false, false, false},
+	}
+	for _, tt := range tests {
+		v := ValueOf(tt.v)
+		if v.IsInteger() != tt.integer || v.IsUnsigned() != tt.unsigned || v.IsFloat() != tt.float ||
+			v.IsComplex() != tt.complex || v.IsNumeric() != tt.numeric {
+			t.Errorf("ValueOf(%#v): predicates %v %v %v %v %v, want %v %v %v %v %v", tt.v,
+				v.IsInteger(), v.IsUnsigned(), v.IsFloat(), v.IsComplex(), v.IsNumeric(),
+				tt.integer, tt.unsigned, tt.float, tt.complex, tt.numeric)
+		}
+	}
+}
+
 func TestExported(t *testing.T) {
 	type ΦExported struct{}
 	type φUnexported struct{}
@@ -5722,3 +7787,205 @@ func TestTypeStrings(t *testing.T) {
 		}
 	}
 }
+
+type forEachElem struct {
+	A, B int64
+	S    string
+}
+
+var forEachSink int64
+
+func BenchmarkIndexLoop(b *testing.B) {
+	b.Run("int", func(b *testing.B) {
+		v := ValueOf(make([]int, 1<<20))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var sum int64
+			for j, n := 0, v.Len(); j < n; j++ {
+				sum += v.Index(j).Int()
+			}
+			forEachSink = sum
+		}
+	})
+	b.Run("struct", func(b *testing.B) {
+		v := ValueOf(make([]forEachElem, 1<<20))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var sum int64
+			for j, n := 0, v.Len(); j < n; j++ {
+				sum += v.Index(j).Field(1).Int()
+			}
+			forEachSink = sum
+		}
+	})
+}
+
+func BenchmarkForEach(b *testing.B) {
+	b.Run("int", func(b *testing.B) {
+		v := ValueOf(make([]int, 1<<20))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var sum int64
+			v.ForEach(func(_ int, elem Value) bool {
+				sum += elem.Int()
+				return true
+			})
+			forEachSink = sum
+		}
+	})
+	b.Run("struct", func(b *testing.B) {
+		v := ValueOf(make([]forEachElem, 1<<20))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var sum int64
+			v.ForEach(func(_ int, elem Value) bool {
+				sum += elem.Field(1).Int()
+				return true
+			})
+			forEachSink = sum
+		}
+	})
+}
+
+func BenchmarkMapKeysIndex(b *testing.B) {
+	m := make(map[int]int)
+	for i := 0; i < 1<<12; i++ {
+		m[i] = i
+	}
+	v := ValueOf(m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum int64
+		for _, k := range v.MapKeys() {
+			sum += v.MapIndex(k).Int()
+		}
+		forEachSink = sum
+	}
+}
+
+func BenchmarkMapKeys(b *testing.B) {
+	m := make(map[int]int)
+	for i := 0; i < 1<<16; i++ {
+		m[i] = i
+	}
+	v := ValueOf(m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum int64
+		for _, k := range v.MapKeys() {
+			sum += k.Int()
+		}
+		forEachSink = sum
+	}
+}
+
+func BenchmarkMapRange(b *testing.B) {
+	m := make(map[int]int)
+	for i := 0; i < 1<<16; i++ {
+		m[i] = i
+	}
+	v := ValueOf(m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum int64
+		for iter := v.MapRange(); iter.Next(); {
+			sum += iter.Key().Int()
+		}
+		forEachSink = sum
+	}
+}
+
+func BenchmarkForEachMap(b *testing.B) {
+	m := make(map[int]int)
+	for i := 0; i < 1<<12; i++ {
+		m[i] = i
+	}
+	v := ValueOf(m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum int64
+		v.ForEachMap(func(key, val Value) bool {
+			sum += val.Int()
+			return true
+		})
+		forEachSink = sum
+	}
+}
+
+func TestIsRuntimeConstructed(t *testing.T) {
+	compiled := []Type{
+		TypeOf(0),
+		TypeOf(struct{ A int }{}),
+		PtrTo(TypeOf(0)),
+		SliceOf(TypeOf("")),
+		MapOf(TypeOf(""), TypeOf(0)),
+		FuncOf([]Type{TypeOf(0)}, []Type{TypeOf("")}, false), // func(int) string, from strconv.Itoa
+		StructOf([]StructField{{Name: "A", Type: TypeOf(0)}}),
+	}
+	for _, typ := range compiled {
+		if typ.IsRuntimeConstructed() {
+			t.Errorf("%v.IsRuntimeConstructed() = true for a compiled type", typ)
+		}
+		if stack := ConstructionStack(typ); stack != nil {
+			t.Errorf("ConstructionStack(%v) = %v for a compiled type, want nil", typ, stack)
+		}
+	}
+
+	st := StructOf([]StructField{{Name: "IsRuntimeConstructed", Type: TypeOf(0)}})
+	constructed := []Type{
+		st,
+		PtrTo(st),
+		SliceOf(st),
+		ArrayOf(3, st),
+		ChanOf(BothDir, st),
+		MapOf(TypeOf(""), st),
+		FuncOf([]Type{st}, nil, false),
+		NamedOf("Constructed", "reflect_test", TypeOf(0)),
+	}
+	for _, typ := range constructed {
+		if !typ.IsRuntimeConstructed() {
+			t.Errorf("%v.IsRuntimeConstructed() = false for a type built at run time", typ)
+		}
+	}
+}
+
+func TestConstructionStack(t *testing.T) {
+	if os.Getenv("GO_REFLECTSTACKS_TEST") == "" {
+		testenv.MustHaveExec(t)
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConstructionStack$")
+		cmd.Env = []string{"GO_REFLECTSTACKS_TEST=1", "GODEBUG=reflectstacks=1"}
+		for _, env := range os.Environ() {
+			if !strings.HasPrefix(env, "GODEBUG=") {
+				cmd.Env = append(cmd.Env, env)
+			}
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("with GODEBUG=reflectstacks=1: %v\n%s", err, out)
+		}
+		return
+	}
+
+	_, file, line, _ := runtime.Caller(0)
+	typ := StructOf([]StructField{{Name: "ConstructionStack", Type: TypeOf(0)}})
+	stack := ConstructionStack(typ)
+	if len(stack) == 0 {
+		t.Fatalf("ConstructionStack(%v) is empty", typ)
+	}
+	frame, _ := runtime.CallersFrames(stack).Next()
+	if frame.Function != "reflect_test.TestConstructionStack" || frame.File != file || frame.Line != line+1 {
+		t.Errorf("ConstructionStack(%v) starts at %s %s:%d, want the call of StructOf at %s:%d", typ, frame.Function, frame.File, frame.Line, file, line+1)
+	}
+
+	// The frames of package reflect are left out of the stack of a type
+	// built by a function that another calls.
+	_, _, line, _ = runtime.Caller(0)
+	ptr := PtrTo(typ)
+	frame, _ = runtime.CallersFrames(ConstructionStack(ptr)).Next()
+	if frame.Function != "reflect_test.TestConstructionStack" || frame.Line != line+1 {
+		t.Errorf("ConstructionStack(%v) starts at %s:%d, want the call of PtrTo at line %d", ptr, frame.Function, frame.Line, line+1)
+	}
+
+	if stack := ConstructionStack(TypeOf(0)); stack != nil {
+		t.Errorf("ConstructionStack(int) = %v, want nil", stack)
+	}
+}
@@ -28,3 +28,25 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$16
 	MOVQ	CX, 8(SP)
 	CALL	·callMethod(SB)
 	RET
+
+// methodStubs holds the table of methodStubCount method stubs described
+// in makefunc.go, followed by the code they call, which finds the stub
+// from its return address and calls the func value for it.
+
+// Stub i is a CALL to the code after the table (0xe8 and the offset
+// from the next instruction), padded to 8 bytes. The assembler does not
+// accept a CALL to a label.
+#define STUB(i)		BYTE $0xe8; LONG $(256*8-(i)*8-5); BYTE $0x90; BYTE $0x90; BYTE $0x90
+#define STUB4(i)	STUB((i)*4); STUB((i)*4+1); STUB((i)*4+2); STUB((i)*4+3)
+#define STUB16(i)	STUB4((i)*4); STUB4((i)*4+1); STUB4((i)*4+2); STUB4((i)*4+3)
+#define STUB64(i)	STUB16((i)*4); STUB16((i)*4+1); STUB16((i)*4+2); STUB16((i)*4+3)
+TEXT ·methodStubs(SB),NOSPLIT,$0-0
+	STUB64(0); STUB64(1); STUB64(2); STUB64(3)
+	// The return address is 5 bytes into the stub, at 8*i.
+	POPQ	AX
+	LEAQ	·methodStubs+5(SB), BX
+	SUBQ	BX, AX
+	LEAQ	·methodStubFuncs(SB), DX
+	MOVQ	(DX)(AX*1), DX
+	MOVQ	0(DX), BX
+	JMP	BX
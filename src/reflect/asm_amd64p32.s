@@ -28,3 +28,8 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$8
 	MOVL	CX, 4(SP)
 	CALL	·callMethod(SB)
 	RET
+
+
+// There are no method stubs on nacl (see methodStubSize).
+TEXT ·methodStubs(SB),NOSPLIT,$0-0
+	RET
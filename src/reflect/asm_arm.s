@@ -28,3 +28,26 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$8
 	MOVW	R1, 8(R13)
 	BL	·callMethod(SB)
 	RET
+
+// methodStubs holds the table of methodStubCount method stubs described
+// in makefunc.go, followed by the code they call, which finds the stub
+// from its return address and calls the func value for it.
+
+// Stub i saves the caller's LR in R2 and calls the code after the table.
+#define STUB	MOVW	R14, R2; BL	call
+#define STUB4	STUB; STUB; STUB; STUB
+#define STUB16	STUB4; STUB4; STUB4; STUB4
+#define STUB64	STUB16; STUB16; STUB16; STUB16
+TEXT ·methodStubs(SB),NOSPLIT,$-4-0
+	STUB64; STUB64; STUB64; STUB64
+call:
+	// The return address is the end of the stub, at 8*i+8.
+	MOVW	$·methodStubs+8(SB), R1
+	SUB	R1, R14, R1
+	MOVW	R2, R14
+	MOVW	R1>>1, R1
+	MOVW	$·methodStubFuncs(SB), R7
+	ADD	R1, R7
+	MOVW	0(R7), R7
+	MOVW	0(R7), R3
+	B	(R3)
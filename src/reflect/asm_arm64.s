@@ -28,3 +28,25 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$24
 	MOVD	R3, 16(RSP)
 	BL	·callMethod(SB)
 	RET
+
+// methodStubs holds the table of methodStubCount method stubs described
+// in makefunc.go, followed by the code they call, which finds the stub
+// from its return address and calls the func value for it.
+
+// Stub i saves the caller's LR in R4 and calls the code after the table.
+#define STUB	MOVD	R30, R4; BL	call
+#define STUB4	STUB; STUB; STUB; STUB
+#define STUB16	STUB4; STUB4; STUB4; STUB4
+#define STUB64	STUB16; STUB16; STUB16; STUB16
+TEXT ·methodStubs(SB),NOSPLIT,$-8-0
+	STUB64; STUB64; STUB64; STUB64
+call:
+	// The return address is the end of the stub, at 8*i+8.
+	MOVD	$·methodStubs+8(SB), R3
+	SUB	R3, R30, R3
+	MOVD	R4, R30
+	MOVD	$·methodStubFuncs(SB), R26
+	ADD	R3, R26
+	MOVD	0(R26), R26
+	MOVD	0(R26), R3
+	B	(R3)
@@ -32,3 +32,27 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$16
 	MOVV	R1, 16(R29)
 	JAL	·callMethod(SB)
 	RET
+
+// methodStubs holds the table of methodStubCount method stubs described
+// in makefunc.go, followed by the code they call, which finds the stub
+// from its return address and calls the func value for it.
+
+// Stub i saves the caller's LR in R4 and calls the code after the table.
+// The JAL is followed by a NOP in its delay slot and a padding word.
+#define STUB	MOVV	R31, R4; JAL	call; WORD	$0
+#define STUB4	STUB; STUB; STUB; STUB
+#define STUB16	STUB4; STUB4; STUB4; STUB4
+#define STUB64	STUB16; STUB16; STUB16; STUB16
+TEXT ·methodStubs(SB),NOSPLIT,$-8-0
+	STUB64; STUB64; STUB64; STUB64
+call:
+	// The return address is 12 bytes into the stub, at 16*i.
+	MOVV	$·methodStubs+12(SB), R3
+	SUBVU	R3, R31, R3
+	MOVV	R4, R31
+	SRLV	$1, R3
+	MOVV	$·methodStubFuncs(SB), REGCTXT
+	ADDVU	R3, REGCTXT
+	MOVV	0(REGCTXT), REGCTXT
+	MOVV	0(REGCTXT), R5
+	JMP	(R5)
@@ -31,3 +31,27 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$16
 	MOVD	R3, FIXED_FRAME+8(R1)
 	BL	·callMethod(SB)
 	RET
+
+// methodStubs holds the table of methodStubCount method stubs described
+// in makefunc.go, followed by the code they call, which finds the stub
+// from its return address and calls the func value for it.
+
+// Stub i saves the caller's LR in R4 and calls the code after the table.
+#define STUB	MOVD	LR, R4; BL	call
+#define STUB4	STUB; STUB; STUB; STUB
+#define STUB16	STUB4; STUB4; STUB4; STUB4
+#define STUB64	STUB16; STUB16; STUB16; STUB16
+TEXT ·methodStubs(SB),NOSPLIT|NOFRAME,$0-0
+	STUB64; STUB64; STUB64; STUB64
+call:
+	// The return address is the end of the stub, at 8*i+8.
+	MOVD	LR, R3
+	MOVD	R4, LR
+	MOVD	$·methodStubs+8(SB), R11
+	SUB	R11, R3
+	MOVD	$·methodStubFuncs(SB), R11
+	ADD	R3, R11
+	MOVD	0(R11), R11
+	MOVD	0(R11), R12
+	MOVD	R12, CTR
+	BR	(CTR)
@@ -28,3 +28,27 @@ TEXT ·methodValueCall(SB),(NOSPLIT|WRAPPER),$16
 	MOVD	R3, 16(R15)
 	BL	·callMethod(SB)
 	RET
+
+// methodStubs holds the table of methodStubCount method stubs described
+// in makefunc.go, followed by the code they call, which finds the stub
+// from its return address and calls the func value for it.
+
+// Stub i saves the caller's LR in R4 and calls the code after the
+// table. The BL is padded to 16 bytes.
+#define STUB	MOVD	R14, R4; BL	call; WORD	$0x07000700; BYTE	$0x07; BYTE	$0x00
+#define STUB4	STUB; STUB; STUB; STUB
+#define STUB16	STUB4; STUB4; STUB4; STUB4
+#define STUB64	STUB16; STUB16; STUB16; STUB16
+TEXT ·methodStubs(SB),NOSPLIT|NOFRAME,$0-0
+	STUB64; STUB64; STUB64; STUB64
+call:
+	// The return address is 10 bytes into the stub, at 16*i.
+	MOVD	$·methodStubs+10(SB), R3
+	SUB	R3, R14, R3
+	MOVD	R4, R14
+	SRD	$1, R3
+	MOVD	$·methodStubFuncs(SB), R12
+	ADD	R3, R12
+	MOVD	0(R12), R12
+	MOVD	0(R12), R5
+	BR	(R5)
@@ -0,0 +1,122 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflect
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// A readMap is a concurrent map for the type caches, which are read by
+// every call of functions like PtrTo and FuncOf but only written the
+// first time a type is looked up. Keys are never deleted.
+//
+// Loads of keys in the read-only part of the map take no locks. Keys
+// stored since the read-only map was last replaced are kept in a dirty
+// map, guarded by mu, which holds all the keys of the map. Once loads
+// have missed the read-only map as many times as the dirty map has
+// keys, the dirty map becomes the new read-only map, so that the cost
+// of copying the keys into the next dirty map is paid for by the locked
+// loads it saves.
+//
+// A value stored for a key that already has one replaces it atomically,
+// so that it is seen by loads through either map.
+type readMap struct {
+	read atomic.Value // map[interface{}]*readMapEntry, never modified once stored
+
+	mu     sync.Mutex
+	dirty  map[interface{}]*readMapEntry // nil if it has no keys that read does not
+	misses int                           // loads that locked mu since dirty was last promoted
+}
+
+type readMapEntry struct {
+	p unsafe.Pointer // the value, loaded and stored atomically
+}
+
+func (m *readMap) loadReadOnly() map[interface{}]*readMapEntry {
+	read, _ := m.read.Load().(map[interface{}]*readMapEntry)
+	return read
+}
+
+// Load returns the value stored for key, or nil if there is none.
+func (m *readMap) Load(key interface{}) unsafe.Pointer {
+	e := m.loadReadOnly()[key]
+	if e == nil {
+		m.mu.Lock()
+		// The dirty map may have been promoted while we were
+		// waiting for the lock.
+		e = m.loadReadOnly()[key]
+		if e == nil && m.dirty != nil {
+			e = m.dirty[key]
+			m.missLocked()
+		}
+		m.mu.Unlock()
+		if e == nil {
+			return nil
+		}
+	}
+	return atomic.LoadPointer(&e.p)
+}
+
+// LoadOrStore returns the value stored for key, if any.
+// Otherwise it stores p for key and returns p.
+func (m *readMap) LoadOrStore(key interface{}, p unsafe.Pointer) unsafe.Pointer {
+	if e := m.loadReadOnly()[key]; e != nil {
+		return atomic.LoadPointer(&e.p)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e := m.entryLocked(key); e != nil {
+		return atomic.LoadPointer(&e.p)
+	}
+	m.addLocked(key, p)
+	return p
+}
+
+// Store sets the value for key to p.
+func (m *readMap) Store(key interface{}, p unsafe.Pointer) {
+	if e := m.loadReadOnly()[key]; e != nil {
+		atomic.StorePointer(&e.p, p)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e := m.entryLocked(key); e != nil {
+		atomic.StorePointer(&e.p, p)
+		return
+	}
+	m.addLocked(key, p)
+}
+
+// entryLocked returns the entry for key in either map, or nil.
+func (m *readMap) entryLocked(key interface{}) *readMapEntry {
+	if e := m.loadReadOnly()[key]; e != nil {
+		return e
+	}
+	return m.dirty[key]
+}
+
+// addLocked adds key, which is in neither map, to the dirty map.
+func (m *readMap) addLocked(key interface{}, p unsafe.Pointer) {
+	if m.dirty == nil {
+		read := m.loadReadOnly()
+		m.dirty = make(map[interface{}]*readMapEntry, len(read)+1)
+		for k, e := range read {
+			m.dirty[k] = e
+		}
+	}
+	m.dirty[key] = &readMapEntry{p: p}
+}
+
+func (m *readMap) missLocked() {
+	m.misses++
+	if m.misses < len(m.dirty) {
+		return
+	}
+	m.read.Store(m.dirty)
+	m.dirty = nil
+	m.misses = 0
+}
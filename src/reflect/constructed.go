@@ -0,0 +1,78 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflect
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// A construction records how a type was built at run time by one of the
+// functions like StructOf, rather than by the compiler.
+type construction struct {
+	stack []uintptr // where the type was built, with GODEBUG=reflectstacks=1
+}
+
+// constructed holds the types built at run time. Recording a type costs
+// one insertion; its stack is recorded only with GODEBUG=reflectstacks=1.
+var constructed readMap // map[*rtype]*construction
+
+// noStack is the construction of every type built without a stack.
+var noStack construction
+
+// constructionStacks reports whether GODEBUG has reflectstacks=1.
+// It is implemented in runtime.
+func constructionStacks() bool
+
+// addConstructed records that t was built at run time. It is called
+// before t is stored in a cache, so that no caller can see t before it
+// is recorded. A type built by a goroutine that loses the race to store
+// it in a cache is never returned, but it stays recorded all the same.
+func addConstructed(t *rtype) {
+	c := &noStack
+	if constructionStacks() {
+		c = &construction{stack: constructionCallers()}
+	}
+	constructed.Store(t, unsafe.Pointer(c))
+}
+
+// constructionCallers returns the stack of calls that led to addConstructed,
+// without the frames of package reflect, so that it starts with the call
+// of StructOf or the like.
+func constructionCallers() []uintptr {
+	pc := make([]uintptr, 32)
+	pc = pc[:runtime.Callers(3, pc)] // Skip runtime.Callers, constructionCallers and addConstructed.
+	for len(pc) > 0 {
+		f := runtime.FuncForPC(pc[0] - 1)
+		if f == nil || !hasPrefix(f.Name(), "reflect.") {
+			break
+		}
+		pc = pc[1:]
+	}
+	return pc
+}
+
+func (t *rtype) IsRuntimeConstructed() bool {
+	return constructed.Load(t) != nil
+}
+
+// ConstructionStack returns the program counters of the stack of calls
+// that built t, starting with the call of the function of package
+// reflect, such as StructOf, ArrayOf, FuncOf or MapOf, that built it.
+// Like the result of runtime.Callers, they can be turned into functions
+// and lines with runtime.CallersFrames.
+//
+// The stacks are recorded only while the GODEBUG environment variable
+// has reflectstacks=1; see the runtime package. ConstructionStack
+// returns nil for the types that were built without it and for the
+// types compiled into the program, for which t.IsRuntimeConstructed
+// reports false.
+func ConstructionStack(t Type) []uintptr {
+	p := constructed.Load(t.common())
+	if p == nil {
+		return nil
+	}
+	return append([]uintptr(nil), (*construction)(p).stack...)
+}
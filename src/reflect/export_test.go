@@ -109,3 +109,51 @@ func IsExported(t Type) bool {
 	n := typ.nameOff(typ.str)
 	return n.isExported()
 }
+
+// StrPkgPath returns the import path recorded in the name of t's string.
+func StrPkgPath(t Type) string {
+	typ := t.(*rtype)
+	return typ.nameOff(typ.str).pkgPath()
+}
+
+// FingerprintAnon is an unnamed struct type declared in package reflect.
+// It has the same structure as a type declared in package reflect_test.
+var FingerprintAnon = TypeOf(struct {
+	x int
+	Y string `json:"y"`
+}{})
+
+// TypeEncoding returns the encoding of t hashed by TypeFingerprint.
+func TypeEncoding(t Type) []byte {
+	e := typeEncoder{seen: make(map[Type]uint64)}
+	e.typ(t)
+	return e.buf
+}
+
+const MethodCacheSize = methodCacheSize
+
+// MethodCacheLen returns the number of types in the methodCache.
+func MethodCacheLen() int {
+	n := 0
+	for i := range methodCache {
+		n += len(methodCache[i].load())
+	}
+	return n
+}
+
+const MethodStubCount = methodStubCount
+
+// MethodStubCode returns the code address of method stub i.
+func MethodStubCode(i int) uintptr {
+	return uintptr(methodStubCode(i))
+}
+
+// SetMethodStubsUsed sets the number of method stubs in use to n and
+// returns the previous number.
+func SetMethodStubsUsed(n int) int {
+	methodStubLock.Lock()
+	defer methodStubLock.Unlock()
+	old := methodStubLock.used
+	methodStubLock.used = n
+	return old
+}
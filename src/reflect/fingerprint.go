@@ -0,0 +1,179 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflect
+
+import "internal/sha256block"
+
+// TypeFingerprint returns a structural hash of t: the SHA-256 digest of a
+// canonical encoding of t and of every type reachable from it.
+// Unlike a Type value, which identifies a type only within one process,
+// the fingerprint is stable across processes and program versions, so
+// it can key caches and schema registries that outlive a process.
+// Two types have the same fingerprint if they have the same structure
+// and names as described below, even if they are distinct Go types,
+// such as identical struct literal types declared in different packages.
+// Because sizes and field offsets are part of the encoding, fingerprints
+// are stable only across programs built for the same word size.
+//
+// The encoding is a sequence of unsigned varints (as written by
+// encoding/binary.PutUvarint) and strings, each string being written as
+// the varint length in bytes followed by the bytes. Types are numbered
+// 0, 1, 2, ... in the order the encoding first reaches them. A type that
+// has already been numbered is written as the varint 0 followed by the
+// varint of its number, which makes recursive types finite. Any other
+// type is written as
+//
+//	varint(1 + Kind) varint(Size)
+//
+// followed by, if the type is named (Name is not empty), the varint 1
+// and the strings PkgPath and Name, or else the varint 0, followed by
+// the kind-specific part:
+//
+//	Array:         varint(Len) elem
+//	Chan:          varint(ChanDir) elem
+//	Func:          varint(NumIn) in... varint(NumOut) out... varint(IsVariadic ? 1 : 0)
+//	Interface:     varint(NumMethod) then for each method: string(Name) string(PkgPath) type
+//	Map:           key elem
+//	Ptr, Slice:    elem
+//	Struct:        varint(NumField) then for each field:
+//	               string(Name) string(Tag) varint(Offset) varint(Anonymous ? 1 : 0) type
+//
+// Other kinds have no kind-specific part. The package paths of
+// unexported struct fields are not encoded, and neither are the methods
+// of non-interface types.
+func TypeFingerprint(t Type) [32]byte {
+	if t == nil {
+		panic("reflect: TypeFingerprint of nil Type")
+	}
+	e := typeEncoder{seen: make(map[Type]uint64)}
+	e.typ(t)
+	return sha256Sum(e.buf)
+}
+
+// typeEncoder builds the canonical encoding hashed by TypeFingerprint.
+type typeEncoder struct {
+	buf  []byte
+	seen map[Type]uint64 // type numbers
+}
+
+func (e *typeEncoder) uvarint(x uint64) {
+	for x >= 0x80 {
+		e.buf = append(e.buf, byte(x)|0x80)
+		x >>= 7
+	}
+	e.buf = append(e.buf, byte(x))
+}
+
+func (e *typeEncoder) bool(b bool) {
+	if b {
+		e.uvarint(1)
+	} else {
+		e.uvarint(0)
+	}
+}
+
+func (e *typeEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *typeEncoder) typ(t Type) {
+	if n, ok := e.seen[t]; ok {
+		e.uvarint(0)
+		e.uvarint(n)
+		return
+	}
+	e.seen[t] = uint64(len(e.seen))
+
+	k := t.Kind()
+	e.uvarint(1 + uint64(k))
+	e.uvarint(uint64(t.Size()))
+	if name := t.Name(); name != "" {
+		e.uvarint(1)
+		e.string(t.PkgPath())
+		e.string(name)
+	} else {
+		e.uvarint(0)
+	}
+
+	switch k {
+	case Array:
+		e.uvarint(uint64(t.Len()))
+		e.typ(t.Elem())
+	case Chan:
+		e.uvarint(uint64(t.ChanDir()))
+		e.typ(t.Elem())
+	case Func:
+		e.uvarint(uint64(t.NumIn()))
+		for i := 0; i < t.NumIn(); i++ {
+			e.typ(t.In(i))
+		}
+		e.uvarint(uint64(t.NumOut()))
+		for i := 0; i < t.NumOut(); i++ {
+			e.typ(t.Out(i))
+		}
+		e.bool(t.IsVariadic())
+	case Interface:
+		e.uvarint(uint64(t.NumMethod()))
+		for i := 0; i < t.NumMethod(); i++ {
+			m := t.Method(i)
+			e.string(m.Name)
+			e.string(m.PkgPath)
+			e.typ(m.Type)
+		}
+	case Map:
+		e.typ(t.Key())
+		e.typ(t.Elem())
+	case Ptr, Slice:
+		e.typ(t.Elem())
+	case Struct:
+		e.uvarint(uint64(t.NumField()))
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			e.string(f.Name)
+			e.string(string(f.Tag))
+			e.uvarint(uint64(f.Offset))
+			e.bool(f.Anonymous)
+			e.typ(f.Type)
+		}
+	}
+}
+
+// sha256Sum returns the SHA-256 digest of data.
+// The reflect package cannot import crypto/sha256,
+// so this pads data and runs its block step itself.
+func sha256Sum(data []byte) [32]byte {
+	h := [8]uint32{
+		0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+		0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+	}
+
+	// Pad with a 1 bit, zeros, and the length in bits,
+	// to a multiple of the 64-byte block size.
+	n := uint64(len(data))
+	msg := make([]byte, 0, (n+8)/64*64+64)
+	msg = append(msg, data...)
+	msg = append(msg, 0x80)
+	for len(msg)%64 != 56 {
+		msg = append(msg, 0)
+	}
+	for i := uint(56); ; i -= 8 {
+		msg = append(msg, byte(n*8>>i))
+		if i == 0 {
+			break
+		}
+	}
+
+	sha256block.Block(&h, msg)
+
+	var sum [32]byte
+	for i, v := range h {
+		sum[i*4] = byte(v >> 24)
+		sum[i*4+1] = byte(v >> 16)
+		sum[i*4+2] = byte(v >> 8)
+		sum[i*4+3] = byte(v)
+	}
+	return sum
+}
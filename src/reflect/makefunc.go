@@ -7,6 +7,7 @@
 package reflect
 
 import (
+	"runtime"
 	"unsafe"
 )
 
@@ -127,3 +128,61 @@ func makeMethodValue(op string, v Value) Value {
 // where ctxt is the context register and frame is a pointer to the first
 // word in the passed-in argument frame.
 func methodValueCall()
+
+// The method stubs are the code of the methods of the types built by
+// StructOfWithMethods and StructOf. A method is called through an
+// interface without a closure context, so each method needs its own
+// code address. Like the function returned by MakeFunc, each method is
+// implemented by a func value: method stub i calls the code that
+// follows the stubs, which finds i from its return address, drops the return address,
+// and jumps to the code of methodStubFuncs[i] with it in the closure
+// context register, as if the caller of the method had called the
+// func value directly. The stubs have no frame, so the callee sees the
+// caller's arguments.
+//
+// As for runtime·duffzero, the stubs are the entries of a single
+// function, methodStubs, each methodStubSize() bytes long.
+
+// methodStubCount is the number of method stubs, the limit documented
+// for StructOfWithMethods. Changing it requires changing asm_*.s.
+const methodStubCount = 256
+
+// methodStubFuncs holds the func values called by the method stubs.
+// Elements are set once and never cleared, since the types whose
+// methods use them are never freed.
+var methodStubFuncs [methodStubCount]unsafe.Pointer
+
+func methodStubs()
+
+// methodStubSize returns the size in bytes of the code of a method
+// stub, or 0 if there are no method stubs for this system.
+// It must match the STUB macro in asm_*.s.
+func methodStubSize() uintptr {
+	if runtime.GOOS == "nacl" {
+		return 0
+	}
+	switch runtime.GOARCH {
+	case "386", "amd64":
+		return 8 // CALL, padding
+	case "ppc64", "ppc64le":
+		// In position-independent code each function starts by
+		// loading the TOC pointer from its own address (addis r2,r12),
+		// which would be wrong in every stub but the first.
+		if *(*uint32)(funcCode(methodStubs))>>16 == 0x3c4c {
+			return 0
+		}
+		return 8 // save LR, BL
+	case "arm", "arm64":
+		return 8 // save LR, BL
+	case "mips64", "mips64le":
+		return 16 // save LR, JAL, NOP, padding
+	case "s390x":
+		return 16 // save LR, BRASL, padding
+	}
+	return 0
+}
+
+// methodStubCode returns the code address of method stub i.
+func methodStubCode(i int) unsafe.Pointer {
+	return add(funcCode(methodStubs), uintptr(i)*methodStubSize())
+}
@@ -16,9 +16,12 @@
 package reflect
 
 import (
+	"errors"
 	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"unicode"
 	"unsafe"
 )
 
@@ -99,6 +102,15 @@ type Type interface {
 	// Comparable reports whether values of this type are comparable.
 	Comparable() bool
 
+	// IsRuntimeConstructed reports whether the type was built at run
+	// time by a function of this package such as StructOf, ArrayOf,
+	// FuncOf, MapOf or PtrTo, rather than compiled into the program.
+	// Except for NamedOf and StructOfWithMethods, those functions
+	// return the type compiled into the program if it has an identical
+	// one, and build a type only if not. ConstructionStack reports
+	// where a type was built.
+	IsRuntimeConstructed() bool
+
 	// Methods applicable only to some types, depending on Kind.
 	// The methods allowed for each kind are:
 	//
@@ -522,6 +534,7 @@ func newName(n, tag, pkgPath string, exported bool) name {
 		bits |= 1 << 1
 	}
 	if pkgPath != "" {
+		l += 4
 		bits |= 1 << 2
 	}
 
@@ -538,7 +551,10 @@ func newName(n, tag, pkgPath string, exported bool) name {
 	}
 
 	if pkgPath != "" {
-		panic("reflect: creating a name with a package path is not supported")
+		// The import path is a name of its own, referred to by the
+		// nameOff in the last 4 bytes.
+		off := resolveReflectName(newName(pkgPath, "", "", false))
+		copy(b[l-4:], (*[4]byte)(unsafe.Pointer(&off))[:])
 	}
 
 	return name{bytes: &b[0]}
@@ -609,6 +625,35 @@ var kindNames = []string{
 	UnsafePointer: "unsafe.Pointer",
 }
 
+// IsInteger reports whether k is a signed or unsigned integer kind:
+// Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64
+// or Uintptr.
+func (k Kind) IsInteger() bool {
+	return Int <= k && k <= Uintptr
+}
+
+// IsUnsigned reports whether k is an unsigned integer kind:
+// Uint, Uint8, Uint16, Uint32, Uint64 or Uintptr.
+func (k Kind) IsUnsigned() bool {
+	return Uint <= k && k <= Uintptr
+}
+
+// IsFloat reports whether k is a floating-point kind: Float32 or Float64.
+func (k Kind) IsFloat() bool {
+	return k == Float32 || k == Float64
+}
+
+// IsComplex reports whether k is a complex kind: Complex64 or Complex128.
+func (k Kind) IsComplex() bool {
+	return k == Complex64 || k == Complex128
+}
+
+// IsNumeric reports whether k is an integer, floating-point or complex
+// kind. UnsafePointer is not numeric, although Uintptr is.
+func (k Kind) IsNumeric() bool {
+	return Int <= k && k <= Complex128
+}
+
 func (t *uncommonType) methods() []method {
 	return (*[1 << 16]method)(add(unsafe.Pointer(t), uintptr(t.moff)))[:t.mcount:t.mcount]
 }
@@ -763,17 +808,107 @@ func (t *rtype) pointers() bool { return t.kind&kindNoPointers == 0 }
 
 func (t *rtype) common() *rtype { return t }
 
-var methodCache struct {
-	sync.RWMutex
-	m map[*rtype][]method
+// The methodCache caches the exported methods of types.
+//
+// Types built at run time by StructOf and friends are never freed while
+// the cache refers to them, so the cache holds at most methodCacheSize
+// entries. It is sharded by type, and each shard publishes its entries
+// as an immutable map, so that lookups take no locks. Adding an entry
+// copies the shard's map, which the large number of shards keeps small.
+// When a shard is full, adding an entry evicts one that has not been
+// looked up since the last eviction passed over it.
+var methodCache [methodCacheShards]methodCacheShard
+
+const (
+	methodCacheShards = 256
+	methodCacheSize   = 4096 // total entries, split evenly between the shards
+)
+
+// A methodCacheShard is a bounded cache of exported methods.
+type methodCacheShard struct {
+	mu      sync.Mutex   // serializes writers
+	entries atomic.Value // map[*rtype]*methodCacheEntry, never modified once stored
 }
 
-func (t *rtype) exportedMethods() []method {
-	methodCache.RLock()
-	methods, found := methodCache.m[t]
-	methodCache.RUnlock()
+type methodCacheEntry struct {
+	methods []method
+	used    uint32 // set by lookups, cleared by evictions that pass over the entry
+}
+
+// methodCacheShardOf returns the shard of the methodCache that holds t.
+func methodCacheShardOf(t *rtype) *methodCacheShard {
+	h := uintptr(unsafe.Pointer(t))
+	h ^= h >> 16
+	h *= 0x9e3779b1
+	return &methodCache[(h>>8)%methodCacheShards]
+}
 
-	if found {
+func (c *methodCacheShard) load() map[*rtype]*methodCacheEntry {
+	m, _ := c.entries.Load().(map[*rtype]*methodCacheEntry)
+	return m
+}
+
+// get returns the cached methods of t and marks them as used.
+// It takes no locks.
+func (c *methodCacheShard) get(t *rtype) ([]method, bool) {
+	e := c.load()[t]
+	if e == nil {
+		return nil, false
+	}
+	// Avoid writing to the entry's cache line on every lookup.
+	if atomic.LoadUint32(&e.used) == 0 {
+		atomic.StoreUint32(&e.used, 1)
+	}
+	return e.methods, true
+}
+
+// put caches methods as the methods of t, evicting an entry if the
+// shard is full. If another goroutine cached the methods of t first,
+// put returns those instead, so that all callers see the same slice.
+func (c *methodCacheShard) put(t *rtype, methods []method) []method {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.load()
+	if e := old[t]; e != nil {
+		return e.methods
+	}
+	var victim *rtype
+	if len(old) >= methodCacheSize/methodCacheShards {
+		victim = methodCacheVictim(old)
+	}
+	m := make(map[*rtype]*methodCacheEntry, len(old)+1)
+	for k, e := range old {
+		if k != victim {
+			m[k] = e
+		}
+	}
+	m[t] = &methodCacheEntry{methods: methods, used: 1}
+	c.entries.Store(m)
+	return methods
+}
+
+// methodCacheVictim returns the type of the entry of m to evict: the
+// first, in map order, that is not marked as used. The marks of the
+// entries before it are cleared, so that they are evicted next time
+// unless they are used meanwhile. If all are marked, it returns the
+// first.
+func methodCacheVictim(m map[*rtype]*methodCacheEntry) *rtype {
+	var first *rtype
+	for t, e := range m {
+		if first == nil {
+			first = t
+		}
+		if atomic.LoadUint32(&e.used) == 0 {
+			return t
+		}
+		atomic.StoreUint32(&e.used, 0)
+	}
+	return first
+}
+
+func (t *rtype) exportedMethods() []method {
+	c := methodCacheShardOf(t)
+	if methods, found := c.get(t); found {
 		return methods
 	}
 
@@ -782,6 +917,7 @@ func (t *rtype) exportedMethods() []method {
 		return nil
 	}
 	allm := ut.methods()
+	var methods []method
 	allExported := true
 	for _, m := range allm {
 		name := t.nameOff(m.name)
@@ -803,14 +939,7 @@ func (t *rtype) exportedMethods() []method {
 		methods = methods[:len(methods):len(methods)]
 	}
 
-	methodCache.Lock()
-	if methodCache.m == nil {
-		methodCache.m = make(map[*rtype][]method)
-	}
-	methodCache.m[t] = methods
-	methodCache.Unlock()
-
-	return methods
+	return c.put(t, methods)
 }
 
 func (t *rtype) NumMethod() int {
@@ -848,8 +977,19 @@ func (t *rtype) Method(i int) (m Method) {
 	mt := FuncOf(in, out, ft.IsVariadic())
 	m.Type = mt
 	tfn := t.textOff(p.tfn)
-	fn := unsafe.Pointer(&tfn)
-	m.Func = Value{mt.(*rtype), fn, fl}
+	if tfn == funcCode(methodTfn) {
+		// A method built by StructOf or StructOfWithMethods is called
+		// through the receiver.
+		m.Func = MakeFunc(mt, func(args []Value) []Value {
+			if ft.IsVariadic() {
+				return args[0].Method(i).CallSlice(args[1:])
+			}
+			return args[0].Method(i).Call(args[1:])
+		})
+	} else {
+		fn := unsafe.Pointer(&tfn)
+		m.Func = Value{mt.(*rtype), fn, fl}
+	}
 
 	m.Index = i
 	return m
@@ -1416,10 +1556,7 @@ func TypeOf(i interface{}) Type {
 }
 
 // ptrMap is the cache for PtrTo.
-var ptrMap struct {
-	sync.RWMutex
-	m map[*rtype]*ptrType
-}
+var ptrMap readMap // map[*rtype]*ptrType
 
 // PtrTo returns the pointer type with element t.
 // For example, if t represents type Foo, PtrTo(t) represents *Foo.
@@ -1429,40 +1566,22 @@ func PtrTo(t Type) Type {
 
 func (t *rtype) ptrTo() *rtype {
 	// Check the cache.
-	ptrMap.RLock()
-	if m := ptrMap.m; m != nil {
-		if p := m[t]; p != nil {
-			ptrMap.RUnlock()
-			return &p.rtype
-		}
-	}
-	ptrMap.RUnlock()
-
-	ptrMap.Lock()
-	if ptrMap.m == nil {
-		ptrMap.m = make(map[*rtype]*ptrType)
-	}
-	p := ptrMap.m[t]
-	if p != nil {
-		// some other goroutine won the race and created it
-		ptrMap.Unlock()
-		return &p.rtype
+	if p := ptrMap.Load(t); p != nil {
+		return &(*ptrType)(p).rtype
 	}
 
 	// Look in known types.
 	s := "*" + t.String()
 	for _, tt := range typesByString(s) {
-		p = (*ptrType)(unsafe.Pointer(tt))
+		p := (*ptrType)(unsafe.Pointer(tt))
 		if p.elem == t {
-			ptrMap.m[t] = p
-			ptrMap.Unlock()
-			return &p.rtype
+			return &(*ptrType)(ptrMap.LoadOrStore(t, unsafe.Pointer(p))).rtype
 		}
 	}
 
 	// Create a new ptrType starting with the description
 	// of an *unsafe.Pointer.
-	p = new(ptrType)
+	p := new(ptrType)
 	var iptr interface{} = (*unsafe.Pointer)(nil)
 	prototype := *(**ptrType)(unsafe.Pointer(&iptr))
 	*p = *prototype
@@ -1478,9 +1597,9 @@ func (t *rtype) ptrTo() *rtype {
 
 	p.elem = t
 
-	ptrMap.m[t] = p
-	ptrMap.Unlock()
-	return &p.rtype
+	// If another goroutine created the type first, use that one.
+	addConstructed(&p.rtype)
+	return &(*ptrType)(ptrMap.LoadOrStore(t, unsafe.Pointer(p))).rtype
 }
 
 // fnv1 incorporates the list of bytes into the hash x using the FNV-1 hash function.
@@ -1594,10 +1713,26 @@ func directlyAssignable(T, V *rtype) bool {
 	}
 
 	// x's type T and V must  have identical underlying types.
-	return haveIdenticalUnderlyingType(T, V)
+	return haveIdenticalUnderlyingType(T, V, true)
 }
 
-func haveIdenticalUnderlyingType(T, V *rtype) bool {
+// haveIdenticalType reports whether T and V are identical types,
+// ignoring struct tags unless cmpTags is set.
+func haveIdenticalType(T, V *rtype, cmpTags bool) bool {
+	if cmpTags || T == V {
+		return T == V
+	}
+	// Named types are identical only to themselves.
+	if T.Name() != "" || V.Name() != "" || T.Kind() != V.Kind() {
+		return false
+	}
+	return haveIdenticalUnderlyingType(T, V, false)
+}
+
+// haveIdenticalUnderlyingType reports whether T and V have identical
+// underlying types. If cmpTags is not set, struct tags are ignored,
+// as they are when converting between struct types.
+func haveIdenticalUnderlyingType(T, V *rtype, cmpTags bool) bool {
 	if T == V {
 		return true
 	}
@@ -1616,18 +1751,18 @@ func haveIdenticalUnderlyingType(T, V *rtype) bool {
 	// Composite types.
 	switch kind {
 	case Array:
-		return T.Elem() == V.Elem() && T.Len() == V.Len()
+		return T.Len() == V.Len() && haveIdenticalType(T.Elem().common(), V.Elem().common(), cmpTags)
 
 	case Chan:
 		// Special case:
 		// x is a bidirectional channel value, T is a channel type,
 		// and x's type V and T have identical element types.
-		if V.ChanDir() == BothDir && T.Elem() == V.Elem() {
+		if V.ChanDir() == BothDir && haveIdenticalType(T.Elem().common(), V.Elem().common(), cmpTags) {
 			return true
 		}
 
 		// Otherwise continue test for identical underlying type.
-		return V.ChanDir() == T.ChanDir() && T.Elem() == V.Elem()
+		return V.ChanDir() == T.ChanDir() && haveIdenticalType(T.Elem().common(), V.Elem().common(), cmpTags)
 
 	case Func:
 		t := (*funcType)(unsafe.Pointer(T))
@@ -1635,13 +1770,13 @@ func haveIdenticalUnderlyingType(T, V *rtype) bool {
 		if t.outCount != v.outCount || t.inCount != v.inCount {
 			return false
 		}
-		for i := 0; i < t.NumIn(); i++ {
-			if t.In(i) != v.In(i) {
+		for i, in := range t.in() {
+			if !haveIdenticalType(in, v.in()[i], cmpTags) {
 				return false
 			}
 		}
-		for i := 0; i < t.NumOut(); i++ {
-			if t.Out(i) != v.Out(i) {
+		for i, out := range t.out() {
+			if !haveIdenticalType(out, v.out()[i], cmpTags) {
 				return false
 			}
 		}
@@ -1658,10 +1793,11 @@ func haveIdenticalUnderlyingType(T, V *rtype) bool {
 		return false
 
 	case Map:
-		return T.Key() == V.Key() && T.Elem() == V.Elem()
+		return haveIdenticalType(T.Key().common(), V.Key().common(), cmpTags) &&
+			haveIdenticalType(T.Elem().common(), V.Elem().common(), cmpTags)
 
 	case Ptr, Slice:
-		return T.Elem() == V.Elem()
+		return haveIdenticalType(T.Elem().common(), V.Elem().common(), cmpTags)
 
 	case Struct:
 		t := (*structType)(unsafe.Pointer(T))
@@ -1675,10 +1811,10 @@ func haveIdenticalUnderlyingType(T, V *rtype) bool {
 			if tf.name.name() != vf.name.name() {
 				return false
 			}
-			if tf.typ != vf.typ {
+			if !haveIdenticalType(tf.typ, vf.typ, cmpTags) {
 				return false
 			}
-			if tf.name.tag() != vf.name.tag() {
+			if cmpTags && tf.name.tag() != vf.name.tag() {
 				return false
 			}
 			if tf.offset != vf.offset {
@@ -1756,10 +1892,13 @@ func typesByString(s string) []*rtype {
 }
 
 // The lookupCache caches ArrayOf, ChanOf, MapOf and SliceOf lookups.
-var lookupCache struct {
-	sync.RWMutex
-	m map[cacheKey]*rtype
-}
+//
+// The lookups, and the caches of the other constructed types, assume
+// that the set of modules is fixed once the program has started: when
+// typesByString finds no linked type, the type constructed instead is
+// the only one with its string for the life of the program. Loading
+// modules at run time would require revisiting such entries.
+var lookupCache readMap // map[cacheKey]*rtype
 
 // A cacheKey is the key for use in the lookupCache.
 // Four values describe any of the types we are looking for:
@@ -1772,46 +1911,28 @@ type cacheKey struct {
 }
 
 // cacheGet looks for a type under the key k in the lookupCache.
-// If it finds one, it returns that type.
-// If not, it returns nil with the cache locked.
-// The caller is expected to use cachePut to unlock the cache.
+// If it finds one, it returns that type. If not, it returns nil.
 func cacheGet(k cacheKey) Type {
-	lookupCache.RLock()
-	t := lookupCache.m[k]
-	lookupCache.RUnlock()
-	if t != nil {
-		return t
-	}
-
-	lookupCache.Lock()
-	t = lookupCache.m[k]
-	if t != nil {
-		lookupCache.Unlock()
-		return t
-	}
-
-	if lookupCache.m == nil {
-		lookupCache.m = make(map[cacheKey]*rtype)
+	if t := lookupCache.Load(k); t != nil {
+		return (*rtype)(t)
 	}
-
 	return nil
 }
 
-// cachePut stores the given type in the cache, unlocks the cache,
-// and returns the type. It is expected that the cache is locked
-// because cacheGet returned nil.
+// cachePut stores the given type in the cache under the key k and
+// returns it. If another goroutine stored a type under k first,
+// cachePut returns that type instead, so that all callers see the
+// same one.
 func cachePut(k cacheKey, t *rtype) Type {
-	lookupCache.m[k] = t
-	lookupCache.Unlock()
-	return t
+	return (*rtype)(lookupCache.LoadOrStore(k, unsafe.Pointer(t)))
 }
 
 // The funcLookupCache caches FuncOf lookups.
 // FuncOf does not share the common lookupCache since cacheKey is not
 // sufficient to represent functions unambiguously.
 var funcLookupCache struct {
-	sync.RWMutex
-	m map[uint32][]*rtype // keyed by hash calculated in FuncOf
+	sync.Mutex         // serializes writers
+	m          readMap // map[uint32]*[]*rtype, keyed by hash calculated in FuncOf
 }
 
 // ChanOf returns the channel type with the given direction and element type.
@@ -1830,7 +1951,6 @@ func ChanOf(dir ChanDir, t Type) Type {
 
 	// This restriction is imposed by the gc compiler and the runtime.
 	if typ.size >= 1<<16 {
-		lookupCache.Unlock()
 		panic("reflect.ChanOf: element size too large")
 	}
 
@@ -1839,7 +1959,6 @@ func ChanOf(dir ChanDir, t Type) Type {
 	var s string
 	switch dir {
 	default:
-		lookupCache.Unlock()
 		panic("reflect.ChanOf: invalid dir")
 	case SendDir:
 		s = "chan<- " + typ.String()
@@ -1865,6 +1984,7 @@ func ChanOf(dir ChanDir, t Type) Type {
 	ch.hash = fnv1(typ.hash, 'c', byte(dir))
 	ch.elem = typ
 
+	addConstructed(&ch.rtype)
 	return cachePut(ckey, &ch.rtype)
 }
 
@@ -1926,6 +2046,7 @@ func MapOf(key, elem Type) Type {
 	mt.reflexivekey = isReflexive(ktyp)
 	mt.needkeyupdate = needKeyUpdate(ktyp)
 
+	addConstructed(&mt.rtype)
 	return cachePut(ckey, &mt.rtype)
 }
 
@@ -1999,7 +2120,19 @@ func FuncOf(in, out []Type, variadic bool) Type {
 		args = fixed.args[:0:len(fixed.args)]
 		ft = &fixed.funcType
 	default:
-		panic("reflect.FuncOf: too many arguments")
+		if len(in) >= 1<<16 || len(out) >= 1<<15 {
+			panic("reflect.FuncOf: too many arguments")
+		}
+		// Too many for the fixed layouts: build one of the same
+		// shape at run time, so that the garbage collector still
+		// sees the args array as pointers.
+		st := StructOf([]StructField{
+			{Name: "FuncType", Type: TypeOf(funcType{})},
+			{Name: "Args", Type: ArrayOf(n, TypeOf((*rtype)(nil)))},
+		})
+		p := unsafe_New(st.(*rtype))
+		args = (*[1 << 20]*rtype)(add(p, st.Field(1).Offset))[:0:n]
+		ft = (*funcType)(p)
 	}
 	*ft = *prototype
 
@@ -2019,9 +2152,6 @@ func FuncOf(in, out []Type, variadic bool) Type {
 		args = append(args, t)
 		hash = fnv1(hash, byte(t.hash>>24), byte(t.hash>>16), byte(t.hash>>8), byte(t.hash))
 	}
-	if len(args) > 50 {
-		panic("reflect.FuncOf does not support more than 50 arguments")
-	}
 	ft.tflag = 0
 	ft.hash = hash
 	ft.inCount = uint16(len(in))
@@ -2031,41 +2161,46 @@ func FuncOf(in, out []Type, variadic bool) Type {
 	}
 
 	// Look in cache.
-	funcLookupCache.RLock()
-	for _, t := range funcLookupCache.m[hash] {
-		if haveIdenticalUnderlyingType(&ft.rtype, t) {
-			funcLookupCache.RUnlock()
-			return t
+	if ts := funcLookupCache.m.Load(hash); ts != nil {
+		for _, t := range *(*[]*rtype)(ts) {
+			if haveIdenticalUnderlyingType(&ft.rtype, t, true) {
+				return t
+			}
 		}
 	}
-	funcLookupCache.RUnlock()
 
 	// Not in cache, lock and retry.
 	funcLookupCache.Lock()
 	defer funcLookupCache.Unlock()
-	if funcLookupCache.m == nil {
-		funcLookupCache.m = make(map[uint32][]*rtype)
+	var rts []*rtype
+	if ts := funcLookupCache.m.Load(hash); ts != nil {
+		rts = *(*[]*rtype)(ts)
 	}
-	for _, t := range funcLookupCache.m[hash] {
-		if haveIdenticalUnderlyingType(&ft.rtype, t) {
+	for _, t := range rts {
+		if haveIdenticalUnderlyingType(&ft.rtype, t, true) {
 			return t
 		}
 	}
+	// The slices in the cache are read without locks, so
+	// adding a type stores a new slice.
+	addToCache := func(t *rtype) Type {
+		ts := append(rts[:len(rts):len(rts)], t)
+		funcLookupCache.m.Store(hash, unsafe.Pointer(&ts))
+		return t
+	}
 
 	// Look in known types for the same string representation.
 	str := funcStr(ft)
 	for _, tt := range typesByString(str) {
-		if haveIdenticalUnderlyingType(&ft.rtype, tt) {
-			funcLookupCache.m[hash] = append(funcLookupCache.m[hash], tt)
-			return tt
+		if haveIdenticalUnderlyingType(&ft.rtype, tt, true) {
+			return addToCache(tt)
 		}
 	}
 
 	// Populate the remaining fields of ft and store in cache.
 	ft.str = resolveReflectName(newName(str, "", "", false))
-	funcLookupCache.m[hash] = append(funcLookupCache.m[hash], &ft.rtype)
-
-	return &ft.rtype
+	addConstructed(&ft.rtype)
+	return addToCache(&ft.rtype)
 }
 
 // funcStr builds a string representation of a funcType.
@@ -2294,6 +2429,7 @@ func SliceOf(t Type) Type {
 	slice.hash = fnv1(typ.hash, '[')
 	slice.elem = typ
 
+	addConstructed(&slice.rtype)
 	return cachePut(ckey, &slice.rtype)
 }
 
@@ -2348,10 +2484,676 @@ type structTypeFixed32 struct {
 	m [32]method
 }
 
+type ptrTypeFixed4 struct {
+	ptrType
+	u uncommonType
+	m [4]method
+}
+
+type ptrTypeFixed8 struct {
+	ptrType
+	u uncommonType
+	m [8]method
+}
+
+type ptrTypeFixed16 struct {
+	ptrType
+	u uncommonType
+	m [16]method
+}
+
+type ptrTypeFixed32 struct {
+	ptrType
+	u uncommonType
+	m [32]method
+}
+
+// structMethodTypes holds the types built by StructOfWithMethods,
+// which are not kept in structLookupCache. It is protected by
+// structLookupCache's lock.
+var structMethodTypes []interface {
+	common() *rtype
+}
+
+var methodStubLock struct {
+	sync.Mutex
+	used int // number of stubs in use
+}
+
+// newMethodStubs returns the code pointers of unused method stubs
+// that call each of fns. op names the function building the methods.
+// It returns an error, and uses no stubs, if there are not enough
+// unused ones.
+func newMethodStubs(op string, fns []Value) ([]unsafe.Pointer, error) {
+	methodStubLock.Lock()
+	defer methodStubLock.Unlock()
+	n := methodStubCount
+	if methodStubSize() == 0 {
+		n = 0
+	}
+	if methodStubLock.used+len(fns) > n {
+		return nil, errors.New("reflect." + op + ": too many methods created")
+	}
+	code := make([]unsafe.Pointer, len(fns))
+	for i, fn := range fns {
+		if fn.flag&flagMethod != 0 {
+			fn = makeMethodValue(op, fn)
+		}
+		n := methodStubLock.used
+		methodStubLock.used++
+		methodStubFuncs[n] = fn.pointer()
+		code[i] = methodStubCode(n)
+	}
+	return code, nil
+}
+
+// checkMethods checks the methods ms given to op and returns the set
+// of their names. fieldNames is the set of field names of the struct.
+func checkMethods(op string, ms []Method, fieldNames map[string]struct{}) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, m := range ms {
+		if !isExportedIdent(m.Name) {
+			panic("reflect." + op + ": method name " + strconv.Quote(m.Name) + " is not an exported identifier")
+		}
+		if m.PkgPath != "" {
+			panic("reflect." + op + ": method " + m.Name + " has a PkgPath")
+		}
+		if _, dup := names[m.Name]; dup {
+			panic("reflect." + op + ": duplicate method " + m.Name)
+		}
+		if _, dup := fieldNames[m.Name]; dup {
+			panic("reflect." + op + ": field and method named " + m.Name)
+		}
+		names[m.Name] = struct{}{}
+		if m.Func.Kind() != Func {
+			panic("reflect." + op + ": method " + m.Name + " is not a func")
+		}
+		ft := m.Func.Type()
+		if ft.NumIn() == 0 {
+			panic("reflect." + op + ": method " + m.Name + " has no receiver")
+		}
+		recv := ft.In(0)
+		if recv.Kind() == Ptr {
+			recv = recv.Elem()
+		}
+		if recv.Kind() != Struct {
+			panic("reflect." + op + ": method " + m.Name + " has receiver type " + ft.In(0).String())
+		}
+	}
+	return names
+}
+
+// isIdent reports whether s is a Go identifier.
+func isIdent(s string) bool {
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isExportedIdent reports whether s is an exported Go identifier.
+func isExportedIdent(s string) bool {
+	for i, c := range s {
+		if i == 0 && !unicode.IsUpper(c) {
+			return false
+		}
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// structMethods returns the methods of a struct type and of its
+// pointer type, and their names, implemented by the functions in ms.
+// op names the function building them. direct reports whether the
+// struct type is stored directly in interface values.
+//
+// The functions take the receiver as their first argument. The methods
+// have code only for calls through an interface or by Value.Method,
+// which get the receiver as one word (ifn). Their tfn is methodTfn, and
+// Type.Method calls them by Value.Method instead. For a struct type that
+// is not direct, the word points to the receiver, so a value method is
+// called through a function that dereferences it, the same for the
+// struct type and its pointer type. A value method of a direct struct
+// type needs one method stub for each.
+func structMethods(op string, ms []Method, direct bool) (vmethods []method, vnames []string, pmethods []method, pnames []string, err error) {
+	var fns []Value
+	for _, m := range ms {
+		fn := m.Func
+		ft := fn.Type()
+		switch {
+		case ft.In(0).Kind() == Ptr:
+			fns = append(fns, fn)
+		case direct:
+			fns = append(fns, fn, derefMethod(m.Name, fn))
+		default:
+			fns = append(fns, derefMethod(m.Name, fn))
+		}
+	}
+	code, err := newMethodStubs(op, fns)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	tfn := resolveReflectText(funcCode(methodTfn))
+	for _, m := range ms {
+		ft := m.Func.Type()
+		in := make([]Type, ft.NumIn()-1)
+		for i := range in {
+			in[i] = ft.In(i + 1)
+		}
+		out := make([]Type, ft.NumOut())
+		for i := range out {
+			out[i] = ft.Out(i)
+		}
+		mt := method{
+			name: resolveReflectName(newName(m.Name, "", "", true)),
+			mtyp: resolveReflectType(FuncOf(in, out, ft.IsVariadic()).common()),
+		}
+		mt.ifn = resolveReflectText(code[0])
+		mt.tfn = tfn
+		code = code[1:]
+		if ft.In(0).Kind() == Ptr {
+			pmethods = append(pmethods, mt)
+			pnames = append(pnames, m.Name)
+			continue
+		}
+		vmethods = append(vmethods, mt)
+		vnames = append(vnames, m.Name)
+		if direct {
+			mt.ifn = resolveReflectText(code[0])
+			code = code[1:]
+		}
+		pmethods = append(pmethods, mt)
+		pnames = append(pnames, m.Name)
+	}
+	return
+}
+
+// derefMethod returns a function that calls fn, the value method
+// name, with the receiver its first argument points to.
+func derefMethod(name string, fn Value) Value {
+	ft := fn.Type()
+	in := make([]Type, ft.NumIn())
+	in[0] = PtrTo(ft.In(0))
+	for i := 1; i < len(in); i++ {
+		in[i] = ft.In(i)
+	}
+	out := make([]Type, ft.NumOut())
+	for i := range out {
+		out[i] = ft.Out(i)
+	}
+	return MakeFunc(FuncOf(in, out, ft.IsVariadic()), func(args []Value) []Value {
+		if args[0].IsNil() {
+			panic("reflect: value method " + name + " called using nil pointer")
+		}
+		args[0] = args[0].Elem()
+		if ft.IsVariadic() {
+			return fn.CallSlice(args)
+		}
+		return fn.Call(args)
+	})
+}
+
+// A promotion describes a method promoted to a struct type from one of
+// its embedded fields.
+type promotion struct {
+	name   string
+	index  []int  // index sequence of the embedded field
+	mindex int    // index of the method in the method set of its receiver
+	mtyp   *rtype // method type, without receiver
+	ptr    bool   // the method has a pointer receiver
+	indir  bool   // the field is reached through an embedded pointer
+}
+
+// promotedMethods returns the exported methods promoted from the
+// embedded fields of a struct type with the given fields. As in the
+// language, a method is promoted from the shallowest depth at which
+// its name is found, and only if no other method or field of that
+// name is found at that depth. in holds the struct types whose own
+// promoted methods are being found, to stop at recursive types.
+func promotedMethods(fields []structField, in map[*rtype]bool) []promotion {
+	type embedded struct {
+		typ   *rtype // type of the field
+		index []int
+		indir bool
+	}
+	var current []embedded
+	found := make(map[string]bool) // names found at shallower depths
+	for i := range fields {
+		f := &fields[i]
+		found[fieldName(f)] = true
+		if f.name.name() == "" {
+			current = append(current, embedded{f.typ, []int{i}, f.typ.Kind() == Ptr})
+		}
+	}
+
+	var promoted []promotion
+	visited := make(map[*rtype]bool) // struct types found at shallower depths
+	for len(current) > 0 {
+		count := make(map[string]int) // number of methods and fields of each name
+		methods := make(map[string]promotion)
+		var next []embedded
+		for _, e := range current {
+			t := e.typ
+			if t.Kind() == Ptr {
+				t = t.Elem().(*rtype)
+			}
+			if visited[t] {
+				continue
+			}
+
+			if t.Kind() == Interface {
+				tt := (*interfaceType)(unsafe.Pointer(t))
+				for i := range tt.methods {
+					m := &tt.methods[i]
+					name := tt.nameOff(m.name)
+					if !name.isExported() {
+						continue
+					}
+					count[name.name()]++
+					methods[name.name()] = promotion{
+						name:   name.name(),
+						index:  e.index,
+						mindex: i,
+						mtyp:   tt.typeOff(m.typ),
+						indir:  e.indir,
+					}
+				}
+				continue
+			}
+
+			// The fields of an embedded struct are found at this depth,
+			// and the methods it promotes from them at deeper ones.
+			var inner map[string]bool
+			if t.Kind() == Struct {
+				st := (*structType)(unsafe.Pointer(t))
+				for j := range st.fields {
+					f := &st.fields[j]
+					count[fieldName(f)]++
+					if f.name.name() == "" {
+						index := append(e.index[:len(e.index):len(e.index)], j)
+						next = append(next, embedded{f.typ, index, e.indir || f.typ.Kind() == Ptr})
+					}
+				}
+				if !in[t] {
+					in[t] = true
+					inner = make(map[string]bool)
+					for _, p := range promotedMethods(st.fields, in) {
+						inner[p.name] = true
+					}
+					delete(in, t)
+				}
+			}
+
+			// A method of the pointer type is called on the field if it
+			// is a pointer, and otherwise on its address if the method
+			// has a pointer receiver.
+			vt, pt := t, e.typ
+			if pt.Kind() != Ptr {
+				pt = t.ptrTo()
+			}
+			var vms []method
+			if ut := vt.uncommon(); ut != nil {
+				vms = ut.methods()
+			}
+			ut := pt.uncommon()
+			if ut == nil {
+				continue
+			}
+			for i, m := range ut.methods() {
+				name := pt.nameOff(m.name)
+				if !name.isExported() || inner[name.name()] {
+					continue
+				}
+				p := promotion{
+					name:   name.name(),
+					index:  e.index,
+					mindex: i,
+					mtyp:   pt.typeOff(m.mtyp),
+					ptr:    true,
+					indir:  e.indir,
+				}
+				for j, vm := range vms {
+					if vt.nameOff(vm.name).name() == p.name {
+						p.ptr = false
+						if e.typ.Kind() != Ptr {
+							p.mindex = j
+						}
+						break
+					}
+				}
+				count[p.name]++
+				methods[p.name] = p
+			}
+		}
+
+		for _, e := range current {
+			t := e.typ
+			if t.Kind() == Ptr {
+				t = t.Elem().(*rtype)
+			}
+			visited[t] = true
+		}
+		for name, n := range count {
+			if p, ok := methods[name]; ok && n == 1 && !found[name] {
+				promoted = append(promoted, p)
+			}
+			found[name] = true
+		}
+		current = next
+	}
+	return promoted
+}
+
+// fieldName returns the name of the struct field f, which for an
+// embedded field is the name of its type.
+func fieldName(f *structField) string {
+	if name := f.name.name(); name != "" {
+		return name
+	}
+	t := f.typ
+	if t.Kind() == Ptr {
+		t = t.Elem().(*rtype)
+	}
+	return t.Name()
+}
+
+// promotedStructMethods returns the methods of the struct type t and
+// of its pointer type, and their names, promoted from its embedded
+// fields as described by ps. op and direct are as for structMethods.
+//
+// As for structMethods, a promoted method has code only for calls
+// through an interface or by Value.Method, and its tfn is methodTfn.
+// The code is that of the method of the embedded field if the field
+// gets the same receiver word, and otherwise a method stub.
+func promotedStructMethods(op string, t *rtype, ps []promotion, direct bool) (vmethods []method, vnames []string, pmethods []method, pnames []string, err error) {
+	tfn := resolveReflectText(funcCode(methodTfn))
+	for _, p := range ps {
+		code, err := embeddedCode(op, t, p, false)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		mt := method{
+			name: resolveReflectName(newName(p.name, "", "", true)),
+			mtyp: resolveReflectType(p.mtyp),
+			ifn:  resolveReflectText(code),
+			tfn:  tfn,
+		}
+		pmethods = append(pmethods, mt)
+		pnames = append(pnames, p.name)
+		if p.ptr && !p.indir {
+			continue
+		}
+		if direct {
+			code, err := embeddedCode(op, t, p, true)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			mt.ifn = resolveReflectText(code)
+		}
+		vmethods = append(vmethods, mt)
+		vnames = append(vnames, p.name)
+	}
+	return
+}
+
+// embeddedCode returns the code of the method p promoted to the struct
+// type t, as for promotedCode. If the embedded field is the first
+// field of t and is itself the receiver, reached through the pointer
+// to t or, if direct is set, as the one word of t, the receiver word
+// needs no adjustment, and the code is that of the method of the
+// field. Otherwise it is a method stub made by promotedCode.
+func embeddedCode(op string, t *rtype, p promotion, direct bool) (unsafe.Pointer, error) {
+	if len(p.index) == 1 {
+		f := &(*structType)(unsafe.Pointer(t)).fields[p.index[0]]
+		// mt is the type whose method gets the receiver word.
+		var mt *rtype
+		switch {
+		case f.offset != 0 || f.typ.Kind() == Interface:
+		case direct:
+			mt = f.typ
+		case f.typ.Kind() != Ptr:
+			mt = f.typ.ptrTo()
+		}
+		if mt != nil {
+			if ut := mt.uncommon(); ut != nil {
+				for _, m := range ut.methods() {
+					if mt.nameOff(m.name).name() == p.name {
+						return mt.textOff(m.ifn), nil
+					}
+				}
+			}
+		}
+	}
+	return promotedCode(op, t, p, direct)
+}
+
+// methodTfn is the tfn of the methods built by StructOf and
+// StructOfWithMethods, which Type.Method calls by Value.Method.
+func methodTfn() {
+	panic("reflect: internal error: call of method tfn")
+}
+
+// funcCode returns the code pointer of the func value f.
+func funcCode(f func()) unsafe.Pointer {
+	return **(**unsafe.Pointer)(unsafe.Pointer(&f))
+}
+
+// A promotedKey identifies the code of a promoted method, which only
+// depends on where the embedded field is found from the receiver.
+type promotedKey struct {
+	typ    *rtype // type of the embedded field
+	path   string // offsets of the fields leading to it
+	mindex int
+	ptr    bool
+	direct bool
+}
+
+// promotedCodes caches the code of promoted methods, to share the
+// method stubs among struct types.
+var promotedCodes struct {
+	sync.Mutex
+	m map[promotedKey]unsafe.Pointer
+}
+
+// promotedCode returns the code of the method p promoted to the struct
+// type t, which gets as its receiver a pointer to a value of t or, if
+// direct is set, the one word of a value of t.
+func promotedCode(op string, t *rtype, p promotion, direct bool) (unsafe.Pointer, error) {
+	// Find the offsets of the fields leading to the embedded field,
+	// and whether each is reached through a pointer.
+	offs := make([]uintptr, len(p.index))
+	derefs := make([]bool, len(p.index))
+	var path []byte
+	ft := t
+	for i, x := range p.index {
+		if ft.Kind() == Ptr {
+			ft = ft.Elem().(*rtype)
+			derefs[i] = true
+			path = append(path, '*')
+		}
+		f := &(*structType)(unsafe.Pointer(ft)).fields[x]
+		offs[i] = f.offset
+		path = appendVarint(append(path, '.'), f.offset)
+		ft = f.typ
+	}
+
+	key := promotedKey{ft, string(path), p.mindex, p.ptr, direct}
+	promotedCodes.Lock()
+	defer promotedCodes.Unlock()
+	if code, ok := promotedCodes.m[key]; ok {
+		return code, nil
+	}
+
+	mt := (*funcType)(unsafe.Pointer(p.mtyp))
+	in := make([]Type, 0, 1+len(mt.in()))
+	in = append(in, TypeOf(unsafe.Pointer(nil)))
+	for _, arg := range mt.in() {
+		in = append(in, arg)
+	}
+	out := make([]Type, 0, len(mt.out()))
+	for _, ret := range mt.out() {
+		out = append(out, ret)
+	}
+	name, mindex, ptr := p.name, p.mindex, p.ptr
+	fn := MakeFunc(FuncOf(in, out, mt.IsVariadic()), func(args []Value) []Value {
+		recv := args[0].pointer()
+		if direct {
+			word := recv
+			recv = unsafe.Pointer(&word)
+		} else if recv == nil {
+			panic("reflect: method " + name + " called using nil pointer")
+		}
+		for i, off := range offs {
+			if derefs[i] {
+				recv = *(*unsafe.Pointer)(recv)
+				if recv == nil {
+					panic("reflect: indirection through nil pointer to embedded struct")
+				}
+			}
+			recv = add(recv, off)
+		}
+		v := Value{ft, recv, flag(ft.Kind()) | flagIndir | flagAddr}
+		if ptr && ft.Kind() != Ptr {
+			v = Value{ft.ptrTo(), recv, flag(Ptr)}
+		}
+		m := v.Method(mindex)
+		if mt.IsVariadic() {
+			return m.CallSlice(args[1:])
+		}
+		return m.Call(args[1:])
+	})
+
+	code, err := newMethodStubs(op, []Value{fn})
+	if err != nil {
+		return nil, err
+	}
+	if promotedCodes.m == nil {
+		promotedCodes.m = make(map[promotedKey]unsafe.Pointer)
+	}
+	promotedCodes.m[key] = code[0]
+	return code[0], nil
+}
+
+// newTypeWithMethods returns memory laid out as t, a structTypeFixedN
+// or ptrTypeFixedN, with the method array at offset moff extended to
+// hold n methods. Methods hold no pointers, so the pointer data of t
+// also describes the extended memory.
+func newTypeWithMethods(t *rtype, moff uintptr, n int) unsafe.Pointer {
+	ext := *t
+	ext.size = moff + uintptr(n)*unsafe.Sizeof(method{})
+	return unsafe_New(&ext)
+}
+
+// sortMethods sorts methods by name, as the runtime expects.
+// names holds the names of methods.
+func sortMethods(methods []method, names []string) {
+	for i := 1; i < len(methods); i++ {
+		for j := i; j > 0 && names[j] < names[j-1]; j-- {
+			methods[j], methods[j-1] = methods[j-1], methods[j]
+			names[j], names[j-1] = names[j-1], names[j]
+		}
+	}
+}
+
+// ptrToWithMethods returns a new pointer type to t with methods.
+func ptrToWithMethods(t *rtype, methods []method) *ptrType {
+	var p *ptrType
+	var ut *uncommonType
+	switch {
+	case len(methods) <= 4:
+		pt := new(ptrTypeFixed4)
+		p, ut = &pt.ptrType, &pt.u
+	case len(methods) <= 8:
+		pt := new(ptrTypeFixed8)
+		p, ut = &pt.ptrType, &pt.u
+	case len(methods) <= 16:
+		pt := new(ptrTypeFixed16)
+		p, ut = &pt.ptrType, &pt.u
+	case len(methods) <= 32:
+		pt := new(ptrTypeFixed32)
+		p, ut = &pt.ptrType, &pt.u
+	default:
+		pt := (*ptrTypeFixed4)(newTypeWithMethods(TypeOf(ptrTypeFixed4{}).common(), unsafe.Offsetof(ptrTypeFixed4{}.m), len(methods)))
+		p, ut = &pt.ptrType, &pt.u
+	}
+	ut.mcount = uint16(len(methods))
+	ut.moff = uint16(unsafe.Sizeof(uncommonType{}))
+	copy(ut.methods(), methods)
+
+	// As in ptrTo.
+	var iptr interface{} = (*unsafe.Pointer)(nil)
+	prototype := *(**ptrType)(unsafe.Pointer(&iptr))
+	*p = *prototype
+	p.str = resolveReflectName(newName("*"+t.String(), "", "", false))
+	p.hash = fnv1(t.hash, '*')
+	p.elem = t
+	if len(methods) > 0 {
+		p.tflag |= tflagUncommon
+	}
+	addConstructed(&p.rtype)
+	return p
+}
+
 // StructOf returns the struct type containing fields.
 // The Offset and Index fields are ignored and computed as they would be
 // by the compiler.
+//
+// The struct type and its pointer type have the exported methods
+// promoted from the embedded fields, as for a struct type declared in
+// Go. Unexported methods are not promoted. A promoted method takes up
+// a method stub, as described for StructOfWithMethods, unless its
+// embedded field is the first field, is not an interface, and gets the
+// receiver word unchanged. The struct types with the same path to an
+// embedded field share its stubs. StructOf panics if none are left.
 func StructOf(fields []StructField) Type {
+	t, err := structOf("StructOf", fields, nil)
+	if err != nil {
+		panic(err.Error())
+	}
+	return t
+}
+
+// StructOfWithMethods returns a new struct type containing fields,
+// whose methods are implemented by the given functions, in addition
+// to the methods promoted from embedded fields.
+//
+// The Func of each method is called with the receiver as its first
+// argument, followed by the arguments of the method. The receiver
+// type determines which method set the method belongs to: a Func whose
+// first parameter has the type of StructOf(fields) implements a method
+// of the returned type and of its pointer type, and a Func whose first
+// parameter has the type PtrTo(StructOf(fields)) implements a method of
+// the pointer type only. The receiver types are checked once the
+// struct type has been built. Such a Func is usually made by MakeFunc.
+// The Name of each method must be an exported identifier; the PkgPath,
+// Type and Index fields are ignored.
+//
+// Unlike StructOf, StructOfWithMethods returns a new type on each call,
+// distinct from every other type, even for the same fields and methods.
+// Each method of a type built by StructOfWithMethods takes up a method
+// stub that is never released, and a value method of a struct type
+// whose only field is a pointer takes up two. A program has 256 method
+// stubs in all, shared with the methods that StructOf and
+// StructOfWithMethods promote from embedded fields. If there are not
+// enough unused ones left, or if the system has none,
+// StructOfWithMethods returns an error saying that too many methods
+// were created.
+func StructOfWithMethods(fields []StructField, methods []Method) (Type, error) {
+	if len(methods) == 0 {
+		panic("reflect.StructOfWithMethods: no methods")
+	}
+	return structOf("StructOfWithMethods", fields, methods)
+}
+
+// structOf implements StructOf and StructOfWithMethods, where op names
+// the caller and ms are the methods given to StructOfWithMethods.
+// It returns an error if the methods cannot be built.
+func structOf(op string, fields []StructField, ms []Method) (Type, error) {
 	var (
 		hash       = fnv1(0, []byte("struct {")...)
 		size       uintptr
@@ -2359,6 +3161,7 @@ func StructOf(fields []StructField) Type {
 		comparable = true
 		hashable   = true
 		methods    []method
+		mnames     []string // names of methods
 
 		fs   = make([]structField, len(fields))
 		repr = make([]byte, 0, 64)
@@ -2401,117 +3204,6 @@ func StructOf(fields []StructField) Type {
 				name = ft.String()
 			}
 			// TODO(sbinet) check for syntactically impossible type names?
-
-			switch f.typ.Kind() {
-			case Interface:
-				ift := (*interfaceType)(unsafe.Pointer(ft))
-				for im, m := range ift.methods {
-					if ift.nameOff(m.name).pkgPath() != "" {
-						// TODO(sbinet)
-						panic("reflect: embedded interface with unexported method(s) not implemented")
-					}
-
-					var (
-						mtyp    = ift.typeOff(m.typ)
-						ifield  = i
-						imethod = im
-						ifn     Value
-						tfn     Value
-					)
-
-					if ft.kind&kindDirectIface != 0 {
-						tfn = MakeFunc(mtyp, func(in []Value) []Value {
-							var args []Value
-							var recv = in[0]
-							if len(in) > 1 {
-								args = in[1:]
-							}
-							return recv.Field(ifield).Method(imethod).Call(args)
-						})
-						ifn = MakeFunc(mtyp, func(in []Value) []Value {
-							var args []Value
-							var recv = in[0]
-							if len(in) > 1 {
-								args = in[1:]
-							}
-							return recv.Field(ifield).Method(imethod).Call(args)
-						})
-					} else {
-						tfn = MakeFunc(mtyp, func(in []Value) []Value {
-							var args []Value
-							var recv = in[0]
-							if len(in) > 1 {
-								args = in[1:]
-							}
-							return recv.Field(ifield).Method(imethod).Call(args)
-						})
-						ifn = MakeFunc(mtyp, func(in []Value) []Value {
-							var args []Value
-							var recv = Indirect(in[0])
-							if len(in) > 1 {
-								args = in[1:]
-							}
-							return recv.Field(ifield).Method(imethod).Call(args)
-						})
-					}
-
-					methods = append(methods, method{
-						name: resolveReflectName(ift.nameOff(m.name)),
-						mtyp: resolveReflectType(mtyp),
-						ifn:  resolveReflectText(unsafe.Pointer(&ifn)),
-						tfn:  resolveReflectText(unsafe.Pointer(&tfn)),
-					})
-				}
-			case Ptr:
-				ptr := (*ptrType)(unsafe.Pointer(ft))
-				if unt := ptr.uncommon(); unt != nil {
-					for _, m := range unt.methods() {
-						mname := ptr.nameOff(m.name)
-						if mname.pkgPath() != "" {
-							// TODO(sbinet)
-							panic("reflect: embedded interface with unexported method(s) not implemented")
-						}
-						methods = append(methods, method{
-							name: resolveReflectName(mname),
-							mtyp: resolveReflectType(ptr.typeOff(m.mtyp)),
-							ifn:  resolveReflectText(ptr.textOff(m.ifn)),
-							tfn:  resolveReflectText(ptr.textOff(m.tfn)),
-						})
-					}
-				}
-				if unt := ptr.elem.uncommon(); unt != nil {
-					for _, m := range unt.methods() {
-						mname := ptr.nameOff(m.name)
-						if mname.pkgPath() != "" {
-							// TODO(sbinet)
-							panic("reflect: embedded interface with unexported method(s) not implemented")
-						}
-						methods = append(methods, method{
-							name: resolveReflectName(mname),
-							mtyp: resolveReflectType(ptr.elem.typeOff(m.mtyp)),
-							ifn:  resolveReflectText(ptr.elem.textOff(m.ifn)),
-							tfn:  resolveReflectText(ptr.elem.textOff(m.tfn)),
-						})
-					}
-				}
-			default:
-				if unt := ft.uncommon(); unt != nil {
-					for _, m := range unt.methods() {
-						mname := ft.nameOff(m.name)
-						if mname.pkgPath() != "" {
-							// TODO(sbinet)
-							panic("reflect: embedded interface with unexported method(s) not implemented")
-						}
-						methods = append(methods, method{
-							name: resolveReflectName(mname),
-							mtyp: resolveReflectType(ft.typeOff(m.mtyp)),
-							ifn:  resolveReflectText(ft.textOff(m.ifn)),
-							tfn:  resolveReflectText(ft.textOff(m.tfn)),
-						})
-
-					}
-				}
-			}
 		}
 		if _, dup := fset[name]; dup {
 			panic("reflect.StructOf: duplicate field " + name)
@@ -2541,6 +3233,33 @@ func StructOf(fields []StructField) Type {
 		fs[i] = f
 	}
 
+	// The given methods replace promoted methods of the same name.
+	// Those with a value receiver are also methods of the struct type,
+	// as are the promoted methods not reached through a pointer that
+	// have a pointer receiver.
+	promoted := promotedMethods(fs, map[*rtype]bool{})
+	if len(ms) > 0 {
+		own := checkMethods(op, ms, fset)
+		var kept []promotion
+		for _, p := range promoted {
+			if _, ok := own[p.name]; !ok {
+				kept = append(kept, p)
+			}
+		}
+		promoted = kept
+	}
+	nmethods := 0
+	for _, p := range promoted {
+		if !p.ptr || p.indir {
+			nmethods++
+		}
+	}
+	for _, m := range ms {
+		if m.Func.Type().In(0).Kind() != Ptr {
+			nmethods++
+		}
+	}
+
 	var typ *structType
 	var ut *uncommonType
 	var typPin interface {
@@ -2548,39 +3267,38 @@ func StructOf(fields []StructField) Type {
 	} // structTypeFixedN
 
 	switch {
-	case len(methods) == 0:
+	case nmethods == 0:
 		t := new(structTypeUncommon)
 		typ = &t.structType
 		ut = &t.u
 		typPin = t
-	case len(methods) <= 4:
+	case nmethods <= 4:
 		t := new(structTypeFixed4)
 		typ = &t.structType
 		ut = &t.u
-		copy(t.m[:], methods)
 		typPin = t
-	case len(methods) <= 8:
+	case nmethods <= 8:
 		t := new(structTypeFixed8)
 		typ = &t.structType
 		ut = &t.u
-		copy(t.m[:], methods)
 		typPin = t
-	case len(methods) <= 16:
+	case nmethods <= 16:
 		t := new(structTypeFixed16)
 		typ = &t.structType
 		ut = &t.u
-		copy(t.m[:], methods)
 		typPin = t
-	case len(methods) <= 32:
+	case nmethods <= 32:
 		t := new(structTypeFixed32)
 		typ = &t.structType
 		ut = &t.u
-		copy(t.m[:], methods)
 		typPin = t
 	default:
-		panic("reflect.StructOf: too many methods")
+		p := newTypeWithMethods(TypeOf(structTypeFixed4{}).common(), unsafe.Offsetof(structTypeFixed4{}.m), nmethods)
+		typ = (*structType)(p)
+		ut = &(*structTypeUncommon)(p).u
+		typPin = typ
 	}
-	ut.mcount = uint16(len(methods))
+	ut.mcount = uint16(nmethods)
 	ut.moff = uint16(unsafe.Sizeof(uncommonType{}))
 
 	if len(fs) > 0 {
@@ -2597,18 +3315,35 @@ func StructOf(fields []StructField) Type {
 	var istruct interface{} = struct{}{}
 	prototype := *(**structType)(unsafe.Pointer(&istruct))
 	*typ = *prototype
+	typ.tflag = 0 // the prototype's string has an extra star
 	typ.fields = fs
 
-	// Look in cache
-	structLookupCache.RLock()
-	for _, st := range structLookupCache.m[hash] {
-		t := st.common()
-		if haveIdenticalUnderlyingType(&typ.rtype, t) {
-			structLookupCache.RUnlock()
-			return t
+	// Now that the fields are known, check that the receivers of the
+	// given methods have the new type.
+	for _, m := range ms {
+		recv := m.Func.Type().In(0).(*rtype)
+		if recv.Kind() == Ptr {
+			recv = recv.Elem().(*rtype)
+		}
+		if recv.Name() != "" || !haveIdenticalUnderlyingType(&typ.rtype, recv, true) {
+			panic("reflect.StructOfWithMethods: method " + m.Name + " has receiver type " + m.Func.Type().In(0).String() + ", not " + str + " or a pointer to it")
 		}
 	}
-	structLookupCache.RUnlock()
+
+	// A type with given methods is distinct from every other type,
+	// so only look for other types without them.
+	if len(ms) == 0 {
+		// Look in cache
+		structLookupCache.RLock()
+		for _, st := range structLookupCache.m[hash] {
+			t := st.common()
+			if haveIdenticalUnderlyingType(&typ.rtype, t, true) {
+				structLookupCache.RUnlock()
+				return t, nil
+			}
+		}
+		structLookupCache.RUnlock()
+	}
 
 	// not in cache, lock and retry
 	structLookupCache.Lock()
@@ -2618,21 +3353,23 @@ func StructOf(fields []StructField) Type {
 			common() *rtype
 		})
 	}
-	for _, st := range structLookupCache.m[hash] {
-		t := st.common()
-		if haveIdenticalUnderlyingType(&typ.rtype, t) {
-			return t
+	if len(ms) == 0 {
+		for _, st := range structLookupCache.m[hash] {
+			t := st.common()
+			if haveIdenticalUnderlyingType(&typ.rtype, t, true) {
+				return t, nil
+			}
 		}
-	}
 
-	// Look in known types.
-	for _, t := range typesByString(str) {
-		if haveIdenticalUnderlyingType(&typ.rtype, t) {
-			// even if 't' wasn't a structType with methods, we should be ok
-			// as the 'u uncommonType' field won't be accessed except when
-			// tflag&tflagUncommon is set.
-			structLookupCache.m[hash] = append(structLookupCache.m[hash], t)
-			return t
+		// Look in known types.
+		for _, t := range typesByString(str) {
+			if haveIdenticalUnderlyingType(&typ.rtype, t, true) {
+				// even if 't' wasn't a structType with methods, we should be ok
+				// as the 'u uncommonType' field won't be accessed except when
+				// tflag&tflagUncommon is set.
+				structLookupCache.m[hash] = append(structLookupCache.m[hash], t)
+				return t, nil
+			}
 		}
 	}
 
@@ -2641,7 +3378,7 @@ func StructOf(fields []StructField) Type {
 	typ.size = size
 	typ.align = typalign
 	typ.fieldAlign = typalign
-	if len(methods) > 0 {
+	if nmethods > 0 {
 		typ.tflag |= tflagUncommon
 	}
 	if !hasPtr {
@@ -2741,19 +3478,58 @@ func StructOf(fields []StructField) Type {
 		typ.kind &^= kindDirectIface
 	}
 
+	// Now that the type is complete, build the methods of the type and
+	// of its pointer type.
+	if len(ms) > 0 || len(promoted) > 0 {
+		direct := typ.kind&kindDirectIface != 0
+		var ptrMethods []method
+		var pnames []string
+		vm, vn, pm, pn, err := promotedStructMethods(op, &typ.rtype, promoted, direct)
+		if err != nil {
+			return nil, err
+		}
+		methods, mnames = append(methods, vm...), append(mnames, vn...)
+		ptrMethods, pnames = append(ptrMethods, pm...), append(pnames, pn...)
+		if len(ms) > 0 {
+			vm, vn, pm, pn, err := structMethods(op, ms, direct)
+			if err != nil {
+				return nil, err
+			}
+			methods, mnames = append(methods, vm...), append(mnames, vn...)
+			ptrMethods, pnames = append(ptrMethods, pm...), append(pnames, pn...)
+		}
+		sortMethods(methods, mnames)
+		copy(ut.methods(), methods)
+		sortMethods(ptrMethods, pnames)
+
+		p := ptrToWithMethods(&typ.rtype, ptrMethods)
+		ptrMap.Store(&typ.rtype, unsafe.Pointer(p))
+	}
+
+	addConstructed(&typ.rtype)
+
+	if len(ms) > 0 {
+		// Types are never freed, since itabs refer to them.
+		structMethodTypes = append(structMethodTypes, typPin)
+		return &typ.rtype, nil
+	}
+
 	structLookupCache.m[hash] = append(structLookupCache.m[hash], typPin)
-	return &typ.rtype
+	return &typ.rtype, nil
 }
 
 func runtimeStructField(field StructField) structField {
-	exported := field.PkgPath == ""
+	if field.PkgPath != "" {
+		panic("reflect.StructOf: field \"" + field.Name + "\" has a PkgPath, which is not supported")
+	}
+	exported := true
 	if field.Name == "" {
 		t := field.Type.(*rtype)
 		if t.Kind() == Ptr {
 			t = t.Elem().(*rtype)
 		}
 		exported = t.nameOff(t.str).isExported()
-	} else if exported {
+	} else {
 		b0 := field.Name[0]
 		if ('a' <= b0 && b0 <= 'z') || b0 == '_' {
 			panic("reflect.StructOf: field \"" + field.Name + "\" is unexported but has no PkgPath")
@@ -2804,9 +3580,6 @@ const maxPtrmaskBytes = 2048
 // ArrayOf panics.
 func ArrayOf(count int, elem Type) Type {
 	typ := elem.(*rtype)
-	// call SliceOf here as it calls cacheGet/cachePut.
-	// ArrayOf also calls cacheGet/cachePut and thus may modify the state of
-	// the lookupCache mutex.
 	slice := SliceOf(elem)
 
 	// Look in cache.
@@ -2966,9 +3739,227 @@ func ArrayOf(count int, elem Type) Type {
 		array.kind &^= kindDirectIface
 	}
 
+	addConstructed(&array.rtype)
 	return cachePut(ckey, &array.rtype)
 }
 
+// The namedLookupCache caches NamedOf lookups.
+var namedLookupCache struct {
+	sync.RWMutex
+	m map[namedKey]*rtype
+}
+
+// A namedKey is the key for use in the namedLookupCache.
+type namedKey struct {
+	name       string
+	pkgPath    string
+	underlying *rtype
+}
+
+type funcTypeUncommonFixed4 struct {
+	funcType
+	u    uncommonType
+	args [4]*rtype
+}
+type funcTypeUncommonFixed8 struct {
+	funcType
+	u    uncommonType
+	args [8]*rtype
+}
+type funcTypeUncommonFixed16 struct {
+	funcType
+	u    uncommonType
+	args [16]*rtype
+}
+type funcTypeUncommonFixed32 struct {
+	funcType
+	u    uncommonType
+	args [32]*rtype
+}
+type funcTypeUncommonFixed64 struct {
+	funcType
+	u    uncommonType
+	args [64]*rtype
+}
+type funcTypeUncommonFixed128 struct {
+	funcType
+	u    uncommonType
+	args [128]*rtype
+}
+
+// NamedOf returns the named type with the given name, declared in the
+// package with import path pkgPath, whose underlying type is that of
+// underlying. For example, if t represents int, NamedOf("Celsius",
+// "example.com/temp", t) represents a type declared as
+//
+//	type Celsius int
+//
+// in package example.com/temp. Its String method returns the name
+// qualified by the last element of pkgPath, here "temp.Celsius".
+//
+// The type has no methods, even if underlying has some, as for a type
+// declaration in Go. NamedOf panics if name is not an identifier, if
+// pkgPath is empty, or if underlying is a struct type with embedded
+// fields, whose promoted methods it cannot yet provide.
+//
+// Calls to NamedOf with the same arguments return the same type, which
+// is distinct from every other type, including types of the same name
+// declared by the program.
+func NamedOf(name, pkgPath string, underlying Type) Type {
+	if !isIdent(name) {
+		panic("reflect.NamedOf: invalid type name " + strconv.Quote(name))
+	}
+	if pkgPath == "" {
+		panic("reflect.NamedOf: empty package path for type " + name)
+	}
+	u := underlying.(*rtype)
+	if u.Kind() == Struct {
+		for _, f := range (*structType)(unsafe.Pointer(u)).fields {
+			if f.name.name() == "" {
+				panic("reflect.NamedOf: struct type with embedded field " + f.typ.String())
+			}
+		}
+	}
+
+	// Look in cache.
+	key := namedKey{name, pkgPath, u}
+	namedLookupCache.RLock()
+	if t := namedLookupCache.m[key]; t != nil {
+		namedLookupCache.RUnlock()
+		return t
+	}
+	namedLookupCache.RUnlock()
+
+	// Not in cache, lock and retry.
+	namedLookupCache.Lock()
+	defer namedLookupCache.Unlock()
+	if t := namedLookupCache.m[key]; t != nil {
+		return t
+	}
+	if namedLookupCache.m == nil {
+		namedLookupCache.m = make(map[namedKey]*rtype)
+	}
+
+	// Copy the description of the underlying type into one followed by
+	// an uncommonType, as for a named type of the same kind.
+	var t *rtype
+	var ut *uncommonType
+	switch u.Kind() {
+	case Array:
+		nt := &struct {
+			arrayType
+			u uncommonType
+		}{arrayType: *(*arrayType)(unsafe.Pointer(u))}
+		t, ut = &nt.rtype, &nt.u
+	case Chan:
+		nt := &struct {
+			chanType
+			u uncommonType
+		}{chanType: *(*chanType)(unsafe.Pointer(u))}
+		t, ut = &nt.rtype, &nt.u
+	case Func:
+		t, ut = namedFuncType((*funcType)(unsafe.Pointer(u)))
+	case Interface:
+		nt := &struct {
+			interfaceType
+			u uncommonType
+		}{interfaceType: *(*interfaceType)(unsafe.Pointer(u))}
+		// The offsets of the methods are relative to u, so resolve
+		// them again relative to the new type.
+		methods := make([]imethod, len(nt.methods))
+		for i, m := range nt.methods {
+			methods[i] = imethod{
+				name: resolveReflectName(u.nameOff(m.name)),
+				typ:  resolveReflectType(u.typeOff(m.typ)),
+			}
+		}
+		nt.methods = methods
+		t, ut = &nt.rtype, &nt.u
+	case Map:
+		nt := &struct {
+			mapType
+			u uncommonType
+		}{mapType: *(*mapType)(unsafe.Pointer(u))}
+		t, ut = &nt.rtype, &nt.u
+	case Ptr:
+		nt := &struct {
+			ptrType
+			u uncommonType
+		}{ptrType: *(*ptrType)(unsafe.Pointer(u))}
+		t, ut = &nt.rtype, &nt.u
+	case Slice:
+		nt := &struct {
+			sliceType
+			u uncommonType
+		}{sliceType: *(*sliceType)(unsafe.Pointer(u))}
+		t, ut = &nt.rtype, &nt.u
+	case Struct:
+		nt := &structTypeUncommon{structType: *(*structType)(unsafe.Pointer(u))}
+		t, ut = &nt.rtype, &nt.u
+	default:
+		nt := &struct {
+			rtype
+			u uncommonType
+		}{rtype: *u}
+		t, ut = &nt.rtype, &nt.u
+	}
+
+	// The package is referred to by its import path and, in the type's
+	// string, by the last element of the path.
+	qualifier := pkgPath
+	for i := len(pkgPath) - 1; i >= 0; i-- {
+		if pkgPath[i] == '/' {
+			qualifier = pkgPath[i+1:]
+			break
+		}
+	}
+	t.str = resolveReflectName(newName(qualifier+"."+name, "", pkgPath, isExportedIdent(name)))
+	t.tflag = tflagUncommon
+	t.hash = fnv1(u.hash, []byte(pkgPath+"."+name)...)
+	ut.pkgPath = resolveReflectName(newName(pkgPath, "", "", false))
+	ut.mcount = 0
+	ut.moff = uint16(unsafe.Sizeof(uncommonType{}))
+
+	addConstructed(t)
+	namedLookupCache.m[key] = t
+	return t
+}
+
+// namedFuncType returns a copy of the func type ft laid out as a named
+// func type: the funcType, an uncommonType and then the parameters.
+func namedFuncType(ft *funcType) (*rtype, *uncommonType) {
+	in, out := ft.in(), ft.out()
+	var nt *funcType
+	var ut *uncommonType
+	var args []*rtype
+	switch n := len(in) + len(out); {
+	case n <= 4:
+		fixed := new(funcTypeUncommonFixed4)
+		nt, ut, args = &fixed.funcType, &fixed.u, fixed.args[:0]
+	case n <= 8:
+		fixed := new(funcTypeUncommonFixed8)
+		nt, ut, args = &fixed.funcType, &fixed.u, fixed.args[:0]
+	case n <= 16:
+		fixed := new(funcTypeUncommonFixed16)
+		nt, ut, args = &fixed.funcType, &fixed.u, fixed.args[:0]
+	case n <= 32:
+		fixed := new(funcTypeUncommonFixed32)
+		nt, ut, args = &fixed.funcType, &fixed.u, fixed.args[:0]
+	case n <= 64:
+		fixed := new(funcTypeUncommonFixed64)
+		nt, ut, args = &fixed.funcType, &fixed.u, fixed.args[:0]
+	case n <= 128:
+		fixed := new(funcTypeUncommonFixed128)
+		nt, ut, args = &fixed.funcType, &fixed.u, fixed.args[:0]
+	default:
+		panic("reflect.NamedOf: too many arguments")
+	}
+	*nt = *ft
+	args = append(args, in...)
+	args = append(args, out...)
+	return &nt.rtype, ut
+}
+
 func appendVarint(x []byte, v uintptr) []byte {
 	for ; v >= 0x80; v >>= 7 {
 		x = append(x, byte(v|0x80))
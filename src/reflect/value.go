@@ -7,6 +7,7 @@ package reflect
 import (
 	"math"
 	"runtime"
+	"strconv"
 	"unsafe"
 )
 
@@ -48,9 +49,11 @@ type Value struct {
 	//	- flagIndir: val holds a pointer to the data
 	//	- flagAddr: v.CanAddr is true (implies flagIndir)
 	//	- flagMethod: v is a method value.
+	//	- flagUnexportedOK: derived from AllowUnexportedAccess, so unexported
+	//	  fields are not read-only
 	// The next five bits give the Kind of the value.
 	// This repeats typ.Kind() except for method values.
-	// The remaining 23+ bits give a method number for method values.
+	// The remaining 22+ bits give a method number for method values.
 	// If flag.kind() != Func, code can assume that flagMethod is unset.
 	// If ifaceIndir(typ), code can assume that flagIndir is set.
 	flag
@@ -65,15 +68,20 @@ type Value struct {
 type flag uintptr
 
 const (
-	flagKindWidth        = 5 // there are 27 kinds
-	flagKindMask    flag = 1<<flagKindWidth - 1
-	flagStickyRO    flag = 1 << 5
-	flagEmbedRO     flag = 1 << 6
-	flagIndir       flag = 1 << 7
-	flagAddr        flag = 1 << 8
-	flagMethod      flag = 1 << 9
-	flagMethodShift      = 10
-	flagRO          flag = flagStickyRO | flagEmbedRO
+	flagKindWidth         = 5 // there are 27 kinds
+	flagKindMask     flag = 1<<flagKindWidth - 1
+	flagStickyRO     flag = 1 << 5
+	flagEmbedRO      flag = 1 << 6
+	flagIndir        flag = 1 << 7
+	flagAddr         flag = 1 << 8
+	flagMethod       flag = 1 << 9
+	flagUnexportedOK flag = 1 << 10
+	flagMethodShift       = 11
+	flagRO           flag = flagStickyRO | flagEmbedRO
+
+	// flagPerm holds the permission bits that values derived from
+	// v by Elem, Field, Index and the like inherit from v.
+	flagPerm flag = flagRO | flagUnexportedOK
 )
 
 func (f flag) kind() Kind {
@@ -238,7 +246,7 @@ func (v Value) Addr() Value {
 	if v.flag&flagAddr == 0 {
 		panic("reflect.Value.Addr of unaddressable value")
 	}
-	return Value{v.typ.ptrTo(), v.ptr, (v.flag & flagRO) | flag(Ptr)}
+	return Value{v.typ.ptrTo(), v.ptr, (v.flag & flagPerm) | flag(Ptr)}
 }
 
 // Bool returns v's underlying value.
@@ -249,14 +257,31 @@ func (v Value) Bool() bool {
 }
 
 // Bytes returns v's underlying value.
-// It panics if v's underlying value is not a slice of bytes.
+// It panics if v's underlying value is not a slice of bytes or
+// an addressable array of bytes. The element type may be any type
+// of kind Uint8, not only byte.
+// The result shares its storage with v: for an array, the result
+// refers to the array, with length and capacity equal to its length.
+// Changes made through the result are visible in v, and vice versa.
 func (v Value) Bytes() []byte {
-	v.mustBe(Slice)
-	if v.typ.Elem().Kind() != Uint8 {
-		panic("reflect.Value.Bytes of non-byte slice")
+	switch v.kind() {
+	case Slice:
+		if v.typ.Elem().Kind() != Uint8 {
+			panic("reflect.Value.Bytes of non-byte slice")
+		}
+		// Slice is always bigger than a word; assume flagIndir.
+		return *(*[]byte)(v.ptr)
+	case Array:
+		if v.typ.Elem().Kind() != Uint8 {
+			panic("reflect.Value.Bytes of non-byte array")
+		}
+		if !v.CanAddr() {
+			panic("reflect.Value.Bytes of unaddressable byte array")
+		}
+		n := v.typ.Len()
+		return (*[1 << 30]byte)(v.ptr)[:n:n]
 	}
-	// Slice is always bigger than a word; assume flagIndir.
-	return *(*[]byte)(v.ptr)
+	panic(&ValueError{"reflect.Value.Bytes", v.kind()})
 }
 
 // runes returns v's underlying value.
@@ -674,6 +699,26 @@ func (v Value) Cap() int {
 	panic(&ValueError{"reflect.Value.Cap", v.kind()})
 }
 
+// Clear deletes all entries from the map v or sets all elements of the
+// slice v, from 0 to its length, to their zero values, keeping the
+// storage of either for reuse.
+// It panics if v's Kind is not Map or Slice, or if v was obtained by
+// accessing unexported struct fields.
+func (v Value) Clear() {
+	switch v.kind() {
+	case Map:
+		v.mustBeExported()
+		mapclear(v.typ, v.pointer())
+	case Slice:
+		v.mustBeExported()
+		s := (*sliceHeader)(v.ptr)
+		elem := (*sliceType)(unsafe.Pointer(v.typ)).elem
+		typedmemclrpartial(elem, s.Data, 0, uintptr(s.Len)*elem.size)
+	default:
+		panic(&ValueError{"reflect.Value.Clear", v.kind()})
+	}
+}
+
 // Close closes the channel v.
 // It panics if v's Kind is not Chan.
 func (v Value) Close() {
@@ -700,8 +745,34 @@ func (v Value) Complex() complex128 {
 // It panics if v's Kind is not Interface or Ptr.
 // It returns the zero Value if v is nil.
 func (v Value) Elem() Value {
+	if !v.hasElem() {
+		panic(&ValueError{"reflect.Value.Elem", v.kind()})
+	}
+	return v.elem()
+}
+
+// TryElem is like Elem but reports whether it succeeded
+// instead of panicking. It returns the zero Value and false
+// if v's Kind is not Interface or Ptr, including when v is
+// the zero Value. Like Elem, it returns the zero Value and
+// true if v is a nil interface or pointer.
+func (v Value) TryElem() (Value, bool) {
+	if !v.hasElem() {
+		return Value{}, false
+	}
+	return v.elem(), true
+}
+
+// hasElem reports whether v's Kind is Interface or Ptr,
+// so that v.elem can be called.
+func (v Value) hasElem() bool {
 	k := v.kind()
-	switch k {
+	return k == Interface || k == Ptr
+}
+
+// elem implements Elem for a v whose Kind is Interface or Ptr.
+func (v Value) elem() Value {
+	switch v.kind() {
 	case Interface:
 		var eface interface{}
 		if v.typ.NumMethod() == 0 {
@@ -713,7 +784,7 @@ func (v Value) Elem() Value {
 		}
 		x := unpackEface(eface)
 		if x.flag != 0 {
-			x.flag |= v.flag & flagRO
+			x.flag |= v.flag & flagPerm
 		}
 		return x
 	case Ptr:
@@ -727,30 +798,57 @@ func (v Value) Elem() Value {
 		}
 		tt := (*ptrType)(unsafe.Pointer(v.typ))
 		typ := tt.elem
-		fl := v.flag&flagRO | flagIndir | flagAddr
+		fl := v.flag&flagPerm | flagIndir | flagAddr
 		fl |= flag(typ.Kind())
 		return Value{typ, ptr, fl}
 	}
-	panic(&ValueError{"reflect.Value.Elem", v.kind()})
+	panic("reflect: elem of non-interface, non-pointer value")
 }
 
 // Field returns the i'th field of the struct v.
 // It panics if v's Kind is not Struct or i is out of range.
 func (v Value) Field(i int) Value {
+	if !v.hasField(i) {
+		if v.kind() != Struct {
+			panic(&ValueError{"reflect.Value.Field", v.kind()})
+		}
+		panic("reflect: Field index out of range")
+	}
+	return v.field(i)
+}
+
+// TryField is like Field but reports whether it succeeded
+// instead of panicking. It returns the zero Value and false
+// if v's Kind is not Struct, including when v is the zero Value,
+// or if i is out of range.
+func (v Value) TryField(i int) (Value, bool) {
+	if !v.hasField(i) {
+		return Value{}, false
+	}
+	return v.field(i), true
+}
+
+// hasField reports whether v is a struct with an i'th field,
+// so that v.field(i) can be called.
+func (v Value) hasField(i int) bool {
 	if v.kind() != Struct {
-		panic(&ValueError{"reflect.Value.Field", v.kind()})
+		return false
 	}
 	tt := (*structType)(unsafe.Pointer(v.typ))
-	if uint(i) >= uint(len(tt.fields)) {
-		panic("reflect: Field index out of range")
-	}
+	return uint(i) < uint(len(tt.fields))
+}
+
+// field implements Field for a struct v with an i'th field.
+func (v Value) field(i int) Value {
+	tt := (*structType)(unsafe.Pointer(v.typ))
 	field := &tt.fields[i]
 	typ := field.typ
 
 	// Inherit permission bits from v, but clear flagEmbedRO.
-	fl := v.flag&(flagStickyRO|flagIndir|flagAddr) | flag(typ.Kind())
-	// Using an unexported field forces flagRO.
-	if !field.name.isExported() {
+	fl := v.flag&(flagStickyRO|flagUnexportedOK|flagIndir|flagAddr) | flag(typ.Kind())
+	// Using an unexported field forces flagRO,
+	// unless access was allowed by AllowUnexportedAccess.
+	if !field.name.isExported() && fl&flagUnexportedOK == 0 {
 		if field.name.name() == "" {
 			fl |= flagEmbedRO
 		} else {
@@ -827,12 +925,211 @@ var uint8Type = TypeOf(uint8(0)).(*rtype)
 // Index returns v's i'th element.
 // It panics if v's Kind is not Array, Slice, or String or i is out of range.
 func (v Value) Index(i int) Value {
+	n, ok := v.indexLen()
+	if !ok {
+		panic(&ValueError{"reflect.Value.Index", v.kind()})
+	}
+	if uint(i) >= uint(n) {
+		panic(indexError("reflect: ", v.kind(), i, n))
+	}
+	return v.index(i)
+}
+
+// TryIndex is like Index but reports whether it succeeded
+// instead of panicking. It returns the zero Value and false
+// if v's Kind is not Array, Slice, or String, including when v
+// is the zero Value, or if i is out of range.
+func (v Value) TryIndex(i int) (Value, bool) {
+	n, ok := v.indexLen()
+	if !ok || uint(i) >= uint(n) {
+		return Value{}, false
+	}
+	return v.index(i), true
+}
+
+// ForEach calls f for each element of v in index order, stopping
+// early if f returns false. The elem passed to f is the Value
+// v.Index(i) would return: elements of slices and addressable
+// arrays are addressable and refer to v's underlying storage.
+// ForEach checks v's Kind and computes the element type once,
+// so it is considerably cheaper than calling Index in a loop.
+// If f changes the length of v, the change is not observed.
+// It panics if v's Kind is not Array, Slice, or String.
+func (v Value) ForEach(f func(i int, elem Value) bool) {
+	n, ok := v.indexLen()
+	if !ok {
+		panic(&ValueError{"reflect.Value.ForEach", v.kind()})
+	}
+	var (
+		typ  *rtype
+		base unsafe.Pointer
+		fl   flag
+	)
 	switch v.kind() {
 	case Array:
-		tt := (*arrayType)(unsafe.Pointer(v.typ))
-		if uint(i) >= uint(tt.len) {
-			panic("reflect: array index out of range")
+		if v.flag&flagIndir == 0 {
+			// v.ptr is the array data itself; see index.
+			for i := 0; i < n; i++ {
+				if !f(i, v.index(i)) {
+					return
+				}
+			}
+			return
+		}
+		typ = (*arrayType)(unsafe.Pointer(v.typ)).elem
+		base = v.ptr
+		fl = v.flag&(flagPerm|flagIndir|flagAddr) | flag(typ.Kind())
+	case Slice:
+		typ = (*sliceType)(unsafe.Pointer(v.typ)).elem
+		base = (*sliceHeader)(v.ptr).Data
+		fl = flagAddr | flagIndir | v.flag&flagPerm | flag(typ.Kind())
+	case String:
+		typ = uint8Type
+		base = (*stringHeader)(v.ptr).Data
+		fl = v.flag&flagPerm | flag(Uint8) | flagIndir
+	}
+	for i := 0; i < n; i++ {
+		if !f(i, Value{typ, arrayAt(base, i, typ.size), fl}) {
+			return
 		}
+	}
+}
+
+// ForEachMap calls f for each key and value of the map v, stopping
+// early if f returns false. Iteration order is unspecified and
+// follows the same rules as a range statement over the map: an
+// entry deleted during iteration is not visited, and an entry added
+// during iteration may or may not be visited. The key and val
+// passed to f are copies, as with MapKeys and MapIndex, and may be
+// retained after f returns. ForEachMap avoids the lookup that
+// MapIndex performs for every key.
+// It panics if v's Kind is not Map.
+func (v Value) ForEachMap(f func(key, val Value) bool) {
+	v.mustBe(Map)
+	tt := (*mapType)(unsafe.Pointer(v.typ))
+	keyType := tt.key
+	valType := tt.elem
+	keyFlag := v.flag&flagPerm | flag(keyType.Kind())
+	valFlag := v.flag&flagPerm | flag(valType.Kind())
+
+	it := mapiterinit(v.typ, v.pointer())
+	for {
+		k := mapiterkey(it)
+		if k == nil {
+			return
+		}
+		key := copyVal(keyType, keyFlag, k)
+		val := copyVal(valType, valFlag, mapitervalue(it))
+		if !f(key, val) {
+			return
+		}
+		mapiternext(it)
+	}
+}
+
+// A MapIter is an iterator for ranging over a map.
+// See Value.MapRange.
+type MapIter struct {
+	m  Value
+	it unsafe.Pointer
+}
+
+// Key returns the key of the iterator's current map entry.
+func (it *MapIter) Key() Value {
+	if it.it == nil {
+		panic("reflect: MapIter.Key called before Next")
+	}
+	k := mapiterkey(it.it)
+	if k == nil {
+		panic("reflect: MapIter.Key called on exhausted iterator")
+	}
+	t := (*mapType)(unsafe.Pointer(it.m.typ))
+	return copyVal(t.key, it.m.flag&flagPerm|flag(t.key.Kind()), k)
+}
+
+// Value returns the value of the iterator's current map entry.
+func (it *MapIter) Value() Value {
+	if it.it == nil {
+		panic("reflect: MapIter.Value called before Next")
+	}
+	if mapiterkey(it.it) == nil {
+		panic("reflect: MapIter.Value called on exhausted iterator")
+	}
+	t := (*mapType)(unsafe.Pointer(it.m.typ))
+	return copyVal(t.elem, it.m.flag&flagPerm|flag(t.elem.Kind()), mapitervalue(it.it))
+}
+
+// Next advances the map iterator and reports whether there is another
+// entry. It returns false when the iterator is exhausted; subsequent
+// calls to Key, Value, or Next will panic.
+func (it *MapIter) Next() bool {
+	if it.it == nil {
+		it.it = mapiterinit(it.m.typ, it.m.pointer())
+	} else {
+		if mapiterkey(it.it) == nil {
+			panic("reflect: MapIter.Next called on exhausted iterator")
+		}
+		mapiternext(it.it)
+	}
+	return mapiterkey(it.it) != nil
+}
+
+// MapRange returns a range iterator for a map.
+// It panics if v's Kind is not Map.
+//
+// Call Next to advance the iterator, and Key/Value to access each entry.
+// Next returns false when the iterator is exhausted.
+// MapRange follows the same iteration semantics as a range statement
+// and, unlike MapKeys, does not allocate a slice of all the keys.
+//
+// Example:
+//
+//	iter := reflect.ValueOf(m).MapRange()
+//	for iter.Next() {
+//		k := iter.Key()
+//		v := iter.Value()
+//		...
+//	}
+//
+func (v Value) MapRange() *MapIter {
+	v.mustBe(Map)
+	return &MapIter{m: v}
+}
+
+// copyVal returns a Value containing the map key or value at ptr,
+// allocating a new variable as needed.
+func copyVal(typ *rtype, fl flag, ptr unsafe.Pointer) Value {
+	if ifaceIndir(typ) {
+		// Copy result so future changes to the map
+		// won't change the underlying value.
+		c := unsafe_New(typ)
+		typedmemmove(typ, c, ptr)
+		return Value{typ, c, fl | flagIndir}
+	}
+	return Value{typ, *(*unsafe.Pointer)(ptr), fl}
+}
+
+// indexLen returns the number of elements of v that can be
+// accessed with v.index and reports whether v's Kind is
+// Array, Slice, or String.
+func (v Value) indexLen() (n int, ok bool) {
+	switch v.kind() {
+	case Array:
+		return int((*arrayType)(unsafe.Pointer(v.typ)).len), true
+	case Slice:
+		return (*sliceHeader)(v.ptr).Len, true
+	case String:
+		return (*stringHeader)(v.ptr).Len, true
+	}
+	return 0, false
+}
+
+// index implements Index for an array, slice or string v
+// and an i that is in range.
+func (v Value) index(i int) Value {
+	switch v.kind() {
+	case Array:
+		tt := (*arrayType)(unsafe.Pointer(v.typ))
 		typ := tt.elem
 		offset := uintptr(i) * typ.size
 
@@ -842,32 +1139,26 @@ func (v Value) Index(i int) Value {
 		// In the latter case, we must be doing Index(0), so offset = 0,
 		// so v.ptr + offset is still okay.
 		val := unsafe.Pointer(uintptr(v.ptr) + offset)
-		fl := v.flag&(flagRO|flagIndir|flagAddr) | flag(typ.Kind()) // bits same as overall array
+		fl := v.flag&(flagPerm|flagIndir|flagAddr) | flag(typ.Kind()) // bits same as overall array
 		return Value{typ, val, fl}
 
 	case Slice:
 		// Element flag same as Elem of Ptr.
 		// Addressable, indirect, possibly read-only.
 		s := (*sliceHeader)(v.ptr)
-		if uint(i) >= uint(s.Len) {
-			panic("reflect: slice index out of range")
-		}
 		tt := (*sliceType)(unsafe.Pointer(v.typ))
 		typ := tt.elem
 		val := arrayAt(s.Data, i, typ.size)
-		fl := flagAddr | flagIndir | v.flag&flagRO | flag(typ.Kind())
+		fl := flagAddr | flagIndir | v.flag&flagPerm | flag(typ.Kind())
 		return Value{typ, val, fl}
 
 	case String:
 		s := (*stringHeader)(v.ptr)
-		if uint(i) >= uint(s.Len) {
-			panic("reflect: string index out of range")
-		}
 		p := arrayAt(s.Data, i, 1)
-		fl := v.flag&flagRO | flag(Uint8) | flagIndir
+		fl := v.flag&flagPerm | flag(Uint8) | flagIndir
 		return Value{uint8Type, p, fl}
 	}
-	panic(&ValueError{"reflect.Value.Index", v.kind()})
+	panic("reflect: index of non-array, non-slice, non-string value")
 }
 
 // Int returns v's underlying value, as an int64.
@@ -895,7 +1186,14 @@ func (v Value) CanInterface() bool {
 	if v.flag == 0 {
 		panic(&ValueError{"reflect.Value.CanInterface", Invalid})
 	}
-	return v.flag&flagRO == 0
+	return v.canInterface()
+}
+
+// canInterface reports whether v is valid and was not obtained
+// by accessing unexported struct fields, so that Interface
+// can be used without panicking.
+func (v Value) canInterface() bool {
+	return v.flag != 0 && v.flag&flagRO == 0
 }
 
 // Interface returns v's current value as an interface{}.
@@ -907,6 +1205,16 @@ func (v Value) Interface() (i interface{}) {
 	return valueInterface(v, true)
 }
 
+// TryInterface is like Interface but reports whether it succeeded
+// instead of panicking. It returns nil and false if v is the zero
+// Value or was obtained by accessing unexported struct fields.
+func (v Value) TryInterface() (i interface{}, ok bool) {
+	if !v.canInterface() {
+		return nil, false
+	}
+	return valueInterface(v, true), true
+}
+
 func valueInterface(v Value, safe bool) interface{} {
 	if v.flag == 0 {
 		panic(&ValueError{"reflect.Value.Interface", 0})
@@ -992,6 +1300,37 @@ func (v Value) Kind() Kind {
 	return v.kind()
 }
 
+// IsInteger reports whether v's Kind is a signed or unsigned integer
+// kind, including Uintptr. It returns false if v is the zero Value.
+func (v Value) IsInteger() bool {
+	return v.kind().IsInteger()
+}
+
+// IsUnsigned reports whether v's Kind is an unsigned integer kind,
+// including Uintptr. It returns false if v is the zero Value.
+func (v Value) IsUnsigned() bool {
+	return v.kind().IsUnsigned()
+}
+
+// IsFloat reports whether v's Kind is Float32 or Float64.
+// It returns false if v is the zero Value.
+func (v Value) IsFloat() bool {
+	return v.kind().IsFloat()
+}
+
+// IsComplex reports whether v's Kind is Complex64 or Complex128.
+// It returns false if v is the zero Value.
+func (v Value) IsComplex() bool {
+	return v.kind().IsComplex()
+}
+
+// IsNumeric reports whether v's Kind is an integer, floating-point or
+// complex kind; UnsafePointer is not numeric. It returns false if v is
+// the zero Value.
+func (v Value) IsNumeric() bool {
+	return v.kind().IsNumeric()
+}
+
 // Len returns v's length.
 // It panics if v's Kind is not Array, Chan, Map, Slice, or String.
 func (v Value) Len() int {
@@ -1042,7 +1381,7 @@ func (v Value) MapIndex(key Value) Value {
 		return Value{}
 	}
 	typ := tt.elem
-	fl := (v.flag | key.flag) & flagRO
+	fl := (v.flag|key.flag)&flagRO | v.flag&flagUnexportedOK
 	fl |= flag(typ.Kind())
 	if ifaceIndir(typ) {
 		// Copy result so future changes to the map
@@ -1064,7 +1403,7 @@ func (v Value) MapKeys() []Value {
 	tt := (*mapType)(unsafe.Pointer(v.typ))
 	keyType := tt.key
 
-	fl := v.flag&flagRO | flag(keyType.Kind())
+	fl := v.flag&flagPerm | flag(keyType.Kind())
 
 	m := v.pointer()
 	mlen := int(0)
@@ -1213,6 +1552,79 @@ func (v Value) OverflowUint(x uint64) bool {
 	panic(&ValueError{"reflect.Value.OverflowUint", v.kind()})
 }
 
+// FitsInt reports whether v's value can be converted exactly to a
+// signed integer of the given bit size: it must be a whole number
+// within range. The bit sizes 0, 8, 16, 32, and 64 correspond to int,
+// int8, int16, int32, and int64. NaN and infinities never fit.
+// It panics if v's Kind is not an integer or floating-point kind, or
+// if bits is not one of the sizes above.
+func (v Value) FitsInt(bits int) bool {
+	n := intBitSize("reflect.Value.FitsInt", bits)
+	switch v.kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		x := v.Int()
+		return x == (x<<(64-n))>>(64-n)
+	case Uint, Uintptr, Uint8, Uint16, Uint32, Uint64:
+		return v.Uint() < 1<<(n-1)
+	case Float32, Float64:
+		x := v.Float()
+		if math.Trunc(x) != x { // also rejects NaN
+			return false
+		}
+		limit := math.Ldexp(1, int(n-1))
+		return -limit <= x && x < limit
+	}
+	panic(&ValueError{"reflect.Value.FitsInt", v.kind()})
+}
+
+// FitsFloat reports whether v's integer value can be converted to a
+// floating-point number of the given bit size, 32 or 64, without
+// rounding, so that converting the result back yields the same value.
+// It panics if v's Kind is not an integer kind, or if bits is not 32
+// or 64.
+func (v Value) FitsFloat(bits int) bool {
+	var mant uint
+	switch bits {
+	case 32:
+		mant = 24
+	case 64:
+		mant = 53
+	default:
+		panic("reflect.Value.FitsFloat: invalid bit size " + strconv.Itoa(bits))
+	}
+	var m uint64
+	switch v.kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		x := v.Int()
+		m = uint64(x)
+		if x < 0 {
+			m = -m
+		}
+	case Uint, Uintptr, Uint8, Uint16, Uint32, Uint64:
+		m = v.Uint()
+	default:
+		panic(&ValueError{"reflect.Value.FitsFloat", v.kind()})
+	}
+	// The value is exact if its significant bits fit in the mantissa;
+	// trailing zeros are absorbed by the exponent.
+	for m != 0 && m&1 == 0 {
+		m >>= 1
+	}
+	return m < 1<<mant
+}
+
+// intBitSize returns the integer bit size named by bits,
+// where 0 means the size of int.
+func intBitSize(op string, bits int) uint {
+	switch bits {
+	case 0:
+		return uint(8 * ptrSize)
+	case 8, 16, 32, 64:
+		return uint(bits)
+	}
+	panic(op + ": invalid bit size " + strconv.Itoa(bits))
+}
+
 // Pointer returns v's value as a uintptr.
 // It returns uintptr instead of unsafe.Pointer so that
 // code using reflect cannot obtain unsafe.Pointers
@@ -1348,6 +1760,8 @@ func (v Value) SetBool(x bool) {
 
 // SetBytes sets v's underlying value.
 // It panics if v's underlying value is not a slice of bytes.
+// As with Bytes, the element type may be any type of kind Uint8.
+// The slice v then shares its storage with x.
 func (v Value) SetBytes(x []byte) {
 	v.mustBeAssignable()
 	v.mustBe(Slice)
@@ -1424,7 +1838,7 @@ func (v Value) SetLen(n int) {
 	v.mustBe(Slice)
 	s := (*sliceHeader)(v.ptr)
 	if uint(n) > uint(s.Cap) {
-		panic("reflect: slice length out of range in SetLen")
+		panic(boundsError("reflect.Value.SetLen: slice length out of range", "["+strconv.Itoa(n)+"]", "capacity", s.Cap, n >= 0))
 	}
 	s.Len = n
 }
@@ -1436,8 +1850,11 @@ func (v Value) SetCap(n int) {
 	v.mustBeAssignable()
 	v.mustBe(Slice)
 	s := (*sliceHeader)(v.ptr)
-	if n < s.Len || n > s.Cap {
-		panic("reflect: slice capacity out of range in SetCap")
+	if n < s.Len {
+		panic(boundsError("reflect.Value.SetCap: slice capacity out of range", "["+strconv.Itoa(n)+"]", "length", s.Len, n >= 0))
+	}
+	if n > s.Cap {
+		panic(boundsError("reflect.Value.SetCap: slice capacity out of range", "["+strconv.Itoa(n)+"]", "capacity", s.Cap, true))
 	}
 	s.Cap = n
 }
@@ -1544,14 +1961,14 @@ func (v Value) Slice(i, j int) Value {
 	case String:
 		s := (*stringHeader)(v.ptr)
 		if i < 0 || j < i || j > s.Len {
-			panic("reflect.Value.Slice: string slice index out of bounds")
+			panic(sliceError("reflect.Value.Slice: ", String, i, j, s.Len))
 		}
 		t := stringHeader{arrayAt(s.Data, i, 1), j - i}
 		return Value{v.typ, unsafe.Pointer(&t), v.flag}
 	}
 
 	if i < 0 || j < i || j > cap {
-		panic("reflect.Value.Slice: slice index out of bounds")
+		panic(sliceError("reflect.Value.Slice: ", v.kind(), i, j, cap))
 	}
 
 	// Declare slice so that gc can see the base pointer in it.
@@ -1568,10 +1985,77 @@ func (v Value) Slice(i, j int) Value {
 		s.Data = base
 	}
 
-	fl := v.flag&flagRO | flagIndir | flag(Slice)
+	fl := v.flag&flagPerm | flagIndir | flag(Slice)
 	return Value{typ.common(), unsafe.Pointer(&x), fl}
 }
 
+// The bounds errors below describe the failing indexes the way the
+// runtime's own index and slice errors do, such as
+//	slice bounds out of range [:5] with capacity 4
+// so that tools that parse one kind of message also handle the other.
+
+// indexError returns the panic message for an index i that is out of
+// range for an array, slice or string of length n.
+func indexError(prefix string, k Kind, i, n int) string {
+	return boundsError(prefix+k.String()+" index out of range", "["+strconv.Itoa(i)+"]", "length", n, i >= 0)
+}
+
+// sliceError returns the panic message for v[i:j] with out of range
+// indexes, where bound is the capacity of an array or slice or the
+// length of a string. As in the runtime, the upper index is checked first.
+func sliceError(prefix string, k Kind, i, j, bound int) string {
+	what := "capacity"
+	if k == String {
+		what = "length"
+	}
+	prefix += sliceBoundsDesc(k)
+	if j < 0 || j > bound {
+		return boundsError(prefix, "[:"+strconv.Itoa(j)+"]", what, bound, j >= 0)
+	}
+	if i < 0 {
+		return boundsError(prefix, "["+strconv.Itoa(i)+":]", "", 0, false)
+	}
+	return boundsError(prefix, "["+strconv.Itoa(i)+":"+strconv.Itoa(j)+"]", "", 0, false)
+}
+
+// slice3Error returns the panic message for v[i:j:k] with out of range
+// indexes, where bound is the capacity of the array or slice.
+func slice3Error(prefix string, kind Kind, i, j, k, bound int) string {
+	prefix += sliceBoundsDesc(kind)
+	switch {
+	case k < 0 || k > bound:
+		return boundsError(prefix, "[::"+strconv.Itoa(k)+"]", "capacity", bound, k >= 0)
+	case j < 0:
+		return boundsError(prefix, "[:"+strconv.Itoa(j)+":]", "", 0, false)
+	case j > k:
+		return boundsError(prefix, "[:"+strconv.Itoa(j)+":"+strconv.Itoa(k)+"]", "", 0, false)
+	case i < 0:
+		return boundsError(prefix, "["+strconv.Itoa(i)+"::]", "", 0, false)
+	}
+	return boundsError(prefix, "["+strconv.Itoa(i)+":"+strconv.Itoa(j)+":]", "", 0, false)
+}
+
+// sliceBoundsDesc returns the description of a slice bounds error for
+// a value of kind k: "slice bounds out of range", as in the runtime,
+// preceded by "array" or "string" for those kinds.
+func sliceBoundsDesc(k Kind) string {
+	if k == Slice {
+		return "slice bounds out of range"
+	}
+	return k.String() + " slice bounds out of range"
+}
+
+// boundsError assembles a bounds error message from its description and
+// the bracketed indexes. If withBound is set and what is not empty, the
+// message ends with the length or capacity that was exceeded.
+func boundsError(desc, indexes, what string, bound int, withBound bool) string {
+	msg := desc + " " + indexes
+	if withBound && what != "" {
+		msg += " with " + what + " " + strconv.Itoa(bound)
+	}
+	return msg
+}
+
 // Slice3 is the 3-index form of the slice operation: it returns v[i:j:k].
 // It panics if v's Kind is not Array or Slice, or if v is an unaddressable array,
 // or if the indexes are out of bounds.
@@ -1602,7 +2086,7 @@ func (v Value) Slice3(i, j, k int) Value {
 	}
 
 	if i < 0 || j < i || k < j || k > cap {
-		panic("reflect.Value.Slice3: slice index out of bounds")
+		panic(slice3Error("reflect.Value.Slice3: ", v.kind(), i, j, k, cap))
 	}
 
 	// Declare slice so that the garbage collector
@@ -1620,7 +2104,7 @@ func (v Value) Slice3(i, j, k int) Value {
 		s.Data = base
 	}
 
-	fl := v.flag&flagRO | flagIndir | flag(Slice)
+	fl := v.flag&flagPerm | flagIndir | flag(Slice)
 	return Value{typ.common(), unsafe.Pointer(&x), fl}
 }
 
@@ -2078,6 +2562,32 @@ func Indirect(v Value) Value {
 	return v.Elem()
 }
 
+// AllowUnexportedAccess returns a Value identical to v except that it
+// is not read-only, so that Interface, Set and the other methods that
+// refuse values obtained using unexported struct fields work on it.
+// Values later derived from the result by Elem, Field, Index, MapIndex,
+// MapKeys, Slice and the like inherit the permission, including values
+// for unexported fields. Values derived from v before the call, method
+// values and the results of Convert do not.
+// It panics if v is not addressable.
+//
+// Like package unsafe, AllowUnexportedAccess bypasses the protection
+// the language gives to unexported fields. It is meant for code that
+// inspects or builds values it owns, such as test and serialization
+// helpers; the caller is responsible for not using it to read or
+// modify the internal state of values owned by other packages,
+// including state reached through interfaces or pointers they hold.
+func AllowUnexportedAccess(v Value) Value {
+	if v.flag == 0 {
+		panic(&ValueError{"reflect.AllowUnexportedAccess", Invalid})
+	}
+	if v.flag&flagAddr == 0 {
+		panic("reflect.AllowUnexportedAccess of unaddressable value")
+	}
+	v.flag = v.flag&^flagRO | flagUnexportedOK
+	return v
+}
+
 // ValueOf returns a new Value initialized to the concrete value
 // stored in the interface i. ValueOf(nil) returns the zero Value.
 func ValueOf(i interface{}) Value {
@@ -2177,6 +2687,196 @@ func (v Value) Convert(t Type) Value {
 	return op(v, t)
 }
 
+// ConvertSat is like Convert, but conversions to integer types saturate:
+// a value that cannot be represented by t converts to the minimum or
+// maximum value of t, whichever is nearer, and a NaN converts to zero.
+// Other conversions, including those to floating-point types, follow
+// Convert.
+func (v Value) ConvertSat(t Type) Value {
+	if v.flag&flagMethod != 0 {
+		v = makeMethodValue("ConvertSat", v)
+	}
+	op := convertOp(t.common(), v.typ)
+	if op == nil {
+		panic("reflect.Value.ConvertSat: value of type " + v.typ.String() + " cannot be converted to type " + t.String())
+	}
+	switch t.Kind() {
+	case Int, Int8, Int16, Int32, Int64, Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		if overflowValue(t.common(), v) != "" {
+			return cvtIntSat(v, t)
+		}
+	}
+	return op(v, t)
+}
+
+// ConvertChecked is like Convert, but instead of panicking or wrapping
+// it returns a *ConversionError if v cannot be converted to type t or
+// if v is a number whose value cannot be represented by t's numeric type.
+// Conversions of numbers that only lose precision, such as from an
+// integer or float64 to float32, do not fail.
+func (v Value) ConvertChecked(t Type) (Value, error) {
+	if v.flag&flagMethod != 0 {
+		v = makeMethodValue("ConvertChecked", v)
+	}
+	op := convertOp(t.common(), v.typ)
+	if op == nil {
+		return Value{}, &ConversionError{"reflect.Value.ConvertChecked", v.typ, t, ""}
+	}
+	if s := overflowValue(t.common(), v); s != "" {
+		return Value{}, &ConversionError{"reflect.Value.ConvertChecked", v.typ, t, s}
+	}
+	return op(v, t), nil
+}
+
+// A ConversionError is returned by SetConvert, ConvertChecked and the
+// Set*Checked methods when a value cannot be stored in the destination,
+// either because its type cannot be converted to the destination's type
+// or because the value overflows the destination's numeric type.
+type ConversionError struct {
+	Method string // the method that failed, such as "reflect.Value.SetConvert"
+	Src    Type   // type of the value being stored
+	Dst    Type   // type of the destination
+	Value  string // the value that overflowed, or "" if the types are not convertible
+}
+
+func (e *ConversionError) Error() string {
+	if e.Value == "" {
+		return e.Method + ": value of type " + e.Src.String() + " cannot be converted to type " + e.Dst.String()
+	}
+	return e.Method + ": value " + e.Value + " of type " + e.Src.String() + " overflows type " + e.Dst.String()
+}
+
+// SetConvert converts x to v's type, following the rules of Convert,
+// and assigns the result to v. Instead of panicking or truncating, it
+// returns a *ConversionError if x cannot be converted to v's type or
+// if x is a number whose value cannot be represented by v's numeric type.
+// As with Set, it panics if CanSet returns false.
+func (v Value) SetConvert(x Value) error {
+	v.mustBeAssignable()
+	x.mustBeExported() // do not let unexported x leak
+	if x.flag&flagMethod != 0 {
+		x = makeMethodValue("SetConvert", x)
+	}
+	op := convertOp(v.typ, x.typ)
+	if op == nil {
+		return &ConversionError{"reflect.Value.SetConvert", x.typ, v.typ, ""}
+	}
+	if s := overflowValue(v.typ, x); s != "" {
+		return &ConversionError{"reflect.Value.SetConvert", x.typ, v.typ, s}
+	}
+	v.Set(op(x, v.typ))
+	return nil
+}
+
+// SetIntChecked is like SetInt, but if x cannot be represented by v's type
+// it returns a *ConversionError and leaves v unchanged.
+func (v Value) SetIntChecked(x int64) error {
+	v.mustBeAssignable()
+	switch k := v.kind(); k {
+	default:
+		panic(&ValueError{"reflect.Value.SetIntChecked", k})
+	case Int, Int8, Int16, Int32, Int64:
+	}
+	if v.OverflowInt(x) {
+		return &ConversionError{"reflect.Value.SetIntChecked", TypeOf(x), v.typ, strconv.FormatInt(x, 10)}
+	}
+	v.SetInt(x)
+	return nil
+}
+
+// SetUintChecked is like SetUint, but if x cannot be represented by v's type
+// it returns a *ConversionError and leaves v unchanged.
+func (v Value) SetUintChecked(x uint64) error {
+	v.mustBeAssignable()
+	switch k := v.kind(); k {
+	default:
+		panic(&ValueError{"reflect.Value.SetUintChecked", k})
+	case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+	}
+	if v.OverflowUint(x) {
+		return &ConversionError{"reflect.Value.SetUintChecked", TypeOf(x), v.typ, strconv.FormatUint(x, 10)}
+	}
+	v.SetUint(x)
+	return nil
+}
+
+// SetFloatChecked is like SetFloat, but if x cannot be represented by v's type
+// it returns a *ConversionError and leaves v unchanged.
+func (v Value) SetFloatChecked(x float64) error {
+	v.mustBeAssignable()
+	switch k := v.kind(); k {
+	default:
+		panic(&ValueError{"reflect.Value.SetFloatChecked", k})
+	case Float32, Float64:
+	}
+	if v.OverflowFloat(x) {
+		return &ConversionError{"reflect.Value.SetFloatChecked", TypeOf(x), v.typ, strconv.FormatFloat(x, 'g', -1, 64)}
+	}
+	v.SetFloat(x)
+	return nil
+}
+
+// overflowValue returns the formatted value of x if x is a number that
+// cannot be represented by the numeric type dst, and "" otherwise.
+// Converting a floating-point number to an integer type overflows if
+// the truncated value cannot be represented.
+func overflowValue(dst *rtype, x Value) string {
+	d := Value{dst, nil, flag(dst.Kind())}
+	bits := int(dst.size * 8)
+	switch dst.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		switch x.kind() {
+		case Int, Int8, Int16, Int32, Int64:
+			if i := x.Int(); d.OverflowInt(i) {
+				return strconv.FormatInt(i, 10)
+			}
+		case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+			if u := x.Uint(); u > math.MaxInt64 || d.OverflowInt(int64(u)) {
+				return strconv.FormatUint(u, 10)
+			}
+		case Float32, Float64:
+			// f+max rather than f > -max-1, which rounds to -max for Int64.
+			if f, max := x.Float(), math.Ldexp(1, bits-1); !(f+max > -1 && f < max) {
+				return strconv.FormatFloat(f, 'g', -1, 64)
+			}
+		}
+	case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+		switch x.kind() {
+		case Int, Int8, Int16, Int32, Int64:
+			if i := x.Int(); i < 0 || d.OverflowUint(uint64(i)) {
+				return strconv.FormatInt(i, 10)
+			}
+		case Uint, Uint8, Uint16, Uint32, Uint64, Uintptr:
+			if u := x.Uint(); d.OverflowUint(u) {
+				return strconv.FormatUint(u, 10)
+			}
+		case Float32, Float64:
+			if f := x.Float(); !(f > -1 && f < math.Ldexp(1, bits)) {
+				return strconv.FormatFloat(f, 'g', -1, 64)
+			}
+		}
+	case Float32:
+		switch x.kind() {
+		case Float32, Float64:
+			if f := x.Float(); d.OverflowFloat(f) {
+				return strconv.FormatFloat(f, 'g', -1, 64)
+			}
+		}
+	case Complex64:
+		switch x.kind() {
+		case Complex64, Complex128:
+			if c := x.Complex(); d.OverflowComplex(c) {
+				im := strconv.FormatFloat(imag(c), 'g', -1, 64)
+				if im[0] != '-' && im[0] != '+' {
+					im = "+" + im
+				}
+				return "(" + strconv.FormatFloat(real(c), 'g', -1, 64) + im + "i)"
+			}
+		}
+	}
+	return ""
+}
+
 // convertOp returns the function to convert a value of type src
 // to a value of type dst. If the conversion is illegal, convertOp returns nil.
 func convertOp(dst, src *rtype) func(Value, Type) Value {
@@ -2238,15 +2938,16 @@ func convertOp(dst, src *rtype) func(Value, Type) Value {
 		}
 	}
 
-	// dst and src have same underlying type.
-	if haveIdenticalUnderlyingType(dst, src) {
+	// dst and src have same underlying type, ignoring struct tags.
+	if haveIdenticalUnderlyingType(dst, src, false) {
 		return cvtDirect
 	}
 
-	// dst and src are unnamed pointer types with same underlying base type.
+	// dst and src are unnamed pointer types with same underlying base type,
+	// ignoring struct tags.
 	if dst.Kind() == Ptr && dst.Name() == "" &&
 		src.Kind() == Ptr && src.Name() == "" &&
-		haveIdenticalUnderlyingType(dst.Elem().common(), src.Elem().common()) {
+		haveIdenticalUnderlyingType(dst.Elem().common(), src.Elem().common(), false) {
 		return cvtDirect
 	}
 
@@ -2372,6 +3073,37 @@ func cvtComplex(v Value, t Type) Value {
 	return makeComplex(v.flag&flagRO, v.Complex(), t)
 }
 
+// cvtIntSat returns the minimum or maximum value of the integer type t,
+// whichever is nearer to the number v, which overflows t, or zero if
+// v is a NaN. It implements the saturating conversions of ConvertSat.
+func cvtIntSat(v Value, t Type) Value {
+	bits := t.Size() * 8
+	neg := false
+	switch v.kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		neg = v.Int() < 0
+	case Float32, Float64:
+		f := v.Float()
+		if f != f {
+			return makeInt(v.flag&flagRO, 0, t)
+		}
+		neg = f < 0
+	}
+	var x uint64
+	switch t.Kind() {
+	case Int, Int8, Int16, Int32, Int64:
+		x = 1<<(bits-1) - 1 // max
+		if neg {
+			x = -x - 1 // min, truncated by makeInt
+		}
+	default:
+		if !neg {
+			x = 1<<bits - 1 // max, all ones for 64 bits
+		}
+	}
+	return makeInt(v.flag&flagRO, x, t)
+}
+
 // convertOp: intXX -> string
 func cvtIntString(v Value, t Type) Value {
 	return makeString(v.flag&flagRO, string(v.Int()), t)
@@ -2470,6 +3202,9 @@ func mapassign(t *rtype, m unsafe.Pointer, key, val unsafe.Pointer)
 //go:noescape
 func mapdelete(t *rtype, m unsafe.Pointer, key unsafe.Pointer)
 
+//go:noescape
+func mapclear(t *rtype, m unsafe.Pointer)
+
 // m escapes into the return value, but the caller of mapiterinit
 // doesn't let the return value escape.
 //go:noescape
@@ -2478,6 +3213,9 @@ func mapiterinit(t *rtype, m unsafe.Pointer) unsafe.Pointer
 //go:noescape
 func mapiterkey(it unsafe.Pointer) (key unsafe.Pointer)
 
+//go:noescape
+func mapitervalue(it unsafe.Pointer) (val unsafe.Pointer)
+
 //go:noescape
 func mapiternext(it unsafe.Pointer)
 
@@ -2502,6 +3240,11 @@ func typedmemmove(t *rtype, dst, src unsafe.Pointer)
 //go:noescape
 func typedmemmovepartial(t *rtype, dst, src unsafe.Pointer, off, size uintptr)
 
+// typedmemclrpartial is like typedmemmovepartial but clears the
+// size bytes at ptr instead of copying into them.
+//go:noescape
+func typedmemclrpartial(t *rtype, ptr unsafe.Pointer, off, size uintptr)
+
 // typedslicecopy copies a slice of elemType values from src to dst,
 // returning the number of elements copied.
 //go:noescape
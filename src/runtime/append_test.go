@@ -100,6 +100,18 @@ func BenchmarkAppendSlice(b *testing.B) {
 	}
 }
 
+func BenchmarkAppendSliceReuse(b *testing.B) {
+	for _, length := range []int{1, 4, 7, 8, 15, 16, 32} {
+		b.Run(fmt.Sprint(length, "Bytes"), func(b *testing.B) {
+			x := make([]byte, 0, N)
+			y := make([]byte, length)
+			for i := 0; i < b.N; i++ {
+				x = append(x[:0], y...)
+			}
+		})
+	}
+}
+
 func BenchmarkAppendStr(b *testing.B) {
 	for _, str := range []string{
 		"1",
@@ -160,6 +172,40 @@ func TestAppendOverlap(t *testing.T) {
 	}
 }
 
+func TestAppendReuse(t *testing.T) {
+	// The source overlaps the array that is reused.
+	x := []byte("1234")
+	x = append(x[:0], x[1:]...)
+	if got, want := string(x), "234"; got != want {
+		t.Errorf("append(x[:0], x[1:]...) = %q, want %q", got, want)
+	}
+	x = x[:0]
+	x = append(x, "56789"...)
+	if got, want := string(x), "56789"; got != want {
+		t.Errorf("append(x, \"56789\"...) after x = x[:0] = %q, want %q", got, want)
+	}
+
+	// The array is too small and the result has a new one.
+	y := make([]byte, 1, 2)
+	z := append(y[:0], "abc"...)
+	if string(z) != "abc" || &z[0] == &y[0] {
+		t.Errorf("append(y[:0], \"abc\"...) = %q reusing a too small array", z)
+	}
+
+	// The array of y is reused, not its elements.
+	y = append(y[:0], "d"...)
+	if string(y) != "d" {
+		t.Errorf("append(y[:0], \"d\"...) = %q, want %q", y, "d")
+	}
+
+	p := []*int{new(int), new(int), new(int)}
+	q := []*int{new(int)}
+	p = append(p[:0], q...)
+	if len(p) != 1 || cap(p) != 3 || p[0] != q[0] {
+		t.Errorf("append(p[:0], q...) = %v with cap %d, want [%v] with cap 3", p, cap(p), q[0])
+	}
+}
+
 func BenchmarkCopy(b *testing.B) {
 	for _, l := range []int{1, 2, 4, 8, 12, 16, 32, 128, 1024} {
 		buf := make([]byte, 4096)
@@ -37,7 +37,7 @@ func casp(ptr *unsafe.Pointer, old, new unsafe.Pointer) bool {
 // We cannot just call the runtime routines, because the race detector expects
 // to be able to intercept the sync/atomic forms but not the runtime forms.
 
-//go:linkname sync_atomic_StoreUintptr sync/atomic.StoreUintptr
+//go:linkname sync_atomic_StoreUintptr sync/atomic.storeUintptr
 func sync_atomic_StoreUintptr(ptr *uintptr, new uintptr)
 
 //go:linkname sync_atomic_StorePointer sync/atomic.StorePointer
@@ -47,7 +47,7 @@ func sync_atomic_StorePointer(ptr *unsafe.Pointer, new unsafe.Pointer) {
 	writebarrierptr_nostore((*uintptr)(unsafe.Pointer(ptr)), uintptr(new))
 }
 
-//go:linkname sync_atomic_SwapUintptr sync/atomic.SwapUintptr
+//go:linkname sync_atomic_SwapUintptr sync/atomic.swapUintptr
 func sync_atomic_SwapUintptr(ptr *uintptr, new uintptr) uintptr
 
 //go:linkname sync_atomic_SwapPointer sync/atomic.SwapPointer
@@ -58,7 +58,7 @@ func sync_atomic_SwapPointer(ptr *unsafe.Pointer, new unsafe.Pointer) unsafe.Poi
 	return old
 }
 
-//go:linkname sync_atomic_CompareAndSwapUintptr sync/atomic.CompareAndSwapUintptr
+//go:linkname sync_atomic_CompareAndSwapUintptr sync/atomic.compareAndSwapUintptr
 func sync_atomic_CompareAndSwapUintptr(ptr *uintptr, old, new uintptr) bool
 
 //go:linkname sync_atomic_CompareAndSwapPointer sync/atomic.CompareAndSwapPointer
@@ -70,3 +70,16 @@ func sync_atomic_CompareAndSwapPointer(ptr *unsafe.Pointer, old, new unsafe.Poin
 	writebarrierptr_nostore((*uintptr)(unsafe.Pointer(ptr)), uintptr(new))
 	return true
 }
+
+// Support for GODEBUG=atomicptrcheck=1, which makes sync/atomic's
+// uintptr operations reject Go heap pointers.
+
+//go:linkname sync_atomic_runtime_atomicPtrCheck sync/atomic.runtime_atomicPtrCheck
+func sync_atomic_runtime_atomicPtrCheck() bool {
+	return debug.atomicptrcheck > 0
+}
+
+//go:linkname sync_atomic_runtime_inHeap sync/atomic.runtime_inHeap
+func sync_atomic_runtime_inHeap(p uintptr) bool {
+	return inheap(p)
+}
@@ -0,0 +1,212 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+// getcgroupcpu returns the number of CPUs the process may use according
+// to its cgroup CPU bandwidth limit, or 0 if it is not limited.
+func getcgroupcpu() int32 {
+	return cgroupCPULimit("")
+}
+
+// cgroupCPULimit returns ceil(quota/period) for the most restrictive
+// CPU bandwidth limit that applies to the current process, considering
+// both cgroup v1 (cpu.cfs_quota_us, cpu.cfs_period_us) and cgroup v2
+// (cpu.max) hierarchies and every ancestor of the process's cgroup up to
+// the root of the mounted hierarchy. It returns 0 if there is no limit
+// or the limit cannot be determined.
+//
+// All files are read relative to root, which is "" except in tests.
+func cgroupCPULimit(root string) int32 {
+	cgroups := readcgroupfile(root + "/proc/self/cgroup")
+	mounts := readcgroupfile(root + "/proc/self/mountinfo")
+	if cgroups == "" || mounts == "" {
+		return 0
+	}
+
+	// Lines in /proc/self/cgroup are "id:controllers:path".
+	// The unified (v2) hierarchy has id 0 and no controllers.
+	var v1path, v2path string
+	for _, line := range cgroupLines(cgroups) {
+		id, rest := cgroupCut(line, ':')
+		ctls, path := cgroupCut(rest, ':')
+		switch {
+		case id == "0" && ctls == "":
+			v2path = path
+		case cgroupHasField(ctls, ',', "cpu"):
+			v1path = path
+		}
+	}
+
+	// Lines in /proc/self/mountinfo are
+	// "id parent major:minor root mountpoint options [optional...] - fstype source superoptions".
+	limit := int32(0)
+	for _, line := range cgroupLines(mounts) {
+		i := index(line, " - ")
+		if i < 0 {
+			continue
+		}
+		pre := cgroupFields(line[:i])
+		post := cgroupFields(line[i+3:])
+		if len(pre) < 5 || len(post) < 3 {
+			continue
+		}
+		mroot, mpoint := pre[3], pre[4]
+		var n int32
+		switch {
+		case post[0] == "cgroup2" && v2path != "":
+			n = cgroupWalk(root, mroot, mpoint, v2path, cgroupV2Limit)
+		case post[0] == "cgroup" && v1path != "" && cgroupHasField(post[2], ',', "cpu"):
+			n = cgroupWalk(root, mroot, mpoint, v1path, cgroupV1Limit)
+		}
+		if n > 0 && (limit == 0 || n < limit) {
+			limit = n
+		}
+	}
+	return limit
+}
+
+// cgroupWalk applies read to the directory of the cgroup path within the
+// hierarchy mounted at mpoint (whose root is mroot), and to each of its
+// ancestors up to mpoint, and returns the smallest positive result.
+func cgroupWalk(root, mroot, mpoint, path string, read func(dir string) int32) int32 {
+	if mroot != "/" {
+		// The mount exposes only part of the hierarchy
+		// (for example, inside a cgroup namespace).
+		if !hasprefix(path, mroot) {
+			return 0
+		}
+		path = path[len(mroot):]
+	}
+	base := root + mpoint
+	dir := base + path
+	limit := int32(0)
+	for {
+		if n := read(dir); n > 0 && (limit == 0 || n < limit) {
+			limit = n
+		}
+		if len(dir) <= len(base) {
+			break
+		}
+		i := len(dir) - 1
+		for i > len(base) && dir[i] != '/' {
+			i--
+		}
+		dir = dir[:i]
+	}
+	return limit
+}
+
+// cgroupV1Limit reads the CFS quota and period in dir.
+// A quota of -1 means there is no limit.
+func cgroupV1Limit(dir string) int32 {
+	quota := readcgroupfile(dir + "/cpu.cfs_quota_us")
+	period := readcgroupfile(dir + "/cpu.cfs_period_us")
+	if quota == "" || quota[0] == '-' {
+		return 0
+	}
+	return cgroupQuotaCPUs(atoi(quota), atoi(period))
+}
+
+// cgroupV2Limit reads cpu.max in dir, which holds "$MAX $PERIOD".
+// A $MAX of "max" means there is no limit.
+func cgroupV2Limit(dir string) int32 {
+	f := cgroupFields(readcgroupfile(dir + "/cpu.max"))
+	if len(f) != 2 || f[0] == "max" {
+		return 0
+	}
+	return cgroupQuotaCPUs(atoi(f[0]), atoi(f[1]))
+}
+
+// cgroupQuotaCPUs returns ceil(quota/period), or 0 if either is unset.
+func cgroupQuotaCPUs(quota, period int) int32 {
+	if quota <= 0 || period <= 0 {
+		return 0
+	}
+	n := (quota + period - 1) / period
+	if n > _MaxGomaxprocs {
+		n = _MaxGomaxprocs
+	}
+	return int32(n)
+}
+
+// readcgroupfile returns the contents of the named file,
+// or "" if it cannot be read.
+func readcgroupfile(name string) string {
+	p := make([]byte, len(name)+1)
+	copy(p, name)
+	fd := open(&p[0], 0 /* O_RDONLY */, 0)
+	if fd < 0 {
+		return ""
+	}
+	buf := make([]byte, 0, 1024)
+	for {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n := read(fd, unsafe.Pointer(&buf[len(buf):cap(buf)][0]), int32(cap(buf)-len(buf)))
+		if n <= 0 {
+			break
+		}
+		buf = buf[:len(buf)+int(n)]
+	}
+	closefd(fd)
+	return string(buf)
+}
+
+// cgroupCut splits s around the first instance of sep.
+func cgroupCut(s string, sep byte) (before, after string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == sep {
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// cgroupLines splits s into non-empty lines.
+func cgroupLines(s string) []string {
+	var lines []string
+	for s != "" {
+		var line string
+		line, s = cgroupCut(s, '\n')
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// cgroupFields splits s into fields separated by spaces or newlines.
+func cgroupFields(s string) []string {
+	var f []string
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] != ' ' && s[i] != '\n' {
+			i++
+		}
+		if i > 0 {
+			f = append(f, s[:i])
+		}
+		if i == len(s) {
+			break
+		}
+		s = s[i+1:]
+	}
+	return f
+}
+
+// cgroupHasField reports whether the sep-separated list s contains field.
+func cgroupHasField(s string, sep byte, field string) bool {
+	for s != "" {
+		var f string
+		f, s = cgroupCut(s, sep)
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
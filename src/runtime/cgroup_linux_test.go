@@ -0,0 +1,167 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	. "runtime"
+	"testing"
+)
+
+// writeCgroupFiles creates the named files below root.
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	for name, data := range files {
+		name = filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const (
+	cgroupV1Mounts = "25 20 0:22 / /sys/fs/cgroup/cpu,cpuacct rw,nosuid shared:9 - cgroup cgroup rw,cpu,cpuacct\n" +
+		"26 20 0:23 / /sys/fs/cgroup/memory rw,nosuid shared:10 - cgroup cgroup rw,memory\n"
+	cgroupV2Mounts = "30 20 0:26 / /sys/fs/cgroup rw,nosuid shared:4 - cgroup2 cgroup2 rw,nsdelegate\n"
+)
+
+var cgroupCPULimitTests = []struct {
+	name  string
+	files map[string]string
+	want  int32
+}{
+	{
+		name: "no cgroup files",
+		want: 0,
+	},
+	{
+		name: "v1 unlimited",
+		files: map[string]string{
+			"/proc/self/cgroup":                                "4:memory:/job\n3:cpu,cpuacct:/job\n",
+			"/proc/self/mountinfo":                             cgroupV1Mounts,
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_quota_us":  "-1\n",
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_period_us": "100000\n",
+		},
+		want: 0,
+	},
+	{
+		name: "v1 exact",
+		files: map[string]string{
+			"/proc/self/cgroup":                                "3:cpu,cpuacct:/job\n",
+			"/proc/self/mountinfo":                             cgroupV1Mounts,
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_quota_us":  "200000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_period_us": "100000\n",
+		},
+		want: 2,
+	},
+	{
+		name: "v1 rounds up",
+		files: map[string]string{
+			"/proc/self/cgroup":                                "3:cpu,cpuacct:/job\n",
+			"/proc/self/mountinfo":                             cgroupV1Mounts,
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_quota_us":  "150000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_period_us": "100000\n",
+		},
+		want: 2,
+	},
+	{
+		name: "v1 fraction of a cpu",
+		files: map[string]string{
+			"/proc/self/cgroup":                                "3:cpu,cpuacct:/job\n",
+			"/proc/self/mountinfo":                             cgroupV1Mounts,
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_quota_us":  "50000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_period_us": "100000\n",
+		},
+		want: 1,
+	},
+	{
+		name: "v1 nested parent limits",
+		files: map[string]string{
+			"/proc/self/cgroup":                                    "3:cpu,cpuacct:/pod/job\n",
+			"/proc/self/mountinfo":                                 cgroupV1Mounts,
+			"/sys/fs/cgroup/cpu,cpuacct/pod/cpu.cfs_quota_us":      "300000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/pod/cpu.cfs_period_us":     "100000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/pod/job/cpu.cfs_quota_us":  "-1\n",
+			"/sys/fs/cgroup/cpu,cpuacct/pod/job/cpu.cfs_period_us": "100000\n",
+		},
+		want: 3,
+	},
+	{
+		name: "v1 namespaced mount root",
+		files: map[string]string{
+			"/proc/self/cgroup":                    "3:cpu,cpuacct:/docker/abc\n",
+			"/proc/self/mountinfo":                 "25 20 0:22 /docker/abc /sys/fs/cgroup/cpu ro - cgroup cgroup rw,cpu\n",
+			"/sys/fs/cgroup/cpu/cpu.cfs_quota_us":  "400000\n",
+			"/sys/fs/cgroup/cpu/cpu.cfs_period_us": "100000\n",
+		},
+		want: 4,
+	},
+	{
+		name: "v2 max",
+		files: map[string]string{
+			"/proc/self/cgroup":          "0::/job\n",
+			"/proc/self/mountinfo":       cgroupV2Mounts,
+			"/sys/fs/cgroup/job/cpu.max": "max 100000\n",
+		},
+		want: 0,
+	},
+	{
+		name: "v2 limited",
+		files: map[string]string{
+			"/proc/self/cgroup":          "0::/job\n",
+			"/proc/self/mountinfo":       cgroupV2Mounts,
+			"/sys/fs/cgroup/job/cpu.max": "250000 100000\n",
+		},
+		want: 3,
+	},
+	{
+		name: "v2 nested child limits",
+		files: map[string]string{
+			"/proc/self/cgroup":              "0::/pod/job\n",
+			"/proc/self/mountinfo":           cgroupV2Mounts,
+			"/sys/fs/cgroup/pod/cpu.max":     "800000 100000\n",
+			"/sys/fs/cgroup/pod/job/cpu.max": "100000 50000\n",
+		},
+		want: 2,
+	},
+	{
+		name: "v1 and v2 hybrid",
+		files: map[string]string{
+			"/proc/self/cgroup":                                "3:cpu,cpuacct:/job\n0::/job\n",
+			"/proc/self/mountinfo":                             cgroupV1Mounts + "31 20 0:27 / /sys/fs/cgroup/unified rw - cgroup2 cgroup2 rw\n",
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_quota_us":  "600000\n",
+			"/sys/fs/cgroup/cpu,cpuacct/job/cpu.cfs_period_us": "100000\n",
+			"/sys/fs/cgroup/unified/job/cpu.max":               "500000 100000\n",
+		},
+		want: 5,
+	},
+}
+
+func TestCgroupCPULimit(t *testing.T) {
+	for _, tt := range cgroupCPULimitTests {
+		root, err := ioutil.TempDir("", "cgroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeCgroupFiles(t, root, tt.files)
+		if got := CgroupCPULimit(root); got != tt.want {
+			t.Errorf("%s: CgroupCPULimit = %d; want %d", tt.name, got, tt.want)
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func TestUpdateCPUQuota(t *testing.T) {
+	// GOMAXPROCS has been set explicitly by the test framework
+	// or by other tests, so UpdateCPUQuota must leave it alone.
+	defer GOMAXPROCS(GOMAXPROCS(3))
+	if n := UpdateCPUQuota(); n != 3 {
+		t.Errorf("UpdateCPUQuota = %d; want 3", n)
+	}
+}
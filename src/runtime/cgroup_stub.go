@@ -0,0 +1,12 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package runtime
+
+// getcgroupcpu returns 0: CPU quotas are only detected on Linux.
+func getcgroupcpu() int32 {
+	return 0
+}
@@ -188,6 +188,14 @@ func TestStackOverflow(t *testing.T) {
 	}
 }
 
+func TestStackOverflowLargeFrame(t *testing.T) {
+	output := runTestProg(t, "testprog", "StackOverflowLargeFrame")
+	want := "runtime: goroutine stack exceeds 16777216-byte limit\nfatal error: stack overflow"
+	if !strings.HasPrefix(output, want) {
+		t.Fatalf("output does not start with %q:\n%s", want, output)
+	}
+}
+
 func TestThreadExhaustion(t *testing.T) {
 	output := runTestProg(t, "testprog", "ThreadExhaustion")
 	want := "runtime: program exceeds 10-thread limit\nfatal error: thread exhaustion"
@@ -24,6 +24,9 @@ func GOMAXPROCS(n int) int {
 	}
 	lock(&sched.lock)
 	ret := int(gomaxprocs)
+	if n > 0 {
+		sched.customgomaxprocs = true
+	}
 	unlock(&sched.lock)
 	if n <= 0 || n == ret {
 		return ret
@@ -38,6 +41,52 @@ func GOMAXPROCS(n int) int {
 	return ret
 }
 
+// UpdateCPUQuota re-reads the CPU quota imposed on the process by the
+// operating system and returns the resulting GOMAXPROCS setting.
+//
+// By default GOMAXPROCS is the number of logical CPUs, lowered on Linux
+// to ceil(quota/period) of the process's cgroup CPU bandwidth limit.
+// Since the quota may change while the process runs, long-running
+// programs may call UpdateCPUQuota to recompute that default. If
+// GOMAXPROCS was set by the GOMAXPROCS environment variable or by
+// a call to GOMAXPROCS, the setting is left unchanged.
+
+// UpdateCPUQuota 重新读取操作系统施加于进程的CPU配额，并返回由此得出的 GOMAXPROCS 设置。
+//
+// GOMAXPROCS 默认为逻辑CPU数，在Linux上，若进程的 cgroup CPU带宽有限制，则会降低为
+// ceil(quota/period)。由于配额可能在进程运行时改变，因此长时间运行的程序可调用
+// UpdateCPUQuota 来重新计算该默认值。若 GOMAXPROCS 已由 GOMAXPROCS 环境变量或
+// GOMAXPROCS 调用设置，则该设置保持不变。
+func UpdateCPUQuota() int {
+	n := defaultgomaxprocs()
+	lock(&sched.lock)
+	ret := int(gomaxprocs)
+	custom := sched.customgomaxprocs
+	unlock(&sched.lock)
+	if custom || int(n) == ret {
+		return ret
+	}
+
+	stopTheWorld("GOMAXPROCS")
+
+	// A call to GOMAXPROCS may have set it while the world was
+	// being stopped.
+	lock(&sched.lock)
+	custom = sched.customgomaxprocs
+	unlock(&sched.lock)
+	if custom {
+		ret = int(gomaxprocs)
+		startTheWorld()
+		return ret
+	}
+
+	// newprocs will be processed by startTheWorld
+	newprocs = n
+
+	startTheWorld()
+	return int(n)
+}
+
 // NumCPU returns the number of logical CPUs usable by the current process.
 //
 // The set of available CPUs is checked by querying the operating system
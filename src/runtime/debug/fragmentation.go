@@ -0,0 +1,147 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Fragmentation describes how much of the memory the heap holds in
+// spans is occupied by objects. The heap allocates small objects from
+// spans of one or more pages, each holding objects of one size class,
+// and can only reuse a span for another size class, or return it to
+// the operating system, once every object in it is dead. A few live
+// objects can therefore pin spans that are otherwise empty.
+type Fragmentation struct {
+	// Classes describes the spans of each small object size class,
+	// in order of increasing size.
+	Classes []SizeClassFragmentation
+
+	LargeSpans uint64 // spans holding a single large object
+	LargeBytes uint64 // bytes in large object spans
+
+	// FreeBytes is the memory in free spans that is still mapped.
+	// It is reclaimable: it can be used for objects of any size.
+	FreeBytes uint64
+
+	// ReleasedBytes is the memory in free spans that has been
+	// returned to the operating system.
+	ReleasedBytes uint64
+}
+
+// SizeClassFragmentation describes the spans of one small object size class.
+type SizeClassFragmentation struct {
+	Size      uint64 // size of the objects of the class
+	Spans     uint64 // spans in use
+	SpanBytes uint64 // bytes in the spans in use
+	Objects   uint64 // allocated objects
+	Slots     uint64 // object slots in the spans in use
+
+	// SparseSpans is the number of spans less than a quarter of
+	// whose slots hold allocated objects, and StrandedBytes the
+	// size of the free slots in those spans. That memory can only
+	// be used for objects of this size class.
+	SparseSpans   uint64
+	StrandedBytes uint64
+}
+
+// Occupancy returns the fraction of the slots of the class's spans
+// that hold allocated objects, or 0 if the class has no spans.
+func (c *SizeClassFragmentation) Occupancy() float64 {
+	if c.Slots == 0 {
+		return 0
+	}
+	return float64(c.Objects) / float64(c.Slots)
+}
+
+// ReadFragmentation reads statistics about the occupancy of the heap's
+// spans into f. The f.Classes slice will be reused if large enough,
+// reallocated otherwise.
+//
+// ReadFragmentation first finishes sweeping the spans left by the last
+// garbage collection, so that objects it found dead are not counted,
+// and then stops the world while it walks the heap's spans, for a time
+// proportional to their number. The statistics are approximate: if a
+// collection starts before the world stops, objects it finds dead
+// still count as allocated.
+func ReadFragmentation(f *Fragmentation) {
+	p := readFragmentation()
+	const classFields = 7
+	n := (len(p) - 4) / classFields
+	if cap(f.Classes) < n {
+		f.Classes = make([]SizeClassFragmentation, n)
+	}
+	f.Classes = f.Classes[:n]
+	for i := range f.Classes {
+		c := p[i*classFields:]
+		f.Classes[i] = SizeClassFragmentation{
+			Size:          c[0],
+			Spans:         c[1],
+			SpanBytes:     c[2],
+			Objects:       c[3],
+			Slots:         c[4],
+			SparseSpans:   c[5],
+			StrandedBytes: c[6],
+		}
+	}
+	p = p[n*classFields:]
+	f.LargeSpans = p[0]
+	f.LargeBytes = p[1]
+	f.FreeBytes = p[2]
+	f.ReleasedBytes = p[3]
+}
+
+// FragmentationReport writes a report of the occupancy of the heap's
+// spans to w, as read by ReadFragmentation, to help decide whether the
+// heap would benefit from compaction, for example by allocating
+// long-lived objects of a size class together. Memory in free spans
+// can be reused for any size; stranded memory, the free slots of
+// sparse spans, cannot until the objects pinning those spans die.
+// The size classes with spans in use are listed by decreasing
+// stranded memory.
+//
+// The runtime does not record the types of objects, so the report
+// does not say which types pin the sparse spans.
+func FragmentationReport(w io.Writer) error {
+	var f Fragmentation
+	ReadFragmentation(&f)
+
+	var classes []*SizeClassFragmentation
+	var spanBytes, objectBytes, stranded, sparse uint64
+	for i := range f.Classes {
+		c := &f.Classes[i]
+		if c.Spans == 0 {
+			continue
+		}
+		classes = append(classes, c)
+		spanBytes += c.SpanBytes
+		objectBytes += c.Objects * c.Size
+		stranded += c.StrandedBytes
+		sparse += c.SparseSpans
+	}
+	sort.Stable(byStranded(classes))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# small objects: %d bytes in %d bytes of spans\n", objectBytes, spanBytes)
+	fmt.Fprintf(&buf, "# stranded: %d bytes in %d sparse spans\n", stranded, sparse)
+	fmt.Fprintf(&buf, "# large objects: %d bytes in %d spans\n", f.LargeBytes, f.LargeSpans)
+	fmt.Fprintf(&buf, "# free: %d bytes reclaimable, %d bytes released\n", f.FreeBytes, f.ReleasedBytes)
+	fmt.Fprintf(&buf, "%8s %10s %12s %10s %9s %10s %12s\n", "size", "spans", "span bytes", "objects", "occupancy", "sparse", "stranded")
+	for _, c := range classes {
+		fmt.Fprintf(&buf, "%8d %10d %12d %10d %8.1f%% %10d %12d\n",
+			c.Size, c.Spans, c.SpanBytes, c.Objects, 100*c.Occupancy(), c.SparseSpans, c.StrandedBytes)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type byStranded []*SizeClassFragmentation
+
+func (x byStranded) Len() int           { return len(x) }
+func (x byStranded) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byStranded) Less(i, j int) bool { return x[i].StrandedBytes > x[j].StrandedBytes }
@@ -0,0 +1,92 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	. "runtime/debug"
+	"strings"
+	"testing"
+)
+
+// A block is 256 bytes, a size class on all architectures.
+type block [256]byte
+
+func class(f *Fragmentation, size uint64) *SizeClassFragmentation {
+	for i := range f.Classes {
+		if f.Classes[i].Size == size {
+			return &f.Classes[i]
+		}
+	}
+	return nil
+}
+
+func TestReadFragmentation(t *testing.T) {
+	const n = 100000
+	var before Fragmentation
+	runtime.GC()
+	ReadFragmentation(&before)
+
+	// Keep every 100th block live. Each lands in a span of its own,
+	// which it pins with all its other slots free.
+	all := make([]*block, n)
+	for i := range all {
+		all[i] = new(block)
+	}
+	var kept []*block
+	for i := 0; i < n; i += 100 {
+		kept = append(kept, all[i])
+	}
+	all = nil
+	runtime.GC()
+
+	var f Fragmentation
+	ReadFragmentation(&f)
+	b, c := class(&before, 256), class(&f, 256)
+	if c == nil {
+		t.Fatal("no 256-byte size class")
+	}
+	if c.Objects < uint64(len(kept)) {
+		t.Errorf("%d objects, want at least %d", c.Objects, len(kept))
+	}
+	if occ := c.Occupancy(); occ > 0.25 {
+		t.Errorf("occupancy %.2f, want below 0.25", occ)
+	}
+	perSpan := c.Slots / c.Spans
+	want := uint64(len(kept)) * (perSpan - 1) * 256
+	if got := c.StrandedBytes - b.StrandedBytes; got < want/2 || got > 2*want {
+		t.Errorf("%d bytes stranded, want about %d", got, want)
+	}
+	if got := c.SparseSpans - b.SparseSpans; got < uint64(len(kept))/2 || got > 2*uint64(len(kept)) {
+		t.Errorf("%d sparse spans, want about %d", got, len(kept))
+	}
+	runtime.KeepAlive(kept)
+}
+
+func TestFragmentationReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := FragmentationReport(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 6 || !strings.HasPrefix(lines[0], "# small objects: ") || !strings.HasPrefix(strings.TrimSpace(lines[4]), "size ") {
+		t.Fatalf("malformed report:\n%s", buf.String())
+	}
+	// The classes are listed by decreasing stranded bytes.
+	prev := ^uint64(0)
+	for _, line := range lines[5:] {
+		var size, spans, spanBytes, objects, sparse, stranded uint64
+		var occ float64
+		if _, err := fmt.Sscanf(line, "%d %d %d %d %f%% %d %d", &size, &spans, &spanBytes, &objects, &occ, &sparse, &stranded); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		if stranded > prev {
+			t.Errorf("line %q out of order", line)
+		}
+		prev = stranded
+	}
+}
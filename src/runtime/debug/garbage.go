@@ -18,6 +18,12 @@ type GCStats struct {
 	Pause          []time.Duration // pause history, most recent first
 	PauseEnd       []time.Time     // pause end times history, most recent first
 	PauseQuantiles []time.Duration
+
+	// StopTheWorldMax is the longest time the runtime has taken to
+	// stop the world, for a collection or any other reason. It
+	// measures the wait for all goroutines to stop, which is part of
+	// the pause of a collection.
+	StopTheWorldMax time.Duration
 }
 
 // ReadGCStats reads statistics about garbage collection into stats.
@@ -35,21 +41,22 @@ func ReadGCStats(stats *GCStats) {
 	// for end times history and as a temporary buffer for
 	// computing quantiles.
 	const maxPause = len(((*runtime.MemStats)(nil)).PauseNs)
-	if cap(stats.Pause) < 2*maxPause+3 {
-		stats.Pause = make([]time.Duration, 2*maxPause+3)
+	if cap(stats.Pause) < 2*maxPause+4 {
+		stats.Pause = make([]time.Duration, 2*maxPause+4)
 	}
 
 	// readGCStats fills in the pause and end times histories (up to
-	// maxPause entries) and then three more: Unix ns time of last GC,
-	// number of GC, and total pause time in nanoseconds. Here we
-	// depend on the fact that time.Duration's native unit is
-	// nanoseconds, so the pauses and the total pause time do not need
-	// any conversion.
+	// maxPause entries) and then four more: Unix ns time of last GC,
+	// number of GC, total pause time in nanoseconds, and the longest
+	// time taken to stop the world in nanoseconds. Here we depend on
+	// the fact that time.Duration's native unit is nanoseconds, so the
+	// pauses and the total pause time do not need any conversion.
 	readGCStats(&stats.Pause)
-	n := len(stats.Pause) - 3
+	n := len(stats.Pause) - 4
 	stats.LastGC = time.Unix(0, int64(stats.Pause[n]))
 	stats.NumGC = int64(stats.Pause[n+1])
 	stats.PauseTotal = stats.Pause[n+2]
+	stats.StopTheWorldMax = stats.Pause[n+3]
 	n /= 2 // buffer holds pauses and end times
 	stats.Pause = stats.Pause[:n]
 
@@ -100,6 +107,30 @@ func SetGCPercent(percent int) int {
 	return int(old)
 }
 
+// SetHeapGoalAdjuster installs f as the heap goal adjuster and returns
+// the previous one, or nil if there was none. A nil f removes the
+// adjuster.
+//
+// At the end of each collection, the runtime computes the heap size at
+// which the next collection is triggered (reported as MemStats.NextGC)
+// from the live heap and the garbage collection percentage, and then
+// calls f with that goal. The goal returned by f is used instead. This
+// lets a program shrink the Go heap when memory used outside it, such as
+// mapped files or C allocations, grows. The runtime does not lower the
+// goal below the live heap plus a small margin, so a collection cannot
+// be triggered immediately after the previous one. f is not called
+// while garbage collection is disabled.
+//
+// f is called by the goroutine that finished the collection, with the
+// world running but with preemption disabled, before the next
+// collection can start. It must be fast, it must not allocate, and it
+// must not block or call any function that may block, such as a
+// channel operation or a mutex. To use external signals, have another
+// goroutine store them in variables that f reads atomically.
+func SetHeapGoalAdjuster(f func(defaultGoal uint64) uint64) func(defaultGoal uint64) uint64 {
+	return setHeapGoalAdjuster(f)
+}
+
 // FreeOSMemory forces a garbage collection followed by an
 // attempt to return as much memory to the operating system
 // as possible. (Even if this is not called, the runtime gradually
@@ -7,6 +7,7 @@ package debug_test
 import (
 	"runtime"
 	. "runtime/debug"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -118,3 +119,62 @@ func TestSetGCPercent(t *testing.T) {
 		t.Errorf("SetGCPercent(123); SetGCPercent(x) = %d, want 123", new)
 	}
 }
+
+var sink []byte
+
+// gcCycles returns the number of collections triggered by allocating
+// n bytes of garbage.
+func gcCycles(n int) uint32 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	start := ms.NumGC
+	for i := 0; i < n; i += 64 << 10 {
+		sink = make([]byte, 64<<10)
+	}
+	sink = nil
+	runtime.ReadMemStats(&ms)
+	return ms.NumGC - start
+}
+
+func TestSetHeapGoalAdjuster(t *testing.T) {
+	// A high percentage keeps the default goal at the heap minimum,
+	// well above the live heap, so halving it is not clamped.
+	defer SetGCPercent(SetGCPercent(800))
+
+	const garbage = 512 << 20
+	runtime.GC()
+	unadjusted := gcCycles(garbage)
+
+	var defaultGoal uint64
+	halve := func(goal uint64) uint64 {
+		atomic.StoreUint64(&defaultGoal, goal)
+		return goal / 2
+	}
+	if old := SetHeapGoalAdjuster(halve); old != nil {
+		t.Fatalf("SetHeapGoalAdjuster returned non-nil adjuster")
+	}
+	defer SetHeapGoalAdjuster(nil)
+
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	goal := atomic.LoadUint64(&defaultGoal)
+	if goal == 0 {
+		t.Fatalf("adjuster was not called")
+	}
+	if want := goal / 2; ms.NextGC != want {
+		t.Errorf("NextGC = %d, want %d (half of %d)", ms.NextGC, want, goal)
+	}
+
+	// Collections should now trigger at about half the heap size,
+	// so about twice as often.
+	adjusted := gcCycles(garbage)
+	t.Logf("%d collections unadjusted, %d with halved goal", unadjusted, adjusted)
+	if adjusted < unadjusted*3/2 {
+		t.Errorf("%d collections with halved goal, want at least %d", adjusted, unadjusted*3/2)
+	}
+
+	if old := SetHeapGoalAdjuster(nil); old == nil {
+		t.Errorf("SetHeapGoalAdjuster(nil) returned nil, want previous adjuster")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+// GraphSize estimates the heap memory retained by the value p: the
+// total size and the number of the heap objects reachable from it.
+// It is meant for capacity planning and cache accounting, in place of
+// reflection-based walkers that cannot see the internal memory of maps
+// or the unused capacity of slices.
+//
+// GraphSize follows pointers using the same layout information as the
+// garbage collector, so it finds every pointer of every type, including
+// those inside maps, channels and interfaces. Each object is counted
+// once per call, at the size the allocator rounded it up to, however
+// many pointers refer to it: two strings sharing the same bytes, or two
+// values pointing to the same subgraph, count that memory once. If p is
+// a pointer, the object it points to is counted; otherwise the walk
+// starts from the pointers in p itself.
+//
+// Only heap memory is counted. Global variables, stacks and memory
+// allocated outside the Go heap are not, although the heap objects
+// reachable from a global variable that p points to are. A closure
+// counts its captured variables but not its code.
+//
+// The result is a best-effort estimate. GraphSize does not stop the
+// world: if other goroutines modify the graph during the call, the
+// result may include objects that are no longer reachable, or miss
+// objects that just became reachable. The cost of the call is
+// proportional to the size of the graph. If maxObjects is positive,
+// GraphSize stops after counting maxObjects objects and reports that
+// the result is truncated; if it is zero or negative, the walk is
+// unbounded and may visit the entire heap.
+func GraphSize(p interface{}, maxObjects int) (bytes, objects uint64, truncated bool) {
+	return graphSize(p, maxObjects)
+}
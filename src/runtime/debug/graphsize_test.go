@@ -0,0 +1,113 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"runtime"
+	. "runtime/debug"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+const ptrSize = unsafe.Sizeof(uintptr(0))
+
+// A node is 48 bytes, a size class on all architectures,
+// so a list of n nodes is exactly 48*n bytes of heap.
+type node struct {
+	next *node
+	pad  [48 - ptrSize]byte
+}
+
+func list(n int) *node {
+	var head *node
+	for i := 0; i < n; i++ {
+		head = &node{next: head}
+	}
+	return head
+}
+
+var globalList struct {
+	head *node
+}
+
+// checkGraph checks the result of GraphSize against the expected number
+// of objects and the sum of the sizes requested for them. The allocator
+// rounds a request of n bytes up to its size class, by at most n/8 plus
+// one word for small objects.
+func checkGraph(t *testing.T, name string, p interface{}, wantObjects, wantBytes uint64) {
+	bytes, objects, truncated := GraphSize(p, 0)
+	if truncated {
+		t.Errorf("%s: truncated", name)
+	}
+	if objects != wantObjects {
+		t.Errorf("%s: %d objects, want %d", name, objects, wantObjects)
+	}
+	max := wantBytes + wantBytes/8 + uint64(ptrSize)*wantObjects
+	if bytes < wantBytes || bytes > max {
+		t.Errorf("%s: %d bytes, want between %d and %d", name, bytes, wantBytes, max)
+	}
+}
+
+func TestGraphSize(t *testing.T) {
+	l := list(100)
+	checkGraph(t, "list", l, 100, 100*48)
+
+	// A subgraph reachable twice is counted once.
+	type pair struct{ a, b *node }
+	checkGraph(t, "shared", &pair{l, l.next.next}, 101, 2*uint64(ptrSize)+100*48)
+
+	// The capacity of a slice beyond its length is counted,
+	// and the nil pointers in it are skipped.
+	s := make([]*node, 1, 128)
+	s[0] = l
+	checkGraph(t, "slice", &s, 1+1+100, 3*uint64(ptrSize)+128*uint64(ptrSize)+100*48)
+
+	// Strings sharing their bytes count them once.
+	str := strings.Repeat("x", 1000)
+	type strings2 struct{ a, b string }
+	checkGraph(t, "strings", &strings2{str, str[10:20]}, 2, 4*uint64(ptrSize)+1000)
+
+	// A non-pointer value counts what it refers to, but not itself.
+	checkGraph(t, "value", pair{l, nil}, 100, 100*48)
+
+	// A global variable is not counted; what it refers to is.
+	globalList.head = l
+	checkGraph(t, "global", &globalList, 100, 100*48)
+	globalList.head = nil
+
+	checkGraph(t, "nil", nil, 0, 0)
+	runtime.KeepAlive(l)
+}
+
+func TestGraphSizeMap(t *testing.T) {
+	m := make(map[int]*node)
+	for i := 0; i < 100; i++ {
+		m[i] = &node{}
+	}
+	bytes, objects, _ := GraphSize(m, 0)
+	// The map has a header and at least one bucket array, holding
+	// a key, a value and a hash byte for each entry. Growth and
+	// overflow buckets at most double that.
+	entries := uint64(100 * (8 + ptrSize + 1))
+	if objects < 100+2 {
+		t.Errorf("map: %d objects, want at least %d", objects, 100+2)
+	}
+	if min, max := 100*48+entries, 100*48+2*entries+1024; bytes < min || bytes > max {
+		t.Errorf("map: %d bytes, want between %d and %d", bytes, min, max)
+	}
+}
+
+func TestGraphSizeTruncated(t *testing.T) {
+	l := list(100)
+	bytes, objects, truncated := GraphSize(l, 10)
+	if !truncated || objects != 10 || bytes != 10*48 {
+		t.Errorf("GraphSize(list(100), 10) = %d, %d, %v, want %d, 10, true", bytes, objects, truncated, 10*48)
+	}
+	_, _, truncated = GraphSize(l, 100)
+	if truncated {
+		t.Errorf("GraphSize(list(100), 100) is truncated")
+	}
+}
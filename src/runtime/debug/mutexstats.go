@@ -0,0 +1,56 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import "time"
+
+// MutexStats describes the contention of the locks of package sync:
+// of sync.Mutex, and of the other types that, like sync.RWMutex and
+// sync.WaitGroup, use the same runtime semaphores to make goroutines
+// wait. The statistics are collected only if the program runs with
+// GODEBUG=mutexstats=1; otherwise they are all zero.
+type MutexStats struct {
+	// Starved is the number of acquisitions that waited more than
+	// 1ms. A goroutine woken by an unlock competes for the lock with
+	// goroutines that have not waited, and may lose again and again.
+	Starved uint64
+
+	// Handoffs is the number of times an unlock woke a waiting
+	// goroutine.
+	Handoffs uint64
+
+	// Waits is a histogram of the durations of the acquisitions that
+	// waited. Waits[i] is the number of waits shorter than
+	// WaitBounds[i] and, for i > 0, at least WaitBounds[i-1].
+	// The last element, Waits[len(WaitBounds)], is the number of
+	// waits of at least WaitBounds[len(WaitBounds)-1].
+	Waits      []uint64
+	WaitBounds []time.Duration
+}
+
+// ReadMutexStats reads the lock contention statistics into s, as
+// collected since the program started. The s.Waits and s.WaitBounds
+// slices will be reused if large enough, reallocated otherwise.
+func ReadMutexStats(s *MutexStats) {
+	p := readMutexStats()
+	s.Starved = p[0]
+	s.Handoffs = p[1]
+	p = p[2:]
+	if cap(s.Waits) < len(p) {
+		s.Waits = make([]uint64, len(p))
+	}
+	s.Waits = s.Waits[:len(p)]
+	copy(s.Waits, p)
+	if cap(s.WaitBounds) < len(p)-1 {
+		s.WaitBounds = make([]time.Duration, len(p)-1)
+	}
+	s.WaitBounds = s.WaitBounds[:len(p)-1]
+	// The bounds are powers of 4 microseconds.
+	bound := time.Microsecond
+	for i := range s.WaitBounds {
+		s.WaitBounds[i] = bound
+		bound *= 4
+	}
+}
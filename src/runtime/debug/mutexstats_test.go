@@ -0,0 +1,122 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"internal/testenv"
+	"os"
+	"os/exec"
+	. "runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unfairWorkload runs one goroutine that holds mu most of the time and
+// releases it only briefly, while others need it, for d.
+func unfairWorkload(d time.Duration) {
+	var mu sync.Mutex
+	var done int32
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for atomic.LoadInt32(&done) == 0 {
+			mu.Lock()
+			start := time.Now()
+			for time.Since(start) < 20*time.Microsecond {
+			}
+			mu.Unlock()
+		}
+	}()
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&done) == 0 {
+				mu.Lock()
+				mu.Unlock()
+				time.Sleep(10 * time.Microsecond)
+			}
+		}()
+	}
+	time.Sleep(d)
+	atomic.StoreInt32(&done, 1)
+	wg.Wait()
+}
+
+func TestMutexStats(t *testing.T) {
+	if os.Getenv("GO_MUTEXSTATS_TEST") == "" {
+		testenv.MustHaveExec(t)
+		cmd := exec.Command(os.Args[0], "-test.run=^TestMutexStats$")
+		cmd.Env = []string{"GO_MUTEXSTATS_TEST=1", "GODEBUG=mutexstats=1"}
+		for _, env := range os.Environ() {
+			if !strings.HasPrefix(env, "GODEBUG=") {
+				cmd.Env = append(cmd.Env, env)
+			}
+		}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("with GODEBUG=mutexstats=1: %v\n%s", err, out)
+		}
+		return
+	}
+
+	unfairWorkload(200 * time.Millisecond)
+
+	var s MutexStats
+	ReadMutexStats(&s)
+	t.Logf("%+v", s)
+	if len(s.Waits) != len(s.WaitBounds)+1 {
+		t.Fatalf("len(Waits) = %d, len(WaitBounds) = %d, want one more wait bucket than bounds", len(s.Waits), len(s.WaitBounds))
+	}
+	if s.WaitBounds[0] != time.Microsecond {
+		t.Errorf("WaitBounds[0] = %v, want 1µs", s.WaitBounds[0])
+	}
+	if s.Starved == 0 {
+		t.Errorf("no starved acquisitions")
+	}
+	if s.Handoffs == 0 {
+		t.Errorf("no handoffs")
+	}
+
+	// The starved acquisitions are counted in the buckets of waits
+	// that may be longer than 1ms.
+	var long uint64
+	buckets := 0
+	for i, n := range s.Waits {
+		if i == len(s.WaitBounds) || s.WaitBounds[i] > time.Millisecond {
+			long += n
+		}
+		if n > 0 {
+			buckets++
+		}
+	}
+	if long < s.Starved {
+		t.Errorf("%d waits that may be longer than 1ms, fewer than %d starved", long, s.Starved)
+	}
+	if buckets < 2 {
+		t.Errorf("waits in %d buckets, want a spread over at least 2: %v", buckets, s.Waits)
+	}
+}
+
+func TestMutexStatsOff(t *testing.T) {
+	if strings.Contains(os.Getenv("GODEBUG"), "mutexstats=") {
+		t.Skip("GODEBUG sets mutexstats")
+	}
+	unfairWorkload(20 * time.Millisecond)
+
+	var s MutexStats
+	ReadMutexStats(&s)
+	if s.Starved != 0 || s.Handoffs != 0 {
+		t.Errorf("Starved = %d, Handoffs = %d without GODEBUG=mutexstats=1, want 0", s.Starved, s.Handoffs)
+	}
+	for i, n := range s.Waits {
+		if n != 0 {
+			t.Errorf("Waits[%d] = %d without GODEBUG=mutexstats=1, want 0", i, n)
+		}
+	}
+}
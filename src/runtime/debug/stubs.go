@@ -13,5 +13,9 @@ func readGCStats(*[]time.Duration)
 func freeOSMemory()
 func setMaxStack(int) int
 func setGCPercent(int32) int32
+func setHeapGoalAdjuster(func(uint64) uint64) func(uint64) uint64
 func setPanicOnFault(bool) bool
 func setMaxThreads(int) int
+func graphSize(interface{}, int) (uint64, uint64, bool)
+func readFragmentation() []uint64
+func readMutexStats() []uint64
@@ -8,3 +8,5 @@ package runtime
 
 var NewOSProc0 = newosproc0
 var Mincore = mincore
+
+var CgroupCPULimit = cgroupCPULimit
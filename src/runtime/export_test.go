@@ -32,6 +32,8 @@ var FuncPC = funcPC
 
 var Fastlog2 = fastlog2
 
+var GCCheckmarkDue = gcCheckmarkDue
+
 type LFNode struct {
 	Next    uint64
 	Pushcnt uintptr
@@ -244,3 +246,28 @@ func CountPagesInUse() (pagesInUse, counted uintptr) {
 
 	return
 }
+
+const MallocZeroPoisonByte = mallocZeroPoisonByte
+
+// SetMallocZeroPoison turns GODEBUG=malloczero=poison on or off
+// and reports whether it was on.
+func SetMallocZeroPoison(on bool) bool {
+	old := debug.malloczero == mallocZeroPoison
+	if on {
+		debug.malloczero = mallocZeroPoison
+	} else {
+		debug.malloczero = 0
+	}
+	return old
+}
+
+// MallocNoZero allocates size bytes without pointers and without
+// asking for them to be zeroed, like rawstring does.
+func MallocNoZero(size int) []byte {
+	return *(*[]byte)(unsafe.Pointer(&slice{mallocgc(uintptr(size), nil, false), size, size}))
+}
+
+// StackAlloc returns the size of the stack of the calling goroutine.
+func StackAlloc() uintptr {
+	return getg().stackAlloc
+}
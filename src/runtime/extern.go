@@ -27,6 +27,11 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	allocfreetrace: setting allocfreetrace=1 causes every allocation to be
 	profiled and a stack trace printed on each object's allocation and free.
 
+	atomicptrcheck: setting atomicptrcheck=1 causes sync/atomic's StoreUintptr,
+	SwapUintptr and CompareAndSwapUintptr to panic when the value being stored
+	points into an allocated object in the Go heap, which usually means a Go
+	pointer is being hidden from the garbage collector in a uintptr.
+
 	cgocheck: setting cgocheck=0 disables all checks for packages
 	using cgo to incorrectly pass Go pointers to non-Go code.
 	Setting cgocheck=1 (the default) enables relatively cheap
@@ -42,7 +47,11 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	garbage collector's concurrent mark phase by performing a
 	second mark pass while the world is stopped.  If the second
 	pass finds a reachable object that was not found by concurrent
-	mark, the garbage collector will panic.
+	mark, the garbage collector will panic, reporting the object,
+	its span size class and the object that references it.
+	Setting gccheckmark=N performs the verification only on every
+	Nth GC cycle, which keeps long-running programs usable; cycles
+	that are verified are marked "(checkmark)" in the gctrace output.
 
 	gcpacertrace: setting gcpacertrace=1 causes the garbage collector to
 	print information about the internal state of the concurrent pacer.
@@ -82,6 +91,22 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	If the line ends with "(forced)", this GC was forced by a
 	runtime.GC() call and all phases are STW.
 
+	malloczero: setting malloczero=poison causes the allocator to fill objects
+	without pointers that are allocated without being zeroed, such as the
+	backing store of new strings, with the byte 0xA5 instead of leaving
+	whatever the memory held before. Setting malloczero=verify causes the
+	allocator to check that every object it hands out as zeroed really
+	contains only zeros, and to crash the program with a report of the
+	span and the allocating goroutine's stack when it does not.
+	Both modes slow down allocation and are meant for testing.
+
+	mutexstats: setting mutexstats=1 causes the runtime to collect statistics
+	about the goroutines that wait for the semaphores of sync.Mutex and the
+	other types of package sync: how long they wait, how many wait more
+	than 1ms, and how many are woken by an unlock. The statistics are
+	returned by runtime/debug.ReadMutexStats. Acquisitions that do not
+	wait are not slowed down.
+
 	memprofilerate: setting memprofilerate=X will update the value of runtime.MemProfileRate.
 	When set to 0 memory profiling is disabled.  Refer to the description of
 	MemProfileRate for the default value.
@@ -92,6 +117,10 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	This should only be used as a temporary workaround to diagnose buggy code.
 	The real fix is to not store integers in pointer-typed locations.
 
+	reflectstacks: setting reflectstacks=1 causes package reflect to record
+	the stack of calls that builds each type it builds at run time, with
+	functions such as StructOf, for reflect.ConstructionStack.
+
 	sbrk: setting sbrk=1 replaces the memory allocator and garbage collector
 	with a trivial allocator that obtains memory from the operating system and
 	never reclaims any memory.
@@ -105,6 +134,12 @@ It is a comma-separated list of name=val pairs setting these named variables:
 	schedtrace: setting schedtrace=X causes the scheduler to emit a single line to standard
 	error every X milliseconds, summarizing the scheduler state.
 
+	stwtimeout: setting stwtimeout=X causes the runtime to emit a report to standard
+	error when stopping the world for a garbage collection or any other reason takes
+	longer than X milliseconds, listing the processors that have not stopped and the
+	goroutine running on each, with the function where it was last scheduled.
+	The default is 10; stwtimeout=0 disables the report.
+
 The net and net/http packages also refer to debugging variables in GODEBUG.
 See the documentation for those packages for details.
 
@@ -0,0 +1,76 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Span occupancy statistics, for runtime/debug.ReadFragmentation.
+
+package runtime
+
+import _ "unsafe" // for go:linkname
+
+// fragClassFields is the number of values readFragmentation
+// reports for each small size class.
+const fragClassFields = 7
+
+// readFragmentation returns the occupancy of the heap's spans.
+// For each small size class, in order, it reports the object size,
+// the number of spans in use and their bytes, the number of allocated
+// objects, the number of object slots, and the number of sparse spans
+// (less than a quarter full) and the bytes of their free slots. Four
+// more values follow: the number of large object spans and their bytes,
+// and the bytes of free spans retained and released to the OS.
+//
+//go:linkname readFragmentation runtime/debug.readFragmentation
+func readFragmentation() []uint64 {
+	p := make([]uint64, (_NumSizeClasses-1)*fragClassFields+4)
+
+	// Finish sweeping, so that the allocation counts do not include
+	// objects the last collection found dead. A collection may start
+	// before the world stops; the report is approximate in that case.
+	for gosweepone() != ^uintptr(0) {
+		sweep.nbgsweep++
+	}
+
+	stopTheWorld("fragmentation report")
+	systemstack(func() {
+		readFragmentation_m(p)
+	})
+	startTheWorld()
+	return p
+}
+
+func readFragmentation_m(p []uint64) {
+	for i := 1; i < _NumSizeClasses; i++ {
+		p[(i-1)*fragClassFields] = uint64(class_to_size[i])
+	}
+	large := p[(_NumSizeClasses-1)*fragClassFields:]
+
+	// The world is stopped, so no span changes state, but the
+	// scavenger may release free pages concurrently.
+	lock(&mheap_.lock)
+	for _, s := range h_allspans {
+		switch s.state {
+		case _MSpanInUse:
+			if s.sizeclass == 0 {
+				large[0]++
+				large[1] += uint64(s.npages << _PageShift)
+				continue
+			}
+			c := p[(int(s.sizeclass)-1)*fragClassFields:]
+			n := uintptr(s.allocCount)
+			c[1]++
+			c[2] += uint64(s.npages << _PageShift)
+			c[3] += uint64(n)
+			c[4] += uint64(s.nelems)
+			if n*4 < s.nelems {
+				c[5]++
+				c[6] += uint64((s.nelems - n) * s.elemsize)
+			}
+		case _MSpanFree:
+			released := s.npreleased << _PageShift
+			large[2] += uint64(s.npages<<_PageShift - released)
+			large[3] += uint64(released)
+		}
+	}
+	unlock(&mheap_.lock)
+}
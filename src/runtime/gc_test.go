@@ -184,6 +184,33 @@ func TestPeriodicGC(t *testing.T) {
 	}
 }
 
+func TestGCCheckmarkDue(t *testing.T) {
+	// Walk a mocked cycle counter and check exactly which
+	// cycles are selected for verification.
+	tests := []struct {
+		every int32
+		want  []uint32
+	}{
+		{-1, nil},
+		{0, nil},
+		{1, []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{3, []uint32{3, 6, 9}},
+		{7, []uint32{7}},
+		{20, nil},
+	}
+	for _, tt := range tests {
+		var selected []uint32
+		for cycle := uint32(1); cycle <= 10; cycle++ {
+			if runtime.GCCheckmarkDue(tt.every, cycle) {
+				selected = append(selected, cycle)
+			}
+		}
+		if !reflect.DeepEqual(selected, tt.want) {
+			t.Errorf("gccheckmark=%d selected cycles %v, want %v", tt.every, selected, tt.want)
+		}
+	}
+}
+
 func BenchmarkSetTypePtr(b *testing.B) {
 	benchSetType(b, new(*byte))
 }
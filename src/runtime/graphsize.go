@@ -0,0 +1,141 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Estimation of the memory retained by an object graph,
+// for runtime/debug.GraphSize.
+
+package runtime
+
+import (
+	"runtime/internal/sys"
+	"unsafe"
+)
+
+// graphWalker walks the heap objects reachable from a value using the
+// heap bitmap, the same layout information the garbage collector uses.
+//
+// The walk runs concurrently with the mutator and the garbage
+// collector, so it validates each pointer before following it instead
+// of trusting it the way scanobject does: a concurrent mutation may
+// make an object unreachable and let it be freed while it is queued.
+// The work list holds uintptrs, which do not keep objects alive.
+type graphWalker struct {
+	visited map[uintptr]struct{}
+	work    []uintptr
+	max     uint64
+
+	bytes, objects uint64
+	truncated      bool
+}
+
+// object returns the base address and span of the allocated heap
+// object containing p, or 0 if p does not point into one.
+func (w *graphWalker) object(p uintptr) (uintptr, *mspan) {
+	s := spanOf(p)
+	if s == nil || p < s.base() || p >= s.limit || s.state != mSpanInUse {
+		return 0, nil
+	}
+	idx := s.objIndex(p)
+	if idx >= s.freeindex && s.isFree(idx) {
+		return 0, nil
+	}
+	return s.base() + idx*s.elemsize, s
+}
+
+// scanType queues the pointers in the value of type t at p, using the
+// type's pointer bitmap. Types described by a GC program are only
+// scanned if the value is in the heap, using the heap bitmap.
+func (w *graphWalker) scanType(p uintptr, t *_type) {
+	if t.kind&kindGCProg != 0 {
+		if base, s := w.object(p); base == p {
+			w.scanObject(base, s)
+		}
+		return
+	}
+	for i := uintptr(0); i < t.ptrdata/sys.PtrSize; i++ {
+		if *addb(t.gcdata, i/8)>>(i%8)&1 != 0 {
+			w.work = append(w.work, *(*uintptr)(unsafe.Pointer(p + i*sys.PtrSize)))
+		}
+	}
+}
+
+// scanObject queues the pointers in the heap object at base.
+// Like scanobject, it stops at the last pointer of the object.
+func (w *graphWalker) scanObject(base uintptr, s *mspan) {
+	n := s.elemsize
+	hbits := heapBitsForAddr(base)
+	if !hbits.hasPointers(n) {
+		return
+	}
+	for i := uintptr(0); i < n; i += sys.PtrSize {
+		if i != 0 {
+			hbits = hbits.next()
+		}
+		if i != 1*sys.PtrSize && !hbits.morePointers() {
+			break
+		}
+		if hbits.isPointer() {
+			if obj := *(*uintptr)(unsafe.Pointer(base + i)); obj != 0 {
+				w.work = append(w.work, obj)
+			}
+		}
+	}
+}
+
+// walk counts the objects reachable from the queued pointers.
+func (w *graphWalker) walk() {
+	for len(w.work) > 0 {
+		p := w.work[len(w.work)-1]
+		w.work = w.work[:len(w.work)-1]
+		base, s := w.object(p)
+		if base == 0 {
+			continue
+		}
+		if _, ok := w.visited[base]; ok {
+			continue
+		}
+		if w.max > 0 && w.objects >= w.max {
+			w.truncated = true
+			return
+		}
+		w.visited[base] = struct{}{}
+		w.objects++
+		w.bytes += uint64(s.elemsize)
+		w.scanObject(base, s)
+	}
+}
+
+//go:linkname graphSize runtime/debug.graphSize
+func graphSize(p interface{}, maxObjects int) (bytes, objects uint64, truncated bool) {
+	e := efaceOf(&p)
+	t := e._type
+	if t == nil || e.data == nil {
+		return
+	}
+	w := &graphWalker{visited: make(map[uintptr]struct{})}
+	if maxObjects > 0 {
+		w.max = uint64(maxObjects)
+	}
+	data := uintptr(e.data)
+	switch {
+	case !isDirectIface(t):
+		// The interface holds a pointer to a copy of the value,
+		// which the caller did not allocate. Walk from the
+		// pointers in it without counting it.
+		w.scanType(data, t)
+	case t.kind&kindMask == kindPtr:
+		if base, _ := w.object(data); base == 0 {
+			// A pointer to memory outside the heap, such as
+			// a global variable. Walk from the pointers in it.
+			w.scanType(data, (*ptrtype)(unsafe.Pointer(t)).elem)
+			break
+		}
+		w.work = append(w.work, data)
+	default:
+		w.work = append(w.work, data)
+	}
+	w.walk()
+	KeepAlive(p)
+	return w.bytes, w.objects, w.truncated
+}
@@ -0,0 +1,209 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Goroutine state profiling.
+//
+// While the profile is enabled, sysmon wakes the sampler goroutine
+// once per sampling period. The sampler looks at a bounded random
+// subset of all goroutines and, for each one, records its state (or
+// wait reason) and stack in a profile bucket. The world is not
+// stopped: a goroutine that is not running is held still for the
+// duration of its stack walk by setting its scan bit, the same way
+// the garbage collector scans stacks concurrently.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"runtime/internal/sys"
+	"unsafe"
+)
+
+// gstateMaxSample is the maximum number of goroutines examined per sample.
+const gstateMaxSample = 64
+
+var gstateprof struct {
+	period  uint64 // sampling period in nanoseconds, 0 if disabled; accessed atomically
+	last    int64  // nanotime of the last sample
+	elapsed int64  // nanoseconds covered by the pending sample
+	lock    mutex
+	g       *g
+	idle    uint32
+	started uint32
+}
+
+// SetGoroutineStateProfileRate sets the sampling rate of the goroutine
+// state profile to hz samples per second. If hz <= 0, sampling is
+// turned off. A rate of 100 (one sample every 10ms) is a good default.
+// The sampler is driven by the runtime's system monitor, which checks
+// at most every 10ms, so rates above 100 are not honored exactly.
+//
+// Each sample records the state of a bounded random subset of the
+// goroutines, so the cost of a sample does not grow with the number of
+// goroutines. Sampling does not stop the world.
+//
+// Most clients should use the runtime/pprof package's "goroutinestate"
+// profile instead of calling GoroutineStateProfile directly.
+func SetGoroutineStateProfileRate(hz int) {
+	var period uint64
+	if hz > 0 {
+		period = 1e9 / uint64(hz)
+		if period == 0 {
+			period = 1
+		}
+		if atomic.Cas(&gstateprof.started, 0, 1) {
+			go gstateprofhelper()
+		}
+	}
+	lock(&gstateprof.lock)
+	gstateprof.last = nanotime()
+	unlock(&gstateprof.lock)
+	atomic.Store64(&gstateprof.period, period)
+}
+
+// gstateprofwake is called by sysmon to start a sample if one is due.
+//
+//go:nowritebarrierrec
+func gstateprofwake(now int64) {
+	period := int64(atomic.Load64(&gstateprof.period))
+	if period == 0 || atomic.Load(&gstateprof.idle) == 0 {
+		return
+	}
+	lock(&gstateprof.lock)
+	if now-gstateprof.last < period {
+		unlock(&gstateprof.lock)
+		return
+	}
+	gstateprof.elapsed = now - gstateprof.last
+	gstateprof.last = now
+	gstateprof.idle = 0
+	gstateprof.g.schedlink = 0
+	injectglist(gstateprof.g)
+	unlock(&gstateprof.lock)
+}
+
+func gstateprofhelper() {
+	gstateprof.g = getg()
+	for {
+		lock(&gstateprof.lock)
+		atomic.Store(&gstateprof.idle, 1)
+		goparkunlock(&gstateprof.lock, "goroutine state profile (idle)", traceEvGoBlock, 1)
+		// this goroutine is explicitly resumed by sysmon
+		elapsed := gstateprof.elapsed
+		systemstack(func() {
+			gstatesample(elapsed)
+		})
+	}
+}
+
+// gstatesample records the states of a random subset of at most
+// gstateMaxSample goroutines. The subset stands for all goroutines
+// over the elapsed nanoseconds.
+//
+//go:systemstack
+func gstatesample(elapsed int64) {
+	// Pick the goroutines without holding allglock for the rest of
+	// the sample. allgs only grows and its goroutines are never freed,
+	// so the pointers stay valid.
+	var gs [gstateMaxSample]*g
+	lock(&allglock)
+	ng := len(allgs)
+	n := copy(gs[:], allgs)
+	if ng > len(gs) {
+		for i := range gs {
+			gs[i] = allgs[fastrand1()%uint32(ng)]
+		}
+	}
+	unlock(&allglock)
+	if n == 0 {
+		return
+	}
+	nanos := elapsed * int64(ng) / int64(n)
+
+	me := getg().m.curg
+	var stk [maxStack]uintptr
+	for _, gp := range gs[:n] {
+		if gp == me || isSystemGoroutine(gp) {
+			continue
+		}
+		var state string
+		var nstk int
+		switch s := readgstatus(gp); s {
+		default:
+			// Dead, or changing state; leave it out of this sample.
+			continue
+		case _Grunning:
+			// The stack of a running goroutine cannot be walked;
+			// report the function that started it instead.
+			state = "running"
+			stk[0] = gp.startpc + sys.PCQuantum
+			nstk = 1
+		case _Grunnable, _Gsyscall, _Gwaiting:
+			// The scan bit keeps gp from running until we are done.
+			if !castogscanstatus(gp, s, s|_Gscan) {
+				continue
+			}
+			switch s {
+			case _Grunnable:
+				state = "runnable"
+			case _Gsyscall:
+				state = "syscall"
+			default:
+				state = gp.waitreason
+				if state == "" {
+					state = "waiting"
+				}
+			}
+			nstk = gentraceback(^uintptr(0), ^uintptr(0), 0, gp, 0, &stk[0], len(stk), nil, nil, 0)
+			restartg(gp)
+		}
+		lock(&proflock)
+		b := stkbucket(gstateProfile, uintptr((*stringStruct)(unsafe.Pointer(&state)).str), stk[:nstk], true)
+		r := b.gsp()
+		r.state = state
+		r.count++
+		r.nanos += nanos
+		unlock(&proflock)
+	}
+}
+
+// GoroutineStateProfileRecord describes the goroutines seen in one
+// state at a particular call sequence (stack trace).
+type GoroutineStateProfileRecord struct {
+	State       string // "running", "runnable", "syscall" or the wait reason
+	Count       int64  // number of goroutines seen, summed over all samples
+	Nanoseconds int64  // estimated goroutine time spent in State
+	StackRecord
+}
+
+// GoroutineStateProfile returns n, the number of records in the current
+// goroutine state profile.
+// If len(p) >= n, GoroutineStateProfile copies the profile into p and returns n, true.
+// If len(p) < n, GoroutineStateProfile does not change p and returns n, false.
+//
+// Most clients should use the runtime/pprof package instead
+// of calling GoroutineStateProfile directly.
+func GoroutineStateProfile(p []GoroutineStateProfileRecord) (n int, ok bool) {
+	lock(&proflock)
+	for b := gsbuckets; b != nil; b = b.allnext {
+		n++
+	}
+	if n <= len(p) {
+		ok = true
+		for b := gsbuckets; b != nil; b = b.allnext {
+			gsp := b.gsp()
+			r := &p[0]
+			r.State = gsp.state
+			r.Count = gsp.count
+			r.Nanoseconds = gsp.nanos
+			i := copy(r.Stack0[:], b.stk())
+			for ; i < len(r.Stack0); i++ {
+				r.Stack0[i] = 0
+			}
+			p = p[1:]
+		}
+	}
+	unlock(&proflock)
+	return
+}
@@ -617,6 +617,55 @@ done:
 	h.flags &^= hashWriting
 }
 
+// mapclear deletes all entries from h. The buckets, including overflow
+// buckets, are kept for reuse by later insertions. Iterators in progress
+// see the entries as deleted, as with mapdelete.
+func mapclear(t *maptype, h *hmap) {
+	if raceenabled && h != nil {
+		callerpc := getcallerpc(unsafe.Pointer(&t))
+		racewritepc(unsafe.Pointer(h), callerpc, funcPC(mapclear))
+	}
+	if h == nil || h.count == 0 {
+		return
+	}
+	if h.flags&hashWriting != 0 {
+		throw("concurrent map writes")
+	}
+	h.flags |= hashWriting
+
+	// Clearing stores only nil pointers, which need no
+	// write barrier.
+	datasize := uintptr(t.bucketsize) - dataOffset - uintptr(sys.PtrSize)
+	clearbuckets := func(buckets unsafe.Pointer, n uintptr) {
+		for i := uintptr(0); i < n; i++ {
+			b := (*bmap)(add(buckets, i*uintptr(t.bucketsize)))
+			for ; b != nil; b = b.overflow(t) {
+				for j := range b.tophash {
+					b.tophash[j] = empty
+				}
+				memclr(add(unsafe.Pointer(b), dataOffset), datasize)
+			}
+		}
+	}
+	clearbuckets(h.buckets, uintptr(1)<<h.B)
+	if h.oldbuckets != nil {
+		// Abandon the grow: every old bucket is now empty,
+		// so there is nothing left to evacuate.
+		clearbuckets(h.oldbuckets, uintptr(1)<<(h.B-1))
+		h.oldbuckets = nil
+		h.nevacuate = 0
+		if h.overflow != nil {
+			h.overflow[1] = nil
+		}
+	}
+	h.count = 0
+
+	if h.flags&hashWriting == 0 {
+		throw("concurrent map writes")
+	}
+	h.flags &^= hashWriting
+}
+
 func mapiterinit(t *maptype, h *hmap, it *hiter) {
 	// Clear pointer fields so garbage collector does not complain.
 	it.key = nil
@@ -1023,6 +1072,11 @@ func reflect_mapdelete(t *maptype, h *hmap, key unsafe.Pointer) {
 	mapdelete(t, h, key)
 }
 
+//go:linkname reflect_mapclear reflect.mapclear
+func reflect_mapclear(t *maptype, h *hmap) {
+	mapclear(t, h)
+}
+
 //go:linkname reflect_mapiterinit reflect.mapiterinit
 func reflect_mapiterinit(t *maptype, h *hmap) *hiter {
 	it := new(hiter)
@@ -1040,6 +1094,11 @@ func reflect_mapiterkey(it *hiter) unsafe.Pointer {
 	return it.key
 }
 
+//go:linkname reflect_mapitervalue reflect.mapitervalue
+func reflect_mapitervalue(it *hiter) unsafe.Pointer {
+	return it.value
+}
+
 //go:linkname reflect_maplen reflect.maplen
 func reflect_maplen(h *hmap) int {
 	if h == nil {
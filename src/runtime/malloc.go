@@ -661,6 +661,9 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 			if needzero && span.needzero != 0 {
 				memclr(unsafe.Pointer(v), size)
 			}
+			if debug.malloczero != 0 {
+				mallocZeroDebug(x, size, span, needzero, noscan)
+			}
 		}
 	} else {
 		var s *mspan
@@ -672,6 +675,9 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 		s.allocCount = 1
 		x = unsafe.Pointer(s.base())
 		size = s.elemsize
+		if debug.malloczero != 0 {
+			mallocZeroDebug(x, size, s, needzero, noscan)
+		}
 	}
 
 	var scanSize uintptr
@@ -755,6 +761,49 @@ func mallocgc(size uintptr, typ *_type, needzero bool) unsafe.Pointer {
 	return x
 }
 
+// Values of debug.malloczero, set by GODEBUG=malloczero=poison or
+// GODEBUG=malloczero=verify.
+const (
+	// mallocZeroPoison fills noscan objects allocated without
+	// zeroing with mallocZeroPoisonByte, so code that reads memory
+	// it never initialized sees garbage instead of zeros.
+	mallocZeroPoison = 1
+
+	// mallocZeroVerify checks that every object the allocator
+	// returns as zeroed really is zero, which catches writes into
+	// free slots of spans whose needzero flag is clear.
+	mallocZeroVerify = 2
+
+	mallocZeroPoisonByte = 0xa5
+)
+
+// mallocZeroDebug implements GODEBUG=malloczero for the object x of
+// size bytes just allocated from span s. It is kept out of line so
+// that mallocgc only pays for a single test of debug.malloczero.
+//go:noinline
+func mallocZeroDebug(x unsafe.Pointer, size uintptr, s *mspan, needzero, noscan bool) {
+	switch debug.malloczero {
+	case mallocZeroPoison:
+		if needzero || !noscan {
+			return
+		}
+		for i := uintptr(0); i < size; i++ {
+			*(*byte)(add(x, i)) = mallocZeroPoisonByte
+		}
+	case mallocZeroVerify:
+		if !needzero {
+			return
+		}
+		for i := uintptr(0); i < size; i++ {
+			if v := *(*byte)(add(x, i)); v != 0 {
+				print("runtime: malloczero: object ", x, " of size ", size, " has byte ", hex(v), " at offset ", i, "\n")
+				print("runtime: span base=", hex(s.base()), " limit=", hex(s.limit), " sizeclass=", s.sizeclass, " elemsize=", s.elemsize, " npages=", s.npages, " needzero=", s.needzero, "\n")
+				throw("malloczero: allocated memory is not zero")
+			}
+		}
+	}
+}
+
 func largeAlloc(size uintptr, needzero bool) *mspan {
 	// print("largeAlloc size=", size, "\n")
 
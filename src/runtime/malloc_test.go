@@ -6,7 +6,10 @@ package runtime_test
 
 import (
 	"flag"
+	"internal/testenv"
+	"os/exec"
 	. "runtime"
+	"strings"
 	"testing"
 	"time"
 	"unsafe"
@@ -108,6 +111,65 @@ func TestTinyAlloc(t *testing.T) {
 	}
 }
 
+func TestMallocZeroPoison(t *testing.T) {
+	defer SetMallocZeroPoison(SetMallocZeroPoison(true))
+
+	// Sizes below the tiny allocator's limit share zeroed blocks
+	// and are never poisoned.
+	for _, size := range []int{16, 100, 1000, 9000, 40 << 10} {
+		b := MallocNoZero(size)
+		for i, v := range b {
+			if v != MallocZeroPoisonByte {
+				t.Fatalf("MallocNoZero(%d)[%d] = %#x, want %#x", size, i, v, MallocZeroPoisonByte)
+			}
+		}
+		b = make([]byte, size)
+		for i, v := range b {
+			if v != 0 {
+				t.Fatalf("make([]byte, %d)[%d] = %#x, want 0", size, i, v)
+			}
+		}
+	}
+
+	// Poisoned objects that are freed must be zeroed again before
+	// they are handed out as zeroed memory.
+	for i := 0; i < 100; i++ {
+		MallocNoZero(1000)
+	}
+	GC()
+	for i := 0; i < 100; i++ {
+		b := make([]byte, 1000)
+		for j, v := range b {
+			if v != 0 {
+				t.Fatalf("make([]byte, 1000)[%d] = %#x after GC, want 0", j, v)
+			}
+		}
+	}
+}
+
+func TestMallocZeroVerify(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	exe, err := buildTestProg(t, "testprog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := testEnv(exec.Command(exe, "MallocZeroVerify"))
+	cmd.Env = append(cmd.Env, "GODEBUG=malloczero=verify")
+	out, _ := cmd.CombinedOutput()
+	output := string(out)
+	for _, want := range []string{
+		"runtime: malloczero: object ",
+		" has byte 0x1 at offset 1\n",
+		"runtime: span base=",
+		"fatal error: malloczero: allocated memory is not zero",
+		"main.MallocZeroVerify(",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+}
+
 var mallocSink uintptr
 
 func BenchmarkMalloc8(b *testing.B) {
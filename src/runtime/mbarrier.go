@@ -207,6 +207,15 @@ func reflect_typedmemmovepartial(typ *_type, dst, src unsafe.Pointer, off, size
 	heapBitsBulkBarrier(uintptr(dst), size&^(sys.PtrSize-1))
 }
 
+// typedmemclrpartial clears size bytes at ptr, which points off bytes
+// into a value of type typ. Unlike typedmemmove it needs no bulk write
+// barrier: the write barrier shades the pointers being stored, and
+// clearing only stores nil.
+//go:linkname reflect_typedmemclrpartial reflect.typedmemclrpartial
+func reflect_typedmemclrpartial(typ *_type, ptr unsafe.Pointer, off, size uintptr) {
+	memclr(ptr, size)
+}
+
 // callwritebarrier is invoked at the end of reflectcall, to execute
 // write barrier operations to record the fact that a call's return
 // values have just been copied to frame, starting at retoffset
@@ -213,6 +213,23 @@ func setGCPercent(in int32) (out int32) {
 	return out
 }
 
+// heapGoalAdjuster, if non-nil, is called at the end of each GC cycle
+// to adjust the heap goal of the next cycle. It is protected by
+// mheap_.lock. See gcAdjustHeapGoal.
+var heapGoalAdjuster func(uint64) uint64
+
+//go:linkname setHeapGoalAdjuster runtime/debug.setHeapGoalAdjuster
+func setHeapGoalAdjuster(f func(uint64) uint64) (old func(uint64) uint64) {
+	lock(&mheap_.lock)
+	old = heapGoalAdjuster
+	heapGoalAdjuster = f
+	if raceenabled {
+		racereleasemerge(unsafe.Pointer(&heapGoalAdjuster))
+	}
+	unlock(&mheap_.lock)
+	return old
+}
+
 // Garbage collector phase.
 // Indicates to write barrier and sychronization task to preform.
 var gcphase uint32
@@ -368,6 +385,11 @@ type gcControllerState struct {
 	// assistBytesPerWork is 1/assistWorkPerByte.
 	assistBytesPerWork float64
 
+	// tracedAssistWorkPerByte is the assist ratio in the last
+	// traceEvGCPacer event, so that revise only traces changes
+	// that matter.
+	tracedAssistWorkPerByte float64
+
 	// fractionalUtilizationGoal is the fraction of wall clock
 	// time that should be spent in the fractional mark worker.
 	// For example, if the overall mark utilization goal is 25%
@@ -453,6 +475,10 @@ func (c *gcControllerState) startCycle() {
 	// throughout the cycle.
 	c.revise()
 
+	if trace.enabled {
+		traceGCPacer(traceGCPacerStart)
+	}
+
 	if debug.gcpacertrace > 0 {
 		print("pacer: assist ratio=", c.assistWorkPerByte,
 			" (scan ", memstats.heap_scan>>20, " MB in ",
@@ -518,6 +544,15 @@ func (c *gcControllerState) revise() {
 	// have done (or stolen) the remaining amount of scan work.
 	c.assistWorkPerByte = float64(scanWorkExpected) / float64(heapDistance)
 	c.assistBytesPerWork = float64(heapDistance) / float64(scanWorkExpected)
+
+	if trace.enabled && gcBlackenEnabled != 0 {
+		// revise runs on every span allocation during the cycle,
+		// so only trace changes of more than 1/8 of the ratio.
+		d := c.assistWorkPerByte - c.tracedAssistWorkPerByte
+		if d > c.tracedAssistWorkPerByte/8 || -d > c.tracedAssistWorkPerByte/8 {
+			traceGCPacer(traceGCPacerRevise)
+		}
+	}
 }
 
 // endCycle updates the GC controller state at the end of the
@@ -525,6 +560,10 @@ func (c *gcControllerState) revise() {
 func (c *gcControllerState) endCycle() {
 	h_t := c.triggerRatio // For debugging
 
+	if trace.enabled {
+		traceGCPacer(traceGCPacerEnd)
+	}
+
 	// Proportional response gain for the trigger controller. Must
 	// be in [0, 1]. Lower values smooth out transient effects but
 	// take longer to respond to phase changes. Higher values
@@ -798,6 +837,11 @@ var work struct {
 	// mode is the concurrency mode of the current GC cycle.
 	mode gcMode
 
+	// checkmark indicates that this cycle's mark termination runs
+	// a checkmark verification pass. It is chosen at the start of
+	// mark termination according to debug.gccheckmark.
+	checkmark bool
+
 	// Copy of mheap.allspans for marker or sweeper.
 	spans []*mspan
 
@@ -1152,6 +1196,14 @@ top:
 	}
 }
 
+// gcCheckmarkDue reports whether GC cycle number cycle (counting
+// from 1) should run a checkmark verification pass when
+// GODEBUG=gccheckmark=every. A value of 1 checks every cycle and a
+// value of 0 or less disables checking.
+func gcCheckmarkDue(every int32, cycle uint32) bool {
+	return every > 0 && cycle%uint32(every) == 0
+}
+
 func gcMarkTermination() {
 	// World is stopped.
 	// Start marktermination which includes enabling the write barrier.
@@ -1160,6 +1212,7 @@ func gcMarkTermination() {
 	setGCPhase(_GCmarktermination)
 
 	work.heap1 = memstats.heap_live
+	work.checkmark = gcCheckmarkDue(debug.gccheckmark, memstats.numgc+1)
 	startTime := nanotime()
 
 	mp := acquirem()
@@ -1188,7 +1241,7 @@ func gcMarkTermination() {
 
 	systemstack(func() {
 		work.heap2 = work.bytesMarked
-		if debug.gccheckmark > 0 {
+		if work.checkmark {
 			// Run a full stop-the-world mark using checkmark bits,
 			// to check that we didn't forget to mark anything during
 			// the concurrent mark process.
@@ -1310,10 +1363,17 @@ func gcMarkTermination() {
 		if work.mode != gcBackgroundMode {
 			print(" (forced)")
 		}
+		if work.checkmark {
+			print(" (checkmark)")
+		}
 		print("\n")
 		printunlock()
 	}
 
+	// Adjust the goal before dropping worldsema so the next cycle
+	// cannot start with the unadjusted goal.
+	gcAdjustHeapGoal()
+
 	semrelease(&worldsema)
 	// Careful: another GC cycle may start now.
 
@@ -1578,7 +1638,7 @@ func gcMark(start_time int64) {
 	gcDrain(gcw, gcDrainBlock)
 	gcw.dispose()
 
-	if debug.gccheckmark > 0 {
+	if work.checkmark {
 		// This is expensive when there's a large number of
 		// Gs, so only do it if checkmark is also enabled.
 		gcMarkRootCheck()
@@ -1654,6 +1714,58 @@ func gcMark(start_time int64) {
 	}
 }
 
+// gcAdjustHeapGoal passes the heap goal computed by the pacer for the
+// next cycle to heapGoalAdjuster, if any, and replaces the goal with
+// the result.
+//
+// The world must be running and worldsema must be held, so that the
+// next cycle cannot start. The adjuster is called without mheap_.lock
+// held but with the m locked, so it must not block. If it allocates,
+// the allocation cannot start a cycle.
+func gcAdjustHeapGoal() {
+	lock(&mheap_.lock)
+	f := heapGoalAdjuster
+	goal := memstats.next_gc
+	unlock(&mheap_.lock)
+	if f == nil || gcpercent < 0 {
+		return
+	}
+	if raceenabled {
+		// The adjuster may read what was written before
+		// it was installed.
+		raceacquire(unsafe.Pointer(&heapGoalAdjuster))
+	}
+	goal = f(goal)
+
+	lock(&mheap_.lock)
+	// As in gcMark, leave concurrent sweep some heap growth
+	// in which to sweep before the next cycle starts.
+	minNextGC := memstats.heap_marked + sweepMinHeapDistance*uint64(gcpercent)/100
+	if goal < minNextGC {
+		goal = minNextGC
+	}
+	if int64(goal) < 0 {
+		goal = 1<<63 - 1
+	}
+	memstats.next_gc = goal
+	if mheap_.sweepPagesPerByte != 0 {
+		// Recompute the sweep ratio of gcSweep for the new goal.
+		// Proportional sweep has already started, so count the
+		// spans allocated so far as part of the distance.
+		heapDistance := int64(goal) - int64(memstats.heap_live) - 1024*1024
+		if heapDistance < _PageSize {
+			heapDistance = _PageSize
+		}
+		heapDistance += int64(atomic.Load64(&mheap_.spanBytesAlloc))
+		mheap_.sweepPagesPerByte = float64(mheap_.pagesInUse) / float64(heapDistance)
+	}
+	unlock(&mheap_.lock)
+
+	if trace.enabled {
+		traceNextGC()
+	}
+}
+
 func gcSweep(mode gcMode) {
 	if gcphase != _GCoff {
 		throw("gcSweep being done but phase is not GCoff")
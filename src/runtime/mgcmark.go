@@ -1193,6 +1193,8 @@ func greyobject(obj, base, off uintptr, hbits heapBits, span *mspan, gcw *gcWork
 		if !mbits.isMarked() {
 			printlock()
 			print("runtime:greyobject: checkmarks finds unexpected unmarked object obj=", hex(obj), "\n")
+			print("runtime: in GC cycle ", memstats.numgc+1, ", obj is reachable but was not marked by the concurrent mark phase\n")
+			print("runtime: obj span s.sizeclass=", span.sizeclass, " s.elemsize=", span.elemsize, "\n")
 			print("runtime: found obj at *(", hex(base), "+", hex(off), ")\n")
 
 			// Dump the source (base) object
@@ -22,6 +22,7 @@ const (
 	// profile types
 	memProfile bucketType = 1 + iota
 	blockProfile
+	gstateProfile
 
 	// size of bucket hash table
 	buckHashSize = 179999
@@ -36,14 +37,14 @@ type bucketType int
 // The representation is a bit sleazy, inherited from C.
 // This struct defines the bucket header. It is followed in
 // memory by the stack words and then the actual record
-// data, either a memRecord or a blockRecord.
+// data, either a memRecord, a blockRecord or a gstateRecord.
 //
 // Per-call-stack profiling information.
 // Lookup by hashing call stack into a linked-list hash table.
 type bucket struct {
 	next    *bucket
 	allnext *bucket
-	typ     bucketType // memBucket or blockBucket
+	typ     bucketType // memBucket, blockBucket or gstateBucket
 	hash    uintptr
 	size    uintptr
 	nstk    uintptr
@@ -89,9 +90,19 @@ type blockRecord struct {
 	cycles int64
 }
 
+// A gstateRecord is the bucket data for a bucket of type gstateProfile,
+// part of the goroutine state profile. The bucket size is the address
+// of the state string, which is always a string constant.
+type gstateRecord struct {
+	state string
+	count int64
+	nanos int64
+}
+
 var (
 	mbuckets  *bucket // memory profile buckets
 	bbuckets  *bucket // blocking profile buckets
+	gsbuckets *bucket // goroutine state profile buckets
 	buckhash  *[179999]*bucket
 	bucketmem uintptr
 )
@@ -106,6 +117,8 @@ func newBucket(typ bucketType, nstk int) *bucket {
 		size += unsafe.Sizeof(memRecord{})
 	case blockProfile:
 		size += unsafe.Sizeof(blockRecord{})
+	case gstateProfile:
+		size += unsafe.Sizeof(gstateRecord{})
 	}
 
 	b := (*bucket)(persistentalloc(size, 0, &memstats.buckhash_sys))
@@ -139,6 +152,15 @@ func (b *bucket) bp() *blockRecord {
 	return (*blockRecord)(data)
 }
 
+// gsp returns the gstateRecord associated with the gstateProfile bucket b.
+func (b *bucket) gsp() *gstateRecord {
+	if b.typ != gstateProfile {
+		throw("bad use of bucket.gsp")
+	}
+	data := add(unsafe.Pointer(b), unsafe.Sizeof(*b)+b.nstk*unsafe.Sizeof(uintptr(0)))
+	return (*gstateRecord)(data)
+}
+
 // Return the bucket for stk[0:nstk], allocating new bucket if needed.
 func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket {
 	if buckhash == nil {
@@ -181,12 +203,16 @@ func stkbucket(typ bucketType, size uintptr, stk []uintptr, alloc bool) *bucket
 	b.size = size
 	b.next = buckhash[i]
 	buckhash[i] = b
-	if typ == memProfile {
+	switch typ {
+	case memProfile:
 		b.allnext = mbuckets
 		mbuckets = b
-	} else {
+	case blockProfile:
 		b.allnext = bbuckets
 		bbuckets = b
+	case gstateProfile:
+		b.allnext = gsbuckets
+		gsbuckets = b
 	}
 	return b
 }
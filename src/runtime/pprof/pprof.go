@@ -38,14 +38,19 @@ import (
 //
 // Each Profile has a unique name. A few profiles are predefined:
 //
-//	goroutine    - stack traces of all current goroutines
-//	heap         - a sampling of all heap allocations
-//	threadcreate - stack traces that led to the creation of new OS threads
-//	block        - stack traces that led to blocking on synchronization primitives
+//	goroutine      - stack traces of all current goroutines
+//	heap           - a sampling of all heap allocations
+//	threadcreate   - stack traces that led to the creation of new OS threads
+//	block          - stack traces that led to blocking on synchronization primitives
+//	goroutinestate - sampled states and wait reasons of goroutines over time
 //
 // These predefined profiles maintain themselves and panic on an explicit
 // Add or Remove method call.
 //
+// The goroutinestate profile is empty unless sampling has been enabled
+// with runtime.SetGoroutineStateProfileRate. Unlike the CPU profile, it
+// also covers goroutines that are blocked or in system calls.
+//
 // The heap profile reports statistics as of the most recently completed
 // garbage collection; it elides more recent allocation to avoid skewing
 // the profile away from live data and toward garbage.
@@ -65,10 +70,14 @@ import (
 //
 // 每个 Profile 都有唯一的名称。有些 Profile 是预定义的：
 //
-//	goroutine    - 所有当前Go程的栈跟踪
-//	heap         - 所有堆分配的采样
-//	threadcreate - 引导新OS的线程创建的栈跟踪
-//	block        - 引导同步原语中阻塞的栈跟踪
+//	goroutine      - 所有当前Go程的栈跟踪
+//	heap           - 所有堆分配的采样
+//	threadcreate   - 引导新OS的线程创建的栈跟踪
+//	block          - 引导同步原语中阻塞的栈跟踪
+//	goroutinestate - Go程随时间采样的状态及等待原因
+//
+// 除非已通过 runtime.SetGoroutineStateProfileRate 启用采样，否则 goroutinestate
+// 分析为空。与 CPU 分析不同，它也涵盖了阻塞中或处于系统调用中的Go程。
 //
 // 这些预声明分析并不能作为 Profile 使用。它有专门的API，即 StartCPUProfile 和
 // StopCPUProfile 函数，因为它在分析时是以流的形式输出到写入器的。
@@ -113,15 +122,22 @@ var blockProfile = &Profile{
 	write: writeBlock,
 }
 
+var goroutineStateProfile = &Profile{
+	name:  "goroutinestate",
+	count: countGoroutineState,
+	write: writeGoroutineState,
+}
+
 func lockProfiles() {
 	profiles.mu.Lock()
 	if profiles.m == nil {
 		// Initial built-in profiles.
 		profiles.m = map[string]*Profile{
-			"goroutine":    goroutineProfile,
-			"threadcreate": threadcreateProfile,
-			"heap":         heapProfile,
-			"block":        blockProfile,
+			"goroutine":      goroutineProfile,
+			"threadcreate":   threadcreateProfile,
+			"heap":           heapProfile,
+			"block":          blockProfile,
+			"goroutinestate": goroutineStateProfile,
 		}
 	}
 }
@@ -825,4 +841,59 @@ func writeBlock(w io.Writer, debug int) error {
 	return b.Flush()
 }
 
+type byNanoseconds []runtime.GoroutineStateProfileRecord
+
+func (x byNanoseconds) Len() int           { return len(x) }
+func (x byNanoseconds) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x byNanoseconds) Less(i, j int) bool { return x[i].Nanoseconds > x[j].Nanoseconds }
+
+// countGoroutineState returns the number of records in the goroutine state profile.
+func countGoroutineState() int {
+	n, _ := runtime.GoroutineStateProfile(nil)
+	return n
+}
+
+// writeGoroutineState writes the current goroutine state profile to w.
+// Each record is followed by a comment line naming the state.
+func writeGoroutineState(w io.Writer, debug int) error {
+	var p []runtime.GoroutineStateProfileRecord
+	n, ok := runtime.GoroutineStateProfile(nil)
+	for {
+		p = make([]runtime.GoroutineStateProfileRecord, n+50)
+		n, ok = runtime.GoroutineStateProfile(p)
+		if ok {
+			p = p[:n]
+			break
+		}
+	}
+
+	sort.Sort(byNanoseconds(p))
+
+	b := bufio.NewWriter(w)
+	var tw *tabwriter.Writer
+	w = b
+	if debug > 0 {
+		tw = tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+		w = tw
+	}
+
+	fmt.Fprintf(w, "--- goroutinestate:\n")
+	for i := range p {
+		r := &p[i]
+		fmt.Fprintf(w, "%v %v @", r.Nanoseconds, r.Count)
+		for _, pc := range r.Stack() {
+			fmt.Fprintf(w, " %#x", pc)
+		}
+		fmt.Fprintf(w, "\n# state: %s\n", r.State)
+		if debug > 0 {
+			printStackRecord(w, r.Stack(), true)
+		}
+	}
+
+	if tw != nil {
+		tw.Flush()
+	}
+	return b.Flush()
+}
+
 func runtime_cyclesPerSecond() int64
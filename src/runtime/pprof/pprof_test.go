@@ -631,3 +631,84 @@ func containsInOrder(s string, all ...string) bool {
 	}
 	return true
 }
+
+func gstateChanWait(c chan int, wg *sync.WaitGroup) {
+	defer wg.Done()
+	<-c
+}
+
+func gstateSyscallWait(r *os.File, wg *sync.WaitGroup) {
+	defer wg.Done()
+	r.Read(make([]byte, 1))
+}
+
+func TestGoroutineStateProfile(t *testing.T) {
+	if runtime.GOOS == "nacl" {
+		t.Skip("skipping on nacl; pipes do not block in system calls")
+	}
+	c := make(chan int)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	// Twice as many goroutines wait on the channel as in the system call.
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		if i%3 == 0 {
+			go gstateSyscallWait(r, &wg)
+		} else {
+			go gstateChanWait(c, &wg)
+		}
+	}
+	time.Sleep(10 * time.Millisecond) // let goroutines block
+
+	runtime.SetGoroutineStateProfileRate(100)
+	time.Sleep(500 * time.Millisecond)
+	runtime.SetGoroutineStateProfileRate(0)
+
+	close(c)
+	w.Write(make([]byte, 2))
+	wg.Wait()
+
+	var p []runtime.GoroutineStateProfileRecord
+	n, ok := runtime.GoroutineStateProfile(nil)
+	for !ok {
+		p = make([]runtime.GoroutineStateProfileRecord, n+10)
+		n, ok = runtime.GoroutineStateProfile(p)
+	}
+	p = p[:n]
+
+	nanos := make(map[string]int64)
+	for _, r := range p {
+		for _, pc := range r.Stack() {
+			f := runtime.FuncForPC(pc - 1)
+			if f == nil {
+				continue
+			}
+			if name := f.Name(); strings.HasSuffix(name, ".gstateChanWait") || strings.HasSuffix(name, ".gstateSyscallWait") {
+				nanos[name[strings.LastIndex(name, ".")+1:]+" "+r.State] += r.Nanoseconds
+				break
+			}
+		}
+	}
+	chanWait := nanos["gstateChanWait chan receive"]
+	syscallWait := nanos["gstateSyscallWait syscall"]
+	if chanWait == 0 || syscallWait == 0 {
+		t.Fatalf("missing samples: got %v", nanos)
+	}
+	if ratio := float64(chanWait) / float64(syscallWait); ratio < 1.25 || ratio > 3.5 {
+		t.Errorf("chan receive/syscall time ratio = %.2f, want about 2 (%v)", ratio, nanos)
+	}
+
+	var buf bytes.Buffer
+	Lookup("goroutinestate").WriteTo(&buf, 1)
+	prof := buf.String()
+	if !strings.HasPrefix(prof, "--- goroutinestate:\n") || !strings.Contains(prof, "\n# state: chan receive\n") ||
+		!strings.Contains(prof, "pprof_test.gstateChanWait+") {
+		t.Errorf("unexpected goroutinestate profile:\n%s", prof)
+	}
+}
@@ -457,18 +457,19 @@ func schedinit() {
 	goargs()
 	goenvs()
 	parsedebugvars()
+	if raceenabled {
+		raceancestryinit()
+	}
 	gcinit()
 
 	sched.lastpoll = uint64(nanotime())
-	procs := int(ncpu)
-	if procs > _MaxGomaxprocs {
-		procs = _MaxGomaxprocs
-	}
+	procs := int(defaultgomaxprocs())
 	if n := atoi(gogetenv("GOMAXPROCS")); n > 0 {
 		if n > _MaxGomaxprocs {
 			n = _MaxGomaxprocs
 		}
 		procs = n
+		sched.customgomaxprocs = true
 	}
 	if procresize(int32(procs)) != nil {
 		throw("unknown runnable goroutine during bootstrap")
@@ -487,6 +488,20 @@ func dumpgstatus(gp *g) {
 	print("runtime:  g:  g=", _g_, ", goid=", _g_.goid, ",  g->atomicstatus=", readgstatus(_g_), "\n")
 }
 
+// defaultgomaxprocs returns the GOMAXPROCS setting to use when none
+// is given explicitly: the number of CPUs, lowered to the CPU quota
+// imposed on the process, if any.
+func defaultgomaxprocs() int32 {
+	procs := ncpu
+	if n := getcgroupcpu(); n > 0 && n < procs {
+		procs = n
+	}
+	if procs > _MaxGomaxprocs {
+		procs = _MaxGomaxprocs
+	}
+	return procs
+}
+
 func checkmcount() {
 	// sched lock is held
 	if sched.mcount > sched.maxmcount {
@@ -993,15 +1008,29 @@ func stopTheWorldWithSema() {
 	unlock(&sched.lock)
 
 	// wait for remaining P's to stop voluntarily
+	start := nanotime()
 	if wait {
+		timeout := int64(debug.stwtimeout) * 1000 * 1000
+		reported := false
 		for {
 			// wait for 100us, then try to re-preempt in case of any races
 			if notetsleep(&sched.stopnote, 100*1000) {
 				noteclear(&sched.stopnote)
 				break
 			}
+			if timeout > 0 && !reported && nanotime()-start > timeout {
+				stwReport(nanotime() - start)
+				reported = true
+			}
 			preemptall()
 		}
+		if reported {
+			print("runtime: stopTheWorld took ", (nanotime()-start)/1e6, "ms\n")
+		}
+	}
+	if d := uint64(nanotime() - start); d > atomic.Load64(&sched.stwmax) {
+		// Only one M stops the world at a time.
+		atomic.Store64(&sched.stwmax, d)
 	}
 	if sched.stopwait != 0 {
 		throw("stopTheWorld: not stopped")
@@ -1014,6 +1043,74 @@ func stopTheWorldWithSema() {
 	}
 }
 
+var pStatusStrings = [...]string{
+	_Pidle:    "idle",
+	_Prunning: "running",
+	_Psyscall: "syscall",
+	_Pgcstop:  "gcstop",
+	_Pdead:    "dead",
+}
+
+// stwReport prints the P's that have not stopped after stopTheWorld has
+// waited for them for waited nanoseconds, with the goroutine each one
+// is running. The current PC of a running goroutine is not known, so
+// the report gives the PC at which it was last scheduled, which is the
+// function a goroutine stuck in a loop without calls started at or
+// returned to the scheduler from, and for a goroutine in a system call
+// the PC at which it entered the call.
+func stwReport(waited int64) {
+	lock(&sched.lock)
+	print("runtime: stopTheWorld has waited ", waited/1e6, "ms for ", sched.stopwait, " P's to stop:\n")
+	// As in schedtrace, the P's and M's may change concurrently.
+	for i := int32(0); i < gomaxprocs; i++ {
+		_p_ := allp[i]
+		if _p_ == nil || _p_.status == _Pgcstop {
+			continue
+		}
+		print("  P", i, ": status=")
+		if _p_.status < uint32(len(pStatusStrings)) {
+			print(pStatusStrings[_p_.status])
+		} else {
+			print(_p_.status)
+		}
+		mp := _p_.m.ptr()
+		if mp == nil {
+			print("\n")
+			continue
+		}
+		print(" m=", mp.id)
+		gp := mp.curg
+		if gp == nil {
+			print("\n")
+			continue
+		}
+		status := readgstatus(gp) &^ _Gscan
+		print(" g=", gp.goid, " gstatus=")
+		if status < uint32(len(gStatusStrings)) && gStatusStrings[status] != "" {
+			print(gStatusStrings[status])
+		} else {
+			print(status)
+		}
+		pc := gp.sched.pc
+		if status == _Gsyscall {
+			pc = gp.syscallpc
+			print(" entered syscall at pc=", hex(pc))
+		} else {
+			print(" last scheduled at pc=", hex(pc))
+		}
+		if f := findfunc(pc); f != nil {
+			print(" ", funcname(f))
+		}
+		if mp.ncgo > 0 {
+			print(" in cgo")
+		} else if status == _Gsyscall {
+			print(" in syscall")
+		}
+		print("\n")
+	}
+	unlock(&sched.lock)
+}
+
 func mhelpgc() {
 	_g_ := getg()
 	_g_.m.helpgc = -1
@@ -2223,6 +2320,7 @@ func goexit0(gp *g) {
 	gp.writebuf = nil
 	gp.waitreason = ""
 	gp.param = nil
+	gp.labels = nil
 
 	// Note that gp's stack scan is now "valid" because it has no
 	// stack. We could dequeueRescan, but that takes a lock and
@@ -2782,6 +2880,11 @@ func newproc1(fn *funcval, argp *uint8, narg int32, nret int32, callerpc uintptr
 	_p_.goidcache++
 	if raceenabled {
 		newg.racectx = racegostart(callerpc)
+		racegoancestry(newg, callerpc)
+	}
+	if _g_.m.curg != nil {
+		// The new goroutine inherits its creator's profiler labels.
+		newg.labels = _g_.m.curg.labels
 	}
 	if trace.enabled {
 		traceGoCreate(newg, newg.startpc)
@@ -3542,6 +3645,7 @@ func sysmon() {
 	nscavenge := 0
 
 	lasttrace := int64(0)
+	lastclocksync := int64(0)
 	idle := 0 // how many cycles in succession we had not wokeup somebody
 	delay := uint32(0)
 	for {
@@ -3554,7 +3658,11 @@ func sysmon() {
 			delay = 10 * 1000
 		}
 		usleep(delay)
-		if debug.schedtrace <= 0 && (sched.gcwaiting != 0 || atomic.Load(&sched.npidle) == uint32(gomaxprocs)) { // TODO: fast atomic
+		// Keep running while the goroutine state profile is enabled:
+		// goroutines that are all blocked still need to be sampled.
+		// Likewise, an idle program being traced still needs clock
+		// sync events.
+		if debug.schedtrace <= 0 && atomic.Load64(&gstateprof.period) == 0 && !trace.enabled && (sched.gcwaiting != 0 || atomic.Load(&sched.npidle) == uint32(gomaxprocs)) { // TODO: fast atomic
 			lock(&sched.lock)
 			if atomic.Load(&sched.gcwaiting) != 0 || atomic.Load(&sched.npidle) == uint32(gomaxprocs) {
 				atomic.Store(&sched.sysmonwait, 1)
@@ -3610,6 +3718,8 @@ func sysmon() {
 			injectglist(forcegc.g)
 			unlock(&forcegc.lock)
 		}
+		// sample goroutine states if the profile is enabled
+		gstateprofwake(now)
 		// scavenge heap once in a while
 		if lastscavenge+scavengelimit/2 < now {
 			mheap_.scavenge(int32(nscavenge), uint64(now), uint64(scavengelimit))
@@ -3620,6 +3730,11 @@ func sysmon() {
 			lasttrace = now
 			schedtrace(debug.scheddetail > 0)
 		}
+		// relate execution trace timestamps to the wall clock
+		if trace.enabled && lastclocksync+traceClockSyncPeriod <= now {
+			lastclocksync = now
+			traceClockSync()
+		}
 	}
 }
 
@@ -5,8 +5,10 @@
 package runtime_test
 
 import (
+	"internal/testenv"
 	"math"
 	"net"
+	"os/exec"
 	"runtime"
 	"runtime/debug"
 	"strings"
@@ -52,6 +54,39 @@ func TestStopTheWorldDeadlock(t *testing.T) {
 	runtime.GOMAXPROCS(maxprocs)
 }
 
+func TestStopTheWorldTimeout(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("skipping on non-amd64")
+	}
+	testenv.MustHaveGoBuild(t)
+	exe, err := buildTestProg(t, "testprog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The report is on by default, with a timeout of 10ms.
+	for _, godebug := range []string{"", "stwtimeout=5", "stwtimeout=0"} {
+		cmd := testEnv(exec.Command(exe, "STWTimeout"))
+		cmd.Env = append(cmd.Env, "GODEBUG="+godebug)
+		out, _ := cmd.CombinedOutput()
+		output := string(out)
+		if godebug == "stwtimeout=0" {
+			if output != "OK\n" {
+				t.Errorf("with GODEBUG=%s, output is not OK:\n%s", godebug, output)
+			}
+			continue
+		}
+		// The report names the goroutine looping without calls.
+		for _, want := range []string{"runtime: stopTheWorld has waited ", ": status=running ", " last scheduled at pc=", " main.spin\n", "runtime: stopTheWorld took "} {
+			if !strings.Contains(output, want) {
+				t.Errorf("with GODEBUG=%s, output does not contain %q:\n%s", godebug, want, output)
+			}
+		}
+		if !strings.HasSuffix(output, "OK\n") {
+			t.Errorf("with GODEBUG=%s, output does not end in OK:\n%s", godebug, output)
+		}
+	}
+}
+
 func TestYieldProgress(t *testing.T) {
 	testYieldProgress(t, false)
 }
@@ -352,6 +387,27 @@ func TestGCFairness2(t *testing.T) {
 	}
 }
 
+func TestUpdateCPUQuotaDefault(t *testing.T) {
+	// The testing package sets GOMAXPROCS, so the default
+	// setting is only seen in a separate program.
+	exe, err := buildTestProg(t, "testprog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := testEnv(exec.Command(exe, "UpdateCPUQuota"))
+	env := cmd.Env[:0]
+	for _, e := range cmd.Env {
+		if !strings.HasPrefix(e, "GOMAXPROCS=") {
+			env = append(env, e)
+		}
+	}
+	cmd.Env = env
+	output, _ := cmd.CombinedOutput()
+	if want := "OK\n"; string(output) != want {
+		t.Fatalf("want %q, got %q", want, output)
+	}
+}
+
 func TestNumGoroutine(t *testing.T) {
 	output := runTestProg(t, "testprog", "NumGoroutine")
 	want := "1\n"
@@ -0,0 +1,21 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import "unsafe"
+
+//go:linkname runtime_setProfLabel internal/proflabel.runtime_setProfLabel
+func runtime_setProfLabel(labels unsafe.Pointer) {
+	gp := getg()
+	if trace.enabled {
+		traceGoLabels(gp, gp.labels, labels)
+	}
+	gp.labels = labels
+}
+
+//go:linkname runtime_getProfLabel internal/proflabel.runtime_getProfLabel
+func runtime_getProfLabel() unsafe.Pointer {
+	return getg().labels
+}
@@ -92,6 +92,9 @@ func racecallback(cmd uintptr, ctx unsafe.Pointer) {
 }
 
 func raceSymbolizeCode(ctx *symbolizeCodeContext) {
+	if raceSymbolizeAncestor(ctx) {
+		return
+	}
 	f := findfunc(ctx.pc)
 	if f == nil {
 		ctx.fn = &qq[0]
@@ -156,6 +159,9 @@ var __tsan_go_start byte
 //go:linkname __tsan_go_end __tsan_go_end
 var __tsan_go_end byte
 
+//go:linkname __tsan_func_enter __tsan_func_enter
+var __tsan_func_enter byte
+
 //go:linkname __tsan_malloc __tsan_malloc
 var __tsan_malloc byte
 
@@ -467,3 +473,81 @@ func RaceEnable() {
 		racecall(&__tsan_go_ignore_sync_end, _g_.racectx, 0, 0, 0)
 	}
 }
+
+// ThreadSanitizer has no bitwise atomic operations, so the sync/atomic
+// And, Or and Xor functions are compare-and-swap loops over the
+// instrumented sync/atomic loads and compare-and-swaps. The race
+// detector sees those operations, as it would for a loop written by
+// the caller.
+
+//go:linkname sync_atomic_LoadUint32 sync/atomic.LoadUint32
+func sync_atomic_LoadUint32(addr *uint32) uint32
+
+//go:linkname sync_atomic_LoadUint64 sync/atomic.LoadUint64
+func sync_atomic_LoadUint64(addr *uint64) uint64
+
+//go:linkname sync_atomic_CompareAndSwapUint32 sync/atomic.CompareAndSwapUint32
+func sync_atomic_CompareAndSwapUint32(addr *uint32, old, new uint32) bool
+
+//go:linkname sync_atomic_CompareAndSwapUint64 sync/atomic.CompareAndSwapUint64
+func sync_atomic_CompareAndSwapUint64(addr *uint64, old, new uint64) bool
+
+//go:linkname sync_atomic_AndUint32 sync/atomic.AndUint32
+func sync_atomic_AndUint32(addr *uint32, mask uint32) (old uint32) {
+	for {
+		old = sync_atomic_LoadUint32(addr)
+		if sync_atomic_CompareAndSwapUint32(addr, old, old&mask) {
+			return
+		}
+	}
+}
+
+//go:linkname sync_atomic_OrUint32 sync/atomic.OrUint32
+func sync_atomic_OrUint32(addr *uint32, mask uint32) (old uint32) {
+	for {
+		old = sync_atomic_LoadUint32(addr)
+		if sync_atomic_CompareAndSwapUint32(addr, old, old|mask) {
+			return
+		}
+	}
+}
+
+//go:linkname sync_atomic_XorUint32 sync/atomic.XorUint32
+func sync_atomic_XorUint32(addr *uint32, mask uint32) (old uint32) {
+	for {
+		old = sync_atomic_LoadUint32(addr)
+		if sync_atomic_CompareAndSwapUint32(addr, old, old^mask) {
+			return
+		}
+	}
+}
+
+//go:linkname sync_atomic_AndUint64 sync/atomic.AndUint64
+func sync_atomic_AndUint64(addr *uint64, mask uint64) (old uint64) {
+	for {
+		old = sync_atomic_LoadUint64(addr)
+		if sync_atomic_CompareAndSwapUint64(addr, old, old&mask) {
+			return
+		}
+	}
+}
+
+//go:linkname sync_atomic_OrUint64 sync/atomic.OrUint64
+func sync_atomic_OrUint64(addr *uint64, mask uint64) (old uint64) {
+	for {
+		old = sync_atomic_LoadUint64(addr)
+		if sync_atomic_CompareAndSwapUint64(addr, old, old|mask) {
+			return
+		}
+	}
+}
+
+//go:linkname sync_atomic_XorUint64 sync/atomic.XorUint64
+func sync_atomic_XorUint64(addr *uint64, mask uint64) (old uint64) {
+	for {
+		old = sync_atomic_LoadUint64(addr)
+		if sync_atomic_CompareAndSwapUint64(addr, old, old^mask) {
+			return
+		}
+	}
+}
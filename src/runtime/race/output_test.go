@@ -197,4 +197,76 @@ func main() {
       .*/runtime/string\.go:.*
   main\.main\.func1\(\)
       .*/main.go:7`},
+
+	{"ancestry_depth", "run", "atexit_sleep_ms=0 ancestry_depth=3", `
+package main
+import "time"
+var x int
+func worker(done chan bool) {
+	x = 1
+	done <- true
+}
+func spawn(done chan bool) {
+	go worker(done)
+}
+func level2(done chan bool) {
+	spawn(done)
+}
+func level1(done chan bool) {
+	go level2(done)
+}
+func main() {
+	done := make(chan bool)
+	go level1(done)
+	time.Sleep(10 * time.Millisecond)
+	x = 2
+	<-done
+}
+`, `
+Goroutine [0-9]+ \(running\) created at:
+  main\.spawn\(\)
+      .+/main\.go:10 \+0x[0-9,a-f]+
+  main\.level2\(\)
+      .+/main\.go:13 \+0x[0-9,a-f]+
+  runtime\.goexit\(\)
+      .+
+  \[goroutine [0-9]+\] main\.level1\(\)
+      .+/main\.go:16 \+0x[0-9,a-f]+
+  \[goroutine 1\] main\.main\(\)
+      .+/main\.go:20 \+0x[0-9,a-f]+
+==================
+`},
+
+	{"ancestry_depth_default", "run", "atexit_sleep_ms=0", `
+package main
+import "time"
+var x int
+func worker(done chan bool) {
+	x = 1
+	done <- true
+}
+func spawn(done chan bool) {
+	go worker(done)
+}
+func level2(done chan bool) {
+	spawn(done)
+}
+func level1(done chan bool) {
+	go level2(done)
+}
+func main() {
+	done := make(chan bool)
+	go level1(done)
+	time.Sleep(10 * time.Millisecond)
+	x = 2
+	<-done
+}
+`, `
+Goroutine [0-9]+ \(running\) created at:
+  main\.spawn\(\)
+      .+/main\.go:10 \+0x[0-9,a-f]+
+  main\.level2\(\)
+      .+/main\.go:13 \+0x[0-9,a-f]+
+==================
+`},
 }
@@ -38,3 +38,5 @@ func racemalloc(p unsafe.Pointer, sz uintptr)                               { th
 func racefree(p unsafe.Pointer, sz uintptr)                                 { throw("race") }
 func racegostart(pc uintptr) uintptr                                        { throw("race"); return 0 }
 func racegoend()                                                            { throw("race") }
+func raceancestryinit()                                                     { throw("race") }
+func racegoancestry(newg *g, pc uintptr)                                    { throw("race") }
@@ -199,6 +199,7 @@ TEXT	runtime·racefuncexit(SB), NOSPLIT, $0-0
 	JMP	racecall<>(SB)
 
 // Atomic operations for sync/atomic package.
+// Code generated by go generate in sync/atomic (mkrace.go); DO NOT EDIT.
 
 // Load
 TEXT	sync∕atomic·LoadInt32(SB), NOSPLIT, $0-0
@@ -240,7 +241,7 @@ TEXT	sync∕atomic·StoreUint32(SB), NOSPLIT, $0-0
 TEXT	sync∕atomic·StoreUint64(SB), NOSPLIT, $0-0
 	JMP	sync∕atomic·StoreInt64(SB)
 
-TEXT	sync∕atomic·StoreUintptr(SB), NOSPLIT, $0-0
+TEXT	sync∕atomic·storeUintptr(SB), NOSPLIT, $0-0
 	JMP	sync∕atomic·StoreInt64(SB)
 
 // Swap
@@ -260,7 +261,7 @@ TEXT	sync∕atomic·SwapUint32(SB), NOSPLIT, $0-0
 TEXT	sync∕atomic·SwapUint64(SB), NOSPLIT, $0-0
 	JMP	sync∕atomic·SwapInt64(SB)
 
-TEXT	sync∕atomic·SwapUintptr(SB), NOSPLIT, $0-0
+TEXT	sync∕atomic·swapUintptr(SB), NOSPLIT, $0-0
 	JMP	sync∕atomic·SwapInt64(SB)
 
 // Add
@@ -304,7 +305,7 @@ TEXT	sync∕atomic·CompareAndSwapUint32(SB), NOSPLIT, $0-0
 TEXT	sync∕atomic·CompareAndSwapUint64(SB), NOSPLIT, $0-0
 	JMP	sync∕atomic·CompareAndSwapInt64(SB)
 
-TEXT	sync∕atomic·CompareAndSwapUintptr(SB), NOSPLIT, $0-0
+TEXT	sync∕atomic·compareAndSwapUintptr(SB), NOSPLIT, $0-0
 	JMP	sync∕atomic·CompareAndSwapInt64(SB)
 
 // Generic atomic operation implementation.
@@ -0,0 +1,190 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build race
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+// Goroutine ancestry in race reports.
+//
+// A race report shows where each goroutine involved was created, which
+// in a worker pool is the pool's spawning function. With
+// GORACE=ancestry_depth=N, the creation stack of a goroutine continues
+// with where its creator was created, and so on, up to N levels in all.
+//
+// newproc1 records the creation of each goroutine in a fixed-size ring
+// of records linked to the record of its creator, and pushes the
+// creation sites of the new goroutine and of its ancestors on the
+// bottom of its shadow stack in the race runtime, oldest first. They
+// then appear below the goroutine's own frames in every stack the race
+// runtime collects for it, including the creation stacks of the
+// goroutines it creates. The frames have fake PCs that encode the
+// sequence number of their record, and raceSymbolizeCode prints them
+// as the go statement, prefixed with the id of the goroutine that
+// executed it.
+//
+// The ring holds the records of the most recently created goroutines,
+// so the memory used does not depend on the number of goroutines. The
+// ancestry of a goroutine is cut where a record has been overwritten.
+
+const (
+	raceAncestryRecords  = 1 << 14 // size of the ring, a power of 2
+	raceMaxAncestryDepth = 16
+
+	// Fake PCs are raceAncestryPC + seq<<4 + 8. They are below 1<<61,
+	// as the race runtime requires, and above any code. The race
+	// runtime may subtract 1 from a PC before symbolizing it.
+	raceAncestryPC = 1 << 60
+)
+
+type raceAncestor struct {
+	seq    uint64  // sequence number, 0 while the record is written
+	parent uint64  // sequence number of the creator's record, or 0
+	goid   int64   // id of the creating goroutine
+	pc     uintptr // pc of the go statement
+}
+
+var raceAncestry struct {
+	depth int    // GORACE ancestry_depth, at most raceMaxAncestryDepth
+	seq   uint64 // last sequence number used
+	ring  *[raceAncestryRecords]raceAncestor
+
+	symLock mutex
+	symBuf  [256]byte // function name returned by raceSymbolizeAncestor
+}
+
+// raceancestryinit parses the ancestry_depth option of GORACE.
+// The race runtime ignores options it does not know.
+func raceancestryinit() {
+	env := gogetenv("GORACE")
+	for env != "" {
+		var opt string
+		if i := index(env, " "); i >= 0 {
+			opt, env = env[:i], env[i+1:]
+		} else {
+			opt, env = env, ""
+		}
+		if hasprefix(opt, "ancestry_depth=") {
+			raceAncestry.depth = atoi(opt[len("ancestry_depth="):])
+		}
+	}
+	if raceAncestry.depth > raceMaxAncestryDepth {
+		raceAncestry.depth = raceMaxAncestryDepth
+	}
+	if raceAncestry.depth > 1 {
+		raceAncestry.ring = (*[raceAncestryRecords]raceAncestor)(persistentalloc(unsafe.Sizeof(*raceAncestry.ring), 8, &memstats.other_sys))
+	}
+}
+
+// racegoancestry records the creation of newg at pc by the current
+// goroutine and pushes the creation sites of newg and its ancestors on
+// newg's race shadow stack.
+func racegoancestry(newg *g, pc uintptr) {
+	if raceAncestry.depth <= 1 {
+		return
+	}
+	spawng := getg().m.curg
+	if spawng == nil {
+		// The main goroutine, created by the bootstrap code.
+		return
+	}
+
+	ring := raceAncestry.ring
+	seq := atomic.Xadd64(&raceAncestry.seq, 1)
+	r := &ring[seq%raceAncestryRecords]
+	atomic.Store64(&r.seq, 0)
+	r.parent = spawng.raceancestor
+	r.goid = spawng.goid
+	r.pc = pc
+	atomic.Store64(&r.seq, seq)
+	newg.raceancestor = seq
+
+	var chain [raceMaxAncestryDepth]uint64
+	n := 0
+	for s := seq; s != 0 && n < raceAncestry.depth-1; n++ {
+		chain[n] = s
+		r := &ring[s%raceAncestryRecords]
+		parent := r.parent
+		if atomic.Load64(&r.seq) != s {
+			// Overwritten; the frame will print as lost.
+			n++
+			break
+		}
+		s = parent
+	}
+	if n == 0 {
+		return
+	}
+	// The race runtime leaves the bottom frame of a goroutine out of
+	// its reports, so push a frame for the invalid sequence number 0
+	// first.
+	racecall(&__tsan_func_enter, newg.racectx, raceAncestryPC+8, 0, 0)
+	for i := n - 1; i >= 0; i-- {
+		racecall(&__tsan_func_enter, newg.racectx, raceAncestryPC+uintptr(chain[i])<<4+8, 0, 0)
+	}
+}
+
+// raceSymbolizeAncestor symbolizes ctx.pc if it is a fake PC pushed by
+// racegoancestry, and reports whether it is.
+func raceSymbolizeAncestor(ctx *symbolizeCodeContext) bool {
+	if ctx.pc < raceAncestryPC || raceAncestry.ring == nil {
+		return false
+	}
+	seq := uint64(ctx.pc-raceAncestryPC) >> 4
+	r := &raceAncestry.ring[seq%raceAncestryRecords]
+	goid, pc := r.goid, r.pc
+	var f *_func
+	if atomic.Load64(&r.seq) == seq {
+		f = findfunc(pc)
+	}
+	if f == nil {
+		ctx.fn = &lostAncestor[0]
+		ctx.file = &dash[0]
+		ctx.line = 0
+		ctx.off = 0
+		ctx.res = 1
+		return true
+	}
+
+	// As in tracebacks, show the line of the go statement, not of the
+	// instruction after the call to newproc.
+	tracepc := pc
+	if pc > f.entry {
+		tracepc--
+	}
+	file, line := funcline(f, tracepc)
+
+	// The race runtime copies the strings before it calls back again.
+	lock(&raceAncestry.symLock)
+	var tmp [20]byte
+	i := len(tmp)
+	for u := uint64(goid); i == len(tmp) || u > 0; u /= 10 {
+		i--
+		tmp[i] = byte(u%10 + '0')
+	}
+	buf := append(raceAncestry.symBuf[:0], "[goroutine "...)
+	buf = append(buf, tmp[i:]...)
+	buf = append(buf, "] "...)
+	name := funcname(f)
+	if max := cap(buf) - len(buf) - 1; len(name) > max {
+		name = name[:max]
+	}
+	buf = append(buf, name...)
+	buf = append(buf, 0)
+	ctx.fn = &buf[0]
+	unlock(&raceAncestry.symLock)
+
+	ctx.file = &bytes(file)[0] // assume NUL-terminated
+	ctx.line = uintptr(line)
+	ctx.off = pc - f.entry
+	ctx.res = 1
+	return true
+}
+
+var lostAncestor = [...]byte{'[', 'a', 'n', 'c', 'e', 's', 't', 'o', 'r', ' ', 'l', 'o', 's', 't', ']', 0}
@@ -312,9 +312,11 @@ type dbgVar struct {
 // Holds variables parsed from GODEBUG env var,
 // except for "memprofilerate" since there is an
 // existing int var for that value, which may
-// already have an initial value.
+// already have an initial value. "malloczero" takes
+// a mode name and is parsed separately as well.
 var debug struct {
 	allocfreetrace    int32
+	atomicptrcheck    int32
 	cgocheck          int32
 	efence            int32
 	gccheckmark       int32
@@ -325,15 +327,20 @@ var debug struct {
 	gcstoptheworld    int32
 	gctrace           int32
 	invalidptr        int32
+	malloczero        int32
+	mutexstats        int32
+	reflectstacks     int32
 	sbrk              int32
 	scavenge          int32
 	scheddetail       int32
 	schedtrace        int32
+	stwtimeout        int32
 	wbshadow          int32
 }
 
 var dbgvars = []dbgVar{
 	{"allocfreetrace", &debug.allocfreetrace},
+	{"atomicptrcheck", &debug.atomicptrcheck},
 	{"cgocheck", &debug.cgocheck},
 	{"efence", &debug.efence},
 	{"gccheckmark", &debug.gccheckmark},
@@ -344,10 +351,13 @@ var dbgvars = []dbgVar{
 	{"gcstoptheworld", &debug.gcstoptheworld},
 	{"gctrace", &debug.gctrace},
 	{"invalidptr", &debug.invalidptr},
+	{"mutexstats", &debug.mutexstats},
+	{"reflectstacks", &debug.reflectstacks},
 	{"sbrk", &debug.sbrk},
 	{"scavenge", &debug.scavenge},
 	{"scheddetail", &debug.scheddetail},
 	{"schedtrace", &debug.schedtrace},
+	{"stwtimeout", &debug.stwtimeout},
 	{"wbshadow", &debug.wbshadow},
 }
 
@@ -355,6 +365,7 @@ func parsedebugvars() {
 	// defaults
 	debug.cgocheck = 1
 	debug.invalidptr = 1
+	debug.stwtimeout = 10
 
 	for p := gogetenv("GODEBUG"); p != ""; {
 		field := ""
@@ -375,6 +386,15 @@ func parsedebugvars() {
 		// if specified in GODEBUG.
 		if key == "memprofilerate" {
 			MemProfileRate = atoi(value)
+		} else if key == "malloczero" {
+			switch value {
+			case "poison":
+				debug.malloczero = mallocZeroPoison
+			case "verify":
+				debug.malloczero = mallocZeroVerify
+			default:
+				debug.malloczero = 0
+			}
 		} else {
 			for _, v := range dbgvars {
 				if v.name == key {
@@ -487,6 +507,13 @@ func reflect_typelinks() ([]unsafe.Pointer, [][]int32) {
 	return sections, ret
 }
 
+// reflect_constructionStacks reports whether package reflect records the
+// stacks of the types it builds, for reflect.ConstructionStack.
+//go:linkname reflect_constructionStacks reflect.constructionStacks
+func reflect_constructionStacks() bool {
+	return debug.reflectstacks > 0
+}
+
 // reflect_resolveNameOff resolves a name offset from a base pointer.
 //go:linkname reflect_resolveNameOff reflect.resolveNameOff
 func reflect_resolveNameOff(ptrInModule unsafe.Pointer, off int32) unsafe.Pointer {
@@ -509,7 +536,7 @@ func reflect_resolveTextOff(rtype unsafe.Pointer, off int32) unsafe.Pointer {
 // reflect_addReflectOff adds a pointer to the reflection offset lookup map.
 //go:linkname reflect_addReflectOff reflect.addReflectOff
 func reflect_addReflectOff(ptr unsafe.Pointer) int32 {
-	lock(&reflectOffs.lock)
+	reflectOffsLock()
 	if reflectOffs.m == nil {
 		reflectOffs.m = make(map[int32]unsafe.Pointer)
 		reflectOffs.minv = make(map[unsafe.Pointer]int32)
@@ -522,6 +549,6 @@ func reflect_addReflectOff(ptr unsafe.Pointer) int32 {
 		reflectOffs.m[id] = ptr
 		reflectOffs.minv[ptr] = id
 	}
-	unlock(&reflectOffs.lock)
+	reflectOffsUnlock()
 	return id
 }
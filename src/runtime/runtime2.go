@@ -352,8 +352,10 @@ type g struct {
 	gopc           uintptr // pc of go statement that created this goroutine
 	startpc        uintptr // pc of goroutine function
 	racectx        uintptr
-	waiting        *sudog    // sudog structures this g is waiting on (that have a valid elem ptr); in lock order
-	cgoCtxt        []uintptr // cgo traceback context
+	raceancestor   uint64         // sequence number of creation record, see race_ancestry.go
+	waiting        *sudog         // sudog structures this g is waiting on (that have a valid elem ptr); in lock order
+	cgoCtxt        []uintptr      // cgo traceback context
+	labels         unsafe.Pointer // profiler labels set by internal/proflabel
 
 	// Per-G GC state
 
@@ -511,6 +513,7 @@ type schedt struct {
 	// accessed atomically. keep at top to ensure alignment on 32-bit systems.
 	goidgen  uint64
 	lastpoll uint64
+	stwmax   uint64 // longest time taken to stop the world, in nanoseconds
 
 	lock mutex
 
@@ -526,6 +529,11 @@ type schedt struct {
 	npidle     uint32
 	nmspinning uint32 // See "Worker thread parking/unparking" comment in proc.go.
 
+	// customgomaxprocs is set if GOMAXPROCS was chosen by the user,
+	// through the environment or a call to GOMAXPROCS, rather than
+	// derived from the CPU count and quota. Protected by lock.
+	customgomaxprocs bool
+
 	// Global runnable queue.
 	runqhead guintptr
 	runqtail guintptr
@@ -95,6 +95,10 @@ func semacquire(addr *uint32, profile bool) {
 		t0 = cputicks()
 		s.releasetime = -1
 	}
+	waitstart := int64(0)
+	if profile && debug.mutexstats > 0 {
+		waitstart = nanotime()
+	}
 	for {
 		lock(&root.lock)
 		// Add ourselves to nwait to disable "easy case" in semrelease.
@@ -116,6 +120,9 @@ func semacquire(addr *uint32, profile bool) {
 	if s.releasetime > 0 {
 		blockevent(s.releasetime-t0, 3)
 	}
+	if waitstart != 0 {
+		semaRecordWait(nanotime() - waitstart)
+	}
 	releaseSudog(s)
 }
 
@@ -148,6 +155,9 @@ func semrelease(addr *uint32) {
 	}
 	unlock(&root.lock)
 	if s != nil {
+		if debug.mutexstats > 0 {
+			atomic.Xadd64(&semastats.handoffs, 1)
+		}
 		readyWithTime(s, 5)
 	}
 }
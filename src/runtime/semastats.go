@@ -0,0 +1,63 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Contention statistics of the semaphores of package sync, for
+// runtime/debug.ReadMutexStats.
+//
+// With GODEBUG=mutexstats=1, semacquire times each acquisition that
+// has to wait, that is, that does not succeed on its first try, and
+// semrelease counts the waiters it wakes. The easy cases of both are
+// unchanged, and with mutexstats unset the hard cases only test a flag.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+const (
+	// semaStarvingNs is the wait after which an acquisition counts as
+	// starved: the waiter lost the semaphore, again and again, to
+	// goroutines that did not have to wait.
+	semaStarvingNs = 1e6
+
+	// Bucket 0 of the wait histogram holds waits shorter than 1µs,
+	// bucket i, for 0 < i < semaWaitBuckets-1, waits of at least
+	// 4^(i-1)µs and less than 4^iµs, and the last bucket the longer
+	// waits, of about a second or more.
+	semaWaitBuckets = 12
+)
+
+var semastats struct {
+	starved  uint64
+	handoffs uint64
+	waits    [semaWaitBuckets]uint64
+}
+
+// semaRecordWait records an acquisition that waited for ns nanoseconds.
+func semaRecordWait(ns int64) {
+	if ns > semaStarvingNs {
+		atomic.Xadd64(&semastats.starved, 1)
+	}
+	b := 0
+	for bound := int64(1000); b < semaWaitBuckets-1 && ns >= bound; bound *= 4 {
+		b++
+	}
+	atomic.Xadd64(&semastats.waits[b], 1)
+}
+
+// readMutexStats returns the number of starved acquisitions, the
+// number of handoffs to waiters and the wait histogram, in that order.
+//
+//go:linkname readMutexStats runtime/debug.readMutexStats
+func readMutexStats() []uint64 {
+	p := make([]uint64, 2+semaWaitBuckets)
+	p[0] = atomic.Load64(&semastats.starved)
+	p[1] = atomic.Load64(&semastats.handoffs)
+	for i := range semastats.waits {
+		p[2+i] = atomic.Load64(&semastats.waits[i])
+	}
+	return p
+}
@@ -1127,6 +1127,57 @@ func shrinkstack(gp *g) {
 	copystack(gp, newsize, false)
 }
 
+// ShrinkStackHint shrinks the stack of the calling goroutine to fit the
+// stack it is using now, with the usual room to grow. Goroutine stacks
+// grow as needed but shrink only by half at each garbage collection, so
+// a long-lived goroutine that once used a deep stack, such as a worker
+// that served one deeply recursive request, can hold on to it for a
+// long time. Calling ShrinkStackHint where the goroutine knows that its
+// stack is shallow, such as at the top of its service loop, returns the
+// unused stack right away.
+//
+// ShrinkStackHint is cheap when the stack is already minimal, and it
+// does nothing with GODEBUG=gcshrinkstackoff=1.
+func ShrinkStackHint() {
+	if getg().stackAlloc <= _FixedStack {
+		return
+	}
+	// Nothing must run on this stack between the copy and the return:
+	// on amd64 the frame pointer register still points to the old stack
+	// until the caller's frame pointer is restored from the new one.
+	systemstack(shrinkStackHint_m)
+}
+
+// shrinkStackHint_m shrinks the stack of the goroutine that called
+// ShrinkStackHint by halving it, as shrinkstack does, for as long as the
+// goroutine uses less than a quarter of it. The goroutine is running
+// and calls this through systemstack, so it is not in a system call and
+// its stack is not being scanned, as for newstack.
+func shrinkStackHint_m() {
+	gp := getg().m.curg
+	if debug.gcshrinkstackoff > 0 {
+		return
+	}
+	if sys.GoosWindows != 0 && gp.m.libcallsp != 0 {
+		return
+	}
+	oldsize := gp.stackAlloc
+	newsize := oldsize
+	used := gp.stack.hi - gp.sched.sp + _StackLimit
+	for newsize/2 >= _FixedStack && used < newsize/4 {
+		newsize /= 2
+	}
+	if newsize == oldsize {
+		return
+	}
+	if stackDebug > 0 {
+		print("shrinking stack on hint ", oldsize, "->", newsize, "\n")
+	}
+	casgstatus(gp, _Grunning, _Gcopystack)
+	copystack(gp, newsize, true)
+	casgstatus(gp, _Gcopystack, _Grunning)
+}
+
 // freeStackSpans frees unused stack spans at the end of GC.
 func freeStackSpans() {
 	lock(&stackpoolmu)
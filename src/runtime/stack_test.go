@@ -447,3 +447,33 @@ func count(n int) int {
 	}
 	return 1 + count(n-1)
 }
+
+func TestShrinkStackHint(t *testing.T) {
+	type sizes struct{ grown, hinted, again uintptr }
+	c := make(chan sizes)
+	go func() {
+		var s sizes
+		x := 42
+		p := &x
+		useStack(64)
+		s.grown = StackAlloc()
+		ShrinkStackHint()
+		s.hinted = StackAlloc()
+		ShrinkStackHint()
+		s.again = StackAlloc()
+		if *p != 42 {
+			panic("stack is corrupted")
+		}
+		c <- s
+	}()
+	s := <-c
+	if s.grown < 64<<10 {
+		t.Fatalf("stack grew to %d bytes, want at least 64KB", s.grown)
+	}
+	if s.hinted >= s.grown/4 {
+		t.Errorf("stack shrunk from %d to %d bytes, want less than a quarter", s.grown, s.hinted)
+	}
+	if s.again != s.hinted {
+		t.Errorf("second hint changed the stack size from %d to %d bytes", s.hinted, s.again)
+	}
+}
@@ -20,6 +20,7 @@ func init() {
 	register("LockedDeadlock2", LockedDeadlock2)
 	register("GoexitDeadlock", GoexitDeadlock)
 	register("StackOverflow", StackOverflow)
+	register("StackOverflowLargeFrame", StackOverflowLargeFrame)
 	register("ThreadExhaustion", ThreadExhaustion)
 	register("RecursivePanic", RecursivePanic)
 	register("GoexitExit", GoexitExit)
@@ -79,6 +80,17 @@ func StackOverflow() {
 	f()
 }
 
+func StackOverflowLargeFrame() {
+	var f func(int) byte
+	f = func(i int) byte {
+		var buf [4 << 20]byte
+		buf[i] = byte(i)
+		return buf[i] + f(i+1)
+	}
+	debug.SetMaxStack(16 << 20)
+	f(0)
+}
+
 func ThreadExhaustion() {
 	debug.SetMaxThreads(10)
 	c := make(chan int)
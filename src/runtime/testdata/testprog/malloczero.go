@@ -0,0 +1,38 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"unsafe"
+)
+
+func init() {
+	register("MallocZeroVerify", MallocZeroVerify)
+}
+
+var malloczeroSink [3][]byte
+
+// MallocZeroVerify overruns an allocation into the next free slot
+// of its span and then allocates that slot, which must be reported
+// when run with GODEBUG=malloczero=verify.
+func MallocZeroVerify() {
+	debug.SetGCPercent(-1)
+
+	// Nothing else allocates objects of this size, so they come
+	// from a fresh span that the allocator considers zeroed and
+	// whose slots it hands out in address order.
+	const size = 9000
+	a := make([]byte, size)
+	b := make([]byte, size)
+	malloczeroSink[0], malloczeroSink[1] = a, b
+	stride := uintptr(unsafe.Pointer(&b[0])) - uintptr(unsafe.Pointer(&a[0]))
+	*(*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(&b[0])) + stride + 1)) = 1
+
+	c := make([]byte, size)
+	malloczeroSink[2] = c
+	fmt.Println("OK", c[1])
+}
@@ -8,8 +8,26 @@ import "runtime"
 
 func init() {
 	register("NumGoroutine", NumGoroutine)
+	register("UpdateCPUQuota", UpdateCPUQuota)
 }
 
 func NumGoroutine() {
 	println(runtime.NumGoroutine())
 }
+
+// UpdateCPUQuota must be run without the GOMAXPROCS environment variable.
+func UpdateCPUQuota() {
+	// The default has not changed since the program started.
+	want := runtime.GOMAXPROCS(0)
+	if n := runtime.UpdateCPUQuota(); n != want {
+		println("UpdateCPUQuota =", n, "want", want)
+		return
+	}
+	// Once set by the program, GOMAXPROCS is left alone.
+	runtime.GOMAXPROCS(want + 1)
+	if n := runtime.UpdateCPUQuota(); n != want+1 {
+		println("UpdateCPUQuota after GOMAXPROCS =", n, "want", want+1)
+		return
+	}
+	println("OK")
+}
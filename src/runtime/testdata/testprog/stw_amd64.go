@@ -0,0 +1,39 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	register("STWTimeout", STWTimeout)
+}
+
+// spin sets *started to 1 and loops n times without making calls,
+// so it cannot be preempted. Implemented in stw_amd64.s.
+func spin(started *uint32, n uint64)
+
+func STWTimeout() {
+	runtime.GOMAXPROCS(2)
+	var started uint32
+	go spin(&started, 1<<30)
+	for atomic.LoadUint32(&started) == 0 {
+		runtime.Gosched()
+	}
+	runtime.GC()
+
+	var stats debug.GCStats
+	debug.ReadGCStats(&stats)
+	if stats.StopTheWorldMax < 5*time.Millisecond {
+		fmt.Printf("StopTheWorldMax = %v, want at least 5ms\n", stats.StopTheWorldMax)
+		return
+	}
+	fmt.Println("OK")
+}
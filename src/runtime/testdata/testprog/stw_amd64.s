@@ -0,0 +1,15 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+#include "textflag.h"
+
+// func spin(started *uint32, n uint64)
+TEXT ·spin(SB),NOSPLIT,$0-16
+	MOVQ	started+0(FP), AX
+	MOVL	$1, (AX)
+	MOVQ	n+8(FP), CX
+loop:
+	DECQ	CX
+	JNZ	loop
+	RET
@@ -60,7 +60,10 @@ const (
 	traceEvGoStartLocal   = 38 // goroutine starts running on the same P as the last event [timestamp, goroutine id]
 	traceEvGoUnblockLocal = 39 // goroutine is unblocked on the same P as the last event [timestamp, goroutine id, stack]
 	traceEvGoSysExitLocal = 40 // syscall exit on the same P as the last event [timestamp, goroutine id, real timestamp]
-	traceEvCount          = 41
+	traceEvGoLabel        = 41 // goroutine profiler label changes [timestamp, goroutine id, key string ID, value string ID]
+	traceEvClockSync      = 42 // wall and monotonic clock readings [timestamp, wall time, monotonic time]
+	traceEvGCPacer        = 43 // GC pacer state [timestamp, phase, heap trigger, heap goal, heap live, assist ratio bits]
+	traceEvCount          = 44
 )
 
 const (
@@ -91,6 +94,15 @@ const (
 	// Such wakeups happen on buffered channels and sync.Mutex,
 	// but are generally not interesting for end user.
 	traceFutileWakeup byte = 128
+	// Period of traceEvClockSync events, in nanoseconds.
+	traceClockSyncPeriod = 1e9
+)
+
+// Phases of traceEvGCPacer events.
+const (
+	traceGCPacerStart  = 0 // a concurrent GC cycle starts
+	traceGCPacerRevise = 1 // the assist ratio changed during the cycle
+	traceGCPacerEnd    = 2 // the concurrent mark phase ends
 )
 
 // trace is global tracing context.
@@ -116,10 +128,11 @@ var trace struct {
 	stackTab      traceStackTable // maps stack traces to unique ids
 
 	// Dictionary for traceEvString.
-	// Currently this is used only for func/file:line info after tracing session,
-	// so we assume single-threaded access.
-	strings   map[string]uint64
-	stringSeq uint64
+	// This is used for func/file:line info after tracing session
+	// and for goroutine profiler labels during it.
+	stringsLock mutex // protects strings and stringSeq
+	strings     map[string]uint64
+	stringSeq   uint64
 
 	bufLock mutex       // protects buf
 	buf     traceBufPtr // global trace buffer, used when running without a p
@@ -184,10 +197,15 @@ func StartTrace() error {
 	// trace.enabled is set afterwards once we have emitted all preliminary events.
 	_g_ := getg()
 	_g_.m.startingtrace = true
+	trace.strings = make(map[string]uint64)
+	trace.stringSeq = 0
 	for _, gp := range allgs {
 		status := readgstatus(gp)
 		if status != _Gdead {
 			traceGoCreate(gp, gp.startpc) // also resets gp.traceseq/tracelastp
+			// The goroutines are created by no goroutine here,
+			// so they start out with no labels.
+			traceGoLabels(gp, nil, gp.labels)
 		}
 		if status == _Gwaiting {
 			// traceEvGoWaiting is implied to have seq=1.
@@ -211,11 +229,10 @@ func StartTrace() error {
 	trace.timeStart = nanotime()
 	trace.headerWritten = false
 	trace.footerWritten = false
-	trace.strings = make(map[string]uint64)
-	trace.stringSeq = 0
 	trace.seqGC = 0
 	_g_.m.startingtrace = false
 	trace.enabled = true
+	traceClockSync()
 
 	unlock(&trace.bufLock)
 
@@ -240,6 +257,7 @@ func StopTrace() {
 	}
 
 	traceGoSched()
+	traceClockSync()
 
 	for _, p := range &allp {
 		if p == nil {
@@ -344,7 +362,7 @@ func ReadTrace() []byte {
 		trace.headerWritten = true
 		trace.lockOwner = nil
 		unlock(&trace.lock)
-		return []byte("go 1.7 trace\x00\x00\x00\x00")
+		return []byte("go 1.8 trace\x00\x00\x00\x00")
 	}
 	// Wait for new data.
 	if trace.fullHead == 0 && !trace.shutdown {
@@ -474,7 +492,7 @@ func traceEvent(ev byte, skip int, args ...uint64) {
 		return
 	}
 	buf := (*bufp).ptr()
-	const maxSize = 2 + 5*traceBytesPerNumber // event type, length, sequence, timestamp, stack id and two add params
+	const maxSize = 2 + 6*traceBytesPerNumber // event type, length and, for the largest event, timestamp and five params
 	if buf == nil || len(buf.arr)-buf.pos < maxSize {
 		buf = traceFlush(traceBufPtrOf(buf)).ptr()
 		(*bufp).set(buf)
@@ -598,13 +616,16 @@ func traceString(buf *traceBuf, s string) (uint64, *traceBuf) {
 	if s == "" {
 		return 0, buf
 	}
+	lock(&trace.stringsLock)
 	if id, ok := trace.strings[s]; ok {
+		unlock(&trace.stringsLock)
 		return id, buf
 	}
 
 	trace.stringSeq++
 	id := trace.stringSeq
 	trace.strings[s] = id
+	unlock(&trace.stringsLock)
 
 	size := 1 + 2*traceBytesPerNumber + len(s)
 	if len(buf.arr)-buf.pos < size {
@@ -991,3 +1012,86 @@ func traceHeapAlloc() {
 func traceNextGC() {
 	traceEvent(traceEvNextGC, -1, memstats.next_gc)
 }
+
+// traceGCPacer emits the state of the GC pacer for the current cycle:
+// the heap size that triggered it, the heap goal, the live heap and the
+// assist ratio, in scan work per allocated byte.
+func traceGCPacer(phase uint64) {
+	c := &gcController
+	c.tracedAssistWorkPerByte = c.assistWorkPerByte
+	traceEvent(traceEvGCPacer, -1, phase, memstats.next_gc, c.heapGoal, memstats.heap_live, float64bits(c.assistWorkPerByte))
+}
+
+// traceGoLabels emits the changes from the profiler label set old of
+// goroutine gp to the label set new. A label that is in old but not in
+// new is emitted with an empty value.
+// A label set is a sorted list of key, value pairs (see internal/proflabel).
+func traceGoLabels(gp *g, old, new unsafe.Pointer) {
+	var okv, nkv []string
+	if old != nil {
+		okv = *(*[]string)(old)
+	}
+	if new != nil {
+		nkv = *(*[]string)(new)
+	}
+	for len(okv) > 0 || len(nkv) > 0 {
+		switch {
+		case len(nkv) == 0 || len(okv) > 0 && okv[0] < nkv[0]:
+			traceGoLabel(gp, okv[0], "")
+			okv = okv[2:]
+		case len(okv) == 0 || nkv[0] < okv[0]:
+			traceGoLabel(gp, nkv[0], nkv[1])
+			nkv = nkv[2:]
+		default:
+			if okv[1] != nkv[1] {
+				traceGoLabel(gp, nkv[0], nkv[1])
+			}
+			okv, nkv = okv[2:], nkv[2:]
+		}
+	}
+}
+
+// traceGoLabel emits a label of goroutine gp, adding its key and value
+// to the string dictionary.
+func traceGoLabel(gp *g, key, value string) {
+	mp, pid, bufp := traceAcquireBuffer()
+	if !trace.enabled && !mp.startingtrace {
+		traceReleaseBuffer(pid)
+		return
+	}
+	buf := (*bufp).ptr()
+	if buf == nil {
+		buf = traceFlush(0).ptr()
+	}
+	var keyID, valueID uint64
+	keyID, buf = traceString(buf, key)
+	valueID, buf = traceString(buf, value)
+	(*bufp).set(buf)
+	traceReleaseBuffer(pid)
+	traceEvent(traceEvGoLabel, -1, uint64(gp.goid), keyID, valueID)
+}
+
+// traceClockSync emits a traceEvClockSync event, which relates the trace
+// timestamps to the wall clock and the monotonic clock as read by Now.
+// It is emitted when tracing starts and stops, and by sysmon every
+// traceClockSyncPeriod nanoseconds in between.
+func traceClockSync() {
+	wall, mono := Now()
+	traceEvent(traceEvClockSync, -1, uint64(wall), uint64(mono))
+}
+
+// Now returns the current wall clock time, in nanoseconds since
+// January 1, 1970 UTC, and the current reading of the runtime's
+// monotonic clock, in nanoseconds since an arbitrary point in the past.
+// The two clocks are read back to back, so they describe the same
+// instant to within the cost of a clock read. Unlike the wall clock,
+// the monotonic clock does not jump when the system time is set.
+//
+// Execution traces contain clock sync events that carry the same pair of
+// readings every second. Programs can log the pair returned by Now to
+// correlate their logs, or other timestamps, with the trace.
+func Now() (wall, mono int64) {
+	wall = unixnanotime()
+	mono = nanotime()
+	return
+}
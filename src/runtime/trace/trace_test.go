@@ -8,10 +8,12 @@ import (
 	"bytes"
 	"internal/trace"
 	"io"
+	"math"
 	"net"
 	"os"
 	"runtime"
 	. "runtime/trace"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -464,3 +466,123 @@ func TestTraceFutileWakeup(t *testing.T) {
 		}
 	}
 }
+
+// TestTraceClockSync checks that the clock sync events of a trace
+// allow reconstructing the wall time of events, by comparing the wall
+// time of goroutine creations with that logged by the program.
+func TestTraceClockSync(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := Start(buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	// Run long enough to see a periodic clock sync event besides
+	// those at start and stop.
+	iters := 25
+	if testing.Short() {
+		iters = 5
+	}
+	type interval struct{ wall0, mono0, wall1 int64 }
+	var log []interval
+	var done sync.WaitGroup
+	for i := 0; i < iters; i++ {
+		var iv interval
+		iv.wall0, iv.mono0 = runtime.Now()
+		done.Add(1)
+		go done.Done()
+		iv.wall1, _ = runtime.Now()
+		log = append(log, iv)
+		time.Sleep(50 * time.Millisecond)
+	}
+	done.Wait()
+	Stop()
+
+	events, _ := parseTrace(t, buf)
+	clock := trace.NewWallClock(events)
+	if clock == nil {
+		t.Fatalf("trace has no clock sync events")
+	}
+	nsync := 0
+	for _, ev := range events {
+		if ev.Type == trace.EvClockSync {
+			nsync++
+		}
+	}
+	want := 2 // at start and stop
+	if !testing.Short() {
+		want++ // and at least one periodic
+	}
+	if nsync < want {
+		t.Errorf("trace has %d clock sync events, want at least %d", nsync, want)
+	}
+
+	// The error comes from the delays between reading the clocks and
+	// writing the events, which are tiny unless a thread is descheduled.
+	const bound = int64(10 * time.Millisecond)
+	i := 0
+	for _, ev := range events {
+		if ev.Type != trace.EvGoCreate || len(ev.Stk) == 0 || !strings.HasSuffix(ev.Stk[0].Fn, ".TestTraceClockSync") {
+			continue
+		}
+		if i >= len(log) {
+			t.Fatalf("found more than %d goroutine creations", len(log))
+		}
+		iv := log[i]
+		i++
+		if wall := clock.Wall(ev.Ts).UnixNano(); wall < iv.wall0-bound || wall > iv.wall1+bound {
+			t.Errorf("goroutine %d created at wall time %d in trace, logged between %d and %d", i, wall, iv.wall0, iv.wall1)
+		}
+		if ts := clock.MonoTs(iv.mono0); ts > ev.Ts+bound {
+			t.Errorf("goroutine %d created at %d in trace, logged monotonic time maps to %d", i, ev.Ts, ts)
+		}
+		if ts := clock.Ts(time.Unix(0, iv.wall1)); ts < ev.Ts-bound {
+			t.Errorf("goroutine %d created at %d in trace, logged wall time maps to %d", i, ev.Ts, ts)
+		}
+	}
+	if i != len(log) {
+		t.Errorf("found %d goroutine creations, want %d", i, len(log))
+	}
+}
+
+// TestTraceGCPacer checks that concurrent GC cycles triggered by
+// allocation emit pacer events with a consistent trigger and goal.
+func TestTraceGCPacer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := Start(buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	// runtime.GC does not run the pacer, so allocate until a few
+	// cycles have been triggered by heap growth, keeping some of the
+	// allocations live.
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	numGC := ms.NumGC
+	live := make([][]byte, 1000)
+	for i := 0; i < 1e6; i++ {
+		b := make([]byte, 1024)
+		live[i%len(live)] = b
+		if i%1000 == 0 {
+			runtime.ReadMemStats(&ms)
+			if ms.NumGC >= numGC+3 {
+				break
+			}
+		}
+	}
+	Stop()
+
+	events, _ := parseTrace(t, buf)
+	phases := make(map[uint64]int)
+	for _, ev := range events {
+		if ev.Type != trace.EvGCPacer {
+			continue
+		}
+		phase, trigger, goal, heapLive := ev.Args[0], ev.Args[1], ev.Args[2], ev.Args[3]
+		ratio := math.Float64frombits(ev.Args[4])
+		phases[phase]++
+		if phase > trace.GCPacerEnd || trigger == 0 || goal <= trigger || heapLive == 0 || !(ratio > 0) || math.IsInf(ratio, 0) {
+			t.Errorf("bad pacer event: phase %d, trigger %d, goal %d, live %d, ratio %v", phase, trigger, goal, heapLive, ratio)
+		}
+	}
+	if phases[trace.GCPacerStart] == 0 || phases[trace.GCPacerEnd] == 0 {
+		t.Errorf("got %d pacer start and %d end events, want some of each", phases[trace.GCPacerStart], phases[trace.GCPacerEnd])
+	}
+}
@@ -47,6 +47,7 @@ var (
 	runfinqPC            uintptr
 	bgsweepPC            uintptr
 	forcegchelperPC      uintptr
+	gstateprofhelperPC   uintptr
 	timerprocPC          uintptr
 	gcBgMarkWorkerPC     uintptr
 	systemstack_switchPC uintptr
@@ -74,6 +75,7 @@ func tracebackinit() {
 	runfinqPC = funcPC(runfinq)
 	bgsweepPC = funcPC(bgsweep)
 	forcegchelperPC = funcPC(forcegchelper)
+	gstateprofhelperPC = funcPC(gstateprofhelper)
 	timerprocPC = funcPC(timerproc)
 	gcBgMarkWorkerPC = funcPC(gcBgMarkWorker)
 	systemstack_switchPC = funcPC(systemstack_switch)
@@ -806,6 +808,7 @@ func isSystemGoroutine(gp *g) bool {
 	return pc == runfinqPC && !fingRunning ||
 		pc == bgsweepPC ||
 		pc == forcegchelperPC ||
+		pc == gstateprofhelperPC ||
 		pc == timerprocPC ||
 		pc == gcBgMarkWorkerPC
 }
@@ -45,6 +45,36 @@ func swapUint64(addr *uint64, new uint64) (old uint64) {
 	return
 }
 
+func andUint64(addr *uint64, mask uint64) (old uint64) {
+	for {
+		old = *addr
+		if CompareAndSwapUint64(addr, old, old&mask) {
+			break
+		}
+	}
+	return
+}
+
+func orUint64(addr *uint64, mask uint64) (old uint64) {
+	for {
+		old = *addr
+		if CompareAndSwapUint64(addr, old, old|mask) {
+			break
+		}
+	}
+	return
+}
+
+func xorUint64(addr *uint64, mask uint64) (old uint64) {
+	for {
+		old = *addr
+		if CompareAndSwapUint64(addr, old, old^mask) {
+			break
+		}
+	}
+	return
+}
+
 // Additional ARM-specific assembly routines.
 // Declaration here to give assembly routines correct stack maps for arguments.
 func armCompareAndSwapUint32(addr *uint32, old, new uint32) (swapped bool)
@@ -52,6 +82,9 @@ func armCompareAndSwapUint64(addr *uint64, old, new uint64) (swapped bool)
 func generalCAS64(addr *uint64, old, new uint64) (swapped bool)
 func armAddUint32(addr *uint32, delta uint32) (new uint32)
 func armAddUint64(addr *uint64, delta uint64) (new uint64)
+func armAndUint32(addr *uint32, mask uint32) (old uint32)
+func armOrUint32(addr *uint32, mask uint32) (old uint32)
+func armXorUint32(addr *uint32, mask uint32) (old uint32)
 func armSwapUint32(addr *uint32, new uint32) (old uint32)
 func armSwapUint64(addr *uint64, new uint64) (old uint64)
 func armLoadUint64(addr *uint64) (val uint64)
@@ -47,7 +47,7 @@ swaploop:
 	MOVL	DX, old_hi+16(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-12
+TEXT ·swapUintptr(SB),NOSPLIT,$0-12
 	JMP	·SwapUint32(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-13
@@ -63,7 +63,7 @@ TEXT ·CompareAndSwapUint32(SB),NOSPLIT,$0-13
 	SETEQ	swapped+12(FP)
 	RET
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-13
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-13
 	JMP	·CompareAndSwapUint32(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-21
@@ -140,6 +140,132 @@ addloop:
 	MOVL	CX, new_hi+16(FP)
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-12
+	MOVL	addr+0(FP), BP
+	MOVL	mask+4(FP), DX
+	MOVL	0(BP), AX
+andloop:
+	// CX = AX (*addr) AND DX (mask)
+	MOVL	AX, CX
+	ANDL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BP)
+	JNZ	andloop
+	MOVL	AX, old+8(FP)
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-12
+	MOVL	addr+0(FP), BP
+	MOVL	mask+4(FP), DX
+	MOVL	0(BP), AX
+orloop:
+	// CX = AX (*addr) OR DX (mask)
+	MOVL	AX, CX
+	ORL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BP)
+	JNZ	orloop
+	MOVL	AX, old+8(FP)
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-12
+	MOVL	addr+0(FP), BP
+	MOVL	mask+4(FP), DX
+	MOVL	0(BP), AX
+xorloop:
+	// CX = AX (*addr) XOR DX (mask)
+	MOVL	AX, CX
+	XORL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BP)
+	JNZ	xorloop
+	MOVL	AX, old+8(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-20
+	// no 64-bit AND so use CMPXCHG8B loop
+	MOVL	addr+0(FP), BP
+	TESTL	$7, BP
+	JZ	2(PC)
+	MOVL	0, AX // crash with nil ptr deref
+	// DI:SI = mask
+	MOVL	mask_lo+4(FP), SI
+	MOVL	mask_hi+8(FP), DI
+	// DX:AX = *addr
+	MOVL	0(BP), AX
+	MOVL	4(BP), DX
+andloop:
+	// CX:BX = DX:AX (*addr) AND DI:SI (mask)
+	MOVL	AX, BX
+	MOVL	DX, CX
+	ANDL	SI, BX
+	ANDL	DI, CX
+	// if *addr == DX:AX { *addr = CX:BX } else { DX:AX = *addr }
+	LOCK
+	CMPXCHG8B	0(BP)
+	JNZ	andloop
+	// return DX:AX
+	MOVL	AX, old_lo+12(FP)
+	MOVL	DX, old_hi+16(FP)
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-20
+	// no 64-bit OR so use CMPXCHG8B loop
+	MOVL	addr+0(FP), BP
+	TESTL	$7, BP
+	JZ	2(PC)
+	MOVL	0, AX // crash with nil ptr deref
+	// DI:SI = mask
+	MOVL	mask_lo+4(FP), SI
+	MOVL	mask_hi+8(FP), DI
+	// DX:AX = *addr
+	MOVL	0(BP), AX
+	MOVL	4(BP), DX
+orloop:
+	// CX:BX = DX:AX (*addr) OR DI:SI (mask)
+	MOVL	AX, BX
+	MOVL	DX, CX
+	ORL	SI, BX
+	ORL	DI, CX
+	// if *addr == DX:AX { *addr = CX:BX } else { DX:AX = *addr }
+	LOCK
+	CMPXCHG8B	0(BP)
+	JNZ	orloop
+	// return DX:AX
+	MOVL	AX, old_lo+12(FP)
+	MOVL	DX, old_hi+16(FP)
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-20
+	// no 64-bit XOR so use CMPXCHG8B loop
+	MOVL	addr+0(FP), BP
+	TESTL	$7, BP
+	JZ	2(PC)
+	MOVL	0, AX // crash with nil ptr deref
+	// DI:SI = mask
+	MOVL	mask_lo+4(FP), SI
+	MOVL	mask_hi+8(FP), DI
+	// DX:AX = *addr
+	MOVL	0(BP), AX
+	MOVL	4(BP), DX
+xorloop:
+	// CX:BX = DX:AX (*addr) XOR DI:SI (mask)
+	MOVL	AX, BX
+	MOVL	DX, CX
+	XORL	SI, BX
+	XORL	DI, CX
+	// if *addr == DX:AX { *addr = CX:BX } else { DX:AX = *addr }
+	LOCK
+	CMPXCHG8B	0(BP)
+	JNZ	xorloop
+	// return DX:AX
+	MOVL	AX, old_lo+12(FP)
+	MOVL	DX, old_hi+16(FP)
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-8
 	JMP	·LoadUint32(SB)
 
@@ -201,5 +327,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-12
 	XADDL	AX, (SP)
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-8
+TEXT ·storeUintptr(SB),NOSPLIT,$0-8
 	JMP	·StoreUint32(SB)
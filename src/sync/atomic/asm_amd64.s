@@ -26,7 +26,7 @@ TEXT ·SwapUint64(SB),NOSPLIT,$0-24
 	MOVQ	AX, old+16(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-24
+TEXT ·swapUintptr(SB),NOSPLIT,$0-24
 	JMP	·SwapUint64(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-17
@@ -41,7 +41,7 @@ TEXT ·CompareAndSwapUint32(SB),NOSPLIT,$0-17
 	SETEQ	swapped+16(FP)
 	RET
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-25
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-25
 	JMP	·CompareAndSwapUint64(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-25
@@ -85,6 +85,90 @@ TEXT ·AddUint64(SB),NOSPLIT,$0-24
 	MOVQ	CX, new+16(FP)
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-20
+	MOVQ	addr+0(FP), BP
+	MOVL	mask+8(FP), DX
+	MOVL	0(BP), AX
+andloop:
+	// CX = AX (*addr) AND DX (mask)
+	MOVL	AX, CX
+	ANDL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BP)
+	JNZ	andloop
+	MOVL	AX, old+16(FP)
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-20
+	MOVQ	addr+0(FP), BP
+	MOVL	mask+8(FP), DX
+	MOVL	0(BP), AX
+orloop:
+	// CX = AX (*addr) OR DX (mask)
+	MOVL	AX, CX
+	ORL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BP)
+	JNZ	orloop
+	MOVL	AX, old+16(FP)
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-20
+	MOVQ	addr+0(FP), BP
+	MOVL	mask+8(FP), DX
+	MOVL	0(BP), AX
+xorloop:
+	// CX = AX (*addr) XOR DX (mask)
+	MOVL	AX, CX
+	XORL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BP)
+	JNZ	xorloop
+	MOVL	AX, old+16(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-24
+	MOVQ	addr+0(FP), BP
+	MOVQ	mask+8(FP), DX
+	MOVQ	0(BP), AX
+andloop:
+	MOVQ	AX, CX
+	ANDQ	DX, CX
+	LOCK
+	CMPXCHGQ	CX, 0(BP)
+	JNZ	andloop
+	MOVQ	AX, old+16(FP)
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-24
+	MOVQ	addr+0(FP), BP
+	MOVQ	mask+8(FP), DX
+	MOVQ	0(BP), AX
+orloop:
+	MOVQ	AX, CX
+	ORQ	DX, CX
+	LOCK
+	CMPXCHGQ	CX, 0(BP)
+	JNZ	orloop
+	MOVQ	AX, old+16(FP)
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-24
+	MOVQ	addr+0(FP), BP
+	MOVQ	mask+8(FP), DX
+	MOVQ	0(BP), AX
+xorloop:
+	MOVQ	AX, CX
+	XORQ	DX, CX
+	LOCK
+	CMPXCHGQ	CX, 0(BP)
+	JNZ	xorloop
+	MOVQ	AX, old+16(FP)
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-12
 	JMP	·LoadUint32(SB)
 
@@ -130,5 +214,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-16
 	XCHGQ	AX, 0(BP)
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-16
+TEXT ·storeUintptr(SB),NOSPLIT,$0-16
 	JMP	·StoreUint64(SB)
@@ -27,7 +27,7 @@ TEXT ·SwapUint64(SB),NOSPLIT,$0-24
 	MOVQ	AX, old+16(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-12
+TEXT ·swapUintptr(SB),NOSPLIT,$0-12
 	JMP	·SwapUint32(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-17
@@ -42,7 +42,7 @@ TEXT ·CompareAndSwapUint32(SB),NOSPLIT,$0-17
 	SETEQ	swapped+16(FP)
 	RET
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-17
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-17
 	JMP	·CompareAndSwapUint32(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-25
@@ -92,6 +92,99 @@ TEXT ·AddUint64(SB),NOSPLIT,$0-24
 	MOVQ	CX, new+16(FP)
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-12
+	MOVL	addr+0(FP), BX
+	MOVL	mask+4(FP), DX
+	MOVL	0(BX), AX
+andloop:
+	// CX = AX (*addr) AND DX (mask)
+	MOVL	AX, CX
+	ANDL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BX)
+	JNZ	andloop
+	MOVL	AX, old+8(FP)
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-12
+	MOVL	addr+0(FP), BX
+	MOVL	mask+4(FP), DX
+	MOVL	0(BX), AX
+orloop:
+	// CX = AX (*addr) OR DX (mask)
+	MOVL	AX, CX
+	ORL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BX)
+	JNZ	orloop
+	MOVL	AX, old+8(FP)
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-12
+	MOVL	addr+0(FP), BX
+	MOVL	mask+4(FP), DX
+	MOVL	0(BX), AX
+xorloop:
+	// CX = AX (*addr) XOR DX (mask)
+	MOVL	AX, CX
+	XORL	DX, CX
+	// if *addr == AX { *addr = CX } else { AX = *addr }
+	LOCK
+	CMPXCHGL	CX, 0(BX)
+	JNZ	xorloop
+	MOVL	AX, old+8(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-24
+	MOVL	addr+0(FP), BX
+	TESTL	$7, BX
+	JZ	2(PC)
+	MOVL	0, BX // crash with nil ptr deref
+	MOVQ	mask+8(FP), DX
+	MOVQ	0(BX), AX
+andloop:
+	MOVQ	AX, CX
+	ANDQ	DX, CX
+	LOCK
+	CMPXCHGQ	CX, 0(BX)
+	JNZ	andloop
+	MOVQ	AX, old+16(FP)
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-24
+	MOVL	addr+0(FP), BX
+	TESTL	$7, BX
+	JZ	2(PC)
+	MOVL	0, BX // crash with nil ptr deref
+	MOVQ	mask+8(FP), DX
+	MOVQ	0(BX), AX
+orloop:
+	MOVQ	AX, CX
+	ORQ	DX, CX
+	LOCK
+	CMPXCHGQ	CX, 0(BX)
+	JNZ	orloop
+	MOVQ	AX, old+16(FP)
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-24
+	MOVL	addr+0(FP), BX
+	TESTL	$7, BX
+	JZ	2(PC)
+	MOVL	0, BX // crash with nil ptr deref
+	MOVQ	mask+8(FP), DX
+	MOVQ	0(BX), AX
+xorloop:
+	MOVQ	AX, CX
+	XORQ	DX, CX
+	LOCK
+	CMPXCHGQ	CX, 0(BX)
+	JNZ	xorloop
+	MOVQ	AX, old+16(FP)
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-12
 	JMP	·LoadUint32(SB)
 
@@ -143,5 +236,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-16
 	XCHGQ	AX, 0(BX)
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-8
+TEXT ·storeUintptr(SB),NOSPLIT,$0-8
 	JMP	·StoreUint32(SB)
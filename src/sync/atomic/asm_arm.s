@@ -111,6 +111,51 @@ add64loop:
 	MOVW	R5, rethi+16(FP)
 	RET
 
+TEXT ·armAndUint32(SB),NOSPLIT,$0-12
+	MOVW	addr+0(FP), R1
+	MOVW	mask+4(FP), R2
+andloop:
+	// LDREX and STREX were introduced in ARMv6.
+	LDREX	(R1), R3
+	AND	R3, R2, R4
+	DMB_ISHST_7
+	STREX	R4, (R1), R0
+	CMP	$0, R0
+	BNE	andloop
+	DMB_ISH_7
+	MOVW	R3, old+8(FP)
+	RET
+
+TEXT ·armOrUint32(SB),NOSPLIT,$0-12
+	MOVW	addr+0(FP), R1
+	MOVW	mask+4(FP), R2
+orloop:
+	// LDREX and STREX were introduced in ARMv6.
+	LDREX	(R1), R3
+	ORR	R3, R2, R4
+	DMB_ISHST_7
+	STREX	R4, (R1), R0
+	CMP	$0, R0
+	BNE	orloop
+	DMB_ISH_7
+	MOVW	R3, old+8(FP)
+	RET
+
+TEXT ·armXorUint32(SB),NOSPLIT,$0-12
+	MOVW	addr+0(FP), R1
+	MOVW	mask+4(FP), R2
+xorloop:
+	// LDREX and STREX were introduced in ARMv6.
+	LDREX	(R1), R3
+	EOR	R3, R2, R4
+	DMB_ISHST_7
+	STREX	R4, (R1), R0
+	CMP	$0, R0
+	BNE	xorloop
+	DMB_ISH_7
+	MOVW	R3, old+8(FP)
+	RET
+
 TEXT ·armSwapUint32(SB),NOSPLIT,$0-12
 	MOVW	addr+0(FP), R1
 	MOVW	new+4(FP), R2
@@ -30,7 +30,7 @@ again:
 	MOVD	R2, old+16(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-24
+TEXT ·swapUintptr(SB),NOSPLIT,$0-24
 	B	·SwapUint64(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-17
@@ -51,7 +51,7 @@ ok:
 	MOVB	R0, swapped+16(FP)
 	RET
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-25
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-25
 	B	·CompareAndSwapUint64(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-25
@@ -103,6 +103,72 @@ again:
 	MOVD	R2, new+16(FP)
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R0
+	MOVW	mask+8(FP), R1
+again:
+	LDAXRW	(R0), R2
+	ANDW	R1, R2, R3
+	STLXRW	R3, (R0), R4
+	CBNZ	R4, again
+	MOVW	R2, old+16(FP)
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R0
+	MOVW	mask+8(FP), R1
+again:
+	LDAXRW	(R0), R2
+	ORRW	R1, R2, R3
+	STLXRW	R3, (R0), R4
+	CBNZ	R4, again
+	MOVW	R2, old+16(FP)
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R0
+	MOVW	mask+8(FP), R1
+again:
+	LDAXRW	(R0), R2
+	EORW	R1, R2, R3
+	STLXRW	R3, (R0), R4
+	CBNZ	R4, again
+	MOVW	R2, old+16(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R0
+	MOVD	mask+8(FP), R1
+again:
+	LDAXR	(R0), R2
+	AND	R1, R2, R3
+	STLXR	R3, (R0), R4
+	CBNZ	R4, again
+	MOVD	R2, old+16(FP)
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R0
+	MOVD	mask+8(FP), R1
+again:
+	LDAXR	(R0), R2
+	ORR	R1, R2, R3
+	STLXR	R3, (R0), R4
+	CBNZ	R4, again
+	MOVD	R2, old+16(FP)
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R0
+	MOVD	mask+8(FP), R1
+again:
+	LDAXR	(R0), R2
+	EOR	R1, R2, R3
+	STLXR	R3, (R0), R4
+	CBNZ	R4, again
+	MOVD	R2, old+16(FP)
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-12
 	B	·LoadUint32(SB)
 
@@ -145,5 +211,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-16
 	STLR	R1, (R0)
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-16
+TEXT ·storeUintptr(SB),NOSPLIT,$0-16
 	B	·StoreUint64(SB)
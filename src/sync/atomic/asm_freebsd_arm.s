@@ -13,7 +13,7 @@ TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0
 TEXT ·CompareAndSwapUint32(SB),NOSPLIT,$0
 	B ·armCompareAndSwapUint32(SB)
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0
 	B ·CompareAndSwapUint32(SB)
 
 TEXT ·AddInt32(SB),NOSPLIT,$0
@@ -31,7 +31,7 @@ TEXT ·SwapInt32(SB),NOSPLIT,$0
 TEXT ·SwapUint32(SB),NOSPLIT,$0
 	B ·armSwapUint32(SB)
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0
+TEXT ·swapUintptr(SB),NOSPLIT,$0
 	B ·SwapUint32(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0
@@ -46,6 +46,24 @@ TEXT ·AddInt64(SB),NOSPLIT,$0
 TEXT ·AddUint64(SB),NOSPLIT,$0
 	B ·addUint64(SB)
 
+TEXT ·AndUint32(SB),NOSPLIT,$0
+	B ·armAndUint32(SB)
+
+TEXT ·OrUint32(SB),NOSPLIT,$0
+	B ·armOrUint32(SB)
+
+TEXT ·XorUint32(SB),NOSPLIT,$0
+	B ·armXorUint32(SB)
+
+TEXT ·AndUint64(SB),NOSPLIT,$0
+	B ·andUint64(SB)
+
+TEXT ·OrUint64(SB),NOSPLIT,$0
+	B ·orUint64(SB)
+
+TEXT ·XorUint64(SB),NOSPLIT,$0
+	B ·xorUint64(SB)
+
 TEXT ·SwapInt64(SB),NOSPLIT,$0
 	B ·swapUint64(SB)
 
@@ -96,5 +114,5 @@ TEXT ·StoreInt64(SB),NOSPLIT,$0
 TEXT ·StoreUint64(SB),NOSPLIT,$0
 	B ·storeUint64(SB)
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0
+TEXT ·storeUintptr(SB),NOSPLIT,$0
 	B ·StoreUint32(SB)
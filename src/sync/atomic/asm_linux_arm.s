@@ -54,7 +54,7 @@ cascheck:
 	MOVW	$0, R0
 	B	casret
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0
 	B	·CompareAndSwapUint32(SB)
 
 TEXT ·AddInt32(SB),NOSPLIT,$0
@@ -91,7 +91,7 @@ swaploop1:
 	MOVW	R4, old+8(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0
+TEXT ·swapUintptr(SB),NOSPLIT,$0
 	B	·SwapUint32(SB)
 
 TEXT cas64<>(SB),NOSPLIT,$0
@@ -154,6 +154,54 @@ TEXT ·AddInt64(SB),NOSPLIT,$0
 TEXT ·AddUint64(SB),NOSPLIT,$0
 	B	·addUint64(SB)
 
+// Implement using kernel cas for portability.
+TEXT ·AndUint32(SB),NOSPLIT,$0-12
+	MOVW	addr+0(FP), R2
+	MOVW	mask+4(FP), R4
+andloop1:
+	MOVW	0(R2), R5
+	MOVW	R5, R0
+	AND	R4, R5, R1
+	BL	cas<>(SB)
+	BCC	andloop1
+	MOVW	R5, old+8(FP)
+	RET
+
+// Implement using kernel cas for portability.
+TEXT ·OrUint32(SB),NOSPLIT,$0-12
+	MOVW	addr+0(FP), R2
+	MOVW	mask+4(FP), R4
+orloop1:
+	MOVW	0(R2), R5
+	MOVW	R5, R0
+	ORR	R4, R5, R1
+	BL	cas<>(SB)
+	BCC	orloop1
+	MOVW	R5, old+8(FP)
+	RET
+
+// Implement using kernel cas for portability.
+TEXT ·XorUint32(SB),NOSPLIT,$0-12
+	MOVW	addr+0(FP), R2
+	MOVW	mask+4(FP), R4
+xorloop1:
+	MOVW	0(R2), R5
+	MOVW	R5, R0
+	EOR	R4, R5, R1
+	BL	cas<>(SB)
+	BCC	xorloop1
+	MOVW	R5, old+8(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0
+	B	·andUint64(SB)
+
+TEXT ·OrUint64(SB),NOSPLIT,$0
+	B	·orUint64(SB)
+
+TEXT ·XorUint64(SB),NOSPLIT,$0
+	B	·xorUint64(SB)
+
 TEXT ·SwapInt64(SB),NOSPLIT,$0
 	B	·swapUint64(SB)
 
@@ -203,5 +251,5 @@ TEXT ·StoreInt64(SB),NOSPLIT,$0
 TEXT ·StoreUint64(SB),NOSPLIT,$0
 	B	·storeUint64(SB)
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0
+TEXT ·storeUintptr(SB),NOSPLIT,$0
 	B	·StoreUint32(SB)
@@ -42,7 +42,7 @@ TEXT ·SwapUint64(SB),NOSPLIT,$0-24
 	SYNC
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-24
+TEXT ·swapUintptr(SB),NOSPLIT,$0-24
 	JMP	·SwapUint64(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-17
@@ -67,7 +67,7 @@ cas_fail:
 	MOVV	$0, R1
 	JMP	-4(PC)
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-25
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-25
 	JMP	·CompareAndSwapUint64(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-25
@@ -127,6 +127,78 @@ TEXT ·AddUint64(SB),NOSPLIT,$0-24
 	SYNC
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-20
+	MOVV	addr+0(FP), R2
+	MOVW	mask+8(FP), R3
+	SYNC
+	LL(2, 1)	// R1 = *R2
+	AND	R1, R3, R4
+	SC(2, 4)	// *R2 = R4
+	BEQ	R4, -3(PC)
+	MOVW	R1, old+16(FP)
+	SYNC
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-20
+	MOVV	addr+0(FP), R2
+	MOVW	mask+8(FP), R3
+	SYNC
+	LL(2, 1)	// R1 = *R2
+	OR	R1, R3, R4
+	SC(2, 4)	// *R2 = R4
+	BEQ	R4, -3(PC)
+	MOVW	R1, old+16(FP)
+	SYNC
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-20
+	MOVV	addr+0(FP), R2
+	MOVW	mask+8(FP), R3
+	SYNC
+	LL(2, 1)	// R1 = *R2
+	XOR	R1, R3, R4
+	SC(2, 4)	// *R2 = R4
+	BEQ	R4, -3(PC)
+	MOVW	R1, old+16(FP)
+	SYNC
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-24
+	MOVV	addr+0(FP), R2
+	MOVV	mask+8(FP), R3
+	SYNC
+	LLV(2, 1)	// R1 = *R2
+	AND	R1, R3, R4
+	SCV(2, 4)	// *R2 = R4
+	BEQ	R4, -3(PC)
+	MOVV	R1, old+16(FP)
+	SYNC
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-24
+	MOVV	addr+0(FP), R2
+	MOVV	mask+8(FP), R3
+	SYNC
+	LLV(2, 1)	// R1 = *R2
+	OR	R1, R3, R4
+	SCV(2, 4)	// *R2 = R4
+	BEQ	R4, -3(PC)
+	MOVV	R1, old+16(FP)
+	SYNC
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-24
+	MOVV	addr+0(FP), R2
+	MOVV	mask+8(FP), R3
+	SYNC
+	LLV(2, 1)	// R1 = *R2
+	XOR	R1, R3, R4
+	SCV(2, 4)	// *R2 = R4
+	BEQ	R4, -3(PC)
+	MOVV	R1, old+16(FP)
+	SYNC
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-12
 	JMP	·LoadUint32(SB)
 
@@ -177,5 +249,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-16
 	SYNC
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-16
+TEXT ·storeUintptr(SB),NOSPLIT,$0-16
 	JMP	·StoreUint64(SB)
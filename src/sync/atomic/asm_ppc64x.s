@@ -34,7 +34,7 @@ TEXT ·SwapUint64(SB),NOSPLIT,$0-24
 	MOVD	R5, old+16(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-24
+TEXT ·swapUintptr(SB),NOSPLIT,$0-24
 	BR	·SwapUint64(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-17
@@ -57,7 +57,7 @@ TEXT ·CompareAndSwapUint32(SB),NOSPLIT,$0-17
 	MOVB	R0, swapped+16(FP)
 	RET
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-25
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-25
 	BR	·CompareAndSwapUint64(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-25
@@ -113,6 +113,78 @@ TEXT ·AddUint64(SB),NOSPLIT,$0-24
 	MOVD	R5, ret+16(FP)
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R3
+	MOVW	mask+8(FP), R4
+	SYNC
+	LWAR	(R3), R5
+	AND	R4, R5, R6
+	STWCCC	R6, (R3)
+	BNE	-3(PC)
+	ISYNC
+	MOVW	R5, old+16(FP)
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R3
+	MOVW	mask+8(FP), R4
+	SYNC
+	LWAR	(R3), R5
+	OR	R4, R5, R6
+	STWCCC	R6, (R3)
+	BNE	-3(PC)
+	ISYNC
+	MOVW	R5, old+16(FP)
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R3
+	MOVW	mask+8(FP), R4
+	SYNC
+	LWAR	(R3), R5
+	XOR	R4, R5, R6
+	STWCCC	R6, (R3)
+	BNE	-3(PC)
+	ISYNC
+	MOVW	R5, old+16(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R3
+	MOVD	mask+8(FP), R4
+	SYNC
+	LDAR	(R3), R5
+	AND	R4, R5, R6
+	STDCCC	R6, (R3)
+	BNE	-3(PC)
+	ISYNC
+	MOVD	R5, old+16(FP)
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R3
+	MOVD	mask+8(FP), R4
+	SYNC
+	LDAR	(R3), R5
+	OR	R4, R5, R6
+	STDCCC	R6, (R3)
+	BNE	-3(PC)
+	ISYNC
+	MOVD	R5, old+16(FP)
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R3
+	MOVD	mask+8(FP), R4
+	SYNC
+	LDAR	(R3), R5
+	XOR	R4, R5, R6
+	STDCCC	R6, (R3)
+	BNE	-3(PC)
+	ISYNC
+	MOVD	R5, old+16(FP)
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-12
 	BR	·LoadUint32(SB)
 
@@ -165,5 +237,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-16
 	MOVD	R4, 0(R3)
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-16
+TEXT ·storeUintptr(SB),NOSPLIT,$0-16
 	BR	·StoreUint64(SB)
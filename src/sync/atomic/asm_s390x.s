@@ -30,7 +30,7 @@ repeat:
 	MOVD	R5, old+16(FP)
 	RET
 
-TEXT ·SwapUintptr(SB),NOSPLIT,$0-24
+TEXT ·swapUintptr(SB),NOSPLIT,$0-24
 	BR	·SwapUint64(SB)
 
 TEXT ·CompareAndSwapInt32(SB),NOSPLIT,$0-17
@@ -48,7 +48,7 @@ cas_fail:
 	MOVB	$0, ret+16(FP)
 	RET
 
-TEXT ·CompareAndSwapUintptr(SB),NOSPLIT,$0-25
+TEXT ·compareAndSwapUintptr(SB),NOSPLIT,$0-25
 	BR	·CompareAndSwapUint64(SB)
 
 TEXT ·CompareAndSwapInt64(SB),NOSPLIT,$0-25
@@ -97,6 +97,72 @@ repeat:
 	MOVD	R6, ret+16(FP)
 	RET
 
+TEXT ·AndUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R4
+	MOVWZ	mask+8(FP), R5
+	MOVWZ	(R4), R3
+repeat:
+	AND	R3, R5, R6
+	CS	R3, R6, (R4) // if R3==(R4) then (R4)=R6 else R3=(R4)
+	BNE	repeat
+	MOVW	R3, old+16(FP)
+	RET
+
+TEXT ·OrUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R4
+	MOVWZ	mask+8(FP), R5
+	MOVWZ	(R4), R3
+repeat:
+	OR	R3, R5, R6
+	CS	R3, R6, (R4) // if R3==(R4) then (R4)=R6 else R3=(R4)
+	BNE	repeat
+	MOVW	R3, old+16(FP)
+	RET
+
+TEXT ·XorUint32(SB),NOSPLIT,$0-20
+	MOVD	addr+0(FP), R4
+	MOVWZ	mask+8(FP), R5
+	MOVWZ	(R4), R3
+repeat:
+	XOR	R3, R5, R6
+	CS	R3, R6, (R4) // if R3==(R4) then (R4)=R6 else R3=(R4)
+	BNE	repeat
+	MOVW	R3, old+16(FP)
+	RET
+
+TEXT ·AndUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R4
+	MOVD	mask+8(FP), R5
+	MOVD	(R4), R3
+repeat:
+	AND	R3, R5, R6
+	CSG	R3, R6, (R4) // if R3==(R4) then (R4)=R6 else R3=(R4)
+	BNE	repeat
+	MOVD	R3, old+16(FP)
+	RET
+
+TEXT ·OrUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R4
+	MOVD	mask+8(FP), R5
+	MOVD	(R4), R3
+repeat:
+	OR	R3, R5, R6
+	CSG	R3, R6, (R4) // if R3==(R4) then (R4)=R6 else R3=(R4)
+	BNE	repeat
+	MOVD	R3, old+16(FP)
+	RET
+
+TEXT ·XorUint64(SB),NOSPLIT,$0-24
+	MOVD	addr+0(FP), R4
+	MOVD	mask+8(FP), R5
+	MOVD	(R4), R3
+repeat:
+	XOR	R3, R5, R6
+	CSG	R3, R6, (R4) // if R3==(R4) then (R4)=R6 else R3=(R4)
+	BNE	repeat
+	MOVD	R3, old+16(FP)
+	RET
+
 TEXT ·LoadInt32(SB),NOSPLIT,$0-12
 	BR	·LoadUint32(SB)
 
@@ -139,5 +205,5 @@ TEXT ·StoreUint64(SB),NOSPLIT,$0-16
 	MOVD	R4, 0(R3)
 	RET
 
-TEXT ·StoreUintptr(SB),NOSPLIT,$0-16
+TEXT ·storeUintptr(SB),NOSPLIT,$0-16
 	BR	·StoreUint64(SB)
@@ -1425,6 +1425,9 @@ func TestUnaligned64(t *testing.T) {
 	shouldPanic(t, "StoreUint64", func() { StoreUint64(p, 1) })
 	shouldPanic(t, "CompareAndSwapUint64", func() { CompareAndSwapUint64(p, 1, 2) })
 	shouldPanic(t, "AddUint64", func() { AddUint64(p, 3) })
+	shouldPanic(t, "AndUint64", func() { AndUint64(p, 3) })
+	shouldPanic(t, "OrUint64", func() { OrUint64(p, 3) })
+	shouldPanic(t, "XorUint64", func() { XorUint64(p, 3) })
 }
 
 func TestNilDeref(t *testing.T) {
@@ -1446,6 +1449,12 @@ func TestNilDeref(t *testing.T) {
 		func() { AddInt64(nil, 0) },
 		func() { AddUint64(nil, 0) },
 		func() { AddUintptr(nil, 0) },
+		func() { AndUint32(nil, 0) },
+		func() { AndUint64(nil, 0) },
+		func() { OrUint32(nil, 0) },
+		func() { OrUint64(nil, 0) },
+		func() { XorUint32(nil, 0) },
+		func() { XorUint64(nil, 0) },
 		func() { LoadInt32(nil) },
 		func() { LoadInt64(nil) },
 		func() { LoadUint32(nil) },
@@ -0,0 +1,176 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic_test
+
+import (
+	"runtime"
+	"sync"
+	. "sync/atomic"
+	"testing"
+)
+
+func TestBitwiseUint32(t *testing.T) {
+	var x struct {
+		before uint32
+		i      uint32
+		after  uint32
+	}
+	x.before = magic32
+	x.after = magic32
+	var j uint32
+	for bit := uint32(1); bit != 0; bit <<= 1 {
+		if k := OrUint32(&x.i, bit); k != j || x.i != j|bit {
+			t.Fatalf("OrUint32: bit=%#x i=%#x j=%#x k=%#x", bit, x.i, j, k)
+		}
+		j |= bit
+	}
+	for bit := uint32(1); bit != 0; bit <<= 2 {
+		if k := XorUint32(&x.i, bit); k != j || x.i != j^bit {
+			t.Fatalf("XorUint32: bit=%#x i=%#x j=%#x k=%#x", bit, x.i, j, k)
+		}
+		j ^= bit
+	}
+	for bit := uint32(2); bit != 0; bit <<= 2 {
+		if k := AndUint32(&x.i, ^bit); k != j || x.i != j&^bit {
+			t.Fatalf("AndUint32: bit=%#x i=%#x j=%#x k=%#x", bit, x.i, j, k)
+		}
+		j &^= bit
+	}
+	if x.i != 0 {
+		t.Fatalf("i=%#x after clearing all bits", x.i)
+	}
+	if x.before != magic32 || x.after != magic32 {
+		t.Fatalf("wrong magic: %#x _ %#x != %#x _ %#x", x.before, x.after, magic32, magic32)
+	}
+}
+
+func TestBitwiseUint64(t *testing.T) {
+	if test64err != nil {
+		t.Skipf("Skipping 64-bit tests: %v", test64err)
+	}
+	var x struct {
+		before uint64
+		i      uint64
+		after  uint64
+	}
+	x.before = magic64
+	x.after = magic64
+	var j uint64
+	for bit := uint64(1); bit != 0; bit <<= 1 {
+		if k := OrUint64(&x.i, bit); k != j || x.i != j|bit {
+			t.Fatalf("OrUint64: bit=%#x i=%#x j=%#x k=%#x", bit, x.i, j, k)
+		}
+		j |= bit
+	}
+	for bit := uint64(1); bit != 0; bit <<= 2 {
+		if k := XorUint64(&x.i, bit); k != j || x.i != j^bit {
+			t.Fatalf("XorUint64: bit=%#x i=%#x j=%#x k=%#x", bit, x.i, j, k)
+		}
+		j ^= bit
+	}
+	for bit := uint64(2); bit != 0; bit <<= 2 {
+		if k := AndUint64(&x.i, ^bit); k != j || x.i != j&^bit {
+			t.Fatalf("AndUint64: bit=%#x i=%#x j=%#x k=%#x", bit, x.i, j, k)
+		}
+		j &^= bit
+	}
+	if x.i != 0 {
+		t.Fatalf("i=%#x after clearing all bits", x.i)
+	}
+	if x.before != magic64 || x.after != magic64 {
+		t.Fatalf("wrong magic: %#x _ %#x != %#x _ %#x", x.before, x.after, uint64(magic64), uint64(magic64))
+	}
+}
+
+// TestHammerBitwise gives each goroutine one bit of a shared word,
+// which it sets, toggles and clears with the bitwise operations.
+// The previous value each operation returns must show the bit in
+// the state the goroutine left it, whatever the other goroutines
+// do to their own bits.
+func TestHammerBitwise(t *testing.T) {
+	const p = 8
+	n := 100000
+	if testing.Short() {
+		n = 1000
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(p))
+
+	var val32 uint32
+	var val64 uint64
+	hammer := func(i int) string {
+		bit32 := uint32(1) << uint(4*i)
+		bit64 := uint64(1) << uint(8*i+4)
+		for j := 0; j < n; j++ {
+			if OrUint32(&val32, bit32)&bit32 != 0 ||
+				XorUint32(&val32, bit32)&bit32 == 0 ||
+				XorUint32(&val32, bit32)&bit32 != 0 ||
+				AndUint32(&val32, ^bit32)&bit32 == 0 {
+				return "32-bit operation lost an update"
+			}
+			if test64err != nil {
+				continue
+			}
+			if OrUint64(&val64, bit64)&bit64 != 0 ||
+				XorUint64(&val64, bit64)&bit64 == 0 ||
+				XorUint64(&val64, bit64)&bit64 != 0 ||
+				AndUint64(&val64, ^bit64)&bit64 == 0 {
+				return "64-bit operation lost an update"
+			}
+		}
+		return ""
+	}
+	errc := make(chan string, p)
+	for i := 0; i < p; i++ {
+		go func(i int) {
+			errc <- hammer(i)
+		}(i)
+	}
+	for i := 0; i < p; i++ {
+		if err := <-errc; err != "" {
+			t.Error(err)
+		}
+	}
+	if val32 != 0 || val64 != 0 {
+		t.Fatalf("val32=%#x val64=%#x, want 0", val32, val64)
+	}
+}
+
+// orUint32CAS is OrUint32 written as a compare-and-swap loop,
+// as it had to be before OrUint32 existed.
+func orUint32CAS(addr *uint32, mask uint32) (old uint32) {
+	for {
+		old = LoadUint32(addr)
+		if CompareAndSwapUint32(addr, old, old|mask) {
+			return
+		}
+	}
+}
+
+func BenchmarkOrUint32(b *testing.B) {
+	benchmarkOrUint32(b, OrUint32)
+}
+
+func BenchmarkOrUint32CAS(b *testing.B) {
+	benchmarkOrUint32(b, orUint32CAS)
+}
+
+// benchmarkOrUint32 runs or on a word shared by 8 goroutines,
+// each setting a bit of its own, for b.N operations in total.
+func benchmarkOrUint32(b *testing.B, or func(*uint32, uint32) uint32) {
+	const p = 8
+	var x uint32
+	var wg sync.WaitGroup
+	for i := 0; i < p; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bit := uint32(1) << uint(i)
+			for j := i; j < b.N; j += p {
+				or(&x, bit)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
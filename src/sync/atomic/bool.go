@@ -0,0 +1,58 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic
+
+// The Bool functions operate on a boolean flag held in a uint32,
+// using the 32-bit operations: they store true as 1 and false as 0,
+// and read any non-zero value as true.
+
+// Bool 函数操作保存在 uint32 中的布尔标志，它们基于32位操作实现：
+// true 被存储为 1，false 被存储为 0，任何非零值都被读作 true。
+
+func b32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// LoadBool atomically loads the flag at *addr.
+
+// LoadBool 自动载入 *addr 处的标志。
+func LoadBool(addr *uint32) (val bool) {
+	return LoadUint32(addr) != 0
+}
+
+// StoreBool atomically stores val into the flag at *addr.
+
+// StoreBool 自动将 val 存储到 *addr 处的标志中。
+func StoreBool(addr *uint32, val bool) {
+	StoreUint32(addr, b32(val))
+}
+
+// SwapBool atomically stores new into the flag at *addr and returns the previous value.
+
+// SwapBool 自动将 new 存储到 *addr 处的标志中并返回上一个值。
+func SwapBool(addr *uint32, new bool) (old bool) {
+	return SwapUint32(addr, b32(new)) != 0
+}
+
+// CompareAndSwapBool executes the compare-and-swap operation for the flag at *addr.
+
+// CompareAndSwapBool 为 *addr 处的标志执行“比较并交换”操作。
+func CompareAndSwapBool(addr *uint32, old, new bool) (swapped bool) {
+	for {
+		// Any non-zero value matches old == true, so compare
+		// against the value actually stored.
+		// 任何非零值都与 old == true 匹配，因此应与实际存储的值进行比较。
+		v := LoadUint32(addr)
+		if (v != 0) != old {
+			return false
+		}
+		if CompareAndSwapUint32(addr, v, b32(new)) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic_test
+
+import (
+	"runtime"
+	"sync"
+	. "sync/atomic"
+	"testing"
+)
+
+func TestBool(t *testing.T) {
+	var x struct {
+		before uint32
+		i      uint32
+		after  uint32
+	}
+	x.before = magic32
+	x.after = magic32
+	if LoadBool(&x.i) {
+		t.Fatal("zero flag is true")
+	}
+	StoreBool(&x.i, true)
+	if x.i != 1 || !LoadBool(&x.i) {
+		t.Fatalf("StoreBool(true): flag is %d", x.i)
+	}
+	if old := SwapBool(&x.i, false); !old || x.i != 0 {
+		t.Fatalf("SwapBool(false) = %v, flag %d; want true, 0", old, x.i)
+	}
+	if old := SwapBool(&x.i, false); old || x.i != 0 {
+		t.Fatalf("SwapBool(false) = %v, flag %d; want false, 0", old, x.i)
+	}
+	if CompareAndSwapBool(&x.i, true, false) {
+		t.Fatal("CompareAndSwapBool(true, false) of false flag succeeded")
+	}
+	if !CompareAndSwapBool(&x.i, false, true) || x.i != 1 {
+		t.Fatalf("CompareAndSwapBool(false, true) failed, flag %d", x.i)
+	}
+
+	// Any non-zero value is true.
+	x.i = 2
+	if !LoadBool(&x.i) {
+		t.Fatal("flag 2 is false")
+	}
+	if CompareAndSwapBool(&x.i, false, true) {
+		t.Fatal("CompareAndSwapBool(false, true) of flag 2 succeeded")
+	}
+	if !CompareAndSwapBool(&x.i, true, false) || x.i != 0 {
+		t.Fatalf("CompareAndSwapBool(true, false) of flag 2 failed, flag %d", x.i)
+	}
+	if x.before != magic32 || x.after != magic32 {
+		t.Fatalf("wrong magic: %#x _ %#x != %#x _ %#x", x.before, x.after, magic32, magic32)
+	}
+}
+
+// TestBoolMutualExclusion uses a flag as a spin lock. If the Bool
+// functions were not linearizable, two goroutines could hold the lock
+// at once and lose an increment, and the race detector would report
+// the accesses to n.
+func TestBoolMutualExclusion(t *testing.T) {
+	p := 4 * runtime.GOMAXPROCS(0)
+	N := int(1e4)
+	if testing.Short() {
+		p /= 2
+		N = 1e2
+	}
+	var flag uint32
+	n := 0
+	errc := make(chan string, p)
+	var wg sync.WaitGroup
+	for i := 0; i < p; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < N; j++ {
+				// Lock alternately with CompareAndSwapBool and SwapBool.
+				if (i+j)%2 == 0 {
+					for !CompareAndSwapBool(&flag, false, true) {
+						runtime.Gosched()
+					}
+				} else {
+					for SwapBool(&flag, true) {
+						runtime.Gosched()
+					}
+				}
+				n++
+				if !LoadBool(&flag) {
+					errc <- "flag not set while lock held"
+					return
+				}
+				if j%2 == 0 {
+					StoreBool(&flag, false)
+				} else if !SwapBool(&flag, false) {
+					errc <- "flag cleared while lock held"
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Fatal(err)
+	}
+	if n != p*N {
+		t.Fatalf("got %d after %d increments, want %d", n, p*N, p*N)
+	}
+}
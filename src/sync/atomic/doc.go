@@ -33,10 +33,26 @@
 //	*addr += delta
 //	return *addr
 //
+// The bitwise operations, implemented by the AndT, OrT and XorT
+// functions, are the atomic equivalents of (here for AndT):
+//
+//	old = *addr
+//	*addr &= mask
+//	return old
+//
+// They set, clear or toggle the bits of mask without a
+// compare-and-swap loop.
+//
 // The load and store operations, implemented by the LoadT and StoreT
 // functions, are the atomic equivalents of "return *addr" and
 // "*addr = val".
 //
+// Storing a Go pointer in a uintptr hides it from the garbage collector;
+// use the Pointer functions for pointers instead. When the program runs
+// with GODEBUG=atomicptrcheck=1, StoreUintptr, SwapUintptr and
+// CompareAndSwapUintptr panic if the new value points into an allocated
+// object in the Go heap.
+//
 
 // atomic 包提供了底层的原子性内存原语，这对于同步算法的实现很有用.
 //
@@ -58,18 +74,32 @@
 //	*addr += delta
 //	return *addr
 //
+// 按位操作由 AndT、OrT 和 XorT 函数实现，它们在原子性上等价于（此处以 AndT 为例）：
+//
+//	old = *addr
+//	*addr &= mask
+//	return old
+//
+// 它们无需“比较并交换”循环即可设置、清除或翻转 mask 中的位。
+//
 // “载入并存储”操作由 LoadT 函数和 StoreT 函数实现，它们在原子性上分别等价于：
 //
 //	"return *addr"
 // 和
 //	"*addr = val".
 //
+// 将 Go 指针存储到 uintptr 中会使其对垃圾回收器不可见，对于指针应当使用 Pointer 函数。
+// 当程序以 GODEBUG=atomicptrcheck=1 运行时，若新值指向 Go 堆中已分配的对象，
+// StoreUintptr、SwapUintptr 和 CompareAndSwapUintptr 就会引发 panic。
+//
 package atomic
 
 import (
 	"unsafe"
 )
 
+//go:generate go run mkrace.go
+
 // BUG(rsc): On x86-32, the 64-bit functions use instructions unavailable before the Pentium MMX.
 //
 // On non-Linux ARM, the 64-bit functions use instructions unavailable before the ARMv6k core.
@@ -106,7 +136,12 @@ func SwapUint64(addr *uint64, new uint64) (old uint64)
 // SwapUintptr atomically stores new into *addr and returns the previous *addr value.
 
 // SwapUintptr 自动将 new 存储到 *addr 中并返回上一个 *addr 值。
-func SwapUintptr(addr *uintptr, new uintptr) (old uintptr)
+func SwapUintptr(addr *uintptr, new uintptr) (old uintptr) {
+	if ptrCheck {
+		checkUintptr("SwapUintptr", new)
+	}
+	return swapUintptr(addr, new)
+}
 
 // SwapPointer atomically stores new into *addr and returns the previous *addr value.
 
@@ -136,7 +171,12 @@ func CompareAndSwapUint64(addr *uint64, old, new uint64) (swapped bool)
 // CompareAndSwapUintptr executes the compare-and-swap operation for a uintptr value.
 
 // CompareAndSwapUintptr 为一个 uintptr 类型的值执行“比较并交换”操作。
-func CompareAndSwapUintptr(addr *uintptr, old, new uintptr) (swapped bool)
+func CompareAndSwapUintptr(addr *uintptr, old, new uintptr) (swapped bool) {
+	if ptrCheck {
+		checkUintptr("CompareAndSwapUintptr", new)
+	}
+	return compareAndSwapUintptr(addr, old, new)
+}
 
 // CompareAndSwapPointer executes the compare-and-swap operation for a unsafe.Pointer value.
 
@@ -176,6 +216,42 @@ func AddUint64(addr *uint64, delta uint64) (new uint64)
 // AddUintptr 自动将 delta 加上 *addr 并返回新值。
 func AddUintptr(addr *uintptr, delta uintptr) (new uintptr)
 
+// AndUint32 atomically performs a bitwise AND of *addr and mask, stores the
+// result into *addr and returns the previous *addr value.
+
+// AndUint32 自动将 *addr 与 mask 进行按位与运算，将结果存储到 *addr 中并返回上一个 *addr 值。
+func AndUint32(addr *uint32, mask uint32) (old uint32)
+
+// OrUint32 atomically performs a bitwise OR of *addr and mask, stores the
+// result into *addr and returns the previous *addr value.
+
+// OrUint32 自动将 *addr 与 mask 进行按位或运算，将结果存储到 *addr 中并返回上一个 *addr 值。
+func OrUint32(addr *uint32, mask uint32) (old uint32)
+
+// XorUint32 atomically performs a bitwise XOR of *addr and mask, stores the
+// result into *addr and returns the previous *addr value.
+
+// XorUint32 自动将 *addr 与 mask 进行按位异或运算，将结果存储到 *addr 中并返回上一个 *addr 值。
+func XorUint32(addr *uint32, mask uint32) (old uint32)
+
+// AndUint64 atomically performs a bitwise AND of *addr and mask, stores the
+// result into *addr and returns the previous *addr value.
+
+// AndUint64 自动将 *addr 与 mask 进行按位与运算，将结果存储到 *addr 中并返回上一个 *addr 值。
+func AndUint64(addr *uint64, mask uint64) (old uint64)
+
+// OrUint64 atomically performs a bitwise OR of *addr and mask, stores the
+// result into *addr and returns the previous *addr value.
+
+// OrUint64 自动将 *addr 与 mask 进行按位或运算，将结果存储到 *addr 中并返回上一个 *addr 值。
+func OrUint64(addr *uint64, mask uint64) (old uint64)
+
+// XorUint64 atomically performs a bitwise XOR of *addr and mask, stores the
+// result into *addr and returns the previous *addr value.
+
+// XorUint64 自动将 *addr 与 mask 进行按位异或运算，将结果存储到 *addr 中并返回上一个 *addr 值。
+func XorUint64(addr *uint64, mask uint64) (old uint64)
+
 // LoadInt32 atomically loads *addr.
 
 // LoadInt32 自动载入 *addr。
@@ -226,10 +302,15 @@ func StoreUint32(addr *uint32, val uint32)
 // StoreUint64 自动将 val 存储到 *addr 中。
 func StoreUint64(addr *uint64, val uint64)
 
-// StoreUint64 atomically stores val into *addr.
+// StoreUintptr atomically stores val into *addr.
 
-// StoreUint64 自动将 val 存储到 *addr 中。
-func StoreUintptr(addr *uintptr, val uintptr)
+// StoreUintptr 自动将 val 存储到 *addr 中。
+func StoreUintptr(addr *uintptr, val uintptr) {
+	if ptrCheck {
+		checkUintptr("StoreUintptr", val)
+	}
+	storeUintptr(addr, val)
+}
 
 // StorePointer atomically stores val into *addr.
 
@@ -0,0 +1,276 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+// mkrace generates the race detector versions of the sync/atomic
+// operations in runtime/race_amd64.s.
+//
+// Without -race, the operations declared without a body in this
+// package are implemented in asm_GOARCH.s. With -race, those files
+// are excluded and the runtime provides the same symbols, routing
+// each operation through ThreadSanitizer. mkrace reads the
+// declarations, so that a new operation cannot be added to one
+// configuration and forgotten in the other.
+//
+// Each operation is classified by its name (Load, Store, Swap,
+// CompareAndSwap or Add) and its operand width. The Int32 and Int64
+// forms call the matching __tsan_go_atomic function; the other forms
+// jump to the Int32 or Int64 form of the same width. Operations that
+// ThreadSanitizer lacks, such as AndUint32, are implemented in the
+// runtime with go:linkname and are left out of the generated file.
+//
+// Usage:
+//
+//	go run mkrace.go [-check] [-o file]
+//
+// With -check, mkrace reports whether the file is up to date
+// instead of rewriting it.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	check  = flag.Bool("check", false, "report whether the file is up to date instead of writing it")
+	output = flag.String("o", filepath.Join("..", "..", "runtime", "race_amd64.s"), "race assembly `file` to update")
+)
+
+const (
+	beginMarker = "// Atomic operations for sync/atomic package.\n"
+	endMarker   = "// Generic atomic operation implementation.\n"
+)
+
+// An op is one of the operation classes, in the order
+// in which they appear in the generated file.
+type op struct {
+	prefix string // function name prefix, such as "Load"
+	tsan   string // ThreadSanitizer operation, such as "load"
+	// post is the code run after the ThreadSanitizer call
+	// in the Int32 and Int64 forms, keyed by width.
+	post map[int]string
+}
+
+var ops = []*op{
+	{prefix: "Load", tsan: "load"},
+	{prefix: "Store", tsan: "store"},
+	{prefix: "Swap", tsan: "exchange"},
+	{prefix: "Add", tsan: "fetch_add", post: map[int]string{
+		32: "\tMOVL\tadd+8(FP), AX\t// convert fetch_add to add_fetch\n\tADDL\tAX, ret+16(FP)\n",
+		64: "\tMOVQ\tadd+8(FP), AX\t// convert fetch_add to add_fetch\n\tADDQ\tAX, ret+16(FP)\n",
+	}},
+	{prefix: "CompareAndSwap", tsan: "compare_exchange"},
+}
+
+// types lists the operand types in the order in which their forms
+// appear in the generated file, with their width on amd64.
+var types = []struct {
+	name  string
+	width int
+}{
+	{"Int32", 32},
+	{"Int64", 64},
+	{"Uint32", 32},
+	{"Uint64", 64},
+	{"Uintptr", 64},
+	{"Pointer", 64},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mkrace: ")
+	flag.Parse()
+
+	names, err := asmFuncs()
+	if err != nil {
+		log.Fatal(err)
+	}
+	provided, err := runtimeFuncs(filepath.Join("..", "..", "runtime"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var funcs []string
+	for _, name := range names {
+		if !provided[name] {
+			funcs = append(funcs, name)
+		}
+	}
+	block, err := generate(funcs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	old, err := ioutil.ReadFile(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	i := bytes.Index(old, []byte(beginMarker))
+	j := bytes.Index(old, []byte(endMarker))
+	if i < 0 || j < i {
+		log.Fatalf("%s: cannot find the sync/atomic section", *output)
+	}
+	var buf bytes.Buffer
+	buf.Write(old[:i])
+	buf.Write(block)
+	buf.Write(old[j:])
+	if bytes.Equal(buf.Bytes(), old) {
+		return
+	}
+	if *check {
+		log.Fatalf("%s is out of date; run go generate in sync/atomic", *output)
+	}
+	if err := ioutil.WriteFile(*output, buf.Bytes(), 0666); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// asmFuncs returns the functions declared without a body in the
+// non-race build of this package, in declaration order.
+func asmFuncs() ([]string, error) {
+	ctxt := build.Default
+	ctxt.GOARCH = "amd64"
+	pkg, err := ctxt.ImportDir(".", 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var names []string
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body == nil && fn.Recv == nil {
+				names = append(names, fn.Name.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// runtimeFuncs returns the sync/atomic functions that the runtime
+// package in dir implements with a body through go:linkname, such as
+// StorePointer. Those are the same in both configurations; the race
+// detector sees the operations they call.
+func runtimeFuncs(dir string) (map[string]bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	provided := make(map[string]bool)
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Contains(src, []byte(" sync/atomic.")) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || fn.Doc == nil {
+				continue
+			}
+			for _, c := range fn.Doc.List {
+				fields := strings.Fields(c.Text)
+				if len(fields) == 3 && fields[0] == "//go:linkname" && strings.HasPrefix(fields[2], "sync/atomic.") {
+					provided[strings.TrimPrefix(fields[2], "sync/atomic.")] = true
+				}
+			}
+		}
+	}
+	return provided, nil
+}
+
+// A form is one function implementing an operation class.
+type form struct {
+	name  string
+	rank  int // index of the operand type in types
+	width int
+}
+
+type byRank []form
+
+func (x byRank) Len() int           { return len(x) }
+func (x byRank) Less(i, j int) bool { return x[i].rank < x[j].rank }
+func (x byRank) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+// generate returns the sync/atomic section of the race assembly file
+// for the functions funcs.
+func generate(funcs []string) ([]byte, error) {
+	byOp := make(map[*op][]form)
+	for _, name := range funcs {
+		o, rank, width := classify(name)
+		if o == nil {
+			return nil, fmt.Errorf("cannot classify %s; add it to mkrace.go", name)
+		}
+		byOp[o] = append(byOp[o], form{name, rank, width})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(beginMarker)
+	buf.WriteString("// Code generated by go generate in sync/atomic (mkrace.go); DO NOT EDIT.\n\n")
+	for _, o := range ops {
+		forms := byOp[o]
+		if len(forms) == 0 {
+			continue
+		}
+		sort.Stable(byRank(forms))
+		fmt.Fprintf(&buf, "// %s\n", o.prefix)
+		for _, f := range forms {
+			fmt.Fprintf(&buf, "TEXT\tsync∕atomic·%s(SB), NOSPLIT, $0-0\n", f.name)
+			if f.rank < 2 {
+				fmt.Fprintf(&buf, "\tMOVQ\t$__tsan_go_atomic%d_%s(SB), AX\n", f.width, o.tsan)
+				buf.WriteString("\tCALL\tracecallatomic<>(SB)\n")
+				buf.WriteString(o.post[f.width])
+				buf.WriteString("\tRET\n\n")
+				continue
+			}
+			canon := "Int64"
+			if f.width == 32 {
+				canon = "Int32"
+			}
+			fmt.Fprintf(&buf, "\tJMP\tsync∕atomic·%s%s(SB)\n\n", o.prefix, canon)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// classify returns the operation class, the rank of the operand type
+// in types and the operand width of the function name. Unexported
+// functions such as swapUintptr are classified like the exported
+// function of the same name.
+func classify(name string) (o *op, rank, width int) {
+	name = strings.ToUpper(name[:1]) + name[1:]
+	for _, oo := range ops {
+		if !strings.HasPrefix(name, oo.prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, oo.prefix)
+		for i, t := range types {
+			if rest == t.name {
+				return oo, i, t.width
+			}
+		}
+	}
+	return nil, 0, 0
+}
@@ -0,0 +1,160 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestRaceGenerated checks that the race detector versions of the
+// operations in runtime/race_amd64.s match the declarations in this
+// package.
+func TestRaceGenerated(t *testing.T) {
+	testenv.MustHaveGoRun(t)
+	out, err := exec.Command(testenv.GoToolPath(t), "run", "mkrace.go", "-check").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run mkrace.go -check: %v\n%s", err, out)
+	}
+}
+
+// exportedAPI returns the exported functions and types of this
+// package when built with tags.
+func exportedAPI(t *testing.T, tags ...string) (funcs, types []string) {
+	ctxt := build.Default
+	ctxt.BuildTags = tags
+	pkg, err := ctxt.ImportDir(".", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.IsExported() {
+					funcs = append(funcs, d.Name.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.IsExported() {
+						types = append(types, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(funcs)
+	sort.Strings(types)
+	return funcs, types
+}
+
+const raceAPIProg = `package main
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+)
+
+var funcs = map[string]interface{}{
+{{FUNCS}}}
+
+var types = map[string]reflect.Type{
+{{TYPES}}}
+
+func main() {
+	for name, f := range funcs {
+		fmt.Println("func", name, runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name())
+	}
+	for name, t := range types {
+		for i := 0; i < t.NumMethod(); i++ {
+			fmt.Println("method", name, t.Method(i).Name, t.Method(i).Type)
+		}
+	}
+}
+`
+
+// TestRaceAPI checks that the package has the same API with and
+// without the race detector, by building and running a program that
+// uses every exported function in both configurations.
+func TestRaceAPI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode: builds the race runtime")
+	}
+	testenv.MustHaveGoRun(t)
+	switch runtime.GOOS + "/" + runtime.GOARCH {
+	case "linux/amd64", "darwin/amd64", "freebsd/amd64", "windows/amd64":
+	default:
+		t.Skipf("race detector not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	funcs, types := exportedAPI(t)
+	raceFuncs, raceTypes := exportedAPI(t, "race")
+	if !reflect.DeepEqual(funcs, raceFuncs) || !reflect.DeepEqual(types, raceTypes) {
+		t.Fatalf("exported API differs:\nwithout race: %v %v\nwith race: %v %v", funcs, types, raceFuncs, raceTypes)
+	}
+
+	var fbuf, tbuf bytes.Buffer
+	for _, name := range funcs {
+		fmt.Fprintf(&fbuf, "\t%q: atomic.%s,\n", name, name)
+	}
+	for _, name := range types {
+		fmt.Fprintf(&tbuf, "\t%q: reflect.TypeOf(new(atomic.%s)),\n", name, name)
+	}
+	src := strings.Replace(raceAPIProg, "{{FUNCS}}", fbuf.String(), 1)
+	src = strings.Replace(src, "{{TYPES}}", tbuf.String(), 1)
+
+	dir, err := ioutil.TempDir("", "atomic-race-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prog := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(prog, []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) []string {
+		args = append(append([]string{"run"}, args...), prog)
+		out, err := exec.Command(testenv.GoToolPath(t), args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		sort.Strings(lines)
+		return lines
+	}
+	plain := run()
+	race := run("-race")
+	if !reflect.DeepEqual(plain, race) {
+		t.Errorf("API differs:\nwithout race:\n%s\nwith race:\n%s", strings.Join(plain, "\n"), strings.Join(race, "\n"))
+	}
+	for _, line := range plain {
+		f := strings.Fields(line)
+		if f[0] == "func" && f[2] != "sync/atomic."+f[1] {
+			t.Errorf("atomic.%s is implemented by %s", f[1], f[2])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic
+
+// Implemented in assembly.
+func swapUintptr(addr *uintptr, new uintptr) (old uintptr)
+func compareAndSwapUintptr(addr *uintptr, old, new uintptr) (swapped bool)
+func storeUintptr(addr *uintptr, val uintptr)
+
+// Provided by runtime.
+func runtime_atomicPtrCheck() bool
+func runtime_inHeap(p uintptr) bool
+
+// ptrCheck reports whether GODEBUG=atomicptrcheck=1 is set.
+// It is read once, so that the check costs a single predictable
+// branch when it is disabled.
+var ptrCheck = runtime_atomicPtrCheck()
+
+// checkUintptr panics if v, the new value stored by the uintptr
+// operation op, points into an object allocated in the Go heap.
+//
+// The runtime consults its span metadata, so an integer that merely
+// falls within the heap's address range is accepted unless it lies
+// inside a span that holds allocated objects. Such integers are
+// still reported, which is why the check is opt-in.
+func checkUintptr(op string, v uintptr) {
+	if runtime_inHeap(v) {
+		panic("sync/atomic: " + op + " of Go heap pointer " + hex(v) + "; use the unsafe.Pointer functions to store pointers")
+	}
+}
+
+// hex returns v formatted as a hexadecimal number with a 0x prefix.
+func hex(v uintptr) string {
+	var buf [2 + 2*8]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = "0123456789abcdef"[v%16]
+		v /= 16
+		if v == 0 {
+			break
+		}
+	}
+	i -= 2
+	buf[i], buf[i+1] = '0', 'x'
+	return string(buf[i:])
+}
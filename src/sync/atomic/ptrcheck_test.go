@@ -0,0 +1,78 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atomic_test
+
+import (
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"strings"
+	. "sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+var uintptrCheckOps = map[string]func(addr *uintptr, v uintptr){
+	"StoreUintptr": StoreUintptr,
+	"SwapUintptr":  func(addr *uintptr, v uintptr) { SwapUintptr(addr, v) },
+	"CompareAndSwapUintptr": func(addr *uintptr, v uintptr) {
+		CompareAndSwapUintptr(addr, *addr, v)
+	},
+}
+
+var uintptrCheckGlobal int
+
+// TestUintptrCheckHelper is run as a subprocess by TestUintptrCheck
+// with GODEBUG=atomicptrcheck=1.
+func TestUintptrCheckHelper(t *testing.T) {
+	op := os.Getenv("GO_ATOMICPTRCHECK_OP")
+	if op == "" {
+		return
+	}
+	var x uintptr
+	f := uintptrCheckOps[op]
+	// Values that are not Go heap pointers must be accepted,
+	// including pointers to global data.
+	f(&x, 0)
+	f(&x, 1<<12)
+	f(&x, ^uintptr(0))
+	f(&x, uintptr(unsafe.Pointer(&uintptrCheckGlobal)))
+	os.Stdout.WriteString("ok\n")
+	p := new([64]byte)
+	f(&x, uintptr(unsafe.Pointer(&p[8])))
+	os.Stdout.WriteString("no panic\n")
+}
+
+func TestUintptrCheck(t *testing.T) {
+	testenv.MustHaveExec(t)
+	for op := range uintptrCheckOps {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestUintptrCheckHelper$")
+		cmd.Env = append(os.Environ(), "GODEBUG=atomicptrcheck=1", "GO_ATOMICPTRCHECK_OP="+op)
+		out, err := cmd.CombinedOutput()
+		if err == nil {
+			t.Errorf("%s: storing a heap pointer did not fail:\n%s", op, out)
+			continue
+		}
+		if !strings.HasPrefix(string(out), "ok\n") {
+			t.Errorf("%s: rejected a value that is not a heap pointer:\n%s", op, out)
+			continue
+		}
+		want := "panic: sync/atomic: " + op + " of Go heap pointer 0x"
+		if !strings.Contains(string(out), want) || !strings.Contains(string(out), "unsafe.Pointer") {
+			t.Errorf("%s: output does not contain %q:\n%s", op, want, out)
+		}
+	}
+}
+
+func TestUintptrCheckDisabled(t *testing.T) {
+	if strings.Contains(os.Getenv("GODEBUG"), "atomicptrcheck=") {
+		t.Skip("GODEBUG sets atomicptrcheck")
+	}
+	p := new([64]byte)
+	for _, f := range uintptrCheckOps {
+		var x uintptr
+		f(&x, uintptr(unsafe.Pointer(&p[8])))
+	}
+}
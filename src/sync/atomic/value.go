@@ -84,6 +84,65 @@ func (v *Value) Store(x interface{}) {
 	}
 }
 
+// CompareAndSwap executes the compare-and-swap operation for the Value:
+// if the value of the Value is equal to old, it sets it to new and
+// reports true. Otherwise it reports false. A Value on which Store
+// has not been called compares equal to a nil old.
+// All calls to CompareAndSwap and Store for a given Value must use
+// values of the same concrete type. CompareAndSwap of an inconsistent
+// type panics, as does CompareAndSwap(old, nil). It also panics if
+// the type of the values is not comparable.
+func (v *Value) CompareAndSwap(old, new interface{}) (swapped bool) {
+	if new == nil {
+		panic("sync/atomic: compare and swap of nil value into Value")
+	}
+	vp := (*ifaceWords)(unsafe.Pointer(v))
+	np := (*ifaceWords)(unsafe.Pointer(&new))
+	op := (*ifaceWords)(unsafe.Pointer(&old))
+	if op.typ != nil && op.typ != np.typ {
+		panic("sync/atomic: compare and swap of inconsistently typed values")
+	}
+	for {
+		typ := LoadPointer(&vp.typ)
+		if typ == nil {
+			if old != nil {
+				return false
+			}
+			// Attempt to start first store, as in Store.
+			runtime_procPin()
+			if !CompareAndSwapPointer(&vp.typ, nil, unsafe.Pointer(^uintptr(0))) {
+				runtime_procUnpin()
+				continue
+			}
+			StorePointer(&vp.data, np.data)
+			StorePointer(&vp.typ, np.typ)
+			runtime_procUnpin()
+			return true
+		}
+		if uintptr(typ) == ^uintptr(0) {
+			// First store in progress. Wait.
+			continue
+		}
+		if typ != np.typ {
+			panic("sync/atomic: compare and swap of inconsistently typed value into Value")
+		}
+		// Compare the current value with old, then replace it
+		// if it has not changed since. If it has, another
+		// Store or CompareAndSwap won the race: compare again.
+		data := LoadPointer(&vp.data)
+		var x interface{}
+		xp := (*ifaceWords)(unsafe.Pointer(&x))
+		xp.typ = typ
+		xp.data = data
+		if x != old {
+			return false
+		}
+		if CompareAndSwapPointer(&vp.data, data, np.data) {
+			return true
+		}
+	}
+}
+
 // Disable/enable preemption, implemented in runtime.
 func runtime_procPin()
 func runtime_procUnpin()
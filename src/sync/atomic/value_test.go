@@ -121,6 +121,103 @@ func TestValueConcurrent(t *testing.T) {
 	}
 }
 
+func TestValueCompareAndSwap(t *testing.T) {
+	var v Value
+	if v.CompareAndSwap(1, 2) {
+		t.Fatal("CompareAndSwap of empty Value with non-nil old succeeded")
+	}
+	if !v.CompareAndSwap(nil, "foo") {
+		t.Fatal("CompareAndSwap of empty Value with nil old failed")
+	}
+	if v.CompareAndSwap(nil, "bar") {
+		t.Fatal("CompareAndSwap with nil old succeeded after first store")
+	}
+	if v.CompareAndSwap("bar", "baz") {
+		t.Fatal("CompareAndSwap with wrong old succeeded")
+	}
+	// Equal strings with different data pointers compare equal.
+	if !v.CompareAndSwap(string([]byte("foo")), "barbaz") {
+		t.Fatal("CompareAndSwap with equal old failed")
+	}
+	if x := v.Load(); x != "barbaz" {
+		t.Fatalf("wrong value: got %+v, want barbaz", x)
+	}
+}
+
+func TestValueCompareAndSwapPanic(t *testing.T) {
+	const nilErr = "sync/atomic: compare and swap of nil value into Value"
+	const typesErr = "sync/atomic: compare and swap of inconsistently typed values"
+	const badErr = "sync/atomic: compare and swap of inconsistently typed value into Value"
+	var v Value
+	expectPanic := func(want string, f func()) {
+		defer func() {
+			err := recover()
+			if err != want {
+				t.Fatalf("inconsistent compare and swap panic: got '%v', want '%v'", err, want)
+			}
+		}()
+		f()
+	}
+	expectPanic(nilErr, func() { v.CompareAndSwap(nil, nil) })
+	expectPanic(typesErr, func() { v.CompareAndSwap(42, "foo") })
+	v.Store(42)
+	expectPanic(badErr, func() { v.CompareAndSwap("foo", "bar") })
+	expectPanic(badErr, func() { v.CompareAndSwap(nil, "bar") })
+	expectPanic(nilErr, func() { v.CompareAndSwap(42, nil) })
+}
+
+func TestValueCompareAndSwapConcurrent(t *testing.T) {
+	p := 4 * runtime.GOMAXPROCS(0)
+	N := int(1e4)
+	if testing.Short() {
+		p /= 2
+		N = 1e2
+	}
+	// Exactly one goroutine wins the first store.
+	var v Value
+	won := make(chan int, p)
+	var wg sync.WaitGroup
+	for i := 0; i < p; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if v.CompareAndSwap(nil, i) {
+				won <- i
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(won)
+	var winners []int
+	for i := range won {
+		winners = append(winners, i)
+	}
+	if len(winners) != 1 || v.Load() != winners[0] {
+		t.Fatalf("first store winners %v, Value holds %v", winners, v.Load())
+	}
+
+	// Read-modify-write loops lose no updates.
+	v.Store(0)
+	for i := 0; i < p; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < N; j++ {
+				for {
+					x := v.Load().(int)
+					if v.CompareAndSwap(x, x+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if x := v.Load().(int); x != p*N {
+		t.Fatalf("got %v after %v increments, want %v", x, p*N, p*N)
+	}
+}
+
 func BenchmarkValueRead(b *testing.B) {
 	var v Value
 	v.Store(new(int))
@@ -0,0 +1,107 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sync
+
+import (
+	"sync/atomic"
+)
+
+// COWMap is a copy-on-write map for read-mostly registries.
+// The zero COWMap is empty and ready for use.
+//
+// Load and Range read an immutable snapshot of the map published through
+// an atomic.Value, so they take no locks and scale with the number of
+// readers. Store and Delete serialize on an internal Mutex, copy the
+// whole current map, change the copy and publish it. Each write thus
+// costs O(n) time and allocates a new map of n entries, while the old
+// snapshot stays alive until no reader refers to it. COWMap is meant for
+// small maps, up to a few hundred entries, that are read far more often
+// than they are written, such as registries set up during
+// initialization. Larger or frequently written maps are better served by
+// a map guarded by a Mutex or RWMutex.
+//
+// A COWMap must not be copied after first use.
+
+// COWMap 是为读多写少的注册表而设计的写时复制映射。
+// COWMap 的零值为空映射，可直接使用。
+//
+// Load 和 Range 读取的是通过 atomic.Value 发布的映射的不可变快照，
+// 因此它们不会获取任何锁，并会随读取者的数量而扩展。Store 和 Delete
+// 通过内部的 Mutex 串行执行，它们会复制当前的整个映射，修改该副本并将其发布。
+// 因此每次写入都需要 O(n) 的时间，并会分配一个包含 n 个条目的新映射，
+// 而旧的快照会一直存活，直到不再有读取者引用它为止。COWMap 适用于
+// 读取远多于写入的小型映射（最多几百个条目），例如在初始化期间建立的注册表。
+// 较大或写入频繁的映射更适合使用由 Mutex 或 RWMutex 保护的映射。
+//
+// COWMap 在第一次使用后必须不能被复制。
+type COWMap struct {
+	mu Mutex        // serializes writers // 串行化写入者
+	m  atomic.Value // map[interface{}]interface{}, never modified once stored // 一经存储就不再修改
+}
+
+// load returns the current snapshot of the map, which may be nil.
+func (m *COWMap) load() map[interface{}]interface{} {
+	snap, _ := m.m.Load().(map[interface{}]interface{})
+	return snap
+}
+
+// Load returns the value stored in the map for key, and whether a value
+// was present. It takes no locks.
+
+// Load 返回映射中为 key 存储的值，以及该值是否存在。它不会获取任何锁。
+func (m *COWMap) Load(key interface{}) (value interface{}, ok bool) {
+	value, ok = m.load()[key]
+	return
+}
+
+// Store sets the value for key. It copies the whole map.
+
+// Store 为 key 设置值。它会复制整个映射。
+func (m *COWMap) Store(key, value interface{}) {
+	m.mu.Lock()
+	old := m.load()
+	snap := make(map[interface{}]interface{}, len(old)+1)
+	for k, v := range old {
+		snap[k] = v
+	}
+	snap[key] = value
+	m.m.Store(snap)
+	m.mu.Unlock()
+}
+
+// Delete removes the value for key, if any. It copies the whole map
+// when key is present.
+
+// Delete 删除 key 的值（若存在）。当 key 存在时，它会复制整个映射。
+func (m *COWMap) Delete(key interface{}) {
+	m.mu.Lock()
+	old := m.load()
+	if _, ok := old[key]; ok {
+		snap := make(map[interface{}]interface{}, len(old)-1)
+		for k, v := range old {
+			if k != key {
+				snap[k] = v
+			}
+		}
+		m.m.Store(snap)
+	}
+	m.mu.Unlock()
+}
+
+// Range calls f for each key and value in a snapshot of the map, in
+// unspecified order, until f returns false. Range takes no locks, so f may
+// call the other methods of m; changes it makes are not seen by the
+// iteration in progress.
+
+// Range 以未指定的顺序，对映射快照中的每个键和值调用 f，直到 f 返回 false 为止。
+// Range 不会获取任何锁，因此 f 可以调用 m 的其它方法；它所做的更改不会被
+// 正在进行的迭代看到。
+func (m *COWMap) Range(f func(key, value interface{}) bool) {
+	for k, v := range m.load() {
+		if !f(k, v) {
+			break
+		}
+	}
+}
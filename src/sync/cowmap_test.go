@@ -0,0 +1,218 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sync_test
+
+import (
+	"runtime"
+	. "sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCOWMap(t *testing.T) {
+	var m COWMap
+	if v, ok := m.Load("a"); ok || v != nil {
+		t.Fatalf("Load of empty map = %v, %v; want nil, false", v, ok)
+	}
+	m.Delete("a")
+	m.Range(func(k, v interface{}) bool {
+		t.Fatalf("Range of empty map called f(%v, %v)", k, v)
+		return true
+	})
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("a", 3)
+	if v, ok := m.Load("a"); !ok || v != 3 {
+		t.Errorf("Load(a) = %v, %v; want 3, true", v, ok)
+	}
+	if v, ok := m.Load("b"); !ok || v != 2 {
+		t.Errorf("Load(b) = %v, %v; want 2, true", v, ok)
+	}
+	m.Delete("b")
+	m.Delete("c")
+	if v, ok := m.Load("b"); ok {
+		t.Errorf("Load(b) after Delete = %v, true; want nil, false", v)
+	}
+
+	// Range sees a snapshot, so f can change the map.
+	m.Store("b", 2)
+	seen := make(map[interface{}]interface{})
+	m.Range(func(k, v interface{}) bool {
+		seen[k] = v
+		m.Store(k.(string)+k.(string), v)
+		return true
+	})
+	if len(seen) != 2 || seen["a"] != 3 || seen["b"] != 2 {
+		t.Errorf("Range saw %v, want map[a:3 b:2]", seen)
+	}
+	if v, ok := m.Load("aa"); !ok || v != 3 {
+		t.Errorf("Load(aa) = %v, %v; want 3, true", v, ok)
+	}
+
+	n := 0
+	m.Range(func(k, v interface{}) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Range called f %d times after it returned false, want 1", n)
+	}
+}
+
+// TestCOWMapSnapshots checks that concurrent readers see consistent
+// snapshots while a writer keeps changing the map. The writer keeps the
+// map holding exactly the keys lo, lo+1, ..., hi-1 mapped to themselves,
+// moving the window by storing hi and deleting lo, so every snapshot
+// must be such a window of at most window+1 keys.
+func TestCOWMapSnapshots(t *testing.T) {
+	const window = 16
+	writes := 10000
+	if testing.Short() {
+		writes = 1000
+	}
+	var m COWMap
+	var done int32
+	var wg WaitGroup
+	readers := runtime.GOMAXPROCS(0)
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&done) == 0 {
+				lo, hi, n := -1, -1, 0
+				m.Range(func(k, v interface{}) bool {
+					i := k.(int)
+					if v.(int) != i {
+						t.Errorf("snapshot maps %d to %v", i, v)
+					}
+					if lo < 0 || i < lo {
+						lo = i
+					}
+					if i > hi {
+						hi = i
+					}
+					n++
+					return true
+				})
+				if n > 0 && (hi-lo+1 != n || n > window+1) {
+					t.Errorf("inconsistent snapshot: %d keys in [%d, %d]", n, lo, hi)
+					return
+				}
+				// The snapshot of a single Load is consistent too.
+				if n > 0 {
+					if v, ok := m.Load(hi); ok && v.(int) != hi {
+						t.Errorf("Load(%d) = %v", hi, v)
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < writes; i++ {
+		m.Store(i, i)
+		if i >= window {
+			m.Delete(i - window)
+		}
+	}
+	atomic.StoreInt32(&done, 1)
+	wg.Wait()
+}
+
+// benchMap is the interface shared by COWMap and the maps it is
+// benchmarked against.
+type benchMap interface {
+	Load(key interface{}) (interface{}, bool)
+	Store(key, value interface{})
+}
+
+// mutexMap is a map guarded by a Mutex.
+type mutexMap struct {
+	mu Mutex
+	m  map[interface{}]interface{}
+}
+
+func (m *mutexMap) Load(key interface{}) (interface{}, bool) {
+	m.mu.Lock()
+	v, ok := m.m[key]
+	m.mu.Unlock()
+	return v, ok
+}
+
+func (m *mutexMap) Store(key, value interface{}) {
+	m.mu.Lock()
+	if m.m == nil {
+		m.m = make(map[interface{}]interface{})
+	}
+	m.m[key] = value
+	m.mu.Unlock()
+}
+
+// rwMutexMap is a map guarded by an RWMutex, the usual choice for
+// read-mostly maps.
+type rwMutexMap struct {
+	mu RWMutex
+	m  map[interface{}]interface{}
+}
+
+func (m *rwMutexMap) Load(key interface{}) (interface{}, bool) {
+	m.mu.RLock()
+	v, ok := m.m[key]
+	m.mu.RUnlock()
+	return v, ok
+}
+
+func (m *rwMutexMap) Store(key, value interface{}) {
+	m.mu.Lock()
+	if m.m == nil {
+		m.m = make(map[interface{}]interface{})
+	}
+	m.m[key] = value
+	m.mu.Unlock()
+}
+
+// benchmarkMap runs parallel operations on a map of 64 entries,
+// one in every writeEvery of which is a Store and the others Loads.
+func benchmarkMap(b *testing.B, m benchMap, writeEvery int) {
+	const size = 64
+	for i := 0; i < size; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			if i%writeEvery == 0 {
+				m.Store(i%size, i)
+			} else {
+				m.Load(i % size)
+			}
+		}
+	})
+}
+
+func BenchmarkCOWMapRead95(b *testing.B) {
+	benchmarkMap(b, new(COWMap), 20)
+}
+
+func BenchmarkMutexMapRead95(b *testing.B) {
+	benchmarkMap(b, new(mutexMap), 20)
+}
+
+func BenchmarkRWMutexMapRead95(b *testing.B) {
+	benchmarkMap(b, new(rwMutexMap), 20)
+}
+
+func BenchmarkCOWMapRead999(b *testing.B) {
+	benchmarkMap(b, new(COWMap), 1000)
+}
+
+func BenchmarkMutexMapRead999(b *testing.B) {
+	benchmarkMap(b, new(mutexMap), 1000)
+}
+
+func BenchmarkRWMutexMapRead999(b *testing.B) {
+	benchmarkMap(b, new(rwMutexMap), 1000)
+}
@@ -7,6 +7,7 @@ package sync_test
 import (
 	"fmt"
 	"sync"
+	"unsafe"
 )
 
 type httpPkg struct{}
@@ -63,3 +64,38 @@ func ExampleOnce() {
 	// Output:
 	// Only once
 }
+
+type Config struct {
+	Name string
+}
+
+var (
+	configOnce sync.OncePointer
+	configInit int
+)
+
+// config returns the shared configuration, loading it on first use.
+// 返回共享的配置，在第一次使用时加载它。
+func config() *Config {
+	return (*Config)(configOnce.Do(func() unsafe.Pointer {
+		configInit++
+		return unsafe.Pointer(&Config{Name: "example"})
+	}))
+}
+
+// This example wraps a OncePointer in a function that returns a typed
+// pointer, initialized exactly once even with concurrent callers.
+func ExampleOncePointer() {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config()
+		}()
+	}
+	wg.Wait()
+	fmt.Println(config().Name, configInit)
+	// Output:
+	// example 1
+}
@@ -6,6 +6,7 @@ package sync
 
 import (
 	"sync/atomic"
+	"unsafe"
 )
 
 // Once is an object that will perform exactly one action.
@@ -62,3 +63,72 @@ func (o *Once) Do(f func()) {
 		f()
 	}
 }
+
+// OncePointer is a Once whose action computes a pointer, for the
+// common pattern of initializing a shared value exactly once on first
+// use. Callers after the first receive the pointer without taking a
+// lock, and see the memory it points to as the action left it.
+// Wrap it in a function that converts the result to its real type:
+//
+//	var cfg sync.OncePointer
+//
+//	func config() *Config {
+//		return (*Config)(cfg.Do(func() unsafe.Pointer {
+//			return unsafe.Pointer(loadConfig())
+//		}))
+//	}
+//
+// A OncePointer must not be copied after first use.
+
+// OncePointer 是其动作会计算出一个指针的 Once，用于在第一次使用时
+// 刚好初始化一次共享值的常见模式。第一次之后的调用者无需获取锁即可得到该指针，
+// 并能看到该动作所留下的、由该指针指向的内存。
+// 可将它包装在一个将结果转换为实际类型的函数中：
+//
+//	var cfg sync.OncePointer
+//
+//	func config() *Config {
+//		return (*Config)(cfg.Do(func() unsafe.Pointer {
+//			return unsafe.Pointer(loadConfig())
+//		}))
+//	}
+//
+// OncePointer 在第一次使用后必须不能被复制。
+type OncePointer struct {
+	m    Mutex
+	done uint32
+	p    unsafe.Pointer // written once, before done is set // 仅在设置 done 之前写入一次
+}
+
+// Do calls the function f if and only if Do is being called for the
+// first time for this instance of OncePointer, and returns the pointer
+// returned by that call of f. Like Once.Do, no call to Do returns until
+// the one call to f returns, so if f causes Do to be called, it will
+// deadlock.
+//
+// If f panics, Do considers it to have returned nil; future calls of Do
+// return nil without calling f.
+
+// Do 方法当且仅当连同此 OncePointer 实例第一次被调用时才执行函数 f，
+// 并返回该次 f 调用所返回的指针。与 Once.Do 一样，在 f 的调用返回之前
+// 没有 Do 的调用会返回，因此若 f 引起了 Do 的调用，它就会死锁。
+//
+// 若 f 发生派错（panic），Do 会认为它返回了 nil；将来对 Do 的调用会直接返回 nil 而不调用 f。
+func (o *OncePointer) Do(f func() unsafe.Pointer) unsafe.Pointer {
+	// The atomic load of done pairs with the atomic store below,
+	// so o.p and what it points to are visible once done is 1.
+	// 对 done 的原子加载与下面的原子存储配对，
+	// 因此一旦 done 为 1，o.p 及其指向的内容就是可见的。
+	if atomic.LoadUint32(&o.done) == 1 {
+		return o.p
+	}
+	// Slow-path.
+	// 慢速通道。
+	o.m.Lock()
+	defer o.m.Unlock()
+	if o.done == 0 {
+		defer atomic.StoreUint32(&o.done, 1)
+		o.p = f()
+	}
+	return o.p
+}
@@ -6,7 +6,9 @@ package sync_test
 
 import (
 	. "sync"
+	"sync/atomic"
 	"testing"
+	"unsafe"
 )
 
 type one int
@@ -66,3 +68,107 @@ func BenchmarkOnce(b *testing.B) {
 		}
 	})
 }
+
+func TestOncePointer(t *testing.T) {
+	var once OncePointer
+	var calls int32
+	init := func() unsafe.Pointer {
+		atomic.AddInt32(&calls, 1)
+		v := new([16]int)
+		for i := range v {
+			v[i] = i
+		}
+		return unsafe.Pointer(v)
+	}
+	const N = 10
+	start := make(chan bool)
+	c := make(chan *[16]int)
+	for i := 0; i < N; i++ {
+		go func() {
+			<-start
+			c <- (*[16]int)(once.Do(init))
+		}()
+	}
+	close(start)
+	first := <-c
+	for i := 1; i < N; i++ {
+		if v := <-c; v != first {
+			t.Errorf("OncePointer.Do returned %p and %p", v, first)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("OncePointer.Do called f %d times, want 1", calls)
+	}
+	for i, x := range first {
+		if x != i {
+			t.Fatalf("value[%d] = %d, want %d", i, x, i)
+		}
+	}
+}
+
+func TestOncePointerPanic(t *testing.T) {
+	var once OncePointer
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("OncePointer.Do did not panic")
+			}
+		}()
+		once.Do(func() unsafe.Pointer {
+			panic("failed")
+		})
+	}()
+
+	p := once.Do(func() unsafe.Pointer {
+		t.Fatalf("OncePointer.Do called twice")
+		return nil
+	})
+	if p != nil {
+		t.Errorf("OncePointer.Do after panic = %p, want nil", p)
+	}
+}
+
+func BenchmarkOncePointer(b *testing.B) {
+	var once OncePointer
+	v := new(int)
+	f := func() unsafe.Pointer { return unsafe.Pointer(v) }
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if once.Do(f) == nil {
+				b.Fatal("nil")
+			}
+		}
+	})
+}
+
+// BenchmarkOnceAndVar is the hand-written equivalent of
+// BenchmarkOncePointer with a Once and a variable.
+func BenchmarkOnceAndVar(b *testing.B) {
+	var once Once
+	var p *int
+	f := func() { p = new(int) }
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			once.Do(f)
+			if p == nil {
+				b.Fatal("nil")
+			}
+		}
+	})
+}
+
+// BenchmarkOnceAndValue is the hand-written equivalent of
+// BenchmarkOncePointer with a Once and an atomic.Value.
+func BenchmarkOnceAndValue(b *testing.B) {
+	var once Once
+	var v atomic.Value
+	f := func() { v.Store(new(int)) }
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			once.Do(f)
+			if v.Load().(*int) == nil {
+				b.Fatal("nil")
+			}
+		}
+	})
+}
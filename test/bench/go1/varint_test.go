@@ -0,0 +1,65 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This benchmark tests the elimination of the bounds checks of
+// consecutive accesses to a slice, in a decoder of varints.
+
+package go1
+
+import "testing"
+
+var varintData = makeVarintData(1 << 16)
+
+// makeVarintData returns the encodings of n varints of 1 to 4 bytes,
+// followed by 3 bytes of padding so that decodeVarint can load 4 bytes
+// at the start of each of them.
+func makeVarintData(n int) []byte {
+	var buf []byte
+	r := uint32(1)
+	for i := 0; i < n; i++ {
+		r = r*1103515245 + 12345
+		x := r >> 4 >> (r % 28)
+		for ; x >= 0x80; x >>= 7 {
+			buf = append(buf, byte(x)|0x80)
+		}
+		buf = append(buf, byte(x))
+	}
+	return append(buf, 0, 0, 0)
+}
+
+// decodeVarint decodes the varint at the start of b, which holds at
+// least 4 bytes, and returns it and its length. Its 4 loads need a
+// single bounds check, that of b[3].
+func decodeVarint(b []byte) (uint32, int) {
+	b0, b1, b2, b3 := uint32(b[0]), uint32(b[1]), uint32(b[2]), uint32(b[3])
+	if b0 < 0x80 {
+		return b0, 1
+	}
+	if b1 < 0x80 {
+		return b0&0x7f | b1<<7, 2
+	}
+	if b2 < 0x80 {
+		return b0&0x7f | (b1&0x7f)<<7 | b2<<14, 3
+	}
+	return b0&0x7f | (b1&0x7f)<<7 | (b2&0x7f)<<14 | b3<<21, 4
+}
+
+func decodeVarints(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i+4 <= len(data); {
+		x, n := decodeVarint(data[i:])
+		sum += x
+		i += n
+	}
+	return sum
+}
+
+var varintSum uint32
+
+func BenchmarkVarintDecode(b *testing.B) {
+	b.SetBytes(int64(len(varintData)))
+	for i := 0; i < b.N; i++ {
+		varintSum = decodeVarints(varintData)
+	}
+}
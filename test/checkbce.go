@@ -102,6 +102,38 @@ func g4(a [100]int) {
 	}
 }
 
+func g5(a []byte) uint32 {
+	return uint32(a[0]) | // ERROR "Found IsInBounds$"
+		uint32(a[1])<<8 |
+		uint32(a[2])<<16 |
+		uint32(a[3])<<24
+}
+
+func g6(a []byte, i int) uint32 {
+	if i < 0 {
+		return 0
+	}
+	return uint32(a[i]) | // ERROR "Found IsInBounds$"
+		uint32(a[i+1])<<8 |
+		uint32(a[i+2])<<16
+}
+
+// decodeVarint4 decodes a varint of at most 4 bytes,
+// loading all of them before looking at the continuation bits.
+func decodeVarint4(b []byte) (uint32, int) {
+	b0, b1, b2, b3 := uint32(b[0]), uint32(b[1]), uint32(b[2]), uint32(b[3]) // ERROR "Found IsInBounds$"
+	if b0 < 0x80 {
+		return b0, 1
+	}
+	if b1 < 0x80 {
+		return b0&0x7f | b1<<7, 2
+	}
+	if b2 < 0x80 {
+		return b0&0x7f | (b1&0x7f)<<7 | b2<<14, 3
+	}
+	return b0&0x7f | (b1&0x7f)<<7 | (b2&0x7f)<<14 | b3<<21, 4
+}
+
 //go:noinline
 func useInt(a int) {
 }
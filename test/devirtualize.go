@@ -0,0 +1,96 @@
+// errorcheck -0 -m -l
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that interface method calls are devirtualized when the
+// dynamic type of the receiver is known, and that escape analysis
+// then sees the method called.
+
+package p
+
+type M interface {
+	M() int
+}
+
+type MN interface {
+	M
+	N()
+}
+
+type T struct{ x int }
+
+func (t *T) M() int { return t.x } // ERROR "\(\*T\).M t does not escape"
+func (t *T) N()     {}             // ERROR "\(\*T\).N t does not escape"
+
+type V int
+
+func (v V) M() int { return int(v) }
+
+type E struct{ MN }
+
+func assigned() int {
+	var t T
+	var m M = &t // ERROR "&t does not escape"
+	return m.M() // ERROR "devirtualizing m.M to \*T"
+}
+
+func converted() int {
+	var t T
+	return M(&t).M() // ERROR "devirtualizing M\(&t\).M to \*T" "&t does not escape" "M\(&t\) does not escape"
+}
+
+func branches(b bool) int {
+	var m M
+	if b {
+		m = &T{x: 1} // ERROR "&T literal does not escape"
+	} else {
+		m = &T{x: 2} // ERROR "&T literal does not escape"
+	}
+	return m.M() // ERROR "devirtualizing m.M to \*T"
+}
+
+func chained() int {
+	var t T
+	var mn MN = &t // ERROR "&t does not escape"
+	m := M(mn)     // ERROR "M\(mn\) does not escape"
+	mn.N()         // ERROR "devirtualizing mn.N to \*T"
+	return m.M()   // ERROR "devirtualizing m.M to \*T"
+}
+
+func multiple(b bool) int {
+	var m M = &T{} // ERROR "&T literal escapes to heap"
+	if b {
+		m = V(1) // ERROR "V\(1\) escapes to heap"
+	}
+	return m.M()
+}
+
+func param(m M) int { // ERROR "leaking param: m"
+	return m.M()
+}
+
+func addrtaken(f func(*M)) int { // ERROR "f does not escape"
+	var m M = &T{} // ERROR "moved to heap: m" "&T literal escapes to heap"
+	f(&m)          // ERROR "&m escapes to heap"
+	return m.M()
+}
+
+func captured() int {
+	var m M = &T{} // ERROR "&T literal escapes to heap"
+	func() {       // ERROR "func literal does not escape"
+		m = V(2) // ERROR "V\(2\) escapes to heap"
+	}()
+	return m.M()
+}
+
+func nonpointer() int {
+	var m M = V(3) // ERROR "V\(3\) escapes to heap"
+	return m.M()
+}
+
+func embedded() int {
+	var m M = &E{&T{}} // ERROR "&E literal escapes to heap" "&T literal escapes to heap"
+	return m.M()
+}
@@ -0,0 +1,49 @@
+// run
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that a devirtualized call through a nil interface panics
+// with a nil pointer dereference, as the interface call would.
+
+package main
+
+import (
+	"runtime"
+	"strings"
+)
+
+type M interface {
+	M() int
+}
+
+type T struct{ x int }
+
+func (t *T) M() int { return t.x }
+
+//go:noinline
+func call(b bool, x int) int {
+	var m M
+	if b {
+		m = &T{x: x}
+	}
+	return m.M()
+}
+
+func main() {
+	if got := call(true, 7); got != 7 {
+		panic(got)
+	}
+
+	func() {
+		defer func() {
+			err, ok := recover().(runtime.Error)
+			if !ok || !strings.Contains(err.Error(), "nil pointer dereference") {
+				panic(err)
+			}
+		}()
+		call(false, 0)
+		panic("no panic calling method of nil interface")
+	}()
+}
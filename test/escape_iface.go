@@ -194,7 +194,7 @@ func efaceEscape2() {
 		v := &M2{&i} // ERROR "&i escapes to heap" "&M2 literal escapes to heap"
 		// BAD: v does not escape to heap here
 		var x M = v // ERROR "v escapes to heap"
-		x.M()
+		x.M()       // ERROR "devirtualizing x.M to \*M2"
 	}
 	{
 		i := 0       // ERROR "moved to heap: i"
@@ -0,0 +1,42 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+// Count does not retain args.
+func Count(args ...interface{}) int { // ERROR "Count args does not escape"
+	n := 0
+	for _, x := range args {
+		if x != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Sum does not retain args.
+func Sum(args ...interface{}) int { // ERROR "Sum args does not escape"
+	n := 0
+	for _, x := range args {
+		switch v := x.(type) {
+		case int:
+			n += v
+		case string:
+			n += len(v)
+		}
+	}
+	return n
+}
+
+// Logf passes args only to other non-retaining functions.
+func Logf(format string, args ...interface{}) int { // ERROR "Logf format does not escape" "Logf args does not escape"
+	return len(format) + Sum(args...) + Count(args...)
+}
+
+var Sink []interface{}
+
+// Keep retains args.
+func Keep(args ...interface{}) { // ERROR "leaking param: args"
+	Sink = args
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"./a"
+	"runtime"
+)
+
+func logf(x, y int, s string) int { // ERROR "logf s does not escape"
+	return a.Logf("%d %d %s", x, y, s) // ERROR "logf x does not escape" "logf y does not escape" "logf s does not escape" "logf ... argument does not escape"
+}
+
+func keep(x, y int, s string) { // ERROR "leaking param: s"
+	a.Keep(x, y, s) // ERROR "... argument escapes to heap" "x escapes to heap" "y escapes to heap" "s escapes to heap"
+}
+
+func main() {
+	s := "hello"
+	logf(1, 2, s)
+	var m0, m1 runtime.MemStats
+	runtime.ReadMemStats(&m0) // ERROR "main &m0 does not escape"
+	for i := 0; i < 100; i++ {
+		if n := logf(i, 2, s); n != i+2+5+len("%d %d %s")+3 {
+			panic("bad result")
+		}
+	}
+	runtime.ReadMemStats(&m1) // ERROR "main &m1 does not escape"
+	if n := m1.Mallocs - m0.Mallocs; n != 0 {
+		println("logf allocated", n, "times")
+		panic("fail")
+	}
+	keep(1, 2, s)
+	if len(a.Sink) != 3 {
+		panic("keep did not retain its arguments")
+	}
+}
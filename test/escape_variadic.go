@@ -0,0 +1,12 @@
+// errorcheckandrundir -0 -m -l
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that escape analysis summaries of variadic ...interface{}
+// parameters are recorded in export data and used by callers in
+// other packages, so that calls to non-retaining helpers do not
+// allocate their arguments or the backing array of the ... slice.
+
+package ignored
@@ -0,0 +1,28 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+func ForEach(s []int, f func(i, x int)) { // ERROR "can inline ForEach" "ForEach s does not escape" "ForEach f does not escape"
+	for i := 0; i < len(s); i++ {
+		f(i, s[i])
+	}
+}
+
+func Find(s []int, f func(int) bool) int { // ERROR "can inline Find" "Find s does not escape" "Find f does not escape"
+	for i := 0; i < len(s); i++ {
+		if f(s[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func Sum(s []int) int { // ERROR "Sum s does not escape"
+	n := 0
+	for _, x := range s {
+		n += x
+	}
+	return n
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "./a"
+
+func main() {
+	s := []int{1, 2, 3, 4, 5} // ERROR "\[\]int literal does not escape"
+
+	// Captured by reference.
+	sum := 0
+	a.ForEach(s, func(i, x int) { sum += x * i }) // ERROR "inlining call to a.ForEach" "inlining call to main.func1" "can inline main.func1" "func literal does not escape"
+	if sum != 40 {
+		panic(sum)
+	}
+
+	// Captured by value.
+	k := 3
+	if i := a.Find(s, func(x int) bool { return x > k }); i != 3 { // ERROR "inlining call to a.Find" "inlining call to main.func2" "can inline main.func2" "func literal does not escape"
+		panic(i)
+	}
+
+	// Without a func literal to specialize, the loop is not inlined.
+	var f func(int) bool = func(x int) bool { return x == 2 } // ERROR "can inline main.func3" "func literal does not escape"
+	if i := a.Find(s, f); i != 1 {
+		panic(i)
+	}
+	if i := a.Find(s, func(x int) bool { return x > 10 }); i != -1 { // ERROR "inlining call to a.Find" "inlining call to main.func4" "can inline main.func4" "func literal does not escape"
+		panic(i)
+	}
+
+	// Functions with loops and no callbacks are not inlined.
+	if n := a.Sum(s); n != 15 {
+		panic(n)
+	}
+}
@@ -0,0 +1,12 @@
+// errorcheckandrundir -0 -m
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that function literals passed as callbacks to small functions
+// of another package are inlined along with the call that receives
+// them, and that the specialized code still computes the right
+// results.
+
+package ignored
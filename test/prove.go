@@ -438,6 +438,85 @@ func f13i(a uint) int {
 	return 3
 }
 
+// Consecutive accesses at increasing offsets are checked once,
+// against the largest offset.
+func f14a(a []byte) uint32 {
+	return uint32(a[0]) |
+		uint32(a[1])<<8 | // ERROR "Fused IsInBounds$"
+		uint32(a[2])<<16 | // ERROR "Fused IsInBounds$"
+		uint32(a[3])<<24 // ERROR "Fused IsInBounds$"
+}
+
+func f14b(a []byte, i int) uint32 {
+	if i < 0 {
+		return 0
+	}
+	x := uint32(a[i+1])
+	y := uint32(a[i]) // ERROR "Fused IsInBounds$"
+	z := uint32(a[i+2]) // ERROR "Fused IsInBounds$"
+	return x | y<<8 | z<<16
+}
+
+func f14c(a []byte) {
+	for i := range a {
+		x := int(a[i+1])
+		y := int(a[i+2]) // ERROR "Fused IsInBounds$"
+		useInt(x + y)
+	}
+}
+
+// i may be negative, so i+2 < len(a) does not imply that i is in bounds.
+func f14d(a []byte, i int) uint32 {
+	return uint32(a[i]) |
+		uint32(a[i+1])<<8 |
+		uint32(a[i+2])<<16
+}
+
+// A store must happen before the following check fails.
+func f14e(a []byte) {
+	a[0] = 1
+	a[1] = 2
+}
+
+// A function call must happen before the following check fails.
+func f14f(a []byte) {
+	useInt(int(a[0]))
+	useInt(int(a[1]))
+}
+
+// Facts from an early length check apply in the rest of the
+// function, across merges and loops.
+func f15a(a []byte, x bool) int {
+	if len(a) < 4 {
+		return 0
+	}
+	n := 0
+	if x {
+		n = int(a[0]) // ERROR "Proved non-negative bounds IsInBounds$"
+	}
+	for i := 0; i < n; i++ {
+		n += i
+	}
+	useInt(n)
+	return int(a[3]) + // ERROR "Proved non-negative bounds IsInBounds$"
+		int(a[2]) // ERROR "Proved IsInBounds$"
+}
+
+func f15b(a string) int {
+	if 4 > len(a) {
+		panic("short")
+	}
+	return int(a[3]) + // ERROR "Proved non-negative bounds IsInBounds$"
+		int(a[1]) // ERROR "Proved IsInBounds$"
+}
+
+func f15c(a []byte) int {
+	if len(a) != 4 {
+		return 0
+	}
+	return int(a[3]) // ERROR "Proved IsInBounds$"
+}
+
 //go:noinline
 func useInt(a int) {
 }
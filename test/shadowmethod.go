@@ -0,0 +1,77 @@
+// errorcheck -0 -d=shadowmethod
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the warnings for methods that shadow promoted
+// methods with a different signature.
+
+package p
+
+type Stringer interface {
+	String() string
+}
+
+type Base struct{}
+
+func (Base) Len() int        { return 0 }
+func (*Base) Reset()         {}
+func (Base) Get(i int) int   { return i }
+func (Base) Close() error    { return nil }
+func (Base) Name() string    { return "" }
+func (Base) Write(p []byte)  {}
+func (Base) Do(f ...func())  {}
+func (Base) Unrelated() bool { return false }
+
+// Interface-embedded provider.
+type I struct {
+	Stringer
+}
+
+func (I) String() []byte { return nil } // ERROR "method I.String shadows I.Stringer.String with a different signature"
+
+// Struct-embedded provider.
+type S struct {
+	Base
+}
+
+func (S) Len() int64           { return 0 } // ERROR "method S.Len shadows S.Base.Len with a different signature\n\thave Len\(\) int64\n\tpromoted Len\(\) int at "
+func (*S) Reset()              {}           // same signature: intentional wrapper
+func (s *S) Get(i int) int     { return s.Base.Get(i) }
+func (S) Write(p []byte) error { return nil } // ERROR "S.Write shadows S.Base.Write"
+func (S) Do(f []func())        {}             // ERROR "S.Do shadows S.Base.Do"
+func (S) Other()               {}
+
+// Pointer-embedded provider.
+type P struct {
+	*Base
+}
+
+func (*P) Reset(force bool) {} // ERROR "method P.Reset shadows P.Base.Reset with a different signature"
+func (P) Close() error      { return nil }
+
+// Methods promoted through more than one level.
+type D struct {
+	S
+}
+
+func (D) Name() []byte { return nil } // ERROR "method D.Name shadows D.S.Name with a different signature"
+func (D) Len() int64   { return 0 }   // same signature as S.Len, which shadows Base.Len
+
+// Ambiguous selectors promote nothing.
+type Other struct{}
+
+func (Other) Unrelated() bool { return true }
+
+type A struct {
+	Base
+	Other
+}
+
+func (A) Unrelated() int { return 0 }
+
+// Non-struct types have no promoted methods.
+type N int
+
+func (N) Len() int64 { return 0 }
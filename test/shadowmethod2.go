@@ -0,0 +1,28 @@
+// errorcheck -d=shadowmethod=2
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that -d=shadowmethod=2 reports shadowing methods as errors.
+
+package p
+
+type Base struct{}
+
+func (Base) Len() int { return 0 }
+func (*Base) Reset()  {}
+
+type S struct {
+	Base
+}
+
+func (S) Len() int64 { return 0 } // ERROR "method S.Len shadows S.Base.Len with a different signature"
+func (*S) Reset()    {}
+
+type P struct {
+	*Base
+}
+
+func (P) Len() int          { return 0 }
+func (*P) Reset(force bool) {} // ERROR "method P.Reset shadows P.Base.Reset with a different signature"
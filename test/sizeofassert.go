@@ -0,0 +1,49 @@
+// errorcheck
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Verify that failed compile-time assertions on unsafe.Sizeof,
+// Alignof and Offsetof report the measured and expected values.
+// Does not compile.
+
+package p
+
+import "unsafe"
+
+type Small struct {
+	a [7]int32
+}
+
+type Big struct {
+	a [9]int32
+}
+
+type Exact struct {
+	a [8]int32
+}
+
+type Pair struct {
+	x int32
+	y int32
+}
+
+// Constant declarations.
+const _ = unsafe.Sizeof(Exact{}) - 32
+const _ = 32 - unsafe.Sizeof(Exact{})
+const _ = unsafe.Sizeof(Small{}) - 32 // ERROR "size of Small is 28 bytes, expected 32 \(difference -4\)"
+const _ = 32 - unsafe.Sizeof(Big{})   // ERROR "size of Big is 36 bytes, expected 32 \(difference \+4\)"
+
+// Array length assertions.
+var _ [unsafe.Sizeof(Exact{}) - 32]byte
+var _ [32 - unsafe.Sizeof(Exact{})]byte
+var _ [unsafe.Sizeof(Small{}) - 32]byte // ERROR "size of Small is 28 bytes, expected 32 \(difference -4\)"
+var _ [32 - unsafe.Sizeof(Big{})]byte   // ERROR "size of Big is 36 bytes, expected 32 \(difference \+4\)"
+
+var _ [unsafe.Alignof(Pair{}) - 8]byte    // ERROR "alignment of Pair is 4 bytes, expected 8 \(difference -4\)"
+var _ [unsafe.Offsetof(Pair{}.y) - 8]byte // ERROR "offset of field y in Pair is 4 bytes, expected 8 \(difference -4\)"
+var _ [0 - unsafe.Offsetof(Pair{}.y)]byte // ERROR "offset of field y in Pair is 4 bytes, expected 0 \(difference \+4\)"
+
+// Other overflows are reported as before.
+const _ = uintptr(1) - 2 // ERROR "constant -1 overflows uintptr"
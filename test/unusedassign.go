@@ -0,0 +1,120 @@
+// errorcheck -0 -d=unusedassign
+
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the warnings for local variables that are assigned
+// but never used.
+
+package p
+
+import "sync"
+
+func forEach(a []int, f func(int)) {
+	for _, x := range a {
+		f(x)
+	}
+}
+
+func count(a []int) int {
+	n := 0 // ERROR "n is assigned but never used"
+	forEach(a, func(int) { n++ })
+	return len(a)
+}
+
+func last(a []int) {
+	var l int // ERROR "l is assigned but never used"
+	forEach(a, func(x int) { l = x })
+}
+
+func nested(a []int) {
+	var l int // ERROR "l is assigned but never used"
+	func() {
+		forEach(a, func(x int) { l = x })
+	}()
+}
+
+func inClosure(a []int) func() {
+	return func() {
+		var s int // ERROR "s is assigned but never used"
+		forEach(a, func(x int) { s += x })
+	}
+}
+
+func multi() {
+	var v, ok int // ERROR "v is assigned but never used" "ok is assigned but never used"
+	func() {
+		v, ok = 1, 2
+	}()
+}
+
+// The cases below are not reported.
+
+func sum(a []int) int {
+	s := 0
+	forEach(a, func(x int) { s += x })
+	return s
+}
+
+func readInClosure(a []int) func() int {
+	var l int
+	forEach(a, func(x int) { l = x })
+	return func() int { return l }
+}
+
+func result() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = nil
+		}
+	}()
+	return nil
+}
+
+func waitGroup(a []int) {
+	var wg sync.WaitGroup
+	for range a {
+		wg.Add(1)
+		go func() {
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func waitGroupPtr(a []int) {
+	var wg sync.WaitGroup
+	done := func(wg *sync.WaitGroup) { wg.Done() }
+	wg.Add(1)
+	go done(&wg)
+}
+
+func addr(f func(*int)) {
+	var x int
+	func() { x = 1 }()
+	f(&x)
+}
+
+func recv(c chan int) int {
+	var v int
+	var ok bool
+	func() {
+		select {
+		case v, ok = <-c:
+		}
+	}()
+	if ok {
+		return v
+	}
+	return 0
+}
+
+func rangeKey(m map[int]int) int {
+	var k int
+	func() {
+		for k = range m {
+		}
+	}()
+	return k
+}